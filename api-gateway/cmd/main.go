@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
@@ -13,12 +13,20 @@ import (
 	"github.com/gorilla/mux"
 	_ "github.com/movie-microservice/api-gateway/docs"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
+	"github.com/movie-microservice/api-gateway/internal/adapters/blocklist"
 	grpcAdapter "github.com/movie-microservice/api-gateway/internal/adapters/grpc"
 	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
 	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/adapters/metering"
+	"github.com/movie-microservice/api-gateway/internal/adapters/usage"
 	"github.com/movie-microservice/api-gateway/internal/config"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
 	"github.com/movie-microservice/api-gateway/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
 )
 
 // @title Movie API Gateway
@@ -34,12 +42,20 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host localhost:8080
-// @BasePath /api/v1
+// @BasePath /
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Key of an API client; also accepted as Authorization: Bearer <key>
+
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.16.6 init -d ../ -g cmd/main.go -o ../docs
 
 func main() {
 	// Initialize logger
+	var logLevel slog.LevelVar
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: &logLevel,
 	}))
 
 	// Load configuration
@@ -48,11 +64,23 @@ func main() {
 		logger.Error("Invalid configuration", "error", err)
 		os.Exit(1)
 	}
+	logLevel.UnmarshalText([]byte(cfg.Logging.Level))
 
 	logger.Info("Starting API Gateway", "port", cfg.Server.Port)
+	if cfg.Profile == config.ProfileDemo {
+		logger.Warn("Starting the public demo profile: read-only, rate-limited, without admin routes")
+	}
+	cfg.LogSummary(logger)
+	if err := cfg.CheckDependencies(context.Background()); err != nil {
+		logger.Error("Startup check failed", "error", err)
+		os.Exit(1)
+	}
 
 	// Initialize gRPC client for movie service
-	movieGRPCClient, err := grpcAdapter.NewMovieGRPCClient(cfg.MovieService.GRPCAddress, logger)
+	movieGRPCClient, err := grpcAdapter.NewMovieGRPCClient(cfg.MovieService.GRPCAddress, grpcAdapter.ClientOptions{
+		HedgeDelay:    time.Duration(cfg.MovieService.HedgeDelayMs) * time.Millisecond,
+		RetryAttempts: cfg.MovieService.RetryAttempts,
+	}, logger)
 	if err != nil {
 		logger.Error("Failed to connect to movie service", "error", err)
 		os.Exit(1)
@@ -64,34 +92,228 @@ func main() {
 	}()
 
 	// Initialize services
-	movieService := services.NewMovieService(movieGRPCClient, logger)
+	pagination := domain.Pagination{
+		DefaultLimit: int32(cfg.Pagination.DefaultPageSize),
+		MaxLimit:     int32(cfg.Pagination.MaxPageSize),
+		MaxPage:      int32(cfg.Pagination.MaxPage),
+	}
+	movieService := services.NewMovieService(movieGRPCClient, pagination, logger)
+	commentService := services.NewCommentService(movieGRPCClient.(*grpcAdapter.MovieGRPCClient).Comments(), pagination, logger)
+	syncService := services.NewSyncService(movieGRPCClient.(*grpcAdapter.MovieGRPCClient).Sync(), pagination, logger)
+	importService := services.NewImportService(movieGRPCClient.(*grpcAdapter.MovieGRPCClient).Imports(), logger)
+	jobService := services.NewJobService(movieGRPCClient.(*grpcAdapter.MovieGRPCClient).Jobs(), logger)
 
 	// Initialize handlers
 	movieHandler := handlers.NewMovieHandler(movieService, logger)
+	commentHandler := handlers.NewCommentHandler(commentService, logger)
+	syncHandler := handlers.NewSyncHandler(syncService, logger)
+	importHandler := handlers.NewImportHandler(importService, logger)
+	urlSigner := middleware.NewURLSigner(cfg.Policy.URLSecrets(), time.Duration(cfg.Policy.DownloadURLTTLSeconds)*time.Second, clock.System{})
+	jobHandler := handlers.NewJobHandler(jobService, urlSigner, logger)
+	metaHandler := handlers.NewMetaHandler(pagination)
 
 	// Setup router
 	router := mux.NewRouter()
 
 	// Add middleware
+	metrics := middleware.NewMetrics()
+	router.Use(middleware.Trace)
+	router.Use(metrics.Middleware)
+	trustedNetworks, _ := cfg.Server.ParseTrustedNetworks()
+	router.Use(middleware.TimeoutBudget(trustedNetworks, time.Duration(cfg.Server.TimeoutBudgetReserveMs)*time.Millisecond))
 	router.Use(middleware.CORS(logger))
-	router.Use(middleware.Logging(logger))
+	router.Use(middleware.Logging(logger, cfg.Logging.Policy()))
+
+	// Requests, errors and bytes of each API key, reported on /admin/usage and /me/usage
+	usageStore := usage.NewMemoryStore(cfg.Policy.UsageRetentionDays, clock.System{})
+	// and the events of each of those requests, published for billing when configured
+	var meter ports.MeteringPort
+	var meteringService *services.MeteringService
+	if cfg.Metering.Enabled() {
+		meteringService = services.NewMeteringService(
+			metering.NewWebhook(cfg.Metering.URL),
+			services.MeteringOptions{
+				BatchSize:     cfg.Metering.BatchSize,
+				FlushInterval: time.Duration(cfg.Metering.FlushSeconds) * time.Second,
+				JournalSize:   cfg.Metering.JournalSize,
+				Retry:         metering.NewRetry(cfg.Metering.Attempts, logger),
+			},
+			clock.System{}, logger)
+		meter = meteringService
+	}
+	router.Use(middleware.Usage(usageStore, meter, cfg.Policy.Keys(), clock.System{}))
+	// Spikes in the errors and in the traffic of each API key, as an early warning of abuse
+	// and client bugs
+	if cfg.Anomaly.IntervalSeconds > 0 {
+		router.Use(middleware.NewAnomalyDetector(cfg.Anomaly, cfg.Policy.Keys(), metrics, clock.System{}, logger).Middleware)
+	}
+	router.Use(middleware.Gzip)
+
+	// camelCase JSON field names for the clients asking for them in X-Naming
+	router.Use(middleware.NewNaming(cfg.Policy.CamelKeys()).Middleware)
+
+	// Per-route auth, rate limits, timeouts, caching and deprecations declared in
+	// ROUTE_POLICIES_FILE
+	policies, _ := cfg.Policy.Load()
+	router.Use(middleware.NewDeprecations(policies.Deprecations, cfg.Policy.WarningKeys(), metrics).Middleware)
+
+	// Read-only mode for maintenance windows, switched at runtime on /admin/maintenance
+	maintenance := middleware.NewMaintenance(cfg.Maintenance.ReadOnly,
+		time.Duration(cfg.Maintenance.RetryAfterSeconds)*time.Second, cfg.Policy.AdminKeys(), cfg.Policy.Keys(), logger)
+	router.Use(maintenance.Middleware)
+	router.Handle(middleware.MaintenancePath, maintenance).Methods("GET", "PUT")
+	if cfg.Maintenance.ReadOnly {
+		logger.Warn("Starting in read-only mode")
+	}
+	if cfg.Server.RegionHeader != "" {
+		router.Use(middleware.Region(cfg.Server.RegionHeader))
+	}
+
+	// Prefer: count=none changes the body of listings, and Accept chooses between JSON and
+	// protobuf
+	varyHeaders := []string{"Prefer", "Accept"}
+	if cfg.Server.RegionHeader != "" {
+		varyHeaders = append(varyHeaders, cfg.Server.RegionHeader)
+	}
+	policyEngine := middleware.NewPolicyEngine(policies, cfg.Policy.Keys(), varyHeaders, clock.System{}, logger)
+	router.Use(policyEngine.Middleware)
+	policyEngine.Revalidate("/api/v1/movies/{id}", movieHandler.MovieIsCurrent)
 
 	// API routes
 	api := router.PathPrefix("/api/v1").Subrouter()
 
 	// Movie routes
 	api.HandleFunc("/movies", movieHandler.GetMovies).Methods("GET")
+	api.HandleFunc("/movies/facets", movieHandler.GetMovieFacets).Methods("GET")
 	api.HandleFunc("/movies/{id:[0-9]+}", movieHandler.GetMovie).Methods("GET")
+	api.HandleFunc("/movies/{id:[0-9]+}", movieHandler.HeadMovie).Methods("HEAD")
+	api.HandleFunc("/movies/by-external/{source}/{externalId}", movieHandler.GetMovieByExternalID).Methods("GET")
+	api.HandleFunc("/movies/{id:[0-9]+}/history", movieHandler.GetMovieHistory).Methods("GET")
 	api.HandleFunc("/movies", movieHandler.CreateMovie).Methods("POST")
+	api.HandleFunc("/movies/{id:[0-9]+}", movieHandler.UpsertMovie).Methods("PUT")
+	api.HandleFunc("/movies/{id:[0-9]+}", movieHandler.PatchMovie).Methods("PATCH")
 	api.HandleFunc("/movies/{id:[0-9]+}", movieHandler.DeleteMovie).Methods("DELETE")
 
-	// Health check
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// Comment routes
+	api.HandleFunc("/movies/{id:[0-9]+}/comments", commentHandler.GetComments).Methods("GET")
+	api.HandleFunc("/movies/{id:[0-9]+}/comments", commentHandler.CreateComment).Methods("POST")
+	api.HandleFunc("/comments/{commentId}/flags", commentHandler.FlagComment).Methods("POST")
+
+	// Comment moderation, restricted to admins
+	moderation := router.PathPrefix("/admin/comments").Subrouter()
+	moderation.Use(middleware.AdminOnly(cfg.Policy.AdminKeys(), cfg.Policy.Keys()))
+	moderation.HandleFunc("/flagged", commentHandler.GetFlaggedComments).Methods("GET")
+	moderation.HandleFunc("/{commentId}", commentHandler.DeleteComment).Methods("DELETE")
+	moderation.HandleFunc("/{commentId}/flags", commentHandler.DismissCommentFlags).Methods("DELETE")
+	moderation.HandleFunc("/queue", commentHandler.GetModerationQueue).Methods("GET")
+	moderation.HandleFunc("/{commentId}/decision", commentHandler.DecideModeration).Methods("POST")
+
+	// Catalog sync conflicts, restricted to admins
+	catalogSync := router.PathPrefix("/admin/sync").Subrouter()
+	catalogSync.Use(middleware.AdminOnly(cfg.Policy.AdminKeys(), cfg.Policy.Keys()))
+	catalogSync.HandleFunc("/conflicts", syncHandler.GetSyncConflicts).Methods("GET")
+	catalogSync.HandleFunc("/conflicts/{conflictId}", syncHandler.ResolveSyncConflict).Methods("POST")
+
+	// Bulk imports processed in the background, restricted to API key holders
+	imports := api.PathPrefix("/imports").Subrouter()
+	imports.Use(middleware.KeyRequired(cfg.Policy.Keys()))
+	imports.HandleFunc("", importHandler.CreateImport).Methods("POST")
+	imports.HandleFunc("/{importId}", importHandler.GetImport).Methods("GET")
+
+	// Imports, exports and archiving run as jobs in the background, restricted to API key
+	// holders. Job files are also served to URLs signed by the gateway, without a key
+	api.Handle("/jobs/{jobId}/file", middleware.SignedOrAdmin(urlSigner, cfg.Policy.Keys())(http.HandlerFunc(jobHandler.GetJobFile))).Methods("GET")
+	jobs := api.PathPrefix("/jobs").Subrouter()
+	jobs.Use(middleware.KeyRequired(cfg.Policy.Keys()))
+	jobs.HandleFunc("", jobHandler.CreateJob).Methods("POST")
+	jobs.HandleFunc("/{jobId}", jobHandler.GetJob).Methods("GET")
+	if urlSigner != nil {
+		jobs.HandleFunc("/{jobId}/file/url", jobHandler.CreateJobFileURL).Methods("POST")
+	}
+
+	// API capabilities
+	api.HandleFunc("/meta", metaHandler.GetMeta).Methods("GET")
+
+	// IP addresses refused, scanners caught by the abuse middleware and blocks set by hand
+	blockedClients := blocklist.NewMemory(clock.System{})
+	blocklistHandler := handlers.NewBlocklistHandler(blockedClients, clock.System{})
+	blocks := router.PathPrefix("/admin/blocklist").Subrouter()
+	blocks.Use(middleware.AdminOnly(cfg.Policy.AdminKeys(), cfg.Policy.Keys()))
+	blocks.HandleFunc("", blocklistHandler.GetBlocklist).Methods("GET")
+	blocks.HandleFunc("", blocklistHandler.BlockClient).Methods("POST")
+	blocks.HandleFunc("/{ip}", blocklistHandler.UnblockClient).Methods("DELETE")
+
+	// Usage of every API key for admins, and of their own key for each client
+	usageHandler := handlers.NewUsageHandler(usageStore, cfg.Policy.Keys(), clock.System{})
+	router.Handle("/admin/usage", middleware.AdminOnly(cfg.Policy.AdminKeys(), cfg.Policy.Keys())(http.HandlerFunc(usageHandler.GetUsage))).Methods("GET")
+	router.Handle("/me/usage", middleware.KeyRequired(cfg.Policy.Keys())(http.HandlerFunc(usageHandler.GetMyUsage))).Methods("GET")
+	if meter != nil {
+		meteringHandler := handlers.NewMeteringHandler(meter, logger)
+		router.Handle("/admin/metering/replay", middleware.AdminOnly(cfg.Policy.AdminKeys(), cfg.Policy.Keys())(http.HandlerFunc(meteringHandler.ReplayEvents))).Methods("POST")
+	}
+
+	// Routes to additional services, behind the same middleware
+	proxyRoutes, _ := cfg.Proxy.ParseRoutes()
+	proxiesGRPC := false
+	for _, route := range proxyRoutes {
+		router.PathPrefix(route.Prefix).Handler(handlers.NewProxyHandler(route.Backend, logger))
+		proxiesGRPC = proxiesGRPC || route.GRPC()
+		logger.Info("Proxying route", "prefix", route.Prefix, "backend", route.Backend.String())
+	}
+
+	// Health check with the status of each dependency
+	healthHandler := handlers.NewHealthHandler(
+		handlers.HealthCheck{
+			Name:     "movie_service",
+			Required: true,
+			Check: func(ctx context.Context) (map[string]interface{}, error) {
+				client, ok := movieGRPCClient.(*grpcAdapter.MovieGRPCClient)
+				if !ok {
+					return nil, nil
+				}
+				state, err := client.Ping(ctx)
+				return map[string]interface{}{"address": cfg.MovieService.GRPCAddress, "channel_state": state}, err
+			},
+		},
+		handlers.HealthCheck{
+			Name: "response_cache",
+			Check: func(ctx context.Context) (map[string]interface{}, error) {
+				stats := policyEngine.Cache().Stats()
+				return map[string]interface{}{"entries": stats.Entries}, nil
+			},
+		},
+		handlers.HealthCheck{
+			Name: "auth",
+			Check: func(ctx context.Context) (map[string]interface{}, error) {
+				return map[string]interface{}{"provider": "api_keys", "keys": len(cfg.Policy.Keys())}, nil
+			},
+		},
+	)
+	router.Handle("/health", healthHandler).Methods("GET")
+
+	// Readiness, reported once the warm-up requests completed
+	readiness := handlers.NewReadiness()
+	router.Handle("/ready", readiness).Methods("GET")
+
+	// Request counts and durations, with trace exemplars in the OpenMetrics format
+	router.Handle("/metrics", metrics).Methods("GET")
+
+	// Response cache freshness
+	router.HandleFunc("/metrics/cache", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy","timestamp":"%s"}`, time.Now().UTC().Format(time.RFC3339))
+		json.NewEncoder(w).Encode(policyEngine.Cache().Stats())
 	}).Methods("GET")
 
+	// Answer wrong methods with 405 and OPTIONS automatically, advertising the Allow header
+	methodNotAllowed := middleware.Logging(logger, cfg.Logging.Policy())(middleware.MethodNotAllowed(router, logger))
+	router.MethodNotAllowedHandler = methodNotAllowed
+	api.MethodNotAllowedHandler = methodNotAllowed
+	moderation.MethodNotAllowedHandler = methodNotAllowed
+	catalogSync.MethodNotAllowedHandler = methodNotAllowed
+	imports.MethodNotAllowedHandler = methodNotAllowed
+	jobs.MethodNotAllowedHandler = methodNotAllowed
+	blocks.MethodNotAllowedHandler = methodNotAllowed
+
 	// Swagger documentation
 	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8080/swagger/doc.json"),
@@ -99,10 +321,21 @@ func main() {
 		httpSwagger.DocExpansion("none"),
 	))
 
+	// gRPC clients of proxied services speak HTTP/2 without TLS
+	var handler http.Handler = router
+	if proxiesGRPC {
+		handler = h2c.NewHandler(router, &http2.Server{})
+	}
+	// Scanners and blocked IPs are answered before routing, kept out of logs and metrics
+	abuse := middleware.NewAbuse(blockedClients, cfg.Abuse.Traps(), time.Duration(cfg.Abuse.BlockSeconds)*time.Second,
+		time.Duration(cfg.Abuse.TarpitMs)*time.Millisecond, trustedNetworks, clock.System{}, logger)
+	handler = metrics.InFlight(handler)
+	handler = abuse.Middleware(handler)
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
+		Handler:      handler,
 		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 		IdleTimeout:  120 * time.Second,
@@ -121,15 +354,42 @@ func main() {
 		}
 	}()
 
+	// Warm up routes, the movie service connection and the response cache before
+	// reporting ready
+	go func() {
+		warmupPaths, _ := cfg.Server.ParseWarmupPaths()
+		header := make(http.Header)
+		if keys := cfg.Policy.Keys(); len(keys) > 0 {
+			header.Set("X-API-Key", keys[0])
+		}
+		warmupCtx, cancelWarmup := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelWarmup()
+		handlers.Warmup(warmupCtx, handler, warmupPaths, header, logger)
+		readiness.MarkReady()
+		logger.Info("API Gateway ready")
+	}()
+
+	// Publish the metering events in the background until the server has stopped
+	meteringDone := make(chan struct{})
+	meteringCtx, stopMetering := context.WithCancel(context.Background())
+	go func() {
+		defer close(meteringDone)
+		if meteringService != nil {
+			meteringService.Run(meteringCtx)
+		}
+	}()
+
 	// Wait for interrupt signal
 	<-stop
 	logger.Info("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
+	// Graceful shutdown, closing the requests still running after the grace period, then
+	// publishing the metering events left
+	grace := time.Duration(cfg.Server.ShutdownGraceSeconds) * time.Second
+	err = metrics.Drain().Drain(grace, srv.Shutdown, func() { srv.Close() }, logger)
+	stopMetering()
+	<-meteringDone
+	if err != nil {
 		logger.Error("Server forced to shutdown", "error", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,96 @@
+// Package blocklist holds the lists of the IP addresses refused by the gateway
+package blocklist
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// maxEntries bounds the list, so a scan from many addresses cannot exhaust memory; the
+// entries expiring first make room for new ones
+const maxEntries = 100000
+
+// Memory keeps the blocked clients in memory. Each gateway replica blocks the clients it
+// caught or was told about, and the list is lost on restart
+type Memory struct {
+	mu      sync.Mutex
+	clients map[string]domain.BlockedClient
+	clock   clock.Clock
+}
+
+// NewMemory returns an empty list, expiring entries by clk
+func NewMemory(clk clock.Clock) *Memory {
+	return &Memory{clients: make(map[string]domain.BlockedClient), clock: clk}
+}
+
+func (m *Memory) Block(client domain.BlockedClient) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.clients[client.IP]; !ok && len(m.clients) >= maxEntries {
+		m.prune()
+		if len(m.clients) >= maxEntries {
+			m.evict()
+		}
+	}
+	m.clients[client.IP] = client
+}
+
+func (m *Memory) Unblock(ip string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.clients[ip]
+	delete(m.clients, ip)
+	return ok
+}
+
+func (m *Memory) Blocked(ip string) (domain.BlockedClient, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	client, ok := m.clients[ip]
+	if ok && !m.clock.Now().Before(client.Until) {
+		delete(m.clients, ip)
+		return domain.BlockedClient{}, false
+	}
+	return client, ok
+}
+
+func (m *Memory) List() []domain.BlockedClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	clients := make([]domain.BlockedClient, 0, len(m.clients))
+	for _, client := range m.clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].BlockedAt.Equal(clients[j].BlockedAt) {
+			return clients[i].BlockedAt.After(clients[j].BlockedAt)
+		}
+		return clients[i].IP < clients[j].IP
+	})
+	return clients
+}
+
+// prune drops the entries that expired
+func (m *Memory) prune() {
+	now := m.clock.Now()
+	for ip, client := range m.clients {
+		if !now.Before(client.Until) {
+			delete(m.clients, ip)
+		}
+	}
+}
+
+// evict drops the entry expiring first
+func (m *Memory) evict() {
+	var first domain.BlockedClient
+	for _, client := range m.clients {
+		if first.IP == "" || client.Until.Before(first.Until) {
+			first = client
+		}
+	}
+	delete(m.clients, first.IP)
+}
@@ -7,27 +7,51 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
       
-	pb "github.com/movie-microservice/proto/movies"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+	"github.com/movie-microservice/proto/retry"
 	"github.com/movie-microservice/api-gateway/internal/core/domain"
 	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
 )
 
 type MovieGRPCClient struct {
-	client pb.MovieServiceClient
-	conn   *grpc.ClientConn
-	logger *slog.Logger
+	client     pb.MovieServiceClient
+	conn       *grpc.ClientConn
+	hedgeDelay time.Duration
+	retry      retry.Policy
+	logger     *slog.Logger
 }
 
-func NewMovieGRPCClient(serverAddress string, logger *slog.Logger) (ports.MovieServicePort, error) {
+// ClientOptions tunes the calls made to the movie service
+type ClientOptions struct {
+	// HedgeDelay is the time after which an unanswered GetMovie or GetMovies call is sent
+	// again; zero disables hedging
+	HedgeDelay time.Duration
+	// RetryAttempts bounds the attempts of a read answered Unavailable, the first one
+	// included; values below 1 send reads once
+	RetryAttempts int
+}
+
+func NewMovieGRPCClient(serverAddress string, opts ClientOptions, logger *slog.Logger) (ports.MovieServicePort, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, serverAddress,
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
-	)
+		grpc.WithUnaryInterceptor(traceInterceptor),
+	}
+	if opts.HedgeDelay > 0 {
+		dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(roundRobinServiceConfig))
+	}
+
+	conn, err := grpc.DialContext(ctx, serverAddress, dialOpts...)
 	if err != nil {
 		logger.Error("Failed to connect to movie service", "address", serverAddress, "error", err)
 		return nil, fmt.Errorf("failed to connect to movie service: %w", err)
@@ -37,123 +61,324 @@ func NewMovieGRPCClient(serverAddress string, logger *slog.Logger) (ports.MovieS
 	logger.Info("Successfully connected to movie service", "address", serverAddress)
 
 	return &MovieGRPCClient{
-		client: client,
-		conn:   conn,
-		logger: logger,
+		client:     client,
+		conn:       conn,
+		hedgeDelay: opts.HedgeDelay,
+		retry:      newReadRetry(opts.RetryAttempts, logger),
+		logger:     logger,
 	}, nil
 }
 
-func (c *MovieGRPCClient) GetMovies(ctx context.Context, page, limit int32) ([]*domain.Movie, int32, error) {
-	c.logger.Info("gRPC client: Getting movies", "page", page, "limit", limit)
+func (c *MovieGRPCClient) GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting movies", "page", filter.Page, "limit", filter.Limit)
 
 	req := &pb.GetMoviesRequest{
-		Page:  page,
-		Limit: limit,
+		Page:          filter.Page,
+		Limit:         filter.Limit,
+		Filter:        toProtoFilter(filter.Expr),
+		Region:        filter.Region,
+		Certification: filter.Certification,
+		SkipCount:     filter.SkipCount,
 	}
 
-	resp, err := c.client.GetMovies(ctx, req)
+	resp, err := read(ctx, c, "GetMovies", func(ctx context.Context) (*pb.GetMoviesResponse, error) {
+		return c.client.GetMovies(ctx, req)
+	})
 	if err != nil {
-		c.logger.Error("gRPC client: Failed to get movies", "error", err)
-		return nil, 0, fmt.Errorf("failed to get movies: %w", err)
-	}
-
-	if !resp.Success {
-		c.logger.Error("gRPC client: Movie service returned error", "error", resp.Error)
-		return nil, 0, fmt.Errorf("movie service error: %s", resp.Error)
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get movies", "error", err)
+		return nil, 0, fmt.Errorf("failed to get movies: %w", fromStatusError(err))
 	}
 
 	// Convert protobuf movies to domain movies
 	movies := make([]*domain.Movie, len(resp.Movies))
 	for i, pbMovie := range resp.Movies {
-		movies[i] = &domain.Movie{
-			ID:    pbMovie.Id,
-			Title: pbMovie.Title,
-			Year:  pbMovie.Year,
-		}
+		movies[i] = toDomainMovie(pbMovie)
 	}
 
-	c.logger.Info("gRPC client: Successfully retrieved movies", "count", len(movies))
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully retrieved movies", "count", len(movies))
 	return movies, resp.Total, nil
 }
 
 func (c *MovieGRPCClient) GetMovie(ctx context.Context, id int32) (*domain.Movie, error) {
-	c.logger.Info("gRPC client: Getting movie", "id", id)
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting movie", "id", id)
 
 	req := &pb.GetMovieRequest{Id: id}
 
-	resp, err := c.client.GetMovie(ctx, req)
+	resp, err := read(ctx, c, "GetMovie", func(ctx context.Context) (*pb.GetMovieResponse, error) {
+		return c.client.GetMovie(ctx, req)
+	})
 	if err != nil {
-		c.logger.Error("gRPC client: Failed to get movie", "id", id, "error", err)
-		return nil, fmt.Errorf("failed to get movie: %w", err)
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get movie", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie: %w", fromStatusError(err))
 	}
 
-	if !resp.Success {
-		c.logger.Error("gRPC client: Movie service returned error", "id", id, "error", resp.Error)
-		return nil, fmt.Errorf("movie service error: %s", resp.Error)
+	movie := toDomainMovie(resp.Movie)
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully retrieved movie", "id", id, "from_archive", movie.Archived)
+	return movie, nil
+}
+
+func (c *MovieGRPCClient) GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting movie by external ID", "source", source, "external_id", id)
+
+	req := &pb.GetMovieByExternalIdRequest{Source: source, ExternalId: id}
+
+	resp, err := read(ctx, c, "GetMovieByExternalId", func(ctx context.Context) (*pb.GetMovieByExternalIdResponse, error) {
+		return c.client.GetMovieByExternalId(ctx, req)
+	})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get movie by external ID", "source", source, "external_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie by external ID: %w", fromStatusError(err))
 	}
 
-	movie := &domain.Movie{
-		ID:    resp.Movie.Id,
-		Title: resp.Movie.Title,
-		Year:  resp.Movie.Year,
+	movie := toDomainMovie(resp.Movie)
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully retrieved movie by external ID", "id", movie.ID, "from_archive", movie.Archived)
+	return movie, nil
+}
+
+func (c *MovieGRPCClient) CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Creating movie", "title", input.Title, "year", input.Year)
+
+	req := &pb.CreateMovieRequest{Movie: convert.ToProtoMovieInput(convert.MovieInput(input))}
+
+	resp, err := c.client.CreateMovie(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to create movie", "title", input.Title, "year", input.Year, "error", err)
+		return nil, fmt.Errorf("failed to create movie: %w", fromStatusError(err))
 	}
 
-	c.logger.Info("gRPC client: Successfully retrieved movie", "id", id)
+	movie := toDomainMovie(resp.Movie)
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully created movie", "id", movie.ID)
 	return movie, nil
 }
 
-func (c *MovieGRPCClient) CreateMovie(ctx context.Context, title, year string) (*domain.Movie, error) {
-	c.logger.Info("gRPC client: Creating movie", "title", title, "year", year)
+func (c *MovieGRPCClient) UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Upserting movie", "id", id, "title", input.Title, "year", input.Year)
+
+	req := &pb.UpsertMovieRequest{Id: id, Movie: convert.ToProtoMovieInput(convert.MovieInput(input))}
 
-	req := &pb.CreateMovieRequest{
-		Title: title,
-		Year:  year,
+	resp, err := c.client.UpsertMovie(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to upsert movie", "id", id, "error", err)
+		return nil, false, fmt.Errorf("failed to upsert movie: %w", fromStatusError(err))
 	}
 
-	resp, err := c.client.CreateMovie(ctx, req)
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully upserted movie", "id", id, "created", resp.Created)
+	return toDomainMovie(resp.Movie), resp.Created, nil
+}
+
+func (c *MovieGRPCClient) UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error) {
+	return c.updateMovie(ctx, &pb.UpdateMovieRequest{
+		Id:    id,
+		Movie: convert.ToProtoMovieInput(convert.MovieInput(input)),
+	})
+}
+
+func (c *MovieGRPCClient) UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error) {
+	return c.updateMovie(ctx, &pb.UpdateMovieRequest{
+		Id:              id,
+		Movie:           convert.ToProtoMovieInput(convert.MovieInput(input)),
+		ExpectedVersion: &version,
+	})
+}
+
+func (c *MovieGRPCClient) updateMovie(ctx context.Context, req *pb.UpdateMovieRequest) (*domain.Movie, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Updating movie", "id", req.Id, "expected_version", req.ExpectedVersion)
+
+	resp, err := c.client.UpdateMovie(ctx, req)
 	if err != nil {
-		c.logger.Error("gRPC client: Failed to create movie", "title", title, "year", year, "error", err)
-		return nil, fmt.Errorf("failed to create movie: %w", err)
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to update movie", "id", req.Id, "error", err)
+		return nil, fmt.Errorf("failed to update movie: %w", fromStatusError(err))
 	}
 
-	if !resp.Success {
-		c.logger.Error("gRPC client: Movie service returned error", "title", title, "year", year, "error", resp.Error)
-		return nil, fmt.Errorf("movie service error: %s", resp.Error)
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully updated movie", "id", req.Id, "version", resp.Movie.GetVersion())
+	return toDomainMovie(resp.Movie), nil
+}
+
+func (c *MovieGRPCClient) PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Patching movie", "id", id, "paths", patch.Paths)
+
+	req := &pb.PatchMovieRequest{
+		Id:              id,
+		Movie:           convert.ToProtoMovieInput(convert.MovieInput(patch.Input)),
+		UpdateMask:      &fieldmaskpb.FieldMask{Paths: patch.Paths},
+		ExpectedVersion: patch.ExpectedVersion,
 	}
 
-	movie := &domain.Movie{
-		ID:    resp.Movie.Id,
-		Title: resp.Movie.Title,
-		Year:  resp.Movie.Year,
+	resp, err := c.client.PatchMovie(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to patch movie", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to patch movie: %w", fromStatusError(err))
 	}
 
-	c.logger.Info("gRPC client: Successfully created movie", "id", movie.ID)
-	return movie, nil
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully patched movie", "id", id, "version", resp.Movie.GetVersion())
+	return toDomainMovie(resp.Movie), nil
 }
 
 func (c *MovieGRPCClient) DeleteMovie(ctx context.Context, id int32) error {
-	c.logger.Info("gRPC client: Deleting movie", "id", id)
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Deleting movie", "id", id)
 
 	req := &pb.DeleteMovieRequest{Id: id}
 
-	resp, err := c.client.DeleteMovie(ctx, req)
-	if err != nil {
-		c.logger.Error("gRPC client: Failed to delete movie", "id", id, "error", err)
-		return fmt.Errorf("failed to delete movie: %w", err)
+	if _, err := c.client.DeleteMovie(ctx, req); err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to delete movie", "id", id, "error", err)
+		return fmt.Errorf("failed to delete movie: %w", fromStatusError(err))
+	}
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully deleted movie", "id", id)
+	return nil
+}
+
+func (c *MovieGRPCClient) DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Deleting movie if version matches", "id", id, "version", version)
+
+	req := &pb.DeleteMovieRequest{
+		Id:              id,
+		ExpectedVersion: &version,
 	}
 
-	if !resp.Success {
-		c.logger.Error("gRPC client: Movie service returned error", "id", id, "error", resp.Error)
-		return fmt.Errorf("movie service error: %s", resp.Error)
+	if _, err := c.client.DeleteMovie(ctx, req); err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to delete movie", "id", id, "version", version, "error", err)
+		return fmt.Errorf("failed to delete movie: %w", fromStatusError(err))
 	}
 
-	c.logger.Info("gRPC client: Successfully deleted movie", "id", id)
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully deleted movie", "id", id, "version", version)
 	return nil
 }
 
+func (c *MovieGRPCClient) GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting movie facets")
+
+	req := &pb.GetMovieFacetsRequest{
+		Filter:        toProtoFilter(filter.Expr),
+		Region:        filter.Region,
+		Certification: filter.Certification,
+	}
+
+	resp, err := c.client.GetMovieFacets(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get movie facets", "error", err)
+		return nil, fmt.Errorf("failed to get movie facets: %w", fromStatusError(err))
+	}
+
+	facets := &domain.MovieFacets{
+		Years:  make([]domain.FacetBucket, len(resp.Years)),
+		Genres: make([]domain.FacetBucket, len(resp.Genres)),
+		Total:  resp.Total,
+	}
+	for i, bucket := range resp.Years {
+		facets.Years[i] = domain.FacetBucket{Value: bucket.Value, Count: bucket.Count}
+	}
+	for i, bucket := range resp.Genres {
+		facets.Genres[i] = domain.FacetBucket{Value: bucket.Value, Count: bucket.Count}
+	}
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully retrieved movie facets", "total", facets.Total)
+	return facets, nil
+}
+
+// Ping checks that the movie service answers, returning the state of the channel. It asks
+// for the version of movie 0, which the service rejects without reading the database, so
+// any answer other than Unavailable or DeadlineExceeded shows the service is reachable.
+func (c *MovieGRPCClient) Ping(ctx context.Context) (string, error) {
+	_, err := c.client.GetMovieVersion(ctx, &pb.GetMovieVersionRequest{Id: 0})
+	state := c.conn.GetState().String()
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return state, fromStatusError(err)
+	}
+	return state, nil
+}
+
 func (c *MovieGRPCClient) Close() error {
 	if c.conn != nil {
 		return c.conn.Close()
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+func (c *MovieGRPCClient) GetMovieHistory(ctx context.Context, id int32) (*domain.MovieHistory, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting movie history", "id", id)
+
+	resp, err := c.client.GetMovieHistory(ctx, &pb.GetMovieHistoryRequest{Id: id})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get movie history", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie history: %w", fromStatusError(err))
+	}
+
+	history := &domain.MovieHistory{ID: id, Revisions: make([]domain.MovieRevision, len(resp.Revisions))}
+	for i, revision := range resp.Revisions {
+		occurredAt, err := convert.FromTimestamp(revision.OccurredAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid revision time: %w", err)
+		}
+		history.Revisions[i] = domain.MovieRevision{
+			Sequence:      revision.Sequence,
+			Type:          revision.Type,
+			Version:       revision.Version,
+			ChangedFields: revision.ChangedFields,
+			OccurredAt:    occurredAt,
+		}
+		if revision.Movie != nil {
+			history.Revisions[i].Movie = toDomainMovie(revision.Movie)
+		}
+	}
+
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Successfully retrieved movie history", "id", id, "revisions", len(history.Revisions))
+	return history, nil
+}
+
+func (c *MovieGRPCClient) GetMovieVersion(ctx context.Context, id int32) (int64, error) {
+	resp, err := c.client.GetMovieVersion(ctx, &pb.GetMovieVersionRequest{Id: id})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Debug("gRPC client: Failed to get movie version", "id", id, "error", err)
+		return 0, fmt.Errorf("failed to get movie version: %w", fromStatusError(err))
+	}
+
+	return resp.Version, nil
+}
+
+func toDomainMovie(pbMovie *pb.Movie) *domain.Movie {
+	movie := domain.Movie(convert.FromProtoMovie(pbMovie))
+	return &movie
+}
+
+var protoFilterOperators = map[domain.FilterOperator]pb.FilterOperator{
+	domain.OpEq:       pb.FilterOperator_FILTER_OPERATOR_EQ,
+	domain.OpNe:       pb.FilterOperator_FILTER_OPERATOR_NE,
+	domain.OpGt:       pb.FilterOperator_FILTER_OPERATOR_GT,
+	domain.OpGte:      pb.FilterOperator_FILTER_OPERATOR_GTE,
+	domain.OpLt:       pb.FilterOperator_FILTER_OPERATOR_LT,
+	domain.OpLte:      pb.FilterOperator_FILTER_OPERATOR_LTE,
+	domain.OpContains: pb.FilterOperator_FILTER_OPERATOR_CONTAINS,
+}
+
+func toProtoFilter(expr *domain.FilterExpr) *pb.Filter {
+	if expr == nil {
+		return nil
+	}
+
+	switch {
+	case expr.Condition != nil:
+		return &pb.Filter{Node: &pb.Filter_Condition{Condition: &pb.FilterCondition{
+			Field:    expr.Condition.Field,
+			Operator: protoFilterOperators[expr.Condition.Op],
+			Value:    expr.Condition.Value,
+		}}}
+	case len(expr.And) > 0:
+		return &pb.Filter{Node: &pb.Filter_And{And: toProtoFilterGroup(expr.And)}}
+	case len(expr.Or) > 0:
+		return &pb.Filter{Node: &pb.Filter_Or{Or: toProtoFilterGroup(expr.Or)}}
+	}
+	return nil
+}
+
+func toProtoFilterGroup(exprs []*domain.FilterExpr) *pb.FilterGroup {
+	group := &pb.FilterGroup{Filters: make([]*pb.Filter, len(exprs))}
+	for i, expr := range exprs {
+		group.Filters[i] = toProtoFilter(expr)
+	}
+	return group
+}
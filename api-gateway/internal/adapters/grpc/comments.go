@@ -0,0 +1,144 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// CommentGRPCClient calls the comment service of the movie service over the connection
+// of the movie client
+type CommentGRPCClient struct {
+	client pb.CommentServiceClient
+	logger *slog.Logger
+}
+
+// Comments returns a client of the comment service sharing the connection
+func (c *MovieGRPCClient) Comments() ports.CommentServicePort {
+	return &CommentGRPCClient{
+		client: pb.NewCommentServiceClient(c.conn),
+		logger: c.logger,
+	}
+}
+
+func (c *CommentGRPCClient) ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Listing comments", "movie_id", movieID, "page", page, "limit", limit)
+
+	resp, err := c.client.ListComments(ctx, &pb.ListCommentsRequest{MovieId: movieID, Page: page, Limit: limit})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to list comments", "movie_id", movieID, "error", err)
+		return nil, 0, fmt.Errorf("failed to list comments: %w", fromStatusError(err))
+	}
+	return toDomainComments(resp.Comments), resp.Total, nil
+}
+
+func (c *CommentGRPCClient) CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Creating comment", "movie_id", input.MovieID, "parent_id", input.ParentID)
+
+	resp, err := c.client.CreateComment(ctx, &pb.CreateCommentRequest{
+		MovieId:  input.MovieID,
+		ParentId: input.ParentID,
+		Author:   input.Author,
+		Body:     input.Body,
+	})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to create comment", "movie_id", input.MovieID, "error", err)
+		return nil, fmt.Errorf("failed to create comment: %w", fromStatusError(err))
+	}
+	return toDomainComment(resp.Comment), nil
+}
+
+func (c *CommentGRPCClient) FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Flagging comment", "id", id)
+
+	resp, err := c.client.FlagComment(ctx, &pb.FlagCommentRequest{Id: id, Reason: reason})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to flag comment", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to flag comment: %w", fromStatusError(err))
+	}
+	return toDomainComment(resp.Comment), nil
+}
+
+func (c *CommentGRPCClient) ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Listing flagged comments", "page", page, "limit", limit)
+
+	resp, err := c.client.ListFlaggedComments(ctx, &pb.ListFlaggedCommentsRequest{Page: page, Limit: limit})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to list flagged comments", "error", err)
+		return nil, 0, fmt.Errorf("failed to list flagged comments: %w", fromStatusError(err))
+	}
+	return toDomainComments(resp.Comments), resp.Total, nil
+}
+
+func (c *CommentGRPCClient) DeleteComment(ctx context.Context, id string) error {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Deleting comment", "id", id)
+
+	if _, err := c.client.DeleteComment(ctx, &pb.DeleteCommentRequest{Id: id}); err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to delete comment", "id", id, "error", err)
+		return fmt.Errorf("failed to delete comment: %w", fromStatusError(err))
+	}
+	return nil
+}
+
+func (c *CommentGRPCClient) DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Dismissing comment flags", "id", id)
+
+	resp, err := c.client.DismissCommentFlags(ctx, &pb.DismissCommentFlagsRequest{Id: id})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to dismiss comment flags", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to dismiss comment flags: %w", fromStatusError(err))
+	}
+	return toDomainComment(resp.Comment), nil
+}
+
+func (c *CommentGRPCClient) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Listing moderation queue", "page", page, "limit", limit)
+
+	resp, err := c.client.ListModerationQueue(ctx, &pb.ListModerationQueueRequest{Page: page, Limit: limit})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to list moderation queue", "error", err)
+		return nil, 0, fmt.Errorf("failed to list moderation queue: %w", fromStatusError(err))
+	}
+	return toDomainComments(resp.Comments), resp.Total, nil
+}
+
+func (c *CommentGRPCClient) DecideModeration(ctx context.Context, id, decision string) (*domain.Comment, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Deciding moderation", "id", id, "decision", decision)
+
+	resp, err := c.client.DecideModeration(ctx, &pb.DecideModerationRequest{Id: id, Decision: decision})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to decide moderation", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to decide moderation: %w", fromStatusError(err))
+	}
+	return toDomainComment(resp.Comment), nil
+}
+
+func toDomainComment(pbComment *pb.Comment) *domain.Comment {
+	return &domain.Comment{
+		ID:                pbComment.Id,
+		MovieID:           pbComment.MovieId,
+		ParentID:          pbComment.ParentId,
+		Author:            pbComment.Author,
+		Body:              pbComment.Body,
+		CreatedAt:         pbComment.CreatedAt.AsTime(),
+		Deleted:           pbComment.Deleted,
+		FlagCount:         pbComment.FlagCount,
+		FlagReasons:       pbComment.FlagReasons,
+		Status:            pbComment.Status,
+		Replies:           toDomainComments(pbComment.Replies),
+		ModerationReasons: pbComment.ModerationReasons,
+	}
+}
+
+func toDomainComments(pbComments []*pb.Comment) []*domain.Comment {
+	comments := make([]*domain.Comment, len(pbComments))
+	for i, pbComment := range pbComments {
+		comments[i] = toDomainComment(pbComment)
+	}
+	return comments
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/apperr"
+	"github.com/movie-microservice/proto/convert"
+)
+
+// defaultErrors are the failures of the statuses without an ErrorInfo, such as those of
+// the transport or of service versions that did not report reasons
+var defaultErrors = map[codes.Code]*apperr.Error{
+	codes.NotFound:           domain.ErrMovieNotFound,
+	codes.AlreadyExists:      domain.ErrMovieAlreadyExists,
+	codes.FailedPrecondition: domain.ErrVersionMismatch,
+	codes.InvalidArgument:    domain.ErrInvalidMovieData,
+	codes.Unimplemented:      domain.ErrHistoryUnavailable,
+	codes.DeadlineExceeded:   domain.ErrServiceTimeout,
+	codes.Unavailable:        domain.ErrServiceUnavailable,
+}
+
+// fromStatusError turns gRPC status errors returned by the movie service into classified
+// errors keeping the message and details of the status. They match the domain error of
+// the same reason, so errors.Is(err, domain.ErrCommentNotFound) holds for comments the
+// service did not find
+func fromStatusError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	kind, ok := apperr.KindOfCode(st.Code())
+	if !ok || kind == apperr.Internal {
+		return err
+	}
+
+	decoded := statusDetails(st)
+	decoded.Kind, decoded.Message = kind, st.Message()
+	if fallback, ok := defaultErrors[st.Code()]; ok && decoded.Reason == "" {
+		decoded.Reason = fallback.Reason
+	}
+	return decoded
+}
+
+// statusDetails reads the google.rpc ErrorInfo, BadRequest and RetryInfo details of a
+// status, ignoring detail types the gateway does not use
+func statusDetails(st *status.Status) *apperr.Error {
+	var decoded apperr.Error
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			decoded.Reason = d.GetReason()
+		case *errdetails.BadRequest:
+			for _, violation := range d.GetFieldViolations() {
+				decoded.Violations = append(decoded.Violations, apperr.Violation{
+					Field:       violation.GetField(),
+					Description: violation.GetDescription(),
+				})
+			}
+		case *errdetails.RetryInfo:
+			if delay, err := convert.FromDuration(d.GetRetryDelay()); err == nil {
+				decoded.RetryAfter = delay
+			}
+		}
+	}
+	return &decoded
+}
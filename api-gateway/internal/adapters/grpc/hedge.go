@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// roundRobinServiceConfig spreads calls over every address the target resolves to, so a
+// hedged attempt can reach a different replica than the slow one
+const roundRobinServiceConfig = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// hedge makes the call and, when it has not answered after delay, makes it once more,
+// returning the first successful response; the slower attempt is cancelled. An attempt
+// that fails before the hedge is sent is returned as is. A zero delay disables hedging.
+func hedge[T any](ctx context.Context, delay time.Duration, logger *slog.Logger, method string, call func(context.Context) (T, error)) (T, error) {
+	if delay <= 0 {
+		return call(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp T
+		err  error
+	}
+	results := make(chan result, 2)
+	attempt := func() {
+		resp, err := call(ctx)
+		results <- result{resp: resp, err: err}
+	}
+
+	go attempt()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			logger.Info("gRPC client: Sending hedged request", "method", method, "delay", delay)
+			pending++
+			go attempt()
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				return res.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			// Keep waiting for the other attempt when one is in flight
+			if pending == 0 {
+				return res.resp, firstErr
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// ImportGRPCClient calls the import service of the movie service over the connection of
+// the movie client
+type ImportGRPCClient struct {
+	client pb.ImportServiceClient
+	logger *slog.Logger
+}
+
+// Imports returns a client of the import service sharing the connection
+func (c *MovieGRPCClient) Imports() ports.ImportServicePort {
+	return &ImportGRPCClient{
+		client: pb.NewImportServiceClient(c.conn),
+		logger: c.logger,
+	}
+}
+
+func (c *ImportGRPCClient) StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Starting import", "rows", len(rows))
+
+	req := &pb.StartImportRequest{Rows: make([]*pb.ImportRow, len(rows))}
+	for i, row := range rows {
+		req.Rows[i] = &pb.ImportRow{Id: row.ID, Movie: convert.ToProtoMovieInput(convert.MovieInput(row.Input))}
+	}
+
+	resp, err := c.client.StartImport(ctx, req)
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to start import", "rows", len(rows), "error", err)
+		return nil, fmt.Errorf("failed to start import: %w", fromStatusError(err))
+	}
+	return toDomainImport(resp.MovieImport), nil
+}
+
+func (c *ImportGRPCClient) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting import", "id", id)
+
+	resp, err := c.client.GetImport(ctx, &pb.GetImportRequest{Id: id})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get import", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get import: %w", fromStatusError(err))
+	}
+	return toDomainImport(resp.MovieImport), nil
+}
+
+func toDomainImport(imp *pb.MovieImport) *domain.Import {
+	results := make([]domain.ImportRowResult, len(imp.GetResults()))
+	for i, result := range imp.GetResults() {
+		results[i] = domain.ImportRowResult{
+			Row:     result.Row,
+			Status:  result.Status,
+			MovieID: result.MovieId,
+			Field:   result.Field,
+			Error:   result.Error,
+		}
+	}
+	converted := &domain.Import{
+		ID:        imp.GetId(),
+		Status:    imp.GetStatus(),
+		Total:     imp.GetTotal(),
+		Processed: imp.GetProcessed(),
+		Created:   imp.GetCreated(),
+		Updated:   imp.GetUpdated(),
+		Invalid:   imp.GetInvalid(),
+		Failed:    imp.GetFailed(),
+		Results:   results,
+		Error:     imp.GetError(),
+		CreatedAt: imp.GetCreatedAt().AsTime(),
+	}
+	if imp.FinishedAt != nil {
+		finishedAt := imp.FinishedAt.AsTime()
+		converted.FinishedAt = &finishedAt
+	}
+	return converted
+}
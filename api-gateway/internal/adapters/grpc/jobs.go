@@ -0,0 +1,131 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// JobGRPCClient calls the job service of the movie service over the connection of the
+// movie client
+type JobGRPCClient struct {
+	client pb.JobServiceClient
+	logger *slog.Logger
+}
+
+// Jobs returns a client of the job service sharing the connection
+func (c *MovieGRPCClient) Jobs() ports.JobServicePort {
+	return &JobGRPCClient{
+		client: pb.NewJobServiceClient(c.conn),
+		logger: c.logger,
+	}
+}
+
+func (c *JobGRPCClient) SubmitJob(ctx context.Context, jobType, priority string, params json.RawMessage) (*domain.Job, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Submitting job", "type", jobType, "priority", priority)
+
+	resp, err := c.client.SubmitJob(ctx, &pb.SubmitJobRequest{Type: jobType, Priority: priority, ParamsJson: string(params)})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to submit job", "type", jobType, "error", err)
+		return nil, fmt.Errorf("failed to submit job: %w", fromStatusError(err))
+	}
+	return toDomainJob(resp.Job), nil
+}
+
+func (c *JobGRPCClient) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Getting job", "id", id)
+
+	resp, err := c.client.GetJob(ctx, &pb.GetJobRequest{Id: id})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to get job", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get job: %w", fromStatusError(err))
+	}
+	return toDomainJob(resp.Job), nil
+}
+
+// OpenJobFile starts the download and reads its first message, which holds the size of
+// the file, so a job without a file fails here rather than after the response started
+func (c *JobGRPCClient) OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Downloading job file", "id", id, "offset", offset)
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.DownloadJobFile(ctx, &pb.DownloadJobFileRequest{Id: id, Offset: offset})
+	if err == nil {
+		var first *pb.DownloadJobFileResponse
+		if first, err = stream.Recv(); err == nil || errors.Is(err, io.EOF) {
+			reader := &jobFileReader{stream: stream, cancel: cancel, chunk: first.GetChunk(), eof: err != nil}
+			return &domain.JobFile{ReadCloser: reader, Size: first.GetSize()}, nil
+		}
+	}
+	cancel()
+	logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to download job file", "id", id, "error", err)
+	return nil, fmt.Errorf("failed to download job file: %w", fromStatusError(err))
+}
+
+// jobFileReader reads the chunks of a job file as they are received
+type jobFileReader struct {
+	stream pb.JobService_DownloadJobFileClient
+	cancel context.CancelFunc
+	chunk  []byte
+	eof    bool
+}
+
+func (r *jobFileReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		resp, err := r.stream.Recv()
+		if errors.Is(err, io.EOF) {
+			r.eof = true
+			continue
+		}
+		if err != nil {
+			return 0, fromStatusError(err)
+		}
+		r.chunk = resp.Chunk
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// Close stops the download
+func (r *jobFileReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+func toDomainJob(job *pb.Job) *domain.Job {
+	converted := &domain.Job{
+		ID:        job.GetId(),
+		Type:      job.GetType(),
+		Status:    job.GetStatus(),
+		Priority:  job.GetPriority(),
+		Processed: job.GetDone(),
+		Total:     job.GetTotal(),
+		File:      job.GetFile(),
+		Error:     job.GetError(),
+		CreatedAt: job.GetCreatedAt().AsTime(),
+	}
+	if job.GetResultJson() != "" {
+		converted.Result = json.RawMessage(job.GetResultJson())
+	}
+	if job.StartedAt != nil {
+		startedAt := job.StartedAt.AsTime()
+		converted.StartedAt = &startedAt
+	}
+	if job.FinishedAt != nil {
+		finishedAt := job.FinishedAt.AsTime()
+		converted.FinishedAt = &finishedAt
+	}
+	return converted
+}
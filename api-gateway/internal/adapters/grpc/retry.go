@@ -0,0 +1,55 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	"github.com/movie-microservice/proto/retry"
+)
+
+const (
+	// readRetryBaseDelay and readRetryMaxDelay bound the wait before a read is retried
+	readRetryBaseDelay = 50 * time.Millisecond
+	readRetryMaxDelay  = time.Second
+	// readRetryTokens and readRetryTokenRatio size the retry budget of a client: retries
+	// stop after about five failures more than a tenth of the successes
+	readRetryTokens     = 10
+	readRetryTokenRatio = 0.1
+)
+
+// newReadRetry returns the retry policy of the reads of a client, with its own budget
+func newReadRetry(attempts int, logger *slog.Logger) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: max(attempts, 1),
+		BaseDelay:   readRetryBaseDelay,
+		MaxDelay:    readRetryMaxDelay,
+		Retryable:   retryableRead,
+		Budget:      retry.NewBudget(readRetryTokens, readRetryTokenRatio),
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			logger.Info("gRPC client: Retrying read", "attempt", attempt, "backoff", delay, "error", err)
+		},
+	}
+}
+
+// retryableRead reports whether a failed read can be sent again: Unavailable means the
+// call reached no replica, or one that is shutting down
+func retryableRead(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// read makes an idempotent call, hedged after the hedge delay of the client and retried
+// by its retry policy. Writes are never retried, since they may have been applied
+func read[T any](ctx context.Context, c *MovieGRPCClient, method string, call func(context.Context) (T, error)) (T, error) {
+	var resp T
+	err := c.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = hedge(ctx, c.hedgeDelay, logging.FromContext(ctx, c.logger), method, call)
+		return err
+	})
+	return resp, err
+}
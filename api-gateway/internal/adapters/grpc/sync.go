@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// SyncGRPCClient calls the catalog sync service of the movie service over the connection
+// of the movie client
+type SyncGRPCClient struct {
+	client pb.CatalogSyncServiceClient
+	logger *slog.Logger
+}
+
+// Sync returns a client of the catalog sync service sharing the connection
+func (c *MovieGRPCClient) Sync() ports.SyncServicePort {
+	return &SyncGRPCClient{
+		client: pb.NewCatalogSyncServiceClient(c.conn),
+		logger: c.logger,
+	}
+}
+
+func (c *SyncGRPCClient) ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Listing sync conflicts", "page", page, "limit", limit)
+
+	resp, err := c.client.ListSyncConflicts(ctx, &pb.ListSyncConflictsRequest{Page: page, Limit: limit})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to list sync conflicts", "error", err)
+		return nil, 0, fmt.Errorf("failed to list sync conflicts: %w", fromStatusError(err))
+	}
+
+	conflicts := make([]*domain.SyncConflict, len(resp.Conflicts))
+	for i, conflict := range resp.Conflicts {
+		conflicts[i] = &domain.SyncConflict{
+			ID:      conflict.Id,
+			MovieID: conflict.MovieId,
+			Remote: domain.RemoteMovie{
+				Source:         conflict.Remote.GetSource(),
+				ExternalID:     conflict.Remote.GetExternalId(),
+				Title:          conflict.Remote.GetTitle(),
+				Year:           conflict.Remote.GetYear(),
+				Certification:  conflict.Remote.GetCertification(),
+				Regions:        conflict.Remote.GetRegions(),
+				Awards:         conflict.Remote.GetAwards(),
+				Genres:         conflict.Remote.GetGenres(),
+				Director:       conflict.Remote.GetDirector(),
+				Synopsis:       conflict.Remote.GetSynopsis(),
+				RuntimeMinutes: conflict.Remote.GetRuntimeMinutes(),
+			},
+			DetectedAt: conflict.DetectedAt.AsTime(),
+		}
+	}
+	return conflicts, resp.Total, nil
+}
+
+func (c *SyncGRPCClient) ResolveSyncConflict(ctx context.Context, id, resolution string) (*domain.Movie, error) {
+	logging.FromContext(ctx, c.logger).Debug("gRPC client: Resolving sync conflict", "id", id, "resolution", resolution)
+
+	resp, err := c.client.ResolveSyncConflict(ctx, &pb.ResolveSyncConflictRequest{Id: id, Resolution: resolution})
+	if err != nil {
+		logging.FromContext(ctx, c.logger).Error("gRPC client: Failed to resolve sync conflict", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to resolve sync conflict: %w", fromStatusError(err))
+	}
+	if resp.Movie == nil {
+		return nil, nil
+	}
+	return toDomainMovie(resp.Movie), nil
+}
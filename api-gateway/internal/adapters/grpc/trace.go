@@ -0,0 +1,22 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// traceInterceptor sends the trace context of the request in the traceparent metadata,
+// along with tracestate and b3 when the caller sent them, as a span of its own for each
+// call so hedged calls can be told apart
+func traceInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if trace, ok := domain.TraceFromContext(ctx); ok {
+		trace.Child().Propagate(func(key, value string) {
+			ctx = metadata.AppendToOutgoingContext(ctx, key, value)
+		})
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
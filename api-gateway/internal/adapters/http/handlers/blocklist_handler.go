@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// maxBlockSeconds bounds the blocks set by hand to 30 days
+const maxBlockSeconds = 30 * 24 * 60 * 60
+
+// blockRequest is an IP address to block, and for how long
+type blockRequest struct {
+	IP      string `json:"ip" example:"203.0.113.7"`
+	Seconds int    `json:"seconds" example:"86400"`
+	Note    string `json:"note,omitempty" example:"credential stuffing"`
+}
+
+// BlocklistResponse is the list of blocked clients, the latest first
+type BlocklistResponse struct {
+	Clients []domain.BlockedClient `json:"clients"`
+}
+
+type BlocklistHandler struct {
+	blocklist ports.BlocklistPort
+	clock     clock.Clock
+}
+
+func NewBlocklistHandler(blocklist ports.BlocklistPort, clk clock.Clock) *BlocklistHandler {
+	return &BlocklistHandler{blocklist: blocklist, clock: clk}
+}
+
+// GetBlocklist lists the blocked IP addresses
+//
+// @Summary List the blocked IP addresses
+// @Description Lists the IP addresses this gateway replica refuses, the scanners it caught and those blocked by hand, the latest first
+// @Tags Operations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} BlocklistResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/blocklist [get]
+func (h *BlocklistHandler) GetBlocklist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BlocklistResponse{Clients: h.blocklist.List()})
+}
+
+// BlockClient blocks an IP address for a number of seconds
+//
+// @Summary Block an IP address
+// @Description Refuses the requests of the IP address with 403 for the given seconds, up to 30 days, replacing any block of it
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body blockRequest true "IP address and duration"
+// @Success 201 {object} domain.BlockedClient
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/blocklist [post]
+func (h *BlocklistHandler) BlockClient(w http.ResponseWriter, r *http.Request) {
+	var input blockRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	ip, ok := parseIP(w, input.IP)
+	if !ok {
+		return
+	}
+	if input.Seconds < 1 || input.Seconds > maxBlockSeconds {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "seconds must be between 1 and 2592000"})
+		return
+	}
+
+	now := h.clock.Now().UTC()
+	client := domain.BlockedClient{
+		IP:        ip,
+		Reason:    domain.BlockedByAdmin,
+		Note:      input.Note,
+		BlockedAt: now,
+		Until:     now.Add(time.Duration(input.Seconds) * time.Second),
+	}
+	h.blocklist.Block(client)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(client)
+}
+
+// UnblockClient lifts the block of an IP address
+//
+// @Summary Unblock an IP address
+// @Tags Operations
+// @Security ApiKeyAuth
+// @Param ip path string true "IP address"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "The address is not blocked"
+// @Router /admin/blocklist/{ip} [delete]
+func (h *BlocklistHandler) UnblockClient(w http.ResponseWriter, r *http.Request) {
+	ip, ok := parseIP(w, mux.Vars(r)["ip"])
+	if !ok {
+		return
+	}
+	if !h.blocklist.Unblock(ip) {
+		writeError(w, http.StatusNotFound, ErrorResponse{Error: "not_blocked", Message: "the address is not blocked"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseIP normalizes an IP address as the blocklist stores it, answering 400 when it is
+// invalid
+func parseIP(w http.ResponseWriter, value string) (string, bool) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "ip must be an IPv4 or IPv6 address"})
+		return "", false
+	}
+	return addr.Unmap().String(), true
+}
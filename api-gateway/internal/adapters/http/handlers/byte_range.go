@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// byteRange is the single range of bytes asked by a Range header
+type byteRange struct {
+	// start is the first byte, or the number of bytes to send from the end for suffix
+	// ranges such as bytes=-500
+	start int64
+	// end is the last byte, or -1 when the range runs to the end of the file
+	end    int64
+	suffix bool
+}
+
+// parseByteRange parses a Range header asking for one range of bytes. Headers it cannot
+// parse and those asking for several ranges are ignored, as RFC 9110 allows, so the
+// whole file is sent
+func parseByteRange(header string) (byteRange, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return byteRange{}, false
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return byteRange{}, false
+	}
+
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return byteRange{}, false
+		}
+		return byteRange{start: n, end: -1, suffix: true}, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return byteRange{}, false
+	}
+	if last == "" {
+		return byteRange{start: start, end: -1}, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return byteRange{}, false
+	}
+	return byteRange{start: start, end: end}, true
+}
+
+// offset is the offset the file is read from: negative for suffix ranges, which count
+// from the end
+func (b byteRange) offset() int64 {
+	if b.suffix {
+		return -b.start
+	}
+	return b.start
+}
+
+// resolve returns the first and last byte of the range in a file of size, or false when
+// the range holds none of them
+func (b byteRange) resolve(size int64) (first, last int64, ok bool) {
+	if b.suffix {
+		if b.start == 0 || size == 0 {
+			return 0, 0, false
+		}
+		return max(size-b.start, 0), size - 1, true
+	}
+	if b.start >= size {
+		return 0, 0, false
+	}
+	if b.end < 0 || b.end >= size {
+		return b.start, size - 1, true
+	}
+	return b.start, b.end, true
+}
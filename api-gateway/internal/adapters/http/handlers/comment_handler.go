@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type CommentHandler struct {
+	commentService ports.CommentServicePort
+	logger         *slog.Logger
+}
+
+func NewCommentHandler(commentService ports.CommentServicePort, logger *slog.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+		logger:         logger,
+	}
+}
+
+// commentRequest is the body of create comment requests
+type commentRequest struct {
+	ParentID string `json:"parent_id"`
+	Author   string `json:"author"`
+	Body     string `json:"body"`
+}
+
+// flagRequest is the body of flag requests
+type flagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// decisionRequest is the body of moderation decisions
+type decisionRequest struct {
+	Decision string `json:"decision"`
+}
+
+// commentsResponse is a page of threads or flagged comments
+type commentsResponse struct {
+	Comments []*domain.Comment `json:"comments"`
+	Total    int32             `json:"total"`
+}
+
+// GetComments returns a page of the threads of a movie, newest first, each with its
+// replies oldest first
+//
+// @Summary List the threads of a movie
+// @Tags Comments
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Threads per page"
+// @Success 200 {object} commentsResponse
+// @Header 200 {integer} X-Total-Count "Total of threads"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/movies/{id}/comments [get]
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	id, ok := movieID(w, r)
+	if !ok {
+		return
+	}
+	page, limit := pageParams(r)
+
+	comments, total, err := h.commentService.ListComments(r.Context(), id, page, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to list comments", "error", err, "movie_id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(int(total)))
+	json.NewEncoder(w).Encode(commentsResponse{Comments: comments, Total: total})
+}
+
+// CreateComment starts a thread on a movie, or replies to the comment given as parent_id
+//
+// @Summary Comment on a movie
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param comment body commentRequest true "Comment"
+// @Success 201 {object} domain.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Movie or parent comment not found"
+// @Failure 409 {object} ErrorResponse "Parent comment deleted"
+// @Router /api/v1/movies/{id}/comments [post]
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	id, ok := movieID(w, r)
+	if !ok {
+		return
+	}
+
+	var input commentRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode create comment request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(r.Context(), domain.CommentInput{
+		MovieID:  id,
+		ParentID: input.ParentID,
+		Author:   input.Author,
+		Body:     input.Body,
+	})
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to create comment", "error", err, "movie_id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+// FlagComment reports a comment to the moderators
+//
+// @Summary Flag a comment
+// @Tags Comments
+// @Accept json
+// @Produce json
+// @Param commentId path string true "Comment ID"
+// @Param flag body flagRequest true "Reason"
+// @Success 200 {object} domain.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Comment deleted"
+// @Router /api/v1/comments/{commentId}/flags [post]
+func (h *CommentHandler) FlagComment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["commentId"]
+
+	var input flagRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode flag comment request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	comment, err := h.commentService.FlagComment(r.Context(), id, input.Reason)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to flag comment", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// GetFlaggedComments returns the comments awaiting moderation, most flagged first, with
+// the reasons they were flagged for
+//
+// @Summary List flagged comments
+// @Tags Moderation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Comments per page"
+// @Success 200 {object} commentsResponse
+// @Header 200 {integer} X-Total-Count "Total of flagged comments"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/comments/flagged [get]
+func (h *CommentHandler) GetFlaggedComments(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+
+	comments, total, err := h.commentService.ListFlaggedComments(r.Context(), page, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to list flagged comments", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Total-Count", strconv.Itoa(int(total)))
+	json.NewEncoder(w).Encode(commentsResponse{Comments: comments, Total: total})
+}
+
+// DeleteComment hides the author and body of a comment; its replies stay in the thread
+//
+// @Summary Delete a comment
+// @Tags Moderation
+// @Security ApiKeyAuth
+// @Param commentId path string true "Comment ID"
+// @Success 204
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/comments/{commentId} [delete]
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["commentId"]
+
+	if err := h.commentService.DeleteComment(r.Context(), id); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to delete comment", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DismissCommentFlags clears the flags of a comment the moderators chose to keep
+//
+// @Summary Dismiss the flags of a comment
+// @Tags Moderation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param commentId path string true "Comment ID"
+// @Success 200 {object} domain.Comment
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/comments/{commentId}/flags [delete]
+func (h *CommentHandler) DismissCommentFlags(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["commentId"]
+
+	comment, err := h.commentService.DismissCommentFlags(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to dismiss comment flags", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// GetModerationQueue returns the comments the moderation filters held for a moderator,
+// oldest first, with the reasons they were held for
+//
+// @Summary List the moderation queue
+// @Tags Moderation
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Comments per page"
+// @Success 200 {object} commentsResponse
+// @Header 200 {integer} X-Total-Count "Total of queued comments"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/comments/queue [get]
+func (h *CommentHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+
+	comments, total, err := h.commentService.ListModerationQueue(r.Context(), page, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to list moderation queue", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Total-Count", strconv.Itoa(int(total)))
+	json.NewEncoder(w).Encode(commentsResponse{Comments: comments, Total: total})
+}
+
+// DecideModeration publishes or rejects a queued comment
+//
+// @Summary Publish or reject a queued comment
+// @Tags Moderation
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param commentId path string true "Comment ID"
+// @Param decision body decisionRequest true "Decision"
+// @Success 200 {object} domain.Comment
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Comment not awaiting moderation"
+// @Router /admin/comments/{commentId}/decision [post]
+func (h *CommentHandler) DecideModeration(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["commentId"]
+
+	var input decisionRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode moderation decision request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	comment, err := h.commentService.DecideModeration(r.Context(), id, input.Decision)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decide moderation", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+// movieID reads the movie ID of the path, answering 400 when it is out of range
+func movieID(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// pageParams reads the page and limit query parameters; defaults and limits are applied
+// by the service
+func pageParams(r *http.Request) (int32, int32) {
+	page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 32)
+	limit, _ := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 32)
+	return int32(page), int32(limit)
+}
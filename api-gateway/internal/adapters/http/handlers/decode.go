@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const maxBodyBytes = 1 << 20
+
+// bodyError describes why a request body could not be decoded
+type bodyError struct {
+	status int
+	resp   ErrorResponse
+}
+
+func (e *bodyError) Error() string {
+	return e.resp.Message
+}
+
+// decodeJSON strictly decodes a single JSON object from the request body into dst,
+// rejecting unknown fields and reporting type mismatches with the offending field and position
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	return decodeJSONLimit(w, r, dst, maxBodyBytes)
+}
+
+// decodeJSONLimit is decodeJSON for bodies of up to limit bytes
+func decodeJSONLimit(w http.ResponseWriter, r *http.Request, dst interface{}, limit int64) error {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return newBodyError(http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("request body must not be larger than %d bytes", maxErr.Limit))
+		}
+		return newBodyError(http.StatusBadRequest, "invalid_request_body", "failed to read request body")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return translateDecodeError(body, err)
+	}
+	if dec.More() {
+		line, column := position(body, dec.InputOffset())
+		return &bodyError{
+			status: http.StatusBadRequest,
+			resp: ErrorResponse{
+				Error:   "invalid_request_body",
+				Message: "request body must contain a single JSON object",
+				Line:    line,
+				Column:  column,
+			},
+		}
+	}
+
+	return nil
+}
+
+func translateDecodeError(body []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return newBodyError(http.StatusBadRequest, "invalid_request_body", "request body cannot be empty")
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		line, column := position(body, int64(len(body)))
+		return &bodyError{
+			status: http.StatusBadRequest,
+			resp: ErrorResponse{
+				Error:   "invalid_request_body",
+				Message: "request body contains malformed JSON (unexpected end of input)",
+				Line:    line,
+				Column:  column,
+			},
+		}
+
+	case errors.As(err, &syntaxErr):
+		line, column := position(body, syntaxErr.Offset)
+		return &bodyError{
+			status: http.StatusBadRequest,
+			resp: ErrorResponse{
+				Error:   "invalid_request_body",
+				Message: fmt.Sprintf("request body contains malformed JSON at line %d, column %d: %s", line, column, syntaxErr.Error()),
+				Line:    line,
+				Column:  column,
+			},
+		}
+
+	case errors.As(err, &typeErr):
+		line, column := position(body, typeErr.Offset)
+		return &bodyError{
+			status: http.StatusBadRequest,
+			resp: ErrorResponse{
+				Error:   "invalid_field_type",
+				Message: fmt.Sprintf("field %q must be a %s, got %s", typeErr.Field, typeErr.Type.String(), typeErr.Value),
+				Field:   typeErr.Field,
+				Line:    line,
+				Column:  column,
+			},
+		}
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &bodyError{
+			status: http.StatusBadRequest,
+			resp: ErrorResponse{
+				Error:   "unknown_field",
+				Message: fmt.Sprintf("request body contains unknown field %q", field),
+				Field:   field,
+			},
+		}
+	}
+
+	return newBodyError(http.StatusBadRequest, "invalid_request_body", err.Error())
+}
+
+func newBodyError(status int, code, message string) *bodyError {
+	return &bodyError{
+		status: status,
+		resp: ErrorResponse{
+			Error:   code,
+			Message: message,
+		},
+	}
+}
+
+// writeBodyError writes the error returned by decodeJSON
+func writeBodyError(w http.ResponseWriter, err error) {
+	var bodyErr *bodyError
+	if errors.As(err, &bodyErr) {
+		writeError(w, bodyErr.status, bodyErr.resp)
+		return
+	}
+	writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request_body", Message: err.Error()})
+}
+
+// position converts a byte offset in body into a 1-based line and column
+func position(body []byte, offset int64) (int, int) {
+	if offset > int64(len(body)) {
+		offset = int64(len(body))
+	}
+	line, column := 1, 1
+	for _, b := range body[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return line, column
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/apperr"
+)
+
+// ErrorResponse is the JSON body returned for client errors
+type ErrorResponse struct {
+	Error   string `json:"error" example:"invalid_request"`
+	Message string `json:"message,omitempty" example:"invalid page number"`
+	Field   string `json:"field,omitempty" example:"year"`
+	Line    int    `json:"line,omitempty" example:"1"`
+	Column  int    `json:"column,omitempty" example:"27"`
+	// Fields lists every field rejected by the movie service
+	Fields []domain.FieldViolation `json:"fields,omitempty"`
+	// Reason is the movie service's identifier of the failure, e.g. "INVALID_YEAR"
+	Reason string `json:"reason,omitempty" example:"INVALID_YEAR"`
+}
+
+// unavailableRetryAfter is the delay in seconds suggested to clients while the movie
+// service cannot be reached, about the time the gRPC client takes to reconnect
+const unavailableRetryAfter = 5
+
+func writeError(w http.ResponseWriter, status int, resp ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeServiceError maps errors returned by the movie service onto HTTP statuses by their
+// kind, adding the reason and rejected fields reported by the service. The error code of
+// the response is the lowercase reason, such as movie_not_found, except for requests to
+// fix, unavailable services and timeouts, which share theirs
+func writeServiceError(w http.ResponseWriter, err error) {
+	status, resp := http.StatusInternalServerError, ErrorResponse{Error: "internal_error", Message: err.Error()}
+	retryAfter := 0
+	if known, ok := apperr.Find(err); ok {
+		status, resp.Error, resp.Reason = known.Kind.HTTPStatus(), strings.ToLower(known.Reason), known.Reason
+		switch known.Kind {
+		case apperr.Invalid:
+			resp.Error = "invalid_request"
+		case apperr.Unavailable:
+			resp.Error, retryAfter = "service_unavailable", unavailableRetryAfter
+		case apperr.Timeout:
+			resp.Error = "gateway_timeout"
+		case apperr.Internal:
+			resp.Error = "internal_error"
+		}
+		// A stale version fails the If-Match precondition of the request
+		if errors.Is(known, domain.ErrVersionMismatch) {
+			status, resp.Error = http.StatusPreconditionFailed, "precondition_failed"
+		}
+
+		for _, violation := range known.Violations {
+			resp.Fields = append(resp.Fields, domain.FieldViolation{Field: violation.Field, Description: violation.Description})
+		}
+		if len(resp.Fields) == 1 {
+			resp.Field = resp.Fields[0].Field
+		}
+		if known.RetryAfter > 0 {
+			retryAfter = int(math.Ceil(known.RetryAfter.Seconds()))
+		}
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	writeError(w, status, resp)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusDegraded  = "degraded"
+	HealthStatusUnhealthy = "unhealthy"
+
+	ComponentUp   = "up"
+	ComponentDown = "down"
+
+	// healthCheckTimeout bounds each component check
+	healthCheckTimeout = 2 * time.Second
+)
+
+// HealthCheck checks one dependency of the gateway, returning details such as its
+// state, or an error when it cannot be used
+type HealthCheck struct {
+	Name string
+	// Required makes the gateway unhealthy while the component is down; optional
+	// components only degrade it
+	Required bool
+	Check    func(ctx context.Context) (map[string]interface{}, error)
+}
+
+// HealthResponse is the body of the health endpoint
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	Timestamp  string                     `json:"timestamp"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+type ComponentHealth struct {
+	Status    string                 `json:"status"`
+	Required  bool                   `json:"required"`
+	LatencyMs float64                `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthHandler reports the status of each dependency and of the gateway as a whole:
+// unhealthy, with a 503, when a required component is down, degraded when only optional
+// ones are, healthy otherwise
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// @Summary Check the health of the gateway
+// @Tags Operations
+// @Produce json
+// @Success 200 {object} HealthResponse "Healthy or degraded"
+// @Failure 503 {object} HealthResponse "A required component is down"
+// @Router /health [get]
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response := h.Check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status == HealthStatusUnhealthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Check runs the component checks concurrently
+func (h *HealthHandler) Check(ctx context.Context) HealthResponse {
+	components := make([]ComponentHealth, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			components[i] = runHealthCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	response := HealthResponse{
+		Status:     HealthStatusHealthy,
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Components: make(map[string]ComponentHealth, len(components)),
+	}
+	for i, component := range components {
+		response.Components[h.checks[i].Name] = component
+		if component.Status == ComponentUp {
+			continue
+		}
+		if component.Required {
+			response.Status = HealthStatusUnhealthy
+		} else if response.Status == HealthStatusHealthy {
+			response.Status = HealthStatusDegraded
+		}
+	}
+	return response
+}
+
+func runHealthCheck(ctx context.Context, check HealthCheck) ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Check(ctx)
+	component := ComponentHealth{
+		Status:    ComponentUp,
+		Required:  check.Required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		component.Status = ComponentDown
+		component.Error = err.Error()
+	}
+	return component
+}
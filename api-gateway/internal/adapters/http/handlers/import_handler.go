@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+// maxImportBodyBytes bounds the body of imports, keeping the rows within the default
+// message size of the movie service
+const maxImportBodyBytes = 4 << 20
+
+// importPollInterval is the delay in seconds suggested to clients polling a running import
+const importPollInterval = 2
+
+type ImportHandler struct {
+	importService ports.ImportServicePort
+	logger        *slog.Logger
+}
+
+func NewImportHandler(importService ports.ImportServicePort, logger *slog.Logger) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		logger:        logger,
+	}
+}
+
+// importRowRequest is a movie of an import, with the ID of the movie it replaces
+type importRowRequest struct {
+	ID int32 `json:"id"`
+	movieRequest
+}
+
+// importRequest is the body of imports
+type importRequest struct {
+	Movies []importRowRequest `json:"movies"`
+}
+
+// CreateImport starts importing the movies of the body in the background, answering
+// 202 with the pending import and its location, which is polled for the report
+//
+// @Summary Import movies
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param import body importRequest true "Movies to import"
+// @Success 202 {object} domain.Import
+// @Header 202 {string} Location "URL of the import"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/imports [post]
+func (h *ImportHandler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	var input importRequest
+	if err := decodeJSONLimit(w, r, &input, maxImportBodyBytes); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode import request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	rows := make([]domain.ImportRow, len(input.Movies))
+	for i, movie := range input.Movies {
+		rows[i] = domain.ImportRow{ID: movie.ID, Input: movie.toDomain()}
+	}
+
+	imp, err := h.importService.StartImport(r.Context(), rows)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to start import", "error", err, "rows", len(rows))
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/imports/"+imp.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(imp)
+}
+
+// GetImport returns the status of an import with the result of each row processed so
+// far. Until the import is done, Retry-After suggests when to poll again. With
+// format=csv the row results are downloaded as a CSV file
+//
+// @Summary Get an import
+// @Tags Jobs
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Param importId path string true "Import ID"
+// @Param format query string false "csv downloads the row results" Enums(csv)
+// @Success 200 {object} domain.Import
+// @Header 200 {integer} Retry-After "Seconds before polling a running import again"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/imports/{importId} [get]
+func (h *ImportHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["importId"]
+
+	imp, err := h.importService.GetImport(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get import", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if !imp.Done() {
+		w.Header().Set("Retry-After", strconv.Itoa(importPollInterval))
+	}
+	if r.URL.Query().Get("format") == "csv" {
+		writeImportCSV(w, imp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imp)
+}
+
+// writeImportCSV writes the row results of an import as a CSV attachment
+func writeImportCSV(w http.ResponseWriter, imp *domain.Import) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="import-`+imp.ID+`.csv"`)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{"row", "status", "movie_id", "field", "error"})
+	for _, result := range imp.Results {
+		movieID := ""
+		if result.MovieID != 0 {
+			movieID = strconv.Itoa(int(result.MovieID))
+		}
+		out.Write([]string{strconv.Itoa(int(result.Row)), result.Status, movieID, result.Field, result.Error})
+	}
+	out.Flush()
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type JobHandler struct {
+	jobService ports.JobServicePort
+	// signer signs the URLs of job files; nil when signed URLs are disabled
+	signer *middleware.URLSigner
+	logger *slog.Logger
+}
+
+func NewJobHandler(jobService ports.JobServicePort, signer *middleware.URLSigner, logger *slog.Logger) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+		signer:     signer,
+		logger:     logger,
+	}
+}
+
+// jobRequest is the body of jobs; the parameters depend on the type
+type jobRequest struct {
+	Type string `json:"type"`
+	// Priority is "high", "normal" or "low"; empty uses the default of the type
+	Priority string          `json:"priority"`
+	Params   json.RawMessage `json:"params" swaggertype:"object"`
+}
+
+// JobFileURLResponse is a URL downloading the file of a job without an API key until it
+// expires
+type JobFileURLResponse struct {
+	// URL is relative to the gateway
+	URL       string    `json:"url" example:"/api/v1/jobs/0190a4e2/file?expires=1760000000&signature=Qm9..."`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateJob queues the job of the body, answering 202 with the pending job and its
+// location, which is polled for its progress and result. The body is bounded like the
+// body of imports, whose rows go in the parameters of import jobs
+//
+// @Summary Submit a job
+// @Tags Jobs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param job body jobRequest true "Job"
+// @Success 202 {object} domain.Job
+// @Header 202 {string} Location "URL of the job"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 413 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/jobs [post]
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var input jobRequest
+	if err := decodeJSONLimit(w, r, &input, maxImportBodyBytes); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode job request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	job, err := h.jobService.SubmitJob(r.Context(), input.Type, input.Priority, input.Params)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to submit job", "error", err, "type", input.Type, "priority", input.Priority)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/v1/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJob returns the progress of a job with its result so far. Until the job is done,
+// Retry-After suggests when to poll again
+//
+// @Summary Get a job
+// @Tags Jobs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param jobId path string true "Job ID"
+// @Success 200 {object} domain.Job
+// @Header 200 {integer} Retry-After "Seconds before polling a running job again"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/jobs/{jobId} [get]
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["jobId"]
+
+	job, err := h.jobService.GetJob(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get job", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if !job.Done() {
+		w.Header().Set("Retry-After", strconv.Itoa(importPollInterval))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJobFile downloads the file of a completed job, such as the backup written by an
+// export, streaming it as the movie service sends it. A Range of bytes is answered with
+// 206 and only that part of the file, which the movie service starts reading at, so an
+// interrupted download resumes with bytes=<received>- instead of starting over. Job
+// files never change, so the job ID is their entity tag, which If-Range may name
+//
+// @Summary Download the file of a job
+// @Tags Jobs
+// @Produce application/gzip
+// @Security ApiKeyAuth
+// @Param jobId path string true "Job ID"
+// @Param Range header string false "Single range of bytes, e.g. bytes=1048576-"
+// @Param If-Range header string false "Entity tag of the file; the whole file is sent when it does not match"
+// @Success 200 {file} file "Gzipped NDJSON backup"
+// @Success 206 {file} file "Requested range of the backup"
+// @Header 200,206 {string} ETag "Entity tag of the file"
+// @Header 200,206 {string} Accept-Ranges "bytes"
+// @Header 206 {string} Content-Range "Range sent and size of the file, e.g. bytes 0-99/2048"
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "The job has no file"
+// @Failure 416 {object} ErrorResponse "The range starts past the end of the file"
+// @Router /api/v1/jobs/{jobId}/file [get]
+func (h *JobHandler) GetJobFile(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["jobId"]
+	etag := `"` + id + `"`
+
+	rng, ranged := parseByteRange(r.Header.Get("Range"))
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && ifRange != etag {
+		ranged = false
+	}
+	var offset int64
+	if ranged {
+		offset = rng.offset()
+	}
+
+	file, err := h.jobService.OpenJobFile(r.Context(), id, offset)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to open job file", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", etag)
+	size := strconv.FormatInt(file.Size, 10)
+	status, body := http.StatusOK, io.Reader(file)
+	if ranged {
+		first, last, ok := rng.resolve(file.Size)
+		if !ok {
+			w.Header().Set("Content-Range", "bytes */"+size)
+			writeError(w, http.StatusRequestedRangeNotSatisfiable, ErrorResponse{Error: "range_not_satisfiable", Message: "range is outside the " + size + " bytes of the file"})
+			return
+		}
+		w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(first, 10)+"-"+strconv.FormatInt(last, 10)+"/"+size)
+		size = strconv.FormatInt(last-first+1, 10)
+		status, body = http.StatusPartialContent, io.LimitReader(file, last-first+1)
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="job-`+id+`.ndjson.gz"`)
+	w.Header().Set("Content-Length", size)
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, body); err != nil {
+		// The status is sent already, so the client sees a truncated file
+		logging.FromContext(r.Context(), h.logger).Error("failed to stream job file", "error", err, "id", id)
+	}
+}
+
+// CreateJobFileURL signs a URL of the file of a completed job, which downloads it without
+// an API key until it expires, such as from a browser. The route exists only when
+// DOWNLOAD_URL_SECRETS is set
+//
+// @Summary Create a signed URL of the file of a job
+// @Tags Jobs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param jobId path string true "Job ID"
+// @Success 201 {object} JobFileURLResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "The job has no file"
+// @Router /api/v1/jobs/{jobId}/file/url [post]
+func (h *JobHandler) CreateJobFileURL(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["jobId"]
+
+	job, err := h.jobService.GetJob(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get job", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+	if job.Status != domain.ImportCompleted || !job.File {
+		writeServiceError(w, domain.ErrNoJobFile)
+		return
+	}
+
+	signed, expires := h.signer.Sign("/api/v1/jobs/" + id + "/file")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(JobFileURLResponse{URL: signed, ExpiresAt: expires})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// MetaResponse describes the limits of the API
+type MetaResponse struct {
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+// PaginationMeta lists the page sizes the list endpoints accept
+type PaginationMeta struct {
+	DefaultLimit int32 `json:"default_limit" example:"10"`
+	MaxLimit     int32 `json:"max_limit" example:"100"`
+	MaxPage      int32 `json:"max_page" example:"1000"`
+}
+
+type MetaHandler struct {
+	pagination domain.Pagination
+}
+
+func NewMetaHandler(pagination domain.Pagination) *MetaHandler {
+	return &MetaHandler{
+		pagination: pagination,
+	}
+}
+
+// GetMeta describes the capabilities and limits of the API so clients don't have to hard-code them
+//
+// @Summary Describe the limits of the API
+// @Tags Meta
+// @Produce json
+// @Success 200 {object} MetaResponse
+// @Router /api/v1/meta [get]
+func (h *MetaHandler) GetMeta(w http.ResponseWriter, r *http.Request) {
+	response := MetaResponse{Pagination: PaginationMeta{
+		DefaultLimit: h.pagination.DefaultLimit,
+		MaxLimit:     h.pagination.MaxLimit,
+		MaxPage:      h.pagination.MaxPage,
+	}}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+// replayRequest is the period, and optionally the API key, of the metering events to
+// publish again
+type replayRequest struct {
+	From  time.Time `json:"from" example:"2025-06-01T00:00:00Z"`
+	To    time.Time `json:"to" example:"2025-06-02T00:00:00Z"`
+	KeyID string    `json:"key_id,omitempty" example:"3f2a9c0b71de"`
+}
+
+// ReplayResponse is the number of metering events published again, and when the events
+// this replica still keeps start
+type ReplayResponse struct {
+	Replayed     int        `json:"replayed" example:"1250"`
+	JournalStart *time.Time `json:"journal_start,omitempty"`
+}
+
+type MeteringHandler struct {
+	meter  ports.MeteringPort
+	logger *slog.Logger
+}
+
+func NewMeteringHandler(meter ports.MeteringPort, logger *slog.Logger) *MeteringHandler {
+	return &MeteringHandler{meter: meter, logger: logger}
+}
+
+// ReplayEvents publishes again the metering events of a period
+//
+// @Summary Replay metering events
+// @Description Publishes again, with their original IDs, the metering events this gateway replica still keeps that occurred from from until to, of every API key or of the one with key_id. Billing discards the events it already has by their ID
+// @Tags Usage
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body replayRequest true "Period and API key of the events"
+// @Success 200 {object} ReplayResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 502 {object} ErrorResponse "The events could not all be published"
+// @Router /admin/metering/replay [post]
+func (h *MeteringHandler) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	var input replayRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if input.From.IsZero() || input.To.IsZero() || input.From.After(input.To) {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "from and to must be times such as 2025-06-01T00:00:00Z, from up to to"})
+		return
+	}
+
+	replayed, start, err := h.meter.Replay(r.Context(), input.From, input.To, input.KeyID)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to replay metering events", "error", err, "replayed", replayed)
+		writeError(w, http.StatusBadGateway, ErrorResponse{Error: "replay_failed", Message: fmt.Sprintf("failed to publish the metering events after %d of them", replayed)})
+		return
+	}
+
+	response := ReplayResponse{Replayed: replayed}
+	if !start.IsZero() {
+		response.JournalStart = &start
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
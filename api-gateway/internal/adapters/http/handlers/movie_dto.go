@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/apperr"
+)
+
+// The movie DTOs are the JSON representation of movies in the API. They are kept apart
+// from domain.Movie, so fields added to the model and the proto reach clients only when
+// added here, and renames behind the gateway don't change the API
+
+// movieRequest is the body of create and replace requests
+type movieRequest struct {
+	Title          string   `json:"title"`
+	Year           string   `json:"year"`
+	Regions        []string `json:"regions"`
+	Awards         []string `json:"awards"`
+	Certification  string   `json:"certification"`
+	IMDbID         string   `json:"imdb_id"`
+	TMDbID         string   `json:"tmdb_id"`
+	Genres         []string `json:"genres"`
+	Director       string   `json:"director"`
+	Synopsis       string   `json:"synopsis"`
+	RuntimeMinutes int32    `json:"runtime_minutes"`
+}
+
+// validate rejects requests missing a field or with a malformed year before they reach
+// the movie service, naming each rejected field. Checks needing the service's
+// configuration, such as certifications, are left to it
+func (m movieRequest) validate() error {
+	var violations []apperr.Violation
+	reason := domain.ErrInvalidYear.Reason
+	if m.Title == "" {
+		violations = append(violations, apperr.Violation{Field: "title", Description: "title cannot be empty"})
+		reason = "INVALID_ARGUMENT"
+	}
+	if m.Year == "" {
+		violations = append(violations, apperr.Violation{Field: "year", Description: "year cannot be empty"})
+		reason = "INVALID_ARGUMENT"
+	} else if _, err := strconv.Atoi(m.Year); err != nil || len(m.Year) != 4 {
+		violations = append(violations, apperr.Violation{Field: "year", Description: domain.ErrInvalidYear.Error()})
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(violations))
+	for i, violation := range violations {
+		descriptions[i] = violation.Description
+	}
+	return &apperr.Error{
+		Kind:       apperr.Invalid,
+		Reason:     reason,
+		Message:    domain.ErrInvalidMovieData.Error() + ": " + strings.Join(descriptions, ", "),
+		Violations: violations,
+	}
+}
+
+// moviePatchRequest is the body of patch requests: a movie request of which only the
+// fields present change
+type moviePatchRequest struct {
+	movieRequest
+	// paths are the JSON names of the fields present, in the order of MovieInput
+	paths []string
+}
+
+func (p *moviePatchRequest) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p.movieRequest); err != nil {
+		return err
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return err
+	}
+	// Names match fields regardless of case, as when decoding
+	for _, path := range moviePaths {
+		for name := range present {
+			if strings.EqualFold(name, path) {
+				p.paths = append(p.paths, path)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// moviePaths are the JSON names of the fields of movieRequest
+var moviePaths = []string{"title", "year", "regions", "awards", "certification", "imdb_id", "tmdb_id", "genres", "director", "synopsis", "runtime_minutes"}
+
+func (m movieRequest) toDomain() domain.MovieInput {
+	return domain.MovieInput{
+		Title:          m.Title,
+		Year:           m.Year,
+		Regions:        m.Regions,
+		Awards:         m.Awards,
+		Certification:  m.Certification,
+		IMDbID:         m.IMDbID,
+		TMDbID:         m.TMDbID,
+		Genres:         m.Genres,
+		Director:       m.Director,
+		Synopsis:       m.Synopsis,
+		RuntimeMinutes: m.RuntimeMinutes,
+	}
+}
+
+// MovieResponse is a movie as returned by the API
+type MovieResponse struct {
+	ID             int32    `json:"id" example:"1"`
+	Title          string   `json:"title" example:"Heat"`
+	Year           string   `json:"year" example:"1995"`
+	Version        int64    `json:"version" example:"3"`
+	Regions        []string `json:"regions,omitempty" example:"BR,PT"`
+	Awards         []string `json:"awards,omitempty"`
+	Certification  string   `json:"certification,omitempty" example:"R"`
+	IMDbID         string   `json:"imdb_id,omitempty" example:"tt0113277"`
+	TMDbID         string   `json:"tmdb_id,omitempty" example:"949"`
+	Genres         []string `json:"genres,omitempty" example:"Crime,Drama"`
+	Director       string   `json:"director,omitempty" example:"Michael Mann"`
+	Synopsis       string   `json:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty" example:"170"`
+}
+
+func newMovieResponse(movie *domain.Movie) MovieResponse {
+	return MovieResponse{
+		ID:             movie.ID,
+		Title:          movie.Title,
+		Year:           movie.Year,
+		Version:        movie.Version,
+		Regions:        movie.Regions,
+		Awards:         movie.Awards,
+		Certification:  movie.Certification,
+		IMDbID:         movie.IMDbID,
+		TMDbID:         movie.TMDbID,
+		Genres:         movie.Genres,
+		Director:       movie.Director,
+		Synopsis:       movie.Synopsis,
+		RuntimeMinutes: movie.RuntimeMinutes,
+	}
+}
+
+// MovieListResponse is a page of movies; Total is -1 when the movies were not counted
+type MovieListResponse struct {
+	Movies []MovieResponse `json:"movies"`
+	Total  int32           `json:"total"`
+}
+
+func newMovieListResponse(movies []*domain.Movie, total int32) MovieListResponse {
+	resp := MovieListResponse{Movies: make([]MovieResponse, len(movies)), Total: total}
+	for i, movie := range movies {
+		resp.Movies[i] = newMovieResponse(movie)
+	}
+	return resp
+}
+
+// MovieRevisionResponse is one change of a movie with the state it produced, absent for
+// deletions
+type MovieRevisionResponse struct {
+	Sequence      int64          `json:"sequence"`
+	Type          string         `json:"type" example:"replaced"`
+	Version       int64          `json:"version"`
+	ChangedFields []string       `json:"changed_fields"`
+	Movie         *MovieResponse `json:"movie,omitempty"`
+	OccurredAt    time.Time      `json:"occurred_at"`
+}
+
+// MovieHistoryResponse lists the changes of a movie, oldest first
+type MovieHistoryResponse struct {
+	ID        int32                   `json:"id"`
+	Revisions []MovieRevisionResponse `json:"revisions"`
+}
+
+func newMovieHistoryResponse(history *domain.MovieHistory) MovieHistoryResponse {
+	resp := MovieHistoryResponse{ID: history.ID, Revisions: make([]MovieRevisionResponse, len(history.Revisions))}
+	for i, revision := range history.Revisions {
+		resp.Revisions[i] = MovieRevisionResponse{
+			Sequence:      revision.Sequence,
+			Type:          revision.Type,
+			Version:       revision.Version,
+			ChangedFields: revision.ChangedFields,
+			OccurredAt:    revision.OccurredAt,
+		}
+		if revision.Movie != nil {
+			movie := newMovieResponse(revision.Movie)
+			resp.Revisions[i].Movie = &movie
+		}
+	}
+	return resp
+}
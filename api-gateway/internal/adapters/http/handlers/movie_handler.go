@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/movie-microservice/api-gateway/internal/core/domain"
 	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+	pb "github.com/movie-microservice/proto/movies/v2"
 )
 
 type MovieHandler struct {
@@ -23,104 +27,561 @@ func NewMovieHandler(movieService ports.MovieServicePort, logger *slog.Logger) *
 	}
 }
 
+// @Summary List movies
+// @Description Returns a page of movies in ID order. The total is skipped with include_total=false or Prefer: count=none, and reported as -1. Accept: application/x-protobuf returns a binary movies.v2.GetMoviesResponse
+// @Tags Movies
+// @Produce json,application/x-protobuf
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Movies per page"
+// @Param filter query string false "Filter expression, such as year >= 1990 and title contains 'heat'"
+// @Param region query string false "ISO 3166-1 alpha-2 region the movies must be available in"
+// @Param certification query string false "Age certification, such as PG-13"
+// @Param include_total query bool false "Count the matching movies" default(true)
+// @Param Prefer header string false "count=none skips counting the matching movies"
+// @Success 200 {object} MovieListResponse
+// @Header 200 {integer} X-Total-Count "Total of matching movies"
+// @Failure 400 {object} ErrorResponse "Invalid page, limit, filter or region"
+// @Failure 503 {object} ErrorResponse
+// @Failure 504 {object} ErrorResponse
+// @Router /api/v1/movies [get]
 func (h *MovieHandler) GetMovies(w http.ResponseWriter, r *http.Request) {
 	page := r.URL.Query().Get("page")
 	limit := r.URL.Query().Get("limit")
 
+	// Defaults and limits are applied by the service
 	pageNum, _ := strconv.ParseInt(page, 10, 32)
 	limitNum, _ := strconv.ParseInt(limit, 10, 32)
 
-	if pageNum < 1 {
-		pageNum = 1
+	filter := domain.MovieFilter{
+		Page:          int32(pageNum),
+		Limit:         int32(limitNum),
+		Region:        requestRegion(r),
+		Certification: r.URL.Query().Get("certification"),
+		SkipCount:     prefersNoCount(r) || r.URL.Query().Get("include_total") == "false",
 	}
-	if limitNum < 1 {
-		limitNum = 10
+
+	expr, ok := h.parseFilterParam(w, r)
+	if !ok {
+		return
+	}
+	filter.Expr = expr
+
+	logging.FromContext(r.Context(), h.logger).Debug("fetching movies", "page", pageNum, "limit", limitNum, "filter", r.URL.Query().Get("filter"))
+	movies, total, err := h.movieService.GetMovies(r.Context(), filter)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movies", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Add("Vary", "Prefer")
+	w.Header().Add("Vary", "Accept")
+	switch {
+	case !filter.SkipCount:
+		w.Header().Set("X-Total-Count", strconv.Itoa(int(total)))
+	case prefersNoCount(r):
+		w.Header().Set("Preference-Applied", "count=none")
+	}
+	if prefersProtobuf(r) {
+		writeProtobuf(w, http.StatusOK, &pb.GetMoviesResponse{Movies: toProtoMovies(movies), Total: total})
+		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMovieListResponse(movies, total))
+}
 
-	h.logger.Info("fetching movies", "page", pageNum, "limit", limitNum)
-	movies, total, err := h.movieService.GetMovies(r.Context(), int32(pageNum), int32(limitNum))
+// prefersNoCount reports whether the client sent Prefer: count=none, asking to skip
+// counting the matching movies
+func prefersNoCount(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			if strings.EqualFold(strings.TrimSpace(preference), "count=none") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetMovieHistory returns every change of a movie, oldest first, including changes made
+// before it was deleted
+//
+// @Summary Get the history of a movie
+// @Tags Movies
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Success 200 {object} MovieHistoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 501 {object} ErrorResponse "History needs event-sourced persistence"
+// @Router /api/v1/movies/{id}/history [get]
+func (h *MovieHandler) GetMovieHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
 	if err != nil {
-		h.logger.Error("failed to get movies", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
 		return
 	}
 
-	response := struct {
-		Movies []*domain.Movie `json:"movies"`
-		Total  int32           `json:"total"`
-	}{
-		Movies: movies,
-		Total:  total,
+	logging.FromContext(r.Context(), h.logger).Debug("fetching movie history", "id", id)
+	history, err := h.movieService.GetMovieHistory(r.Context(), int32(id))
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie history", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(newMovieHistoryResponse(history))
 }
 
+// GetMovieFacets returns facet counts for the movies matching the optional filter
+//
+// @Summary Count movies by facet
+// @Tags Movies
+// @Produce json
+// @Param filter query string false "Filter expression"
+// @Param region query string false "ISO 3166-1 alpha-2 region the movies must be available in"
+// @Param certification query string false "Age certification"
+// @Success 200 {object} domain.MovieFacets
+// @Failure 400 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/movies/facets [get]
+func (h *MovieHandler) GetMovieFacets(w http.ResponseWriter, r *http.Request) {
+	expr, ok := h.parseFilterParam(w, r)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(r.Context(), h.logger).Debug("fetching movie facets", "filter", r.URL.Query().Get("filter"))
+	facets, err := h.movieService.GetMovieFacets(r.Context(), domain.MovieFilter{
+		Expr:          expr,
+		Region:        requestRegion(r),
+		Certification: r.URL.Query().Get("certification"),
+	})
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie facets", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(facets)
+}
+
+// parseFilterParam parses the filter query parameter, writing a 400 response when it is invalid
+func (h *MovieHandler) parseFilterParam(w http.ResponseWriter, r *http.Request) (*domain.FilterExpr, bool) {
+	input := r.URL.Query().Get("filter")
+	if input == "" {
+		return nil, true
+	}
+
+	expr, err := domain.ParseFilter(input)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Warn("invalid filter expression", "filter", input, "error", err)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_filter", Message: err.Error()})
+		return nil, false
+	}
+	return expr, true
+}
+
+// requestRegion returns the region given in the query string, falling back to the one
+// inferred from request headers
+func requestRegion(r *http.Request) string {
+	if region := r.URL.Query().Get("region"); region != "" {
+		return region
+	}
+	return domain.RegionFromContext(r.Context())
+}
+
+// @Summary Get a movie
+// @Tags Movies
+// @Produce json,application/x-protobuf
+// @Param id path int true "Movie ID"
+// @Success 200 {object} MovieResponse
+// @Header 200 {string} ETag "Version of the movie"
+// @Header 200 {string} X-From-Archive "true when the movie was read from the archive"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 503 {object} ErrorResponse
+// @Router /api/v1/movies/{id} [get]
 func (h *MovieHandler) GetMovie(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
 	id, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
 		return
 	}
 
-	h.logger.Info("fetching movie", "id", id)
+	logging.FromContext(r.Context(), h.logger).Debug("fetching movie", "id", id)
 	movie, err := h.movieService.GetMovie(r.Context(), int32(id))
 	if err != nil {
-		h.logger.Error("failed to get movie", "error", err, "id", id)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie", "error", err, "id", id)
+		writeServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(movie.Version))
+	w.Header().Add("Vary", "Accept")
+	if movie.Archived {
+		w.Header().Set("X-From-Archive", "true")
+	}
+	if prefersProtobuf(r) {
+		writeProtobuf(w, http.StatusOK, &pb.GetMovieResponse{Movie: toProtoMovies([]*domain.Movie{movie})[0]})
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(movie)
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
 }
 
-func (h *MovieHandler) CreateMovie(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Title string `json:"title"`
-		Year  string `json:"year"`
+// HeadMovie answers HEAD with the Content-Type, ETag and Vary headers of GetMovie, taking
+// the ETag from the version of the movie instead of fetching it. X-From-Archive is not
+// sent, since only the movie itself tells whether it was read from the archive, and
+// neither is Last-Modified, since movies do not record when they last changed. Fresh
+// cached responses are answered by the response cache, with their headers and
+// Content-Length, before reaching the handler
+//
+// @Summary Get the headers of a movie
+// @Tags Movies
+// @Param id path int true "Movie ID"
+// @Success 200 "The movie exists"
+// @Header 200 {string} ETag "Version of the movie"
+// @Header 200 {integer} Content-Length "Size of the movie, when its response is cached"
+// @Failure 400
+// @Failure 404
+// @Failure 503
+// @Router /api/v1/movies/{id} [head]
+func (h *MovieHandler) HeadMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
+		return
+	}
+
+	version, err := h.movieService.GetMovieVersion(r.Context(), int32(id))
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie version", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if prefersProtobuf(r) {
+		w.Header().Set("Content-Type", ProtobufContentType+"; messageType=movies.v2.GetMovieResponse")
+	}
+	w.Header().Set("ETag", etag(version))
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetMovieByExternalID returns the movie with the given ID in an external catalog, named
+// by the source path variable
+//
+// @Summary Get a movie by its ID in an external catalog
+// @Tags Movies
+// @Produce json,application/x-protobuf
+// @Param source path string true "External catalog" Enums(imdb, tmdb)
+// @Param externalId path string true "ID of the movie in the catalog, such as tt0113277"
+// @Success 200 {object} MovieResponse
+// @Header 200 {string} ETag "Version of the movie"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/movies/by-external/{source}/{externalId} [get]
+func (h *MovieHandler) GetMovieByExternalID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	source, externalID := vars["source"], vars["externalId"]
+
+	logging.FromContext(r.Context(), h.logger).Debug("fetching movie by external ID", "source", source, "external_id", externalID)
+	movie, err := h.movieService.GetMovieByExternalID(r.Context(), source, externalID)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie by external ID", "error", err, "source", source, "external_id", externalID)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(movie.Version))
+	w.Header().Add("Vary", "Accept")
+	if movie.Archived {
+		w.Header().Set("X-From-Archive", "true")
+	}
+	if prefersProtobuf(r) {
+		writeProtobuf(w, http.StatusOK, &pb.GetMovieByExternalIdResponse{Movie: toProtoMovies([]*domain.Movie{movie})[0]})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
+}
+
+// MovieIsCurrent reports whether the movie of a GetMovie request still has the version of
+// the entity tag, so a cached response can be revalidated without fetching the movie
+func (h *MovieHandler) MovieIsCurrent(r *http.Request, tag string) (bool, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		return false, nil
+	}
+
+	version, err := h.movieService.GetMovieVersion(r.Context(), int32(id))
+	if errors.Is(err, domain.ErrMovieNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
 	}
+	return etag(version) == tag, nil
+}
+
+// @Summary Create a movie
+// @Tags Movies
+// @Accept json
+// @Produce json
+// @Param movie body movieRequest true "Movie"
+// @Success 201 {object} MovieResponse
+// @Header 201 {string} ETag "Version of the movie"
+// @Failure 400 {object} ErrorResponse "Invalid body or movie"
+// @Failure 409 {object} ErrorResponse "External ID of another movie"
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/movies [post]
+func (h *MovieHandler) CreateMovie(w http.ResponseWriter, r *http.Request) {
+	var input movieRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		h.logger.Error("failed to decode create movie request", "error", err)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode create movie request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+	if err := input.validate(); err != nil {
+		logging.FromContext(r.Context(), h.logger).Warn("invalid create movie request", "error", err)
+		writeServiceError(w, err)
 		return
 	}
 
-	h.logger.Info("creating movie", "title", input.Title, "year", input.Year)
-	movie, err := h.movieService.CreateMovie(r.Context(), input.Title, input.Year)
+	logging.FromContext(r.Context(), h.logger).Debug("creating movie", "title", input.Title, "year", input.Year)
+	movie, err := h.movieService.CreateMovie(r.Context(), input.toDomain())
 	if err != nil {
-		h.logger.Error("failed to create movie", "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context(), h.logger).Error("failed to create movie", "error", err)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(movie.Version))
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(movie)
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
 }
 
+// UpsertMovie creates or replaces the movie with the ID given in the path, for clients
+// that manage their own IDs. With If-Match it goes through UpdateMovie instead: the movie
+// is only replaced if it exists, at one of the versions listed unless the header is "*",
+// and a missing movie is not created
+//
+// @Summary Create or replace a movie
+// @Tags Movies
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param If-Match header string false "Entity tags of the versions that may be replaced, or * for any; the movie is then never created"
+// @Param movie body movieRequest true "Movie"
+// @Success 200 {object} MovieResponse "Replaced"
+// @Success 201 {object} MovieResponse "Created"
+// @Header 200,201 {string} ETag "Version of the movie"
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "If-Match given and no movie has the ID"
+// @Failure 409 {object} ErrorResponse "External ID of another movie"
+// @Failure 412 {object} ErrorResponse "The movie has another version, or was modified concurrently"
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/movies/{id} [put]
+func (h *MovieHandler) UpsertMovie(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("invalid movie id format", "id", idStr)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
+		return
+	}
+
+	var input movieRequest
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode upsert movie request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+	if err := input.validate(); err != nil {
+		logging.FromContext(r.Context(), h.logger).Warn("invalid upsert movie request", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	var movie *domain.Movie
+	created := false
+	switch ifMatch := r.Header.Get("If-Match"); ifMatch {
+	case "":
+		logging.FromContext(r.Context(), h.logger).Debug("upserting movie", "id", id, "title", input.Title, "year", input.Year)
+		movie, created, err = h.movieService.UpsertMovie(r.Context(), int32(id), input.toDomain())
+	case "*":
+		logging.FromContext(r.Context(), h.logger).Debug("updating movie", "id", id, "title", input.Title, "year", input.Year)
+		movie, err = h.movieService.UpdateMovie(r.Context(), int32(id), input.toDomain())
+	default:
+		version, ok := h.ifMatchVersion(w, r, int32(id), ifMatch)
+		if !ok {
+			return
+		}
+		logging.FromContext(r.Context(), h.logger).Debug("updating movie if version matches", "id", id, "version", version)
+		movie, err = h.movieService.UpdateMovieIfVersion(r.Context(), int32(id), input.toDomain(), version)
+	}
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to upsert movie", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(movie.Version))
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
+}
+
+// PatchMovie changes only the fields present in the body, as in a JSON merge patch: a
+// null clears the field, and the absent ones keep their values
+//
+// @Summary Update some fields of a movie
+// @Tags Movies
+// @Accept json
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param If-Match header string false "Entity tags of the versions that may be changed"
+// @Param movie body movieRequest true "Fields to change"
+// @Success 200 {object} MovieResponse
+// @Header 200 {string} ETag "Version of the movie"
+// @Failure 400 {object} ErrorResponse "Invalid body, no field or invalid movie"
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "External ID of another movie"
+// @Failure 412 {object} ErrorResponse "The movie has another version, or was modified concurrently"
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/movies/{id} [patch]
+func (h *MovieHandler) PatchMovie(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("invalid movie id format", "id", idStr)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
+		return
+	}
+
+	var input moviePatchRequest
+
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode patch movie request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	patch := domain.MoviePatch{Input: input.toDomain(), Paths: input.paths}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		version, ok := h.ifMatchVersion(w, r, int32(id), ifMatch)
+		if !ok {
+			return
+		}
+		patch.ExpectedVersion = &version
+	}
+
+	logging.FromContext(r.Context(), h.logger).Debug("patching movie", "id", id, "paths", patch.Paths)
+	movie, err := h.movieService.PatchMovie(r.Context(), int32(id), patch)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to patch movie", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(movie.Version))
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
+}
+
+// @Summary Delete a movie
+// @Tags Movies
+// @Produce json
+// @Param id path int true "Movie ID"
+// @Param If-Match header string false "Entity tags of the versions that may be deleted"
+// @Success 204
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 412 {object} ErrorResponse "The movie has another version"
+// @Failure 503 {object} ErrorResponse "Read-only mode or service unavailable"
+// @Router /api/v1/movies/{id} [delete]
 func (h *MovieHandler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
 	id, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
-		h.logger.Error("invalid movie id format", "id", idStr)
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		logging.FromContext(r.Context(), h.logger).Error("invalid movie id format", "id", idStr)
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "invalid movie ID"})
+		return
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" {
+		h.deleteMovieIfMatch(w, r, int32(id), ifMatch)
 		return
 	}
 
-	h.logger.Info("deleting movie", "id", id)
+	logging.FromContext(r.Context(), h.logger).Debug("deleting movie", "id", id)
 	if err := h.movieService.DeleteMovie(r.Context(), int32(id)); err != nil {
-		h.logger.Error("failed to delete movie", "error", err, "id", id)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context(), h.logger).Error("failed to delete movie", "error", err, "id", id)
+		writeServiceError(w, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// deleteMovieIfMatch deletes the movie only if its current version matches one of the
+// entity tags in the If-Match header, answering 412 otherwise
+func (h *MovieHandler) deleteMovieIfMatch(w http.ResponseWriter, r *http.Request, id int32, ifMatch string) {
+	version, ok := h.ifMatchVersion(w, r, id, ifMatch)
+	if !ok {
+		return
+	}
+
+	logging.FromContext(r.Context(), h.logger).Debug("deleting movie if version matches", "id", id, "version", version)
+	if err := h.movieService.DeleteMovieIfVersion(r.Context(), id, version); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to delete movie", "error", err, "id", id, "version", version)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ifMatchVersion returns the version the movie must have to match one of the entity tags
+// in the If-Match header. With several tags, the current version is the one to expect if
+// it is listed. Otherwise it answers 412, or the error of getting the movie, and returns false
+func (h *MovieHandler) ifMatchVersion(w http.ResponseWriter, r *http.Request, id int32, ifMatch string) (int64, bool) {
+	versions := parseIfMatch(ifMatch)
+	if len(versions) == 0 {
+		writeError(w, http.StatusPreconditionFailed, ErrorResponse{Error: "precondition_failed", Message: "If-Match does not match the current movie version"})
+		return 0, false
+	}
+	if len(versions) == 1 {
+		return versions[0], true
+	}
+
+	movie, err := h.movieService.GetMovie(r.Context(), id)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to get movie", "error", err, "id", id)
+		writeServiceError(w, err)
+		return 0, false
+	}
+	if !containsVersion(versions, movie.Version) {
+		writeError(w, http.StatusPreconditionFailed, ErrorResponse{Error: "precondition_failed", Message: "If-Match does not match the current movie version"})
+		return 0, false
+	}
+	return movie.Version, true
+}
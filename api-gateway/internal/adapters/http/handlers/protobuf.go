@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// ProtobufContentType is the media type of responses holding the binary encoding of a
+// message of the movie service API, named by its messageType parameter
+const ProtobufContentType = "application/x-protobuf"
+
+// prefersProtobuf reports whether the client accepts protobuf at least as much as JSON,
+// as in Accept: application/x-protobuf. Wildcards do not count, so browsers keep JSON
+func prefersProtobuf(r *http.Request) bool {
+	var protobuf, json float64
+	for _, header := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(header, ",") {
+			mediaType, params, _ := strings.Cut(mediaRange, ";")
+			quality := 1.0
+			for _, param := range strings.Split(params, ";") {
+				if q, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+					quality, _ = strconv.ParseFloat(q, 64)
+				}
+			}
+			switch strings.ToLower(strings.TrimSpace(mediaType)) {
+			case ProtobufContentType, "application/protobuf":
+				protobuf = max(protobuf, quality)
+			case "application/json":
+				json = max(json, quality)
+			}
+		}
+	}
+	return protobuf > 0 && protobuf >= json
+}
+
+// writeProtobuf writes the binary encoding of message, skipping JSON altogether
+func writeProtobuf(w http.ResponseWriter, status int, message proto.Message) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrorResponse{Error: "internal_error", Message: "failed to encode the response"})
+		return
+	}
+	w.Header().Set("Content-Type", ProtobufContentType+"; messageType="+string(message.ProtoReflect().Descriptor().FullName()))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func toProtoMovies(movies []*domain.Movie) []*pb.Movie {
+	protos := make([]*pb.Movie, len(movies))
+	for i, movie := range movies {
+		protos[i] = convert.ToProtoMovie(convert.Movie(*movie))
+	}
+	return protos
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/http2"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// NewProxyHandler forwards requests to backend, appending the request path to the
+// backend path and sending the time left before the request deadline in
+// X-Timeout-Budget-Ms and the trace context in traceparent, tracestate and b3. Backends with the grpc
+// scheme are gRPC servers reached over cleartext HTTP/2, so gRPC clients can call them
+// through the gateway.
+func NewProxyHandler(backend *url.URL, logger *slog.Logger) http.Handler {
+	target := *backend
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(&target)
+			r.SetXForwarded()
+			// Pass on the time left of the request, and never a budget the gateway did not check
+			r.Out.Header.Del(middleware.TimeoutBudgetHeader)
+			if deadline, ok := r.In.Context().Deadline(); ok {
+				r.Out.Header.Set(middleware.TimeoutBudgetHeader, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+			}
+			// The gateway span replaces the caller's in every trace header
+			for _, header := range []string{domain.B3Header, domain.B3TraceIDHeader, domain.B3SpanIDHeader, domain.B3SampledHeader, "X-B3-ParentSpanId", "X-B3-Flags"} {
+				r.Out.Header.Del(header)
+			}
+			if trace, ok := domain.TraceFromContext(r.In.Context()); ok {
+				trace.Child().Propagate(r.Out.Header.Set)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error("proxy request failed", "backend", backend.Host, "path", r.URL.Path, "error", err)
+			writeError(w, http.StatusBadGateway, ErrorResponse{Error: "bad_gateway", Message: "upstream service unavailable"})
+		},
+	}
+
+	if backend.Scheme == "grpc" {
+		target.Scheme = "http"
+		proxy.Transport = &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+		// gRPC responses are streamed and carry their status in trailers
+		proxy.FlushInterval = -1
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Error("proxy request failed", "backend", backend.Host, "path", r.URL.Path, "error", err)
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Grpc-Status", "14") // UNAVAILABLE
+			w.Header().Set("Grpc-Message", "upstream service unavailable")
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	return proxy
+}
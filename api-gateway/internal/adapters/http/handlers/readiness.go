@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// Readiness answers readiness probes: 503 until the gateway finished warming up, 200 after
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// MarkReady makes the gateway report itself ready
+func (rd *Readiness) MarkReady() {
+	rd.ready.Store(true)
+}
+
+// @Summary Check whether the gateway is ready
+// @Tags Operations
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Failure 503 {object} map[string]string "Warming up"
+// @Router /ready [get]
+func (rd *Readiness) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rd.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"status":"warming_up"}`)
+		return
+	}
+	fmt.Fprint(w, `{"status":"ready"}`)
+}
+
+// Warmup sends a GET request for each path through handler before traffic arrives, so
+// the first requests after a deploy find routes matched once, the movie service
+// connection in use and cacheable responses already cached. header is added to each
+// request, such as an API key for routes requiring auth; the requests are marked as
+// warm-ups, so they are not counted in the usage of the key. Failures are logged and
+// otherwise ignored, since warming up is an optimization.
+func Warmup(ctx context.Context, handler http.Handler, paths []string, header http.Header, logger *slog.Logger) {
+	for _, path := range paths {
+		start := time.Now()
+		req, err := http.NewRequestWithContext(domain.ContextAsWarmup(ctx), http.MethodGet, path, nil)
+		if err != nil {
+			logger.Warn("Invalid warm-up path", "path", path, "error", err)
+			continue
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req.RemoteAddr = "127.0.0.1:0"
+
+		w := &statusRecorder{header: make(http.Header), status: http.StatusOK}
+		handler.ServeHTTP(w, req)
+		if w.status >= http.StatusBadRequest {
+			logger.Warn("Warm-up request failed", "path", path, "status", w.status)
+			continue
+		}
+		logger.Info("Warm-up request completed", "path", path, "status", w.status, "duration", time.Since(start))
+	}
+}
+
+// statusRecorder discards the response of a warm-up request, keeping its status
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (w *statusRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type SyncHandler struct {
+	syncService ports.SyncServicePort
+	logger      *slog.Logger
+}
+
+func NewSyncHandler(syncService ports.SyncServicePort, logger *slog.Logger) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+		logger:      logger,
+	}
+}
+
+// resolutionRequest is the body of sync conflict resolutions
+type resolutionRequest struct {
+	Resolution string `json:"resolution"`
+}
+
+// syncConflictsResponse is a page of sync conflicts
+type syncConflictsResponse struct {
+	Conflicts []*domain.SyncConflict `json:"conflicts"`
+	Total     int32                  `json:"total"`
+}
+
+// GetSyncConflicts returns the movies changed both locally and in the synced feed,
+// oldest first, with the remote version of each
+//
+// @Summary List sync conflicts
+// @Tags Catalog sync
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Conflicts per page"
+// @Success 200 {object} syncConflictsResponse
+// @Header 200 {integer} X-Total-Count "Total of conflicts"
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/sync/conflicts [get]
+func (h *SyncHandler) GetSyncConflicts(w http.ResponseWriter, r *http.Request) {
+	page, limit := pageParams(r)
+
+	conflicts, total, err := h.syncService.ListSyncConflicts(r.Context(), page, limit)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to list sync conflicts", "error", err)
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Total-Count", strconv.Itoa(int(total)))
+	json.NewEncoder(w).Encode(syncConflictsResponse{Conflicts: conflicts, Total: total})
+}
+
+// ResolveSyncConflict keeps the local movie or takes the remote one, answering with the
+// resulting movie, or 204 when the kept local movie was deleted
+//
+// @Summary Resolve a sync conflict
+// @Tags Catalog sync
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param conflictId path string true "Conflict ID"
+// @Param resolution body resolutionRequest true "Resolution"
+// @Success 200 {object} MovieResponse
+// @Success 204 "The kept local movie was deleted"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /admin/sync/conflicts/{conflictId} [post]
+func (h *SyncHandler) ResolveSyncConflict(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["conflictId"]
+
+	var input resolutionRequest
+	if err := decodeJSON(w, r, &input); err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to decode sync resolution request", "error", err)
+		writeBodyError(w, err)
+		return
+	}
+
+	movie, err := h.syncService.ResolveSyncConflict(r.Context(), id, input.Resolution)
+	if err != nil {
+		logging.FromContext(r.Context(), h.logger).Error("failed to resolve sync conflict", "error", err, "id", id)
+		writeServiceError(w, err)
+		return
+	}
+	if movie == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMovieResponse(movie))
+}
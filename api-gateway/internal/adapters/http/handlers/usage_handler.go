@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// defaultUsageDays is the number of days reported when from is not given
+const defaultUsageDays = 30
+
+// UsageCountsResponse is the traffic of an API key over a period
+type UsageCountsResponse struct {
+	domain.UsageCounts
+	ErrorRate float64 `json:"error_rate" example:"0.02"`
+}
+
+// DailyUsageResponse is the traffic of an API key on a UTC day
+type DailyUsageResponse struct {
+	Date string `json:"date" example:"2025-06-01"`
+	UsageCountsResponse
+}
+
+// KeyUsageResponse is the traffic of an API key, identified without revealing it, from
+// one day to another
+type KeyUsageResponse struct {
+	KeyID string               `json:"key_id" example:"3f2a9c0b71de"`
+	From  string               `json:"from" example:"2025-06-01"`
+	To    string               `json:"to" example:"2025-06-30"`
+	Total UsageCountsResponse  `json:"total"`
+	Days  []DailyUsageResponse `json:"days"`
+}
+
+// UsageListResponse is the traffic of each API key
+type UsageListResponse struct {
+	Keys []KeyUsageResponse `json:"keys"`
+}
+
+type UsageHandler struct {
+	store ports.UsageStorePort
+	// keyIDs are the IDs of the configured API keys, in their order
+	keyIDs []string
+	clock  clock.Clock
+}
+
+func NewUsageHandler(store ports.UsageStorePort, apiKeys []string, clk clock.Clock) *UsageHandler {
+	h := &UsageHandler{store: store, clock: clk}
+	for _, key := range apiKeys {
+		h.keyIDs = append(h.keyIDs, domain.APIKeyID(key))
+	}
+	return h
+}
+
+// GetUsage returns the daily traffic of every API key, or of the one given by key
+//
+// @Summary Get the usage of the API keys
+// @Description Reports the requests, errors and bytes of each API key per UTC day, from the traffic of this gateway replica since it started
+// @Tags Usage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param key query string false "API key, or its key_id, to report alone"
+// @Param from query string false "First day, such as 2025-06-01; 30 days before to by default"
+// @Param to query string false "Last day, such as 2025-06-30; today by default"
+// @Success 200 {object} UsageListResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse "Unknown key"
+// @Router /admin/usage [get]
+func (h *UsageHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	from, to, ok := h.period(w, r)
+	if !ok {
+		return
+	}
+
+	keyIDs := h.keyIDs
+	if key := r.URL.Query().Get("key"); key != "" {
+		keyIDs = nil
+		for _, id := range h.keyIDs {
+			if id == key || id == domain.APIKeyID(key) {
+				keyIDs = []string{id}
+			}
+		}
+		if keyIDs == nil {
+			writeError(w, http.StatusNotFound, ErrorResponse{Error: "api_key_not_found", Message: "unknown API key"})
+			return
+		}
+	}
+
+	response := UsageListResponse{Keys: make([]KeyUsageResponse, len(keyIDs))}
+	for i, id := range keyIDs {
+		response.Keys[i] = h.keyUsage(id, from, to)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMyUsage returns the daily traffic of the API key of the request
+//
+// @Summary Get the usage of your API key
+// @Description Reports the requests, errors and bytes of the API key of the request per UTC day, from the traffic of this gateway replica since it started
+// @Tags Usage
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "First day, such as 2025-06-01; 30 days before to by default"
+// @Param to query string false "Last day, such as 2025-06-30; today by default"
+// @Success 200 {object} KeyUsageResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Router /me/usage [get]
+func (h *UsageHandler) GetMyUsage(w http.ResponseWriter, r *http.Request) {
+	keyID := domain.APIKeyIDFromContext(r.Context())
+	if keyID == "" {
+		writeError(w, http.StatusUnauthorized, ErrorResponse{Error: "unauthorized", Message: "a valid API key is required"})
+		return
+	}
+	from, to, ok := h.period(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.keyUsage(keyID, from, to))
+}
+
+// period parses the days of the from and to query parameters, answering 400 when they
+// are invalid
+func (h *UsageHandler) period(w http.ResponseWriter, r *http.Request) (from, to time.Time, ok bool) {
+	to = h.clock.Now().UTC().Truncate(24 * time.Hour)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(domain.UsageDateLayout, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "to must be a date such as 2025-06-30"})
+			return from, to, false
+		}
+		to = parsed
+	}
+	from = to.AddDate(0, 0, 1-defaultUsageDays)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(domain.UsageDateLayout, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "from must be a date such as 2025-06-01"})
+			return from, to, false
+		}
+		from = parsed
+	}
+	if from.After(to) || to.Sub(from) > 366*24*time.Hour {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Error: "invalid_request", Message: "from must be up to a year before to"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+func (h *UsageHandler) keyUsage(keyID string, from, to time.Time) KeyUsageResponse {
+	response := KeyUsageResponse{
+		KeyID: keyID,
+		From:  from.Format(domain.UsageDateLayout),
+		To:    to.Format(domain.UsageDateLayout),
+	}
+	var total domain.UsageCounts
+	for _, day := range h.store.Daily(keyID, from, to) {
+		total.Add(day.UsageCounts)
+		response.Days = append(response.Days, DailyUsageResponse{Date: day.Date, UsageCountsResponse: newUsageCountsResponse(day.UsageCounts)})
+	}
+	response.Total = newUsageCountsResponse(total)
+	return response
+}
+
+func newUsageCountsResponse(counts domain.UsageCounts) UsageCountsResponse {
+	return UsageCountsResponse{UsageCounts: counts, ErrorRate: counts.ErrorRate()}
+}
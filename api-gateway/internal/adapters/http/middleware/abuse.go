@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// maxTarpitted bounds the requests held at once, so scanners cannot tie up the gateway;
+// the others are answered at once
+const maxTarpitted = 256
+
+// Abuse catches the scanners probing for well-known vulnerable software, such as
+// /wp-admin or /.env, and blocks them in the blocklist for a while
+type Abuse struct {
+	blocklist ports.BlocklistPort
+	// traps are the lowercased path segments only scanners request
+	traps     map[string]bool
+	blockFor  time.Duration
+	tarpit    time.Duration
+	trusted   []netip.Prefix
+	clock     clock.Clock
+	logger    *slog.Logger
+	tarpitted atomic.Int64
+}
+
+// NewAbuse returns the middleware blocking, for blockFor, the IPs requesting a path with
+// one of traps as a segment. Trap requests and those of blocked IPs are held for tarpit
+// before being answered. Callers in the trusted networks are never blocked
+func NewAbuse(blocklist ports.BlocklistPort, traps []string, blockFor, tarpit time.Duration, trusted []netip.Prefix, clk clock.Clock, logger *slog.Logger) *Abuse {
+	a := &Abuse{
+		blocklist: blocklist,
+		traps:     make(map[string]bool),
+		blockFor:  blockFor,
+		tarpit:    tarpit,
+		trusted:   trusted,
+		clock:     clk,
+		logger:    logger,
+	}
+	for _, trap := range traps {
+		a.traps[strings.ToLower(trap)] = true
+	}
+	return a
+}
+
+// Middleware refuses blocked IPs with 403 and answers traps with 404. It wraps the
+// router, so their requests are neither routed, logged nor counted in the metrics
+func (a *Abuse) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if trustedCaller(r, a.trusted) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ip := remoteIP(r)
+		if _, blocked := a.blocklist.Blocked(ip); blocked {
+			a.hold(r)
+			writeJSONError(w, http.StatusForbidden, "blocked", "too many suspicious requests from this address")
+			return
+		}
+		if !a.trapped(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := a.clock.Now()
+		a.blocklist.Block(domain.BlockedClient{
+			IP:        ip,
+			Reason:    domain.BlockedScanner,
+			Path:      r.URL.Path,
+			BlockedAt: now,
+			Until:     now.Add(a.blockFor),
+		})
+		a.logger.Warn("Blocked scanner", "ip", ip, "method", r.Method, "path", r.URL.Path,
+			"user_agent", r.UserAgent(), "until", now.Add(a.blockFor))
+		a.hold(r)
+		writeJSONError(w, http.StatusNotFound, "not_found", "not found")
+	})
+}
+
+// trapped reports whether a segment of path is a trap
+func (a *Abuse) trapped(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && a.traps[strings.ToLower(segment)] {
+			return true
+		}
+	}
+	return false
+}
+
+// hold waits for the tarpit, unless the client gives up or too many are already held
+func (a *Abuse) hold(r *http.Request) {
+	if a.tarpit <= 0 {
+		return
+	}
+	if a.tarpitted.Add(1) > maxTarpitted {
+		a.tarpitted.Add(-1)
+		return
+	}
+	defer a.tarpitted.Add(-1)
+	timer := time.NewTimer(a.tarpit)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+}
+
+// remoteIP returns the IP address of the client in the form of netip.Addr.String
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap().String()
+	}
+	return host
+}
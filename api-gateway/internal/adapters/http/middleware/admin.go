@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// KeyRequired restricts the routes it wraps to requests with one of apiKeys: they are
+// not found when no keys are configured
+func KeyRequired(apiKeys []string) func(http.Handler) http.Handler {
+	keys := byteKeys(apiKeys)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(keys) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			if !hasAPIKey(r, keys) {
+				writeUnauthorized(w)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminOnly restricts the /admin routes it wraps to requests with one of adminKeys, like
+// the maintenance route. Requests with one of the client apiKeys are forbidden, and the
+// routes are not found when no admin keys are configured
+func AdminOnly(adminKeys, apiKeys []string) func(http.Handler) http.Handler {
+	admins, clients := byteKeys(adminKeys), byteKeys(apiKeys)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authorizeAdmin(w, r, admins, clients) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// authorizeAdmin reports whether the request carries one of the admin keys, answering it
+// otherwise: 404 without admin keys, 403 for a client key and 401 for any other request
+func authorizeAdmin(w http.ResponseWriter, r *http.Request, admins, clients [][]byte) bool {
+	switch {
+	case len(admins) == 0:
+		http.NotFound(w, r)
+	case hasAPIKey(r, admins):
+		return true
+	case hasAPIKey(r, clients):
+		writeJSONError(w, http.StatusForbidden, "forbidden", "an admin API key is required")
+	default:
+		writeUnauthorized(w)
+	}
+	return false
+}
+
+// writeUnauthorized refuses a request without a valid API key
+func writeUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	writeJSONError(w, http.StatusUnauthorized, "unauthorized", "a valid API key is required")
+}
+
+// byteKeys converts keys for hasAPIKey
+func byteKeys(keys []string) [][]byte {
+	converted := make([][]byte, len(keys))
+	for i, key := range keys {
+		converted[i] = []byte(key)
+	}
+	return converted
+}
+
+// hasAPIKey reports whether the request carries one of keys, comparing in constant time
+func hasAPIKey(r *http.Request, keys [][]byte) bool {
+	key := apiKey(r)
+	if key == "" {
+		return false
+	}
+	for _, known := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), known) == 1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/config"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// Kinds of traffic watched for spikes
+const (
+	AnomalyClientErrors = "client_errors"
+	AnomalyServerErrors = "server_errors"
+	AnomalyKeyRequests  = "key_requests"
+)
+
+// maxIdleIntervals bounds the empty intervals folded into the averages after a pause in
+// the traffic; past it, they hardly change anymore
+const maxIdleIntervals = 100
+
+// trafficSeries is the count of the current interval of a kind of traffic, and the
+// exponentially weighted average and variance of the counts of the previous ones
+type trafficSeries struct {
+	kind      string
+	keyID     string
+	count     int
+	mean      float64
+	variance  float64
+	intervals int
+	// flagged is set once the current interval was reported, so a spike is reported once
+	flagged bool
+}
+
+// AnomalyDetector flags sudden spikes in the 4xx and 5xx responses and in the requests of
+// each API key, as soon as the count of the current interval goes the configured
+// deviations above the moving average of the previous intervals. Spikes are logged as
+// warnings and counted per kind in the metrics
+type AnomalyDetector struct {
+	cfg      config.AnomalyConfig
+	interval time.Duration
+	metrics  *Metrics
+	clock    clock.Clock
+	logger   *slog.Logger
+
+	mu           sync.Mutex
+	start        time.Time
+	clientErrors *trafficSeries
+	serverErrors *trafficSeries
+	series       []*trafficSeries
+	// keys are the series of the requests of each API key, by key ID
+	keys map[string]*trafficSeries
+}
+
+// NewAnomalyDetector returns the detector of the spikes in the traffic of the gateway and
+// of each of apiKeys, counted by metrics
+func NewAnomalyDetector(cfg config.AnomalyConfig, apiKeys []string, metrics *Metrics, clk clock.Clock, logger *slog.Logger) *AnomalyDetector {
+	d := &AnomalyDetector{
+		cfg:          cfg,
+		interval:     time.Duration(cfg.IntervalSeconds) * time.Second,
+		metrics:      metrics,
+		clock:        clk,
+		logger:       logger,
+		clientErrors: &trafficSeries{kind: AnomalyClientErrors},
+		serverErrors: &trafficSeries{kind: AnomalyServerErrors},
+		keys:         make(map[string]*trafficSeries),
+	}
+	d.series = []*trafficSeries{d.clientErrors, d.serverErrors}
+	for _, key := range apiKeys {
+		keyID := domain.APIKeyID(key)
+		if d.keys[keyID] == nil {
+			d.keys[keyID] = &trafficSeries{kind: AnomalyKeyRequests, keyID: keyID}
+			d.series = append(d.series, d.keys[keyID])
+		}
+	}
+	metrics.declareAnomalies([]string{AnomalyClientErrors, AnomalyServerErrors, AnomalyKeyRequests})
+	return d
+}
+
+// Middleware counts the responses, after Usage has identified the API key of the
+// request. Warm-ups of the gateway itself are left out
+func (d *AnomalyDetector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if domain.IsWarmup(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+		d.observe(domain.APIKeyIDFromContext(r.Context()), wrapped.statusCode)
+	})
+}
+
+func (d *AnomalyDetector) observe(keyID string, status int) {
+	d.mu.Lock()
+	d.advance(d.clock.Now())
+	var observed []*trafficSeries
+	switch {
+	case status >= http.StatusInternalServerError:
+		observed = append(observed, d.serverErrors)
+	case status >= http.StatusBadRequest:
+		observed = append(observed, d.clientErrors)
+	}
+	if series := d.keys[keyID]; series != nil {
+		observed = append(observed, series)
+	}
+	var spikes []trafficSeries
+	for _, series := range observed {
+		series.count++
+		if !series.flagged && d.spiking(series) {
+			series.flagged = true
+			spikes = append(spikes, *series)
+		}
+	}
+	start := d.start
+	d.mu.Unlock()
+
+	for _, spike := range spikes {
+		d.metrics.anomaly(spike.kind)
+		attrs := []any{"kind", spike.kind, "requests", spike.count,
+			"expected", math.Round(spike.mean*10) / 10, "interval_start", start, "interval", d.interval}
+		if spike.keyID != "" {
+			attrs = append(attrs, "key_id", spike.keyID)
+		}
+		d.logger.Warn("Traffic anomaly", attrs...)
+	}
+}
+
+// spiking reports whether the count of the current interval of series is a spike. Series
+// still warming up are not judged, since their average means little yet
+func (d *AnomalyDetector) spiking(series *trafficSeries) bool {
+	if series.intervals < d.cfg.WarmupIntervals || series.count < d.cfg.MinRequests {
+		return false
+	}
+	return float64(series.count) > series.mean+d.cfg.Deviations*math.Sqrt(series.variance)
+}
+
+// advance closes the intervals ended by now, folding their counts into the averages
+func (d *AnomalyDetector) advance(now time.Time) {
+	if d.start.IsZero() {
+		d.start = now.Truncate(d.interval)
+		return
+	}
+	ended := int(now.Sub(d.start) / d.interval)
+	if ended < 1 {
+		return
+	}
+	for i := 0; i < min(ended, maxIdleIntervals); i++ {
+		for _, series := range d.series {
+			d.fold(series)
+		}
+	}
+	d.start = d.start.Add(time.Duration(ended) * d.interval)
+}
+
+// fold adds the count of the current interval of series to its average and variance, and
+// starts a new interval
+func (d *AnomalyDetector) fold(series *trafficSeries) {
+	count := float64(series.count)
+	if series.intervals == 0 {
+		series.mean = count
+	} else {
+		diff := count - series.mean
+		series.mean += d.cfg.Alpha * diff
+		series.variance = (1 - d.cfg.Alpha) * (series.variance + d.cfg.Alpha*diff*diff)
+	}
+	series.intervals++
+	series.count = 0
+	series.flagged = false
+}
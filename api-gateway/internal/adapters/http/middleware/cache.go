@@ -0,0 +1,458 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/movie-microservice/proto/clock"
+)
+
+const (
+	// maxCacheEntries bounds the number of cached responses
+	maxCacheEntries = 10000
+	// maxCachedBodySize is the largest response body that is cached
+	maxCachedBodySize = 1 << 20
+	// defaultRefreshTimeout bounds background refreshes of routes without a timeout
+	defaultRefreshTimeout = 30 * time.Second
+	// revalidationRetention is how long expired responses with an entity tag are kept to
+	// be revalidated
+	revalidationRetention = time.Hour
+	// staleWarning is the Warning header of responses served stale because of an error
+	staleWarning = `110 - "Response is Stale"`
+)
+
+// perRequestHeaders describe the request rather than the response, so they are not cached
+var perRequestHeaders = []string{
+	"X-Cache",
+	"RateLimit-Policy",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"Retry-After",
+	"Warning",
+}
+
+// Revalidator reports whether the response the request would get still has the entity
+// tag of a cached response, typically by comparing it with the current version of the
+// resource, which is cheaper than handling the request again
+type Revalidator func(r *http.Request, etag string) (bool, error)
+
+// CachePolicy controls how long responses of a route are served from the cache
+type CachePolicy struct {
+	// TTL is the time a response is fresh
+	TTL time.Duration
+	// StaleWhileRevalidate is the time an expired response is still served while it is
+	// refreshed in the background
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is the time after expiry an expired response is served instead of a
+	// server error
+	StaleIfError time.Duration
+	// RefreshTimeout bounds background refreshes
+	RefreshTimeout time.Duration
+	// Revalidate, when set, checks expired responses with an entity tag before they are
+	// fetched again; current responses are kept for another TTL
+	Revalidate Revalidator
+}
+
+// CacheStats reports how often and how fresh responses were served from the cache
+type CacheStats struct {
+	Entries         int   `json:"entries"`
+	Hits            int64 `json:"hits"`
+	StaleHits       int64 `json:"stale_hits"`
+	Misses          int64 `json:"misses"`
+	Refreshes       int64 `json:"refreshes"`
+	RefreshFailures int64 `json:"refresh_failures"`
+	// StaleIfErrorHits counts expired responses served because the movie service failed
+	StaleIfErrorHits int64 `json:"stale_if_error_hits"`
+	// Revalidations counts expired responses checked against the current version, and
+	// Revalidated those found current and kept
+	Revalidations int64 `json:"revalidations"`
+	Revalidated   int64 `json:"revalidated"`
+	// MeanHitAgeMs is the mean age of the responses served from the cache, fresh or stale
+	MeanHitAgeMs int64 `json:"mean_hit_age_ms"`
+	// MaxStaleAgeMs is the oldest expired response served, measured from its expiry
+	MaxStaleAgeMs int64 `json:"max_stale_age_ms"`
+}
+
+// ResponseCache keeps successful GET responses in memory
+type ResponseCache struct {
+	vary    []string
+	clock   clock.Clock
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// generation changes on every purge, so responses fetched before it are not stored
+	generation uint64
+
+	hits, staleHits, misses    atomic.Int64
+	refreshes, refreshFailures atomic.Int64
+	staleIfErrorHits           atomic.Int64
+	revalidations, revalidated atomic.Int64
+	hitAgeTotal, maxStaleAge   atomic.Int64
+}
+
+type cacheEntry struct {
+	header     http.Header
+	body       []byte
+	stored     time.Time
+	expires    time.Time
+	staleUntil time.Time
+	// keepUntil is when the entry is dropped, after staleUntil when it can be revalidated
+	keepUntil  time.Time
+	refreshing bool
+}
+
+// NewResponseCache creates a cache keying responses on the request URI and the values
+// of the vary headers, expiring them by clk
+func NewResponseCache(vary []string, clk clock.Clock) *ResponseCache {
+	return &ResponseCache{
+		vary:    vary,
+		clock:   clk,
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// Serve answers from the cache when a usable response is stored, and otherwise calls
+// next, storing its response when it succeeds. Expired responses within the
+// stale-while-revalidate window are served as they are while one background request
+// refreshes them; later expired responses are revalidated first when the policy allows,
+// and served with a Warning header when next fails within the stale-if-error window.
+func (c *ResponseCache) Serve(w http.ResponseWriter, r *http.Request, next http.Handler, policy CachePolicy) {
+	key := c.key(r)
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	generation := c.generation
+	entry, ok := c.entries[key]
+	if ok && now.After(entry.keepUntil) {
+		delete(c.entries, key)
+		ok = false
+	}
+	stale := ok && now.After(entry.expires) && !now.After(entry.staleUntil)
+	expired := ok && now.After(entry.staleUntil)
+	refresh := stale && !entry.refreshing
+	if refresh {
+		entry.refreshing = true
+	}
+	c.mu.Unlock()
+
+	var fallback *cacheEntry
+	if expired {
+		if !now.After(entry.expires.Add(policy.StaleIfError)) {
+			fallback = entry
+		}
+		entry, ok = c.revalidate(r, key, entry, policy)
+		now = c.clock.Now()
+	}
+
+	if ok {
+		c.recordHit(now, entry, stale)
+		for name, values := range entry.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Age", strconv.Itoa(int(now.Sub(entry.stored).Seconds())))
+		switch {
+		case stale:
+			w.Header().Set("X-Cache", "STALE")
+		case expired:
+			w.Header().Set("X-Cache", "REVALIDATED")
+		default:
+			w.Header().Set("X-Cache", "HIT")
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(entry.body)
+
+		if refresh {
+			go c.refresh(key, generation, r, next, policy)
+		}
+		return
+	}
+
+	c.misses.Add(1)
+	w.Header().Set("X-Cache", "MISS")
+	if fallback != nil {
+		c.serveOrFallback(w, r, next, key, generation, fallback, policy)
+		return
+	}
+	rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	if rec.status != http.StatusOK || rec.overflow {
+		return
+	}
+	c.store(key, generation, w.Header(), rec.body.Bytes(), policy)
+}
+
+// ServeHead answers a HEAD request with the headers of the fresh cached response of the
+// same GET request, and Content-Length of its body, without calling next. Other requests
+// are passed to next; the cache is neither filled nor refreshed by HEAD requests
+func (c *ResponseCache) ServeHead(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	key := c.key(r)
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || now.After(entry.expires) {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	c.recordHit(now, entry, false)
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.body)))
+	w.Header().Set("Age", strconv.Itoa(int(now.Sub(entry.stored).Seconds())))
+	w.Header().Set("X-Cache", "HIT")
+	w.WriteHeader(http.StatusOK)
+}
+
+// serveOrFallback calls next with its response held back, writing the expired fallback
+// instead when next fails with a server error
+func (c *ResponseCache) serveOrFallback(w http.ResponseWriter, r *http.Request, next http.Handler, key string, generation uint64, fallback *cacheEntry, policy CachePolicy) {
+	held := &discardResponseWriter{header: w.Header().Clone()}
+	rec := &cacheRecorder{ResponseWriter: held, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	if rec.status >= http.StatusInternalServerError {
+		now := c.clock.Now()
+		c.staleIfErrorHits.Add(1)
+		c.recordHit(now, fallback, true)
+		for name, values := range fallback.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Age", strconv.Itoa(int(now.Sub(fallback.stored).Seconds())))
+		w.Header().Set("X-Cache", "STALE")
+		w.Header().Set("Warning", staleWarning)
+		w.WriteHeader(http.StatusOK)
+		w.Write(fallback.body)
+		return
+	}
+
+	for name, values := range held.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+	if rec.status == http.StatusOK && !rec.overflow {
+		c.store(key, generation, w.Header(), rec.body.Bytes(), policy)
+	}
+}
+
+// Stats returns the counters of the cache since it was created
+func (c *ResponseCache) Stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Entries:          entries,
+		Hits:             c.hits.Load(),
+		StaleHits:        c.staleHits.Load(),
+		Misses:           c.misses.Load(),
+		Refreshes:        c.refreshes.Load(),
+		RefreshFailures:  c.refreshFailures.Load(),
+		StaleIfErrorHits: c.staleIfErrorHits.Load(),
+		Revalidations:    c.revalidations.Load(),
+		Revalidated:      c.revalidated.Load(),
+		MaxStaleAgeMs:    time.Duration(c.maxStaleAge.Load()).Milliseconds(),
+	}
+	if served := stats.Hits + stats.StaleHits; served > 0 {
+		stats.MeanHitAgeMs = time.Duration(c.hitAgeTotal.Load() / served).Milliseconds()
+	}
+	return stats
+}
+
+// Purge drops every cached response
+func (c *ResponseCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+	c.generation++
+}
+
+func (c *ResponseCache) recordHit(now time.Time, entry *cacheEntry, stale bool) {
+	c.hitAgeTotal.Add(int64(now.Sub(entry.stored)))
+	if !stale {
+		c.hits.Add(1)
+		return
+	}
+	c.staleHits.Add(1)
+	staleAge := int64(now.Sub(entry.expires))
+	for {
+		current := c.maxStaleAge.Load()
+		if staleAge <= current || c.maxStaleAge.CompareAndSwap(current, staleAge) {
+			return
+		}
+	}
+}
+
+// refresh fetches the response again, detached from the client request that found it stale
+func (c *ResponseCache) refresh(key string, generation uint64, r *http.Request, next http.Handler, policy CachePolicy) {
+	timeout := policy.RefreshTimeout
+	if timeout <= 0 {
+		timeout = defaultRefreshTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), timeout)
+	defer cancel()
+
+	c.refreshes.Add(1)
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+	if entry != nil {
+		if _, ok := c.revalidate(r.Clone(ctx), key, entry, policy); ok {
+			return
+		}
+	}
+
+	rec := &cacheRecorder{ResponseWriter: &discardResponseWriter{header: make(http.Header)}, status: http.StatusOK}
+	next.ServeHTTP(rec, r.Clone(ctx))
+
+	if rec.status != http.StatusOK || rec.overflow {
+		c.refreshFailures.Add(1)
+		c.mu.Lock()
+		if entry, ok := c.entries[key]; ok {
+			entry.refreshing = false
+		}
+		c.mu.Unlock()
+		return
+	}
+	c.store(key, generation, rec.Header(), rec.body.Bytes(), policy)
+}
+
+// revalidate checks whether the expired entry is still current, replacing it with an entry
+// fresh for another TTL when it is
+func (c *ResponseCache) revalidate(r *http.Request, key string, entry *cacheEntry, policy CachePolicy) (*cacheEntry, bool) {
+	tag := entry.header.Get("ETag")
+	if policy.Revalidate == nil || tag == "" {
+		return nil, false
+	}
+
+	c.revalidations.Add(1)
+	current, err := policy.Revalidate(r, tag)
+	if err != nil || !current {
+		return nil, false
+	}
+	c.revalidated.Add(1)
+
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] != entry {
+		return nil, false
+	}
+	revalidated := &cacheEntry{
+		header:     entry.header,
+		body:       entry.body,
+		stored:     now,
+		expires:    now.Add(policy.TTL),
+		staleUntil: now.Add(policy.TTL + policy.StaleWhileRevalidate),
+		keepUntil:  keepUntil(now, entry.header, policy),
+	}
+	c.entries[key] = revalidated
+	return revalidated, true
+}
+
+func (c *ResponseCache) store(key string, generation uint64, header http.Header, body []byte, policy CachePolicy) {
+	header = header.Clone()
+	for _, name := range perRequestHeaders {
+		header.Del(name)
+	}
+	now := c.clock.Now()
+	entry := &cacheEntry{
+		header:     header,
+		body:       body,
+		stored:     now,
+		expires:    now.Add(policy.TTL),
+		staleUntil: now.Add(policy.TTL + policy.StaleWhileRevalidate),
+		keepUntil:  keepUntil(now, header, policy),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if now.After(e.keepUntil) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[key] = entry
+}
+
+// keepUntil returns when an entry stored at now is dropped
+func keepUntil(now time.Time, header http.Header, policy CachePolicy) time.Time {
+	retention := max(policy.StaleWhileRevalidate, policy.StaleIfError)
+	if policy.Revalidate != nil && header.Get("ETag") != "" {
+		retention = max(retention, policy.StaleWhileRevalidate+revalidationRetention)
+	}
+	return now.Add(policy.TTL + retention)
+}
+
+func (c *ResponseCache) key(r *http.Request) string {
+	var key strings.Builder
+	key.WriteString(r.URL.RequestURI())
+	for _, name := range c.vary {
+		key.WriteString("\n")
+		key.WriteString(r.Header.Get(name))
+	}
+	return key.String()
+}
+
+// cacheRecorder writes the response through while keeping a copy of it
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *cacheRecorder) WriteHeader(code int) {
+	rec.status = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *cacheRecorder) Write(p []byte) (int, error) {
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > maxCachedBodySize {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *cacheRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// discardResponseWriter is the response writer of background refreshes, which have no client
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w *discardResponseWriter) WriteHeader(int) {}
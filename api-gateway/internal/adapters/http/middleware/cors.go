@@ -4,15 +4,16 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/logging"
 )
 
 // CORS middleware
 func CORS(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			setCORSHeaders(w)
 			
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
@@ -24,26 +25,55 @@ func CORS(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// Logging middleware
-func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, If-Match, Prefer, X-Naming, traceparent, tracestate, b3")
+	w.Header().Set("Access-Control-Expose-Headers", "ETag, X-Total-Count, Preference-Applied, Deprecation, Sunset, Link, X-Naming")
+}
+
+// Logging writes the canonical log line of each request: every failed, slow or writing
+// request and the sample of successful reads chosen by the policy. Lines of 4xx
+// responses are warnings and lines of 5xx responses are errors. The request gets a
+// logger with its method, route and trace ID, which handlers, services and the gRPC
+// client read with logging.FromContext
+func Logging(logger *slog.Logger, policy logging.Policy) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			
+			trace, _ := domain.TraceFromContext(r.Context())
+			logger := logger.With("method", r.Method, "route", routeTemplate(r), "trace_id", trace.TraceID)
+			r = r.WithContext(logging.NewContext(r.Context(), logger))
+
 			// Wrap the response writer to capture status code
 			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-			
+
 			next.ServeHTTP(wrapped, r)
-			
+
 			duration := time.Since(start)
-			
-			logger.Info("HTTP request",
-				"method", r.Method,
-				"path", r.URL.Path,
-				"status", wrapped.statusCode,
-				"duration", duration,
-				"user_agent", r.UserAgent(),
-			)
+			read := r.Method == http.MethodGet || r.Method == http.MethodHead
+			decision := policy.Decide(read, wrapped.statusCode >= http.StatusBadRequest, duration)
+			if !decision.Log {
+				return
+			}
+
+			level := slog.LevelInfo
+			switch {
+			case wrapped.statusCode >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case wrapped.statusCode >= http.StatusBadRequest:
+				level = slog.LevelWarn
+			}
+			attrs := []slog.Attr{
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Duration("duration", duration),
+				slog.String("user_agent", r.UserAgent()),
+			}
+			if decision.Sampled {
+				attrs = append(attrs, slog.Float64("sample_rate", policy.SampleRate))
+			}
+			logger.LogAttrs(r.Context(), level, "HTTP request", attrs...)
 		})
 	}
 }
@@ -51,9 +81,22 @@ func Logging(logger *slog.Logger) func(http.Handler) http.Handler {
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	// written counts the bytes of the body
+	written int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.written += int64(n)
+	return n, err
+}
+
+// Unwrap exposes the underlying writer so handlers can flush streamed responses
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
\ No newline at end of file
@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/config"
+)
+
+// maxInspectedBody bounds the request bodies read to look for deprecated fields or to
+// rename them; larger bodies are passed on without being looked into
+const maxInspectedBody = 1 << 20
+
+// WarningsField is the field of JSON responses listing the deprecated features used
+const WarningsField = "_warnings"
+
+// Deprecations marks the responses of requests using deprecated routes, query parameters
+// or body fields with the Deprecation (RFC 9745) and Sunset (RFC 8594) headers, with a
+// Link to the migration guide, and counts them per feature in the metrics. Clients with
+// one of the warning keys also get them listed in the _warnings of JSON object responses,
+// along with the deprecated fields of the response
+type Deprecations struct {
+	deprecations []config.Deprecation
+	// warnAll warns every client; otherwise warningKeys are the keys of those warned
+	warnAll     bool
+	warningKeys [][]byte
+	metrics     *Metrics
+}
+
+// Warning reports a deprecated feature in the _warnings of a response
+type Warning struct {
+	Feature string     `json:"feature"`
+	Message string     `json:"message"`
+	Sunset  *time.Time `json:"sunset,omitempty"`
+	Link    string     `json:"link,omitempty"`
+}
+
+// NewDeprecations returns the middleware of the deprecations, counted by metrics. The
+// responses to clients with one of warningKeys, or to all of them with "*", list the
+// deprecations in _warnings
+func NewDeprecations(deprecations []config.Deprecation, warningKeys []string, metrics *Metrics) *Deprecations {
+	d := &Deprecations{metrics: metrics}
+	for _, key := range warningKeys {
+		if key == "*" {
+			d.warnAll = true
+		}
+		d.warningKeys = append(d.warningKeys, []byte(key))
+	}
+	features := make([]string, 0, len(deprecations))
+	for _, deprecation := range deprecations {
+		deprecation.Path = normalizePathTemplate(deprecation.Path)
+		d.deprecations = append(d.deprecations, deprecation)
+		features = append(features, deprecation.Feature)
+	}
+	metrics.declareDeprecated(features)
+	return d
+}
+
+// Middleware applies the deprecations of the matched route; register it with router.Use
+func (d *Deprecations) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var used, responseFields []config.Deprecation
+		var fields map[string]json.RawMessage
+		template := routeTemplate(r)
+		for i, deprecation := range d.deprecations {
+			if deprecation.Path != template || !matchesMethod(deprecation.Methods, r.Method) {
+				continue
+			}
+			switch {
+			case deprecation.ResponseField != "":
+				responseFields = append(responseFields, deprecation)
+				continue
+			case deprecation.Query != "":
+				if !r.URL.Query().Has(deprecation.Query) {
+					continue
+				}
+			case deprecation.Field != "":
+				if fields == nil {
+					fields = bodyFields(r)
+				}
+				if _, ok := fields[deprecation.Field]; !ok {
+					continue
+				}
+			}
+			used = append(used, d.deprecations[i])
+		}
+
+		if len(used) > 0 {
+			setDeprecationHeaders(w.Header(), used)
+			for _, deprecation := range used {
+				d.metrics.deprecatedUse(deprecation.Feature)
+			}
+		}
+		if (len(used) == 0 && len(responseFields) == 0) || r.Method == http.MethodHead || !d.warns(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &jsonBuffer{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if !rec.buffered() {
+			return
+		}
+		if len(responseFields) > 0 {
+			var body interface{}
+			if json.Unmarshal(rec.body.Bytes(), &body) == nil {
+				for _, deprecation := range responseFields {
+					if hasField(body, strings.Split(deprecation.ResponseField, ".")) {
+						used = append(used, deprecation)
+						d.metrics.deprecatedUse(deprecation.Feature)
+					}
+				}
+			}
+		}
+		warnings := make([]Warning, len(used))
+		for i, deprecation := range used {
+			warnings[i] = newWarning(deprecation, r.Method)
+		}
+		rec.send(withWarnings(rec.body.Bytes(), warnings))
+	})
+}
+
+// warns reports whether the client asked for warnings in the responses
+func (d *Deprecations) warns(r *http.Request) bool {
+	return d.warnAll || hasAPIKey(r, d.warningKeys)
+}
+
+func newWarning(deprecation config.Deprecation, method string) Warning {
+	warning := Warning{Feature: deprecation.Feature, Message: deprecation.Message, Link: deprecation.Link}
+	if !deprecation.Sunset.IsZero() {
+		sunset := deprecation.Sunset.UTC()
+		warning.Sunset = &sunset
+	}
+	if warning.Message == "" {
+		subject := method + " " + deprecation.Path
+		switch {
+		case deprecation.Query != "":
+			subject = "query parameter " + deprecation.Query
+		case deprecation.Field != "":
+			subject = "request field " + deprecation.Field
+		case deprecation.ResponseField != "":
+			subject = "response field " + deprecation.ResponseField
+		}
+		warning.Message = subject + " is deprecated since " + deprecation.Since.UTC().Format(time.DateOnly)
+		if warning.Sunset != nil {
+			warning.Message += " and stops working on " + warning.Sunset.Format(time.DateOnly)
+		}
+	}
+	return warning
+}
+
+// hasField reports whether value has the field at path, looking into every element of
+// the arrays on the way
+func hasField(value interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		field, ok := v[path[0]]
+		return ok && hasField(field, path[1:])
+	case []interface{}:
+		for _, element := range v {
+			if hasField(element, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withWarnings returns the JSON body with warnings as its first field when it is an object
+func withWarnings(body []byte, warnings []Warning) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(warnings) == 0 || len(trimmed) == 0 || trimmed[0] != '{' {
+		return body
+	}
+	encoded, _ := json.Marshal(warnings)
+	rest := bytes.TrimSpace(trimmed[1:])
+	out := append([]byte(`{"`+WarningsField+`":`), encoded...)
+	if len(rest) > 0 && rest[0] != '}' {
+		out = append(out, ',')
+	}
+	return append(append(out, rest...), '\n')
+}
+
+// matchesMethod reports whether method is one of methods, or any method when none are
+// listed; HEAD requests match GET, like route policies
+func matchesMethod(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) || (method == http.MethodHead && strings.EqualFold(m, http.MethodGet)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyFields returns the top-level fields of a JSON object body, putting back what it
+// read for the handler. Bodies that are not JSON objects or are too large have none
+func bodyFields(r *http.Request) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	if r.Body == nil || r.Body == http.NoBody {
+		return fields
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxInspectedBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || len(data) > maxInspectedBody {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// setDeprecationHeaders announces the earliest deprecation and sunset of the features
+// used, linking to each migration guide
+func setDeprecationHeaders(header http.Header, used []config.Deprecation) {
+	var since, sunset time.Time
+	for _, deprecation := range used {
+		if since.IsZero() || deprecation.Since.Before(since) {
+			since = deprecation.Since
+		}
+		if !deprecation.Sunset.IsZero() && (sunset.IsZero() || deprecation.Sunset.Before(sunset)) {
+			sunset = deprecation.Sunset
+		}
+		if deprecation.Link != "" {
+			header.Add("Link", "<"+deprecation.Link+`>; rel="deprecation"; type="text/html"`)
+		}
+	}
+	header.Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+	if !sunset.IsZero() {
+		header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipMinBytes is the size from which responses are compressed; smaller ones would hardly
+// shrink
+const gzipMinBytes = 1024
+
+// compressibleTypes are the media types compressed; files such as job exports are sent
+// as they are, already compressed
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/x-protobuf": true,
+	"application/x-ndjson":   true,
+	"text/csv":               true,
+	"text/plain":             true,
+}
+
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+
+// Gzip compresses the JSON, protobuf and text responses of clients accepting gzip, once
+// they reach gzipMinBytes. Partial, empty and already encoded responses are sent as they
+// are
+func Gzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether Accept-Encoding accepts gzip, by name or as *
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(header, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.TrimSpace(name)
+			if !strings.EqualFold(name, "gzip") && name != "*" {
+				continue
+			}
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// gzipWriter holds the start of compressible responses back until they reach
+// gzipMinBytes, then compresses them as they are written
+type gzipWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passThrough bool
+	held        bytes.Buffer
+	gz          *gzip.Writer
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader, w.status = true, status
+	header := w.Header()
+	mediaType, _, _ := strings.Cut(header.Get("Content-Type"), ";")
+	switch {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusPartialContent,
+		status == http.StatusNotModified, header.Get("Content-Encoding") != "", header.Get("Content-Range") != "",
+		!compressibleTypes[strings.ToLower(strings.TrimSpace(mediaType))]:
+		w.passThrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *gzipWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	switch {
+	case w.passThrough:
+		return w.ResponseWriter.Write(p)
+	case w.gz != nil:
+		return w.gz.Write(p)
+	}
+	w.held.Write(p)
+	if w.held.Len() >= gzipMinBytes {
+		if err := w.compress(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// compress starts the compressed response with what was held back
+func (w *gzipWriter) compress() error {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	w.gz = gzipWriters.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	_, err := w.gz.Write(w.held.Bytes())
+	w.held.Reset()
+	return err
+}
+
+// close ends the compressed response, or sends the one held back uncompressed
+func (w *gzipWriter) close() {
+	switch {
+	case w.gz != nil:
+		w.gz.Close()
+		gzipWriters.Put(w.gz)
+	case w.wroteHeader && !w.passThrough:
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.held.Bytes())
+	}
+}
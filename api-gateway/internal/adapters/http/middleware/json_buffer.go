@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// jsonBuffer holds JSON responses back, so middleware can rewrite them before they are
+// sent; other responses are passed on as they are written
+type jsonBuffer struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	passThrough bool
+	body        bytes.Buffer
+}
+
+func (w *jsonBuffer) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader, w.status = true, status
+	mediaType, _, _ := strings.Cut(w.Header().Get("Content-Type"), ";")
+	if strings.TrimSpace(mediaType) != "application/json" {
+		w.passThrough = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *jsonBuffer) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.passThrough {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.body.Write(p)
+}
+
+// buffered reports whether the response was held back
+func (w *jsonBuffer) buffered() bool {
+	return w.wroteHeader && !w.passThrough
+}
+
+// send writes the response held back with body, which may differ from the one written
+func (w *jsonBuffer) send(body []byte) {
+	if !bytes.Equal(body, w.body.Bytes()) {
+		w.Header().Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
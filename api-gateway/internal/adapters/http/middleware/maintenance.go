@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenancePath is the route reading and switching read-only mode
+const MaintenancePath = "/admin/maintenance"
+
+// Maintenance switches the gateway to read-only mode, in which mutations are refused with
+// 503 while reads are still served, for migrations and maintenance windows of the backend
+type Maintenance struct {
+	readOnly   atomic.Bool
+	retryAfter time.Duration
+	adminKeys  [][]byte
+	apiKeys    [][]byte
+	logger     *slog.Logger
+}
+
+// NewMaintenance creates the switch in the given mode. Refused requests are told to retry
+// after retryAfter; only requests with one of adminKeys can switch modes, and those with one
+// of the client apiKeys are forbidden like on the other admin routes.
+func NewMaintenance(readOnly bool, retryAfter time.Duration, adminKeys, apiKeys []string, logger *slog.Logger) *Maintenance {
+	m := &Maintenance{retryAfter: retryAfter, adminKeys: byteKeys(adminKeys), apiKeys: byteKeys(apiKeys), logger: logger}
+	m.readOnly.Store(readOnly)
+	return m
+}
+
+// ReadOnly reports whether mutations are refused
+func (m *Maintenance) ReadOnly() bool {
+	return m.readOnly.Load()
+}
+
+// Middleware refuses requests other than GET, HEAD and OPTIONS in read-only mode; register
+// it with router.Use
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !m.ReadOnly() || r.URL.Path == MaintenancePath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(m.retryAfter), 1)))
+		writeJSONError(w, http.StatusServiceUnavailable, "read_only", "the API is in read-only mode for maintenance")
+	})
+}
+
+// maintenanceState is the body of the maintenance route
+type maintenanceState struct {
+	ReadOnly *bool `json:"read_only"`
+}
+
+// ServeHTTP reports the mode on GET and switches it on PUT with a body such as
+// {"read_only": true}. Both require an admin API key, so the route is not found when none
+// are configured.
+//
+// @Summary Get or switch the read-only mode
+// @Tags Operations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param state body maintenanceState false "New mode, on PUT"
+// @Success 200 {object} maintenanceState
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 "No admin API keys are configured"
+// @Router /admin/maintenance [get]
+// @Router /admin/maintenance [put]
+func (m *Maintenance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorizeAdmin(w, r, m.adminKeys, m.apiKeys) {
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		var state maintenanceState
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil || state.ReadOnly == nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", `body must be {"read_only": true|false}`)
+			return
+		}
+		if previous := m.readOnly.Swap(*state.ReadOnly); previous != *state.ReadOnly {
+			m.logger.Warn("Maintenance mode changed", "read_only", *state.ReadOnly)
+		}
+	}
+
+	readOnly := m.ReadOnly()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(maintenanceState{ReadOnly: &readOnly})
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// MethodNotAllowed handles requests whose path matches a route but whose method doesn't.
+// It answers OPTIONS requests automatically and always reports the methods available
+// for the path in the Allow header.
+func MethodNotAllowed(router *mux.Router, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allow := strings.Join(AllowedMethods(router, r), ", ")
+		w.Header().Set("Allow", allow)
+
+		if r.Method == http.MethodOptions {
+			setCORSHeaders(w)
+			w.Header().Set("Access-Control-Allow-Methods", allow)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		logger.Warn("method not allowed", "method", r.Method, "path", r.URL.Path, "allow", allow)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	})
+}
+
+// AllowedMethods returns the methods registered on the router for the request path, including OPTIONS
+func AllowedMethods(router *mux.Router, r *http.Request) []string {
+	seen := map[string]bool{http.MethodOptions: true}
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if seen[method] {
+				continue
+			}
+			probe := r.Clone(r.Context())
+			probe.Method = method
+			var match mux.RouteMatch
+			if route.Match(probe, &match) && match.MatchErr == nil {
+				seen[method] = true
+			}
+		}
+		return nil
+	})
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
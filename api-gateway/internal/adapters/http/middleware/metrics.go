@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/drain"
+)
+
+// OpenMetricsContentType is the exposition format that carries exemplars
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type routeKey struct {
+	method string
+	route  string
+	code   int
+}
+
+// exemplar links a bucket to the last sampled trace observed in it
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+type durationHistogram struct {
+	counts    []uint64 // one count per bucket, not cumulative
+	exemplars []exemplar
+	sum       float64
+	total     uint64
+}
+
+// Metrics counts HTTP requests by route and status code and records their durations.
+// Each duration bucket keeps the trace ID of its last sampled request as an exemplar,
+// so a latency spike can be followed to an example trace. The requests in flight, the
+// outcome of the shutdown drain, the use of deprecated features and the traffic anomalies
+// are reported too
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[routeKey]uint64
+	durations map[string]*durationHistogram
+	drain     *drain.Tracker
+	// deprecated counts the requests using each deprecated feature
+	deprecated map[string]uint64
+	// anomalies counts the traffic spikes of each kind
+	anomalies map[string]uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:   make(map[routeKey]uint64),
+		durations:  make(map[string]*durationHistogram),
+		drain:      drain.NewTracker("gateway"),
+		deprecated: make(map[string]uint64),
+		anomalies:  make(map[string]uint64),
+	}
+}
+
+// declareDeprecated reports the deprecated features from zero, before they are used
+func (m *Metrics) declareDeprecated(features []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, feature := range features {
+		m.deprecated[feature] += 0
+	}
+}
+
+func (m *Metrics) deprecatedUse(feature string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deprecated[feature]++
+}
+
+// declareAnomalies reports the kinds of traffic anomalies from zero, before any is flagged
+func (m *Metrics) declareAnomalies(kinds []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, kind := range kinds {
+		m.anomalies[kind] += 0
+	}
+}
+
+func (m *Metrics) anomaly(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.anomalies[kind]++
+}
+
+// Drain returns the tracker of the requests in flight, which drains them on shutdown
+func (m *Metrics) Drain() *drain.Tracker {
+	return m.drain
+}
+
+// InFlight counts the requests being handled by next. Wrap the whole server handler
+// with it, so requests matching no route are counted too
+func (m *Metrics) InFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer m.drain.Begin()()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Trace continues the trace of the traceparent header, or of the B3 headers of proxies
+// that only send those, or starts one, and stores the gateway span in the request
+// context for the calls made downstream
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, ok := incomingTrace(r.Header)
+		if ok {
+			trace = trace.Child()
+		} else {
+			trace = domain.NewTraceContext()
+		}
+		next.ServeHTTP(w, r.WithContext(domain.ContextWithTrace(r.Context(), trace)))
+	})
+}
+
+// incomingTrace reads the trace context of a request, preferring traceparent over the
+// single b3 header over the X-B3 headers
+func incomingTrace(header http.Header) (domain.TraceContext, bool) {
+	if trace, ok := domain.ParseTraceParent(header.Get(domain.TraceParentHeader)); ok {
+		trace.State = header.Get(domain.TraceStateHeader)
+		return trace, true
+	}
+	if trace, ok := domain.ParseB3(header.Get(domain.B3Header)); ok {
+		return trace, true
+	}
+	return domain.ParseB3Multi(header.Get(domain.B3TraceIDHeader), header.Get(domain.B3SpanIDHeader), header.Get(domain.B3SampledHeader))
+}
+
+// Middleware records every request routed by the router
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r)
+
+		route := routeTemplate(r)
+		if route == "" {
+			route = "unmatched"
+		}
+		trace, _ := domain.TraceFromContext(r.Context())
+		m.observe(routeKey{method: r.Method, route: route, code: wrapped.statusCode}, trace, time.Since(start))
+	})
+}
+
+func (m *Metrics) observe(key routeKey, trace domain.TraceContext, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[key]++
+
+	histogram, ok := m.durations[key.route]
+	if !ok {
+		histogram = &durationHistogram{
+			counts:    make([]uint64, len(durationBuckets)+1),
+			exemplars: make([]exemplar, len(durationBuckets)+1),
+		}
+		m.durations[key.route] = histogram
+	}
+	seconds := duration.Seconds()
+	bucket := sort.SearchFloat64s(durationBuckets, seconds)
+	histogram.counts[bucket]++
+	if trace.Sampled {
+		histogram.exemplars[bucket] = exemplar{traceID: trace.TraceID, value: seconds, at: time.Now()}
+	}
+	histogram.sum += seconds
+	histogram.total++
+}
+
+// ServeHTTP writes the metrics in the OpenMetrics format, with exemplars, to clients
+// that accept it and in the Prometheus text format otherwise
+//
+// @Summary Request metrics in the Prometheus or OpenMetrics format
+// @Tags Operations
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := acceptsOpenMetrics(r)
+	if openMetrics {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	}
+	m.Write(w, openMetrics)
+}
+
+// Write writes the metrics in the Prometheus text format or, when openMetrics is set,
+// in the OpenMetrics format with the exemplars of each bucket
+func (m *Metrics) Write(w io.Writer, openMetrics bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// OpenMetrics names counter families without the _total suffix of their samples
+	family := "gateway_http_requests_total"
+	if openMetrics {
+		family = "gateway_http_requests"
+	}
+	fmt.Fprintf(w, "# HELP %s HTTP requests served, by method, route and status code.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	keys := make([]routeKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "gateway_http_requests_total{method=%q,route=%q,code=\"%d\"} %d\n", key.method, key.route, key.code, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP gateway_http_request_duration_seconds Time spent serving HTTP requests, by route.")
+	fmt.Fprintln(w, "# TYPE gateway_http_request_duration_seconds histogram")
+	routes := make([]string, 0, len(m.durations))
+	for route := range m.durations {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	for _, route := range routes {
+		histogram := m.durations[route]
+		var cumulative uint64
+		for i := range histogram.counts {
+			cumulative += histogram.counts[i]
+			le := "+Inf"
+			if i < len(durationBuckets) {
+				le = strconv.FormatFloat(durationBuckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(w, "gateway_http_request_duration_seconds_bucket{route=%q,le=%q} %d", route, le, cumulative)
+			if ex := histogram.exemplars[i]; openMetrics && ex.traceID != "" {
+				fmt.Fprintf(w, " # {trace_id=%q} %g %.3f", ex.traceID, ex.value, float64(ex.at.UnixMilli())/1000)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "gateway_http_request_duration_seconds_sum{route=%q} %g\n", route, histogram.sum)
+		fmt.Fprintf(w, "gateway_http_request_duration_seconds_count{route=%q} %d\n", route, histogram.total)
+	}
+
+	if len(m.deprecated) > 0 {
+		family = "gateway_deprecated_requests_total"
+		if openMetrics {
+			family = "gateway_deprecated_requests"
+		}
+		fmt.Fprintf(w, "# HELP %s Requests using a deprecated route or field, by feature.\n", family)
+		fmt.Fprintf(w, "# TYPE %s counter\n", family)
+		features := make([]string, 0, len(m.deprecated))
+		for feature := range m.deprecated {
+			features = append(features, feature)
+		}
+		sort.Strings(features)
+		for _, feature := range features {
+			fmt.Fprintf(w, "gateway_deprecated_requests_total{feature=%q} %d\n", feature, m.deprecated[feature])
+		}
+	}
+
+	if len(m.anomalies) > 0 {
+		family = "gateway_traffic_anomalies_total"
+		if openMetrics {
+			family = "gateway_traffic_anomalies"
+		}
+		fmt.Fprintf(w, "# HELP %s Traffic spikes flagged, by kind.\n", family)
+		fmt.Fprintf(w, "# TYPE %s counter\n", family)
+		kinds := make([]string, 0, len(m.anomalies))
+		for kind := range m.anomalies {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(w, "gateway_traffic_anomalies_total{kind=%q} %d\n", kind, m.anomalies[kind])
+		}
+	}
+
+	m.drain.Write(w, openMetrics)
+
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
+
+// acceptsOpenMetrics reports whether the scraper asked for the OpenMetrics format, as
+// Prometheus does when exemplar storage is enabled
+func acceptsOpenMetrics(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "application/openmetrics-text" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingHeader asks for the naming of the JSON field names of a request and its response
+const NamingHeader = "X-Naming"
+
+// Namings of JSON field names
+const (
+	SnakeCase = "snake_case"
+	CamelCase = "camelCase"
+)
+
+// Naming lets clients use camelCase JSON field names, as in the JSON mapping of the proto
+// messages, instead of the snake_case of the gateway. They ask for it in X-Naming, or
+// their API key prefers it; the gateway then renames the fields of JSON request bodies to
+// snake_case before they are decoded and those of JSON responses to camelCase
+type Naming struct {
+	// camelAll makes camelCase the default of every client; otherwise camelKeys are the
+	// keys of the clients preferring it
+	camelAll  bool
+	camelKeys [][]byte
+}
+
+// NewNaming returns the middleware of the naming of JSON fields, with camelCase the
+// default of the clients with one of camelKeys, or of all of them with "*"
+func NewNaming(camelKeys []string) *Naming {
+	n := &Naming{}
+	for _, key := range camelKeys {
+		if key == "*" {
+			n.camelAll = true
+		}
+		n.camelKeys = append(n.camelKeys, []byte(key))
+	}
+	return n
+}
+
+// Middleware renames the JSON fields of requests and responses of clients using
+// camelCase; register it with router.Use, before the middleware looking into bodies
+func (n *Naming) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", NamingHeader)
+		if len(n.camelKeys) > 0 && !n.camelAll {
+			w.Header().Add("Vary", "Authorization")
+			w.Header().Add("Vary", "X-API-Key")
+		}
+		naming, ok := n.naming(r)
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "invalid_naming",
+				NamingHeader+" must be "+SnakeCase+" or "+CamelCase)
+			return
+		}
+		w.Header().Set(NamingHeader, naming)
+		if naming == SnakeCase {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		renameBody(r, snakeCase)
+		rec := &jsonBuffer{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if !rec.buffered() {
+			return
+		}
+		body, err := renameKeys(rec.body.Bytes(), camelCase)
+		if err != nil {
+			body = rec.body.Bytes()
+		}
+		rec.send(body)
+	})
+}
+
+// naming returns the naming asked by the client, or false when it is not one of them
+func (n *Naming) naming(r *http.Request) (string, bool) {
+	switch asked := r.Header.Get(NamingHeader); {
+	case asked == "":
+	case strings.EqualFold(asked, SnakeCase):
+		return SnakeCase, true
+	case strings.EqualFold(asked, CamelCase):
+		return CamelCase, true
+	default:
+		return "", false
+	}
+	if n.camelAll || hasAPIKey(r, n.camelKeys) {
+		return CamelCase, true
+	}
+	return SnakeCase, true
+}
+
+// renameBody renames the fields of a JSON request body. Bodies that are not JSON or are
+// too large are left for the handler to reject or read as they are
+func renameBody(r *http.Request, rename func(string) string) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxInspectedBody+1))
+	if err == nil && len(data) <= maxInspectedBody {
+		if renamed, err := renameKeys(data, rename); err == nil {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{bytes.NewReader(renamed), r.Body}
+			r.ContentLength = int64(len(renamed))
+			return
+		}
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+}
+
+// renameKeys returns the JSON values of data with the keys of their objects renamed,
+// keeping their order. Values are copied as they are, so numbers keep their precision
+func renameKeys(data []byte, rename func(string) string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	// tokens counts, for each open object or array, the keys and values written in it
+	type container struct {
+		object bool
+		tokens int
+	}
+	var open []container
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && len(open) == 0 {
+			break
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if delim, ok := tok.(json.Delim); ok && (delim == '}' || delim == ']') {
+			open = open[:len(open)-1]
+			out.WriteByte(byte(delim))
+		} else {
+			isKey := false
+			if len(open) > 0 {
+				top := open[len(open)-1]
+				isKey = top.object && top.tokens%2 == 0
+				switch {
+				case top.object && !isKey:
+					out.WriteByte(':')
+				case top.tokens > 0:
+					out.WriteByte(',')
+				}
+			}
+			switch v := tok.(type) {
+			case json.Delim:
+				out.WriteByte(byte(v))
+				open = append(open, container{object: v == '{'})
+				continue
+			case string:
+				if isKey {
+					v = rename(v)
+				}
+				encoded, _ := json.Marshal(v)
+				out.Write(encoded)
+			case json.Number:
+				out.WriteString(v.String())
+			case bool:
+				encoded, _ := json.Marshal(v)
+				out.Write(encoded)
+			case nil:
+				out.WriteString("null")
+			}
+		}
+
+		if len(open) > 0 {
+			open[len(open)-1].tokens++
+		} else {
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// camelCase renames snake_case to camelCase, keeping leading underscores, so created_at
+// becomes createdAt and _warnings stays as it is
+func camelCase(name string) string {
+	trimmed := strings.TrimLeft(name, "_")
+	words := strings.Split(trimmed, "_")
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	for i, word := range words {
+		if i == 0 || word == "" {
+			b.WriteString(word)
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(word[size:])
+	}
+	return b.String()
+}
+
+// snakeCase renames camelCase to snake_case, so createdAt becomes created_at; names
+// already in snake_case are left as they are
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			if i > 0 && name[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
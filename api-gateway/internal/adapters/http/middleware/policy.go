@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/config"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// PolicyEngine applies the declared policy of the route matched by the router: API key
+// auth, per-client rate limiting, timeouts and response caching
+type PolicyEngine struct {
+	policies *config.RoutePolicies
+	rules    []config.RouteRule
+	apiKeys  [][]byte
+	limiters map[string]*RateLimiter
+	cache    *ResponseCache
+	// revalidators are keyed on normalized path templates
+	revalidators map[string]Revalidator
+	logger       *slog.Logger
+}
+
+// NewPolicyEngine creates an engine accepting the given API keys. Cached responses are
+// keyed on the request URI and the varyHeaders. Cache entries and rate limits expire by clk.
+func NewPolicyEngine(policies *config.RoutePolicies, apiKeys, varyHeaders []string, clk clock.Clock, logger *slog.Logger) *PolicyEngine {
+	e := &PolicyEngine{
+		policies:     policies,
+		limiters:     make(map[string]*RateLimiter),
+		cache:        NewResponseCache(varyHeaders, clk),
+		revalidators: make(map[string]Revalidator),
+		logger:       logger,
+	}
+	for _, rule := range policies.Rules {
+		rule.Path = normalizePathTemplate(rule.Path)
+		e.rules = append(e.rules, rule)
+	}
+	for _, key := range apiKeys {
+		e.apiKeys = append(e.apiKeys, []byte(key))
+	}
+	for name, tier := range policies.RateLimits {
+		e.limiters[name] = NewRateLimiter(tier.RequestsPerSecond, tier.Burst, clk)
+	}
+	return e
+}
+
+// Cache returns the cache of the responses of routes with a cache TTL
+func (e *PolicyEngine) Cache() *ResponseCache {
+	return e.cache
+}
+
+// Revalidate lets expired cached responses of the route with the path template be
+// revalidated with fn instead of fetched again. Call it before serving requests.
+func (e *PolicyEngine) Revalidate(path string, fn Revalidator) {
+	e.revalidators[normalizePathTemplate(path)] = fn
+}
+
+// Middleware applies the policy of the matched route; register it with router.Use
+func (e *PolicyEngine) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		template := routeTemplate(r)
+		policy := e.policyFor(r, template)
+
+		if policy.Auth && !e.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			writeJSONError(w, http.StatusUnauthorized, "unauthorized", "a valid API key is required")
+			return
+		}
+
+		if policy.RateLimit != "" {
+			decision := e.limiters[policy.RateLimit].Take(e.clientKey(r))
+			decision.SetHeaders(w.Header())
+			if !decision.Allowed {
+				e.logger.Warn("rate limit exceeded", "tier", policy.RateLimit, "client", e.clientKey(r), "path", r.URL.Path)
+				writeJSONError(w, http.StatusTooManyRequests, "rate_limited", "too many requests")
+				return
+			}
+		}
+
+		timeout := time.Duration(policy.Timeout)
+		if timeout == 0 {
+			timeout = time.Duration(e.policies.Timeout)
+		}
+		handler := next
+		if timeout > 0 {
+			handler = Timeout(timeout, next)
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			if policy.CacheTTL > 0 {
+				e.cache.Serve(w, r, handler, CachePolicy{
+					TTL:                  time.Duration(policy.CacheTTL),
+					StaleWhileRevalidate: time.Duration(policy.StaleWhileRevalidate),
+					StaleIfError:         time.Duration(policy.StaleIfError),
+					RefreshTimeout:       timeout,
+					Revalidate:           e.revalidators[template],
+				})
+				return
+			}
+			handler.ServeHTTP(w, r)
+		case http.MethodHead:
+			if policy.CacheTTL > 0 {
+				e.cache.ServeHead(w, r, handler)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		case http.MethodOptions:
+			handler.ServeHTTP(w, r)
+		default:
+			// Writes may change any cached listing, so cached responses are dropped
+			handler.ServeHTTP(w, r)
+			e.cache.Purge()
+		}
+	})
+}
+
+// policyFor returns the policy of the first rule matching the route and method. HEAD
+// requests get the policy of GET, as their responses are those of GET without the body
+func (e *PolicyEngine) policyFor(r *http.Request, template string) config.RoutePolicy {
+	if template == "" {
+		return e.policies.Default
+	}
+
+	for _, rule := range e.rules {
+		if rule.Path == template && matchesMethod(rule.Methods, r.Method) {
+			return rule.RoutePolicy
+		}
+	}
+	return e.policies.Default
+}
+
+// routeTemplate returns the normalized path template of the matched route, or "" when
+// the request matched no route
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return normalizePathTemplate(template)
+}
+
+// authorized reports whether the request carries a known API key, either as a bearer
+// token or in the X-API-Key header
+func (e *PolicyEngine) authorized(r *http.Request) bool {
+	key := apiKey(r)
+	if key == "" {
+		return false
+	}
+	for _, known := range e.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), known) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func apiKey(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// clientKey identifies the client for rate limiting: its API key when it sent a valid
+// one, otherwise its IP address, so made-up keys don't get clients fresh buckets
+func (e *PolicyEngine) clientKey(r *http.Request) string {
+	if e.authorized(r) {
+		return "key:" + apiKey(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+var pathVariablePattern = regexp.MustCompile(`\{([^}:]+):[^}]*\}`)
+
+// normalizePathTemplate drops the patterns of path variables, so "/movies/{id:[0-9]+}"
+// becomes "/movies/{id}"
+func normalizePathTemplate(template string) string {
+	return pathVariablePattern.ReplaceAllString(template, "{$1}")
+}
+
+// writeJSONError writes an error body shaped like the handlers' error responses
+func writeJSONError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code, "message": message})
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/proto/clock"
+)
+
+// maxRateLimitClients bounds the number of tracked clients; idle clients are dropped
+// once it is reached
+const maxRateLimitClients = 10000
+
+// RateLimiter is a token bucket per client
+type RateLimiter struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	clock   clock.Clock
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter refilling the buckets by clk
+func NewRateLimiter(requestsPerSecond float64, burst int, clk clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		rate:    requestsPerSecond,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		clock:   clk,
+	}
+}
+
+// RateLimitDecision is the outcome of taking a token from a client bucket
+type RateLimitDecision struct {
+	Allowed bool
+	// Limit is the burst size of the bucket
+	Limit int
+	// Remaining is the number of requests the client can still make right away
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available, zero when one is
+	RetryAfter time.Duration
+	// Window is the time the bucket takes to refill from empty
+	Window time.Duration
+}
+
+// Take takes a token from the bucket of the client when one is available
+func (l *RateLimiter) Take(client string) RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	decision := RateLimitDecision{
+		Allowed:   allowed,
+		Limit:     int(l.burst),
+		Remaining: int(b.tokens),
+		Reset:     l.refillTime(l.burst - b.tokens),
+		Window:    l.refillTime(l.burst),
+	}
+	if !allowed {
+		decision.RetryAfter = l.refillTime(1 - b.tokens)
+	}
+	return decision
+}
+
+// refillTime returns the time the bucket takes to gain the given number of tokens
+func (l *RateLimiter) refillTime(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// prune drops the buckets that refilled completely, which behave like new ones
+func (l *RateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// SetHeaders writes the RateLimit header fields of the IETF draft, plus Retry-After when
+// the request was refused
+func (d RateLimitDecision) SetHeaders(h http.Header) {
+	h.Set("RateLimit-Policy", strconv.Itoa(d.Limit)+";w="+strconv.Itoa(ceilSeconds(d.Window)))
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	if !d.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(ceilSeconds(d.RetryAfter), 1)))
+	}
+}
+
+// ceilSeconds rounds up to whole seconds, as header fields carry delays in seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
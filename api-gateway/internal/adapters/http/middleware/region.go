@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// Region infers the request region from the given header (e.g. a CDN country header)
+// and stores it in the request context. Listings use it when no region parameter is given.
+func Region(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if region, err := domain.NormalizeRegion(r.Header.Get(header)); err == nil {
+				r = r.WithContext(domain.ContextWithRegion(r.Context(), region))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
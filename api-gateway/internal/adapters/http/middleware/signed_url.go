@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/movie-microservice/proto/clock"
+)
+
+// Query parameters of signed URLs
+const (
+	ExpiresParam   = "expires"
+	SignatureParam = "signature"
+)
+
+// URLSigner signs paths with an expiry, so the signed URL is served without an API key
+// until it expires, such as a job file opened from a browser. Signatures are HMAC-SHA256
+// of the path and the expiry; several secrets let them be rotated, signing with the first
+// while URLs signed with the others are still accepted
+type URLSigner struct {
+	secrets [][]byte
+	ttl     time.Duration
+	clock   clock.Clock
+}
+
+// NewURLSigner returns a signer of URLs valid for ttl, or nil without secrets
+func NewURLSigner(secrets []string, ttl time.Duration, clk clock.Clock) *URLSigner {
+	if len(secrets) == 0 {
+		return nil
+	}
+	s := &URLSigner{ttl: ttl, clock: clk}
+	for _, secret := range secrets {
+		s.secrets = append(s.secrets, []byte(secret))
+	}
+	return s
+}
+
+// Sign returns the URL of the unescaped path with the query parameters of its signature,
+// and when it expires
+func (s *URLSigner) Sign(path string) (string, time.Time) {
+	expires := s.clock.Now().Add(s.ttl).Truncate(time.Second)
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	signed := url.URL{Path: path, RawQuery: url.Values{ExpiresParam: {unix}, SignatureParam: {s.signature(s.secrets[0], path, unix)}}.Encode()}
+	return signed.String(), expires
+}
+
+// Verify reports whether r is signed for its path and has not expired
+func (s *URLSigner) Verify(r *http.Request) bool {
+	query := r.URL.Query()
+	unix := query.Get(ExpiresParam)
+	expires, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || !s.clock.Now().Before(time.Unix(expires, 0)) {
+		return false
+	}
+	signature := []byte(query.Get(SignatureParam))
+	for _, secret := range s.secrets {
+		if hmac.Equal(signature, []byte(s.signature(secret, r.URL.Path, unix))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *URLSigner) signature(secret []byte, path, expires string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(path + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignedOrAdmin serves the routes it wraps to requests signed by signer without asking
+// for an API key, and restricts the others like KeyRequired. Requests with an invalid or
+// expired signature are refused with 403, even when they carry an API key
+func SignedOrAdmin(signer *URLSigner, apiKeys []string) func(http.Handler) http.Handler {
+	keyRequired := KeyRequired(apiKeys)
+	return func(next http.Handler) http.Handler {
+		restricted := keyRequired(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if signer == nil || !r.URL.Query().Has(SignatureParam) {
+				restricted.ServeHTTP(w, r)
+				return
+			}
+			if !signer.Verify(r) {
+				writeJSONError(w, http.StatusForbidden, "invalid_signature", "the signature of the URL is invalid or expired")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
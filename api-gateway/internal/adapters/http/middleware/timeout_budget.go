@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+)
+
+// TimeoutBudgetHeader carries the time in milliseconds a caller is willing to wait for
+// the whole call chain
+const TimeoutBudgetHeader = "X-Timeout-Budget-Ms"
+
+// TimeoutBudget sets the request deadline from the X-Timeout-Budget-Ms header of callers
+// in the trusted networks, keeping reserve for the gateway to write the response. Calls
+// to the movie service and proxied services carry the remaining time downstream. The
+// header of other callers is ignored.
+func TimeoutBudget(trusted []netip.Prefix, reserve time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			value := r.Header.Get(TimeoutBudgetHeader)
+			if value == "" || !trustedCaller(r, trusted) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			budgetMs, err := strconv.Atoi(value)
+			if err != nil || budgetMs <= 0 {
+				writeJSONError(w, http.StatusBadRequest, "invalid_request", TimeoutBudgetHeader+" must be a positive number of milliseconds")
+				return
+			}
+			budget := time.Duration(budgetMs)*time.Millisecond - reserve
+			if budget <= 0 {
+				writeJSONError(w, http.StatusGatewayTimeout, "gateway_timeout", "timeout budget exhausted")
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func trustedCaller(r *http.Request, trusted []netip.Prefix) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range trusted {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// Usage records the requests, errors and bytes of the requests with one of apiKeys in
+// store, and meters each of them when meter is not nil. The ID of their key is passed on
+// in the context. Requests without a valid key and warm-ups are not recorded, so made-up
+// keys cannot fill the store
+func Usage(store ports.UsageStorePort, meter ports.MeteringPort, apiKeys []string, clk clock.Clock) func(http.Handler) http.Handler {
+	keys := make([][]byte, len(apiKeys))
+	for i, key := range apiKeys {
+		keys[i] = []byte(key)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if domain.IsWarmup(r.Context()) || !hasAPIKey(r, keys) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			keyID := domain.APIKeyID(apiKey(r))
+			r = r.WithContext(domain.ContextWithAPIKeyID(r.Context(), keyID))
+			body := &countingReader{ReadCloser: r.Body}
+			if r.Body != nil {
+				r.Body = body
+			}
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r)
+
+			counts := domain.UsageCounts{Requests: 1, BytesIn: body.read, BytesOut: wrapped.written}
+			switch {
+			case wrapped.statusCode >= http.StatusInternalServerError:
+				counts.ServerErrors = 1
+			case wrapped.statusCode >= http.StatusBadRequest:
+				counts.ClientErrors = 1
+			}
+			now := clk.Now()
+			store.Record(keyID, now, counts)
+			if meter != nil {
+				meter.Meter(domain.MeteringEvent{
+					KeyID:      keyID,
+					Route:      routeTemplate(r),
+					Method:     r.Method,
+					Status:     wrapped.statusCode,
+					BytesIn:    counts.BytesIn,
+					BytesOut:   counts.BytesOut,
+					OccurredAt: now.UTC(),
+				})
+			}
+		})
+	}
+}
+
+// countingReader counts the bytes read from a request body
+type countingReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,82 @@
+// Package metering publishes the metering events of the gateway for billing
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/retry"
+)
+
+const (
+	// maxErrorBodyBytes bounds the part of a failed response kept in the error
+	maxErrorBodyBytes = 512
+	// publishTimeout bounds each post of a batch
+	publishTimeout = 10 * time.Second
+	// retryBaseDelay and retryMaxDelay bound the wait before a batch is posted again
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+// NewRetry returns the retry policy of the posts of a batch, making up to attempts
+func NewRetry(attempts int, logger *slog.Logger) retry.Policy {
+	return retry.Policy{
+		MaxAttempts: max(attempts, 1),
+		BaseDelay:   retryBaseDelay,
+		MaxDelay:    retryMaxDelay,
+		OnRetry: func(attempt int, delay time.Duration, err error) {
+			logger.Warn("Metering: Retrying events", "attempt", attempt, "backoff", delay, "error", err)
+		},
+	}
+}
+
+// Webhook publishes batches of metering events as JSON to an HTTP endpoint, such as the
+// ingestion endpoint of the event bus or of the billing system itself:
+//
+//	POST {"events": [{"id": "...", "key_id": "...", "route": "...", ...}]}
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a publisher posting to url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: publishTimeout}}
+}
+
+// Publish posts the events, failing on any non-2xx answer. Answers that a retry won't
+// change, such as a rejected batch, are marked permanent
+func (w *Webhook) Publish(ctx context.Context, events []domain.MeteringEvent) error {
+	body, err := json.Marshal(map[string][]domain.MeteringEvent{"events": events})
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("invalid metering request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("metering endpoint unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		answer, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		err := fmt.Errorf("metering endpoint answered %s: %s", resp.Status, bytes.TrimSpace(answer))
+		if !retry.RetryableStatus(resp.StatusCode) {
+			return retry.Permanent(err)
+		}
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
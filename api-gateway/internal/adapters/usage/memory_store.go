@@ -0,0 +1,76 @@
+// Package usage holds the stores of the traffic of each API key
+package usage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+type dayKey struct {
+	keyID string
+	date  string
+}
+
+// MemoryStore keeps the usage of each API key per UTC day in memory, for retention days.
+// Each gateway replica counts the requests it served, and the counts are lost on restart
+type MemoryStore struct {
+	mu        sync.Mutex
+	days      map[dayKey]*domain.UsageCounts
+	retention int
+	clock     clock.Clock
+	// pruned is the date the days past the retention were last dropped
+	pruned string
+}
+
+// NewMemoryStore returns a store keeping retention days of usage, dated by clk
+func NewMemoryStore(retention int, clk clock.Clock) *MemoryStore {
+	return &MemoryStore{days: map[dayKey]*domain.UsageCounts{}, retention: retention, clock: clk}
+}
+
+func (s *MemoryStore) Record(keyID string, at time.Time, counts domain.UsageCounts) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+
+	key := dayKey{keyID: keyID, date: at.UTC().Format(domain.UsageDateLayout)}
+	day, ok := s.days[key]
+	if !ok {
+		day = &domain.UsageCounts{}
+		s.days[key] = day
+	}
+	day.Add(counts)
+}
+
+func (s *MemoryStore) Daily(keyID string, from, to time.Time) []domain.DailyUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+
+	var days []domain.DailyUsage
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(to); day = day.AddDate(0, 0, 1) {
+		usage := domain.DailyUsage{Date: day.Format(domain.UsageDateLayout)}
+		if counts, ok := s.days[dayKey{keyID: keyID, date: usage.Date}]; ok {
+			usage.UsageCounts = *counts
+		}
+		days = append(days, usage)
+	}
+	return days
+}
+
+// prune drops the days past the retention, once a day
+func (s *MemoryStore) prune() {
+	today := s.clock.Now().UTC()
+	if s.pruned == today.Format(domain.UsageDateLayout) {
+		return
+	}
+	s.pruned = today.Format(domain.UsageDateLayout)
+	oldest := today.AddDate(0, 0, 1-s.retention).Format(domain.UsageDateLayout)
+	for key := range s.days {
+		if key.date < oldest {
+			delete(s.days, key)
+		}
+	}
+}
@@ -1,37 +1,454 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/logging"
 )
 
+// ProfileDemo is the profile exposing the gateway as a public demo: anonymous clients
+// only read, at a low rate per IP, and the routes needing an API key don't exist
+const ProfileDemo = "demo"
+
+// defaultTrapPaths are path segments requested by scanners looking for vulnerable
+// software, none of which the gateway serves
+const defaultTrapPaths = "wp-admin,wp-login.php,wp-content,xmlrpc.php,.env,.git,.aws,.ssh," +
+	"phpmyadmin,phpunit,cgi-bin,server-status,.DS_Store"
+
 type Config struct {
-	Server      ServerConfig
+	// Profile presets the configuration for a kind of deployment; empty applies none
+	Profile      string
+	Server       ServerConfig
 	MovieService MovieServiceConfig
+	Pagination   PaginationConfig
+	Proxy        ProxyConfig
+	Policy       PolicyConfig
+	Maintenance  MaintenanceConfig
+	Metering     MeteringConfig
+	Anomaly      AnomalyConfig
+	Abuse        AbuseConfig
+	Logging      LoggingConfig
 }
 
 type ServerConfig struct {
 	Port         string
 	ReadTimeout  int
 	WriteTimeout int
+	// RegionHeader names the header the request region is inferred from; empty disables it
+	RegionHeader string
+	// TrustedNetworks is the comma-separated list of networks, such as 10.0.0.0/8, of the
+	// internal callers whose X-Timeout-Budget-Ms header is honoured
+	TrustedNetworks string
+	// TimeoutBudgetReserveMs is the part of a caller's budget kept for the gateway to write
+	// the response
+	TimeoutBudgetReserveMs int
+	// WarmupPaths is the comma-separated list of paths requested on startup, before the
+	// gateway reports ready; empty skips warming up
+	WarmupPaths string
+	// ShutdownGraceSeconds is the time in-flight requests get to finish on shutdown before
+	// they are closed
+	ShutdownGraceSeconds int
+}
+
+// ParseWarmupPaths returns the paths requested on startup
+func (c ServerConfig) ParseWarmupPaths() ([]string, error) {
+	var paths []string
+	for _, path := range strings.Split(c.WarmupPaths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("warm-up path %q must start with /", path)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ParseTrustedNetworks parses TrustedNetworks; single addresses are accepted as networks
+// of one address
+func (c ServerConfig) ParseTrustedNetworks() ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, entry := range strings.Split(c.TrustedNetworks, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted network %q: %w", entry, err)
+			}
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		network, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted network %q: %w", entry, err)
+		}
+		networks = append(networks, network.Masked())
+	}
+	return networks, nil
 }
 
 type MovieServiceConfig struct {
 	GRPCAddress string
+	// HedgeDelayMs is the time after which an unanswered read is sent again, about the p95
+	// latency of the movie service; zero disables hedging
+	HedgeDelayMs int
+	// RetryAttempts bounds the attempts of a read answered Unavailable, the first one
+	// included
+	RetryAttempts int
+}
+
+type PaginationConfig struct {
+	DefaultPageSize int
+	MaxPageSize     int
+	MaxPage         int
+}
+
+// MaintenanceConfig sets the mode the gateway starts in; it can be switched at runtime on
+// /admin/maintenance
+type MaintenanceConfig struct {
+	// ReadOnly refuses mutations with 503 while reads are still served
+	ReadOnly bool
+	// RetryAfterSeconds is the Retry-After of refused mutations
+	RetryAfterSeconds int
+}
+
+// MeteringConfig sets where the usage events of each request made with an API key are
+// published for billing
+type MeteringConfig struct {
+	// URL is the endpoint the events are posted to; empty disables metering
+	URL string
+	// BatchSize is the number of events posted together, at least every FlushSeconds
+	BatchSize    int
+	FlushSeconds int
+	// JournalSize is the number of the latest events kept for replays
+	JournalSize int
+	// Attempts bounds the posts of a batch, the first one included
+	Attempts int
+}
+
+// Enabled reports whether the events are published
+func (c MeteringConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// AnomalyConfig tunes the detection of traffic spikes
+type AnomalyConfig struct {
+	// IntervalSeconds is the length of the intervals whose requests are counted; 0
+	// disables the detection
+	IntervalSeconds int
+	// Alpha is the weight, from 0 to 1, of the last interval in the moving average
+	Alpha float64
+	// Deviations is how many standard deviations above the average a count is a spike
+	Deviations float64
+	// MinRequests is the count below which an interval is never a spike
+	MinRequests int
+	// WarmupIntervals is the number of intervals observed before spikes are flagged
+	WarmupIntervals int
+}
+
+// AbuseConfig sets how scanners probing for well-known vulnerable paths are caught
+type AbuseConfig struct {
+	// TrapPaths is the comma-separated list of path segments, such as wp-admin or .env,
+	// that only scanners request; empty disables catching them
+	TrapPaths string
+	// BlockSeconds is how long the IP of a scanner is blocked
+	BlockSeconds int
+	// TarpitMs is how long the answers to traps and blocked IPs are held, slowing
+	// scanners down; 0 answers at once
+	TarpitMs int
+}
+
+// Traps returns the trap path segments, lowercased
+func (c AbuseConfig) Traps() []string {
+	var traps []string
+	for _, trap := range strings.Split(c.TrapPaths, ",") {
+		if trap = strings.ToLower(strings.Trim(strings.TrimSpace(trap), "/")); trap != "" {
+			traps = append(traps, trap)
+		}
+	}
+	return traps
+}
+
+// LoggingConfig sets the log level and which request log lines are written
+type LoggingConfig struct {
+	// Level is debug, info, warn or error; per-layer request details are logged at debug
+	Level string
+	// SampleRate is the fraction of successful reads logged, from 0 to 1; failed, slow
+	// and writing requests are always logged
+	SampleRate float64
+	// SlowRequestMs logs every request taking at least this long; 0 disables it
+	SlowRequestMs int
+}
+
+// Policy returns the policy applied to request log lines
+func (c LoggingConfig) Policy() logging.Policy {
+	return logging.Policy{
+		SampleRate:    c.SampleRate,
+		SlowThreshold: time.Duration(c.SlowRequestMs) * time.Millisecond,
+	}
+}
+
+type PolicyConfig struct {
+	// File is the JSON file declaring route policies; empty applies no policy
+	File string
+	// APIKeys is the comma-separated list of keys accepted on routes requiring auth
+	APIKeys string
+	// AdminAPIKeys is the comma-separated list of keys accepted on the /admin routes;
+	// client API keys are forbidden there
+	AdminAPIKeys string
+	// DownloadURLSecrets is the comma-separated list of secrets signing the URLs of job
+	// files, the first signing new URLs; empty disables signed URLs
+	DownloadURLSecrets string
+	// DownloadURLTTLSeconds is how long signed URLs stay valid
+	DownloadURLTTLSeconds int
+	// Demo is the rate limit of every client under the demo profile, nil otherwise
+	Demo *RateLimitTier
+	// DeprecationWarningKeys is the comma-separated list of the API keys whose JSON
+	// responses list the deprecated features used in _warnings; "*" warns every client
+	DeprecationWarningKeys string
+	// CamelCaseKeys is the comma-separated list of the API keys whose JSON bodies use
+	// camelCase field names unless they ask otherwise in X-Naming; "*" makes camelCase the
+	// default of every client
+	CamelCaseKeys string
+	// UsageRetentionDays is how many days of the usage of each API key are kept
+	UsageRetentionDays int
+}
+
+// Load reads the route policies, checking API keys exist when a route requires auth.
+// Under the demo profile without a policy file, DemoRoutePolicies apply
+func (c PolicyConfig) Load() (*RoutePolicies, error) {
+	if c.Demo != nil && c.File == "" {
+		policies := DemoRoutePolicies(*c.Demo)
+		if err := policies.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid demo profile: %w", err)
+		}
+		return policies, nil
+	}
+	policies, err := LoadRoutePolicies(c.File)
+	if err != nil {
+		return nil, err
+	}
+	if c.Demo != nil && policies.Default.RateLimit == "" {
+		return nil, fmt.Errorf("the demo profile requires a rate limit on the default route policy")
+	}
+
+	requiresAuth := policies.Default.Auth
+	for _, rule := range policies.Rules {
+		requiresAuth = requiresAuth || rule.Auth
+	}
+	if requiresAuth && len(c.Keys()) == 0 {
+		return nil, fmt.Errorf("route policies require auth but no API keys are configured")
+	}
+	return policies, nil
+}
+
+// Keys returns the configured API keys
+func (c PolicyConfig) Keys() []string {
+	return splitList(c.APIKeys)
+}
+
+// AdminKeys returns the configured admin API keys
+func (c PolicyConfig) AdminKeys() []string {
+	return splitList(c.AdminAPIKeys)
+}
+
+// WarningKeys returns the API keys of the clients warned of deprecations in responses
+func (c PolicyConfig) WarningKeys() []string {
+	return splitList(c.DeprecationWarningKeys)
+}
+
+// CamelKeys returns the API keys of the clients preferring camelCase field names
+func (c PolicyConfig) CamelKeys() []string {
+	return splitList(c.CamelCaseKeys)
+}
+
+// checkClientKeys checks each of the keys singling out clients is an API key or "*"
+func (c PolicyConfig) checkClientKeys(keys []string) bool {
+	for _, key := range keys {
+		if key != "*" && !slices.Contains(c.Keys(), key) {
+			return false
+		}
+	}
+	return true
+}
+
+// URLSecrets returns the secrets signing the URLs of job files
+func (c PolicyConfig) URLSecrets() []string {
+	return splitList(c.DownloadURLSecrets)
+}
+
+// splitList returns the non-empty entries of a comma-separated list
+func splitList(list string) []string {
+	var entries []string
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+type ProxyConfig struct {
+	// Routes is a comma-separated list of prefix=backend entries, such as
+	// "/api/v1/ratings=http://ratings:8080,/reviews.ReviewService/=grpc://reviews:50052"
+	Routes string
+}
+
+// ProxyRoute forwards requests whose path starts with Prefix to Backend
+type ProxyRoute struct {
+	Prefix  string
+	Backend *url.URL
+}
+
+// GRPC reports whether the backend is a gRPC server, reached over cleartext HTTP/2
+func (r ProxyRoute) GRPC() bool {
+	return r.Backend.Scheme == "grpc"
+}
+
+// ParseRoutes parses the configured proxy routes
+func (c ProxyConfig) ParseRoutes() ([]ProxyRoute, error) {
+	var routes []ProxyRoute
+	seen := make(map[string]bool)
+
+	for _, entry := range strings.Split(c.Routes, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		prefix, backend, ok := strings.Cut(entry, "=")
+		prefix, backend = strings.TrimSpace(prefix), strings.TrimSpace(backend)
+		if !ok || !strings.HasPrefix(prefix, "/") || prefix == "/" {
+			return nil, fmt.Errorf("proxy route %q must be /prefix=backend", entry)
+		}
+		if seen[prefix] {
+			return nil, fmt.Errorf("proxy route prefix %s is repeated", prefix)
+		}
+		seen[prefix] = true
+
+		target, err := url.Parse(backend)
+		if err != nil {
+			return nil, fmt.Errorf("proxy route %s: invalid backend: %w", prefix, err)
+		}
+		switch target.Scheme {
+		case "http", "https", "grpc":
+		default:
+			return nil, fmt.Errorf("proxy route %s: backend scheme must be http, https or grpc", prefix)
+		}
+		if target.Host == "" {
+			return nil, fmt.Errorf("proxy route %s: backend host is required", prefix)
+		}
+
+		routes = append(routes, ProxyRoute{Prefix: prefix, Backend: target})
+	}
+
+	return routes, nil
 }
 
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
+		Profile: getEnv("GATEWAY_PROFILE", ""),
 		Server: ServerConfig{
-			Port:         getEnv("SERVER_PORT", "8080"),
-			ReadTimeout:  getEnvAsInt("READ_TIMEOUT", 10),
-			WriteTimeout: getEnvAsInt("WRITE_TIMEOUT", 10),
+			Port:                   getEnv("SERVER_PORT", "8080"),
+			ReadTimeout:            getEnvAsInt("READ_TIMEOUT", 10),
+			WriteTimeout:           getEnvAsInt("WRITE_TIMEOUT", 10),
+			RegionHeader:           getEnv("REGION_HEADER", ""),
+			TrustedNetworks:        getEnv("TRUSTED_NETWORKS", ""),
+			TimeoutBudgetReserveMs: getEnvAsInt("TIMEOUT_BUDGET_RESERVE_MS", 5),
+			WarmupPaths:            getEnv("WARMUP_PATHS", "/api/v1/movies"),
+			ShutdownGraceSeconds:   getEnvAsInt("SHUTDOWN_GRACE_SECONDS", 30),
 		},
 		MovieService: MovieServiceConfig{
-			GRPCAddress: getEnv("MOVIE_SERVICE_GRPC_ADDRESS", "movies-service:50051"),
+			GRPCAddress:   getEnv("MOVIE_SERVICE_GRPC_ADDRESS", "movies-service:50051"),
+			HedgeDelayMs:  getEnvAsInt("MOVIE_SERVICE_HEDGE_DELAY_MS", 0),
+			RetryAttempts: getEnvAsInt("MOVIE_SERVICE_RETRY_ATTEMPTS", 3),
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
+			MaxPageSize:     getEnvAsInt("MAX_PAGE_SIZE", 100),
+			MaxPage:         getEnvAsInt("MAX_PAGE", 0),
+		},
+		Proxy: ProxyConfig{
+			Routes: getEnv("PROXY_ROUTES", ""),
+		},
+		Policy: PolicyConfig{
+			File:                   getEnv("ROUTE_POLICIES_FILE", ""),
+			APIKeys:                getEnv("API_KEYS", ""),
+			AdminAPIKeys:           getEnv("ADMIN_API_KEYS", ""),
+			DownloadURLSecrets:     getEnv("DOWNLOAD_URL_SECRETS", ""),
+			DownloadURLTTLSeconds:  getEnvAsInt("DOWNLOAD_URL_TTL_SECONDS", 900),
+			DeprecationWarningKeys: getEnv("DEPRECATION_WARNING_KEYS", ""),
+			CamelCaseKeys:          getEnv("CAMEL_CASE_KEYS", ""),
+			UsageRetentionDays:     getEnvAsInt("USAGE_RETENTION_DAYS", 90),
+		},
+		Metering: MeteringConfig{
+			URL:          getEnv("METERING_URL", ""),
+			BatchSize:    getEnvAsInt("METERING_BATCH_SIZE", 100),
+			FlushSeconds: getEnvAsInt("METERING_FLUSH_SECONDS", 5),
+			JournalSize:  getEnvAsInt("METERING_JOURNAL_SIZE", 100000),
+			Attempts:     getEnvAsInt("METERING_ATTEMPTS", 5),
+		},
+		Anomaly: AnomalyConfig{
+			IntervalSeconds: getEnvAsInt("ANOMALY_INTERVAL_SECONDS", 60),
+			Alpha:           getEnvAsFloat("ANOMALY_ALPHA", 0.2),
+			Deviations:      getEnvAsFloat("ANOMALY_DEVIATIONS", 4),
+			MinRequests:     getEnvAsInt("ANOMALY_MIN_REQUESTS", 20),
+			WarmupIntervals: getEnvAsInt("ANOMALY_WARMUP_INTERVALS", 10),
+		},
+		Abuse: AbuseConfig{
+			TrapPaths:    getEnv("ABUSE_TRAP_PATHS", defaultTrapPaths),
+			BlockSeconds: getEnvAsInt("ABUSE_BLOCK_SECONDS", 3600),
+			TarpitMs:     getEnvAsInt("ABUSE_TARPIT_MS", 2000),
 		},
+		Maintenance: MaintenanceConfig{
+			ReadOnly:          getEnvAsBool("READ_ONLY", false),
+			RetryAfterSeconds: getEnvAsInt("READ_ONLY_RETRY_AFTER_SECONDS", 300),
+		},
+		Logging: LoggingConfig{
+			Level:         getEnv("LOG_LEVEL", "info"),
+			SampleRate:    getEnvAsFloat("LOG_SAMPLE_RATE", 1),
+			SlowRequestMs: getEnvAsInt("LOG_SLOW_REQUEST_MS", 1000),
+		},
+	}
+	if cfg.Profile == ProfileDemo {
+		cfg.applyDemoProfile(RateLimitTier{
+			RequestsPerSecond: getEnvAsFloat("DEMO_REQUESTS_PER_SECOND", 1),
+			Burst:             getEnvAsInt("DEMO_BURST", 10),
+		})
+	}
+	return cfg
+}
+
+// applyDemoProfile makes the gateway safe to expose publicly, whatever else is set: it
+// starts read-only, without the API keys and secrets opening the admin and job routes,
+// which are then not found, so read-only mode cannot be switched off either. Every client
+// IP is rate-limited by tier
+func (c *Config) applyDemoProfile(tier RateLimitTier) {
+	c.Maintenance.ReadOnly = true
+	c.Policy.APIKeys = ""
+	c.Policy.AdminAPIKeys = ""
+	c.Policy.DownloadURLSecrets = ""
+	if c.Policy.DeprecationWarningKeys != "*" {
+		c.Policy.DeprecationWarningKeys = ""
 	}
+	if c.Policy.CamelCaseKeys != "*" {
+		c.Policy.CamelCaseKeys = ""
+	}
+	c.Policy.Demo = &tier
 }
 
 func getEnv(key, defaultVal string) string {
@@ -49,10 +466,117 @@ func getEnvAsInt(name string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvAsBool(name string, defaultVal bool) bool {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
+func getEnvAsFloat(name string, defaultVal float64) float64 {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	if c.Profile != "" && c.Profile != ProfileDemo {
+		return fmt.Errorf("unknown profile %q", c.Profile)
+	}
 	if c.MovieService.GRPCAddress == "" {
 		log.Fatal("Movie service GRPC address is required")
 	}
+	if c.Pagination.DefaultPageSize < 1 || c.Pagination.DefaultPageSize > c.Pagination.MaxPageSize {
+		return fmt.Errorf("default page size must be between 1 and max page size (%d)", c.Pagination.MaxPageSize)
+	}
+	if c.Pagination.MaxPage < 0 {
+		return fmt.Errorf("max page cannot be negative")
+	}
+	if _, err := c.Server.ParseTrustedNetworks(); err != nil {
+		return err
+	}
+	if _, err := c.Server.ParseWarmupPaths(); err != nil {
+		return err
+	}
+	if c.Server.ShutdownGraceSeconds < 1 {
+		return fmt.Errorf("shutdown grace period must be at least 1 second")
+	}
+	if c.Server.TimeoutBudgetReserveMs < 0 {
+		return fmt.Errorf("timeout budget reserve cannot be negative")
+	}
+	if !c.Policy.checkClientKeys(c.Policy.WarningKeys()) {
+		return fmt.Errorf("deprecation warning keys must be API keys or *")
+	}
+	if !c.Policy.checkClientKeys(c.Policy.CamelKeys()) {
+		return fmt.Errorf("camelCase keys must be API keys or *")
+	}
+	if c.Policy.UsageRetentionDays < 1 {
+		return fmt.Errorf("usage retention must be at least 1 day")
+	}
+	if c.Policy.DownloadURLTTLSeconds < 1 {
+		return fmt.Errorf("download URL TTL must be at least 1 second")
+	}
+	if c.Maintenance.RetryAfterSeconds < 1 {
+		return fmt.Errorf("read-only retry after must be at least 1 second")
+	}
+	if c.Metering.Enabled() {
+		if _, err := url.ParseRequestURI(c.Metering.URL); err != nil {
+			return fmt.Errorf("invalid metering URL: %w", err)
+		}
+		if c.Metering.BatchSize < 1 || c.Metering.FlushSeconds < 1 || c.Metering.Attempts < 1 {
+			return fmt.Errorf("metering batch size, flush interval and attempts must be at least 1")
+		}
+		if c.Metering.JournalSize < c.Metering.BatchSize {
+			return fmt.Errorf("metering journal must hold at least a batch")
+		}
+	}
+	if c.Anomaly.IntervalSeconds < 0 {
+		return fmt.Errorf("anomaly interval cannot be negative")
+	}
+	if c.Anomaly.IntervalSeconds > 0 {
+		if c.Anomaly.Alpha <= 0 || c.Anomaly.Alpha > 1 {
+			return fmt.Errorf("anomaly alpha must be above 0 and up to 1")
+		}
+		if c.Anomaly.Deviations <= 0 || c.Anomaly.MinRequests < 1 || c.Anomaly.WarmupIntervals < 1 {
+			return fmt.Errorf("anomaly deviations, minimum requests and warm-up intervals must be positive")
+		}
+	}
+	for _, trap := range c.Abuse.Traps() {
+		if strings.Contains(trap, "/") {
+			return fmt.Errorf("trap path %q must be a single path segment", trap)
+		}
+	}
+	if c.Abuse.BlockSeconds < 1 {
+		return fmt.Errorf("abuse block must be at least 1 second")
+	}
+	if c.Abuse.TarpitMs < 0 {
+		return fmt.Errorf("abuse tarpit cannot be negative")
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		return fmt.Errorf("invalid log level %q", c.Logging.Level)
+	}
+	if c.Logging.SampleRate < 0 || c.Logging.SampleRate > 1 {
+		return fmt.Errorf("log sample rate must be between 0 and 1")
+	}
+	if c.Logging.SlowRequestMs < 0 {
+		return fmt.Errorf("slow request threshold cannot be negative")
+	}
+	if c.MovieService.HedgeDelayMs < 0 {
+		return fmt.Errorf("hedge delay cannot be negative")
+	}
+	if c.MovieService.RetryAttempts < 1 {
+		return fmt.Errorf("movie service retry attempts must be at least 1")
+	}
+	if _, err := c.Proxy.ParseRoutes(); err != nil {
+		return err
+	}
+	if _, err := c.Policy.Load(); err != nil {
+		return err
+	}
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// RoutePolicies declares the middleware applied to each route, loaded from the JSON file
+// named by ROUTE_POLICIES_FILE
+type RoutePolicies struct {
+	// Default applies to routes matched by no rule
+	Default RoutePolicy `json:"default"`
+	// Rules are checked in order; the first matching rule wins
+	Rules []RouteRule `json:"rules"`
+	// RateLimits defines the tiers referenced by policies
+	RateLimits map[string]RateLimitTier `json:"rate_limits"`
+	// Timeout bounds the requests of routes whose policy sets no timeout; zero means no
+	// bound
+	Timeout Duration `json:"timeout"`
+	// Deprecations lists the deprecated routes and request fields
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+// Deprecation marks a route as deprecated, or only a query parameter or top-level JSON
+// body field of its requests. Requests using it are answered with the Deprecation and
+// Sunset headers, and counted per feature. A deprecated field of the responses is only
+// reported in the _warnings of the responses to the clients asking for warnings
+type Deprecation struct {
+	// Feature names the deprecated feature in the metrics, such as "v1-year-filter"
+	Feature string `json:"feature"`
+	// Path is the path template of the route, for the listed methods or for every method
+	// when none are listed
+	Path    string   `json:"path"`
+	Methods []string `json:"methods"`
+	// Query restricts the deprecation to requests with the query parameter
+	Query string `json:"query"`
+	// Field restricts the deprecation to requests whose JSON body has the field
+	Field string `json:"field"`
+	// ResponseField restricts the deprecation to JSON responses with the field, a path
+	// of names separated by dots that goes through arrays, such as "movies.year"
+	ResponseField string `json:"response_field"`
+	// Message replaces the generated message of the warnings
+	Message string `json:"message"`
+	// Since is when the feature was deprecated, such as "2025-06-01T00:00:00Z"
+	Since time.Time `json:"since"`
+	// Sunset is when the feature stops working; zero while undecided
+	Sunset time.Time `json:"sunset"`
+	// Link is the URL of the migration guide
+	Link string `json:"link"`
+}
+
+type RoutePolicy struct {
+	// Auth requires a valid API key
+	Auth bool `json:"auth"`
+	// RateLimit names the rate limit tier applied per client; empty means unlimited
+	RateLimit string `json:"rate_limit"`
+	// CacheTTL caches successful GET responses for the duration; zero disables caching
+	CacheTTL Duration `json:"cache_ttl"`
+	// StaleWhileRevalidate keeps serving expired responses for the duration while they
+	// are refreshed in the background
+	StaleWhileRevalidate Duration `json:"stale_while_revalidate"`
+	// StaleIfError serves expired responses for the duration after they expire when the
+	// movie service fails, instead of the error
+	StaleIfError Duration `json:"stale_if_error"`
+	// Timeout bounds the time spent handling the request, answering 504 when it runs
+	// out; zero falls back to the global timeout
+	Timeout Duration `json:"timeout"`
+}
+
+// RouteRule applies a policy to the route with the given path template, such as
+// "/api/v1/movies/{id}", for the listed methods or for every method when none are listed
+type RouteRule struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods"`
+	RoutePolicy
+}
+
+type RateLimitTier struct {
+	RequestsPerSecond float64 `json:"requests_per_second"`
+	Burst             int     `json:"burst"`
+}
+
+// validateRoute checks the path template and methods of a rule or deprecation
+func validateRoute(path string, methods []string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path must start with /")
+	}
+	for _, method := range methods {
+		switch strings.ToUpper(method) {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return fmt.Errorf("unknown method %s", method)
+		}
+	}
+	return nil
+}
+
+func (d Deprecation) validate() error {
+	if d.Feature == "" {
+		return fmt.Errorf("feature is required")
+	}
+	if err := validateRoute(d.Path, d.Methods); err != nil {
+		return err
+	}
+	set := 0
+	for _, restriction := range []string{d.Query, d.Field, d.ResponseField} {
+		if restriction != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("set at most one of query, field and response_field")
+	}
+	if d.Since.IsZero() {
+		return fmt.Errorf("since is required")
+	}
+	if !d.Sunset.IsZero() && !d.Sunset.After(d.Since) {
+		return fmt.Errorf("sunset must be after since")
+	}
+	if d.Link != "" {
+		if link, err := url.Parse(d.Link); err != nil || !link.IsAbs() {
+			return fmt.Errorf("link must be an absolute URL")
+		}
+	}
+	return nil
+}
+
+// DemoRoutePolicies are the policies of the demo profile: each client is limited to tier,
+// and the movie listing and movies are cached, so bursts of demo traffic reach the movie
+// service at most once per TTL
+func DemoRoutePolicies(tier RateLimitTier) *RoutePolicies {
+	cached := RoutePolicy{RateLimit: "demo", CacheTTL: Duration(30 * time.Second), StaleIfError: Duration(5 * time.Minute)}
+	return &RoutePolicies{
+		Default: RoutePolicy{RateLimit: "demo"},
+		Rules: []RouteRule{
+			{Path: "/api/v1/movies", Methods: []string{http.MethodGet}, RoutePolicy: cached},
+			{Path: "/api/v1/movies/{id}", Methods: []string{http.MethodGet}, RoutePolicy: cached},
+		},
+		RateLimits: map[string]RateLimitTier{"demo": tier},
+		Timeout:    Duration(10 * time.Second),
+	}
+}
+
+// Duration is a time.Duration written in JSON as a string such as "30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadRoutePolicies reads route policies from a JSON file; an empty path applies no policy
+func LoadRoutePolicies(path string) (*RoutePolicies, error) {
+	policies := &RoutePolicies{}
+	if path == "" {
+		return policies, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read route policies: %w", err)
+	}
+	if err := json.Unmarshal(data, policies); err != nil {
+		return nil, fmt.Errorf("invalid route policies: %w", err)
+	}
+	if err := policies.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid route policies: %w", err)
+	}
+	return policies, nil
+}
+
+// Validate checks that rules are well formed and reference existing rate limit tiers
+func (p *RoutePolicies) Validate() error {
+	if p.Timeout < 0 {
+		return fmt.Errorf("timeout cannot be negative")
+	}
+	for name, tier := range p.RateLimits {
+		if tier.RequestsPerSecond <= 0 || tier.Burst < 1 {
+			return fmt.Errorf("rate limit tier %s needs a positive rate and a burst of at least 1", name)
+		}
+	}
+
+	policies := []RoutePolicy{p.Default}
+	for i, rule := range p.Rules {
+		if err := validateRoute(rule.Path, rule.Methods); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		policies = append(policies, rule.RoutePolicy)
+	}
+
+	features := make(map[string]bool)
+	for i, deprecation := range p.Deprecations {
+		if err := deprecation.validate(); err != nil {
+			return fmt.Errorf("deprecation %d: %w", i+1, err)
+		}
+		if features[deprecation.Feature] {
+			return fmt.Errorf("deprecation %d: feature %s is declared twice", i+1, deprecation.Feature)
+		}
+		features[deprecation.Feature] = true
+	}
+
+	for _, policy := range policies {
+		if policy.RateLimit != "" {
+			if _, ok := p.RateLimits[policy.RateLimit]; !ok {
+				return fmt.Errorf("unknown rate limit tier %s", policy.RateLimit)
+			}
+		}
+		if policy.CacheTTL < 0 || policy.Timeout < 0 {
+			return fmt.Errorf("cache TTL and timeout cannot be negative")
+		}
+		if policy.StaleWhileRevalidate < 0 || (policy.StaleWhileRevalidate > 0 && policy.CacheTTL == 0) {
+			return fmt.Errorf("stale_while_revalidate needs a cache TTL and cannot be negative")
+		}
+		if policy.StaleIfError < 0 || (policy.StaleIfError > 0 && policy.CacheTTL == 0) {
+			return fmt.Errorf("stale_if_error needs a cache TTL and cannot be negative")
+		}
+	}
+	return nil
+}
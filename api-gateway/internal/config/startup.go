@@ -0,0 +1,100 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// CheckDependencies verifies, before the gateway dials anything, that the movie service
+// address resolves, so a typo fails with a message naming the setting to fix instead of
+// a dial timeout
+func (c *Config) CheckDependencies(ctx context.Context) error {
+	host, ok := c.MovieService.resolvableHost()
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		return fmt.Errorf("cannot resolve movie service host %q (MOVIE_SERVICE_GRPC_ADDRESS=%s): %w; check the service name and that the gateway runs on the same network",
+			host, c.MovieService.GRPCAddress, err)
+	}
+	return nil
+}
+
+// resolvableHost returns the host name of the gRPC address, for the passthrough and dns
+// schemes; addresses such as unix sockets are not resolved
+func (c MovieServiceConfig) resolvableHost() (string, bool) {
+	address := c.GRPCAddress
+	if scheme, rest, ok := strings.Cut(address, "://"); ok {
+		if scheme != "dns" && scheme != "passthrough" {
+			return "", false
+		}
+		// The authority, if any, names the DNS server: dns://8.8.8.8/host:port
+		_, address, _ = strings.Cut(rest, "/")
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if host == "" || net.ParseIP(host) != nil {
+		return "", false
+	}
+	return host, true
+}
+
+// LogSummary logs what the configuration enables, so the effective setup of an instance
+// can be read from its first log lines
+func (c *Config) LogSummary(logger *slog.Logger) {
+	policies, _ := c.Policy.Load()
+	var authRoutes, cachedRoutes int
+	for _, rule := range policies.Rules {
+		if rule.Auth {
+			authRoutes++
+		}
+		if rule.CacheTTL > 0 {
+			cachedRoutes++
+		}
+	}
+	proxyRoutes, _ := c.Proxy.ParseRoutes()
+	warmupPaths, _ := c.Server.ParseWarmupPaths()
+
+	logger.Info("Startup configuration",
+		slog.String("port", c.Server.Port),
+		slog.String("profile", c.Profile),
+		slog.Group("movie_service",
+			slog.String("address", c.MovieService.GRPCAddress),
+			slog.Bool("hedging", c.MovieService.HedgeDelayMs > 0),
+		),
+		slog.Group("auth",
+			slog.Int("api_keys", len(c.Policy.Keys())),
+			slog.Bool("default", policies.Default.Auth),
+			slog.Int("routes", authRoutes),
+			slog.Int("usage_retention_days", c.Policy.UsageRetentionDays),
+		),
+		slog.Group("cache",
+			slog.Bool("default", policies.Default.CacheTTL > 0),
+			slog.Int("routes", cachedRoutes),
+		),
+		slog.Int("rate_limit_tiers", len(policies.RateLimits)),
+		slog.Int("deprecations", len(policies.Deprecations)),
+		slog.Int("proxy_routes", len(proxyRoutes)),
+		slog.String("region_header", c.Server.RegionHeader),
+		slog.Bool("read_only", c.Maintenance.ReadOnly),
+		slog.Bool("metering", c.Metering.Enabled()),
+		slog.Int("anomaly_interval_seconds", c.Anomaly.IntervalSeconds),
+		slog.Int("abuse_traps", len(c.Abuse.Traps())),
+		slog.Int("warmup_paths", len(warmupPaths)),
+		slog.Group("logging",
+			slog.String("level", c.Logging.Level),
+			slog.Float64("sample_rate", c.Logging.SampleRate),
+			slog.Int("slow_request_ms", c.Logging.SlowRequestMs),
+		),
+	)
+}
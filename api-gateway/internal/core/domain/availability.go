@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	// ErrServiceTimeout is returned when the movie service does not answer before the
+	// request deadline
+	ErrServiceTimeout = apperr.New(apperr.Timeout, "DEADLINE_EXCEEDED", "movie service did not respond in time")
+	// ErrServiceUnavailable is returned when the movie service cannot be reached
+	ErrServiceUnavailable = apperr.New(apperr.Unavailable, "SERVICE_UNAVAILABLE", "movie service unavailable")
+)
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// Reasons a client is blocked for
+const (
+	BlockedScanner = "scanner"
+	BlockedByAdmin = "admin"
+)
+
+// BlockedClient is an IP address whose requests are refused until a time
+type BlockedClient struct {
+	IP     string `json:"ip" example:"203.0.113.7"`
+	Reason string `json:"reason" example:"scanner"`
+	// Path is the trap path a scanner requested
+	Path      string    `json:"path,omitempty" example:"/wp-admin/install.php"`
+	Note      string    `json:"note,omitempty" example:"credential stuffing"`
+	BlockedAt time.Time `json:"blocked_at"`
+	Until     time.Time `json:"until"`
+}
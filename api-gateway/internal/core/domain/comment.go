@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrCommentNotFound = apperr.New(apperr.NotFound, "COMMENT_NOT_FOUND", "comment not found")
+	ErrInvalidComment  = apperr.New(apperr.Invalid, "INVALID_COMMENT", "invalid comment data")
+	// ErrCommentDeleted is returned when replying to or flagging a deleted comment
+	ErrCommentDeleted = apperr.New(apperr.Precondition, "COMMENT_DELETED", "comment was deleted")
+	// ErrNotAwaitingModeration is returned when deciding on a comment the moderation
+	// queue no longer holds
+	ErrNotAwaitingModeration = apperr.New(apperr.Precondition, "NOT_AWAITING_MODERATION", "comment is not awaiting moderation")
+	ErrInvalidDecision       = apperr.New(apperr.Invalid, "INVALID_DECISION", "invalid moderation decision")
+)
+
+// Moderation decisions taken on queued comments
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// Comment is a message in a discussion thread of a movie
+type Comment struct {
+	ID      string `json:"id" example:"6650f1c2e4b0a1b2c3d4e5f6"`
+	MovieID int32  `json:"movie_id"`
+	// ParentID is the comment replied to; empty for comments starting a thread
+	ParentID string `json:"parent_id,omitempty"`
+	// Author and Body are empty once a moderator deleted the comment
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	Deleted   bool      `json:"deleted"`
+	FlagCount int32     `json:"flag_count"`
+	// FlagReasons are only returned to moderators
+	FlagReasons []string `json:"flag_reasons,omitempty"`
+	// Status is pending until the moderation filters ran, then approved, review or
+	// rejected; only approved comments are listed
+	Status string `json:"status" example:"approved"`
+	// ModerationReasons explain why the filters queued or rejected the comment and are
+	// only returned to moderators
+	ModerationReasons []string `json:"moderation_reasons,omitempty"`
+	// Replies lists the replies of a thread, oldest first
+	Replies []*Comment `json:"replies,omitempty"`
+}
+
+// CommentInput holds the client-provided fields of a comment
+type CommentInput struct {
+	MovieID  int32
+	ParentID string
+	Author   string
+	Body     string
+}
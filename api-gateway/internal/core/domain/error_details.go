@@ -0,0 +1,7 @@
+package domain
+
+// FieldViolation names a request field rejected by the movie service and why
+type FieldViolation struct {
+	Field       string `json:"field" example:"year"`
+	Description string `json:"description" example:"invalid year format"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrInvalidExternalID = apperr.New(apperr.Invalid, "INVALID_EXTERNAL_ID", "invalid external ID")
+	// ErrExternalIDTaken is returned when an external ID is already held by another movie
+	ErrExternalIDTaken = apperr.New(apperr.Conflict, "EXTERNAL_ID_TAKEN", "external ID already belongs to another movie")
+)
+
+// External catalogs whose identifiers are stored on movies
+const (
+	SourceIMDb = "imdb"
+	SourceTMDb = "tmdb"
+)
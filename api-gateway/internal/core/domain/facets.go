@@ -0,0 +1,17 @@
+package domain
+
+// FacetBucket is the number of movies sharing a facet value
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int32  `json:"count"`
+}
+
+// MovieFacets summarizes the movies matching a filter, for building filter UIs
+type MovieFacets struct {
+	// Years counts movies per decade, e.g. "1990s", in ascending order
+	Years []FacetBucket `json:"years"`
+	// Genres counts movies per genre, the most common first; a movie counts in each of
+	// its genres
+	Genres []FacetBucket `json:"genres"`
+	Total  int32         `json:"total"`
+}
@@ -0,0 +1,304 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var ErrInvalidFilter = apperr.New(apperr.Invalid, "INVALID_FILTER", "invalid filter")
+
+const (
+	maxFilterLength     = 1024
+	maxFilterConditions = 20
+)
+
+type FilterOperator string
+
+const (
+	OpEq       FilterOperator = "="
+	OpNe       FilterOperator = "!="
+	OpGt       FilterOperator = ">"
+	OpGte      FilterOperator = ">="
+	OpLt       FilterOperator = "<"
+	OpLte      FilterOperator = "<="
+	OpContains FilterOperator = "~"
+)
+
+// FilterExpr is a node of a parsed filter expression. Exactly one of Condition, And or Or is set.
+type FilterExpr struct {
+	Condition *FilterCondition
+	And       []*FilterExpr
+	Or        []*FilterExpr
+}
+
+type FilterCondition struct {
+	Field string
+	Op    FilterOperator
+	Value string
+}
+
+// FilterSyntaxError reports where a filter expression could not be parsed
+type FilterSyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterSyntaxError) Error() string {
+	return fmt.Sprintf("invalid filter at position %d: %s", e.Pos, e.Msg)
+}
+
+func (e *FilterSyntaxError) Unwrap() error {
+	return ErrInvalidFilter
+}
+
+// ParseFilter parses a filter expression such as `year>=2000 AND title~"star"`.
+//
+// Grammar (keywords are case-insensitive):
+//
+//	expr       = and_expr { "OR" and_expr }
+//	and_expr   = primary { "AND" primary }
+//	primary    = "(" expr ")" | comparison
+//	comparison = field operator value
+//	field      = letter { letter | digit | "_" }
+//	operator   = "=" | "!=" | ">" | ">=" | "<" | "<=" | "~"
+//	value      = quoted_string | bare_word
+//
+// Quoted strings use double quotes and accept \" and \\ escapes. A bare word is any run of
+// characters other than whitespace, parentheses, quotes and operator symbols. The "~"
+// operator matches values containing the given text. Which fields are accepted is decided
+// by the movie service.
+func ParseFilter(input string) (*FilterExpr, error) {
+	if len(input) > maxFilterLength {
+		return nil, &FilterSyntaxError{Pos: maxFilterLength, Msg: fmt.Sprintf("filter must not be longer than %d characters", maxFilterLength)}
+	}
+
+	tokens, err := tokenizeFilter(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &FilterSyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %q", tok.text)}
+	}
+	if p.conditions > maxFilterConditions {
+		return nil, &FilterSyntaxError{Pos: 0, Msg: fmt.Sprintf("filter must not have more than %d conditions", maxFilterConditions)}
+	}
+
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type filterToken struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isOperatorChar(r rune) bool {
+	return r == '=' || r == '!' || r == '>' || r == '<' || r == '~'
+}
+
+func tokenizeFilter(input string) ([]filterToken, error) {
+	var tokens []filterToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, filterToken{kind: tokenLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, filterToken{kind: tokenRParen, text: ")", pos: i})
+			i++
+
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, &FilterSyntaxError{Pos: start, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: sb.String(), pos: start})
+
+		case isOperatorChar(r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && (r == '!' || r == '>' || r == '<') {
+				op += "="
+			}
+			if op == "!" {
+				return nil, &FilterSyntaxError{Pos: start, Msg: `expected "!="`}
+			}
+			i += len(op)
+			tokens = append(tokens, filterToken{kind: tokenOperator, text: op, pos: start})
+
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !isOperatorChar(runes[i]) &&
+				runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return append(tokens, filterToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type filterParser struct {
+	tokens     []filterToken
+	pos        int
+	conditions int
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() filterToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *filterParser) parseOr() (*FilterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []*FilterExpr{left}
+	for p.isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return &FilterExpr{Or: operands}, nil
+}
+
+func (p *filterParser) parseAnd() (*FilterExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []*FilterExpr{left}
+	for p.isKeyword("AND") {
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return &FilterExpr{And: operands}, nil
+}
+
+func (p *filterParser) parsePrimary() (*FilterExpr, error) {
+	tok := p.peek()
+
+	if tok.kind == tokenLParen {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &FilterSyntaxError{Pos: closing.pos, Msg: `expected ")"`}
+		}
+		return expr, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (*FilterExpr, error) {
+	field := p.next()
+	if field.kind != tokenWord || !isFieldName(field.text) {
+		return nil, &FilterSyntaxError{Pos: field.pos, Msg: "expected field name"}
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator {
+		return nil, &FilterSyntaxError{Pos: op.pos, Msg: "expected comparison operator"}
+	}
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, &FilterSyntaxError{Pos: value.pos, Msg: "expected value"}
+	}
+
+	p.conditions++
+	return &FilterExpr{
+		Condition: &FilterCondition{
+			Field: strings.ToLower(field.text),
+			Op:    FilterOperator(op.text),
+			Value: value.text,
+		},
+	}, nil
+}
+
+func isFieldName(s string) bool {
+	for i, r := range s {
+		if !(unicode.IsLetter(r) || (i > 0 && (unicode.IsDigit(r) || r == '_'))) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+// ErrHistoryUnavailable is returned when the movie service only stores the current state of movies
+var ErrHistoryUnavailable = apperr.New(apperr.Unimplemented, "HISTORY_UNAVAILABLE", "movie history unavailable")
+
+// MovieRevision is one change of a movie with the state it produced
+type MovieRevision struct {
+	// Sequence is the position of the change in the history of the movie, starting at 1
+	Sequence      int64
+	Type          string
+	Version       int64
+	ChangedFields []string
+	Movie         *Movie
+	OccurredAt    time.Time
+}
+
+// MovieHistory lists the changes of a movie, oldest first
+type MovieHistory struct {
+	ID        int32
+	Revisions []MovieRevision
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrImportNotFound = apperr.New(apperr.NotFound, "IMPORT_NOT_FOUND", "import not found")
+	ErrInvalidImport  = apperr.New(apperr.Invalid, "INVALID_IMPORT", "invalid import")
+)
+
+// Statuses of imports
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportRow is a movie to import; rows with an ID create or replace the movie with that ID
+type ImportRow struct {
+	ID    int32
+	Input MovieInput
+}
+
+// ImportRowResult is the outcome of a row of an import
+type ImportRowResult struct {
+	Row int32 `json:"row" example:"4"`
+	// Status is "created", "updated", "invalid" or "failed"
+	Status  string `json:"status" example:"invalid"`
+	MovieID int32  `json:"movie_id,omitempty"`
+	Field   string `json:"field,omitempty" example:"year"`
+	Error   string `json:"error,omitempty" example:"invalid movie data: invalid year format"`
+}
+
+// Import is a bulk import with the report of the rows processed so far
+type Import struct {
+	ID         string            `json:"id"`
+	Status     string            `json:"status" example:"running"`
+	Total      int32             `json:"total"`
+	Processed  int32             `json:"processed"`
+	Created    int32             `json:"created"`
+	Updated    int32             `json:"updated"`
+	Invalid    int32             `json:"invalid"`
+	Failed     int32             `json:"failed"`
+	Results    []ImportRowResult `json:"results"`
+	Error      string            `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the import stopped processing rows
+func (i *Import) Done() bool {
+	return i.Status == ImportCompleted || i.Status == ImportFailed
+}
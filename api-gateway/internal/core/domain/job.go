@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrJobNotFound = apperr.New(apperr.NotFound, "JOB_NOT_FOUND", "job not found")
+	ErrInvalidJob  = apperr.New(apperr.Invalid, "INVALID_JOB", "invalid job")
+	// ErrNoJobFile is returned for the file of a job that produced none, or not yet
+	ErrNoJobFile = apperr.New(apperr.Precondition, "NO_JOB_FILE", "job has no file")
+)
+
+// Types of jobs
+const (
+	JobImport  = "import"
+	JobExport  = "export"
+	JobArchive = "archive"
+)
+
+// Job is a long-running operation run by the movie service in the background
+type Job struct {
+	ID string `json:"id"`
+	// Type is "import", "export" or "archive"
+	Type string `json:"type" example:"export"`
+	// Status is "pending", "running", "completed" or "failed", as for imports
+	Status string `json:"status" example:"running"`
+	// Priority is "high", "normal" or "low"; pending jobs of a higher priority run first
+	Priority  string `json:"priority" example:"normal"`
+	Processed int32  `json:"processed"`
+	// Total is zero while the size of the job is unknown
+	Total int32 `json:"total"`
+	// Result is specific to the type of the job; running jobs may have a partial one
+	Result json.RawMessage `json:"result,omitempty" swaggertype:"object"`
+	// File is set once the job has a file to download
+	File       bool       `json:"file"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the job stopped running
+func (j *Job) Done() bool {
+	return j.Status == ImportCompleted || j.Status == ImportFailed
+}
+
+// JobFile is the file of a completed job, read from the offset it was opened at
+type JobFile struct {
+	io.ReadCloser
+	// Size is the length of the whole file
+	Size int64
+}
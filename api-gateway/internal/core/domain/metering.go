@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MeteringEvent is the billable usage of one request made with an API key
+type MeteringEvent struct {
+	// ID identifies the event, replays included, so billing counts it once
+	ID    string `json:"id"`
+	KeyID string `json:"key_id"`
+	// Route is the route template, such as /api/v1/movies/{id}
+	Route      string    `json:"route"`
+	Method     string    `json:"method"`
+	Status     int       `json:"status"`
+	BytesIn    int64     `json:"bytes_in"`
+	BytesOut   int64     `json:"bytes_out"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type warmupContextKey struct{}
+
+// ContextAsWarmup returns a context marking the request as a warm-up of the gateway itself,
+// which is neither counted in the usage of its API key nor billed
+func ContextAsWarmup(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warmupContextKey{}, true)
+}
+
+// IsWarmup reports whether the request is a warm-up of the gateway itself
+func IsWarmup(ctx context.Context) bool {
+	warmup, _ := ctx.Value(warmupContextKey{}).(bool)
+	return warmup
+}
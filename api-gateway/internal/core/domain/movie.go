@@ -4,24 +4,74 @@ import (
 	"errors"
 	"strconv"
 	"time"
+
+	"github.com/movie-microservice/proto/apperr"
 )
 
 var (
-	ErrMovieNotFound     = errors.New("movie not found")
-	ErrInvalidMovieData  = errors.New("invalid movie data")
-	ErrMovieAlreadyExists = errors.New("movie already exists")
-	ErrInvalidYear       = errors.New("invalid year format")
+	ErrMovieNotFound     = apperr.New(apperr.NotFound, "MOVIE_NOT_FOUND", "movie not found")
+	ErrInvalidMovieData  = apperr.New(apperr.Invalid, "INVALID_MOVIE_DATA", "invalid movie data")
+	ErrMovieAlreadyExists = apperr.New(apperr.Conflict, "MOVIE_ALREADY_EXISTS", "movie already exists")
+	ErrInvalidYear       = apperr.New(apperr.Invalid, "INVALID_YEAR", "invalid year format")
+	ErrVersionMismatch   = apperr.New(apperr.Precondition, "VERSION_MISMATCH", "movie version mismatch")
 )
 
 type Movie struct {
-	ID    int32  `json:"id"`
-	Title string `json:"title"`
-	Year  string `json:"year"`
+	ID      int32
+	Title   string
+	Year    string
+	Version int64
+	// Regions lists the markets where the movie is available; empty means everywhere
+	Regions []string
+	// Awards lists the awards the movie received, e.g. "Oscar for Best Picture"
+	Awards        []string
+	Certification string
+	// IMDbID and TMDbID identify the movie in external catalogs; each is unique among movies
+	IMDbID string
+	TMDbID string
+	// Genres lists the genres of the movie, e.g. "Crime" and "Drama"
+	Genres   []string
+	Director string
+	Synopsis string
+	// RuntimeMinutes is the running time of the movie; 0 means unknown
+	RuntimeMinutes int32
+	// Archived is set when the movie was served from the archive of rarely accessed movies
+	Archived bool
+}
+
+// MovieInput holds the client-provided fields of a movie
+type MovieInput struct {
+	Title          string
+	Year           string
+	Regions        []string
+	Awards         []string
+	Certification  string
+	IMDbID         string
+	TMDbID         string
+	Genres         []string
+	Director       string
+	Synopsis       string
+	RuntimeMinutes int32
+}
+
+// MoviePatch changes some fields of a movie: those named in Paths, as in the API, take
+// their values from Input, and the others keep theirs
+type MoviePatch struct {
+	Input MovieInput
+	Paths []string
+	// ExpectedVersion, when set, is the version the movie must have to be patched
+	ExpectedVersion *int64
 }
 
 type MovieFilter struct {
 	Page  int32
 	Limit int32
+	Expr  *FilterExpr
+	// Region restricts results to movies available in the region
+	Region        string
+	Certification string
+	// SkipCount leaves the total of matching movies uncounted, reported as UncountedTotal
+	SkipCount bool
 }
 
 // NewMovie creates a new movie with validation
@@ -29,7 +79,7 @@ func NewMovie(id int32, title, year string) (*Movie, error) {
 	if title == "" {
 		return nil, errors.New("title cannot be empty")
 	}
-	
+
 	if year == "" {
 		return nil, errors.New("year cannot be empty")
 	}
@@ -62,7 +112,7 @@ func (m *Movie) Validate() error {
 	if m.Title == "" {
 		return errors.New("title cannot be empty")
 	}
-	
+
 	if m.Year == "" {
 		return errors.New("year cannot be empty")
 	}
@@ -83,7 +133,7 @@ func (m *Movie) Update(title, year string) error {
 	if title != "" {
 		m.Title = title
 	}
-	
+
 	if year != "" {
 		if len(year) != 4 {
 			return ErrInvalidYear
@@ -105,8 +155,19 @@ func (m *Movie) IsEqual(other *Movie) bool {
 // Copy creates a copy of the movie
 func (m *Movie) Copy() *Movie {
 	return &Movie{
-		ID:    m.ID,
-		Title: m.Title,
-		Year:  m.Year,
+		ID:             m.ID,
+		Title:          m.Title,
+		Year:           m.Year,
+		Version:        m.Version,
+		Regions:        append([]string(nil), m.Regions...),
+		Awards:         append([]string(nil), m.Awards...),
+		Certification:  m.Certification,
+		IMDbID:         m.IMDbID,
+		TMDbID:         m.TMDbID,
+		Genres:         append([]string(nil), m.Genres...),
+		Director:       m.Director,
+		Synopsis:       m.Synopsis,
+		RuntimeMinutes: m.RuntimeMinutes,
+		Archived:       m.Archived,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrSyncConflictNotFound = apperr.New(apperr.NotFound, "SYNC_CONFLICT_NOT_FOUND", "sync conflict not found")
+	ErrInvalidResolution    = apperr.New(apperr.Invalid, "INVALID_RESOLUTION", "invalid sync conflict resolution")
+)
+
+// Resolutions of sync conflicts
+const (
+	ResolutionKeepLocal  = "keep_local"
+	ResolutionTakeRemote = "take_remote"
+)
+
+// RemoteMovie is a movie as published by an external catalog feed
+type RemoteMovie struct {
+	Source         string   `json:"source" example:"tmdb"`
+	ExternalID     string   `json:"external_id" example:"949"`
+	Title          string   `json:"title"`
+	Year           string   `json:"year"`
+	Certification  string   `json:"certification,omitempty"`
+	Regions        []string `json:"regions,omitempty"`
+	Awards         []string `json:"awards,omitempty"`
+	Genres         []string `json:"genres,omitempty"`
+	Director       string   `json:"director,omitempty"`
+	Synopsis       string   `json:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty"`
+}
+
+// SyncConflict is a movie changed both locally and in the synced feed, awaiting a
+// decision
+type SyncConflict struct {
+	ID         string      `json:"id"`
+	MovieID    int32       `json:"movie_id"`
+	Remote     RemoteMovie `json:"remote"`
+	DetectedAt time.Time   `json:"detected_at"`
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// TraceParentHeader carries the W3C trace context of a request
+	TraceParentHeader = "traceparent"
+	// TraceStateHeader carries the vendor data of the W3C trace context, passed on as is
+	TraceStateHeader = "tracestate"
+	// B3Header carries the Zipkin B3 trace context in a single header, such as
+	// "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1"
+	B3Header = "b3"
+	// B3TraceIDHeader, B3SpanIDHeader and B3SampledHeader carry the B3 trace context in
+	// one header per field
+	B3TraceIDHeader = "X-B3-TraceId"
+	B3SpanIDHeader  = "X-B3-SpanId"
+	B3SampledHeader = "X-B3-Sampled"
+)
+
+// TraceContext identifies the trace a request belongs to and the span that sent it
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Sampled bool
+	// State is the tracestate of the caller, passed on unchanged
+	State string
+	// B3 is set when the caller sent the trace in B3 headers, so calls made downstream
+	// carry them too for meshes that only read B3
+	B3 bool
+}
+
+// NewTraceContext starts a sampled trace for a request that arrived without one
+func NewTraceContext() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8), Sampled: true}
+}
+
+// ParseTraceParent reads a traceparent header of version 00, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func ParseTraceParent(value string) (TraceContext, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || parts[0] != "00" || !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return TraceContext{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return TraceContext{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return TraceContext{TraceID: parts[1], SpanID: parts[2], Sampled: flags[0]&1 == 1}, true
+}
+
+// ParseB3 reads a single b3 header, such as
+// "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1". 64-bit trace IDs are widened to
+// 128 bits. A header without the sampling state, or with only one, leaves the trace
+// sampled unless it is "0"
+func ParseB3(value string) (TraceContext, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return TraceContext{}, false
+	}
+	sampled := ""
+	if len(parts) > 2 {
+		sampled = parts[2]
+	}
+	return ParseB3Multi(parts[0], parts[1], sampled)
+}
+
+// ParseB3Multi reads the B3 trace context sent one field per header
+func ParseB3Multi(traceID, spanID, sampled string) (TraceContext, bool) {
+	traceID, spanID = strings.ToLower(traceID), strings.ToLower(spanID)
+	if isHex(traceID, 16) {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) {
+		return TraceContext{}, false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return TraceContext{}, false
+	}
+	switch sampled {
+	case "", "0", "1", "d", "true", "false":
+	default:
+		return TraceContext{}, false
+	}
+	return TraceContext{TraceID: traceID, SpanID: spanID, Sampled: sampled != "0" && sampled != "false", B3: true}, true
+}
+
+// Child returns the context of a span started under t, in the same trace
+func (t TraceContext) Child() TraceContext {
+	return TraceContext{TraceID: t.TraceID, SpanID: randomHex(8), Sampled: t.Sampled, State: t.State, B3: t.B3}
+}
+
+// Propagate calls set with each header passing t on to a downstream call: traceparent,
+// then tracestate and b3 when the caller sent them
+func (t TraceContext) Propagate(set func(key, value string)) {
+	set(TraceParentHeader, t.String())
+	if t.State != "" {
+		set(TraceStateHeader, t.State)
+	}
+	if t.B3 {
+		sampled := "0"
+		if t.Sampled {
+			sampled = "1"
+		}
+		set(B3Header, t.TraceID+"-"+t.SpanID+"-"+sampled)
+	}
+}
+
+// String formats t as a traceparent header value
+func (t TraceContext) String() string {
+	flags := "00"
+	if t.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", t.TraceID, t.SpanID, flags)
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns a context carrying the trace context of the request
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, trace)
+}
+
+// TraceFromContext returns the trace context of the request, if any
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return trace, ok
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isHex reports whether s has n lowercase hex digits, as the trace context requires
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// UsageDateLayout is the layout of the dates of daily usage, in UTC
+const UsageDateLayout = "2006-01-02"
+
+// UsageCounts is the traffic of an API key over a period
+type UsageCounts struct {
+	Requests     int64 `json:"requests"`
+	ClientErrors int64 `json:"client_errors"`
+	ServerErrors int64 `json:"server_errors"`
+	BytesIn      int64 `json:"bytes_in"`
+	BytesOut     int64 `json:"bytes_out"`
+}
+
+// Add adds the counts of other
+func (c *UsageCounts) Add(other UsageCounts) {
+	c.Requests += other.Requests
+	c.ClientErrors += other.ClientErrors
+	c.ServerErrors += other.ServerErrors
+	c.BytesIn += other.BytesIn
+	c.BytesOut += other.BytesOut
+}
+
+// ErrorRate is the fraction of the requests answered with a 4xx or 5xx status
+func (c UsageCounts) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.ClientErrors+c.ServerErrors) / float64(c.Requests)
+}
+
+// DailyUsage is the traffic of an API key on a UTC day
+type DailyUsage struct {
+	Date string
+	UsageCounts
+}
+
+// APIKeyID identifies an API key in usage reports without revealing it: the first 12
+// hex digits of its SHA-256
+func APIKeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
+}
+
+type apiKeyIDContextKey struct{}
+
+// ContextWithAPIKeyID returns a context carrying the ID of the valid API key of the request
+func ContextWithAPIKeyID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, apiKeyIDContextKey{}, id)
+}
+
+// APIKeyIDFromContext returns the ID of the valid API key of the request, if any
+func APIKeyIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDContextKey{}).(string)
+	return id
+}
@@ -0,0 +1,16 @@
+package ports
+
+import "github.com/movie-microservice/api-gateway/internal/core/domain"
+
+// BlocklistPort keeps the IP addresses whose requests are refused. IPs are in the form
+// of netip.Addr.String, and entries past their Until are dropped
+type BlocklistPort interface {
+	// Block adds the client, replacing the entry of its IP
+	Block(client domain.BlockedClient)
+	// Unblock removes the entry of ip, reporting whether there was one
+	Unblock(ip string) bool
+	// Blocked returns the entry of ip, if it is blocked
+	Blocked(ip string) (domain.BlockedClient, bool)
+	// List returns the blocked clients, the latest first
+	List() []domain.BlockedClient
+}
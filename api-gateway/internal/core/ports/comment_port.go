@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// CommentServicePort defines the contract for external comment service communication
+type CommentServicePort interface {
+	ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error)
+	CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error)
+	FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error)
+	ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	DeleteComment(ctx context.Context, id string) error
+	DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error)
+	ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	DecideModeration(ctx context.Context, id, decision string) (*domain.Comment, error)
+}
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// ImportServicePort defines the contract for bulk imports run by the movie service
+type ImportServicePort interface {
+	// StartImport returns the pending import of the rows, processed in the background
+	StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error)
+	GetImport(ctx context.Context, id string) (*domain.Import, error)
+}
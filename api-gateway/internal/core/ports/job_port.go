@@ -0,0 +1,20 @@
+package ports
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// JobServicePort defines the contract for the jobs run by the movie service
+type JobServicePort interface {
+	// SubmitJob returns the pending job, run in the background; an empty priority uses
+	// the default of the type
+	SubmitJob(ctx context.Context, jobType, priority string, params json.RawMessage) (*domain.Job, error)
+	GetJob(ctx context.Context, id string) (*domain.Job, error)
+	// OpenJobFile returns the file of a completed job read from offset, failing before
+	// any of it is read when the job has none. Negative offsets count from the end, and
+	// offsets past either end are clamped to the file
+	OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error)
+}
@@ -0,0 +1,23 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// MeteringPort records the billable usage of the requests made with an API key
+type MeteringPort interface {
+	Meter(event domain.MeteringEvent)
+	// Replay publishes again the events kept that occurred from from until to, of the key
+	// with keyID or of every key when it is empty, returning how many were published and
+	// when the events kept start
+	Replay(ctx context.Context, from, to time.Time, keyID string) (int, time.Time, error)
+}
+
+// MeteringPublisherPort delivers metering events to the event bus read by billing. An
+// event may be delivered more than once, such as when replayed, and keeps its ID
+type MeteringPublisherPort interface {
+	Publish(ctx context.Context, events []domain.MeteringEvent) error
+}
@@ -8,16 +8,33 @@ import (
 
 // MovieServicePort defines the contract for external movie service communication
 type MovieServicePort interface {
-	GetMovies(ctx context.Context, page, limit int32) ([]*domain.Movie, int32, error)
+	GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error)
 	GetMovie(ctx context.Context, id int32) (*domain.Movie, error)
-	CreateMovie(ctx context.Context, title, year string) (*domain.Movie, error)
+	// GetMovieByExternalID returns the movie with the ID in an external catalog, such as
+	// domain.SourceIMDb
+	GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error)
+	CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error)
+	UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error)
+	// UpdateMovie replaces an existing movie, never creating it
+	UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error)
+	UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error)
+	PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error)
 	DeleteMovie(ctx context.Context, id int32) error
+	DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error
+	GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error)
+	GetMovieHistory(ctx context.Context, id int32) (*domain.MovieHistory, error)
+	GetMovieVersion(ctx context.Context, id int32) (int64, error)
 }
 
 // MovieHandler defines HTTP handler contract
 type MovieHandler interface {
 	GetMovies(w http.ResponseWriter, r *http.Request)
+	GetMovieFacets(w http.ResponseWriter, r *http.Request)
 	GetMovie(w http.ResponseWriter, r *http.Request)
+	GetMovieByExternalID(w http.ResponseWriter, r *http.Request)
+	GetMovieHistory(w http.ResponseWriter, r *http.Request)
 	CreateMovie(w http.ResponseWriter, r *http.Request)
+	UpsertMovie(w http.ResponseWriter, r *http.Request)
+	PatchMovie(w http.ResponseWriter, r *http.Request)
 	DeleteMovie(w http.ResponseWriter, r *http.Request)
 }
\ No newline at end of file
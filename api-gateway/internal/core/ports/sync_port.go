@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// SyncServicePort defines the contract for the resolution of catalog sync conflicts
+type SyncServicePort interface {
+	ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error)
+	// ResolveSyncConflict returns the local movie after the resolution, nil when the kept
+	// local movie was deleted
+	ResolveSyncConflict(ctx context.Context, id, resolution string) (*domain.Movie, error)
+}
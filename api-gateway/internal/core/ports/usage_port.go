@@ -0,0 +1,16 @@
+package ports
+
+import (
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+// UsageStorePort aggregates the traffic of each API key into daily rollups
+type UsageStorePort interface {
+	// Record adds the counts of a request made at a time by the key with the ID
+	Record(keyID string, at time.Time, counts domain.UsageCounts)
+	// Daily returns the usage of the key on each UTC day from from to to, both included,
+	// with zero counts on the days without traffic or past the retention
+	Daily(keyID string, from, to time.Time) []domain.DailyUsage
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type CommentService struct {
+	commentPort ports.CommentServicePort
+	pagination  domain.Pagination
+	logger      *slog.Logger
+}
+
+func NewCommentService(commentPort ports.CommentServicePort, pagination domain.Pagination, logger *slog.Logger) *CommentService {
+	return &CommentService{
+		commentPort: commentPort,
+		pagination:  pagination,
+		logger:      logger,
+	}
+}
+
+func (s *CommentService) ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Listing comments", "movie_id", movieID, "page", page, "limit", limit)
+
+	if movieID <= 0 {
+		return nil, 0, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, movieID)
+	}
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments, total, err := s.commentPort.ListComments(ctx, movieID, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to list comments", "movie_id", movieID, "error", err)
+		return nil, 0, fmt.Errorf("failed to list comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Creating comment", "movie_id", input.MovieID, "parent_id", input.ParentID)
+
+	if input.MovieID <= 0 {
+		return nil, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, input.MovieID)
+	}
+	if input.Author == "" || input.Body == "" {
+		return nil, fmt.Errorf("%w: author and body are required", domain.ErrInvalidComment)
+	}
+
+	comment, err := s.commentPort.CreateComment(ctx, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to create comment", "movie_id", input.MovieID, "error", err)
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return comment, nil
+}
+
+func (s *CommentService) FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Flagging comment", "id", id)
+
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", domain.ErrInvalidComment)
+	}
+
+	comment, err := s.commentPort.FlagComment(ctx, id, reason)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to flag comment", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to flag comment: %w", err)
+	}
+	return comment, nil
+}
+
+func (s *CommentService) ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments, total, err := s.commentPort.ListFlaggedComments(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to list flagged comments", "error", err)
+		return nil, 0, fmt.Errorf("failed to list flagged comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, id string) error {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Deleting comment", "id", id)
+
+	if err := s.commentPort.DeleteComment(ctx, id); err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to delete comment", "id", id, "error", err)
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+func (s *CommentService) DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Dismissing comment flags", "id", id)
+
+	comment, err := s.commentPort.DismissCommentFlags(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to dismiss comment flags", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to dismiss comment flags: %w", err)
+	}
+	return comment, nil
+}
+
+func (s *CommentService) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments, total, err := s.commentPort.ListModerationQueue(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to list moderation queue", "error", err)
+		return nil, 0, fmt.Errorf("failed to list moderation queue: %w", err)
+	}
+	return comments, total, nil
+}
+
+func (s *CommentService) DecideModeration(ctx context.Context, id, decision string) (*domain.Comment, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Deciding moderation", "id", id, "decision", decision)
+
+	if decision != domain.DecisionApprove && decision != domain.DecisionReject {
+		return nil, fmt.Errorf("%w: decision must be %q or %q", domain.ErrInvalidDecision, domain.DecisionApprove, domain.DecisionReject)
+	}
+
+	comment, err := s.commentPort.DecideModeration(ctx, id, decision)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to decide moderation", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to decide moderation: %w", err)
+	}
+	return comment, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type ImportService struct {
+	importPort ports.ImportServicePort
+	logger     *slog.Logger
+}
+
+func NewImportService(importPort ports.ImportServicePort, logger *slog.Logger) *ImportService {
+	return &ImportService{
+		importPort: importPort,
+		logger:     logger,
+	}
+}
+
+func (s *ImportService) StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Starting import", "rows", len(rows))
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: movies cannot be empty", domain.ErrInvalidImport)
+	}
+
+	imp, err := s.importPort.StartImport(ctx, rows)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to start import", "rows", len(rows), "error", err)
+		return nil, fmt.Errorf("failed to start import: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("API Gateway: Import started", "id", imp.ID, "rows", imp.Total)
+	return imp, nil
+}
+
+func (s *ImportService) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	imp, err := s.importPort.GetImport(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get import", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return imp, nil
+}
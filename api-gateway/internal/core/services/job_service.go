@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type JobService struct {
+	jobPort ports.JobServicePort
+	logger  *slog.Logger
+}
+
+func NewJobService(jobPort ports.JobServicePort, logger *slog.Logger) *JobService {
+	return &JobService{
+		jobPort: jobPort,
+		logger:  logger,
+	}
+}
+
+func (s *JobService) SubmitJob(ctx context.Context, jobType, priority string, params json.RawMessage) (*domain.Job, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Submitting job", "type", jobType, "priority", priority)
+
+	if jobType == "" {
+		return nil, fmt.Errorf("%w: type cannot be empty", domain.ErrInvalidJob)
+	}
+
+	job, err := s.jobPort.SubmitJob(ctx, jobType, priority, params)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to submit job", "type", jobType, "error", err)
+		return nil, fmt.Errorf("failed to submit job: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("API Gateway: Job submitted", "id", job.ID, "type", job.Type, "priority", job.Priority)
+	return job, nil
+}
+
+func (s *JobService) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	job, err := s.jobPort.GetJob(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get job", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+func (s *JobService) OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error) {
+	file, err := s.jobPort.OpenJobFile(ctx, id, offset)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to open job file", "id", id, "offset", offset, "error", err)
+		return nil, fmt.Errorf("failed to open job file: %w", err)
+	}
+	return file, nil
+}
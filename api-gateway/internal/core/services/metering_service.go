@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// finalFlushTimeout bounds the publication of the events pending on shutdown
+const finalFlushTimeout = 10 * time.Second
+
+// MeteringOptions tune how metering events are batched, kept and published
+type MeteringOptions struct {
+	// BatchSize is the number of events published together; a full batch is published
+	// without waiting for FlushInterval
+	BatchSize     int
+	FlushInterval time.Duration
+	// JournalSize is the number of the latest events kept for replays
+	JournalSize int
+	// Retry is the policy of each publication
+	Retry retry.Policy
+}
+
+// MeteringService gives the usage of each request an event ID and publishes the events
+// in batches, in the background. The latest events are kept in a journal, so those billing
+// missed, such as after the event bus was down for longer than the retries, are published
+// again with the same IDs by Replay
+type MeteringService struct {
+	publisher ports.MeteringPublisherPort
+	opts      MeteringOptions
+	// instance prefixes the event IDs, unique to this process so replicas and restarts
+	// never reuse an ID
+	instance string
+	clock    clock.Clock
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	sequence uint64
+	journal  []domain.MeteringEvent
+	pending  []domain.MeteringEvent
+	full     chan struct{}
+}
+
+func NewMeteringService(publisher ports.MeteringPublisherPort, opts MeteringOptions, clk clock.Clock, logger *slog.Logger) *MeteringService {
+	instance := make([]byte, 4)
+	rand.Read(instance)
+	return &MeteringService{
+		publisher: publisher,
+		opts:      opts,
+		instance:  hex.EncodeToString(instance),
+		clock:     clk,
+		logger:    logger,
+		full:      make(chan struct{}, 1),
+	}
+}
+
+// Meter journals the event with a new ID and queues it for publication. Events beyond the
+// journal still waiting to be published are dropped, oldest first
+func (s *MeteringService) Meter(event domain.MeteringEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	event.ID = fmt.Sprintf("%s-%012d", s.instance, s.sequence)
+	s.journal = append(s.journal, event)
+	if excess := len(s.journal) - s.opts.JournalSize; excess > 0 {
+		s.journal = s.journal[excess:]
+	}
+	s.pending = append(s.pending, event)
+	if excess := len(s.pending) - s.opts.JournalSize; excess > 0 {
+		s.logger.Warn("Dropped metering events", "count", excess, "first_id", s.pending[0].ID)
+		s.pending = s.pending[excess:]
+	}
+	if len(s.pending) >= s.opts.BatchSize {
+		select {
+		case s.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run publishes the pending events every flush interval, and as soon as a batch is full,
+// until ctx is done; the events still pending are then published before it returns.
+// Publications are not cut short by ctx, so stopping never drops a batch midway
+func (s *MeteringService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalFlushTimeout)
+			defer cancel()
+			s.flush(flushCtx)
+			return
+		case <-ticker.C:
+		case <-s.full:
+		}
+		s.flush(context.WithoutCancel(ctx))
+	}
+}
+
+// flush publishes the pending events in batches. Batches still failing after the retries
+// are dropped from the queue but stay in the journal, logged so they can be replayed
+func (s *MeteringService) flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	for len(pending) > 0 {
+		batch := pending[:min(len(pending), s.opts.BatchSize)]
+		pending = pending[len(batch):]
+		if err := s.publish(ctx, batch); err != nil {
+			s.logger.Error("Failed to publish metering events", "count", len(batch),
+				"first_id", batch[0].ID, "from", batch[0].OccurredAt, "to", batch[len(batch)-1].OccurredAt, "error", err)
+		}
+	}
+}
+
+func (s *MeteringService) publish(ctx context.Context, batch []domain.MeteringEvent) error {
+	return s.opts.Retry.Do(ctx, func(ctx context.Context) error {
+		return s.publisher.Publish(ctx, batch)
+	})
+}
+
+// Replay publishes again the journaled events that occurred from from until to, of the
+// key with keyID or of every key when it is empty, returning how many were published and
+// when the journal starts. Events before that start are no longer kept
+func (s *MeteringService) Replay(ctx context.Context, from, to time.Time, keyID string) (int, time.Time, error) {
+	s.mu.Lock()
+	var start time.Time
+	if len(s.journal) > 0 {
+		start = s.journal[0].OccurredAt
+	}
+	var events []domain.MeteringEvent
+	for _, event := range s.journal {
+		if event.OccurredAt.Before(from) || event.OccurredAt.After(to) || (keyID != "" && event.KeyID != keyID) {
+			continue
+		}
+		events = append(events, event)
+	}
+	s.mu.Unlock()
+
+	for published := 0; published < len(events); {
+		batch := events[published:min(len(events), published+s.opts.BatchSize)]
+		if err := s.publish(ctx, batch); err != nil {
+			return published, start, fmt.Errorf("failed to replay metering events from %s: %w", batch[0].ID, err)
+		}
+		published += len(batch)
+	}
+	return len(events), start, nil
+}
@@ -7,87 +7,260 @@ import (
 
 	"github.com/movie-microservice/api-gateway/internal/core/domain"
 	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
 )
 
 type MovieService struct {
-	moviePort ports.MovieServicePort
-	logger    *slog.Logger
+	moviePort  ports.MovieServicePort
+	pagination domain.Pagination
+	logger     *slog.Logger
 }
 
-func NewMovieService(moviePort ports.MovieServicePort, logger *slog.Logger) *MovieService {
+func NewMovieService(moviePort ports.MovieServicePort, pagination domain.Pagination, logger *slog.Logger) *MovieService {
 	return &MovieService{
-		moviePort: moviePort,
-		logger:    logger,
+		moviePort:  moviePort,
+		pagination: pagination,
+		logger:     logger,
 	}
 }
 
-func (s *MovieService) GetMovies(ctx context.Context, page, limit int32) ([]*domain.Movie, int32, error) {
-	s.logger.Info("API Gateway: Getting movies", "page", page, "limit", limit)
+func (s *MovieService) GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Getting movies", "page", filter.Page, "limit", filter.Limit)
 
 	// Validate parameters
-	if page < 1 {
-		page = 1
+	page, limit, err := s.pagination.Normalize(filter.Page, filter.Limit)
+	if err != nil {
+		return nil, 0, err
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+	filter.Page, filter.Limit = page, limit
+	if filter.Region, err = normalizeRegionFilter(filter.Region); err != nil {
+		return nil, 0, err
 	}
 
-	movies, total, err := s.moviePort.GetMovies(ctx, page, limit)
+	movies, total, err := s.moviePort.GetMovies(ctx, filter)
 	if err != nil {
-		s.logger.Error("API Gateway: Failed to get movies", "error", err)
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get movies", "error", err)
 		return nil, 0, fmt.Errorf("failed to get movies: %w", err)
 	}
 
-	s.logger.Info("API Gateway: Successfully retrieved movies", "count", len(movies), "total", total)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully retrieved movies", "count", len(movies), "total", total)
 	return movies, total, nil
 }
 
 func (s *MovieService) GetMovie(ctx context.Context, id int32) (*domain.Movie, error) {
-	s.logger.Info("API Gateway: Getting movie by ID", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Getting movie by ID", "id", id)
 
 	if id <= 0 {
-		return nil, fmt.Errorf("invalid movie ID: %d", id)
+		return nil, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
 	}
 
 	movie, err := s.moviePort.GetMovie(ctx, id)
 	if err != nil {
-		s.logger.Error("API Gateway: Failed to get movie", "id", id, "error", err)
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get movie", "id", id, "error", err)
 		return nil, fmt.Errorf("failed to get movie: %w", err)
 	}
 
-	s.logger.Info("API Gateway: Successfully retrieved movie", "id", id, "title", movie.Title)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully retrieved movie", "id", id, "title", movie.Title)
+	return movie, nil
+}
+
+func (s *MovieService) GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Getting movie by external ID", "source", source, "external_id", id)
+
+	if source != domain.SourceIMDb && source != domain.SourceTMDb {
+		return nil, fmt.Errorf("%w: source must be %q or %q", domain.ErrInvalidExternalID, domain.SourceIMDb, domain.SourceTMDb)
+	}
+
+	movie, err := s.moviePort.GetMovieByExternalID(ctx, source, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get movie by external ID", "source", source, "external_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie by external ID: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully retrieved movie by external ID", "id", movie.ID, "title", movie.Title)
 	return movie, nil
 }
 
-func (s *MovieService) CreateMovie(ctx context.Context, title, year string) (*domain.Movie, error) {
-	s.logger.Info("API Gateway: Creating movie", "title", title, "year", year)
+func (s *MovieService) CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Creating movie", "title", input.Title, "year", input.Year)
 
-	if title == "" || year == "" {
-		return nil, fmt.Errorf("title and year are required")
+	if input.Title == "" || input.Year == "" {
+		return nil, fmt.Errorf("%w: title and year are required", domain.ErrInvalidMovieData)
 	}
 
-	movie, err := s.moviePort.CreateMovie(ctx, title, year)
+	movie, err := s.moviePort.CreateMovie(ctx, input)
 	if err != nil {
-		s.logger.Error("API Gateway: Failed to create movie", "title", title, "year", year, "error", err)
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to create movie", "title", input.Title, "year", input.Year, "error", err)
 		return nil, fmt.Errorf("failed to create movie: %w", err)
 	}
 
-	s.logger.Info("API Gateway: Successfully created movie", "id", movie.ID, "title", movie.Title)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully created movie", "id", movie.ID, "title", movie.Title)
+	return movie, nil
+}
+
+func (s *MovieService) UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Upserting movie", "id", id, "title", input.Title, "year", input.Year)
+
+	if id <= 0 {
+		return nil, false, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
+	}
+	if input.Title == "" || input.Year == "" {
+		return nil, false, fmt.Errorf("%w: title and year are required", domain.ErrInvalidMovieData)
+	}
+
+	movie, created, err := s.moviePort.UpsertMovie(ctx, id, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to upsert movie", "id", id, "error", err)
+		return nil, false, fmt.Errorf("failed to upsert movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully upserted movie", "id", movie.ID, "created", created)
+	return movie, created, nil
+}
+
+func (s *MovieService) UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Updating movie", "id", id)
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
+	}
+	if input.Title == "" || input.Year == "" {
+		return nil, fmt.Errorf("%w: title and year are required", domain.ErrInvalidMovieData)
+	}
+
+	movie, err := s.moviePort.UpdateMovie(ctx, id, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to update movie", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to update movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully updated movie", "id", movie.ID, "version", movie.Version)
+	return movie, nil
+}
+
+func (s *MovieService) UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Updating movie if version matches", "id", id, "version", version)
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
+	}
+	if input.Title == "" || input.Year == "" {
+		return nil, fmt.Errorf("%w: title and year are required", domain.ErrInvalidMovieData)
+	}
+
+	movie, err := s.moviePort.UpdateMovieIfVersion(ctx, id, input, version)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to update movie", "id", id, "version", version, "error", err)
+		return nil, fmt.Errorf("failed to update movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully updated movie", "id", movie.ID, "version", movie.Version)
+	return movie, nil
+}
+
+func (s *MovieService) PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Patching movie", "id", id, "paths", patch.Paths)
+
+	if id <= 0 {
+		return nil, fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
+	}
+	if len(patch.Paths) == 0 {
+		return nil, fmt.Errorf("%w: the patch must set at least one field", domain.ErrInvalidMovieData)
+	}
+
+	movie, err := s.moviePort.PatchMovie(ctx, id, patch)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to patch movie", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to patch movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully patched movie", "id", movie.ID, "version", movie.Version)
 	return movie, nil
 }
 
 func (s *MovieService) DeleteMovie(ctx context.Context, id int32) error {
-	s.logger.Info("API Gateway: Deleting movie", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Deleting movie", "id", id)
 
 	if id <= 0 {
-		return fmt.Errorf("invalid movie ID: %d", id)
+		return fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
 	}
 
 	if err := s.moviePort.DeleteMovie(ctx, id); err != nil {
-		s.logger.Error("API Gateway: Failed to delete movie", "id", id, "error", err)
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to delete movie", "id", id, "error", err)
 		return fmt.Errorf("failed to delete movie: %w", err)
 	}
 
-	s.logger.Info("API Gateway: Successfully deleted movie", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully deleted movie", "id", id)
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *MovieService) DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Deleting movie if version matches", "id", id, "version", version)
+
+	if id <= 0 {
+		return fmt.Errorf("%w: invalid movie ID %d", domain.ErrInvalidMovieData, id)
+	}
+
+	if err := s.moviePort.DeleteMovieIfVersion(ctx, id, version); err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to delete movie", "id", id, "version", version, "error", err)
+		return fmt.Errorf("failed to delete movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully deleted movie", "id", id, "version", version)
+	return nil
+}
+
+func (s *MovieService) GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Getting movie facets")
+
+	var err error
+	if filter.Region, err = normalizeRegionFilter(filter.Region); err != nil {
+		return nil, err
+	}
+
+	facets, err := s.moviePort.GetMovieFacets(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get movie facets", "error", err)
+		return nil, fmt.Errorf("failed to get movie facets: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully retrieved movie facets", "total", facets.Total)
+	return facets, nil
+}
+
+func (s *MovieService) GetMovieHistory(ctx context.Context, id int32) (*domain.MovieHistory, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Getting movie history", "id", id)
+
+	if id <= 0 {
+		return nil, domain.ErrInvalidMovieData
+	}
+
+	history, err := s.moviePort.GetMovieHistory(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to get movie history", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie history: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Successfully retrieved movie history", "id", id, "revisions", len(history.Revisions))
+	return history, nil
+}
+
+// GetMovieVersion returns the current version of the movie, which is cheaper than getting it
+func (s *MovieService) GetMovieVersion(ctx context.Context, id int32) (int64, error) {
+	if id <= 0 {
+		return 0, domain.ErrInvalidMovieData
+	}
+
+	version, err := s.moviePort.GetMovieVersion(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get movie version: %w", err)
+	}
+	return version, nil
+}
+
+func normalizeRegionFilter(region string) (string, error) {
+	if region == "" {
+		return "", nil
+	}
+	return domain.NormalizeRegion(region)
+}
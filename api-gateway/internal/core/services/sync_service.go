@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/ports"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+type SyncService struct {
+	syncPort   ports.SyncServicePort
+	pagination domain.Pagination
+	logger     *slog.Logger
+}
+
+func NewSyncService(syncPort ports.SyncServicePort, pagination domain.Pagination, logger *slog.Logger) *SyncService {
+	return &SyncService{
+		syncPort:   syncPort,
+		pagination: pagination,
+		logger:     logger,
+	}
+}
+
+func (s *SyncService) ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conflicts, total, err := s.syncPort.ListSyncConflicts(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to list sync conflicts", "error", err)
+		return nil, 0, fmt.Errorf("failed to list sync conflicts: %w", err)
+	}
+	return conflicts, total, nil
+}
+
+func (s *SyncService) ResolveSyncConflict(ctx context.Context, id, resolution string) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("API Gateway: Resolving sync conflict", "id", id, "resolution", resolution)
+
+	if resolution != domain.ResolutionKeepLocal && resolution != domain.ResolutionTakeRemote {
+		return nil, fmt.Errorf("%w: resolution must be %q or %q", domain.ErrInvalidResolution, domain.ResolutionKeepLocal, domain.ResolutionTakeRemote)
+	}
+
+	movie, err := s.syncPort.ResolveSyncConflict(ctx, id, resolution)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("API Gateway: Failed to resolve sync conflict", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to resolve sync conflict: %w", err)
+	}
+	return movie, nil
+}
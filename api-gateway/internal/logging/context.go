@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a context carrying the logger of a request, already holding the
+// attributes identifying the request, so every log line written for it has them
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request ctx belongs to, or fallback outside of
+// requests, such as in scheduled jobs
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// With returns a context whose request logger also has the attributes args, given as to
+// slog.Logger.With, for the code called with it
+func With(ctx context.Context, fallback *slog.Logger, args ...any) context.Context {
+	return NewContext(ctx, FromContext(ctx, fallback).With(args...))
+}
@@ -0,0 +1,44 @@
+// Package logging decides which per-request log lines are written, so each hop logs one
+// canonical line per request without flooding the logs with successful reads
+package logging
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Policy logs every failed, slow or writing request and a sample of the successful reads
+type Policy struct {
+	// SampleRate is the fraction of successful reads logged, from 0 to 1
+	SampleRate float64
+	// SlowThreshold logs every request taking at least this long; zero disables it
+	SlowThreshold time.Duration
+}
+
+// Decision is the outcome of the policy for one request
+type Decision struct {
+	// Log reports whether the request log line is written
+	Log bool
+	// Sampled reports whether the line was kept by sampling, so it stands for
+	// 1/SampleRate requests when counting
+	Sampled bool
+}
+
+// Decide applies the policy to a request that read or wrote data, failed or not, and
+// took duration
+func (p Policy) Decide(read, failed bool, duration time.Duration) Decision {
+	switch {
+	case failed, !read:
+		return Decision{Log: true}
+	case p.SlowThreshold > 0 && duration >= p.SlowThreshold:
+		return Decision{Log: true}
+	case p.SampleRate >= 1:
+		return Decision{Log: true}
+	case p.SampleRate <= 0:
+		return Decision{}
+	}
+	if rand.Float64() < p.SampleRate {
+		return Decision{Log: true, Sampled: true}
+	}
+	return Decision{}
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/blocklist"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestAbuse(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))
+	keys := []string{"secret"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	blocked := blocklist.NewMemory(clk)
+	blocklistHandler := handlers.NewBlocklistHandler(blocked, clk)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	blocks := router.PathPrefix("/admin/blocklist").Subrouter()
+	blocks.Use(middleware.AdminOnly(keys, nil))
+	blocks.HandleFunc("", blocklistHandler.GetBlocklist).Methods("GET")
+	blocks.HandleFunc("", blocklistHandler.BlockClient).Methods("POST")
+	blocks.HandleFunc("/{ip}", blocklistHandler.UnblockClient).Methods("DELETE")
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	handler := middleware.NewAbuse(blocked, []string{"wp-admin", ".env"}, time.Hour, 0, trusted, clk, logger).Middleware(router)
+
+	send := func(method, target, ip, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.RemoteAddr = ip + ":41234"
+		req.Header.Set("X-API-Key", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Traps are path segments, matched anywhere and in any case
+	for ip, target := range map[string]string{"203.0.113.7": "/wp-admin/install.php", "203.0.113.8": "/api/.ENV"} {
+		if rec := send("GET", target, ip, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s status = %d, want 404", target, rec.Code)
+		}
+	}
+	if rec := send("GET", "/api/v1/movies", "203.0.113.7", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("scanner status = %d, want 403 once blocked", rec.Code)
+	}
+	if rec := send("GET", "/api/v1/movies?q=.env", "198.51.100.1", ""); rec.Code != http.StatusOK {
+		t.Errorf("query mentioning a trap status = %d, want 200", rec.Code)
+	}
+	send("GET", "/.env", "10.1.2.3", "")
+	if _, ok := blocked.Blocked("10.1.2.3"); ok {
+		t.Error("trusted caller blocked")
+	}
+	if !blocked.Unblock("203.0.113.8") {
+		t.Error("scanner of /api/.ENV not blocked")
+	}
+
+	// Blocks are listed, set and lifted by hand
+	rec := send("POST", "/admin/blocklist", "198.51.100.1", `{"ip":"::ffff:192.0.2.9","seconds":60,"note":"credential stuffing"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("block status = %d: %s", rec.Code, rec.Body.String())
+	}
+	clk.Advance(time.Second)
+	rec = send("GET", "/admin/blocklist", "198.51.100.1", "")
+	var list handlers.BlocklistResponse
+	json.NewDecoder(rec.Body).Decode(&list)
+	if len(list.Clients) != 2 || list.Clients[0].IP != "192.0.2.9" || list.Clients[0].Reason != domain.BlockedByAdmin ||
+		list.Clients[1].Reason != domain.BlockedScanner || list.Clients[1].Path != "/wp-admin/install.php" {
+		t.Errorf("blocklist = %+v, want the admin block then the scanner", list.Clients)
+	}
+	if rec := send("GET", "/api/v1/movies", "192.0.2.9", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("blocked address status = %d, want 403", rec.Code)
+	}
+	for target, status := range map[string]int{
+		"/admin/blocklist/192.0.2.9": http.StatusNoContent,
+		"/admin/blocklist/192.0.2.8": http.StatusNotFound,
+		"/admin/blocklist/nowhere":   http.StatusBadRequest,
+	} {
+		if rec := send("DELETE", target, "198.51.100.1", ""); rec.Code != status {
+			t.Errorf("DELETE %s status = %d, want %d", target, rec.Code, status)
+		}
+	}
+	if rec := send("POST", "/admin/blocklist", "198.51.100.1", `{"ip":"192.0.2.9","seconds":0}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("block without a duration status = %d, want 400", rec.Code)
+	}
+
+	// Blocks expire
+	clk.Advance(time.Hour)
+	if rec := send("GET", "/api/v1/movies", "203.0.113.7", ""); rec.Code != http.StatusOK {
+		t.Errorf("status after the block expired = %d, want 200", rec.Code)
+	}
+}
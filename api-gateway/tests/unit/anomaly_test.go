@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/adapters/usage"
+	"github.com/movie-microservice/api-gateway/internal/config"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))
+	keys := []string{"key-a", "key-b"}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	metrics := middleware.NewMetrics()
+	detector := middleware.NewAnomalyDetector(config.AnomalyConfig{
+		IntervalSeconds: 60,
+		Alpha:           0.2,
+		Deviations:      4,
+		MinRequests:     10,
+		WarmupIntervals: 3,
+	}, keys, metrics, clk, logger)
+
+	router := mux.NewRouter()
+	router.Use(middleware.Usage(usage.NewMemoryStore(7, clk), nil, keys, clk))
+	router.Use(detector.Middleware)
+	router.HandleFunc("/api/v1/movies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["id"] == "500" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}).Methods("GET")
+
+	send := func(n int, target, key string) {
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest("GET", target, nil)
+			req.Header.Set("X-API-Key", key)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+	anomalies := func() int {
+		return strings.Count(logs.String(), "Traffic anomaly")
+	}
+
+	// Steady traffic sets the baseline
+	for i := 0; i < 5; i++ {
+		send(8, "/api/v1/movies/1", "key-a")
+		send(1, "/api/v1/movies/500", "")
+		clk.Advance(time.Minute)
+	}
+	if n := anomalies(); n != 0 {
+		t.Fatalf("%d anomalies flagged in steady traffic:\n%s", n, logs.String())
+	}
+
+	// Spikes are flagged once per interval and kind, as soon as they happen
+	send(9, "/api/v1/movies/1", "key-b")
+	if n := anomalies(); n != 0 {
+		t.Errorf("%d anomalies flagged below the minimum requests", n)
+	}
+	send(30, "/api/v1/movies/1", "key-a")
+	if n := anomalies(); n != 1 || !strings.Contains(logs.String(), "kind=key_requests") {
+		t.Errorf("logs = %s, want one key_requests anomaly", logs.String())
+	}
+	send(20, "/api/v1/movies/500", "")
+	if n := anomalies(); n != 2 || !strings.Contains(logs.String(), "kind=server_errors") {
+		t.Errorf("logs = %s, want a server_errors anomaly too", logs.String())
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range []string{
+		`gateway_traffic_anomalies_total{kind="client_errors"} 0`,
+		`gateway_traffic_anomalies_total{kind="key_requests"} 1`,
+		`gateway_traffic_anomalies_total{kind="server_errors"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line) {
+			t.Errorf("metrics missing %q", line)
+		}
+	}
+
+	// The spike raises the baseline, and a quiet hour brings it down again
+	clk.Advance(time.Hour)
+	logs.Reset()
+	send(10, "/api/v1/movies/1", "key-a")
+	if n := anomalies(); n != 1 {
+		t.Errorf("%d anomalies after a quiet hour, want the new burst flagged", n)
+	}
+}
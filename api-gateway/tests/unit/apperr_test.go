@@ -0,0 +1,63 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/apperr"
+)
+
+func TestFind_MostSpecific(t *testing.T) {
+	err := fmt.Errorf("failed to create movie: %w", fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, domain.ErrInvalidYear))
+
+	known, ok := apperr.Find(err)
+	if !ok || known.Reason != "INVALID_YEAR" {
+		t.Fatalf("Find() = %+v, %v, want the INVALID_YEAR failure", known, ok)
+	}
+	if !errors.Is(err, apperr.Invalid) || errors.Is(err, apperr.NotFound) {
+		t.Errorf("errors.Is() does not match the kind of %v", err)
+	}
+	if !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("errors.Is(%v, ErrInvalidMovieData) = false, want the broader failure matched too", err)
+	}
+
+	if _, ok := apperr.Find(errors.New("connection reset")); ok {
+		t.Error("Find() classified a plain error")
+	}
+	if kind := apperr.KindOf(errors.New("connection reset")); kind != apperr.Internal {
+		t.Errorf("KindOf() = %v, want %v", kind, apperr.Internal)
+	}
+}
+
+func TestError_IsReason(t *testing.T) {
+	decoded := &apperr.Error{Kind: apperr.NotFound, Reason: "COMMENT_NOT_FOUND", Message: "comment not found"}
+
+	if !errors.Is(fmt.Errorf("failed to flag comment: %w", decoded), domain.ErrCommentNotFound) {
+		t.Error("decoded failure does not match the sentinel of its reason")
+	}
+	if errors.Is(decoded, domain.ErrMovieNotFound) {
+		t.Error("decoded failure matches the sentinel of another reason")
+	}
+	if errors.Is(&apperr.Error{Kind: apperr.NotFound}, &apperr.Error{Kind: apperr.NotFound}) {
+		t.Error("failures without reason match each other")
+	}
+}
+
+func TestKind_Codes(t *testing.T) {
+	for _, kind := range []apperr.Kind{apperr.NotFound, apperr.Conflict, apperr.Precondition, apperr.Invalid,
+		apperr.Unavailable, apperr.Timeout, apperr.Unimplemented, apperr.Internal} {
+		if got, ok := apperr.KindOfCode(kind.Code()); !ok || got != kind {
+			t.Errorf("KindOfCode(%v) = %v, %v, want %v", kind.Code(), got, ok, kind)
+		}
+	}
+	if _, ok := apperr.KindOfCode(codes.ResourceExhausted); ok {
+		t.Error("KindOfCode(ResourceExhausted) classified a code no kind maps to")
+	}
+	if status := apperr.Precondition.HTTPStatus(); status != 409 {
+		t.Errorf("Precondition.HTTPStatus() = %d, want 409", status)
+	}
+}
@@ -0,0 +1,266 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/services"
+)
+
+// MockCommentPort stands in for the comment service of the movie service
+type MockCommentPort struct {
+	comments []*domain.Comment
+}
+
+func (m *MockCommentPort) ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	if movieID != 1 {
+		return nil, 0, domain.ErrMovieNotFound
+	}
+	var threads []*domain.Comment
+	for _, c := range m.comments {
+		if c.ParentID == "" {
+			threads = append(threads, c)
+		}
+	}
+	return threads, int32(len(threads)), nil
+}
+
+func (m *MockCommentPort) CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error) {
+	comment := &domain.Comment{
+		ID:       fmt.Sprintf("c%d", len(m.comments)+1),
+		MovieID:  input.MovieID,
+		ParentID: input.ParentID,
+		Author:   input.Author,
+		Body:     input.Body,
+	}
+	m.comments = append(m.comments, comment)
+	return comment, nil
+}
+
+func (m *MockCommentPort) FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	for _, c := range m.comments {
+		if c.ID == id {
+			c.FlagCount++
+			c.FlagReasons = append(c.FlagReasons, reason)
+			return &domain.Comment{ID: c.ID, FlagCount: c.FlagCount}, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func (m *MockCommentPort) ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	var flagged []*domain.Comment
+	for _, c := range m.comments {
+		if c.FlagCount > 0 {
+			flagged = append(flagged, c)
+		}
+	}
+	return flagged, int32(len(flagged)), nil
+}
+
+func (m *MockCommentPort) DeleteComment(ctx context.Context, id string) error {
+	for _, c := range m.comments {
+		if c.ID == id {
+			c.Deleted, c.Author, c.Body, c.FlagCount, c.FlagReasons = true, "", "", 0, nil
+			return nil
+		}
+	}
+	return domain.ErrCommentNotFound
+}
+
+func (m *MockCommentPort) DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	for _, c := range m.comments {
+		if c.ID == id {
+			c.FlagCount, c.FlagReasons = 0, nil
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func (m *MockCommentPort) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	var queued []*domain.Comment
+	for _, c := range m.comments {
+		if c.Status == "review" {
+			queued = append(queued, c)
+		}
+	}
+	return queued, int32(len(queued)), nil
+}
+
+func (m *MockCommentPort) DecideModeration(ctx context.Context, id, decision string) (*domain.Comment, error) {
+	for _, c := range m.comments {
+		if c.ID == id {
+			if c.Status != "review" {
+				return nil, domain.ErrNotAwaitingModeration
+			}
+			c.Status = decision + "d"
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func newCommentRouter(port *MockCommentPort, apiKeys []string) *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := handlers.NewCommentHandler(services.NewCommentService(port, domain.DefaultPagination(), logger), logger)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}/comments", handler.GetComments).Methods("GET")
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}/comments", handler.CreateComment).Methods("POST")
+	router.HandleFunc("/api/v1/comments/{commentId}/flags", handler.FlagComment).Methods("POST")
+	moderation := router.PathPrefix("/admin/comments").Subrouter()
+	moderation.Use(middleware.AdminOnly(apiKeys, nil))
+	moderation.HandleFunc("/flagged", handler.GetFlaggedComments).Methods("GET")
+	moderation.HandleFunc("/{commentId}", handler.DeleteComment).Methods("DELETE")
+	moderation.HandleFunc("/{commentId}/flags", handler.DismissCommentFlags).Methods("DELETE")
+	moderation.HandleFunc("/queue", handler.GetModerationQueue).Methods("GET")
+	moderation.HandleFunc("/{commentId}/decision", handler.DecideModeration).Methods("POST")
+	return router
+}
+
+func serveComments(router http.Handler, method, path, body, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCommentHandler_Threads(t *testing.T) {
+	port := &MockCommentPort{}
+	router := newCommentRouter(port, []string{"secret"})
+
+	rec := serveComments(router, "POST", "/api/v1/movies/1/comments", `{"author":"Ana","body":"Loved it"}`, "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST comments status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	rec = serveComments(router, "POST", "/api/v1/movies/1/comments", `{"parent_id":"c1","author":"Bia","body":"Me too"}`, "")
+	if rec.Code != http.StatusCreated || port.comments[1].ParentID != "c1" {
+		t.Fatalf("POST reply status = %d, stored %+v, want a reply to c1", rec.Code, port.comments[1])
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"missing body", `{"author":"Ana"}`, http.StatusBadRequest},
+		{"unknown field", `{"author":"Ana","body":"Hi","rating":5}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveComments(router, "POST", "/api/v1/movies/1/comments", tt.body, ""); rec.Code != tt.want {
+				t.Errorf("POST comments status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	rec = serveComments(router, "GET", "/api/v1/movies/1/comments?page=1&limit=5", "", "")
+	var page struct {
+		Comments []domain.Comment `json:"comments"`
+		Total    int32            `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET comments status = %d, err %v", rec.Code, err)
+	}
+	if page.Total != 1 || rec.Header().Get("X-Total-Count") != "1" {
+		t.Errorf("GET comments total = %d, X-Total-Count %q, want 1 thread", page.Total, rec.Header().Get("X-Total-Count"))
+	}
+
+	if rec := serveComments(router, "GET", "/api/v1/movies/2/comments", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET comments of unknown movie status = %d, want 404", rec.Code)
+	}
+}
+
+func TestCommentHandler_Moderation(t *testing.T) {
+	port := &MockCommentPort{}
+	router := newCommentRouter(port, []string{"secret"})
+	serveComments(router, "POST", "/api/v1/movies/1/comments", `{"author":"Ana","body":"Spoiler"}`, "")
+
+	if rec := serveComments(router, "POST", "/api/v1/comments/c1/flags", `{}`, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("POST flags without reason status = %d, want 400", rec.Code)
+	}
+	if rec := serveComments(router, "POST", "/api/v1/comments/c9/flags", `{"reason":"spam"}`, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("POST flags of unknown comment status = %d, want 404", rec.Code)
+	}
+	rec := serveComments(router, "POST", "/api/v1/comments/c1/flags", `{"reason":"spoiler"}`, "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "spoiler") {
+		t.Errorf("POST flags status = %d, body %s, want 200 without the reasons", rec.Code, rec.Body)
+	}
+
+	if rec := serveComments(router, "GET", "/admin/comments/flagged", "", ""); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("GET flagged without key status = %d, want 401 with a challenge", rec.Code)
+	}
+	rec = serveComments(router, "GET", "/admin/comments/flagged", "", "secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"flag_reasons":["spoiler"]`) {
+		t.Errorf("GET flagged status = %d, body %s, want the comment with its reasons", rec.Code, rec.Body)
+	}
+
+	if rec := serveComments(router, "DELETE", "/admin/comments/c1/flags", "", "secret"); rec.Code != http.StatusOK || port.comments[0].FlagCount != 0 {
+		t.Errorf("DELETE flags status = %d, flag count %d, want the flags dismissed", rec.Code, port.comments[0].FlagCount)
+	}
+	if rec := serveComments(router, "DELETE", "/admin/comments/c1", "", "secret"); rec.Code != http.StatusNoContent || !port.comments[0].Deleted {
+		t.Errorf("DELETE comment status = %d, want 204 and the comment deleted", rec.Code)
+	}
+}
+
+func TestCommentHandler_ModerationWithoutKeys(t *testing.T) {
+	router := newCommentRouter(&MockCommentPort{}, nil)
+
+	if rec := serveComments(router, "GET", "/admin/comments/flagged", "", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("GET flagged without configured keys status = %d, want 404", rec.Code)
+	}
+}
+
+func TestCommentHandler_ModerationQueue(t *testing.T) {
+	port := &MockCommentPort{comments: []*domain.Comment{
+		{ID: "c1", Status: "review", ModerationReasons: []string{`word_list: word "spoiler" needs review`}},
+		{ID: "c2", Status: "approved"},
+	}}
+	router := newCommentRouter(port, []string{"secret"})
+
+	if rec := serveComments(router, "GET", "/admin/comments/queue", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET queue without key status = %d, want 401", rec.Code)
+	}
+	rec := serveComments(router, "GET", "/admin/comments/queue", "", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Total-Count") != "1" || !strings.Contains(rec.Body.String(), "moderation_reasons") {
+		t.Errorf("GET queue status = %d, body %s, want the queued comment with its reasons", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"unknown decision", "/admin/comments/c1/decision", `{"decision":"maybe"}`, http.StatusBadRequest},
+		{"unknown comment", "/admin/comments/c9/decision", `{"decision":"approve"}`, http.StatusNotFound},
+		{"approve", "/admin/comments/c1/decision", `{"decision":"approve"}`, http.StatusOK},
+		{"decided twice", "/admin/comments/c1/decision", `{"decision":"reject"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveComments(router, "POST", tt.path, tt.body, "secret"); rec.Code != tt.want {
+				t.Errorf("POST decision status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+	if port.comments[0].Status != "approved" {
+		t.Errorf("comment status = %q, want approved", port.comments[0].Status)
+	}
+}
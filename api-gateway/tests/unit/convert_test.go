@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+func TestConvert_Timestamp(t *testing.T) {
+	if ts := convert.ToTimestamp(time.Time{}); ts != nil {
+		t.Errorf("ToTimestamp(zero) = %v, want nil", ts)
+	}
+	if got, err := convert.FromTimestamp(nil); err != nil || !got.IsZero() {
+		t.Errorf("FromTimestamp(nil) = %v, %v, want the zero time", got, err)
+	}
+
+	occurred := time.Date(2024, 5, 17, 12, 30, 45, 123456789, time.FixedZone("BRT", -3*60*60))
+	got, err := convert.FromTimestamp(convert.ToTimestamp(occurred))
+	if err != nil || !got.Equal(occurred) || got.Location() != time.UTC {
+		t.Errorf("FromTimestamp(ToTimestamp(%v)) = %v, %v, want the same instant in UTC", occurred, got, err)
+	}
+
+	if _, err := convert.FromTimestamp(&timestamppb.Timestamp{Seconds: 1, Nanos: -1}); err == nil {
+		t.Error("FromTimestamp() accepted a timestamp with negative nanos")
+	}
+}
+
+func TestConvert_Duration(t *testing.T) {
+	if d := convert.ToDuration(0); d != nil {
+		t.Errorf("ToDuration(0) = %v, want nil", d)
+	}
+	if got, err := convert.FromDuration(nil); err != nil || got != 0 {
+		t.Errorf("FromDuration(nil) = %v, %v, want 0", got, err)
+	}
+
+	got, err := convert.FromDuration(convert.ToDuration(1500 * time.Millisecond))
+	if err != nil || got != 1500*time.Millisecond {
+		t.Errorf("FromDuration(ToDuration(1.5s)) = %v, %v, want 1.5s", got, err)
+	}
+
+	if _, err := convert.FromDuration(&durationpb.Duration{Seconds: 1, Nanos: -1}); err == nil {
+		t.Error("FromDuration() accepted a duration with mismatched signs")
+	}
+}
+
+// populate sets every field of m to a distinct non-zero value, so a conversion that drops
+// a field is caught by comparing round trips
+func populate(t *testing.T, m protoreflect.Message) {
+	t.Helper()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		var value protoreflect.Value
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			value = protoreflect.ValueOfString(fmt.Sprintf("%s-%d", fd.Name(), i))
+		case protoreflect.Int32Kind:
+			value = protoreflect.ValueOfInt32(int32(i + 1))
+		case protoreflect.Int64Kind:
+			value = protoreflect.ValueOfInt64(int64(i + 1))
+		case protoreflect.BoolKind:
+			value = protoreflect.ValueOfBool(true)
+		default:
+			t.Fatalf("%s.%s has kind %s; extend populate and the converters", m.Descriptor().Name(), fd.Name(), fd.Kind())
+		}
+		if fd.IsList() {
+			m.Mutable(fd).List().Append(value)
+		} else {
+			m.Set(fd, value)
+		}
+	}
+}
+
+func TestConvert_MovieRoundTrip(t *testing.T) {
+	movie := &pb.Movie{}
+	populate(t, movie.ProtoReflect())
+	if got := convert.ToProtoMovie(convert.FromProtoMovie(movie)); !proto.Equal(got, movie) {
+		t.Errorf("Movie round trip = %v, want %v", got, movie)
+	}
+
+	input := &pb.MovieInput{}
+	populate(t, input.ProtoReflect())
+	if got := convert.ToProtoMovieInput(convert.FromProtoMovieInput(input)); !proto.Equal(got, input) {
+		t.Errorf("MovieInput round trip = %v, want %v", got, input)
+	}
+}
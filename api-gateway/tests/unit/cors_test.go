@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+)
+
+// corsHeaderList splits a comma-separated CORS header into its names
+func corsHeaderList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+func TestCORS_Headers(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	called := false
+	handler := middleware.CORS(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	// Browsers send a preflight before a conditional DELETE with an API key
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/movies/1", nil)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	req.Header.Set("Access-Control-Request-Headers", "if-match, x-api-key")
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || called {
+		t.Errorf("preflight status = %v, handler called = %v, want 200 without calling it", rec.Code, called)
+	}
+	// Request headers the API reads must be allowed, or the browser never sends them
+	allowed := corsHeaderList(rec.Header().Get("Access-Control-Allow-Headers"))
+	for _, name := range []string{"Content-Type", "If-Match", "X-API-Key"} {
+		if !slices.Contains(allowed, name) {
+			t.Errorf("Access-Control-Allow-Headers = %v, want %s", allowed, name)
+		}
+	}
+
+	// Response headers clients read must be exposed, or scripts cannot see them
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil))
+	exposed := corsHeaderList(rec.Header().Get("Access-Control-Expose-Headers"))
+	for _, name := range []string{"ETag", "X-Total-Count"} {
+		if !slices.Contains(exposed, name) {
+			t.Errorf("Access-Control-Expose-Headers = %v, want %s", exposed, name)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/config"
+)
+
+func TestDeprecations(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	metrics := middleware.NewMetrics()
+	deprecations := middleware.NewDeprecations([]config.Deprecation{
+		{Feature: "comments-v1", Path: "/api/v1/movies/{id:[0-9]+}/comments", Methods: []string{"GET"},
+			Since: since, Sunset: since.AddDate(1, 0, 0), Link: "https://example.com/migrate"},
+		{Feature: "year-query", Path: "/api/v1/movies", Methods: []string{"GET"}, Query: "year", Since: since.AddDate(0, 1, 0)},
+		{Feature: "movie-rating-field", Path: "/api/v1/movies", Methods: []string{"POST"}, Field: "rating", Since: since},
+	}, nil, metrics)
+
+	var bodies []string
+	router := mux.NewRouter()
+	router.Use(deprecations.Middleware)
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}/comments", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET", "HEAD")
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}).Methods("POST")
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		deprecation string
+		sunset      string
+	}{
+		{"deprecated route", "GET", "/api/v1/movies/1/comments", "", "@1748736000", "Mon, 01 Jun 2026 00:00:00 GMT"},
+		{"HEAD of a deprecated GET", "HEAD", "/api/v1/movies/1/comments", "", "@1748736000", "Mon, 01 Jun 2026 00:00:00 GMT"},
+		{"deprecated query parameter", "GET", "/api/v1/movies?year=1999", "", "@1751328000", ""},
+		{"without the deprecated parameter", "GET", "/api/v1/movies?page=2", "", "", ""},
+		{"deprecated body field", "POST", "/api/v1/movies", `{"title": "Heat", "rating": 5}`, "@1748736000", ""},
+		{"without the deprecated field", "POST", "/api/v1/movies", `{"title": "Heat", "notes": {"rating": 5}}`, "", ""},
+		{"body that is not JSON", "POST", "/api/v1/movies", `rating`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if got := rec.Header().Get("Deprecation"); got != tt.deprecation {
+				t.Errorf("Deprecation = %q, want %q", got, tt.deprecation)
+			}
+			if got := rec.Header().Get("Sunset"); got != tt.sunset {
+				t.Errorf("Sunset = %q, want %q", got, tt.sunset)
+			}
+		})
+	}
+
+	// The bodies read to look for deprecated fields are put back for the handler
+	if got := strings.Join(bodies, "|"); got != `{"title": "Heat", "rating": 5}|{"title": "Heat", "notes": {"rating": 5}}|rating` {
+		t.Errorf("handler read %s, want the whole bodies", got)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/movies/1/comments", nil))
+	if link := rec.Header().Get("Link"); link != `<https://example.com/migrate>; rel="deprecation"; type="text/html"` {
+		t.Errorf("Link = %q, want the migration guide", link)
+	}
+
+	var out strings.Builder
+	metrics.Write(&out, false)
+	for _, want := range []string{
+		`gateway_deprecated_requests_total{feature="comments-v1"} 3`,
+		`gateway_deprecated_requests_total{feature="year-query"} 1`,
+		`gateway_deprecated_requests_total{feature="movie-rating-field"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %s:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDeprecations_Warnings(t *testing.T) {
+	since := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	deprecations := []config.Deprecation{
+		{Feature: "year-query", Path: "/api/v1/movies", Methods: []string{"GET"}, Query: "year", Since: since,
+			Sunset: since.AddDate(1, 0, 0), Link: "https://example.com/migrate"},
+		{Feature: "movie-year-field", Path: "/api/v1/movies", Methods: []string{"GET"}, ResponseField: "movies.year",
+			Since: since, Message: "use release_date instead of year"},
+	}
+	newRouter := func(warningKeys []string) *mux.Router {
+		router := mux.NewRouter()
+		router.Use(middleware.NewDeprecations(deprecations, warningKeys, middleware.NewMetrics()).Middleware)
+		router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("format") == "csv" {
+				w.Header().Set("Content-Type", "text/csv")
+				w.Write([]byte("id,title,year\n1,Heat,1995\n"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"movies":[{"id":1,"title":"Heat","year":"1995"}],"total":1}`))
+		}).Methods("GET")
+		return router
+	}
+
+	tests := []struct {
+		name        string
+		warningKeys []string
+		key         string
+		target      string
+		want        string
+	}{
+		{"key not opted in", []string{"key-a"}, "key-b", "/api/v1/movies?year=1995",
+			`{"movies":[{"id":1,"title":"Heat","year":"1995"}],"total":1}`},
+		{"without an API key", []string{"key-a"}, "", "/api/v1/movies?year=1995",
+			`{"movies":[{"id":1,"title":"Heat","year":"1995"}],"total":1}`},
+		{"query parameter and response field", []string{"key-a"}, "key-a", "/api/v1/movies?year=1995",
+			`{"_warnings":[{"feature":"year-query","message":"query parameter year is deprecated since 2025-06-01 and stops working on 2026-06-01","sunset":"2026-06-01T00:00:00Z","link":"https://example.com/migrate"},` +
+				`{"feature":"movie-year-field","message":"use release_date instead of year"}],"movies":[{"id":1,"title":"Heat","year":"1995"}],"total":1}` + "\n"},
+		{"every client", []string{"*"}, "", "/api/v1/movies",
+			`{"_warnings":[{"feature":"movie-year-field","message":"use release_date instead of year"}],"movies":[{"id":1,"title":"Heat","year":"1995"}],"total":1}` + "\n"},
+		{"response that is not JSON", []string{"*"}, "", "/api/v1/movies?year=1995&format=csv",
+			"id,title,year\n1,Heat,1995\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(tt.warningKeys).ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
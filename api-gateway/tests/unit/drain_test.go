@@ -0,0 +1,58 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/proto/drain"
+)
+
+func TestTracker_Drain(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tracker := drain.NewTracker("gateway")
+
+	end := tracker.Begin()
+	if tracker.InFlight() != 1 {
+		t.Fatalf("InFlight() = %d, want 1", tracker.InFlight())
+	}
+	// The request finishes while the server drains
+	err := tracker.Drain(time.Second, func(ctx context.Context) error {
+		end()
+		return nil
+	}, func() { t.Error("force called after a complete drain") }, logger)
+	if err != nil || tracker.InFlight() != 0 {
+		t.Errorf("Drain() = %v with %d in flight, want a complete drain", err, tracker.InFlight())
+	}
+
+	// A stuck request is closed when the grace period runs out
+	tracker.Begin()
+	forced := false
+	err = tracker.Drain(20*time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, func() { forced = true }, logger)
+	if err == nil || !forced {
+		t.Errorf("Drain() = %v, forced = %v, want the stuck request closed", err, forced)
+	}
+
+	var buf bytes.Buffer
+	tracker.Write(&buf, true)
+	for _, want := range []string{
+		"gateway_in_flight_requests 1\n",
+		"gateway_draining 1\n",
+		"# TYPE gateway_shutdown_forced_closes counter\n",
+		"gateway_shutdown_forced_closes_total 1\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "gateway_shutdown_drain_seconds 0\n") {
+		t.Error("drain time not recorded")
+	}
+}
@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/api-gateway/internal/adapters/grpc"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+)
+
+// rejectingMovieServer fails every call with a status carrying google.rpc details
+type rejectingMovieServer struct {
+	pb.UnimplementedMovieServiceServer
+}
+
+func (s *rejectingMovieServer) CreateMovie(ctx context.Context, req *pb.CreateMovieRequest) (*pb.CreateMovieResponse, error) {
+	st, _ := status.New(codes.InvalidArgument, "invalid movie data: invalid year format").WithDetails(
+		&errdetails.ErrorInfo{Reason: "INVALID_YEAR", Domain: "movies-service"},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "year", Description: "invalid year format"},
+		}},
+	)
+	return nil, st.Err()
+}
+
+func (s *rejectingMovieServer) GetMovies(ctx context.Context, req *pb.GetMoviesRequest) (*pb.GetMoviesResponse, error) {
+	st, _ := status.New(codes.Unavailable, "movie storage unavailable").WithDetails(
+		&errdetails.ErrorInfo{Reason: "STORAGE_UNAVAILABLE", Domain: "movies-service"},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+	)
+	return nil, st.Err()
+}
+
+func TestMovieHandler_ServiceErrorDetails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	client, err := grpcAdapter.NewMovieGRPCClient(startMovieServer(t, &rejectingMovieServer{}), grpcAdapter.ClientOptions{}, logger)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.(*grpcAdapter.MovieGRPCClient).Close()
+	handler := handlers.NewMovieHandler(client, logger)
+
+	rec := httptest.NewRecorder()
+	handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(`{"title":"Movie","year":"1999"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("CreateMovie() status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+	var resp handlers.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if resp.Reason != "INVALID_YEAR" || resp.Field != "year" || len(resp.Fields) != 1 || resp.Fields[0].Description != "invalid year format" {
+		t.Errorf("CreateMovie() error response = %+v, want the year field violation", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetMovies(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "2" {
+		t.Errorf("GetMovies() status = %v with Retry-After %q, want %v with the service's delay rounded up to 2",
+			rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+}
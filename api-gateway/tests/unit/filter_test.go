@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+func cond(field string, op domain.FilterOperator, value string) *domain.FilterExpr {
+	return &domain.FilterExpr{Condition: &domain.FilterCondition{Field: field, Op: op, Value: value}}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *domain.FilterExpr
+	}{
+		{
+			name:  "single comparison",
+			input: "year>=2000",
+			want:  cond("year", domain.OpGte, "2000"),
+		},
+		{
+			name:  "and with quoted contains",
+			input: `year>=2000 AND title~"star"`,
+			want: &domain.FilterExpr{And: []*domain.FilterExpr{
+				cond("year", domain.OpGte, "2000"),
+				cond("title", domain.OpContains, "star"),
+			}},
+		},
+		{
+			name:  "and binds tighter than or",
+			input: `year<1900 or year>2000 and title!="Remake"`,
+			want: &domain.FilterExpr{Or: []*domain.FilterExpr{
+				cond("year", domain.OpLt, "1900"),
+				{And: []*domain.FilterExpr{
+					cond("year", domain.OpGt, "2000"),
+					cond("title", domain.OpNe, "Remake"),
+				}},
+			}},
+		},
+		{
+			name:  "parentheses",
+			input: `(year = 1999 OR year = 2001) AND title ~ "odyssey"`,
+			want: &domain.FilterExpr{And: []*domain.FilterExpr{
+				{Or: []*domain.FilterExpr{
+					cond("year", domain.OpEq, "1999"),
+					cond("year", domain.OpEq, "2001"),
+				}},
+				cond("title", domain.OpContains, "odyssey"),
+			}},
+		},
+		{
+			name:  "escaped quotes and field case",
+			input: `Title="The \"Best\" Movie"`,
+			want:  cond("title", domain.OpEq, `The "Best" Movie`),
+		},
+		{
+			name:  "less or equal",
+			input: "id<=10",
+			want:  cond("id", domain.OpLte, "10"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.ParseFilter(tt.input)
+			if err != nil {
+				t.Fatalf("ParseFilter() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos int
+	}{
+		{name: "missing operator", input: "year 2000", wantPos: 5},
+		{name: "missing value", input: "year>=", wantPos: 6},
+		{name: "unterminated string", input: `title~"star`, wantPos: 6},
+		{name: "unbalanced parenthesis", input: "(year=2000", wantPos: 10},
+		{name: "dangling and", input: "year=2000 AND", wantPos: 13},
+		{name: "bare bang", input: "year!2000", wantPos: 4},
+		{name: "trailing token", input: "year=2000 title=x", wantPos: 10},
+		{name: "too long", input: strings.Repeat("a", 1025), wantPos: 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.ParseFilter(tt.input)
+			if !errors.Is(err, domain.ErrInvalidFilter) {
+				t.Fatalf("ParseFilter() error = %v, want %v", err, domain.ErrInvalidFilter)
+			}
+
+			var syntaxErr *domain.FilterSyntaxError
+			if errors.As(err, &syntaxErr) && syntaxErr.Pos != tt.wantPos {
+				t.Errorf("ParseFilter() error position = %d, want %d (%v)", syntaxErr.Pos, tt.wantPos, err)
+			}
+		})
+	}
+}
+
+func TestParseFilter_TooManyConditions(t *testing.T) {
+	input := strings.TrimSuffix(strings.Repeat("year=2000 AND ", 21), " AND ")
+	if _, err := domain.ParseFilter(input); !errors.Is(err, domain.ErrInvalidFilter) {
+		t.Errorf("ParseFilter() error = %v, want %v", err, domain.ErrInvalidFilter)
+	}
+}
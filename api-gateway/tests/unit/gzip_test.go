@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+)
+
+func TestGzip(t *testing.T) {
+	large := `{"movies":[` + strings.Repeat(`{"id":1,"title":"Heat"},`, 100) + `{"id":2}]}`
+	handler := middleware.Gzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":1}`))
+		case "/file":
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write([]byte(large))
+		case "/partial":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Range", "bytes 0-9/100")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(large))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			// Written in pieces, as streamed responses are
+			for _, piece := range strings.SplitAfter(large, "},") {
+				w.Write([]byte(piece))
+			}
+		}
+	}))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		gzipped        bool
+		want           string
+	}{
+		{"large JSON", "/", "gzip, deflate", true, large},
+		{"gzip not accepted", "/", "", false, large},
+		{"gzip refused", "/", "gzip;q=0, identity", false, large},
+		{"any coding", "/", "*", true, large},
+		{"small response", "/small", "gzip", false, `{"id":1}`},
+		{"already compressed file", "/file", "gzip", false, large},
+		{"partial content", "/partial", "gzip", false, large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			body := io.Reader(rec.Body)
+			if gzipped := rec.Header().Get("Content-Encoding") == "gzip"; gzipped != tt.gzipped {
+				t.Fatalf("Content-Encoding = %q, want gzip %v", rec.Header().Get("Content-Encoding"), tt.gzipped)
+			}
+			if tt.gzipped {
+				gz, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gz
+			}
+			if got, _ := io.ReadAll(body); string(got) != tt.want {
+				t.Errorf("body = %.60s..., want %.60s...", got, tt.want)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", vary)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+)
+
+func healthCheck(name string, required bool, err error) handlers.HealthCheck {
+	return handlers.HealthCheck{
+		Name:     name,
+		Required: required,
+		Check: func(ctx context.Context) (map[string]interface{}, error) {
+			return map[string]interface{}{"checked": true}, err
+		},
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	down := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		checks     []handlers.HealthCheck
+		wantStatus string
+		wantCode   int
+	}{
+		{"all up", []handlers.HealthCheck{healthCheck("movie_service", true, nil), healthCheck("response_cache", false, nil)}, handlers.HealthStatusHealthy, http.StatusOK},
+		{"optional down", []handlers.HealthCheck{healthCheck("movie_service", true, nil), healthCheck("response_cache", false, down)}, handlers.HealthStatusDegraded, http.StatusOK},
+		{"required down", []handlers.HealthCheck{healthCheck("movie_service", true, down), healthCheck("response_cache", false, down)}, handlers.HealthStatusUnhealthy, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handlers.NewHealthHandler(tt.checks...).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var resp handlers.HealthResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("invalid health response: %v", err)
+			}
+			if resp.Status != tt.wantStatus || len(resp.Components) != len(tt.checks) {
+				t.Errorf("response = %+v, want status %s with every component", resp, tt.wantStatus)
+			}
+			for _, check := range tt.checks {
+				component := resp.Components[check.Name]
+				if component.Required != check.Required || component.Details["checked"] != true {
+					t.Errorf("component %s = %+v, want its details", check.Name, component)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/api-gateway/internal/adapters/grpc"
+)
+
+// slowFirstMovieServer answers the first GetMovie call only when it is cancelled
+type slowFirstMovieServer struct {
+	pb.UnimplementedMovieServiceServer
+	calls atomic.Int32
+}
+
+func (s *slowFirstMovieServer) GetMovie(ctx context.Context, req *pb.GetMovieRequest) (*pb.GetMovieResponse, error) {
+	if s.calls.Add(1) == 1 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &pb.GetMovieResponse{Movie: &pb.Movie{Id: req.Id, Title: "Movie", Year: "1994"}}, nil
+}
+
+// flakyMovieServer fails the first GetMovie calls with its code, until its failures run out
+type flakyMovieServer struct {
+	pb.UnimplementedMovieServiceServer
+	failures int32
+	code     codes.Code
+	calls    atomic.Int32
+}
+
+func (s *flakyMovieServer) GetMovie(ctx context.Context, req *pb.GetMovieRequest) (*pb.GetMovieResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(s.code, "replica shutting down")
+	}
+	return &pb.GetMovieResponse{Movie: &pb.Movie{Id: req.Id, Title: "Movie", Year: "1994"}}, nil
+}
+
+func startMovieServer(t *testing.T, server pb.MovieServiceServer) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterMovieServiceServer(srv, server)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestMovieGRPCClient_Hedging(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &slowFirstMovieServer{}
+	address := startMovieServer(t, server)
+
+	client, err := grpcAdapter.NewMovieGRPCClient(address, grpcAdapter.ClientOptions{HedgeDelay: 20 * time.Millisecond}, logger)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.(*grpcAdapter.MovieGRPCClient).Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	movie, err := client.GetMovie(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetMovie() unexpected error = %v", err)
+	}
+	if movie.ID != 1 || server.calls.Load() != 2 {
+		t.Errorf("GetMovie() = %+v after %d calls, want movie 1 from the hedged call", movie, server.calls.Load())
+	}
+}
+
+func TestMovieGRPCClient_NoHedging(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := &slowFirstMovieServer{}
+	address := startMovieServer(t, server)
+
+	client, err := grpcAdapter.NewMovieGRPCClient(address, grpcAdapter.ClientOptions{}, logger)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.(*grpcAdapter.MovieGRPCClient).Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetMovie(ctx, 1); err == nil {
+		t.Error("GetMovie() expected the deadline to expire without hedging")
+	}
+	if server.calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", server.calls.Load())
+	}
+}
+
+func TestMovieGRPCClient_Retry(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name      string
+		server    *flakyMovieServer
+		wantErr   bool
+		wantCalls int32
+	}{
+		{"unavailable retried", &flakyMovieServer{failures: 2, code: codes.Unavailable}, false, 3},
+		{"attempts exhausted", &flakyMovieServer{failures: 5, code: codes.Unavailable}, true, 3},
+		{"not found not retried", &flakyMovieServer{failures: 5, code: codes.NotFound}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startMovieServer(t, tt.server)
+			client, err := grpcAdapter.NewMovieGRPCClient(address, grpcAdapter.ClientOptions{RetryAttempts: 3}, logger)
+			if err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+			defer client.(*grpcAdapter.MovieGRPCClient).Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err = client.GetMovie(ctx, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetMovie() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls := tt.server.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("server called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/services"
+)
+
+// MockImportPort stands in for the import service of the movie service, keeping imports
+// pending until finish is called
+type MockImportPort struct {
+	imports map[string]*domain.Import
+	rows    []domain.ImportRow
+}
+
+func (m *MockImportPort) StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error) {
+	m.rows = rows
+	imp := &domain.Import{ID: "imp1", Status: domain.ImportPending, Total: int32(len(rows))}
+	m.imports[imp.ID] = imp
+	return imp, nil
+}
+
+func (m *MockImportPort) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	imp, ok := m.imports[id]
+	if !ok {
+		return nil, domain.ErrImportNotFound
+	}
+	return imp, nil
+}
+
+// finish completes the import with a created and an invalid row
+func (m *MockImportPort) finish(id string) {
+	imp := m.imports[id]
+	imp.Status, imp.Processed, imp.Created, imp.Invalid = domain.ImportCompleted, 2, 1, 1
+	imp.Results = []domain.ImportRowResult{
+		{Row: 1, Status: "created", MovieID: 8},
+		{Row: 2, Status: "invalid", Field: "year", Error: "invalid movie data: invalid year format"},
+	}
+}
+
+func newImportRouter(port *MockImportPort) *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := handlers.NewImportHandler(services.NewImportService(port, logger), logger)
+
+	router := mux.NewRouter()
+	imports := router.PathPrefix("/api/v1/imports").Subrouter()
+	imports.Use(middleware.KeyRequired([]string{"secret"}))
+	imports.HandleFunc("", handler.CreateImport).Methods("POST")
+	imports.HandleFunc("/{importId}", handler.GetImport).Methods("GET")
+	return router
+}
+
+func TestImportHandler_Report(t *testing.T) {
+	port := &MockImportPort{imports: make(map[string]*domain.Import)}
+	router := newImportRouter(port)
+	body := `{"movies": [{"title": "Central Station", "year": "1998", "regions": ["BR"]}, {"id": 7, "title": "Heat", "year": "19x5"}]}`
+
+	if rec := serveComments(router, "POST", "/api/v1/imports", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST import without key status = %d, want 401", rec.Code)
+	}
+	rec := serveComments(router, "POST", "/api/v1/imports", body, "secret")
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Location") != "/api/v1/imports/imp1" {
+		t.Fatalf("POST import status = %d, Location %q, want 202 with the import location: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+	if len(port.rows) != 2 || port.rows[0].Input.Regions[0] != "BR" || port.rows[1].ID != 7 {
+		t.Errorf("rows = %+v, want both movies with their IDs and fields", port.rows)
+	}
+
+	rec = serveComments(router, "GET", "/api/v1/imports/imp1", "", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("GET pending import status = %d, Retry-After %q, want 200 with a poll delay", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	port.finish("imp1")
+	rec = serveComments(router, "GET", "/api/v1/imports/imp1", "", "secret")
+	var imp domain.Import
+	if err := json.NewDecoder(rec.Body).Decode(&imp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rec.Header().Get("Retry-After") != "" || imp.Status != domain.ImportCompleted || len(imp.Results) != 2 || imp.Results[1].Field != "year" {
+		t.Errorf("GET completed import = %+v, Retry-After %q, want the row results without a poll delay", imp, rec.Header().Get("Retry-After"))
+	}
+
+	rec = serveComments(router, "GET", "/api/v1/imports/imp1?format=csv", "", "secret")
+	wantCSV := "row,status,movie_id,field,error\n1,created,8,,\n2,invalid,,year,invalid movie data: invalid year format\n"
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") || rec.Body.String() != wantCSV {
+		t.Errorf("GET import as CSV = %q (%s), want %q", rec.Body, rec.Header().Get("Content-Type"), wantCSV)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"no movies", "POST", "/api/v1/imports", `{"movies": []}`, http.StatusBadRequest},
+		{"unknown field", "POST", "/api/v1/imports", `{"movies": [{"title": "Heat", "year": "1995", "rating": 5}]}`, http.StatusBadRequest},
+		{"unknown import", "GET", "/api/v1/imports/imp9", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveComments(router, tt.method, tt.path, tt.body, "secret"); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
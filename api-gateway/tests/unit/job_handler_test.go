@@ -0,0 +1,277 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// MockJobPort stands in for the job service of the movie service, keeping jobs pending
+// until finish is called
+type MockJobPort struct {
+	jobs   map[string]*domain.Job
+	params json.RawMessage
+	// offset is the offset the last file was opened at
+	offset int64
+}
+
+func (m *MockJobPort) SubmitJob(ctx context.Context, jobType, priority string, params json.RawMessage) (*domain.Job, error) {
+	if jobType != domain.JobExport {
+		return nil, domain.ErrInvalidJob
+	}
+	if priority == "" {
+		priority = "normal"
+	}
+	m.params = params
+	job := &domain.Job{ID: "job1", Type: jobType, Status: domain.ImportPending, Priority: priority}
+	m.jobs[job.ID] = job
+	return job, nil
+}
+
+func (m *MockJobPort) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (m *MockJobPort) OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error) {
+	job, err := m.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.File {
+		return nil, domain.ErrNoJobFile
+	}
+	m.offset = offset
+	data := "backup"
+	if offset < 0 {
+		offset = max(offset+int64(len(data)), 0)
+	}
+	return &domain.JobFile{ReadCloser: io.NopCloser(strings.NewReader(data[min(offset, int64(len(data))):])), Size: int64(len(data))}, nil
+}
+
+// finish completes the job with a file
+func (m *MockJobPort) finish(id string) {
+	job := m.jobs[id]
+	job.Status, job.Processed, job.File = domain.ImportCompleted, 3, true
+	job.Result = json.RawMessage(`{"movies":3}`)
+}
+
+func newJobRouter(port *MockJobPort) *mux.Router {
+	return newSignedJobRouter(port, nil)
+}
+
+// newSignedJobRouter routes jobs as the gateway does, serving job files to the URLs
+// signed by signer
+func newSignedJobRouter(port *MockJobPort, signer *middleware.URLSigner) *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := handlers.NewJobHandler(services.NewJobService(port, logger), signer, logger)
+
+	router := mux.NewRouter()
+	router.Handle("/api/v1/jobs/{jobId}/file", middleware.SignedOrAdmin(signer, []string{"secret"})(http.HandlerFunc(handler.GetJobFile))).Methods("GET")
+	jobs := router.PathPrefix("/api/v1/jobs").Subrouter()
+	jobs.Use(middleware.KeyRequired([]string{"secret"}))
+	jobs.HandleFunc("", handler.CreateJob).Methods("POST")
+	jobs.HandleFunc("/{jobId}", handler.GetJob).Methods("GET")
+	if signer != nil {
+		jobs.HandleFunc("/{jobId}/file/url", handler.CreateJobFileURL).Methods("POST")
+	}
+	return router
+}
+
+func TestJobHandler_Export(t *testing.T) {
+	port := &MockJobPort{jobs: make(map[string]*domain.Job)}
+	router := newJobRouter(port)
+	body := `{"type": "export", "priority": "high", "params": {"note": "nightly"}}`
+
+	if rec := serveComments(router, "POST", "/api/v1/jobs", body, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST job without key status = %d, want 401", rec.Code)
+	}
+	rec := serveComments(router, "POST", "/api/v1/jobs", body, "secret")
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Location") != "/api/v1/jobs/job1" {
+		t.Fatalf("POST job status = %d, Location %q, want 202 with the job location: %s", rec.Code, rec.Header().Get("Location"), rec.Body)
+	}
+	if string(port.params) != `{"note": "nightly"}` {
+		t.Errorf("params = %s, want the params of the body", port.params)
+	}
+	if !strings.Contains(rec.Body.String(), `"priority":"high"`) {
+		t.Errorf("POST job body = %s, want the priority of the request", rec.Body)
+	}
+
+	rec = serveComments(router, "GET", "/api/v1/jobs/job1", "", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("GET pending job status = %d, Retry-After %q, want 200 with a poll delay", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := serveComments(router, "GET", "/api/v1/jobs/job1/file", "", "secret"); rec.Code != http.StatusConflict {
+		t.Errorf("GET file of a pending job status = %d, want 409", rec.Code)
+	}
+
+	port.finish("job1")
+	rec = serveComments(router, "GET", "/api/v1/jobs/job1", "", "secret")
+	var job struct {
+		Status string          `json:"status"`
+		File   bool            `json:"file"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rec.Header().Get("Retry-After") != "" || job.Status != domain.ImportCompleted || !job.File || string(job.Result) != `{"movies":3}` {
+		t.Errorf("GET completed job = %+v, Retry-After %q, want the result without a poll delay", job, rec.Header().Get("Retry-After"))
+	}
+
+	rec = serveComments(router, "GET", "/api/v1/jobs/job1/file", "", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/gzip" || rec.Body.String() != "backup" {
+		t.Errorf("GET job file = %d %q (%s), want the file", rec.Code, rec.Body, rec.Header().Get("Content-Type"))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"no type", "POST", "/api/v1/jobs", `{"params": {}}`, http.StatusBadRequest},
+		{"unknown type", "POST", "/api/v1/jobs", `{"type": "enrich"}`, http.StatusBadRequest},
+		{"unknown field", "POST", "/api/v1/jobs", `{"type": "export", "priority": 1}`, http.StatusBadRequest},
+		{"unknown job", "GET", "/api/v1/jobs/job9", "", http.StatusNotFound},
+		{"file of an unknown job", "GET", "/api/v1/jobs/job9/file", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveComments(router, tt.method, tt.path, tt.body, "secret"); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestJobHandler_FileRange(t *testing.T) {
+	port := &MockJobPort{jobs: make(map[string]*domain.Job)}
+	router := newJobRouter(port)
+	if _, err := port.SubmitJob(context.Background(), domain.JobExport, "", nil); err != nil {
+		t.Fatalf("SubmitJob() unexpected error = %v", err)
+	}
+	port.finish("job1")
+
+	tests := []struct {
+		name       string
+		rng        string
+		ifRange    string
+		want       int
+		body       string
+		offset     int64
+		contentRng string
+	}{
+		{"whole file", "", "", http.StatusOK, "backup", 0, ""},
+		{"resumed", "bytes=2-", "", http.StatusPartialContent, "ckup", 2, "bytes 2-5/6"},
+		{"closed range", "bytes=1-3", "", http.StatusPartialContent, "ack", 1, "bytes 1-3/6"},
+		{"range past the end", "bytes=4-99", "", http.StatusPartialContent, "up", 4, "bytes 4-5/6"},
+		{"suffix", "bytes=-2", "", http.StatusPartialContent, "up", -2, "bytes 4-5/6"},
+		{"suffix longer than the file", "bytes=-10", "", http.StatusPartialContent, "backup", -10, "bytes 0-5/6"},
+		{"matching If-Range", "bytes=2-", `"job1"`, http.StatusPartialContent, "ckup", 2, "bytes 2-5/6"},
+		{"stale If-Range", "bytes=2-", `"job0"`, http.StatusOK, "backup", 0, ""},
+		{"several ranges", "bytes=0-1,3-4", "", http.StatusOK, "backup", 0, ""},
+		{"malformed", "bytes=3-1", "", http.StatusOK, "backup", 0, ""},
+		{"unsatisfiable", "bytes=6-", "", http.StatusRequestedRangeNotSatisfiable, "", 6, "bytes */6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/jobs/job1/file", nil)
+			req.Header.Set("X-API-Key", "secret")
+			if tt.rng != "" {
+				req.Header.Set("Range", tt.rng)
+			}
+			if tt.ifRange != "" {
+				req.Header.Set("If-Range", tt.ifRange)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want || rec.Header().Get("Content-Range") != tt.contentRng {
+				t.Fatalf("status = %d, Content-Range %q, want %d with %q: %s", rec.Code, rec.Header().Get("Content-Range"), tt.want, tt.contentRng, rec.Body)
+			}
+			if port.offset != tt.offset {
+				t.Errorf("file opened at %d, want %d", port.offset, tt.offset)
+			}
+			if tt.want == http.StatusRequestedRangeNotSatisfiable {
+				return
+			}
+			if rec.Body.String() != tt.body || rec.Header().Get("Content-Length") != strconv.Itoa(len(tt.body)) {
+				t.Errorf("body = %q with Content-Length %s, want %q", rec.Body, rec.Header().Get("Content-Length"), tt.body)
+			}
+			if rec.Header().Get("Accept-Ranges") != "bytes" || rec.Header().Get("ETag") != `"job1"` {
+				t.Errorf("Accept-Ranges %q, ETag %q, want bytes and the job ID", rec.Header().Get("Accept-Ranges"), rec.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestJobHandler_SignedFileURL(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	port := &MockJobPort{jobs: make(map[string]*domain.Job)}
+	router := newSignedJobRouter(port, middleware.NewURLSigner([]string{"new", "old"}, 15*time.Minute, clk))
+	if _, err := port.SubmitJob(context.Background(), domain.JobExport, "", nil); err != nil {
+		t.Fatalf("SubmitJob() unexpected error = %v", err)
+	}
+
+	if rec := serveComments(router, "POST", "/api/v1/jobs/job1/file/url", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST file URL without key status = %d, want 401", rec.Code)
+	}
+	if rec := serveComments(router, "POST", "/api/v1/jobs/job1/file/url", "", "secret"); rec.Code != http.StatusConflict {
+		t.Errorf("POST file URL of a pending job status = %d, want 409", rec.Code)
+	}
+	if rec := serveComments(router, "POST", "/api/v1/jobs/job9/file/url", "", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("POST file URL of an unknown job status = %d, want 404", rec.Code)
+	}
+
+	port.finish("job1")
+	rec := serveComments(router, "POST", "/api/v1/jobs/job1/file/url", "", "secret")
+	var signed handlers.JobFileURLResponse
+	if err := json.NewDecoder(rec.Body).Decode(&signed); err != nil || rec.Code != http.StatusCreated {
+		t.Fatalf("POST file URL status = %d, error = %v, want 201 with the URL", rec.Code, err)
+	}
+	if !strings.HasPrefix(signed.URL, "/api/v1/jobs/job1/file?") || !signed.ExpiresAt.Equal(clk.Now().Add(15*time.Minute)) {
+		t.Fatalf("signed URL = %+v, want the file URL expiring in 15 minutes", signed)
+	}
+
+	if rec := serveComments(router, "GET", signed.URL, "", ""); rec.Code != http.StatusOK || rec.Body.String() != "backup" {
+		t.Errorf("GET signed URL = %d %q, want the file without a key", rec.Code, rec.Body)
+	}
+	tampered := strings.Replace(signed.URL, "/job1/", "/job2/", 1)
+	if rec := serveComments(router, "GET", tampered, "", "secret"); rec.Code != http.StatusForbidden {
+		t.Errorf("GET URL signed for another job status = %d, want 403", rec.Code)
+	}
+
+	// URLs signed with a secret being rotated out are still accepted
+	previous, _ := middleware.NewURLSigner([]string{"old"}, time.Minute, clk).Sign("/api/v1/jobs/job1/file")
+	if rec := serveComments(router, "GET", previous, "", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET URL signed with the previous secret status = %d, want 200", rec.Code)
+	}
+
+	clk.Advance(15 * time.Minute)
+	if rec := serveComments(router, "GET", signed.URL, "", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GET expired URL status = %d, want 403", rec.Code)
+	}
+	if rec := serveComments(router, "GET", "/api/v1/jobs/job1/file", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET unsigned file without key status = %d, want 401", rec.Code)
+	}
+}
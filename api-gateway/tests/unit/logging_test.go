@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/logging"
+)
+
+func TestLogging_Policy(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	status := http.StatusOK
+	handler := middleware.Logging(logger, logging.Policy{SampleRate: 0, SlowThreshold: time.Minute})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+
+	request := func(method string, code int) map[string]interface{} {
+		buf.Reset()
+		status = code
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/api/v1/movies/1", nil))
+		if buf.Len() == 0 {
+			return nil
+		}
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %v", err)
+		}
+		return line
+	}
+
+	if line := request(http.MethodGet, http.StatusOK); line != nil {
+		t.Errorf("successful read logged with sample rate 0: %v", line)
+	}
+	if line := request(http.MethodDelete, http.StatusNoContent); line == nil || line["level"] != "INFO" {
+		t.Errorf("write log line = %v, want an INFO line", line)
+	}
+	if line := request(http.MethodGet, http.StatusNotFound); line == nil || line["level"] != "WARN" {
+		t.Errorf("not found log line = %v, want a WARN line", line)
+	}
+	if line := request(http.MethodGet, http.StatusBadGateway); line == nil || line["level"] != "ERROR" || line["status"] != float64(http.StatusBadGateway) {
+		t.Errorf("bad gateway log line = %v, want an ERROR line", line)
+	}
+
+	status = http.StatusOK
+	handler = middleware.Logging(logger, logging.Policy{SampleRate: 1})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	buf.Reset()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if buf.Len() == 0 {
+		t.Error("read not logged with sample rate 1")
+	}
+}
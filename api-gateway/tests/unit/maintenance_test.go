@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+)
+
+func newMaintenanceRouter(readOnly bool, adminKeys []string) *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	maintenance := middleware.NewMaintenance(readOnly, 90*time.Second, adminKeys, []string{"client"}, logger)
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	router.Use(maintenance.Middleware)
+	router.Handle(middleware.MaintenancePath, maintenance).Methods("GET", "PUT")
+	router.HandleFunc("/api/v1/movies", noop).Methods("GET", "POST")
+	return router
+}
+
+func TestMaintenance_ReadOnly(t *testing.T) {
+	router := newMaintenanceRouter(true, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET in read-only mode status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "90" {
+		t.Errorf("POST in read-only mode = %d, Retry-After %q, want %d and 90", rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+}
+
+func TestMaintenance_Toggle(t *testing.T) {
+	router := newMaintenanceRouter(false, []string{"secret"})
+	toggle := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, middleware.MaintenancePath, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	post := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", nil))
+		return rec.Code
+	}
+
+	if rec := toggle("", `{"read_only": true}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("toggle without a key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := toggle("client", `{"read_only": true}`); rec.Code != http.StatusForbidden {
+		t.Errorf("toggle with a client key status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	if rec := toggle("secret", `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("toggle without read_only status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := toggle("secret", `{"read_only": true}`); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"read_only":true`) {
+		t.Fatalf("toggle on = %d %s, want read-only mode", rec.Code, rec.Body)
+	}
+	if code := post(); code != http.StatusServiceUnavailable {
+		t.Errorf("POST after switching on status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	// The switch itself is a mutation that must keep working in read-only mode
+	if rec := toggle("secret", `{"read_only": false}`); rec.Code != http.StatusOK {
+		t.Fatalf("toggle off status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if code := post(); code != http.StatusOK {
+		t.Errorf("POST after switching off status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestMaintenance_NoKeys(t *testing.T) {
+	router := newMaintenanceRouter(false, nil)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, middleware.MaintenancePath, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("maintenance route without admin API keys status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,30 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+func TestMetaHandler_GetMeta(t *testing.T) {
+	handler := handlers.NewMetaHandler(domain.Pagination{DefaultLimit: 20, MaxLimit: 200, MaxPage: 500})
+
+	rec := httptest.NewRecorder()
+	handler.GetMeta(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("GetMeta() = %d with Content-Type %q, want 200 JSON", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var meta handlers.MetaResponse
+	if err := json.NewDecoder(rec.Body).Decode(&meta); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := handlers.PaginationMeta{DefaultLimit: 20, MaxLimit: 200, MaxPage: 500}
+	if meta.Pagination != want {
+		t.Errorf("GetMeta() pagination = %+v, want %+v", meta.Pagination, want)
+	}
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/adapters/metering"
+	"github.com/movie-microservice/api-gateway/internal/adapters/usage"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// billingServer stands for the event bus, keeping the batches it is sent
+type billingServer struct {
+	mu      sync.Mutex
+	batches [][]domain.MeteringEvent
+	status  int
+}
+
+func (s *billingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Events []domain.MeteringEvent `json:"events"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	s.batches = append(s.batches, body.Events)
+}
+
+func (s *billingServer) received() [][]domain.MeteringEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches
+}
+
+func TestMetering(t *testing.T) {
+	billing := &billingServer{}
+	server := httptest.NewServer(billing)
+	defer server.Close()
+
+	clk := clock.NewFake(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))
+	keys := []string{"key-a", "key-b"}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := services.NewMeteringService(metering.NewWebhook(server.URL), services.MeteringOptions{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		JournalSize:   3,
+		Retry:         metering.NewRetry(1, logger),
+	}, clk, logger)
+	meteringHandler := handlers.NewMeteringHandler(service, logger)
+
+	router := mux.NewRouter()
+	router.Use(middleware.Usage(usage.NewMemoryStore(7, clk), service, keys, clk))
+	router.HandleFunc("/api/v1/movies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("movie"))
+	}).Methods("GET")
+	router.Handle("/admin/metering/replay", middleware.AdminOnly([]string{"admin"}, keys)(http.HandlerFunc(meteringHandler.ReplayEvents))).Methods("POST")
+
+	send := func(ctx context.Context, method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, key := range []string{"key-a", "key-b", "key-a", "key-a"} {
+		send(context.Background(), "GET", "/api/v1/movies/1", key, "")
+		clk.Advance(time.Minute)
+	}
+	// Warm-ups of the gateway are not billed
+	send(domain.ContextAsWarmup(context.Background()), "GET", "/api/v1/movies/1", "key-a", "")
+
+	// The events left are published once the service stops; the oldest one did not fit
+	// the journal and was dropped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	service.Run(ctx)
+	batches := billing.received()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("batches = %+v, want 2 then 1 events", batches)
+	}
+	first := batches[0][0]
+	if first.KeyID != domain.APIKeyID("key-b") || first.Route != "/api/v1/movies/{id}" || first.Method != "GET" ||
+		first.Status != http.StatusOK || first.BytesOut != 5 || !first.OccurredAt.Equal(time.Date(2025, 6, 2, 12, 1, 0, 0, time.UTC)) {
+		t.Errorf("event = %+v, want the request of key-b at 12:01", first)
+	}
+	if first.ID == "" || first.ID == batches[0][1].ID {
+		t.Errorf("event IDs %q and %q, want them set and unique", first.ID, batches[0][1].ID)
+	}
+
+	// Replays publish the events kept again, with the same IDs
+	rec := send(context.Background(), "POST", "/admin/metering/replay", "admin",
+		`{"from":"2025-06-02T12:00:00Z","to":"2025-06-02T13:00:00Z","key_id":"`+domain.APIKeyID("key-a")+`"}`)
+	var replay handlers.ReplayResponse
+	if err := json.NewDecoder(rec.Body).Decode(&replay); err != nil {
+		t.Fatalf("status %d: %v", rec.Code, err)
+	}
+	if replay.Replayed != 2 || replay.JournalStart == nil || !replay.JournalStart.Equal(first.OccurredAt) {
+		t.Errorf("replay = %+v, want 2 events kept since %s", replay, first.OccurredAt)
+	}
+	batches = billing.received()
+	if len(batches) != 3 || batches[2][0].ID != batches[0][1].ID || batches[2][1].ID != batches[1][0].ID {
+		t.Errorf("replayed batch = %+v, want the events of key-a with their IDs", batches[len(batches)-1])
+	}
+
+	billing.status = http.StatusBadRequest
+	for body, status := range map[string]int{
+		`{"from":"2025-06-02T12:00:00Z","to":"2025-06-02T13:00:00Z"}`: http.StatusBadGateway,
+		`{"from":"2025-06-02T13:00:00Z","to":"2025-06-02T12:00:00Z"}`: http.StatusBadRequest,
+		`{"to":"2025-06-02T12:00:00Z"}`:                               http.StatusBadRequest,
+		`{"from":"yesterday","to":"2025-06-02T12:00:00Z"}`:            http.StatusBadRequest,
+	} {
+		if rec := send(context.Background(), "POST", "/admin/metering/replay", "admin", body); rec.Code != status {
+			t.Errorf("replay %s status = %d, want %d", body, rec.Code, status)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+)
+
+func newTestRouter() *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/movies", noop).Methods("GET")
+	api.HandleFunc("/movies", noop).Methods("POST")
+	api.HandleFunc("/movies/{id:[0-9]+}", noop).Methods("GET")
+	api.HandleFunc("/movies/{id:[0-9]+}", noop).Methods("DELETE")
+
+	router.MethodNotAllowedHandler = middleware.MethodNotAllowed(router, logger)
+	api.MethodNotAllowedHandler = router.MethodNotAllowedHandler
+	return router
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	router := newTestRouter()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+	}{
+		{
+			name:       "wrong method on collection",
+			method:     http.MethodDelete,
+			path:       "/api/v1/movies",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "GET, OPTIONS, POST",
+		},
+		{
+			name:       "wrong method on item",
+			method:     http.MethodPost,
+			path:       "/api/v1/movies/1",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "DELETE, GET, OPTIONS",
+		},
+		{
+			name:       "automatic OPTIONS",
+			method:     http.MethodOptions,
+			path:       "/api/v1/movies/1",
+			wantStatus: http.StatusNoContent,
+			wantAllow:  "DELETE, GET, OPTIONS",
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "/api/v1/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
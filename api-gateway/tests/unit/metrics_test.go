@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	trace, ok := domain.ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || trace.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.SpanID != "00f067aa0ba902b7" || !trace.Sampled {
+		t.Fatalf("ParseTraceParent() = %+v, %v", trace, ok)
+	}
+	child := trace.Child()
+	if child.TraceID != trace.TraceID || child.SpanID == trace.SpanID {
+		t.Errorf("Child() = %+v, want a new span in trace %s", child, trace.TraceID)
+	}
+	if _, ok := domain.ParseTraceParent(child.String()); !ok {
+		t.Errorf("ParseTraceParent(%q) failed on a formatted child", child.String())
+	}
+
+	for _, invalid := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+	} {
+		if _, ok := domain.ParseTraceParent(invalid); ok {
+			t.Errorf("ParseTraceParent(%q) expected failure", invalid)
+		}
+	}
+}
+
+func TestParseB3(t *testing.T) {
+	trace, ok := domain.ParseB3("80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-0-05e3ac9a4f6e3b90")
+	if !ok || trace.TraceID != "80f198ee56343ba864fe8b2a57d3eff7" || trace.SpanID != "e457b5a2e4d86bd1" || trace.Sampled || !trace.B3 {
+		t.Fatalf("ParseB3() = %+v, %v", trace, ok)
+	}
+	trace, ok = domain.ParseB3Multi("a3ce929d0e0e4736", "00f067aa0ba902b7", "")
+	if !ok || trace.TraceID != "0000000000000000a3ce929d0e0e4736" || !trace.Sampled {
+		t.Errorf("ParseB3Multi() = %+v, %v, want a widened sampled trace", trace, ok)
+	}
+
+	for _, invalid := range []string{"", "1", "80f198ee56343ba864fe8b2a57d3eff7", "80f198ee56343ba8-e457b5a2e4d86bd1-x", "0000000000000000-e457b5a2e4d86bd1"} {
+		if _, ok := domain.ParseB3(invalid); ok {
+			t.Errorf("ParseB3(%q) expected failure", invalid)
+		}
+	}
+}
+
+func TestTrace_Propagation(t *testing.T) {
+	var headers http.Header
+	handler := middleware.Trace(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, _ := domain.TraceFromContext(r.Context())
+		headers = http.Header{}
+		trace.Child().Propagate(headers.Set)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil)
+	req.Header.Set(domain.TraceParentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(domain.TraceStateHeader, "vendor=opaque")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if headers.Get(domain.TraceStateHeader) != "vendor=opaque" || headers.Get(domain.B3Header) != "" {
+		t.Errorf("headers of a W3C trace = %v, want tracestate passed on and no b3", headers)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil)
+	req.Header.Set(domain.B3TraceIDHeader, "80f198ee56343ba864fe8b2a57d3eff7")
+	req.Header.Set(domain.B3SpanIDHeader, "e457b5a2e4d86bd1")
+	req.Header.Set(domain.B3SampledHeader, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	trace, ok := domain.ParseTraceParent(headers.Get(domain.TraceParentHeader))
+	if !ok || trace.TraceID != "80f198ee56343ba864fe8b2a57d3eff7" || !trace.Sampled {
+		t.Errorf("traceparent of a B3 trace = %q, want the B3 trace continued", headers.Get(domain.TraceParentHeader))
+	}
+	if b3 := headers.Get(domain.B3Header); !strings.HasPrefix(b3, "80f198ee56343ba864fe8b2a57d3eff7-") || strings.Contains(b3, "e457b5a2e4d86bd1") {
+		t.Errorf("b3 = %q, want a new span in the B3 trace", b3)
+	}
+}
+
+func TestMetrics_TraceExemplars(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	metrics := middleware.NewMetrics()
+
+	var seen domain.TraceContext
+	router := mux.NewRouter()
+	router.Use(middleware.Trace)
+	router.Use(metrics.Middleware)
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = domain.TraceFromContext(r.Context())
+	}).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil)
+	req.Header.Set(domain.TraceParentHeader, "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if seen.TraceID != traceID || seen.SpanID == "00f067aa0ba902b7" {
+		t.Errorf("trace in context = %+v, want a gateway span in trace %s", seen, traceID)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/movies/2", nil))
+	if seen.TraceID == "" || seen.TraceID == traceID {
+		t.Errorf("trace without traceparent = %+v, want a new trace", seen)
+	}
+
+	scrape := func(accept string) string {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		metrics.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	body := scrape("application/openmetrics-text; version=1.0.0")
+	for _, want := range []string{
+		`gateway_http_requests_total{method="GET",route="/api/v1/movies/{id}",code="200"} 2`,
+		`gateway_http_request_duration_seconds_bucket{route="/api/v1/movies/{id}",le="0.005"} 2 # {trace_id="`,
+		"# EOF",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("OpenMetrics body missing %q:\n%s", want, body)
+		}
+	}
+
+	body = scrape("text/plain")
+	if strings.Contains(body, "trace_id") || !strings.Contains(body, "# TYPE gateway_http_requests_total counter") {
+		t.Errorf("Prometheus text body:\n%s", body)
+	}
+}
@@ -0,0 +1,828 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// Mock movie service for testing
+type MockMovieService struct {
+	movies       map[int32]*domain.Movie
+	nextID       int32
+	lastFilter   domain.MovieFilter
+	historyErr   error
+	versionCalls int
+	lastPatch    domain.MoviePatch
+}
+
+func NewMockMovieService() *MockMovieService {
+	return &MockMovieService{
+		movies: make(map[int32]*domain.Movie),
+		nextID: 1,
+	}
+}
+
+func (m *MockMovieService) GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error) {
+	m.lastFilter = filter
+	var movies []*domain.Movie
+	for _, movie := range m.movies {
+		movies = append(movies, movie.Copy())
+	}
+	if filter.SkipCount {
+		return movies, domain.UncountedTotal, nil
+	}
+	return movies, int32(len(movies)), nil
+}
+
+func (m *MockMovieService) GetMovie(ctx context.Context, id int32) (*domain.Movie, error) {
+	movie, exists := m.movies[id]
+	if !exists {
+		return nil, domain.ErrMovieNotFound
+	}
+	return movie.Copy(), nil
+}
+
+func (m *MockMovieService) GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	for _, movie := range m.movies {
+		if (source == domain.SourceIMDb && movie.IMDbID == id) || (source == domain.SourceTMDb && movie.TMDbID == id) {
+			return movie.Copy(), nil
+		}
+	}
+	return nil, domain.ErrMovieNotFound
+}
+
+func (m *MockMovieService) CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error) {
+	movie := &domain.Movie{
+		ID:             m.nextID,
+		Title:          input.Title,
+		Year:           input.Year,
+		Version:        1,
+		Regions:        input.Regions,
+		Awards:         input.Awards,
+		Certification:  input.Certification,
+		IMDbID:         input.IMDbID,
+		TMDbID:         input.TMDbID,
+		Genres:         input.Genres,
+		Director:       input.Director,
+		Synopsis:       input.Synopsis,
+		RuntimeMinutes: input.RuntimeMinutes,
+	}
+	for _, existing := range m.movies {
+		if movie.IMDbID != "" && existing.IMDbID == movie.IMDbID {
+			return nil, fmt.Errorf("imdb_id: %w", domain.ErrExternalIDTaken)
+		}
+	}
+	m.movies[movie.ID] = movie
+	m.nextID++
+	return movie.Copy(), nil
+}
+
+func (m *MockMovieService) UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error) {
+	movie := &domain.Movie{ID: id, Title: input.Title, Year: input.Year, Version: 1, Regions: input.Regions}
+	existing, exists := m.movies[id]
+	if exists {
+		movie.Version = existing.Version + 1
+	}
+	m.movies[id] = movie
+	return movie.Copy(), !exists, nil
+}
+
+func (m *MockMovieService) UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error) {
+	existing, exists := m.movies[id]
+	if !exists {
+		return nil, domain.ErrMovieNotFound
+	}
+	return m.UpdateMovieIfVersion(ctx, id, input, existing.Version)
+}
+
+func (m *MockMovieService) UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error) {
+	existing, exists := m.movies[id]
+	if !exists {
+		return nil, domain.ErrMovieNotFound
+	}
+	if existing.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+	movie := &domain.Movie{ID: id, Title: input.Title, Year: input.Year, Version: version + 1, Regions: input.Regions}
+	m.movies[id] = movie
+	return movie.Copy(), nil
+}
+
+func (m *MockMovieService) PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error) {
+	m.lastPatch = patch
+	movie, exists := m.movies[id]
+	if !exists {
+		return nil, domain.ErrMovieNotFound
+	}
+	if patch.ExpectedVersion != nil && movie.Version != *patch.ExpectedVersion {
+		return nil, domain.ErrVersionMismatch
+	}
+	for _, path := range patch.Paths {
+		switch path {
+		case "title":
+			movie.Title = patch.Input.Title
+		case "year":
+			movie.Year = patch.Input.Year
+		case "certification":
+			movie.Certification = patch.Input.Certification
+		}
+	}
+	movie.Version++
+	return movie.Copy(), nil
+}
+
+func (m *MockMovieService) DeleteMovie(ctx context.Context, id int32) error {
+	if _, exists := m.movies[id]; !exists {
+		return domain.ErrMovieNotFound
+	}
+	delete(m.movies, id)
+	return nil
+}
+
+func (m *MockMovieService) DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error {
+	movie, exists := m.movies[id]
+	if !exists {
+		return domain.ErrMovieNotFound
+	}
+	if movie.Version != version {
+		return domain.ErrVersionMismatch
+	}
+	delete(m.movies, id)
+	return nil
+}
+
+func (m *MockMovieService) GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	facets := &domain.MovieFacets{Years: []domain.FacetBucket{}, Genres: []domain.FacetBucket{}}
+	for _, movie := range m.movies {
+		facets.Years = append(facets.Years, domain.FacetBucket{Value: movie.Year[:3] + "0s", Count: 1})
+		for _, genre := range movie.Genres {
+			facets.Genres = append(facets.Genres, domain.FacetBucket{Value: genre, Count: 1})
+		}
+		facets.Total++
+	}
+	return facets, nil
+}
+
+func (m *MockMovieService) GetMovieHistory(ctx context.Context, id int32) (*domain.MovieHistory, error) {
+	if m.historyErr != nil {
+		return nil, m.historyErr
+	}
+	movie, ok := m.movies[id]
+	if !ok {
+		return nil, domain.ErrMovieNotFound
+	}
+	return &domain.MovieHistory{ID: id, Revisions: []domain.MovieRevision{
+		{Sequence: 1, Type: "created", Version: movie.Version, ChangedFields: []string{"title", "year"}, Movie: movie},
+	}}, nil
+}
+
+func (m *MockMovieService) GetMovieVersion(ctx context.Context, id int32) (int64, error) {
+	m.versionCalls++
+	movie, ok := m.movies[id]
+	if !ok {
+		return 0, domain.ErrMovieNotFound
+	}
+	return movie.Version, nil
+}
+
+func newTestHandler() *handlers.MovieHandler {
+	handler, _ := newTestHandlerWithService()
+	return handler
+}
+
+func newTestHandlerWithService() (*handlers.MovieHandler, *MockMovieService) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := NewMockMovieService()
+	return handlers.NewMovieHandler(service, logger), service
+}
+
+func TestMovieHandler_CreateMovie_StrictDecoding(t *testing.T) {
+	handler := newTestHandler()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+		wantField  string
+		wantLine   int
+	}{
+		{
+			name:       "valid body",
+			body:       `{"title": "Movie", "year": "2020"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "numeric year",
+			body:       "{\n  \"title\": \"Movie\",\n  \"year\": 2020\n}",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_field_type",
+			wantField:  "year",
+			wantLine:   3,
+		},
+		{
+			name:       "unknown field",
+			body:       `{"title": "Movie", "year": "2020", "rating": 5}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "unknown_field",
+			wantField:  "rating",
+		},
+		{
+			name:       "malformed JSON",
+			body:       `{"title": "Movie",, "year": "2020"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request_body",
+			wantLine:   1,
+		},
+		{
+			name:       "empty body",
+			body:       ``,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request_body",
+		},
+		{
+			name:       "multiple objects",
+			body:       `{"title": "Movie", "year": "2020"} {}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request_body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(tt.body))
+			handler.CreateMovie(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantError == "" {
+				return
+			}
+
+			var resp handlers.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if resp.Error != tt.wantError {
+				t.Errorf("error = %v, want %v", resp.Error, tt.wantError)
+			}
+			if resp.Field != tt.wantField {
+				t.Errorf("field = %v, want %v", resp.Field, tt.wantField)
+			}
+			if tt.wantLine != 0 && resp.Line != tt.wantLine {
+				t.Errorf("line = %v, want %v", resp.Line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestMovieHandler_CreateMovie_Validation(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+
+	tests := []struct {
+		name       string
+		body       string
+		wantReason string
+		wantFields []string
+	}{
+		{"missing fields", `{"regions": ["BR"]}`, "INVALID_ARGUMENT", []string{"title", "year"}},
+		{"missing title", `{"year": "2020"}`, "INVALID_ARGUMENT", []string{"title"}},
+		{"malformed year", `{"title": "Movie", "year": "20x0"}`, "INVALID_YEAR", []string{"year"}},
+		{"short year", `{"title": "Movie", "year": "202"}`, "INVALID_YEAR", []string{"year"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(tt.body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %v, want %v (body: %s)", rec.Code, http.StatusBadRequest, rec.Body.String())
+			}
+			var resp handlers.ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if resp.Error != "invalid_request" || resp.Reason != tt.wantReason || len(resp.Fields) != len(tt.wantFields) {
+				t.Fatalf("error response = %+v, want %s naming %v", resp, tt.wantReason, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if resp.Fields[i].Field != field {
+					t.Errorf("fields = %+v, want %v", resp.Fields, tt.wantFields)
+				}
+			}
+		})
+	}
+	if len(service.movies) != 0 {
+		t.Errorf("service created %d movies from invalid requests", len(service.movies))
+	}
+}
+
+func TestMovieHandler_DeleteMovie_IfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+	}{
+		{
+			name:       "no precondition",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "matching version",
+			ifMatch:    `"2"`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "stale version",
+			ifMatch:    `"1"`,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "one of several versions",
+			ifMatch:    `"1", "2"`,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "weak tag never matches",
+			ifMatch:    `W/"2"`,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "any version",
+			ifMatch:    `*`,
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, service := newTestHandlerWithService()
+			service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "2020", Version: 2}
+
+			rec := httptest.NewRecorder()
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v1/movies/1", nil), map[string]string{"id": "1"})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			handler.DeleteMovie(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestMovieHandler_GetMovie_Headers(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "2020", Version: 3}
+
+	rec := httptest.NewRecorder()
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil), map[string]string{"id": "1"})
+	handler.GetMovie(rec, req)
+
+	if got := rec.Header().Get("ETag"); got != `"3"` {
+		t.Errorf("ETag = %v, want %v", got, `"3"`)
+	}
+	if got := rec.Header().Get("X-From-Archive"); got != "" {
+		t.Errorf("X-From-Archive = %v, want no header", got)
+	}
+
+	service.movies[4] = &domain.Movie{ID: 4, Title: "Old Movie", Year: "1950", Version: 1, Archived: true}
+	rec = httptest.NewRecorder()
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/4", nil), map[string]string{"id": "4"})
+	handler.GetMovie(rec, req)
+
+	if got := rec.Header().Get("X-From-Archive"); got != "true" {
+		t.Errorf("X-From-Archive = %v, want true", got)
+	}
+
+	rec = httptest.NewRecorder()
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/2", nil), map[string]string{"id": "2"})
+	handler.GetMovie(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestMovieHandler_UpsertMovie(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+
+	upsert := func(id, body, ifMatch string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPut, "/api/v1/movies/"+id, strings.NewReader(body)), map[string]string{"id": id})
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		handler.UpsertMovie(rec, req)
+		return rec
+	}
+
+	if rec := upsert("42", `{"title": "Synced", "year": "2001"}`, ""); rec.Code != http.StatusCreated {
+		t.Errorf("first upsert status = %v, want %v", rec.Code, http.StatusCreated)
+	}
+
+	rec := upsert("42", `{"title": "Synced (Remastered)", "year": "2001"}`, "")
+	if rec.Code != http.StatusOK {
+		t.Errorf("second upsert status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag = %v, want %v", got, `"2"`)
+	}
+
+	// With If-Match the movie is only replaced at the versions listed
+	rec = upsert("42", `{"title": "Synced (Final Cut)", "year": "2001"}`, `"2"`)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("upsert with If-Match status = %v, ETag %v, want 200 and \"3\" (body: %s)", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	if rec := upsert("42", `{"title": "Synced", "year": "2001"}`, `"2"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("upsert with a stale If-Match status = %v, want %v", rec.Code, http.StatusPreconditionFailed)
+	}
+	if rec := upsert("43", `{"title": "New", "year": "2001"}`, `"1"`); rec.Code != http.StatusNotFound {
+		t.Errorf("upsert with If-Match of a missing movie status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+	if movie := service.movies[42]; movie.Title != "Synced (Final Cut)" || movie.Version != 3 {
+		t.Errorf("movie 42 = %+v, want the replacement at version 3", movie)
+	}
+
+	// If-Match: * replaces the movie at any version, but only if it exists
+	rec = upsert("42", `{"title": "Synced (Extended)", "year": "2001"}`, "*")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"4"` {
+		t.Errorf("upsert with If-Match * status = %v, ETag %v, want 200 and \"4\"", rec.Code, rec.Header().Get("ETag"))
+	}
+	if rec := upsert("43", `{"title": "New", "year": "2001"}`, "*"); rec.Code != http.StatusNotFound {
+		t.Errorf("upsert with If-Match * of a missing movie status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+	if _, exists := service.movies[43]; exists {
+		t.Error("upsert with If-Match created movie 43")
+	}
+}
+
+func TestMovieHandler_PatchMovie(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Heat", Year: "1995", Version: 2, Certification: "R"}
+
+	patch := func(body, ifMatch string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodPatch, "/api/v1/movies/1", strings.NewReader(body)), map[string]string{"id": "1"})
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		handler.PatchMovie(rec, req)
+		return rec
+	}
+
+	rec := patch(`{"Year": "1996"}`, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("patch of the year status = %v, ETag %v, want 200 and \"3\" (body: %s)", rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+	}
+	var resp handlers.MovieResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Title != "Heat" || resp.Year != "1996" || resp.Certification != "R" {
+		t.Errorf("patch of the year = %+v, want the title and certification kept", resp)
+	}
+	if !reflect.DeepEqual(service.lastPatch.Paths, []string{"year"}) || service.lastPatch.ExpectedVersion != nil {
+		t.Errorf("patch of the year = %+v, want only the year unconditionally", service.lastPatch)
+	}
+
+	if rec := patch(`{"title": "Heat (1995)", "certification": null}`, `"3"`); rec.Code != http.StatusOK {
+		t.Fatalf("patch with If-Match status = %v, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if got := service.lastPatch; !reflect.DeepEqual(got.Paths, []string{"title", "certification"}) || got.ExpectedVersion == nil || *got.ExpectedVersion != 3 {
+		t.Errorf("patch with If-Match = %+v, want title and certification at version 3", got)
+	}
+	if movie := service.movies[1]; movie.Title != "Heat (1995)" || movie.Certification != "" {
+		t.Errorf("patched movie = %+v, want the new title and no certification", movie)
+	}
+
+	if rec := patch(`{"title": "Heat"}`, `"3"`); rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("patch of a stale version status = %v, want 412", rec.Code)
+	}
+	if rec := patch(`{"budget": 60000000}`, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("patch of an unknown field status = %v, want 400", rec.Code)
+	}
+	if rec := patch(`{"year": 1996}`, ""); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"year"`) {
+		t.Errorf("patch with a number as year status = %v, body %s, want 400 naming the field", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMovieHandler_GetMovieFacets(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "1994", Version: 1, Genres: []string{"Drama"}}
+
+	rec := httptest.NewRecorder()
+	handler.GetMovieFacets(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies/facets", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	var facets domain.MovieFacets
+	if err := json.NewDecoder(rec.Body).Decode(&facets); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if facets.Total != 1 || len(facets.Years) != 1 || facets.Years[0].Value != "1990s" {
+		t.Errorf("facets = %+v, want one movie in the 1990s", facets)
+	}
+	if len(facets.Genres) != 1 || facets.Genres[0] != (domain.FacetBucket{Value: "Drama", Count: 1}) {
+		t.Errorf("genres = %+v, want one drama", facets.Genres)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetMovieFacets(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies/facets?filter=year%3E%3D", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestMovieHandler_GetMovieHistory(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "1994", Version: 1}
+
+	historyRequest := func(id string) *http.Request {
+		return mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/"+id+"/history", nil), map[string]string{"id": id})
+	}
+
+	rec := httptest.NewRecorder()
+	handler.GetMovieHistory(rec, historyRequest("1"))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	var history handlers.MovieHistoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(history.Revisions) != 1 || history.Revisions[0].Type != "created" {
+		t.Errorf("history = %+v, want a single creation", history)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetMovieHistory(rec, historyRequest("2"))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing movie status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+
+	service.historyErr = domain.ErrHistoryUnavailable
+	rec = httptest.NewRecorder()
+	handler.GetMovieHistory(rec, historyRequest("1"))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("unavailable history status = %v, want %v", rec.Code, http.StatusNotImplemented)
+	}
+
+	service.historyErr = domain.ErrServiceUnavailable
+	rec = httptest.NewRecorder()
+	handler.GetMovieHistory(rec, historyRequest("1"))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("unreachable service status = %v with Retry-After %q, want %v with a delay",
+			rec.Code, rec.Header().Get("Retry-After"), http.StatusServiceUnavailable)
+	}
+}
+
+func TestMovieHandler_GetMovies_Region(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	inferred := middleware.Region("CF-IPCountry")(http.HandlerFunc(handler.GetMovies))
+
+	tests := []struct {
+		name   string
+		url    string
+		header string
+		want   string
+	}{
+		{name: "no region", url: "/api/v1/movies", want: ""},
+		{name: "query parameter", url: "/api/v1/movies?region=BR", want: "BR"},
+		{name: "inferred from header", url: "/api/v1/movies", header: "pt", want: "PT"},
+		{name: "query parameter wins", url: "/api/v1/movies?region=BR", header: "PT", want: "BR"},
+		{name: "invalid header ignored", url: "/api/v1/movies", header: "XX1", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("CF-IPCountry", tt.header)
+			}
+			inferred.ServeHTTP(httptest.NewRecorder(), req)
+
+			if service.lastFilter.Region != tt.want {
+				t.Errorf("region = %q, want %q", service.lastFilter.Region, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieHandler_GetMovies_TotalCount(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "2020"}
+
+	rec := httptest.NewRecorder()
+	handler.GetMovies(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil))
+	if rec.Header().Get("X-Total-Count") != "1" || !strings.Contains(rec.Body.String(), `"total":1`) {
+		t.Errorf("X-Total-Count = %q, body %s, want the total in both", rec.Header().Get("X-Total-Count"), rec.Body)
+	}
+	if service.lastFilter.SkipCount {
+		t.Error("SkipCount = true without Prefer: count=none")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil)
+	req.Header.Set("Prefer", "return=minimal, count=none")
+	rec = httptest.NewRecorder()
+	handler.GetMovies(rec, req)
+	if !service.lastFilter.SkipCount {
+		t.Error("SkipCount = false with Prefer: count=none")
+	}
+	if rec.Header().Get("X-Total-Count") != "" || rec.Header().Get("Preference-Applied") != "count=none" || !strings.Contains(rec.Body.String(), `"total":-1`) {
+		t.Errorf("headers = %v, body %s, want an uncounted total and the preference applied", rec.Header(), rec.Body)
+	}
+
+	service.lastFilter = domain.MovieFilter{}
+	rec = httptest.NewRecorder()
+	handler.GetMovies(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies?page=1&include_total=false", nil))
+	if !service.lastFilter.SkipCount {
+		t.Error("SkipCount = false with include_total=false")
+	}
+	if rec.Header().Get("X-Total-Count") != "" || rec.Header().Get("Preference-Applied") != "" || !strings.Contains(rec.Body.String(), `"total":-1`) {
+		t.Errorf("headers = %v, body %s, want an uncounted total", rec.Header(), rec.Body)
+	}
+}
+
+func TestMovieHandler_CreateMovie_AwardsAndCertification(t *testing.T) {
+	handler := newTestHandler()
+
+	body := `{"title": "Movie", "year": "2020", "awards": ["Palme d'Or"], "certification": "PG-13"}`
+	rec := httptest.NewRecorder()
+	handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %v, want %v", rec.Code, http.StatusCreated)
+	}
+	var movie handlers.MovieResponse
+	if err := json.NewDecoder(rec.Body).Decode(&movie); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if movie.Certification != "PG-13" || len(movie.Awards) != 1 || movie.Awards[0] != "Palme d'Or" {
+		t.Errorf("movie = %+v, want awards and certification from the request", movie)
+	}
+}
+
+func TestMovieHandler_CreateMovie_Details(t *testing.T) {
+	handler := newTestHandler()
+
+	body := `{"title": "Heat", "year": "1995", "genres": ["Crime", "Drama"], "director": "Michael Mann", "synopsis": "Robbers and the police.", "runtime_minutes": 170}`
+	rec := httptest.NewRecorder()
+	handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %v, want %v (body: %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var movie handlers.MovieResponse
+	if err := json.NewDecoder(rec.Body).Decode(&movie); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := handlers.MovieResponse{ID: movie.ID, Title: "Heat", Year: "1995", Version: 1, Genres: []string{"Crime", "Drama"},
+		Director: "Michael Mann", Synopsis: "Robbers and the police.", RuntimeMinutes: 170}
+	if !reflect.DeepEqual(movie, want) {
+		t.Errorf("movie = %+v, want %+v", movie, want)
+	}
+}
+
+func TestMovieHandler_ExternalIDs(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+
+	body := `{"title": "Heat", "year": "1995", "imdb_id": "tt0113277", "tmdb_id": "949"}`
+	rec := httptest.NewRecorder()
+	handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %v, want %v (body: %s)", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.CreateMovie(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies",
+		strings.NewReader(`{"title": "Heat", "year": "1986", "imdb_id": "tt0113277"}`)))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "external_id_taken") {
+		t.Errorf("status = %v, body = %s, want %v with external_id_taken", rec.Code, rec.Body.String(), http.StatusConflict)
+	}
+
+	service.movies[4] = &domain.Movie{ID: 4, Title: "Old Movie", Year: "1950", Version: 2, TMDbID: "7", Archived: true}
+
+	tests := []struct {
+		name        string
+		source, id  string
+		wantStatus  int
+		wantID      int32
+		wantArchive string
+	}{
+		{"by IMDb ID", "imdb", "tt0113277", http.StatusOK, 1, ""},
+		{"by TMDb ID", "tmdb", "949", http.StatusOK, 1, ""},
+		{"archived movie", "tmdb", "7", http.StatusOK, 4, "true"},
+		{"unknown ID", "imdb", "tt0000001", http.StatusNotFound, 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/by-external/"+tt.source+"/"+tt.id, nil),
+				map[string]string{"source": tt.source, "externalId": tt.id})
+			handler.GetMovieByExternalID(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %v, want %v (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("X-From-Archive"); got != tt.wantArchive {
+				t.Errorf("X-From-Archive = %v, want %v", got, tt.wantArchive)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var movie handlers.MovieResponse
+			if err := json.NewDecoder(rec.Body).Decode(&movie); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if movie.ID != tt.wantID {
+				t.Errorf("movie ID = %v, want %v", movie.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestMovieHandler_Protobuf(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Heat", Year: "1995", Version: 3, IMDbID: "tt0113277"}
+
+	tests := []struct {
+		accept   string
+		protobuf bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/x-protobuf", true},
+		{"application/x-protobuf, application/json;q=0.5", true},
+		{"application/json, application/x-protobuf;q=0.5", false},
+		{"application/x-protobuf;q=0", false},
+	}
+	for _, tt := range tests {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil), map[string]string{"id": "1"})
+		req.Header.Set("Accept", tt.accept)
+		rec := httptest.NewRecorder()
+		handler.GetMovie(rec, req)
+		if got := strings.HasPrefix(rec.Header().Get("Content-Type"), handlers.ProtobufContentType); got != tt.protobuf {
+			t.Errorf("Accept %q: Content-Type = %q, want protobuf %v", tt.accept, rec.Header().Get("Content-Type"), tt.protobuf)
+		}
+	}
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil), map[string]string{"id": "1"})
+	req.Header.Set("Accept", handlers.ProtobufContentType)
+	rec := httptest.NewRecorder()
+	handler.GetMovie(rec, req)
+	var movie pb.GetMovieResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &movie); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if movie.GetMovie().GetTitle() != "Heat" || movie.GetMovie().GetImdbId() != "tt0113277" || rec.Header().Get("ETag") != `"3"` {
+		t.Errorf("movie = %v, ETag %s, want Heat at version 3", movie.GetMovie(), rec.Header().Get("ETag"))
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/x-protobuf; messageType=movies.v2.GetMovieResponse" {
+		t.Errorf("Content-Type = %q, want the message type", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/movies?include_total=false", nil)
+	req.Header.Set("Accept", handlers.ProtobufContentType)
+	rec = httptest.NewRecorder()
+	handler.GetMovies(rec, req)
+	var list pb.GetMoviesResponse
+	if err := proto.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(list.GetMovies()) != 1 || list.GetTotal() != domain.UncountedTotal {
+		t.Errorf("movies = %v, total %d, want one uncounted movie", list.GetMovies(), list.GetTotal())
+	}
+
+	// Errors stay JSON
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/movies/2", nil), map[string]string{"id": "2"})
+	req.Header.Set("Accept", handlers.ProtobufContentType)
+	rec = httptest.NewRecorder()
+	handler.GetMovie(rec, req)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("status = %d, Content-Type %q, want a JSON 404", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
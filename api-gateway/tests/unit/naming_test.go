@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+)
+
+func TestNaming(t *testing.T) {
+	var received string
+	router := mux.NewRouter()
+	router.Use(middleware.NewNaming([]string{"camel-key"}).Middleware)
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"movies":[{"id":1,"imdb_id":"tt0113277","release_year":1995.50}],"total_count":1,"_warnings":[]}` + "\n"))
+	}).Methods("GET")
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+	router.HandleFunc("/api/v1/movies/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("imdb_id\ntt0113277\n"))
+	}).Methods("GET")
+
+	snake := `{"movies":[{"id":1,"imdb_id":"tt0113277","release_year":1995.50}],"total_count":1,"_warnings":[]}` + "\n"
+	camel := `{"movies":[{"id":1,"imdbId":"tt0113277","releaseYear":1995.50}],"totalCount":1,"_warnings":[]}` + "\n"
+	tests := []struct {
+		name   string
+		naming string
+		key    string
+		target string
+		status int
+		want   string
+	}{
+		{"snake_case by default", "", "", "/api/v1/movies", http.StatusOK, snake},
+		{"camelCase asked", "camelCase", "", "/api/v1/movies", http.StatusOK, camel},
+		{"camelCase preferred by the key", "", "camel-key", "/api/v1/movies", http.StatusOK, camel},
+		{"snake_case asked over the key", "snake_case", "camel-key", "/api/v1/movies", http.StatusOK, snake},
+		{"response that is not JSON", "camelCase", "", "/api/v1/movies/export", http.StatusOK, "imdb_id\ntt0113277\n"},
+		{"unknown naming", "kebab-case", "", "/api/v1/movies", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.naming != "" {
+				req.Header.Set(middleware.NamingHeader, tt.naming)
+			}
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := rec.Body.String(); got != tt.want {
+				t.Errorf("body = %s, want %s", got, tt.want)
+			}
+			if vary := strings.Join(rec.Header().Values("Vary"), ", "); !strings.Contains(vary, middleware.NamingHeader) {
+				t.Errorf("Vary = %q, want it to list %s", vary, middleware.NamingHeader)
+			}
+		})
+	}
+
+	// Request bodies in camelCase reach the handler in snake_case; those that are not JSON
+	// are passed on as they are
+	for body, want := range map[string]string{
+		`{"title": "Heat", "imdbId": "tt0113277", "tmdb_id": "949"}`: `{"title":"Heat","imdb_id":"tt0113277","tmdb_id":"949"}` + "\n",
+		`{"title": "Heat",`: `{"title": "Heat",`,
+	} {
+		req := httptest.NewRequest("POST", "/api/v1/movies", strings.NewReader(body))
+		req.Header.Set(middleware.NamingHeader, "camelCase")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if received != want {
+			t.Errorf("handler read %s, want %s", received, want)
+		}
+	}
+}
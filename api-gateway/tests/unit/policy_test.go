@@ -0,0 +1,542 @@
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/config"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+const testPolicies = `{
+	"default": {"rate_limit": "standard"},
+	"rules": [
+		{"path": "/api/v1/movies", "methods": ["POST"], "auth": true},
+		{"path": "/api/v1/movies/{id}", "methods": ["GET"], "cache_ttl": "1m"},
+		{"path": "/api/v1/slow", "timeout": "10ms"}
+	],
+	"rate_limits": {"standard": {"requests_per_second": 0.001, "burst": 2}},
+	"timeout": "20ms"
+}`
+
+func TestLoadRoutePolicies(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "policies.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	policies, err := config.LoadRoutePolicies(write(testPolicies))
+	if err != nil {
+		t.Fatalf("LoadRoutePolicies() unexpected error = %v", err)
+	}
+	if len(policies.Rules) != 3 || time.Duration(policies.Rules[1].CacheTTL) != time.Minute {
+		t.Errorf("LoadRoutePolicies() = %+v, want three rules", policies)
+	}
+
+	invalid := []string{
+		`{"default": {"rate_limit": "missing"}}`,
+		`{"rules": [{"path": "movies"}]}`,
+		`{"rules": [{"path": "/movies", "methods": ["FETCH"]}]}`,
+		`{"rules": [{"path": "/movies", "timeout": 30}]}`,
+		`{"timeout": "-1s"}`,
+		`{"rate_limits": {"standard": {"requests_per_second": 1, "burst": 0}}}`,
+		`{"rules": [{"path": "/movies", "stale_while_revalidate": "1m"}]}`,
+		`{"rules": [{"path": "/movies", "stale_if_error": "1h"}]}`,
+		`{"deprecations": [{"path": "/movies", "since": "2025-01-01T00:00:00Z"}]}`,
+		`{"deprecations": [{"feature": "year", "path": "/movies", "query": "year", "field": "year", "since": "2025-01-01T00:00:00Z"}]}`,
+		`{"deprecations": [{"feature": "year", "path": "/movies", "field": "year", "response_field": "year", "since": "2025-01-01T00:00:00Z"}]}`,
+		`{"deprecations": [{"feature": "year", "path": "/movies", "since": "2025-01-01T00:00:00Z", "sunset": "2024-01-01T00:00:00Z"}]}`,
+		`{"deprecations": [{"feature": "year", "path": "/movies", "since": "2025-01-01T00:00:00Z", "link": "/docs"}]}`,
+		`{"deprecations": [{"feature": "year", "path": "/movies", "since": "2025-01-01T00:00:00Z"}, {"feature": "year", "path": "/comments", "since": "2025-01-01T00:00:00Z"}]}`,
+	}
+	for _, content := range invalid {
+		if _, err := config.LoadRoutePolicies(write(content)); err == nil {
+			t.Errorf("LoadRoutePolicies(%s) expected error", content)
+		}
+	}
+
+	if _, err := (config.PolicyConfig{File: write(testPolicies)}).Load(); err == nil {
+		t.Error("Load() expected error when auth is required without API keys")
+	}
+}
+
+func TestConfig_DemoProfile(t *testing.T) {
+	t.Setenv("GATEWAY_PROFILE", config.ProfileDemo)
+	t.Setenv("API_KEYS", "secret")
+	t.Setenv("DOWNLOAD_URL_SECRETS", "signing")
+	t.Setenv("READ_ONLY", "false")
+	t.Setenv("DEMO_BURST", "2")
+
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error = %v", err)
+	}
+	if !cfg.Maintenance.ReadOnly || len(cfg.Policy.Keys()) != 0 || len(cfg.Policy.URLSecrets()) != 0 {
+		t.Errorf("demo config = %+v, want read-only without keys or secrets", cfg)
+	}
+	policies, err := cfg.Policy.Load()
+	if err != nil || policies.Default.RateLimit == "" || policies.RateLimits[policies.Default.RateLimit].Burst != 2 {
+		t.Fatalf("Load() = %+v, %v, want the default route limited to the demo burst", policies, err)
+	}
+
+	// Made-up keys don't get fresh buckets
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := mux.NewRouter()
+	router.Use(middleware.NewPolicyEngine(policies, cfg.Policy.Keys(), nil, clock.System{}, logger).Middleware)
+	router.HandleFunc("/api/v1/meta", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil)
+		req.Header.Set("X-API-Key", fmt.Sprintf("guess-%d", i))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the third request rate limited", codes)
+	}
+
+	path := filepath.Join(t.TempDir(), "policies.json")
+	os.WriteFile(path, []byte(`{"default": {"cache_ttl": "1m"}}`), 0o600)
+	cfg.Policy.File = path
+	if _, err := cfg.Policy.Load(); err == nil {
+		t.Error("Load() expected error for demo policies without a default rate limit")
+	}
+
+	t.Setenv("GATEWAY_PROFILE", "public")
+	if err := config.Load().Validate(); err == nil {
+		t.Error("Validate() expected error for an unknown profile")
+	}
+}
+
+func TestConfig_ClientKeys(t *testing.T) {
+	t.Setenv("API_KEYS", "key-a,key-b")
+	t.Setenv("DEPRECATION_WARNING_KEYS", "key-a, *")
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if got := strings.Join(cfg.Policy.WarningKeys(), ","); got != "key-a,*" {
+		t.Errorf("WarningKeys() = %s, want key-a,*", got)
+	}
+
+	t.Setenv("DEPRECATION_WARNING_KEYS", "key-c")
+	if err := config.Load().Validate(); err == nil {
+		t.Error("Validate() expected error for a warning key that is not an API key")
+	}
+
+	t.Setenv("DEPRECATION_WARNING_KEYS", "")
+	t.Setenv("CAMEL_CASE_KEYS", "key-b,key-c")
+	if err := config.Load().Validate(); err == nil {
+		t.Error("Validate() expected error for a camelCase key that is not an API key")
+	}
+}
+
+// stuck is never closed, so handlers reading it block like a hung downstream call
+var stuck = make(chan struct{})
+
+func newPolicyRouter(t *testing.T, calls *int) *mux.Router {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	os.WriteFile(path, []byte(testPolicies), 0o600)
+	policies, err := config.LoadRoutePolicies(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	router := mux.NewRouter()
+	router.Use(middleware.NewPolicyEngine(policies, []string{"secret"}, nil, clock.System{}, logger).Middleware)
+
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/movies", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+	api.HandleFunc("/movies/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Write([]byte(`{"id":1}`))
+	}).Methods("GET")
+	api.HandleFunc("/meta", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	api.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case <-time.After(time.Second):
+		}
+	})
+	api.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		// Ignores its context, like a handler blocked on a downstream call
+		<-stuck
+		w.Write([]byte("late"))
+	})
+	return router
+}
+
+func TestPolicyEngine_Auth(t *testing.T) {
+	router := newPolicyRouter(t, new(int))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST without key status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	for _, header := range [][2]string{{"Authorization", "Bearer secret"}, {"X-API-Key", "secret"}} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/movies", nil)
+		req.Header.Set(header[0], header[1])
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("POST with %s status = %d, want %d", header[0], rec.Code, http.StatusCreated)
+		}
+	}
+}
+
+func TestPolicyEngine_RateLimitAndCache(t *testing.T) {
+	calls := 0
+	router := newPolicyRouter(t, &calls)
+
+	// The rule of the movie route sets no tier, so the default tier does not apply
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		want := "HIT"
+		if i == 0 {
+			want = "MISS"
+		}
+		if got := rec.Header().Get("X-Cache"); got != want {
+			t.Errorf("request %d X-Cache = %q, want %q", i+1, got, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	// Writes drop cached responses
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/movies", nil)
+	req.Header.Set("X-API-Key", "secret")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil))
+	if calls != 2 {
+		t.Errorf("handler called %d times after a write, want 2", calls)
+	}
+
+	// Routes without a rule get the default tier with a burst of two
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil))
+		codes = append(codes, rec.Code)
+	}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want the third request rate limited", codes)
+	}
+}
+
+func TestPolicyEngine_RateLimitHeaders(t *testing.T) {
+	router := newPolicyRouter(t, new(int))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil))
+	if rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("RateLimit-Limit = %q, RateLimit-Remaining = %q, want 2 and 1",
+			rec.Header().Get("RateLimit-Limit"), rec.Header().Get("RateLimit-Remaining"))
+	}
+	if rec.Header().Get("Retry-After") != "" {
+		t.Error("Retry-After set on an allowed request")
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/meta", nil))
+
+	// The tier refills one request every 1000 seconds
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1000" {
+		t.Errorf("Retry-After = %q, want %q", got, "1000")
+	}
+	if got := rec.Header().Get("RateLimit-Policy"); got != "2;w=2000" {
+		t.Errorf("RateLimit-Policy = %q, want %q", got, "2;w=2000")
+	}
+	if rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", rec.Header().Get("RateLimit-Remaining"))
+	}
+}
+
+func TestPolicyEngine_Timeout(t *testing.T) {
+	router := newPolicyRouter(t, new(int))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/slow", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("slow request status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+
+	// The route has no rule, so the global timeout applies
+	start := time.Now()
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stuck", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stuck request answered after %v, want the global timeout", elapsed)
+	}
+	if rec.Code != http.StatusGatewayTimeout || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("stuck request status = %d, Content-Type = %q, want a 504 problem", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body struct {
+		Status int    `json:"status"`
+		Title  string `json:"title"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("problem body: %v", err)
+	}
+	if body.Status != http.StatusGatewayTimeout || body.Title != "Gateway Timeout" || body.Error != "gateway_timeout" {
+		t.Errorf("problem = %+v, want a gateway timeout", body)
+	}
+}
+
+func TestTimeout_PassesResponse(t *testing.T) {
+	handler := middleware.Timeout(time.Second, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/movies", nil))
+	if rec.Code != http.StatusCreated || rec.Header().Get("ETag") != `"1"` || rec.Body.String() != `{"id":1}` {
+		t.Errorf("response = %d %v %q, want the handler's response", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
+
+// testNow is the time of the fake clocks given to the code under test
+var testNow = time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+func TestResponseCache_StaleWhileRevalidate(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	cache := middleware.NewResponseCache(nil, clk)
+	policy := middleware.CachePolicy{TTL: 20 * time.Millisecond, StaleWhileRevalidate: time.Minute}
+
+	var calls atomic.Int32
+	refreshed := make(chan struct{}, 1)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		fmt.Fprintf(w, `{"call":%d}`, n)
+		if n > 1 {
+			refreshed <- struct{}{}
+		}
+	})
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		cache.Serve(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil), next, policy)
+		return rec
+	}
+
+	if rec := serve(); rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first request X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+	clk.Advance(30 * time.Millisecond)
+
+	// The expired response is served right away and refreshed in the background
+	rec := serve()
+	if rec.Header().Get("X-Cache") != "STALE" || rec.Body.String() != `{"call":1}` {
+		t.Fatalf("expired request = %q %s, want the stale response", rec.Header().Get("X-Cache"), rec.Body)
+	}
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale response was not refreshed")
+	}
+
+	// The refresh is stored once the handler returns
+	deadline := time.Now().Add(time.Second)
+	for rec = serve(); rec.Header().Get("X-Cache") != "HIT" && time.Now().Before(deadline); rec = serve() {
+		time.Sleep(time.Millisecond)
+	}
+	if rec.Body.String() != `{"call":2}` {
+		t.Errorf("refreshed response = %s, want the second call", rec.Body)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler called %d times, want 2", calls.Load())
+	}
+
+	stats := cache.Stats()
+	if stats.Misses != 1 || stats.StaleHits < 1 || stats.Refreshes != 1 || stats.RefreshFailures != 0 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v, want one miss, one refresh and stale hits", stats)
+	}
+}
+
+func TestResponseCache_StaleIfError(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	cache := middleware.NewResponseCache(nil, clk)
+	policy := middleware.CachePolicy{TTL: 10 * time.Millisecond, StaleIfError: time.Minute}
+
+	status := http.StatusOK
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"status":%d}`, status)
+	})
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		cache.Serve(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil), next, policy)
+		return rec
+	}
+
+	serve()
+	clk.Advance(20 * time.Millisecond)
+
+	// The movie service is down: the expired response is served with a warning
+	status = http.StatusServiceUnavailable
+	rec := serve()
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"status":200}` || rec.Header().Get("Warning") == "" {
+		t.Errorf("request while failing = %d %s, Warning %q, want the stale response with a warning", rec.Code, rec.Body, rec.Header().Get("Warning"))
+	}
+
+	// Client errors are not masked
+	status = http.StatusNotFound
+	if rec := serve(); rec.Code != http.StatusNotFound || rec.Header().Get("Warning") != "" {
+		t.Errorf("request with a client error = %d, Warning %q, want the error", rec.Code, rec.Header().Get("Warning"))
+	}
+
+	// Once the movie service is back, its response replaces the stale one
+	status = http.StatusOK
+	if rec := serve(); rec.Header().Get("X-Cache") != "MISS" || rec.Header().Get("Warning") != "" {
+		t.Errorf("request after recovery X-Cache = %q, Warning %q, want a fresh response", rec.Header().Get("X-Cache"), rec.Header().Get("Warning"))
+	}
+	if rec := serve(); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("request after recovery X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	if stats := cache.Stats(); stats.StaleIfErrorHits != 1 {
+		t.Errorf("Stats().StaleIfErrorHits = %d, want 1", stats.StaleIfErrorHits)
+	}
+}
+
+func TestPolicyEngine_RevalidateMovie(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "1994", Version: 1}
+
+	policies := &config.RoutePolicies{Rules: []config.RouteRule{{
+		Path:        "/api/v1/movies/{id}",
+		Methods:     []string{"GET"},
+		RoutePolicy: config.RoutePolicy{CacheTTL: config.Duration(20 * time.Millisecond)},
+	}}}
+	clk := clock.NewFake(testNow)
+	engine := middleware.NewPolicyEngine(policies, nil, nil, clk, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	engine.Revalidate("/api/v1/movies/{id:[0-9]+}", handler.MovieIsCurrent)
+
+	router := mux.NewRouter()
+	router.Use(engine.Middleware)
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}", handler.GetMovie).Methods("GET")
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/movies/1", nil))
+		return rec
+	}
+
+	if rec := get(); rec.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first request X-Cache = %q, want MISS", rec.Header().Get("X-Cache"))
+	}
+
+	// An unchanged movie is checked by version and served from the cache
+	clk.Advance(30 * time.Millisecond)
+	rec := get()
+	if rec.Header().Get("X-Cache") != "REVALIDATED" || rec.Header().Get("ETag") != `"1"` || service.versionCalls != 1 {
+		t.Errorf("unchanged movie X-Cache = %q, ETag = %q after %d version checks, want REVALIDATED",
+			rec.Header().Get("X-Cache"), rec.Header().Get("ETag"), service.versionCalls)
+	}
+	if rec := get(); rec.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("revalidated response X-Cache = %q, want HIT", rec.Header().Get("X-Cache"))
+	}
+
+	// A changed movie is fetched again
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie (Remastered)", Year: "1994", Version: 2}
+	clk.Advance(30 * time.Millisecond)
+	rec = get()
+	if rec.Header().Get("X-Cache") != "MISS" || rec.Header().Get("ETag") != `"2"` {
+		t.Errorf("changed movie X-Cache = %q, ETag = %q, want MISS with the new version",
+			rec.Header().Get("X-Cache"), rec.Header().Get("ETag"))
+	}
+
+	stats := engine.Cache().Stats()
+	if stats.Revalidations != 2 || stats.Revalidated != 1 {
+		t.Errorf("Stats() = %+v, want two revalidations, one current", stats)
+	}
+}
+
+func TestPolicyEngine_HeadMovie(t *testing.T) {
+	handler, service := newTestHandlerWithService()
+	service.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "1994", Version: 3}
+
+	policies := &config.RoutePolicies{Rules: []config.RouteRule{{
+		Path:        "/api/v1/movies/{id}",
+		Methods:     []string{"GET"},
+		RoutePolicy: config.RoutePolicy{CacheTTL: config.Duration(time.Minute)},
+	}}}
+	engine := middleware.NewPolicyEngine(policies, nil, nil, clock.NewFake(testNow), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := mux.NewRouter()
+	router.Use(engine.Middleware)
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}", handler.GetMovie).Methods("GET")
+	router.HandleFunc("/api/v1/movies/{id:[0-9]+}", handler.HeadMovie).Methods("HEAD")
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	// Without a cached response, only the version of the movie is read
+	rec := serve(http.MethodHead, "/api/v1/movies/1")
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` || rec.Body.Len() != 0 {
+		t.Fatalf("HEAD = %d, ETag %q, body %q, want 200 with the ETag of version 3", rec.Code, rec.Header().Get("ETag"), rec.Body)
+	}
+	if service.versionCalls != 1 {
+		t.Errorf("version read %d times, want 1", service.versionCalls)
+	}
+	if rec := serve(http.MethodHead, "/api/v1/movies/2"); rec.Code != http.StatusNotFound {
+		t.Errorf("HEAD of a missing movie = %d, want 404", rec.Code)
+	}
+	// Archived movies keep their version, read like any other
+	service.movies[4] = &domain.Movie{ID: 4, Title: "Old Movie", Year: "1950", Version: 2, Archived: true}
+	if rec := serve(http.MethodHead, "/api/v1/movies/4"); rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"2"` || service.versionCalls != 3 {
+		t.Errorf("HEAD of an archived movie = %d, ETag %q after %d version reads, want 200 with the ETag of version 2 from its version",
+			rec.Code, rec.Header().Get("ETag"), service.versionCalls)
+	}
+
+	// A cached GET response answers HEAD with its headers and length
+	get := serve(http.MethodGet, "/api/v1/movies/1")
+	service.versionCalls = 0
+	rec = serve(http.MethodHead, "/api/v1/movies/1")
+	if rec.Header().Get("X-Cache") != "HIT" || rec.Header().Get("ETag") != get.Header().Get("ETag") || rec.Body.Len() != 0 {
+		t.Errorf("HEAD after GET X-Cache = %q, ETag %q, want the cached headers", rec.Header().Get("X-Cache"), rec.Header().Get("ETag"))
+	}
+	if got, want := rec.Header().Get("Content-Length"), fmt.Sprint(get.Body.Len()); got != want {
+		t.Errorf("Content-Length = %s, want %s", got, want)
+	}
+	if service.versionCalls != 0 {
+		t.Errorf("version read %d times for a cached movie, want 0", service.versionCalls)
+	}
+}
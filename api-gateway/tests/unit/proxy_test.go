@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/config"
+)
+
+func TestProxyConfig_ParseRoutes(t *testing.T) {
+	routes, err := config.ProxyConfig{Routes: "/api/v1/ratings=http://ratings:8080, /reviews.ReviewService/=grpc://reviews:50052"}.ParseRoutes()
+	if err != nil {
+		t.Fatalf("ParseRoutes() unexpected error = %v", err)
+	}
+	if len(routes) != 2 || routes[0].Prefix != "/api/v1/ratings" || routes[0].GRPC() || !routes[1].GRPC() {
+		t.Errorf("ParseRoutes() = %+v, want an HTTP and a gRPC route", routes)
+	}
+
+	invalid := []string{
+		"ratings=http://ratings:8080",
+		"/api/v1/ratings",
+		"/=http://ratings:8080",
+		"/api/v1/ratings=ftp://ratings",
+		"/api/v1/ratings=http://",
+		"/a=http://one:80,/a=http://two:80",
+	}
+	for _, spec := range invalid {
+		if _, err := (config.ProxyConfig{Routes: spec}).ParseRoutes(); err == nil {
+			t.Errorf("ParseRoutes(%q) expected error", spec)
+		}
+	}
+}
+
+func TestProxyHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var gotPath, gotForwarded string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotForwarded = r.URL.Path, r.Header.Get("X-Forwarded-Host")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	// Proxied prefixes share the API prefix with the gateway's own routes
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/movies", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.PathPrefix("/api/v1/ratings").Handler(handlers.NewProxyHandler(backendURL, logger))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://gateway.local/api/v1/ratings/42", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %v, want the backend status %v", rec.Code, http.StatusTeapot)
+	}
+	if gotPath != "/api/v1/ratings/42" || gotForwarded != "gateway.local" {
+		t.Errorf("backend got path %q forwarded host %q", gotPath, gotForwarded)
+	}
+
+	backend.Close()
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ratings/42", nil))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status with backend down = %v, want %v", rec.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,57 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+)
+
+func TestReadiness(t *testing.T) {
+	readiness := handlers.NewReadiness()
+
+	rec := httptest.NewRecorder()
+	readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status before warm-up = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	readiness.MarkReady()
+	rec = httptest.NewRecorder()
+	readiness.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status after warm-up = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestWarmup(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var requested []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			t.Errorf("warm-up request %s without the API key", r.URL)
+		}
+		requested = append(requested, r.URL.String())
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	header := http.Header{"X-Api-Key": []string{"secret"}}
+	handlers.Warmup(context.Background(), handler, []string{"/api/v1/movies", "/missing", "/api/v1/movies?page=2"}, header, logger)
+
+	want := []string{"/api/v1/movies", "/missing", "/api/v1/movies?page=2"}
+	if len(requested) != len(want) {
+		t.Fatalf("requested %v, want %v", requested, want)
+	}
+	for i := range want {
+		if requested[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, requested[i], want[i])
+		}
+	}
+}
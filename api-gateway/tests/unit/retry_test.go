@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/proto/retry"
+)
+
+var errTransient = errors.New("connection reset")
+
+// failing returns an operation failing with err on its first n calls, counting the calls
+func failing(n int, err error, calls *int) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestPolicy_Do(t *testing.T) {
+	permanent := errors.New("invalid request")
+
+	tests := []struct {
+		name      string
+		policy    retry.Policy
+		failures  int
+		err       error
+		wantErr   error
+		wantCalls int
+	}{
+		{"success", retry.Policy{MaxAttempts: 3}, 0, errTransient, nil, 1},
+		{"retried until success", retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}, 2, errTransient, nil, 3},
+		{"attempts exhausted", retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}, 5, errTransient, errTransient, 3},
+		{"permanent", retry.Policy{MaxAttempts: 3}, 5, retry.Permanent(permanent), permanent, 1},
+		{"not retryable", retry.Policy{MaxAttempts: 3, Retryable: func(err error) bool { return false }}, 5, errTransient, errTransient, 1},
+		{"max elapsed", retry.Policy{BaseDelay: time.Hour, MaxElapsed: time.Minute}, 5, errTransient, errTransient, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			err := tt.policy.Do(context.Background(), failing(tt.failures, tt.err, &calls))
+			if err != tt.wantErr {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() made %d attempts, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPolicy_DoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := retry.Policy{BaseDelay: time.Hour, OnRetry: func(int, time.Duration, error) { cancel() }}
+
+	var calls int
+	if err := policy.Do(ctx, failing(5, errTransient, &calls)); err != errTransient || calls != 1 {
+		t.Errorf("Do() = %v after %d attempts, want the last error once canceled", err, calls)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	budget := retry.NewBudget(4, 0.5)
+	policy := retry.Policy{MaxAttempts: 10, Budget: budget}
+
+	var calls int
+	policy.Do(context.Background(), failing(10, errTransient, &calls))
+	if calls != 2 || budget.Allow() {
+		t.Fatalf("Do() made %d attempts, want retries stopped once half the budget is spent", calls)
+	}
+
+	for i := 0; i < 2; i++ {
+		policy.Do(context.Background(), func(context.Context) error { return nil })
+	}
+	if !budget.Allow() {
+		t.Error("Allow() = false after successes, want the budget refilled")
+	}
+}
+
+func TestBackoff_Next(t *testing.T) {
+	backoff := retry.Policy{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond}.Backoff()
+
+	prev := time.Duration(0)
+	for i := 0; i < 50; i++ {
+		delay := backoff.Next()
+		if delay < 10*time.Millisecond || delay > 100*time.Millisecond || (prev > 0 && delay > 3*prev) {
+			t.Fatalf("Next() = %v after %v, want between the base delay and min(3x, max)", delay, prev)
+		}
+		prev = delay
+	}
+}
@@ -0,0 +1,36 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/api-gateway/internal/config"
+)
+
+func TestConfig_CheckDependencies(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"localhost:50051", false},
+		{"dns:///localhost:50051", false},
+		{"127.0.0.1:50051", false},
+		{"unix:///var/run/movies.sock", false},
+		{"movies-service.invalid:50051", true},
+		{"dns:///movies-service.invalid:50051", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			cfg := &config.Config{MovieService: config.MovieServiceConfig{GRPCAddress: tt.address}}
+			err := cfg.CheckDependencies(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckDependencies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "MOVIE_SERVICE_GRPC_ADDRESS") {
+				t.Errorf("CheckDependencies() error = %v, want it to name the setting", err)
+			}
+		})
+	}
+}
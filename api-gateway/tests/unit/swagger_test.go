@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/swaggo/swag"
+
+	_ "github.com/movie-microservice/api-gateway/docs"
+)
+
+func TestSwaggerDoc_TypedErrors(t *testing.T) {
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Responses map[string]struct {
+				Schema struct {
+					Ref string `json:"$ref"`
+				} `json:"schema"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	raw, err := swag.ReadDoc()
+	if err != nil {
+		t.Fatalf("ReadDoc() error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("generated doc is not JSON: %v", err)
+	}
+
+	for _, path := range []string{"/api/v1/movies", "/api/v1/movies/{id}", "/api/v1/movies/{id}/comments",
+		"/api/v1/imports", "/api/v1/jobs/{jobId}", "/admin/sync/conflicts/{conflictId}", "/health"} {
+		if _, ok := doc.Paths[path]; !ok {
+			t.Errorf("doc does not describe %s; regenerate it with make docs", path)
+		}
+	}
+	for path, operations := range doc.Paths {
+		if path == "/admin/maintenance" || path == "/ready" || path == "/health" {
+			continue
+		}
+		for method, operation := range operations {
+			// HEAD responses have no body
+			if method == "head" {
+				continue
+			}
+			for status, response := range operation.Responses {
+				if status[0] >= '4' && response.Schema.Ref != "#/definitions/handlers.ErrorResponse" {
+					t.Errorf("%s %s: %s response is %q, want handlers.ErrorResponse", method, path, status, response.Schema.Ref)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/api-gateway/internal/core/services"
+)
+
+// MockSyncPort stands in for the catalog sync service of the movie service
+type MockSyncPort struct {
+	conflicts []*domain.SyncConflict
+	movies    map[int32]*domain.Movie
+}
+
+func (m *MockSyncPort) ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	return m.conflicts, int32(len(m.conflicts)), nil
+}
+
+func (m *MockSyncPort) ResolveSyncConflict(ctx context.Context, id, resolution string) (*domain.Movie, error) {
+	for i, c := range m.conflicts {
+		if c.ID == id {
+			m.conflicts = append(m.conflicts[:i], m.conflicts[i+1:]...)
+			if resolution == domain.ResolutionTakeRemote {
+				m.movies[c.MovieID] = &domain.Movie{ID: c.MovieID, Title: c.Remote.Title, Year: c.Remote.Year}
+			}
+			return m.movies[c.MovieID], nil
+		}
+	}
+	return nil, domain.ErrSyncConflictNotFound
+}
+
+func newSyncRouter(port *MockSyncPort) *mux.Router {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	handler := handlers.NewSyncHandler(services.NewSyncService(port, domain.DefaultPagination(), logger), logger)
+
+	router := mux.NewRouter()
+	catalogSync := router.PathPrefix("/admin/sync").Subrouter()
+	catalogSync.Use(middleware.AdminOnly([]string{"secret"}, nil))
+	catalogSync.HandleFunc("/conflicts", handler.GetSyncConflicts).Methods("GET")
+	catalogSync.HandleFunc("/conflicts/{conflictId}", handler.ResolveSyncConflict).Methods("POST")
+	return router
+}
+
+func TestSyncHandler_Conflicts(t *testing.T) {
+	port := &MockSyncPort{
+		conflicts: []*domain.SyncConflict{
+			{ID: "s1", MovieID: 1, Remote: domain.RemoteMovie{Source: "tmdb", ExternalID: "949", Title: "Heat", Year: "1995", Certification: "R"}},
+			{ID: "s2", MovieID: 2, Remote: domain.RemoteMovie{Source: "tmdb", ExternalID: "598", Title: "City of God", Year: "2002"}},
+		},
+		movies: map[int32]*domain.Movie{
+			1: {ID: 1, Title: "Heat", Year: "1995"},
+		},
+	}
+	router := newSyncRouter(port)
+
+	if rec := serveComments(router, "GET", "/admin/sync/conflicts", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET conflicts without key status = %d, want 401", rec.Code)
+	}
+	rec := serveComments(router, "GET", "/admin/sync/conflicts", "", "secret")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Total-Count") != "2" || !strings.Contains(rec.Body.String(), `"external_id":"949"`) {
+		t.Errorf("GET conflicts status = %d, body %s, want both conflicts with their remote movies", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"unknown resolution", "/admin/sync/conflicts/s1", `{"resolution":"merge"}`, http.StatusBadRequest},
+		{"unknown field", "/admin/sync/conflicts/s1", `{"resolution":"keep_local","force":true}`, http.StatusBadRequest},
+		{"unknown conflict", "/admin/sync/conflicts/s9", `{"resolution":"keep_local"}`, http.StatusNotFound},
+		{"take remote", "/admin/sync/conflicts/s1", `{"resolution":"take_remote"}`, http.StatusOK},
+		{"resolved twice", "/admin/sync/conflicts/s1", `{"resolution":"keep_local"}`, http.StatusNotFound},
+		{"keep deleted movie", "/admin/sync/conflicts/s2", `{"resolution":"keep_local"}`, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveComments(router, "POST", tt.path, tt.body, "secret"); rec.Code != tt.want {
+				t.Errorf("POST resolution status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+	if len(port.conflicts) != 0 {
+		t.Errorf("conflicts left = %v, want none", port.conflicts)
+	}
+}
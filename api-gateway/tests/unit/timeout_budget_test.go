@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/config"
+)
+
+func TestServerConfig_ParseTrustedNetworks(t *testing.T) {
+	networks, err := config.ServerConfig{TrustedNetworks: "10.0.0.0/8, 192.0.2.7,fd00::/8"}.ParseTrustedNetworks()
+	if err != nil {
+		t.Fatalf("ParseTrustedNetworks() unexpected error = %v", err)
+	}
+	if len(networks) != 3 || networks[1] != netip.MustParsePrefix("192.0.2.7/32") {
+		t.Errorf("ParseTrustedNetworks() = %v, want three networks", networks)
+	}
+
+	if _, err := (config.ServerConfig{TrustedNetworks: "10.0.0.0/33"}).ParseTrustedNetworks(); err == nil {
+		t.Error("ParseTrustedNetworks() expected error for an invalid network")
+	}
+}
+
+func TestTimeoutBudget(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+	budget := middleware.TimeoutBudget(trusted, 5*time.Millisecond)
+
+	var remaining time.Duration
+	var hasDeadline bool
+	handler := budget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deadline time.Time
+		deadline, hasDeadline = r.Context().Deadline()
+		remaining = time.Until(deadline)
+	}))
+
+	request := func(remoteAddr, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/movies", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(middleware.TimeoutBudgetHeader, value)
+		rec := httptest.NewRecorder()
+		hasDeadline = false
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	request("192.0.2.10:4000", "200")
+	if !hasDeadline || remaining > 195*time.Millisecond || remaining < 100*time.Millisecond {
+		t.Errorf("trusted caller deadline in %v, want just under 195ms", remaining)
+	}
+
+	request("203.0.113.10:4000", "200")
+	if hasDeadline {
+		t.Error("untrusted caller budget applied")
+	}
+
+	if rec := request("192.0.2.10:4000", "soon"); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid budget status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := request("192.0.2.10:4000", "3"); rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("exhausted budget status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestProxyHandler_TimeoutBudget(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var forwarded string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get(middleware.TimeoutBudgetHeader)
+	}))
+	defer backend.Close()
+	backendURL, _ := url.Parse(backend.URL)
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+	handler := middleware.TimeoutBudget(trusted, 0)(handlers.NewProxyHandler(backendURL, logger))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/ratings", nil)
+	req.RemoteAddr = "192.0.2.10:4000"
+	req.Header.Set(middleware.TimeoutBudgetHeader, "1000")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if ms, err := strconv.Atoi(forwarded); err != nil || ms <= 0 || ms > 1000 {
+		t.Errorf("forwarded budget = %q, want the remaining milliseconds", forwarded)
+	}
+
+	// A budget the gateway did not honour is not passed on
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/ratings", nil)
+	req.RemoteAddr = "203.0.113.10:4000"
+	req.Header.Set(middleware.TimeoutBudgetHeader, "1000")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if forwarded != "" {
+		t.Errorf("forwarded budget of untrusted caller = %q, want none", forwarded)
+	}
+}
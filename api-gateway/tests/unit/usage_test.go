@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/handlers"
+	"github.com/movie-microservice/api-gateway/internal/adapters/http/middleware"
+	"github.com/movie-microservice/api-gateway/internal/adapters/usage"
+	"github.com/movie-microservice/api-gateway/internal/core/domain"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestUsage(t *testing.T) {
+	clk := clock.NewFake(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC))
+	keys := []string{"key-a", "key-b"}
+	store := usage.NewMemoryStore(7, clk)
+	usageHandler := handlers.NewUsageHandler(store, keys, clk)
+
+	router := mux.NewRouter()
+	router.Use(middleware.Usage(store, nil, keys, clk))
+	router.HandleFunc("/api/v1/movies", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Write([]byte("0123456789"))
+	}).Methods("POST")
+	router.HandleFunc("/api/v1/movies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusNotFound
+		if mux.Vars(r)["id"] == "500" {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+	}).Methods("GET")
+	router.Handle("/admin/usage", middleware.AdminOnly([]string{"admin"}, keys)(http.HandlerFunc(usageHandler.GetUsage))).Methods("GET")
+	router.Handle("/me/usage", middleware.KeyRequired(keys)(http.HandlerFunc(usageHandler.GetMyUsage))).Methods("GET")
+
+	send := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send("POST", "/api/v1/movies", "key-a", `{"title":"Heat"}`)
+	clk.Advance(24 * time.Hour)
+	send("POST", "/api/v1/movies", "key-a", `{}`)
+	send("GET", "/api/v1/movies/404", "key-a", "")
+	send("GET", "/api/v1/movies/500", "key-a", "")
+	// Requests without a valid key are not recorded
+	send("POST", "/api/v1/movies", "", `{}`)
+	send("POST", "/api/v1/movies", "made-up", `{}`)
+
+	rec := send("GET", "/me/usage?from=2025-06-01", "key-a", "")
+	var mine handlers.KeyUsageResponse
+	if err := json.NewDecoder(rec.Body).Decode(&mine); err != nil {
+		t.Fatalf("status %d: %v", rec.Code, err)
+	}
+	// The usage request itself is recorded only once it is answered
+	wantTotal := domain.UsageCounts{Requests: 4, ClientErrors: 1, ServerErrors: 1, BytesIn: 18, BytesOut: 20}
+	if mine.KeyID != domain.APIKeyID("key-a") || mine.From != "2025-06-01" || mine.To != "2025-06-03" || mine.Total.UsageCounts != wantTotal {
+		t.Errorf("usage = %+v, want %+v from 2025-06-01 to 2025-06-03", mine, wantTotal)
+	}
+	if mine.Total.ErrorRate != 0.5 {
+		t.Errorf("error rate = %v, want 0.5", mine.Total.ErrorRate)
+	}
+	if len(mine.Days) != 3 || mine.Days[0].Requests != 0 || mine.Days[1].Requests != 1 || mine.Days[2].Requests != 3 {
+		t.Errorf("days = %+v, want 0, 1 and 3 requests", mine.Days)
+	}
+
+	rec = send("GET", "/admin/usage?key=key-a&from=2025-06-03&to=2025-06-03", "admin", "")
+	var report handlers.UsageListResponse
+	json.NewDecoder(rec.Body).Decode(&report)
+	if len(report.Keys) != 1 || report.Keys[0].Total.Requests != 4 {
+		t.Errorf("report = %+v, want the 4 requests of key-a on 2025-06-03", report)
+	}
+	send("GET", "/me/usage", "key-b", "")
+	rec = send("GET", "/admin/usage", "admin", "")
+	json.NewDecoder(rec.Body).Decode(&report)
+	if len(report.Keys) != 2 || report.Keys[1].KeyID != domain.APIKeyID("key-b") || report.Keys[1].Total.Requests != 1 {
+		t.Errorf("report = %+v, want both keys, with the usage request of key-b", report)
+	}
+	// Client keys can read their own usage only
+	if rec := send("GET", "/admin/usage", "key-b", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GET /admin/usage with a client key status = %d, want 403", rec.Code)
+	}
+
+	for target, status := range map[string]int{
+		"/admin/usage?key=key-c":                       http.StatusNotFound,
+		"/admin/usage?from=2025-06-04&to=2025-06-03":   http.StatusBadRequest,
+		"/admin/usage?from=yesterday":                  http.StatusBadRequest,
+		"/admin/usage?key=" + domain.APIKeyID("key-a"): http.StatusOK,
+	} {
+		if rec := send("GET", target, "admin", ""); rec.Code != status {
+			t.Errorf("GET %s status = %d, want %d", target, rec.Code, status)
+		}
+	}
+	if rec := send("GET", "/me/usage", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /me/usage without a key status = %d, want 401", rec.Code)
+	}
+
+	// Days past the retention are dropped
+	clk.Advance(7 * 24 * time.Hour)
+	days := store.Daily(domain.APIKeyID("key-a"), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC))
+	for _, day := range days {
+		if day.Requests != 0 {
+			t.Errorf("day %s has %d requests, want it dropped", day.Date, day.Requests)
+		}
+	}
+}
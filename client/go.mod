@@ -0,0 +1,3 @@
+module github.com/movie-microservice/client
+
+go 1.21
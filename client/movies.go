@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Movie struct {
+	ID             int32    `json:"id"`
+	Title          string   `json:"title"`
+	Year           string   `json:"year"`
+	Version        int64    `json:"version"`
+	Regions        []string `json:"regions,omitempty"`
+	Awards         []string `json:"awards,omitempty"`
+	Certification  string   `json:"certification,omitempty"`
+	IMDbID         string   `json:"imdb_id,omitempty"`
+	TMDbID         string   `json:"tmdb_id,omitempty"`
+	Genres         []string `json:"genres,omitempty"`
+	Director       string   `json:"director,omitempty"`
+	Synopsis       string   `json:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty"`
+}
+
+type CreateMovieInput struct {
+	Title          string   `json:"title"`
+	Year           string   `json:"year"`
+	Regions        []string `json:"regions,omitempty"`
+	Awards         []string `json:"awards,omitempty"`
+	Certification  string   `json:"certification,omitempty"`
+	IMDbID         string   `json:"imdb_id,omitempty"`
+	TMDbID         string   `json:"tmdb_id,omitempty"`
+	Genres         []string `json:"genres,omitempty"`
+	Director       string   `json:"director,omitempty"`
+	Synopsis       string   `json:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty"`
+}
+
+// MoviePatch holds the fields PatchMovie changes; nil fields keep their values, and
+// pointers to empty values clear them
+type MoviePatch struct {
+	Title          *string   `json:"title,omitempty"`
+	Year           *string   `json:"year,omitempty"`
+	Regions        *[]string `json:"regions,omitempty"`
+	Awards         *[]string `json:"awards,omitempty"`
+	Certification  *string   `json:"certification,omitempty"`
+	IMDbID         *string   `json:"imdb_id,omitempty"`
+	TMDbID         *string   `json:"tmdb_id,omitempty"`
+	Genres         *[]string `json:"genres,omitempty"`
+	Director       *string   `json:"director,omitempty"`
+	Synopsis       *string   `json:"synopsis,omitempty"`
+	RuntimeMinutes *int32    `json:"runtime_minutes,omitempty"`
+}
+
+// External catalogs of GetMovieByExternalID
+const (
+	SourceIMDb = "imdb"
+	SourceTMDb = "tmdb"
+)
+
+type ListMoviesOptions struct {
+	Page  int32
+	Limit int32
+	// Filter is a filter expression such as `year>=2000 AND title~"star"`
+	Filter string
+	// Region restricts the listing to movies available in the region, e.g. "BR"
+	Region        string
+	Certification string
+}
+
+type MovieList struct {
+	Movies []*Movie `json:"movies"`
+	Total  int32    `json:"total"`
+}
+
+// ListMovies returns a page of movies
+func (c *Client) ListMovies(ctx context.Context, opts ListMoviesOptions) (*MovieList, error) {
+	query := url.Values{}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(int(opts.Page)))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(int(opts.Limit)))
+	}
+	if opts.Filter != "" {
+		query.Set("filter", opts.Filter)
+	}
+	if opts.Region != "" {
+		query.Set("region", opts.Region)
+	}
+	if opts.Certification != "" {
+		query.Set("certification", opts.Certification)
+	}
+
+	var list MovieList
+	if err := c.do(ctx, http.MethodGet, "/api/v1/movies", query, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int32  `json:"count"`
+}
+
+type MovieFacets struct {
+	Years []FacetBucket `json:"years"`
+	Total int32         `json:"total"`
+}
+
+// GetMovieFacets returns movie counts per decade, restricted to movies matching filter when not empty
+func (c *Client) GetMovieFacets(ctx context.Context, filter string) (*MovieFacets, error) {
+	query := url.Values{}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+
+	var facets MovieFacets
+	if err := c.do(ctx, http.MethodGet, "/api/v1/movies/facets", query, nil, &facets); err != nil {
+		return nil, err
+	}
+	return &facets, nil
+}
+
+// GetMovie returns the movie with the given ID
+func (c *Client) GetMovie(ctx context.Context, id int32) (*Movie, error) {
+	var movie Movie
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/movies/%d", id), nil, nil, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// GetMovieByExternalID returns the movie with the given ID in an external catalog,
+// SourceIMDb or SourceTMDb
+func (c *Client) GetMovieByExternalID(ctx context.Context, source, id string) (*Movie, error) {
+	var movie Movie
+	path := "/api/v1/movies/by-external/" + url.PathEscape(source) + "/" + url.PathEscape(id)
+	if err := c.do(ctx, http.MethodGet, path, nil, nil, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// CreateMovie creates a new movie
+func (c *Client) CreateMovie(ctx context.Context, input CreateMovieInput) (*Movie, error) {
+	var movie Movie
+	if err := c.do(ctx, http.MethodPost, "/api/v1/movies", nil, input, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// UpsertMovie creates or replaces the movie with the given ID, reporting whether it was created
+func (c *Client) UpsertMovie(ctx context.Context, id int32, input CreateMovieInput) (*Movie, bool, error) {
+	var movie Movie
+	status, err := c.doWithStatus(ctx, http.MethodPut, fmt.Sprintf("/api/v1/movies/%d", id), nil, input, &movie)
+	if err != nil {
+		return nil, false, err
+	}
+	return &movie, status == http.StatusCreated, nil
+}
+
+// PatchMovie changes only the fields set in the patch of the movie with the given ID
+func (c *Client) PatchMovie(ctx context.Context, id int32, patch MoviePatch) (*Movie, error) {
+	var movie Movie
+	if err := c.do(ctx, http.MethodPatch, fmt.Sprintf("/api/v1/movies/%d", id), nil, patch, &movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+type MovieRevision struct {
+	Sequence      int64     `json:"sequence"`
+	Type          string    `json:"type"`
+	Version       int64     `json:"version"`
+	ChangedFields []string  `json:"changed_fields"`
+	Movie         *Movie    `json:"movie,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+type MovieHistory struct {
+	ID        int32           `json:"id"`
+	Revisions []MovieRevision `json:"revisions"`
+}
+
+// GetMovieHistory returns the changes of the movie with the given ID, oldest first. It
+// requires the movies service to run with event-sourced persistence.
+func (c *Client) GetMovieHistory(ctx context.Context, id int32) (*MovieHistory, error) {
+	var history MovieHistory
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/movies/%d/history", id), nil, nil, &history); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// DeleteMovie deletes the movie with the given ID
+func (c *Client) DeleteMovie(ctx context.Context, id int32) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/movies/%d", id), nil, nil, nil)
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/client"
+)
+
+func TestClient_ListMovies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/movies" {
+			t.Errorf("path = %v, want /api/v1/movies", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("page"); got != "2" {
+			t.Errorf("page = %v, want 2", got)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"movies": []map[string]interface{}{{"id": 1, "title": "Movie 1", "year": "2020"}},
+			"total":  1,
+		})
+	}))
+	defer srv.Close()
+
+	c, err := client.New(srv.URL)
+	if err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+
+	list, err := c.ListMovies(context.Background(), client.ListMoviesOptions{Page: 2, Limit: 5})
+	if err != nil {
+		t.Fatalf("ListMovies() unexpected error = %v", err)
+	}
+	if list.Total != 1 || len(list.Movies) != 1 || list.Movies[0].Title != "Movie 1" {
+		t.Errorf("ListMovies() = %+v, unexpected result", list)
+	}
+}
+
+func TestClient_CreateMovie(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input client.CreateMovieInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(client.Movie{ID: 7, Title: input.Title, Year: input.Year, Genres: input.Genres, Director: input.Director, RuntimeMinutes: input.RuntimeMinutes})
+	}))
+	defer srv.Close()
+
+	c, _ := client.New(srv.URL)
+	movie, err := c.CreateMovie(context.Background(), client.CreateMovieInput{
+		Title: "New", Year: "2024", Genres: []string{"Drama"}, Director: "Director", RuntimeMinutes: 95,
+	})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if movie.ID != 7 || movie.Title != "New" || len(movie.Genres) != 1 || movie.Director != "Director" || movie.RuntimeMinutes != 95 {
+		t.Errorf("CreateMovie() = %+v, unexpected result", movie)
+	}
+}
+
+func TestClient_PatchMovie(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/v1/movies/7" || len(body) != 2 || body["year"] != "1996" || body["certification"] != "" {
+			t.Errorf("request = %s %s %v, want the year and an empty certification only", r.Method, r.URL.Path, body)
+		}
+		json.NewEncoder(w).Encode(client.Movie{ID: 7, Title: "Heat", Year: "1996", Version: 4})
+	}))
+	defer srv.Close()
+
+	c, _ := client.New(srv.URL)
+	year, certification := "1996", ""
+	movie, err := c.PatchMovie(context.Background(), 7, client.MoviePatch{Year: &year, Certification: &certification})
+	if err != nil {
+		t.Fatalf("PatchMovie() unexpected error = %v", err)
+	}
+	if movie.Year != "1996" || movie.Version != 4 {
+		t.Errorf("PatchMovie() = %+v, unexpected result", movie)
+	}
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(client.Movie{ID: 1, Title: "Movie", Year: "2020"})
+	}))
+	defer srv.Close()
+
+	c, _ := client.New(srv.URL, client.WithRetries(2, time.Millisecond))
+	if _, err := c.GetMovie(context.Background(), 1); err != nil {
+		t.Fatalf("GetMovie() unexpected error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestClient_DoesNotRetryCreate(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c, _ := client.New(srv.URL, client.WithRetries(2, time.Millisecond))
+	_, err := c.CreateMovie(context.Background(), client.CreateMovieInput{Title: "New", Year: "2024"})
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("CreateMovie() error = %v, want APIError with status 503", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	if _, err := client.New("not a url"); err == nil {
+		t.Errorf("New() expected error but got none")
+	}
+}
+
+func TestNew_HTTPClientOptions(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	if _, err := client.New("http://localhost:8080", client.WithHTTPClient(shared), client.WithTimeout(time.Second)); err != nil {
+		t.Fatalf("New() unexpected error = %v", err)
+	}
+	if shared.Timeout != time.Minute {
+		t.Errorf("shared client timeout = %v, want it left at %v", shared.Timeout, time.Minute)
+	}
+
+	if _, err := client.New("http://localhost:8080", client.WithHTTPClient(nil), client.WithTimeout(time.Second)); err == nil {
+		t.Errorf("New() with a nil HTTP client expected error but got none")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/database"
+	"github.com/movie-microservice/movies-service/internal/config"
+	"github.com/movie-microservice/movies-service/internal/idempotency"
+)
+
+// runBootstrap prepares the database of an environment: the collections of the configured
+// persistence mode with their validators, then every index the service uses. Each step
+// keeps what already exists, so it can run before every deploy
+func runBootstrap(cfg *config.Config, args []string) int {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	client, err := database.Connect(ctx, cfg.Database.ConnectionString, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Disconnect(context.Background(), client, logger)
+
+	events := cfg.Database.PersistenceMode == config.PersistenceEvents
+	steps, err := database.Bootstrap(ctx, client, cfg.Database.DatabaseName, database.BootstrapOptions{
+		EventStore: events,
+		ReadModel:  events && cfg.Database.ReadModelSchedule != "",
+		Sync:       cfg.Sync.Schedule != "",
+	}, logger)
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "COLLECTION\tSTATUS\tVALIDATOR")
+	for _, step := range steps {
+		status, validator := "exists", "-"
+		if step.Created {
+			status = "created"
+		}
+		if step.Validated {
+			validator = "movie schema"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\n", step.Collection, status, validator)
+	}
+	out.Flush()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if cfg.Idempotency.TTLSeconds > 0 {
+		if err := idempotency.NewMongo(client, cfg.Database.DatabaseName).EnsureIndexes(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	fmt.Printf("database %s is ready\n", cfg.Database.DatabaseName)
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/database"
+	"github.com/movie-microservice/movies-service/internal/config"
+)
+
+// runExplain creates the indexes of this version of the service, explains the main
+// query shapes of the repositories and returns 1 when a filtered query would scan the
+// whole collection, or with -warn only reports it. The plans are written to stdout and
+// only warnings are logged, to stderr
+func runExplain(cfg *config.Config, args []string) int {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	warnOnly := flags.Bool("warn", false, "report collection scans without failing")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := database.Connect(ctx, cfg.Database.ConnectionString, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Disconnect(context.Background(), client, logger)
+
+	useReadModel := cfg.Database.PersistenceMode == config.PersistenceEvents && cfg.Database.ReadModelSchedule != ""
+	if err := database.EnsureIndexes(ctx, client, cfg.Database.DatabaseName, logger); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if useReadModel {
+		if err := database.EnsureReadModel(ctx, client, cfg.Database.DatabaseName, logger); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	shapes, err := database.QueryShapes(useReadModel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	failed := false
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "RESULT\tQUERY\tCOLLECTION\tPLAN")
+	for _, result := range database.ExplainQueries(ctx, client, cfg.Database.DatabaseName, shapes) {
+		outcome, plan := "ok", strings.Join(result.Stages, " > ")
+		switch {
+		case result.Err != nil:
+			outcome, plan, failed = "error", result.Err.Error(), true
+		case result.Regression():
+			outcome, failed = "SCAN", true
+		case result.CollectionScan():
+			outcome = "scan"
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", outcome, result.Shape.Name, result.Shape.Collection, plan)
+	}
+	out.Flush()
+
+	if failed && !*warnOnly {
+		fmt.Fprintln(os.Stderr, "some queries would scan the whole collection or could not be explained; add the missing indexes to EnsureIndexes")
+		return 1
+	}
+	return 0
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,17 +16,40 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
-	pb "github.com/movie-microservice/proto/movies"
+	pbv1 "github.com/movie-microservice/proto/movies/v1"
+	"github.com/movie-microservice/proto/clock"
+	pb "github.com/movie-microservice/proto/movies/v2"
+	"github.com/movie-microservice/proto/retry"
+	"github.com/movie-microservice/movies-service/internal/adapters/admin"
 	"github.com/movie-microservice/movies-service/internal/adapters/database"
+	"github.com/movie-microservice/movies-service/internal/adapters/feed"
 	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/adapters/moderation"
+	"github.com/movie-microservice/movies-service/internal/adapters/notify"
+	"github.com/movie-microservice/movies-service/internal/backup"
 	"github.com/movie-microservice/movies-service/internal/config"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
 	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/idempotency"
+	"github.com/movie-microservice/movies-service/internal/leakcheck"
+	"github.com/movie-microservice/movies-service/internal/lock"
+	"github.com/movie-microservice/movies-service/internal/scheduler"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+)
+
+// Failed notifications are retried after waits between notifyRetryBaseDelay and
+// notifyRetryMaxDelay
+const (
+	notifyRetryBaseDelay = time.Second
+	notifyRetryMaxDelay  = 30 * time.Second
 )
 
 func main() {
 	// Initialize logger
+	var logLevel slog.LevelVar
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: &logLevel,
 	}))
 
 	// Load configuration
@@ -31,8 +58,27 @@ func main() {
 		logger.Error("Invalid configuration", "error", err)
 		os.Exit(1)
 	}
+	logLevel.UnmarshalText([]byte(cfg.Debug.LogLevel))
 
-	logger.Info("Starting movies service", "grpc_port", cfg.GRPC.Port)
+	// "health" probes a running instance, for the container health check
+	if len(os.Args) > 1 && os.Args[1] == "health" {
+		os.Exit(runHealthCheck(cfg.Admin.Port))
+	}
+	// "bootstrap" creates the collections, validators and indexes of the database
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap" {
+		os.Exit(runBootstrap(cfg, os.Args[2:]))
+	}
+	// "explain" checks that the repository queries are served by indexes
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		os.Exit(runExplain(cfg, os.Args[2:]))
+	}
+	// "seed" loads a dataset of movies, for local development
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		os.Exit(runSeed(cfg, os.Args[2:]))
+	}
+
+	logger.Info("Starting movies service", "grpc_port", cfg.GRPC.Port, "environment", cfg.Debug.Environment)
+	cfg.LogSummary(logger)
 
 	// Connect to MongoDB
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -49,23 +95,225 @@ func main() {
 		}
 	}()
 
+	if err := database.CheckPermissions(ctx, mongoClient, cfg.Database.DatabaseName); err != nil {
+		logger.Error("Startup check failed", "error", err)
+		os.Exit(1)
+	}
+	if err := database.EnsureIndexes(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+		logger.Error("Failed to prepare MongoDB indexes", "error", err)
+		os.Exit(1)
+	}
+	if err := database.EnsureCommentIndexes(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+		logger.Error("Failed to prepare comment indexes", "error", err)
+		os.Exit(1)
+	}
+	if err := database.EnsureJobIndexes(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+		logger.Error("Failed to prepare job indexes", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize repository
 	movieRepo := database.NewMongoMovieRepository(mongoClient, cfg.Database.DatabaseName, logger)
+	if cfg.Database.PersistenceMode == config.PersistenceEvents {
+		if err := database.EnsureEventStore(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+			logger.Error("Failed to prepare movie event store", "error", err)
+			os.Exit(1)
+		}
+		if cfg.Database.ReadModelSchedule != "" {
+			if err := database.EnsureReadModel(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+				logger.Error("Failed to prepare movie read model", "error", err)
+				os.Exit(1)
+			}
+		}
+		movieRepo = database.NewEventSourcedMovieRepository(mongoClient, cfg.Database.DatabaseName, database.EventStoreOptions{
+			SnapshotEvery: cfg.Database.SnapshotEvery,
+			ReadModel:     cfg.Database.ReadModelSchedule != "",
+		}, logger)
+	}
+	logger.Info("Movie persistence ready", "mode", cfg.Database.PersistenceMode, "read_model", cfg.Database.ReadModelSchedule != "")
 
 	// Initialize service
-	movieService := services.NewMovieService(movieRepo, logger)
+	pagination := domain.Pagination{
+		DefaultLimit: int32(cfg.Pagination.DefaultPageSize),
+		MaxLimit:     int32(cfg.Pagination.MaxPageSize),
+		MaxPage:      int32(cfg.Pagination.MaxPage),
+	}
+	certifications := domain.ParseCertifications(cfg.Catalog.Certifications)
+	movieService := services.NewMovieService(movieRepo, pagination, certifications, logger)
+
+	// Announce catalog changes on the configured chat webhooks
+	notifyTimeout := time.Duration(cfg.Notify.TimeoutMs) * time.Millisecond
+	// Services date and name what they create through these, replaced in tests
+	clk, ids := clock.System{}, database.ObjectIDs{}
+
+	var notifyChannels []services.NotificationChannel
+	if cfg.Notify.SlackWebhookURL != "" {
+		notifyChannels = append(notifyChannels, services.NotificationChannel{
+			Notifier: notify.NewSlack(cfg.Notify.SlackWebhookURL, notifyTimeout),
+			Events:   eventTypes(cfg.Notify.EventList(cfg.Notify.SlackEvents)),
+		})
+	}
+	if cfg.Notify.DiscordWebhookURL != "" {
+		notifyChannels = append(notifyChannels, services.NotificationChannel{
+			Notifier: notify.NewDiscord(cfg.Notify.DiscordWebhookURL, notifyTimeout),
+			Events:   eventTypes(cfg.Notify.EventList(cfg.Notify.DiscordEvents)),
+		})
+	}
+	var notifyPool *workerpool.Pool
+	if len(notifyChannels) > 0 {
+		notifyPool = workerpool.New("notifications", workerpool.Options{
+			Workers:   cfg.Notify.Workers,
+			QueueSize: cfg.Notify.QueueSize,
+			Retry: retry.Policy{
+				MaxAttempts: cfg.Notify.Attempts,
+				BaseDelay:   notifyRetryBaseDelay,
+				MaxDelay:    notifyRetryMaxDelay,
+			},
+		}, logger)
+		movieService = services.NewNotifyingMovieService(movieService, notifyChannels, notifyTimeout, notifyPool, clk, logger)
+	}
+	commentRepo := database.NewMongoCommentRepository(mongoClient, cfg.Database.DatabaseName, logger)
+	commentService := services.NewCommentService(commentRepo, movieRepo, pagination, cfg.Moderation.Schedule != "", clk, ids, logger)
+
+	// Jobs queued through the API run on the workers of every replica
+	jobPool := workerpool.New("jobs", workerpool.Options{Workers: cfg.Jobs.Workers}, logger)
+	jobFiles, err := database.NewGridFSJobFileStore(mongoClient, cfg.Database.DatabaseName)
+	if err != nil {
+		logger.Error("Failed to prepare job files", "error", err)
+		os.Exit(1)
+	}
+	jobs := services.NewJobs(database.NewMongoJobRepository(mongoClient, cfg.Database.DatabaseName, logger), jobFiles,
+		map[domain.JobType]ports.JobHandler{
+			domain.JobImport:  services.NewImportJob(movieService, logger),
+			domain.JobExport:  backup.NewExportJob(movieService, jobFiles, logger),
+			domain.JobArchive: services.NewArchiveJob(movieRepo, time.Duration(cfg.Archive.AfterDays)*24*time.Hour, clk, logger),
+		}, jobPool,
+		services.JobOptions{
+			Owner:        lock.DefaultOwner(),
+			PollInterval: time.Duration(cfg.Jobs.PollIntervalMs) * time.Millisecond,
+			Lease:        time.Duration(cfg.Jobs.LeaseSeconds) * time.Second,
+			Retention:    time.Duration(cfg.Jobs.RetentionHours) * time.Hour,
+			MaxWait:      time.Duration(cfg.Jobs.MaxWaitSeconds) * time.Second,
+		}, clk, ids, logger)
+	importer := services.NewImporter(jobs, logger)
+
+	// Schedule background jobs; replicas elect a single leader to run them
+	sched := scheduler.New(
+		lock.NewMongo(mongoClient, cfg.Database.DatabaseName),
+		lock.DefaultOwner(),
+		time.Duration(cfg.Scheduler.LockTTLSeconds)*time.Second,
+		logger,
+	)
+	if err := sched.Add("jobs-sweep", "@every 1m", jobs.Sweep); err != nil {
+		logger.Error("Invalid job schedule", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Archive.AfterDays > 0 {
+		archiver := services.NewArchiver(movieRepo, time.Duration(cfg.Archive.AfterDays)*24*time.Hour, clk, logger)
+		if err := sched.Add("archive", cfg.Archive.Schedule, archiver.Run); err != nil {
+			logger.Error("Invalid job schedule", "error", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Moderation.Schedule != "" {
+		var filters []ports.ModerationFilter
+		if words := moderation.NewWordList(cfg.Moderation.BlockedWords, cfg.Moderation.ReviewWords); !words.Empty() {
+			filters = append(filters, words)
+		}
+		if cfg.Moderation.APIURL != "" {
+			filters = append(filters, moderation.NewAPI(cfg.Moderation.APIURL, time.Duration(cfg.Moderation.APITimeoutMs)*time.Millisecond))
+		}
+		moderator := services.NewCommentModerator(commentRepo, filters, logger)
+		if err := sched.Add("comment-moderation", cfg.Moderation.Schedule, moderator.Run); err != nil {
+			logger.Error("Invalid job schedule", "error", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.Database.ReadModelSchedule != "" {
+		readModel := database.NewReadModel(mongoClient, cfg.Database.DatabaseName, logger)
+		if err := sched.Add("read-model", cfg.Database.ReadModelSchedule, readModel.Run); err != nil {
+			logger.Error("Invalid job schedule", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var catalogFeed ports.CatalogFeed
+	if cfg.Sync.Schedule != "" {
+		if err := database.EnsureSyncIndexes(ctx, mongoClient, cfg.Database.DatabaseName, logger); err != nil {
+			logger.Error("Failed to prepare catalog sync indexes", "error", err)
+			os.Exit(1)
+		}
+		if catalogFeed, err = newCatalogFeed(cfg.Sync); err != nil {
+			logger.Error("Invalid catalog feed", "error", err)
+			os.Exit(1)
+		}
+	}
+	syncRepo := database.NewMongoSyncRepository(mongoClient, cfg.Database.DatabaseName, logger)
+	catalogSync := services.NewCatalogSync(catalogFeed, movieService, syncRepo, domain.SyncPolicy(cfg.Sync.Policy), pagination, clk, logger)
+	if catalogFeed != nil {
+		if err := sched.Add("catalog-sync", cfg.Sync.Schedule, catalogSync.Run); err != nil {
+			logger.Error("Invalid job schedule", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	schedulerDone := make(chan struct{})
+	go func() {
+		sched.Run(jobsCtx)
+		close(schedulerDone)
+	}()
+	workersDone := make(chan struct{})
+	go func() {
+		jobs.Run(jobsCtx)
+		close(workersDone)
+	}()
 
 	// Initialize gRPC server
+	metrics := grpcAdapter.NewMetrics()
+	interceptors := []grpc.UnaryServerInterceptor{metrics.InFlightInterceptor(), grpcAdapter.RequestLoggingInterceptor(cfg.Debug.LogPolicy(), logger), metrics.UnaryInterceptor()}
+	if cfg.GRPC.RateLimitPerSecond > 0 {
+		limiter := grpcAdapter.NewRateLimiter(float64(cfg.GRPC.RateLimitPerSecond), cfg.GRPC.RateLimitBurst, clk)
+		interceptors = append(interceptors, limiter.UnaryInterceptor())
+		logger.Info("gRPC rate limiting enabled", "per_second", cfg.GRPC.RateLimitPerSecond, "burst", cfg.GRPC.RateLimitBurst)
+	}
+	if cfg.Debug.PayloadLogging {
+		interceptors = append(interceptors, grpcAdapter.PayloadLoggingInterceptor(grpcAdapter.PayloadLogging{
+			RedactFields: cfg.Debug.RedactFields(),
+			MaxBytes:     cfg.Debug.PayloadLogMaxBytes,
+		}, logger))
+		logger.Info("gRPC payload logging enabled", "log_level", cfg.Debug.LogLevel)
+	}
+	if cfg.Idempotency.TTLSeconds > 0 {
+		idempotencyStore := idempotency.NewMongo(mongoClient, cfg.Database.DatabaseName)
+		if err := idempotencyStore.EnsureIndexes(ctx); err != nil {
+			logger.Error("Failed to prepare idempotency keys", "error", err)
+			os.Exit(1)
+		}
+		interceptors = append(interceptors, grpcAdapter.IdempotencyInterceptor(
+			idempotencyStore, time.Duration(cfg.Idempotency.TTLSeconds)*time.Second, logger))
+	}
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(unaryInterceptor(logger)),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(metrics.InFlightStreamInterceptor(), grpcAdapter.StreamLoggerInterceptor(logger)),
+		grpc.MaxConcurrentStreams(uint32(cfg.GRPC.MaxConcurrentStreams)),
 	)
 
-	// Register movie service
+	// Register movie service; v1 is served by translating calls to v2
 	movieGRPCService := grpcAdapter.NewMovieServer(movieService, logger)
 	pb.RegisterMovieServiceServer(grpcServer, movieGRPCService)
+	pbv1.RegisterMovieServiceServer(grpcServer, grpcAdapter.NewMovieServerV1(movieGRPCService))
+	pb.RegisterCommentServiceServer(grpcServer, grpcAdapter.NewCommentServer(commentService, logger))
+	pb.RegisterCatalogSyncServiceServer(grpcServer, grpcAdapter.NewSyncServer(catalogSync, logger))
+	pb.RegisterImportServiceServer(grpcServer, grpcAdapter.NewImportServer(importer, logger))
+	pb.RegisterJobServiceServer(grpcServer, grpcAdapter.NewJobServer(jobs, logger))
 
 	// Enable reflection for grpcurl testing
-	reflection.Register(grpcServer)
+	if cfg.Debug.Reflection {
+		reflection.Register(grpcServer)
+		logger.Info("gRPC reflection enabled")
+	}
 
 	// Start gRPC server
 	lis, err := net.Listen("tcp", ":"+cfg.GRPC.Port)
@@ -87,37 +335,129 @@ func main() {
 		}
 	}()
 
+	var detector *leakcheck.Detector
+	if cfg.Debug.LeakDetectorIntervalSeconds > 0 {
+		interval := time.Duration(cfg.Debug.LeakDetectorIntervalSeconds) * time.Second
+		detector = leakcheck.NewDetector(leakcheck.Probe{Streams: metrics.ActiveStreams}, leakcheck.DetectorOptions{Interval: interval}, logger)
+		go detector.Run(jobsCtx)
+		logger.Info("Leak detector enabled", "interval", interval)
+	}
+
+	// Start the admin listener for health checks, metrics and profiles
+	pools := []*workerpool.Pool{jobPool}
+	if notifyPool != nil {
+		pools = append(pools, notifyPool)
+	}
+	adminServer := admin.NewServer(admin.Options{
+		Ready: func(ctx context.Context) error {
+			return mongoClient.Ping(ctx, nil)
+		},
+		Metrics: func(w io.Writer) {
+			workerpool.WritePrometheus(w, pools...)
+			if detector != nil {
+				detector.WritePrometheus(w)
+			}
+			metrics.WritePrometheus(w)
+		},
+		// The gRPC metrics end with the EOF marker, so they are written last
+		OpenMetrics: func(w io.Writer) {
+			workerpool.WriteOpenMetrics(w, pools...)
+			if detector != nil {
+				detector.WritePrometheus(w)
+			}
+			metrics.WriteOpenMetrics(w)
+		},
+		Pprof: cfg.Debug.Pprof,
+	}, logger)
+	var adminHTTP *http.Server
+	if cfg.Admin.Port != "" {
+		adminHTTP = &http.Server{
+			Addr:              ":" + cfg.Admin.Port,
+			Handler:           adminServer.Handler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			logger.Info("Admin server listening", "port", cfg.Admin.Port, "pprof", cfg.Debug.Pprof)
+			if err := adminHTTP.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Failed to serve admin endpoints", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	<-stop
 	logger.Info("Shutting down gRPC server...")
 
 	// Graceful shutdown
-	grpcServer.GracefulStop()
+	adminServer.Drain()
+	stopJobs()
+	// The admin listener keeps serving the drain metrics until the calls finished
+	grace := time.Duration(cfg.GRPC.ShutdownGraceSeconds) * time.Second
+	metrics.Drain().Drain(grace, func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, grpcServer.Stop, logger)
+	<-schedulerDone
+	<-workersDone
+	// Running jobs are stopped before the notifications they sent are delivered
+	jobPool.Close()
+	if notifyPool != nil {
+		notifyPool.Close()
+	}
+	if adminHTTP != nil {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelShutdown()
+		adminHTTP.Shutdown(shutdownCtx)
+	}
 	logger.Info("Server stopped")
 }
 
-// Unary interceptor for logging
-func unaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		start := time.Now()
-		
-		resp, err := handler(ctx, req)
-		
-		duration := time.Since(start)
-		
-		if err != nil {
-			logger.Error("gRPC request failed",
-				"method", info.FullMethod,
-				"duration", duration,
-				"error", err,
-			)
-		} else {
-			logger.Info("gRPC request completed",
-				"method", info.FullMethod,
-				"duration", duration,
-			)
-		}
-		
-		return resp, err
+// newCatalogFeed returns the feed of the configured sync source
+func newCatalogFeed(cfg config.SyncConfig) (ports.CatalogFeed, error) {
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if cfg.Source == config.SyncSourceTMDb {
+		return feed.NewTMDb(cfg.TMDbURL, cfg.TMDbToken, cfg.TMDbQuery, cfg.TMDbMaxPages, timeout)
+	}
+	return feed.NewCSV(cfg.CSVURL, timeout), nil
+}
+
+// eventTypes converts the event type names of the configuration
+func eventTypes(names []string) []domain.MovieEventType {
+	types := make([]domain.MovieEventType, len(names))
+	for i, name := range names {
+		types[i] = domain.MovieEventType(name)
+	}
+	return types
+}
+
+// runHealthCheck returns 0 when the admin listener reports the service as alive; the
+// scratch image has no HTTP client to probe it with
+func runHealthCheck(port string) int {
+	if port == "" {
+		fmt.Fprintln(os.Stderr, "health check needs the admin listener (ADMIN_PORT)")
+		return 1
+	}
+
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://127.0.0.1:" + port + "/healthz")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "unhealthy:", resp.Status)
+		return 1
 	}
+	return 0
 }
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/backup"
+	"github.com/movie-microservice/movies-service/internal/cli"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// flushEvery is the number of movies written between flushes of the partial backup, which
+// bounds the movies an interrupted backup has to fetch again
+const flushEvery = 500
+
+// backupResult is the outcome of a backup
+type backupResult struct {
+	File   string `json:"file"`
+	Movies int    `json:"movies"`
+	SHA256 string `json:"sha256"`
+}
+
+// backupProgress is what an interrupted or failed backup saved
+type backupProgress struct {
+	Partial string `json:"partial"`
+	Movies  int    `json:"movies"`
+	LastID  int32  `json:"last_id"`
+}
+
+// runBackup streams the catalog into <out>.partial and renames it to out once its trailer
+// is written. With -resume it continues the partial file left by an interrupted backup
+func runBackup(ctx context.Context, client pb.MovieServiceClient, args []string, out *cli.Printer) int {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	path := flags.String("out", "", "backup file to write, e.g. movies.ndjson.gz")
+	resume := flags.Bool("resume", false, "continue the partial file of an interrupted backup")
+	out.Register(flags)
+	if err := flags.Parse(args); err != nil {
+		return cli.ExitUsage
+	}
+	if err := out.Validate(); err != nil {
+		return out.Usage("backup: %v", err)
+	}
+	if *path == "" {
+		return out.Usage("backup: -out is required")
+	}
+
+	partial := *path + ".partial"
+	file, writer, err := openBackup(partial, *resume)
+	if err != nil {
+		return out.Fail("backup", err, nil, "")
+	}
+	defer file.Close()
+	if writer.Count() > 0 {
+		out.Notef("resuming after movie %d, %d movies already saved", writer.LastID(), writer.Count())
+	}
+
+	if err := exportMovies(ctx, client, writer); err != nil {
+		if flushErr := writer.Flush(); flushErr != nil {
+			err = errors.Join(err, flushErr)
+		}
+		progress := backupProgress{Partial: partial, Movies: writer.Count(), LastID: writer.LastID()}
+		return out.Fail("backup", err, progress,
+			fmt.Sprintf("%d movies saved to %s, through movie %d; run again with -resume to continue", writer.Count(), partial, writer.LastID()))
+	}
+
+	if err := writer.Close(); err != nil {
+		return out.Fail("backup", err, nil, "")
+	}
+	if err := file.Sync(); err != nil {
+		return out.Fail("backup", fmt.Errorf("failed to sync %s: %w", partial, err), nil, "")
+	}
+	if err := os.Rename(partial, *path); err != nil {
+		return out.Fail("backup", err, nil, "")
+	}
+
+	return out.Result(backupResult{File: *path, Movies: writer.Count(), SHA256: writer.Checksum()})
+}
+
+// openBackup creates the partial backup file, or with resume rewrites the movies the
+// interrupted one holds into a new file that replaces it
+func openBackup(partial string, resume bool) (*os.File, *backup.Writer, error) {
+	if !resume {
+		file, err := os.Create(partial)
+		if err != nil {
+			return nil, nil, err
+		}
+		writer, err := backup.NewWriter(file, time.Now())
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return file, writer, nil
+	}
+
+	previous, err := os.Open(partial)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no interrupted backup to resume: %w", err)
+	}
+	defer previous.Close()
+
+	rewritten := partial + ".tmp"
+	file, err := os.Create(rewritten)
+	if err != nil {
+		return nil, nil, err
+	}
+	writer, err := backup.Resume(previous, file)
+	if errors.Is(err, backup.ErrIncomplete) {
+		// The backup was interrupted before its header was saved
+		if _, err = file.Seek(0, io.SeekStart); err == nil {
+			writer, err = backup.NewWriter(file, time.Now())
+		}
+	}
+	if err == nil {
+		// The file keeps being written under its new name, so a second interruption
+		// leaves every movie saved so far
+		err = os.Rename(rewritten, partial)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(rewritten)
+		return nil, nil, err
+	}
+	return file, writer, nil
+}
+
+func exportMovies(ctx context.Context, client pb.MovieServiceClient, writer *backup.Writer) error {
+	stream, err := client.ExportMovies(ctx, &pb.ExportMoviesRequest{AfterId: writer.LastID()})
+	if err != nil {
+		return fmt.Errorf("failed to start export: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("export failed: %w", err)
+		}
+		if err := writer.Write(resp.GetMovie()); err != nil {
+			return err
+		}
+		if writer.Count()%flushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
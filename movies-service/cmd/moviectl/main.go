@@ -0,0 +1,96 @@
+// Command moviectl operates the movies service through its gRPC API, for operators
+// without direct access to the database
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/movie-microservice/movies-service/internal/cli"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+const usage = `Usage: moviectl [-addr host:port] [-output table|json|yaml] [-quiet] <command> [flags]
+
+Commands:
+  backup   stream the catalog to a gzipped NDJSON file
+  restore  import a backup file into the catalog
+  tui      browse, search and delete movies in the terminal
+
+The address defaults to MOVIE_SERVICE_GRPC_ADDRESS, or localhost:50051.
+-output and -quiet are also accepted after the command.
+Run "moviectl <command> -h" for the flags of a command.
+
+Exit codes:
+  0  success
+  1  failure of another kind
+  2  usage: invalid command or flags
+  3  unavailable: the movies service cannot be reached, or timed out
+  4  invalid: invalid input, such as a corrupted backup
+  5  not_found: a file or movie does not exist
+  6  conflict: the change conflicts with the catalog
+  7  denied: permission denied
+  8  interrupted: stopped by a signal; backup and restore can be resumed
+`
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	flags := flag.NewFlagSet("moviectl", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	address := flags.String("addr", defaultAddress(), "gRPC address of the movies service")
+	out := cli.NewPrinter(os.Stdout, os.Stderr)
+	out.Register(flags)
+	if err := flags.Parse(args); err != nil {
+		return cli.ExitUsage
+	}
+	if err := out.Validate(); err != nil {
+		return out.Usage("moviectl: %v", err)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return cli.ExitUsage
+	}
+
+	var command func(context.Context, pb.MovieServiceClient, []string, *cli.Printer) int
+	switch flags.Arg(0) {
+	case "backup":
+		command = runBackup
+	case "restore":
+		command = runRestore
+	case "tui":
+		command = runTUI
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", flags.Arg(0))
+		flags.Usage()
+		return cli.ExitUsage
+	}
+
+	conn, err := grpc.NewClient(*address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return out.Usage("moviectl: invalid address %s: %v", *address, err)
+	}
+	defer conn.Close()
+
+	// An interrupted backup or restore stops at a point it can be resumed from
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return command(ctx, pb.NewMovieServiceClient(conn), flags.Args()[1:], out)
+}
+
+func defaultAddress() string {
+	if address := os.Getenv("MOVIE_SERVICE_GRPC_ADDRESS"); address != "" {
+		return address
+	}
+	return "localhost:50051"
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/backup"
+	"github.com/movie-microservice/movies-service/internal/cli"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// restoreResult is the outcome of a restore
+type restoreResult struct {
+	File     string `json:"file"`
+	Imported int32  `json:"imported"`
+	Movies   int    `json:"movies"`
+	LastID   int32  `json:"last_id"`
+}
+
+// restoreProgress is what a failed restore imported
+type restoreProgress struct {
+	LastID int32 `json:"last_id,omitempty"`
+}
+
+// runRestore checks the whole backup against its checksum, then imports its movies.
+// With -after it skips the movies a failed restore already imported
+func runRestore(ctx context.Context, client pb.MovieServiceClient, args []string, out *cli.Printer) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	in := flags.String("in", "", "backup file to restore")
+	after := flags.Int("after", 0, "only restore movies with a greater ID, to resume a failed restore")
+	out.Register(flags)
+	if err := flags.Parse(args); err != nil {
+		return cli.ExitUsage
+	}
+	if err := out.Validate(); err != nil {
+		return out.Usage("restore: %v", err)
+	}
+	if *in == "" {
+		return out.Usage("restore: -in is required")
+	}
+
+	// Nothing is imported from a truncated or corrupted backup
+	count, err := verifyBackup(*in)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) {
+			err = cli.Invalid(err)
+		}
+		return out.Fail("restore", fmt.Errorf("%s is not a valid backup: %w", *in, err), nil, "")
+	}
+
+	imported, lastID, err := importMovies(ctx, client, *in, int32(*after))
+	if err != nil {
+		var hint string
+		if lastID > 0 {
+			hint = fmt.Sprintf("movies through %d were imported; run again with -after %d to continue", lastID, lastID)
+		}
+		return out.Fail("restore", err, restoreProgress{LastID: lastID}, hint)
+	}
+
+	return out.Result(restoreResult{File: *in, Imported: imported, Movies: count, LastID: lastID})
+}
+
+// verifyBackup reads the backup to its trailer and returns its number of movies
+func verifyBackup(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader, err := backup.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		if _, err := reader.Next(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return reader.Count(), nil
+			}
+			return 0, err
+		}
+	}
+}
+
+// importMovies streams the movies of the backup following after to the service and
+// returns how many it imported and the ID of the last one. When the import fails, only
+// the ID of the last movie imported is known
+func importMovies(ctx context.Context, client pb.MovieServiceClient, path string, after int32) (int32, int32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, after, err
+	}
+	defer file.Close()
+
+	reader, err := backup.NewReader(file)
+	if err != nil {
+		return 0, after, err
+	}
+
+	stream, err := client.ImportMovies(ctx)
+	if err != nil {
+		return 0, after, fmt.Errorf("failed to start import: %w", err)
+	}
+
+	for {
+		movie, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			stream.CloseSend()
+			return 0, after, err
+		}
+		if movie.GetId() <= after {
+			continue
+		}
+		// A failed send means the service ended the import; its status is returned by
+		// CloseAndRecv
+		if err := stream.Send(&pb.ImportMoviesRequest{Movie: movie}); err != nil {
+			break
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		// The service reports the last movie it imported even when the import failed
+		lastID := after
+		if values := stream.Trailer().Get(grpcAdapter.ImportedThroughTrailer); len(values) > 0 {
+			if id, parseErr := strconv.ParseInt(values[0], 10, 32); parseErr == nil && int32(id) > after {
+				lastID = int32(id)
+			}
+		}
+		return 0, lastID, fmt.Errorf("import failed: %w", err)
+	}
+	return resp.GetImported(), resp.GetLastId(), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/movie-microservice/movies-service/internal/cli"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// runTUI browses the catalog in the terminal until the operator quits. It has no result
+// to print, so -output and -quiet only apply to its errors
+func runTUI(ctx context.Context, client pb.MovieServiceClient, args []string, out *cli.Printer) int {
+	flags := flag.NewFlagSet("tui", flag.ContinueOnError)
+	out.Register(flags)
+	if err := flags.Parse(args); err != nil {
+		return cli.ExitUsage
+	}
+	if err := out.Validate(); err != nil {
+		return out.Usage("tui: %v", err)
+	}
+	if flags.NArg() > 0 {
+		return out.Usage("tui: unexpected argument %q", flags.Arg(0))
+	}
+
+	program := tea.NewProgram(cli.NewBrowser(ctx, client), tea.WithAltScreen(), tea.WithContext(ctx))
+	if _, err := program.Run(); err != nil {
+		return out.Fail("tui", err, nil, "")
+	}
+	return cli.ExitOK
+}
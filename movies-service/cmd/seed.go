@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/database"
+	"github.com/movie-microservice/movies-service/internal/config"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/fixtures"
+)
+
+// runSeed loads a dataset of movies into the database, through the repository of the
+// configured persistence mode: an embedded dataset named by the argument, "catalog" by
+// default, or the YAML or JSON file given with -file. Movies are replaced by ID, so
+// seeding again only changes the movies edited since. It refuses to run in production
+func runSeed(cfg *config.Config, args []string) int {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := flags.String("file", "", "YAML or JSON file of the dataset to load, instead of an embedded one")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: movies-service seed [-file dataset.yaml] [%s]\n", strings.Join(fixtures.Names(), "|"))
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if cfg.Debug.Environment == config.EnvironmentProduction {
+		fmt.Fprintln(os.Stderr, "refusing to seed a production database")
+		return 1
+	}
+
+	name := "catalog"
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+	var dataset *fixtures.Dataset
+	var err error
+	if *file != "" {
+		name = *file
+		dataset, err = fixtures.ReadFile(*file)
+	} else {
+		dataset, err = fixtures.Open(name)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := database.Connect(ctx, cfg.Database.ConnectionString, logger)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer database.Disconnect(context.Background(), client, logger)
+
+	if err := database.EnsureIndexes(ctx, client, cfg.Database.DatabaseName, logger); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	var repo ports.MovieRepository = database.NewMongoMovieRepository(client, cfg.Database.DatabaseName, logger)
+	if cfg.Database.PersistenceMode == config.PersistenceEvents {
+		if err := database.EnsureEventStore(ctx, client, cfg.Database.DatabaseName, logger); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if cfg.Database.ReadModelSchedule != "" {
+			if err := database.EnsureReadModel(ctx, client, cfg.Database.DatabaseName, logger); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+		repo = database.NewEventSourcedMovieRepository(client, cfg.Database.DatabaseName, database.EventStoreOptions{
+			SnapshotEvery: cfg.Database.SnapshotEvery,
+			ReadModel:     cfg.Database.ReadModelSchedule != "",
+		}, logger)
+	}
+
+	result, err := fixtures.Load(ctx, repo, domain.ParseCertifications(cfg.Catalog.Certifications), dataset)
+	fmt.Printf("%s: %d created, %d replaced, %d unchanged in %s\n",
+		name, result.Created, result.Replaced, result.Unchanged, cfg.Database.DatabaseName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+// Package admin serves the HTTP endpoints used to probe, scrape and profile the movie
+// service without speaking gRPC
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// readyTimeout bounds the dependency checks made by /readyz
+const readyTimeout = 2 * time.Second
+
+type Options struct {
+	// Ready checks the dependencies needed to serve requests, such as MongoDB
+	Ready func(ctx context.Context) error
+	// Metrics writes the metrics served on /metrics in the Prometheus text format
+	Metrics func(w io.Writer)
+	// OpenMetrics writes the metrics, with exemplars, in the OpenMetrics format served
+	// to scrapers that accept it
+	OpenMetrics func(w io.Writer)
+	// Pprof serves the runtime profiles under /debug/pprof/
+	Pprof bool
+}
+
+type Server struct {
+	opts     Options
+	draining atomic.Bool
+	logger   *slog.Logger
+}
+
+func NewServer(opts Options, logger *slog.Logger) *Server {
+	return &Server{opts: opts, logger: logger}
+}
+
+// Drain makes /readyz fail so load balancers stop sending requests before shutdown
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+	if s.opts.Metrics != nil {
+		mux.HandleFunc("GET /metrics", s.metrics)
+	}
+	if s.opts.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+// healthz reports that the process is up; it does not check dependencies, so a
+// MongoDB outage does not get the service restarted
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// readyz reports whether the service can serve requests
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		writeStatus(w, http.StatusServiceUnavailable, "shutting down")
+		return
+	}
+	if s.opts.Ready != nil {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+		if err := s.opts.Ready(ctx); err != nil {
+			s.logger.Warn("Readiness check failed", "error", err)
+			writeStatus(w, http.StatusServiceUnavailable, "not ready")
+			return
+		}
+	}
+	writeStatus(w, http.StatusOK, "ready")
+}
+
+func (s *Server) metrics(w http.ResponseWriter, r *http.Request) {
+	if s.opts.OpenMetrics != nil && acceptsOpenMetrics(r) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		s.opts.OpenMetrics(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.opts.Metrics(w)
+}
+
+// acceptsOpenMetrics reports whether the scraper asked for the OpenMetrics format, as
+// Prometheus does when exemplar storage is enabled
+func acceptsOpenMetrics(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			if strings.TrimSpace(mediaType) == "application/openmetrics-text" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeStatus(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, body+"\n")
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const (
+	archiveCollection = "movies_archive"
+	lastAccessedField = "last_accessed_at"
+
+	// accessTouchInterval limits how often reads refresh the last access time, so
+	// popular movies don't turn every read into a write
+	accessTouchInterval = time.Hour
+)
+
+// findArchived looks a movie up in the archive collection
+func (r *MongoMovieRepository) findArchived(ctx context.Context, id int32) (*domain.Movie, error) {
+	var movie domain.Movie
+	err := r.database.Collection(archiveCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&movie)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, domain.ErrMovieNotFound
+		}
+		return nil, storageError("failed to find archived movie", err)
+	}
+
+	movie.Archived = true
+	return &movie, nil
+}
+
+// touch records that the movie was read
+func (r *MongoMovieRepository) touch(ctx context.Context, id int32) {
+	now := time.Now().UTC()
+	filter := bson.M{"_id": id, lastAccessedField: bson.M{"$lt": now.Add(-accessTouchInterval)}}
+
+	if _, err := r.database.Collection(moviesCollection).UpdateOne(ctx, filter, bson.M{"$set": bson.M{lastAccessedField: now}}); err != nil {
+		logging.FromContext(ctx, r.logger).Warn("Failed to record movie access", "id", id, "error", err)
+	}
+}
+
+// Archive moves up to limit movies not read since olderThan into the archive collection,
+// returning how many were moved
+func (r *MongoMovieRepository) Archive(ctx context.Context, olderThan time.Time, limit int) (int, error) {
+	movies := r.database.Collection(moviesCollection)
+	archive := r.database.Collection(archiveCollection)
+
+	stale := bson.M{lastAccessedField: bson.M{"$lt": olderThan}}
+	cursor, err := movies.Find(ctx, stale, options.Find().SetLimit(int64(limit)).SetSort(bson.D{{Key: lastAccessedField, Value: 1}}))
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to find stale movies", "error", err)
+		return 0, fmt.Errorf("failed to find stale movies: %w", err)
+	}
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, fmt.Errorf("failed to decode stale movies: %w", err)
+	}
+
+	archived := 0
+	for _, doc := range docs {
+		id := doc["_id"]
+
+		if _, err := archive.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true)); err != nil {
+			return archived, fmt.Errorf("failed to archive movie %v: %w", id, err)
+		}
+
+		// Only remove the movie if it was not read or modified while it was being copied
+		result, err := movies.DeleteOne(ctx, bson.M{"_id": id, lastAccessedField: bson.M{"$lt": olderThan}, "version": doc["version"]})
+		if err != nil {
+			return archived, fmt.Errorf("failed to remove archived movie %v: %w", id, err)
+		}
+		if result.DeletedCount == 0 {
+			if _, err := archive.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+				return archived, fmt.Errorf("failed to roll back archived movie %v: %w", id, err)
+			}
+			continue
+		}
+
+		archived++
+	}
+
+	logging.FromContext(ctx, r.logger).Info("Archived stale movies", "count", archived, "older_than", olderThan)
+	return archived, nil
+}
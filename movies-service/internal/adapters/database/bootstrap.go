@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// namespaceExistsCode is the server error code of creating a collection that exists
+const namespaceExistsCode = 48
+
+// movieSchema validates the documents of the movies and archive collections
+var movieSchema = bson.M{"$jsonSchema": bson.M{
+	"bsonType": "object",
+	"required": bson.A{"_id", "title", "year"},
+	"properties": bson.M{
+		"_id":     bson.M{"bsonType": "int", "description": "must be an integer and is required"},
+		"title":   bson.M{"bsonType": "string", "minLength": 1, "description": "must be a non-empty string and is required"},
+		"year":    bson.M{"bsonType": "string", "pattern": "^[0-9]{4}$", "description": "must be a 4-digit year string and is required"},
+		"version": bson.M{"bsonType": bson.A{"int", "long"}, "description": "must be an integer"},
+		"regions": bson.M{
+			"bsonType":    "array",
+			"items":       bson.M{"bsonType": "string", "pattern": "^[A-Z]{2}$"},
+			"description": "must be an array of ISO 3166-1 alpha-2 codes",
+		},
+		"awards": bson.M{
+			"bsonType":    "array",
+			"items":       bson.M{"bsonType": "string"},
+			"description": "must be an array of award names",
+		},
+		"certification": bson.M{"bsonType": "string", "description": "must be an age certification such as PG-13"},
+		"imdb_id":       bson.M{"bsonType": "string", "pattern": "^tt[0-9]{7,10}$", "description": "must be an IMDb ID such as tt0113277"},
+		"tmdb_id":       bson.M{"bsonType": "string", "pattern": "^[1-9][0-9]{0,9}$", "description": "must be a TMDb ID such as 949"},
+		"genres": bson.M{
+			"bsonType":    "array",
+			"items":       bson.M{"bsonType": "string", "minLength": 1},
+			"description": "must be an array of genre names",
+		},
+		"director":        bson.M{"bsonType": "string", "description": "must be a string"},
+		"synopsis":        bson.M{"bsonType": "string", "description": "must be a string"},
+		"runtime_minutes": bson.M{"bsonType": "int", "minimum": 1, "description": "must be a positive number of minutes"},
+	},
+}}
+
+// BootstrapOptions selects the collections of the persistence mode in use
+type BootstrapOptions struct {
+	EventStore bool
+	ReadModel  bool
+	Sync       bool
+}
+
+type collectionSpec struct {
+	name      string
+	validator bson.M
+}
+
+// BootstrapStep describes what Bootstrap did to one collection
+type BootstrapStep struct {
+	Collection string
+	// Created is set when the collection did not exist
+	Created bool
+	// Validated is set when the collection has a schema validator
+	Validated bool
+}
+
+// Bootstrap creates the database collections of the service with their validators, then
+// their indexes. Existing collections keep their data and get the current validator, so
+// it can run on every deploy
+func Bootstrap(ctx context.Context, client *mongo.Client, databaseName string, opts BootstrapOptions, logger *slog.Logger) ([]BootstrapStep, error) {
+	database := client.Database(databaseName)
+
+	collections := []collectionSpec{
+		{moviesCollection, movieSchema},
+		{archiveCollection, movieSchema},
+		{commentsCollection, nil},
+		{jobsCollection, nil},
+		{countersCollection, nil},
+		{tombstonesCollection, nil},
+	}
+	if opts.EventStore {
+		collections = append(collections, collectionSpec{eventsCollection, nil}, collectionSpec{snapshotsCollection, nil})
+	}
+	if opts.ReadModel {
+		collections = append(collections, collectionSpec{readModelCollection, nil}, collectionSpec{checkpointsCollection, nil})
+	}
+	if opts.Sync {
+		collections = append(collections, collectionSpec{syncLinksCollection, nil}, collectionSpec{syncConflictsCollection, nil})
+	}
+
+	steps := make([]BootstrapStep, 0, len(collections))
+	for _, c := range collections {
+		created, err := ensureCollection(ctx, database, c.name, c.validator)
+		if err != nil {
+			logger.Error("Failed to create collection", "collection", c.name, "error", err)
+			return steps, err
+		}
+		steps = append(steps, BootstrapStep{Collection: c.name, Created: created, Validated: c.validator != nil})
+	}
+
+	if err := EnsureIndexes(ctx, client, databaseName, logger); err != nil {
+		return steps, err
+	}
+	if err := EnsureCommentIndexes(ctx, client, databaseName, logger); err != nil {
+		return steps, err
+	}
+	if err := EnsureJobIndexes(ctx, client, databaseName, logger); err != nil {
+		return steps, err
+	}
+	if opts.EventStore {
+		if err := EnsureEventStore(ctx, client, databaseName, logger); err != nil {
+			return steps, err
+		}
+	}
+	if opts.ReadModel {
+		if err := EnsureReadModel(ctx, client, databaseName, logger); err != nil {
+			return steps, err
+		}
+	}
+	if opts.Sync {
+		if err := EnsureSyncIndexes(ctx, client, databaseName, logger); err != nil {
+			return steps, err
+		}
+	}
+	return steps, nil
+}
+
+// ensureCollection creates the collection, or replaces the validator of the existing one,
+// returning whether it was created
+func ensureCollection(ctx context.Context, database *mongo.Database, name string, validator bson.M) (bool, error) {
+	opts := options.CreateCollection()
+	if validator != nil {
+		opts.SetValidator(validator)
+	}
+
+	err := database.CreateCollection(ctx, name, opts)
+	if err == nil {
+		return true, nil
+	}
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != namespaceExistsCode {
+		return false, storageError("failed to create collection "+name, err)
+	}
+
+	if validator != nil {
+		err := database.RunCommand(ctx, bson.D{{Key: "collMod", Value: name}, {Key: "validator", Value: validator}}).Err()
+		if err != nil {
+			return false, storageError("failed to update validator of collection "+name, err)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,397 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const (
+	commentsCollection = "movie_comments"
+
+	// maxFlagReasons is the number of most recent flag reasons kept on a comment; the
+	// count keeps growing
+	maxFlagReasons = 20
+)
+
+// visibleStatus matches the comments shown to readers, including those stored before
+// moderation existed
+var visibleStatus = bson.M{"$in": bson.A{domain.ModerationApproved, nil}}
+
+type commentFlag struct {
+	Reason string    `bson:"reason"`
+	At     time.Time `bson:"at"`
+}
+
+type commentDocument struct {
+	ID        primitive.ObjectID  `bson:"_id"`
+	MovieID   int32               `bson:"movie_id"`
+	ParentID  *primitive.ObjectID `bson:"parent_id"`
+	ThreadID  primitive.ObjectID  `bson:"thread_id"`
+	Author    string              `bson:"author"`
+	Body      string              `bson:"body"`
+	CreatedAt time.Time           `bson:"created_at"`
+	DeletedAt *time.Time          `bson:"deleted_at,omitempty"`
+	Flags     []commentFlag       `bson:"flags,omitempty"`
+	FlagCount int32               `bson:"flag_count"`
+	// Status is the moderation status, missing on comments stored before moderation
+	Status            domain.ModerationStatus `bson:"status,omitempty"`
+	ModerationReasons []string                `bson:"moderation_reasons,omitempty"`
+	ModeratedAt       *time.Time              `bson:"moderated_at,omitempty"`
+}
+
+func (d *commentDocument) toDomain() *domain.Comment {
+	comment := &domain.Comment{
+		ID:                d.ID.Hex(),
+		MovieID:           d.MovieID,
+		ThreadID:          d.ThreadID.Hex(),
+		Author:            d.Author,
+		Body:              d.Body,
+		CreatedAt:         d.CreatedAt,
+		DeletedAt:         d.DeletedAt,
+		FlagCount:         d.FlagCount,
+		Status:            d.Status,
+		ModerationReasons: d.ModerationReasons,
+	}
+	if comment.Status == "" {
+		comment.Status = domain.ModerationApproved
+	}
+	if d.ParentID != nil {
+		comment.ParentID = d.ParentID.Hex()
+	}
+	for _, flag := range d.Flags {
+		comment.FlagReasons = append(comment.FlagReasons, flag.Reason)
+	}
+	return comment
+}
+
+// MongoCommentRepository stores the discussion threads of movies in the movie_comments
+// collection. Every comment holds the ID of its thread, so a page of threads is loaded
+// with two queries whatever the number of replies
+type MongoCommentRepository struct {
+	collection *mongo.Collection
+	logger     *slog.Logger
+}
+
+func NewMongoCommentRepository(client *mongo.Client, databaseName string, logger *slog.Logger) ports.CommentRepository {
+	return &MongoCommentRepository{
+		collection: client.Database(databaseName).Collection(commentsCollection),
+		logger:     logger,
+	}
+}
+
+// EnsureCommentIndexes creates the indexes used to list threads, replies and flagged comments
+func EnsureCommentIndexes(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	collection := client.Database(databaseName).Collection(commentsCollection)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "movie_id", Value: 1}, {Key: "parent_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("movie_threads"),
+		},
+		{
+			Keys:    bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("thread_replies"),
+		},
+		{
+			Keys: bson.D{{Key: "flag_count", Value: -1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("flagged_comments").
+				SetPartialFilterExpression(bson.M{"flag_count": bson.M{"$gt": 0}}),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("moderation_queue"),
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to create comment indexes", "error", err)
+		return storageError("failed to create comment indexes", err)
+	}
+	return nil
+}
+
+func (r *MongoCommentRepository) ListThreads(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	query := bson.M{"movie_id": movieID, "parent_id": nil, "status": visibleStatus}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to count comment threads", "movie_id", movieID, "error", err)
+		return nil, 0, storageError("failed to count comment threads", err)
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	roots, err := r.find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(roots) == 0 {
+		return []*domain.Comment{}, int32(total), nil
+	}
+
+	threadIDs := make([]primitive.ObjectID, len(roots))
+	for i, root := range roots {
+		threadIDs[i] = root.ID
+	}
+	replies, err := r.find(ctx,
+		bson.M{"thread_id": bson.M{"$in": threadIDs}, "parent_id": bson.M{"$ne": nil}, "status": visibleStatus},
+		options.Find().SetSort(bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	threads := make([]*domain.Comment, len(roots))
+	byThread := make(map[primitive.ObjectID]*domain.Comment, len(roots))
+	for i, root := range roots {
+		threads[i] = root.toDomain()
+		byThread[root.ID] = threads[i]
+	}
+	for _, reply := range replies {
+		thread := byThread[reply.ThreadID]
+		thread.Replies = append(thread.Replies, reply.toDomain())
+	}
+	return threads, int32(total), nil
+}
+
+func (r *MongoCommentRepository) FindByID(ctx context.Context, id string) (*domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+
+	var doc commentDocument
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrCommentNotFound
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to find comment", "id", id, "error", err)
+		return nil, storageError("failed to find comment", err)
+	}
+	return doc.toDomain(), nil
+}
+
+// Create stores a comment under its ID, or a new one when it has none. Comments without
+// a thread start their own
+func (r *MongoCommentRepository) Create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	id, err := newObjectID(comment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidComment, err)
+	}
+	doc := commentDocument{
+		ID:        id,
+		MovieID:   comment.MovieID,
+		Author:    comment.Author,
+		Body:      comment.Body,
+		CreatedAt: comment.CreatedAt,
+		Status:    comment.Status,
+	}
+	doc.ThreadID = doc.ID
+	if comment.ParentID != "" {
+		parentID, err := primitive.ObjectIDFromHex(comment.ParentID)
+		if err != nil {
+			return nil, domain.ErrCommentNotFound
+		}
+		threadID, err := primitive.ObjectIDFromHex(comment.ThreadID)
+		if err != nil {
+			return nil, domain.ErrCommentNotFound
+		}
+		doc.ParentID, doc.ThreadID = &parentID, threadID
+	}
+
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to create comment", "movie_id", comment.MovieID, "error", err)
+		return nil, storageError("failed to create comment", err)
+	}
+	return doc.toDomain(), nil
+}
+
+// Flag records a report on a comment that was not deleted
+func (r *MongoCommentRepository) Flag(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	update := bson.M{
+		"$inc": bson.M{"flag_count": 1},
+		"$push": bson.M{"flags": bson.M{
+			"$each":  bson.A{commentFlag{Reason: reason, At: time.Now().UTC()}},
+			"$slice": -maxFlagReasons,
+		}},
+	}
+	return r.updateLive(ctx, id, bson.M{"status": visibleStatus}, update, "failed to flag comment")
+}
+
+func (r *MongoCommentRepository) ListFlagged(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	query := bson.M{"flag_count": bson.M{"$gt": 0}, "deleted_at": nil}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to count flagged comments", "error", err)
+		return nil, 0, storageError("failed to count flagged comments", err)
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "flag_count", Value: -1}, {Key: "created_at", Value: -1}})
+	docs, err := r.find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments := make([]*domain.Comment, len(docs))
+	for i, doc := range docs {
+		comments[i] = doc.toDomain()
+	}
+	return comments, int32(total), nil
+}
+
+// SoftDelete marks the comment deleted, keeping it so its replies stay in their thread
+func (r *MongoCommentRepository) SoftDelete(ctx context.Context, id string) error {
+	update := bson.M{
+		"$set":   bson.M{"deleted_at": time.Now().UTC(), "flag_count": 0},
+		"$unset": bson.M{"flags": ""},
+	}
+	_, err := r.updateLive(ctx, id, nil, update, "failed to delete comment")
+	return err
+}
+
+func (r *MongoCommentRepository) DismissFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	update := bson.M{
+		"$set":   bson.M{"flag_count": 0},
+		"$unset": bson.M{"flags": ""},
+	}
+	return r.updateLive(ctx, id, nil, update, "failed to dismiss comment flags")
+}
+
+// updateLive applies the update to a comment that was not deleted and matches filter,
+// returning domain.ErrCommentDeleted when it was deleted and domain.ErrCommentNotFound
+// when it does not match
+func (r *MongoCommentRepository) updateLive(ctx context.Context, id string, filter, update bson.M, action string) (*domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+
+	query := bson.M{"_id": objectID, "deleted_at": nil}
+	for key, value := range filter {
+		query[key] = value
+	}
+
+	var doc commentDocument
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = r.collection.FindOneAndUpdate(ctx, query, update, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		comment, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if comment.Deleted() {
+			return nil, domain.ErrCommentDeleted
+		}
+		return nil, domain.ErrCommentNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to update comment", "id", id, "error", err)
+		return nil, storageError(action, err)
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoCommentRepository) ListPending(ctx context.Context, limit int) ([]*domain.Comment, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+	docs, err := r.find(ctx, bson.M{"status": domain.ModerationPending, "deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*domain.Comment, len(docs))
+	for i, doc := range docs {
+		comments[i] = doc.toDomain()
+	}
+	return comments, nil
+}
+
+func (r *MongoCommentRepository) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	query := bson.M{"status": domain.ModerationReview, "deleted_at": nil}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to count queued comments", "error", err)
+		return nil, 0, storageError("failed to count queued comments", err)
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+	docs, err := r.find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	comments := make([]*domain.Comment, len(docs))
+	for i, doc := range docs {
+		comments[i] = doc.toDomain()
+	}
+	return comments, int32(total), nil
+}
+
+func (r *MongoCommentRepository) SetStatus(ctx context.Context, id string, from []domain.ModerationStatus, status domain.ModerationStatus, reasons []string) (*domain.Comment, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrCommentNotFound
+	}
+
+	set := bson.M{"status": status, "moderated_at": time.Now().UTC()}
+	if reasons != nil {
+		set["moderation_reasons"] = reasons
+	}
+
+	var doc commentDocument
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	query := bson.M{"_id": objectID, "deleted_at": nil, "status": bson.M{"$in": from}}
+	err = r.collection.FindOneAndUpdate(ctx, query, bson.M{"$set": set}, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrNotAwaitingModeration
+	}
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to set comment status", "id", id, "status", status, "error", err)
+		return nil, storageError("failed to set comment status", err)
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoCommentRepository) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]commentDocument, error) {
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to find comments", "error", err)
+		return nil, storageError("failed to find comments", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			logging.FromContext(ctx, r.logger).Warn("Failed to close cursor", "error", err)
+		}
+	}()
+
+	var docs []commentDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to decode comments", "error", err)
+		return nil, storageError("failed to decode comments", err)
+	}
+	return docs, nil
+}
@@ -0,0 +1,470 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const (
+	eventsCollection    = "movie_events"
+	snapshotsCollection = "movie_snapshots"
+
+	// eventSequenceField records in the movies collection the last event projected there
+	eventSequenceField = "event_sequence"
+)
+
+// errStreamConflict is returned when another writer appended to the stream first
+var errStreamConflict = errors.New("event stream modified concurrently")
+
+// movieSnapshot is the state of a movie after the event at Sequence, so loading it only
+// replays the events that followed
+type movieSnapshot struct {
+	MovieID  int32         `bson:"_id"`
+	Sequence int64         `bson:"sequence"`
+	Movie    *domain.Movie `bson:"movie,omitempty"`
+}
+
+// projectedMovie is the movies collection document written by the event store
+type projectedMovie struct {
+	movieDocument `bson:",inline"`
+	EventSequence int64 `bson:"event_sequence"`
+}
+
+// EventSourcedMovieRepository stores each movie as an append-only stream of events,
+// periodically snapshotted. The movies collection is kept as a projection of the latest
+// state, which listings, counts, facets and archiving query unless a read model is used.
+type EventSourcedMovieRepository struct {
+	*MongoMovieRepository
+	snapshotEvery int
+}
+
+type EventStoreOptions struct {
+	// SnapshotEvery is the number of events of a movie between snapshots of its state;
+	// 0 disables snapshots
+	SnapshotEvery int
+	// ReadModel makes listings, counts and facets query the read model maintained by
+	// ReadModel instead of the movies collection
+	ReadModel bool
+}
+
+// NewEventSourcedMovieRepository creates an event-sourced movie repository
+func NewEventSourcedMovieRepository(client *mongo.Client, databaseName string, opts EventStoreOptions, logger *slog.Logger) ports.MovieRepository {
+	return newEventSourcedMovieRepository(client, databaseName, opts, logger)
+}
+
+func newEventSourcedMovieRepository(client *mongo.Client, databaseName string, opts EventStoreOptions, logger *slog.Logger) *EventSourcedMovieRepository {
+	readCollection := moviesCollection
+	if opts.ReadModel {
+		readCollection = readModelCollection
+	}
+
+	return &EventSourcedMovieRepository{
+		MongoMovieRepository: &MongoMovieRepository{
+			client:         client,
+			database:       client.Database(databaseName),
+			readCollection: readCollection,
+			logger:         logger,
+		},
+		snapshotEvery: opts.SnapshotEvery,
+	}
+}
+
+func (r *EventSourcedMovieRepository) FindByID(ctx context.Context, id int32) (*domain.Movie, error) {
+	movie, _, err := r.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if movie == nil {
+		logging.FromContext(ctx, r.logger).Debug("Movie not found", "id", id)
+		return nil, domain.ErrMovieNotFound
+	}
+
+	r.touch(ctx, id)
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully found movie", "id", id, "title", movie.Title)
+	return movie, nil
+}
+
+func (r *EventSourcedMovieRepository) Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+	if err := movie.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+
+	current, head, err := r.load(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		logging.FromContext(ctx, r.logger).Warn("Movie with ID already exists", "id", movie.ID)
+		return nil, domain.ErrMovieAlreadyExists
+	}
+
+	movie.Version = 1
+	if err := r.append(ctx, head, domain.MovieCreated, nil, movie); err != nil {
+		if errors.Is(err, errStreamConflict) {
+			logging.FromContext(ctx, r.logger).Warn("Movie with ID already exists", "id", movie.ID)
+			return nil, domain.ErrMovieAlreadyExists
+		}
+		return nil, err
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully created movie", "id", movie.ID, "title", movie.Title)
+	return movie, nil
+}
+
+func (r *EventSourcedMovieRepository) Upsert(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error) {
+	if err := movie.Validate(); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+
+	current, head, err := r.load(ctx, movie.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	eventType := domain.MovieCreated
+	if current != nil {
+		eventType = domain.MovieReplaced
+		movie.Version = current.Version + 1
+	} else {
+		// A movie created again continues from the version it was deleted at
+		deleted, err := r.versionAt(ctx, movie.ID, head)
+		if err != nil {
+			return nil, false, err
+		}
+		movie.Version = deleted + 1
+	}
+
+	if err := r.append(ctx, head, eventType, current, movie); err != nil {
+		if errors.Is(err, errStreamConflict) {
+			logging.FromContext(ctx, r.logger).Warn("Movie modified concurrently during upsert", "id", movie.ID)
+			return nil, false, domain.ErrVersionMismatch
+		}
+		return nil, false, err
+	}
+
+	created := current == nil
+	logging.FromContext(ctx, r.logger).Debug("Successfully upserted movie", "id", movie.ID, "created", created, "version", movie.Version)
+	return movie, created, nil
+}
+
+// UpdateVersion appends the replacement of the movie only if its current version matches
+func (r *EventSourcedMovieRepository) UpdateVersion(ctx context.Context, movie *domain.Movie, version int64) (*domain.Movie, error) {
+	if err := movie.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+
+	current, head, err := r.load(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, domain.ErrMovieNotFound
+	}
+	if current.Version != version {
+		logging.FromContext(ctx, r.logger).Debug("Movie version mismatch on update", "id", movie.ID, "version", version)
+		return nil, domain.ErrVersionMismatch
+	}
+
+	movie.Version = version + 1
+	if err := r.append(ctx, head, domain.MovieReplaced, current, movie); err != nil {
+		if errors.Is(err, errStreamConflict) {
+			logging.FromContext(ctx, r.logger).Warn("Movie modified concurrently during update", "id", movie.ID)
+			return nil, domain.ErrVersionMismatch
+		}
+		return nil, err
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully updated movie", "id", movie.ID, "version", movie.Version)
+	return movie, nil
+}
+
+func (r *EventSourcedMovieRepository) Delete(ctx context.Context, id int32) error {
+	return r.delete(ctx, id, nil)
+}
+
+// DeleteVersion deletes the movie only if its current version matches, so concurrent
+// modifications are never silently discarded
+func (r *EventSourcedMovieRepository) DeleteVersion(ctx context.Context, id int32, version int64) error {
+	return r.delete(ctx, id, &version)
+}
+
+func (r *EventSourcedMovieRepository) delete(ctx context.Context, id int32, version *int64) error {
+	current, head, err := r.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		logging.FromContext(ctx, r.logger).Debug("Movie not found for deletion", "id", id)
+		return domain.ErrMovieNotFound
+	}
+	if version != nil && current.Version != *version {
+		logging.FromContext(ctx, r.logger).Debug("Movie version mismatch on deletion", "id", id, "version", *version)
+		return domain.ErrVersionMismatch
+	}
+
+	if err := r.append(ctx, head, domain.MovieDeleted, current, nil); err != nil {
+		if errors.Is(err, errStreamConflict) {
+			logging.FromContext(ctx, r.logger).Debug("Movie modified concurrently during deletion", "id", id)
+			return domain.ErrVersionMismatch
+		}
+		return err
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully deleted movie", "id", id)
+	return nil
+}
+
+func (r *EventSourcedMovieRepository) ExistsByID(ctx context.Context, id int32) (bool, error) {
+	movie, _, err := r.load(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return movie != nil, nil
+}
+
+func (r *EventSourcedMovieRepository) GetNextID(ctx context.Context) (int32, error) {
+	// IDs of deleted movies are never reused, since their history remains
+	var last domain.MovieEvent
+	opts := options.FindOne().SetSort(bson.D{{Key: "movie_id", Value: -1}})
+	err := r.database.Collection(eventsCollection).FindOne(ctx, bson.D{}, opts).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		logging.FromContext(ctx, r.logger).Error("Failed to get max movie ID", "collection", eventsCollection, "error", err)
+		return 0, storageError("failed to get max movie ID", err)
+	}
+
+	return r.allocateID(ctx, last.MovieID)
+}
+
+// History returns every event of the movie with the state it produced, oldest first
+func (r *EventSourcedMovieRepository) History(ctx context.Context, id int32) ([]domain.MovieRevision, error) {
+	events, err := r.events(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, domain.ErrMovieNotFound
+	}
+
+	revisions := make([]domain.MovieRevision, 0, len(events))
+	var state *domain.Movie
+	for _, event := range events {
+		if state, err = event.Apply(state); err != nil {
+			return nil, fmt.Errorf("failed to replay movie %d: %w", id, err)
+		}
+		revisions = append(revisions, domain.MovieRevision{Event: event, Movie: state})
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully loaded movie history", "id", id, "events", len(revisions))
+	return revisions, nil
+}
+
+// load rebuilds the current state of the movie from its latest snapshot and the events
+// that followed, returning nil when the movie does not exist and the sequence of the
+// last event
+func (r *EventSourcedMovieRepository) load(ctx context.Context, id int32) (*domain.Movie, int64, error) {
+	var snapshot movieSnapshot
+	err := r.database.Collection(snapshotsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&snapshot)
+	if err != nil && err != mongo.ErrNoDocuments {
+		logging.FromContext(ctx, r.logger).Error("Failed to load movie snapshot", "id", id, "error", err)
+		return nil, 0, storageError("failed to load movie snapshot", err)
+	}
+
+	events, err := r.events(ctx, id, snapshot.Sequence)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	movie, err := domain.ReplayMovieEvents(snapshot.Movie, events)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to replay movie %d: %w", id, err)
+	}
+
+	head := snapshot.Sequence
+	if len(events) > 0 {
+		head = events[len(events)-1].Sequence
+	}
+	return movie, head, nil
+}
+
+// versionAt returns the version of the movie at the event with the given sequence, zero
+// before its first event
+func (r *EventSourcedMovieRepository) versionAt(ctx context.Context, id int32, sequence int64) (int64, error) {
+	if sequence == 0 {
+		return 0, nil
+	}
+	var event domain.MovieEvent
+	filter := bson.M{"movie_id": id, "sequence": sequence}
+	if err := r.database.Collection(eventsCollection).FindOne(ctx, filter).Decode(&event); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to load movie event", "id", id, "sequence", sequence, "error", err)
+		return 0, storageError("failed to load movie event", err)
+	}
+	return event.Version, nil
+}
+
+// events returns the events of the movie after the given sequence, in order
+func (r *EventSourcedMovieRepository) events(ctx context.Context, id int32, after int64) ([]domain.MovieEvent, error) {
+	filter := bson.M{"movie_id": id, "sequence": bson.M{"$gt": after}}
+	cursor, err := r.database.Collection(eventsCollection).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}))
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to load movie events", "id", id, "error", err)
+		return nil, storageError("failed to load movie events", err)
+	}
+
+	var events []domain.MovieEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, storageError("failed to decode movie events", err)
+	}
+	return events, nil
+}
+
+// append records the change from before to after as the event following head, then
+// brings the projection and snapshot up to date. The unique index on the stream position
+// makes concurrent writers fail with errStreamConflict.
+func (r *EventSourcedMovieRepository) append(ctx context.Context, head int64, eventType domain.MovieEventType, before, after *domain.Movie) error {
+	event := domain.MovieEvent{
+		Sequence:   head + 1,
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+	}
+	if after != nil {
+		event.MovieID = after.ID
+		event.Version = after.Version
+		event.Changes = domain.DiffMovies(before, after)
+	} else {
+		event.MovieID = before.ID
+		event.Version = before.Version
+	}
+
+	if _, err := r.database.Collection(eventsCollection).InsertOne(ctx, event); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errStreamConflict
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to append movie event", "id", event.MovieID, "type", event.Type, "error", err)
+		return storageError("failed to append movie event", err)
+	}
+
+	// The event is the source of truth; a projection that fails here is repaired by the
+	// next write of the movie
+	if err := r.project(ctx, event, after); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to project movie event", "id", event.MovieID, "sequence", event.Sequence, "error", err)
+	}
+	if r.snapshotEvery > 0 && event.Sequence%int64(r.snapshotEvery) == 0 {
+		r.snapshot(ctx, event, after)
+	}
+	return nil
+}
+
+// project writes the state after the event to the movies collection, unless a later
+// event was projected already
+func (r *EventSourcedMovieRepository) project(ctx context.Context, event domain.MovieEvent, movie *domain.Movie) error {
+	older := bson.M{"_id": event.MovieID, "$or": bson.A{
+		bson.M{eventSequenceField: bson.M{"$lt": event.Sequence}},
+		bson.M{eventSequenceField: bson.M{"$exists": false}},
+	}}
+
+	if movie == nil {
+		for _, name := range []string{moviesCollection, archiveCollection} {
+			if _, err := r.database.Collection(name).DeleteOne(ctx, older); err != nil {
+				return storageError("failed to delete projected movie", err)
+			}
+		}
+		return nil
+	}
+
+	doc := projectedMovie{movieDocument: *newMovieDocument(movie), EventSequence: event.Sequence}
+	_, err := r.database.Collection(moviesCollection).ReplaceOne(ctx, older, doc, options.Replace().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return storageError("failed to project movie", err)
+	}
+
+	// A write brings an archived movie back to the hot collection
+	if _, err := r.database.Collection(archiveCollection).DeleteOne(ctx, older); err != nil {
+		return storageError("failed to remove archived copy of movie", err)
+	}
+	return nil
+}
+
+// snapshot stores the state after the event; a failure only makes later loads replay more
+func (r *EventSourcedMovieRepository) snapshot(ctx context.Context, event domain.MovieEvent, movie *domain.Movie) {
+	filter := bson.M{"_id": event.MovieID, "sequence": bson.M{"$lt": event.Sequence}}
+	doc := movieSnapshot{MovieID: event.MovieID, Sequence: event.Sequence, Movie: movie}
+
+	_, err := r.database.Collection(snapshotsCollection).ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		logging.FromContext(ctx, r.logger).Warn("Failed to snapshot movie", "id", event.MovieID, "sequence", event.Sequence, "error", err)
+	}
+}
+
+// EnsureEventStore creates the index that orders movie event streams, and records a
+// creation event for every movie written before event sourcing was enabled
+func EnsureEventStore(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	database := client.Database(databaseName)
+	events := database.Collection(eventsCollection)
+
+	_, err := events.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "movie_id", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().SetName("movie_stream").SetUnique(true),
+	})
+	if err != nil {
+		logger.Error("Failed to create movie event indexes", "error", err)
+		return storageError("failed to create movie event indexes", err)
+	}
+
+	var imported int
+	for _, name := range []string{moviesCollection, archiveCollection} {
+		cursor, err := database.Collection(name).Find(ctx, bson.M{eventSequenceField: bson.M{"$exists": false}})
+		if err != nil {
+			return storageError("failed to find movies without events", err)
+		}
+
+		var movies []*domain.Movie
+		if err := cursor.All(ctx, &movies); err != nil {
+			return storageError("failed to decode movies without events", err)
+		}
+
+		for _, movie := range movies {
+			count, err := events.CountDocuments(ctx, bson.M{"movie_id": movie.ID})
+			if err != nil {
+				return storageError("failed to count movie events", err)
+			}
+			// Streams are numbered from 1 without gaps, so the count is the last sequence
+			sequence := count
+			if count == 0 {
+				sequence = 1
+				event := domain.MovieEvent{
+					MovieID:    movie.ID,
+					Sequence:   1,
+					Type:       domain.MovieCreated,
+					Version:    movie.Version,
+					Changes:    domain.DiffMovies(nil, movie),
+					OccurredAt: time.Now().UTC(),
+				}
+				if _, err := events.InsertOne(ctx, event); err != nil && !mongo.IsDuplicateKeyError(err) {
+					return fmt.Errorf("failed to import movie %d: %w", movie.ID, err)
+				}
+				imported++
+			}
+
+			// Mark the movie as projected so later startups skip it
+			update := bson.M{"$set": bson.M{eventSequenceField: sequence}}
+			if _, err := database.Collection(name).UpdateOne(ctx, bson.M{"_id": movie.ID, eventSequenceField: bson.M{"$exists": false}}, update); err != nil {
+				return fmt.Errorf("failed to mark movie %d as imported: %w", movie.ID, err)
+			}
+		}
+	}
+
+	logger.Info("Movie event store ready", "imported_movies", imported)
+	return nil
+}
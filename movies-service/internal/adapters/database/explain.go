@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// collectionScanStage is the plan stage of queries reading every document
+const collectionScanStage = "COLLSCAN"
+
+// QueryShape is a query the repositories run, explained by ExplainQueries to check it
+// is served by an index
+type QueryShape struct {
+	Name       string
+	Collection string
+	// Command is the find or aggregate command explained
+	Command bson.D
+	// ScanAllowed marks queries that read the whole collection by design, such as
+	// unfiltered counts
+	ScanAllowed bool
+}
+
+// ExplainResult is the winning plan of a query shape
+type ExplainResult struct {
+	Shape QueryShape
+	// Stages lists the stages of the winning plan, outermost first
+	Stages []string
+	Err    error
+}
+
+// CollectionScan reports whether the winning plan reads every document of the collection
+func (r ExplainResult) CollectionScan() bool {
+	for _, stage := range r.Stages {
+		if stage == collectionScanStage {
+			return true
+		}
+	}
+	return false
+}
+
+// Regression reports whether the query scans the collection without being allowed to
+func (r ExplainResult) Regression() bool {
+	return r.Err == nil && r.CollectionScan() && !r.Shape.ScanAllowed
+}
+
+// QueryShapes returns the main queries of the repositories, with listings reading from
+// the read model when useReadModel is set. They are built by the same code as the
+// repositories, so new filters are checked as they are added
+func QueryShapes(useReadModel bool) ([]QueryShape, error) {
+	readCollection := moviesCollection
+	if useReadModel {
+		readCollection = readModelCollection
+	}
+
+	filters := []struct {
+		name   string
+		filter domain.MovieFilter
+	}{
+		// Unfiltered listings count and group the whole collection
+		{"", domain.MovieFilter{}},
+		{"title", domain.MovieFilter{Expr: &domain.FilterExpr{Condition: &domain.FilterCondition{Field: "title", Op: domain.OpEq, Value: "Heat"}}}},
+		{"title_contains", domain.MovieFilter{Expr: &domain.FilterExpr{Condition: &domain.FilterCondition{Field: "title", Op: domain.OpContains, Value: "heat"}}}},
+		{"year_range", domain.MovieFilter{Expr: &domain.FilterExpr{And: []*domain.FilterExpr{
+			{Condition: &domain.FilterCondition{Field: "year", Op: domain.OpGte, Value: "1990"}},
+			{Condition: &domain.FilterCondition{Field: "year", Op: domain.OpLt, Value: "2000"}},
+		}}}},
+		{"region", domain.MovieFilter{Region: "BR"}},
+		{"certification", domain.MovieFilter{Certification: "PG-13"}},
+	}
+
+	collation := searchCollation.ToDocument()
+	shapes := []QueryShape{
+		{Name: "find_by_id", Collection: moviesCollection, Command: findCommand(moviesCollection, bson.M{"_id": int32(1)}, nil, nil)},
+		{Name: "max_id", Collection: moviesCollection, Command: findCommand(moviesCollection, bson.M{}, bson.D{{Key: "_id", Value: -1}}, nil)},
+		{Name: "stale_movies", Collection: moviesCollection, Command: findCommand(moviesCollection,
+			bson.M{lastAccessedField: bson.M{"$lt": time.Now().UTC()}}, bson.D{{Key: lastAccessedField, Value: 1}}, nil)},
+		{Name: "comment_threads", Collection: commentsCollection, Command: findCommand(commentsCollection,
+			bson.M{"movie_id": int32(1), "parent_id": nil}, bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}, nil)},
+		{Name: "comment_replies", Collection: commentsCollection, Command: findCommand(commentsCollection,
+			bson.M{"thread_id": bson.M{"$in": bson.A{primitive.NewObjectID()}}, "parent_id": bson.M{"$ne": nil}},
+			bson.D{{Key: "thread_id", Value: 1}, {Key: "created_at", Value: 1}}, nil)},
+		{Name: "flagged_comments", Collection: commentsCollection, Command: findCommand(commentsCollection,
+			bson.M{"flag_count": bson.M{"$gt": 0}, "deleted_at": nil}, bson.D{{Key: "flag_count", Value: -1}, {Key: "created_at", Value: -1}}, nil)},
+		{Name: "moderation_queue", Collection: commentsCollection, Command: findCommand(commentsCollection,
+			bson.M{"status": domain.ModerationReview, "deleted_at": nil}, bson.D{{Key: "created_at", Value: 1}}, nil)},
+		{Name: "find_by_imdb_id", Collection: moviesCollection, Command: findCommand(moviesCollection, bson.M{"imdb_id": "tt0113277"}, nil, nil)},
+		{Name: "find_by_tmdb_id", Collection: moviesCollection, Command: findCommand(moviesCollection, bson.M{"tmdb_id": "949"}, nil, nil)},
+		{Name: "sync_conflicts", Collection: syncConflictsCollection, Command: findCommand(syncConflictsCollection,
+			bson.M{}, bson.D{{Key: "detected_at", Value: 1}}, nil)},
+		{Name: "job_queue", Collection: jobsCollection, Command: findCommand(jobsCollection,
+			bson.M{"status": domain.JobPending}, bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}, nil)},
+		{Name: "starved_jobs", Collection: jobsCollection, Command: findCommand(jobsCollection,
+			bson.M{"status": domain.JobPending, "created_at": bson.M{"$lt": time.Now().UTC()}}, bson.D{{Key: "created_at", Value: 1}}, nil)},
+		{Name: "expired_jobs", Collection: jobsCollection, Command: findCommand(jobsCollection,
+			bson.M{"status": domain.JobRunning, "lease_until": bson.M{"$lt": time.Now().UTC()}}, nil, nil)},
+	}
+
+	for _, f := range filters {
+		query, err := FilterToBSON(f.filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s query shape: %w", f.name, err)
+		}
+		pipeline, err := facetsPipeline(f.filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s query shape: %w", f.name, err)
+		}
+		suffix, unfiltered := "_by_"+f.name, f.name == ""
+		if unfiltered {
+			suffix = ""
+		}
+		shapes = append(shapes,
+			QueryShape{Name: "list" + suffix, Collection: readCollection, ScanAllowed: unfiltered,
+				Command: findCommand(readCollection, query, bson.D{{Key: "_id", Value: 1}}, collation)},
+			QueryShape{Name: "count" + suffix, Collection: readCollection, ScanAllowed: unfiltered,
+				Command: aggregateCommand(readCollection, countPipeline(query), collation)},
+			QueryShape{Name: "facets" + suffix, Collection: readCollection, ScanAllowed: unfiltered,
+				Command: aggregateCommand(readCollection, pipeline, collation)},
+		)
+	}
+	return shapes, nil
+}
+
+// ExplainQueries explains every query shape against the database, so an index
+// regression shows up as a collection scan before it reaches production traffic
+func ExplainQueries(ctx context.Context, client *mongo.Client, databaseName string, shapes []QueryShape) []ExplainResult {
+	database := client.Database(databaseName)
+
+	results := make([]ExplainResult, len(shapes))
+	for i, shape := range shapes {
+		results[i].Shape = shape
+
+		var explained bson.D
+		err := database.RunCommand(ctx, bson.D{
+			{Key: "explain", Value: shape.Command},
+			{Key: "verbosity", Value: "queryPlanner"},
+		}).Decode(&explained)
+		if err != nil {
+			results[i].Err = fmt.Errorf("failed to explain %s: %w", shape.Name, err)
+			continue
+		}
+		results[i].Stages = winningStages(explained, false)
+	}
+	return results
+}
+
+func findCommand(collection string, filter bson.M, sort bson.D, collation bson.Raw) bson.D {
+	command := bson.D{{Key: "find", Value: collection}, {Key: "filter", Value: filter}}
+	if sort != nil {
+		command = append(command, bson.E{Key: "sort", Value: sort}, bson.E{Key: "limit", Value: 1})
+	}
+	if collation != nil {
+		command = append(command, bson.E{Key: "collation", Value: collation})
+	}
+	return command
+}
+
+func aggregateCommand(collection string, pipeline mongo.Pipeline, collation bson.Raw) bson.D {
+	return bson.D{
+		{Key: "aggregate", Value: collection},
+		{Key: "pipeline", Value: pipeline},
+		{Key: "cursor", Value: bson.D{}},
+		{Key: "collation", Value: collation},
+	}
+}
+
+// countPipeline is the aggregation run by CountDocuments
+func countPipeline(query bson.M) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$group", Value: bson.M{"_id": 1, "n": bson.M{"$sum": 1}}}},
+	}
+}
+
+// winningStages collects the stage names of the winning plans found in an explain
+// output. Aggregations nest them under their $cursor stage and recent servers under
+// queryPlan, so the whole document is searched
+func winningStages(value interface{}, inWinningPlan bool) []string {
+	var stages []string
+	switch v := value.(type) {
+	case bson.D:
+		for _, elem := range v {
+			switch {
+			case elem.Key == "rejectedPlans":
+				continue
+			case elem.Key == "stage" && inWinningPlan:
+				if name, ok := elem.Value.(string); ok {
+					stages = append(stages, name)
+				}
+			default:
+				stages = append(stages, winningStages(elem.Value, inWinningPlan || elem.Key == "winningPlan")...)
+			}
+		}
+	case bson.A:
+		for _, child := range v {
+			stages = append(stages, winningStages(child, inWinningPlan)...)
+		}
+	}
+	return stages
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+// Export returns up to limit movies with an ID greater than afterID, in ID order. Archived
+// movies are included and flagged, so paging through the export copies the whole catalog
+func (r *MongoMovieRepository) Export(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error) {
+	query := bson.M{"_id": bson.M{"$gt": afterID}}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(limit))
+
+	var movies []*domain.Movie
+	for _, name := range []string{moviesCollection, archiveCollection} {
+		cursor, err := r.database.Collection(name).Find(ctx, query, opts)
+		if err != nil {
+			logging.FromContext(ctx, r.logger).Error("Failed to export movies", "collection", name, "error", err)
+			return nil, storageError("failed to export movies", err)
+		}
+
+		var page []*domain.Movie
+		if err := cursor.All(ctx, &page); err != nil {
+			return nil, storageError("failed to decode exported movies", err)
+		}
+		for _, movie := range page {
+			movie.Archived = name == archiveCollection
+		}
+		movies = append(movies, page...)
+	}
+
+	// Each collection returned its first movies, so the first limit of both are the
+	// first of the catalog
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ID < movies[j].ID })
+	if len(movies) > limit {
+		movies = movies[:limit]
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Exported movies", "after_id", afterID, "count", len(movies))
+	return movies, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+// externalIDFields maps each external catalog to the movie field holding its IDs, which
+// is also the name of its unique index
+var externalIDFields = map[string]string{
+	domain.SourceIMDb: "imdb_id",
+	domain.SourceTMDb: "tmdb_id",
+}
+
+// externalIDIndexes keep external IDs unique. They are sparse, since most movies have no
+// ID in some catalogs
+func externalIDIndexes() []mongo.IndexModel {
+	indexes := make([]mongo.IndexModel, 0, len(domain.ExternalSources))
+	for _, source := range domain.ExternalSources {
+		field := externalIDFields[source]
+		indexes = append(indexes, mongo.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetName(field).SetUnique(true).SetSparse(true),
+		})
+	}
+	return indexes
+}
+
+// FindByExternalID returns the movie, archived or not, with the ID in the external catalog
+func (r *MongoMovieRepository) FindByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	field, ok := externalIDFields[source]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown source %q", domain.ErrInvalidExternalID, source)
+	}
+
+	for _, name := range []string{moviesCollection, archiveCollection} {
+		var movie domain.Movie
+		err := r.database.Collection(name).FindOne(ctx, bson.M{field: id}).Decode(&movie)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx, r.logger).Error("Failed to find movie by external ID", "source", source, "external_id", id, "error", err)
+			return nil, storageError("failed to find movie by external ID", err)
+		}
+
+		movie.Archived = name == archiveCollection
+		logging.FromContext(ctx, r.logger).Debug("Successfully found movie by external ID", "source", source, "external_id", id, "id", movie.ID)
+		return &movie, nil
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Movie not found by external ID", "source", source, "external_id", id)
+	return nil, domain.ErrMovieNotFound
+}
+
+// externalIDConflict reports whether err is a duplicate key on one of the external ID
+// indexes rather than on the movie ID
+func externalIDConflict(err error) bool {
+	if !mongo.IsDuplicateKeyError(err) {
+		return false
+	}
+	for _, field := range externalIDFields {
+		if strings.Contains(err.Error(), "index: "+field+" ") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+type facetBucketDocument struct {
+	Value string `bson:"_id"`
+	Count int32  `bson:"count"`
+}
+
+type facetsDocument struct {
+	Years  []facetBucketDocument `bson:"years"`
+	Genres []facetBucketDocument `bson:"genres"`
+	Total  []struct {
+		Count int32 `bson:"count"`
+	} `bson:"total"`
+}
+
+// facetsPipeline builds the aggregation computing every facet of the movies matching
+// the filter in a single $facet stage
+func facetsPipeline(filter domain.MovieFilter) (mongo.Pipeline, error) {
+	query, err := FilterToBSON(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Years are 4-digit strings, so the decade is the first three digits followed by "0s"
+	decade := bson.M{"$concat": bson.A{bson.M{"$substrCP": bson.A{"$year", 0, 3}}, "0s"}}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$facet", Value: bson.M{
+			"years": bson.A{
+				bson.M{"$group": bson.M{"_id": decade, "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"genres": bson.A{
+				bson.M{"$unwind": "$genres"},
+				bson.M{"$group": bson.M{"_id": "$genres", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			},
+			"total": bson.A{
+				bson.M{"$count": "count"},
+			},
+		}}},
+	}, nil
+}
+
+func (r *MongoMovieRepository) Facets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	collection := r.database.Collection(r.readCollection)
+
+	pipeline, err := facetsPipeline(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetCollation(searchCollation))
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to aggregate movie facets", "error", err)
+		return nil, storageError("failed to aggregate movie facets", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			logging.FromContext(ctx, r.logger).Warn("Failed to close cursor", "error", err)
+		}
+	}()
+
+	var results []facetsDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to decode movie facets", "error", err)
+		return nil, storageError("failed to decode movie facets", err)
+	}
+
+	facets := &domain.MovieFacets{Years: []domain.FacetBucket{}, Genres: []domain.FacetBucket{}}
+	if len(results) == 0 {
+		return facets, nil
+	}
+
+	for _, bucket := range results[0].Years {
+		facets.Years = append(facets.Years, domain.FacetBucket{Value: bucket.Value, Count: bucket.Count})
+	}
+	for _, bucket := range results[0].Genres {
+		facets.Genres = append(facets.Genres, domain.FacetBucket{Value: bucket.Value, Count: bucket.Count})
+	}
+	if len(results[0].Total) > 0 {
+		facets.Total = results[0].Total[0].Count
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully aggregated movie facets", "total", facets.Total)
+	return facets, nil
+}
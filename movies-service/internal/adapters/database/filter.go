@@ -0,0 +1,120 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+var mongoOperators = map[domain.FilterOperator]string{
+	domain.OpEq:  "$eq",
+	domain.OpNe:  "$ne",
+	domain.OpGt:  "$gt",
+	domain.OpGte: "$gte",
+	domain.OpLt:  "$lt",
+	domain.OpLte: "$lte",
+}
+
+// mongoFields maps filterable fields onto document fields
+var mongoFields = map[string]string{
+	"id":    "_id",
+	"title": "title",
+	"year":  "year",
+}
+
+// FilterToBSON translates a listing filter into a MongoDB query document. Comparisons on
+// title rely on the query collation to ignore case and diacritics.
+func FilterToBSON(filter domain.MovieFilter) (bson.M, error) {
+	var clauses bson.A
+
+	if filter.Expr != nil {
+		expr, err := exprToBSON(filter.Expr)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, expr)
+	}
+
+	if filter.Region != "" {
+		clauses = append(clauses, regionToBSON(filter.Region))
+	}
+
+	if filter.Certification != "" {
+		clauses = append(clauses, bson.M{"certification": filter.Certification})
+	}
+
+	switch len(clauses) {
+	case 0:
+		return bson.M{}, nil
+	case 1:
+		return clauses[0].(bson.M), nil
+	}
+	return bson.M{"$and": clauses}, nil
+}
+
+// regionToBSON matches movies available in the region, including movies without any
+// region restriction
+func regionToBSON(region string) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"regions": region},
+		bson.M{"regions": bson.M{"$exists": false}},
+		bson.M{"regions": bson.A{}},
+	}}
+}
+
+func exprToBSON(expr *domain.FilterExpr) (bson.M, error) {
+	switch {
+	case expr.Condition != nil:
+		return conditionToBSON(expr.Condition)
+	case len(expr.And) > 0:
+		clauses, err := groupToBSON(expr.And)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$and": clauses}, nil
+	case len(expr.Or) > 0:
+		clauses, err := groupToBSON(expr.Or)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$or": clauses}, nil
+	}
+	return nil, fmt.Errorf("%w: empty expression", domain.ErrInvalidFilter)
+}
+
+func groupToBSON(exprs []*domain.FilterExpr) (bson.A, error) {
+	clauses := make(bson.A, len(exprs))
+	for i, expr := range exprs {
+		clause, err := exprToBSON(expr)
+		if err != nil {
+			return nil, err
+		}
+		clauses[i] = clause
+	}
+	return clauses, nil
+}
+
+func conditionToBSON(c *domain.FilterCondition) (bson.M, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	field := mongoFields[c.Field]
+
+	if c.Op == domain.OpContains {
+		pattern := regexp.QuoteMeta(normalizeSearchText(c.Value))
+		return bson.M{searchTitleField: bson.M{"$regex": pattern}}, nil
+	}
+
+	var value interface{} = c.Value
+	if c.Field == "id" {
+		id, _ := strconv.ParseInt(c.Value, 10, 32)
+		value = int32(id)
+	}
+
+	return bson.M{field: bson.M{mongoOperators[c.Op]: value}}, nil
+}
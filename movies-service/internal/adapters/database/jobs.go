@@ -0,0 +1,328 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const (
+	jobsCollection = "jobs"
+	// jobFilesBucket is the GridFS bucket of the files of jobs, stored under the job ID
+	jobFilesBucket = "job_files"
+)
+
+// jobDocument keeps the parameters and result of a job as JSON text, so they read back
+// exactly as their handler wrote them
+type jobDocument struct {
+	ID     primitive.ObjectID `bson:"_id"`
+	Type   domain.JobType     `bson:"type"`
+	Status domain.JobStatus   `bson:"status"`
+	// Priority is the rank of the priority, so the queue sorts on it
+	Priority   int32      `bson:"priority"`
+	Params     string     `bson:"params"`
+	Done       int32      `bson:"done"`
+	Total      int32      `bson:"total"`
+	Result     string     `bson:"result,omitempty"`
+	File       bool       `bson:"file"`
+	Error      string     `bson:"error,omitempty"`
+	Owner      string     `bson:"owner,omitempty"`
+	LeaseUntil *time.Time `bson:"lease_until,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	StartedAt  *time.Time `bson:"started_at,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty"`
+}
+
+func (d *jobDocument) toDomain() *domain.Job {
+	job := &domain.Job{
+		ID:        d.ID.Hex(),
+		Type:      d.Type,
+		Status:    d.Status,
+		Priority:  domain.JobPriorityOfRank(d.Priority),
+		Params:    json.RawMessage(d.Params),
+		Done:      d.Done,
+		Total:     d.Total,
+		File:      d.File,
+		Error:     d.Error,
+		Owner:     d.Owner,
+		CreatedAt: d.CreatedAt,
+	}
+	if d.Result != "" {
+		job.Result = json.RawMessage(d.Result)
+	}
+	if d.LeaseUntil != nil {
+		job.LeaseUntil = *d.LeaseUntil
+	}
+	if d.StartedAt != nil {
+		job.StartedAt = *d.StartedAt
+	}
+	if d.FinishedAt != nil {
+		job.FinishedAt = *d.FinishedAt
+	}
+	return job
+}
+
+// MongoJobRepository keeps the queue of jobs in one collection. Workers claim pending
+// jobs with an atomic update, so each job runs on a single replica
+type MongoJobRepository struct {
+	collection *mongo.Collection
+	logger     *slog.Logger
+}
+
+func NewMongoJobRepository(client *mongo.Client, databaseName string, logger *slog.Logger) ports.JobRepository {
+	return &MongoJobRepository{
+		collection: client.Database(databaseName).Collection(jobsCollection),
+		logger:     logger,
+	}
+}
+
+// EnsureJobIndexes creates the indexes claiming pending jobs by priority or oldest first
+// and finding expired leases and finished jobs
+func EnsureJobIndexes(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	collection := client.Database(databaseName).Collection(jobsCollection)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("queue"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "priority", Value: -1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("priority_queue"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}},
+			Options: options.Index().SetName("lease"),
+		},
+		{
+			Keys:    bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetName("finished_at"),
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to create job indexes", "error", err)
+		return storageError("failed to create job indexes", err)
+	}
+	return nil
+}
+
+func (r *MongoJobRepository) CreateJob(ctx context.Context, job *domain.Job) error {
+	id, err := newObjectID(job.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidJob, err)
+	}
+	doc := jobDocument{
+		ID:        id,
+		Type:      job.Type,
+		Status:    job.Status,
+		Priority:  job.Priority.Rank(),
+		Params:    string(job.Params),
+		CreatedAt: job.CreatedAt,
+	}
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to create job", "type", job.Type, "error", err)
+		return storageError("failed to create job", err)
+	}
+	job.ID = doc.ID.Hex()
+	return nil
+}
+
+func (r *MongoJobRepository) FindJob(ctx context.Context, id string) (*domain.Job, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrJobNotFound
+	}
+
+	var doc jobDocument
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrJobNotFound
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to find job", "id", id, "error", err)
+		return nil, storageError("failed to find job", err)
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoJobRepository) ClaimJob(ctx context.Context, owner string, leaseUntil, starvedBefore time.Time) (*domain.Job, error) {
+	update := bson.M{"$set": bson.M{
+		"status":      domain.JobRunning,
+		"owner":       owner,
+		"lease_until": leaseUntil,
+		"started_at":  time.Now().UTC(),
+	}}
+
+	job, err := r.claim(ctx, bson.M{"status": domain.JobPending, "created_at": bson.M{"$lt": starvedBefore}},
+		bson.D{{Key: "created_at", Value: 1}}, update)
+	if job != nil || err != nil {
+		return job, err
+	}
+	return r.claim(ctx, bson.M{"status": domain.JobPending},
+		bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}}, update)
+}
+
+// claim applies the claim update to the first job matching filter in the sort order,
+// returning nil when none matches
+func (r *MongoJobRepository) claim(ctx context.Context, filter bson.M, sort bson.D, update bson.M) (*domain.Job, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(sort).
+		SetReturnDocument(options.After)
+
+	var doc jobDocument
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, storageError("failed to claim job", err)
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoJobRepository) UpdateJob(ctx context.Context, job *domain.Job) error {
+	objectID, err := primitive.ObjectIDFromHex(job.ID)
+	if err != nil {
+		return domain.ErrJobNotFound
+	}
+
+	set := bson.M{
+		"status":      job.Status,
+		"done":        job.Done,
+		"total":       job.Total,
+		"file":        job.File,
+		"error":       job.Error,
+		"lease_until": job.LeaseUntil,
+	}
+	if len(job.Result) > 0 {
+		set["result"] = string(job.Result)
+	}
+	if !job.FinishedAt.IsZero() {
+		set["finished_at"] = job.FinishedAt
+	}
+	return r.updateRunning(ctx, objectID, job.Owner, set)
+}
+
+func (r *MongoJobRepository) RenewJobLease(ctx context.Context, id, owner string, leaseUntil time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrJobNotFound
+	}
+	return r.updateRunning(ctx, objectID, owner, bson.M{"lease_until": leaseUntil})
+}
+
+// updateRunning updates a job only while it is running for owner, so a worker that lost
+// its lease can't overwrite the outcome recorded by Sweep
+func (r *MongoJobRepository) updateRunning(ctx context.Context, id primitive.ObjectID, owner string, set bson.M) error {
+	filter := bson.M{"_id": id, "status": domain.JobRunning, "owner": owner}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to update job", "id", id.Hex(), "error", err)
+		return storageError("failed to update job", err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrJobNotFound
+	}
+	return nil
+}
+
+func (r *MongoJobRepository) FailExpiredJobs(ctx context.Context, now time.Time, reason string) (int, error) {
+	filter := bson.M{"status": domain.JobRunning, "lease_until": bson.M{"$lt": now}}
+	update := bson.M{"$set": bson.M{"status": domain.JobFailed, "error": reason, "finished_at": now}}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to fail expired jobs", "error", err)
+		return 0, storageError("failed to fail expired jobs", err)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+func (r *MongoJobRepository) DeleteFinishedJobs(ctx context.Context, before time.Time) ([]string, error) {
+	filter := bson.M{"finished_at": bson.M{"$lt": before}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, storageError("failed to find finished jobs", err)
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, storageError("failed to decode finished jobs", err)
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	ids, objectIDs := make([]string, len(docs)), make(bson.A, len(docs))
+	for i, doc := range docs {
+		ids[i], objectIDs[i] = doc.ID.Hex(), doc.ID
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": objectIDs}}); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to delete finished jobs", "error", err)
+		return nil, storageError("failed to delete finished jobs", err)
+	}
+	return ids, nil
+}
+
+// GridFSJobFileStore keeps the files of jobs in a GridFS bucket, so files of any size
+// are shared by every replica
+type GridFSJobFileStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSJobFileStore(client *mongo.Client, databaseName string) (ports.JobFileStore, error) {
+	bucket, err := gridfs.NewBucket(client.Database(databaseName), options.GridFSBucket().SetName(jobFilesBucket))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSJobFileStore{bucket: bucket}, nil
+}
+
+func (s *GridFSJobFileStore) Create(ctx context.Context, jobID string) (io.WriteCloser, error) {
+	stream, err := s.bucket.OpenUploadStreamWithID(jobID, jobID)
+	if err != nil {
+		return nil, storageError("failed to create job file", err)
+	}
+	return stream, nil
+}
+
+// Open skips the chunks before offset in the bucket, so they are not sent to the gateway
+func (s *GridFSJobFileStore) Open(ctx context.Context, jobID string, offset int64) (*domain.JobFile, error) {
+	stream, err := s.bucket.OpenDownloadStream(jobID)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, domain.ErrNoJobFile
+	}
+	if err != nil {
+		return nil, storageError("failed to open job file", err)
+	}
+	size := stream.GetFile().Length
+	if _, err := stream.Skip(domain.JobFileOffset(offset, size)); err != nil {
+		stream.Close()
+		return nil, storageError("failed to seek job file", err)
+	}
+	return &domain.JobFile{ReadCloser: stream, Size: size}, nil
+}
+
+func (s *GridFSJobFileStore) Delete(ctx context.Context, jobID string) error {
+	err := s.bucket.DeleteContext(ctx, jobID)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return domain.ErrNoJobFile
+	}
+	if err != nil {
+		return storageError("failed to delete job file", err)
+	}
+	return nil
+}
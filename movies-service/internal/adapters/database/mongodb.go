@@ -2,69 +2,95 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/movie-microservice/movies-service/internal/core/domain"
 	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/retry"
 )
 
 const (
-	moviesCollection = "movies"
-	defaultTimeout   = 10 * time.Second
+	moviesCollection   = "movies"
+	countersCollection = "counters"
+	// tombstonesCollection keeps the last version of deleted movies
+	tombstonesCollection = "movie_tombstones"
+	defaultTimeout       = 10 * time.Second
 )
 
+// connectRetry pings an unreachable server again until the context of Connect ends, so
+// the service can start before MongoDB is up
+var connectRetry = retry.Policy{
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  5 * time.Second,
+	Retryable: func(err error) bool {
+		return mongo.IsNetworkError(err) || mongo.IsTimeout(err)
+	},
+}
+
 type MongoMovieRepository struct {
 	client   *mongo.Client
 	database *mongo.Database
-	logger   *slog.Logger
+	// readCollection is the collection queried by listings, counts and facets
+	readCollection string
+	logger         *slog.Logger
 }
 
 func NewMongoMovieRepository(client *mongo.Client, databaseName string, logger *slog.Logger) ports.MovieRepository {
 	database := client.Database(databaseName)
 
 	return &MongoMovieRepository{
-		client:   client,
-		database: database,
-		logger:   logger,
+		client:         client,
+		database:       database,
+		readCollection: moviesCollection,
+		logger:         logger,
 	}
 }
 
 func (r *MongoMovieRepository) FindAll(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, error) {
-	collection := r.database.Collection(moviesCollection)
+	collection := r.database.Collection(r.readCollection)
 
 	// Calculate skip value
 	skip := (filter.Page - 1) * filter.Limit
 
+	query, err := FilterToBSON(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set up options
 	opts := options.Find().
 		SetSkip(int64(skip)).
 		SetLimit(int64(filter.Limit)).
-		SetSort(bson.D{{Key: "_id", Value: 1}})
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetCollation(searchCollation)
 
-	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	cursor, err := collection.Find(ctx, query, opts)
 	if err != nil {
-		r.logger.Error("Failed to find movies", "error", err)
-		return nil, fmt.Errorf("failed to find movies: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to find movies", "error", err)
+		return nil, storageError("failed to find movies", err)
 	}
 	defer func() {
 		if err := cursor.Close(ctx); err != nil {
-			r.logger.Warn("Failed to close cursor", "error", err)
+			logging.FromContext(ctx, r.logger).Warn("Failed to close cursor", "error", err)
 		}
 	}()
 
 	var movies []*domain.Movie
 	if err := cursor.All(ctx, &movies); err != nil {
-		r.logger.Error("Failed to decode movies", "error", err)
-		return nil, fmt.Errorf("failed to decode movies: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to decode movies", "error", err)
+		return nil, storageError("failed to decode movies", err)
 	}
 
-	r.logger.Info("Successfully found movies", "count", len(movies), "page", filter.Page, "limit", filter.Limit)
+	logging.FromContext(ctx, r.logger).Debug("Successfully found movies", "count", len(movies), "page", filter.Page, "limit", filter.Limit)
 	return movies, nil
 }
 
@@ -75,14 +101,21 @@ func (r *MongoMovieRepository) FindByID(ctx context.Context, id int32) (*domain.
 	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&movie)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			r.logger.Info("Movie not found", "id", id)
-			return nil, domain.ErrMovieNotFound
+			archived, err := r.findArchived(ctx, id)
+			if err != nil {
+				logging.FromContext(ctx, r.logger).Debug("Movie not found", "id", id)
+				return nil, err
+			}
+			logging.FromContext(ctx, r.logger).Debug("Found archived movie", "id", id, "title", archived.Title)
+			return archived, nil
 		}
-		r.logger.Error("Failed to find movie by ID", "id", id, "error", err)
-		return nil, fmt.Errorf("failed to find movie by ID: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to find movie by ID", "id", id, "error", err)
+		return nil, storageError("failed to find movie by ID", err)
 	}
 
-	r.logger.Info("Successfully found movie", "id", id, "title", movie.Title)
+	r.touch(ctx, id)
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully found movie", "id", id, "title", movie.Title)
 	return &movie, nil
 }
 
@@ -91,51 +124,229 @@ func (r *MongoMovieRepository) Create(ctx context.Context, movie *domain.Movie)
 
 	// Validate movie before insertion
 	if err := movie.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid movie data: %w", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
 	}
 
-	_, err := collection.InsertOne(ctx, movie)
+	_, err := collection.InsertOne(ctx, newMovieDocument(movie))
 	if err != nil {
+		if externalIDConflict(err) {
+			logging.FromContext(ctx, r.logger).Warn("External ID of movie already taken", "id", movie.ID)
+			return nil, domain.ErrExternalIDTaken
+		}
 		if mongo.IsDuplicateKeyError(err) {
-			r.logger.Warn("Movie with ID already exists", "id", movie.ID)
+			logging.FromContext(ctx, r.logger).Warn("Movie with ID already exists", "id", movie.ID)
 			return nil, domain.ErrMovieAlreadyExists
 		}
-		r.logger.Error("Failed to create movie", "movie", movie, "error", err)
-		return nil, fmt.Errorf("failed to create movie: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to create movie", "movie", movie, "error", err)
+		return nil, storageError("failed to create movie", err)
 	}
 
-	r.logger.Info("Successfully created movie", "id", movie.ID, "title", movie.Title)
+	logging.FromContext(ctx, r.logger).Debug("Successfully created movie", "id", movie.ID, "title", movie.Title)
 	return movie, nil
 }
 
-func (r *MongoMovieRepository) Delete(ctx context.Context, id int32) error {
+// Upsert replaces the movie with the same ID or inserts it when none exists, returning
+// whether it was created. Replacements bump the version and fail with ErrVersionMismatch
+// if the movie was modified concurrently, as do insertions racing with another write of
+// the ID. A movie inserted again after its deletion continues from its last version, so
+// ETags of the deleted movie never match the new one
+func (r *MongoMovieRepository) Upsert(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error) {
 	collection := r.database.Collection(moviesCollection)
 
-	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err := movie.Validate(); err != nil {
+		return nil, false, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+
+	version, archived, err := r.storedVersion(ctx, movie.ID)
+	if errors.Is(err, domain.ErrMovieNotFound) {
+		return r.insertAgain(ctx, movie)
+	}
 	if err != nil {
-		r.logger.Error("Failed to delete movie", "id", id, "error", err)
-		return fmt.Errorf("failed to delete movie: %w", err)
+		return nil, false, err
+	}
+	movie.Version = version + 1
+
+	// The archived copy is only inserted back if no other write brought the movie back first
+	filter := bson.M{"_id": movie.ID, "version": versionFilter(version)}
+	result, err := collection.ReplaceOne(ctx, filter, newMovieDocument(movie), options.Replace().SetUpsert(archived))
+	if err != nil {
+		if externalIDConflict(err) {
+			logging.FromContext(ctx, r.logger).Warn("External ID of movie already taken", "id", movie.ID)
+			return nil, false, domain.ErrExternalIDTaken
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			logging.FromContext(ctx, r.logger).Warn("Movie modified concurrently during upsert", "id", movie.ID)
+			return nil, false, domain.ErrVersionMismatch
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to upsert movie", "movie", movie, "error", err)
+		return nil, false, storageError("failed to upsert movie", err)
+	}
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		logging.FromContext(ctx, r.logger).Warn("Movie modified concurrently during upsert", "id", movie.ID)
+		return nil, false, domain.ErrVersionMismatch
+	}
+
+	if archived {
+		// The replacement brought the movie back to the hot collection
+		if _, err := r.database.Collection(archiveCollection).DeleteOne(ctx, bson.M{"_id": movie.ID}); err != nil {
+			logging.FromContext(ctx, r.logger).Warn("Failed to remove archived copy of upserted movie", "id", movie.ID, "error", err)
+		}
+	}
+	logging.FromContext(ctx, r.logger).Debug("Successfully upserted movie", "id", movie.ID, "created", false, "version", movie.Version)
+	return movie, false, nil
+}
+
+// insertAgain inserts a movie no stored movie has the ID of, with the version following
+// the one it had when deleted, if it ever existed
+func (r *MongoMovieRepository) insertAgain(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error) {
+	var tombstone struct {
+		Version int64 `bson:"version"`
 	}
+	err := r.database.Collection(tombstonesCollection).FindOne(ctx, bson.M{"_id": movie.ID}).Decode(&tombstone)
+	if err != nil && err != mongo.ErrNoDocuments {
+		logging.FromContext(ctx, r.logger).Error("Failed to find version of deleted movie", "id", movie.ID, "error", err)
+		return nil, false, storageError("failed to find version of deleted movie", err)
+	}
+	movie.Version = tombstone.Version + 1
+
+	if _, err := r.database.Collection(moviesCollection).InsertOne(ctx, newMovieDocument(movie)); err != nil {
+		if externalIDConflict(err) {
+			logging.FromContext(ctx, r.logger).Warn("External ID of movie already taken", "id", movie.ID)
+			return nil, false, domain.ErrExternalIDTaken
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			logging.FromContext(ctx, r.logger).Warn("Movie created concurrently during upsert", "id", movie.ID)
+			return nil, false, domain.ErrVersionMismatch
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to upsert movie", "movie", movie, "error", err)
+		return nil, false, storageError("failed to upsert movie", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully upserted movie", "id", movie.ID, "created", true, "version", movie.Version)
+	return movie, true, nil
+}
 
-	if result.DeletedCount == 0 {
-		r.logger.Info("Movie not found for deletion", "id", id)
+// UpdateVersion replaces the movie only if its stored version matches, failing with
+// ErrVersionMismatch otherwise. An archived movie comes back to the hot collection
+func (r *MongoMovieRepository) UpdateVersion(ctx context.Context, movie *domain.Movie, version int64) (*domain.Movie, error) {
+	collection := r.database.Collection(moviesCollection)
+
+	if err := movie.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+
+	existing, err := r.FindByID(ctx, movie.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Version != version {
+		logging.FromContext(ctx, r.logger).Debug("Movie version mismatch on update", "id", movie.ID, "version", version)
+		return nil, domain.ErrVersionMismatch
+	}
+	movie.Version = version + 1
+
+	// The archived copy is only inserted back if no other write brought the movie back first
+	filter := bson.M{"_id": movie.ID, "version": versionFilter(version)}
+	result, err := collection.ReplaceOne(ctx, filter, newMovieDocument(movie), options.Replace().SetUpsert(existing.Archived))
+	if err != nil {
+		if externalIDConflict(err) {
+			logging.FromContext(ctx, r.logger).Warn("External ID of movie already taken", "id", movie.ID)
+			return nil, domain.ErrExternalIDTaken
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			logging.FromContext(ctx, r.logger).Warn("Movie modified concurrently during update", "id", movie.ID)
+			return nil, domain.ErrVersionMismatch
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to update movie", "movie", movie, "error", err)
+		return nil, storageError("failed to update movie", err)
+	}
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		logging.FromContext(ctx, r.logger).Debug("Movie modified concurrently during update", "id", movie.ID, "version", version)
+		return nil, domain.ErrVersionMismatch
+	}
+
+	if existing.Archived {
+		if _, err := r.database.Collection(archiveCollection).DeleteOne(ctx, bson.M{"_id": movie.ID}); err != nil {
+			logging.FromContext(ctx, r.logger).Warn("Failed to remove archived copy of updated movie", "id", movie.ID, "error", err)
+		}
+	}
+	logging.FromContext(ctx, r.logger).Debug("Successfully updated movie", "id", movie.ID, "version", movie.Version)
+	return movie, nil
+}
+
+func (r *MongoMovieRepository) Delete(ctx context.Context, id int32) error {
+	deleted, err := r.deleteFrom(ctx, moviesCollection, bson.M{"_id": id})
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to delete movie", "id", id, "error", err)
+		return storageError("failed to delete movie", err)
+	}
+
+	if !deleted {
+		deleted, err = r.deleteFrom(ctx, archiveCollection, bson.M{"_id": id})
+		if err != nil {
+			logging.FromContext(ctx, r.logger).Error("Failed to delete archived movie", "id", id, "error", err)
+			return storageError("failed to delete archived movie", err)
+		}
+	}
+
+	if !deleted {
+		logging.FromContext(ctx, r.logger).Debug("Movie not found for deletion", "id", id)
 		return domain.ErrMovieNotFound
 	}
 
-	r.logger.Info("Successfully deleted movie", "id", id)
+	logging.FromContext(ctx, r.logger).Debug("Successfully deleted movie", "id", id)
 	return nil
 }
 
-func (r *MongoMovieRepository) Count(ctx context.Context) (int32, error) {
-	collection := r.database.Collection(moviesCollection)
+// DeleteVersion deletes the movie only if its stored version matches, so concurrent
+// modifications are never silently discarded
+func (r *MongoMovieRepository) DeleteVersion(ctx context.Context, id int32, version int64) error {
+	deleted, err := r.deleteFrom(ctx, moviesCollection, bson.M{"_id": id, "version": versionFilter(version)})
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to delete movie", "id", id, "version", version, "error", err)
+		return storageError("failed to delete movie", err)
+	}
 
-	count, err := collection.CountDocuments(ctx, bson.D{})
+	if !deleted {
+		deleted, err = r.deleteFrom(ctx, archiveCollection, bson.M{"_id": id, "version": versionFilter(version)})
+		if err != nil {
+			logging.FromContext(ctx, r.logger).Error("Failed to delete archived movie", "id", id, "version", version, "error", err)
+			return storageError("failed to delete archived movie", err)
+		}
+	}
+
+	if !deleted {
+		exists, err := r.ExistsByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			logging.FromContext(ctx, r.logger).Debug("Movie not found for deletion", "id", id)
+			return domain.ErrMovieNotFound
+		}
+		logging.FromContext(ctx, r.logger).Debug("Movie version mismatch on deletion", "id", id, "version", version)
+		return domain.ErrVersionMismatch
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully deleted movie", "id", id, "version", version)
+	return nil
+}
+
+func (r *MongoMovieRepository) Count(ctx context.Context, filter domain.MovieFilter) (int32, error) {
+	collection := r.database.Collection(r.readCollection)
+
+	query, err := FilterToBSON(filter)
 	if err != nil {
-		r.logger.Error("Failed to count movies", "error", err)
-		return 0, fmt.Errorf("failed to count movies: %w", err)
+		return 0, err
 	}
 
-	r.logger.Debug("Successfully counted movies", "count", count)
+	count, err := collection.CountDocuments(ctx, query, options.Count().SetCollation(searchCollation))
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to count movies", "error", err)
+		return 0, storageError("failed to count movies", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Successfully counted movies", "count", count)
 	return int32(count), nil
 }
 
@@ -144,18 +355,122 @@ func (r *MongoMovieRepository) ExistsByID(ctx context.Context, id int32) (bool,
 
 	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
 	if err != nil {
-		r.logger.Error("Failed to check movie existence", "id", id, "error", err)
-		return false, fmt.Errorf("failed to check movie existence: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to check movie existence", "id", id, "error", err)
+		return false, storageError("failed to check movie existence", err)
+	}
+
+	if count == 0 {
+		count, err = r.database.Collection(archiveCollection).CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			logging.FromContext(ctx, r.logger).Error("Failed to check archived movie existence", "id", id, "error", err)
+			return false, storageError("failed to check movie existence", err)
+		}
 	}
 
 	exists := count > 0
-	r.logger.Debug("Checked movie existence", "id", id, "exists", exists)
+	logging.FromContext(ctx, r.logger).Debug("Checked movie existence", "id", id, "exists", exists)
 	return exists, nil
 }
 
+// FindVersion returns the version of a movie, reading only that field
+func (r *MongoMovieRepository) FindVersion(ctx context.Context, id int32) (int64, error) {
+	version, _, err := r.storedVersion(ctx, id)
+	return version, err
+}
+
+// storedVersion returns the version of the movie and whether it is archived, reading only
+// that field so the read is not recorded as an access
+func (r *MongoMovieRepository) storedVersion(ctx context.Context, id int32) (int64, bool, error) {
+	var doc struct {
+		Version int64 `bson:"version"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"version": 1})
+
+	archived := false
+	err := r.database.Collection(moviesCollection).FindOne(ctx, bson.M{"_id": id}, opts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		archived = true
+		err = r.database.Collection(archiveCollection).FindOne(ctx, bson.M{"_id": id}, opts).Decode(&doc)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, false, domain.ErrMovieNotFound
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to find movie version", "id", id, "error", err)
+		return 0, false, storageError("failed to find movie version", err)
+	}
+
+	return doc.Version, archived, nil
+}
+
+// deleteFrom deletes the movie matching filter from the collection, reporting whether
+// there was one. Its version is kept in a tombstone, for Upsert to continue from it if
+// the movie is created again
+func (r *MongoMovieRepository) deleteFrom(ctx context.Context, name string, filter bson.M) (bool, error) {
+	var doc struct {
+		ID      int32 `bson:"_id"`
+		Version int64 `bson:"version"`
+	}
+	opts := options.FindOneAndDelete().SetProjection(bson.M{"version": 1})
+	err := r.database.Collection(name).FindOneAndDelete(ctx, filter, opts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	update := bson.M{"$max": bson.M{"version": doc.Version}}
+	if _, err := r.database.Collection(tombstonesCollection).UpdateOne(ctx, bson.M{"_id": doc.ID}, update, options.Update().SetUpsert(true)); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to record version of deleted movie", "id", doc.ID, "version", doc.Version, "error", err)
+	}
+	return true, nil
+}
+
 func (r *MongoMovieRepository) GetNextID(ctx context.Context) (int32, error) {
-	collection := r.database.Collection(moviesCollection)
+	// Archived movies keep their IDs, so both collections are considered
+	var maxID int32
+	for _, name := range []string{moviesCollection, archiveCollection} {
+		id, err := r.maxID(ctx, r.database.Collection(name))
+		if err != nil {
+			return 0, err
+		}
+		if id > maxID {
+			maxID = id
+		}
+	}
+
+	return r.allocateID(ctx, maxID)
+}
 
+// allocateID returns an ID above both floor and every ID allocated before. The counter is
+// incremented atomically, so concurrent creations never get the same ID; floor covers the
+// movies stored with IDs of their own, by upserts or before the counter existed
+func (r *MongoMovieRepository) allocateID(ctx context.Context, floor int32) (int32, error) {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"seq": bson.M{"$add": bson.A{bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$seq", int32(0)}}, floor}}, int32(1)}},
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int32 `bson:"seq"`
+	}
+	err := r.database.Collection(countersCollection).FindOneAndUpdate(ctx, bson.M{"_id": moviesCollection}, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two first allocations both inserted the counter; the document exists now
+		err = r.database.Collection(countersCollection).FindOneAndUpdate(ctx, bson.M{"_id": moviesCollection}, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to allocate movie ID", "error", err)
+		return 0, storageError("failed to allocate movie ID", err)
+	}
+
+	logging.FromContext(ctx, r.logger).Debug("Generated next movie ID", "nextID", counter.Seq)
+	return counter.Seq, nil
+}
+
+// maxID returns the highest movie ID in the collection, or 0 when it is empty
+func (r *MongoMovieRepository) maxID(ctx context.Context, collection *mongo.Collection) (int32, error) {
 	// Find the movie with the highest ID
 	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}})
 	var movie domain.Movie
@@ -163,20 +478,60 @@ func (r *MongoMovieRepository) GetNextID(ctx context.Context) (int32, error) {
 	err := collection.FindOne(ctx, bson.D{}, opts).Decode(&movie)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			// No movies exist, start with ID 1
-			r.logger.Info("No movies found, starting with ID 1")
-			return 1, nil
+			return 0, nil
 		}
-		r.logger.Error("Failed to get max movie ID", "error", err)
-		return 0, fmt.Errorf("failed to get max movie ID: %w", err)
+		logging.FromContext(ctx, r.logger).Error("Failed to get max movie ID", "collection", collection.Name(), "error", err)
+		return 0, storageError("failed to get max movie ID", err)
 	}
 
-	nextID := movie.ID + 1
-	r.logger.Debug("Generated next movie ID", "nextID", nextID)
-	return nextID, nil
+	return movie.ID, nil
+}
+
+// History is unavailable because only the current state of movies is stored
+func (r *MongoMovieRepository) History(ctx context.Context, id int32) ([]domain.MovieRevision, error) {
+	return nil, domain.ErrHistoryUnavailable
 }
 
-// Connect creates a new MongoDB connection
+// versionFilter matches the given version; documents written before versioning
+// have no version field and are treated as version 0
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{nil, int64(0)}}
+	}
+	return version
+}
+
+// storageError wraps a MongoDB error, marking network failures and timeouts with
+// domain.ErrStorageUnavailable so clients know the request can be retried
+func storageError(action string, err error) error {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return fmt.Errorf("%s: %w: %w", action, domain.ErrStorageUnavailable, err)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+// ObjectIDs generates the IDs of the records stored under MongoDB ObjectIDs, such as
+// comments and jobs, which sort by creation time
+type ObjectIDs struct{}
+
+func (ObjectIDs) NewID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// newObjectID returns the ObjectID of a record given its ID, generating one when it has none
+func newObjectID(id string) (primitive.ObjectID, error) {
+	if id == "" {
+		return primitive.NewObjectID(), nil
+	}
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid ID %q: %w", id, err)
+	}
+	return objectID, nil
+}
+
+// Connect creates a new MongoDB connection, waiting for the server to answer a ping until
+// ctx is done
 func Connect(ctx context.Context, connectionString string, logger *slog.Logger) (*mongo.Client, error) {
 	clientOptions := options.Client().
 		ApplyURI(connectionString).
@@ -190,9 +545,16 @@ func Connect(ctx context.Context, connectionString string, logger *slog.Logger)
 	}
 
 	// Ping the database
-	if err := client.Ping(ctx, nil); err != nil {
+	policy := connectRetry
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		logger.Warn("MongoDB not reachable, retrying", "attempt", attempt, "backoff", delay, "error", err)
+	}
+	err = policy.Do(ctx, func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+	if err != nil {
 		logger.Error("Failed to ping MongoDB", "error", err)
-		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
+		return nil, fmt.Errorf("failed to ping MongoDB, check MONGODB_URI and that the server is reachable: %w", err)
 	}
 
 	logger.Info("Successfully connected to MongoDB")
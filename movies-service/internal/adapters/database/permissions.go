@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// requiredActions are the privileges the service needs on its database
+var requiredActions = []string{"find", "insert", "update", "remove", "createIndex"}
+
+// connectionStatus is the part of the connectionStatus command reply listing the
+// privileges of the authenticated user
+type connectionStatus struct {
+	AuthInfo struct {
+		AuthenticatedUsers []bson.M `bson:"authenticatedUsers"`
+		Privileges         []struct {
+			Resource struct {
+				DB          *string `bson:"db"`
+				AnyResource bool    `bson:"anyResource"`
+			} `bson:"resource"`
+			Actions []string `bson:"actions"`
+		} `bson:"authenticatedUserPrivileges"`
+	} `bson:"authInfo"`
+}
+
+// CheckPermissions verifies that the connected user may read, write and index the
+// database, so missing roles fail at startup instead of on the first write. It passes
+// when MongoDB runs without authentication.
+func CheckPermissions(ctx context.Context, client *mongo.Client, databaseName string) error {
+	var status connectionStatus
+	err := client.Database(databaseName).RunCommand(ctx, bson.D{
+		{Key: "connectionStatus", Value: 1},
+		{Key: "showPrivileges", Value: true},
+	}).Decode(&status)
+	if err != nil {
+		return storageError("failed to read MongoDB privileges", err)
+	}
+	if len(status.AuthInfo.AuthenticatedUsers) == 0 {
+		return nil
+	}
+
+	granted := make(map[string]bool)
+	for _, privilege := range status.AuthInfo.Privileges {
+		resource := privilege.Resource
+		// An empty database name grants the actions on every database
+		if resource.AnyResource || (resource.DB != nil && (*resource.DB == "" || *resource.DB == databaseName)) {
+			for _, action := range privilege.Actions {
+				granted[action] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, action := range requiredActions {
+		if !granted[action] {
+			missing = append(missing, action)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("MongoDB user lacks %s on database %s; grant it the readWrite role on %s (MONGODB_URI, DATABASE_NAME)",
+			strings.Join(missing, ", "), databaseName, databaseName)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	readModelCollection   = "movies_read"
+	checkpointsCollection = "projection_checkpoints"
+
+	// readModelOverlap rescans events recorded shortly before the last checkpoint, since
+	// writers stamp events before inserting them
+	readModelOverlap = 5 * time.Second
+)
+
+type projectionCheckpoint struct {
+	Name     string    `bson:"_id"`
+	Position time.Time `bson:"position"`
+}
+
+// ReadModel maintains the movies_read collection, a copy of the current state of every
+// movie built from the event store, so heavy listing traffic does not contend with
+// writes on the movies collection. The copy is eventually consistent: it lags behind
+// writes by up to one catch-up run.
+type ReadModel struct {
+	store  *EventSourcedMovieRepository
+	logger *slog.Logger
+}
+
+func NewReadModel(client *mongo.Client, databaseName string, logger *slog.Logger) *ReadModel {
+	return &ReadModel{
+		store:  newEventSourcedMovieRepository(client, databaseName, EventStoreOptions{}, logger),
+		logger: logger,
+	}
+}
+
+// EnsureReadModel creates the indexes used by queries on the read model
+func EnsureReadModel(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	collection := client.Database(databaseName).Collection(readModelCollection)
+
+	if _, err := collection.Indexes().CreateMany(ctx, queryIndexes()); err != nil {
+		logger.Error("Failed to create read model indexes", "error", err)
+		return fmt.Errorf("failed to create read model indexes: %w", err)
+	}
+	return nil
+}
+
+// Run catches up with the event store, for use as a scheduled job
+func (m *ReadModel) Run(ctx context.Context) error {
+	_, err := m.CatchUp(ctx)
+	return err
+}
+
+// CatchUp projects the movies changed since the previous run, returning how many were
+// projected. The first run rebuilds the whole read model. Each movie is rebuilt from its
+// event stream, so projecting the same change twice or out of order is harmless.
+func (m *ReadModel) CatchUp(ctx context.Context) (int, error) {
+	database := m.store.database
+	checkpoints := database.Collection(checkpointsCollection)
+
+	var checkpoint projectionCheckpoint
+	err := checkpoints.FindOne(ctx, bson.M{"_id": readModelCollection}).Decode(&checkpoint)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, fmt.Errorf("failed to load read model checkpoint: %w", err)
+	}
+
+	position := time.Now().UTC()
+	filter := bson.M{}
+	if !checkpoint.Position.IsZero() {
+		filter["occurred_at"] = bson.M{"$gte": checkpoint.Position.Add(-readModelOverlap)}
+	}
+
+	ids, err := database.Collection(eventsCollection).Distinct(ctx, "movie_id", filter)
+	if err != nil {
+		m.logger.Error("Failed to find changed movies", "error", err)
+		return 0, fmt.Errorf("failed to find changed movies: %w", err)
+	}
+
+	for _, raw := range ids {
+		id, ok := raw.(int32)
+		if !ok {
+			return 0, fmt.Errorf("unexpected movie ID %v in event store", raw)
+		}
+		if err := m.project(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"position": position}}
+	if _, err := checkpoints.UpdateOne(ctx, bson.M{"_id": readModelCollection}, update, options.Update().SetUpsert(true)); err != nil {
+		return 0, fmt.Errorf("failed to save read model checkpoint: %w", err)
+	}
+
+	if len(ids) > 0 {
+		m.logger.Info("Read model caught up", "movies", len(ids), "position", position)
+	}
+	return len(ids), nil
+}
+
+// project copies the current state of the movie into the read model, unless a later
+// state was copied already
+func (m *ReadModel) project(ctx context.Context, id int32) error {
+	movie, head, err := m.store.load(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	collection := m.store.database.Collection(readModelCollection)
+	older := bson.M{"_id": id, eventSequenceField: bson.M{"$lt": head}}
+
+	if movie == nil {
+		if _, err := collection.DeleteOne(ctx, older); err != nil {
+			return fmt.Errorf("failed to delete movie %d from read model: %w", id, err)
+		}
+		return nil
+	}
+
+	doc := projectedMovie{movieDocument: *newMovieDocument(movie), EventSequence: head}
+	_, err = collection.ReplaceOne(ctx, older, doc, options.Replace().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to project movie %d into read model: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// searchTitleField holds the title folded to lower case without diacritics, so substring
+// searches (which MongoDB runs as regexes, ignoring collation) match "amelie" to "Amélie"
+const searchTitleField = "search_title"
+
+// searchCollation compares strings ignoring case and diacritics. Strength 1 only
+// considers base letters.
+var searchCollation = &options.Collation{Locale: "en", Strength: 1}
+
+// movieDocument is the stored form of a movie
+type movieDocument struct {
+	domain.Movie   `bson:",inline"`
+	SearchTitle    string    `bson:"search_title"`
+	LastAccessedAt time.Time `bson:"last_accessed_at"`
+}
+
+func newMovieDocument(movie *domain.Movie) *movieDocument {
+	return &movieDocument{
+		Movie:          *movie,
+		SearchTitle:    normalizeSearchText(movie.Title),
+		LastAccessedAt: time.Now().UTC(),
+	}
+}
+
+// normalizeSearchText lower-cases s and strips its diacritics
+func normalizeSearchText(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
+
+// queryIndexes are the indexes used by listing filters. Indexes on strings compared
+// with the query collation must share it to be used
+func queryIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "title", Value: 1}},
+			Options: options.Index().SetName("title_search").SetCollation(searchCollation),
+		},
+		{
+			Keys:    bson.D{{Key: searchTitleField, Value: 1}},
+			Options: options.Index().SetName(searchTitleField),
+		},
+		{
+			Keys:    bson.D{{Key: "year", Value: 1}},
+			Options: options.Index().SetName("year").SetCollation(searchCollation),
+		},
+		{
+			Keys:    bson.D{{Key: "regions", Value: 1}},
+			Options: options.Index().SetName("regions").SetCollation(searchCollation),
+		},
+		{
+			Keys:    bson.D{{Key: "certification", Value: 1}},
+			Options: options.Index().SetName("certification").SetCollation(searchCollation),
+		},
+	}
+}
+
+// EnsureIndexes creates the indexes used by movie queries and the unique indexes of
+// external IDs, and fills the search title and last access time of movies written before
+// they existed
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	collection := client.Database(databaseName).Collection(moviesCollection)
+
+	indexes := append(queryIndexes(), externalIDIndexes()...)
+	_, err := collection.Indexes().CreateMany(ctx, append(indexes, mongo.IndexModel{
+		Keys:    bson.D{{Key: lastAccessedField, Value: 1}},
+		Options: options.Index().SetName(lastAccessedField),
+	}))
+	if err != nil {
+		logger.Error("Failed to create movie indexes", "error", err)
+		return storageError("failed to create movie indexes", err)
+	}
+	if _, err := client.Database(databaseName).Collection(archiveCollection).Indexes().CreateMany(ctx, externalIDIndexes()); err != nil {
+		logger.Error("Failed to create archive indexes", "error", err)
+		return storageError("failed to create archive indexes", err)
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{searchTitleField: bson.M{"$exists": false}})
+	if err != nil {
+		return storageError("failed to find movies without search title", err)
+	}
+	defer cursor.Close(ctx)
+
+	var updated int
+	for cursor.Next(ctx) {
+		var movie domain.Movie
+		if err := cursor.Decode(&movie); err != nil {
+			return storageError("failed to decode movie", err)
+		}
+		update := bson.M{"$set": bson.M{searchTitleField: normalizeSearchText(movie.Title)}}
+		if _, err := collection.UpdateByID(ctx, movie.ID, update); err != nil {
+			return storageError("failed to set search title", err)
+		}
+		updated++
+	}
+	if err := cursor.Err(); err != nil {
+		return storageError("failed to iterate movies", err)
+	}
+
+	// Movies written before access tracking count as accessed now, so they are not all
+	// archived on the first run
+	touched, err := collection.UpdateMany(ctx,
+		bson.M{lastAccessedField: bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{lastAccessedField: time.Now().UTC()}},
+	)
+	if err != nil {
+		return storageError("failed to set last access time", err)
+	}
+
+	logger.Info("Movie indexes ready", "backfilled_search_titles", updated, "backfilled_access_times", touched.ModifiedCount)
+	return nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const (
+	syncLinksCollection     = "catalog_sync_links"
+	syncConflictsCollection = "catalog_sync_conflicts"
+)
+
+// syncKey identifies a movie of a feed
+type syncKey struct {
+	Source     string `bson:"source"`
+	ExternalID string `bson:"external_id"`
+}
+
+type syncLinkDocument struct {
+	ID           syncKey   `bson:"_id"`
+	MovieID      int32     `bson:"movie_id"`
+	Fingerprint  string    `bson:"fingerprint"`
+	LocalVersion int64     `bson:"local_version"`
+	SyncedAt     time.Time `bson:"synced_at"`
+}
+
+type syncConflictDocument struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Source     string             `bson:"source"`
+	ExternalID string             `bson:"external_id"`
+	MovieID    int32              `bson:"movie_id"`
+	Remote     domain.RemoteMovie `bson:"remote"`
+	DetectedAt time.Time          `bson:"detected_at"`
+}
+
+func (d *syncConflictDocument) toDomain() *domain.SyncConflict {
+	return &domain.SyncConflict{
+		ID:         d.ID.Hex(),
+		MovieID:    d.MovieID,
+		Remote:     d.Remote,
+		DetectedAt: d.DetectedAt,
+	}
+}
+
+// MongoSyncRepository keeps the links of the catalog sync, keyed by source and external
+// ID, and the conflicts queued for a moderator, one per remote movie
+type MongoSyncRepository struct {
+	links     *mongo.Collection
+	conflicts *mongo.Collection
+	logger    *slog.Logger
+}
+
+func NewMongoSyncRepository(client *mongo.Client, databaseName string, logger *slog.Logger) ports.SyncRepository {
+	database := client.Database(databaseName)
+	return &MongoSyncRepository{
+		links:     database.Collection(syncLinksCollection),
+		conflicts: database.Collection(syncConflictsCollection),
+		logger:    logger,
+	}
+}
+
+// EnsureSyncIndexes creates the indexes keeping one queued conflict per remote movie and
+// listing conflicts oldest first
+func EnsureSyncIndexes(ctx context.Context, client *mongo.Client, databaseName string, logger *slog.Logger) error {
+	collection := client.Database(databaseName).Collection(syncConflictsCollection)
+
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "source", Value: 1}, {Key: "external_id", Value: 1}},
+			Options: options.Index().SetName("remote_movie").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "detected_at", Value: 1}},
+			Options: options.Index().SetName("conflict_queue"),
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to create sync indexes", "error", err)
+		return storageError("failed to create sync indexes", err)
+	}
+	return nil
+}
+
+func (r *MongoSyncRepository) FindLink(ctx context.Context, source, externalID string) (*domain.SyncLink, error) {
+	var doc syncLinkDocument
+	err := r.links.FindOne(ctx, bson.M{"_id": syncKey{Source: source, ExternalID: externalID}}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to find sync link", "source", source, "external_id", externalID, "error", err)
+		return nil, storageError("failed to find sync link", err)
+	}
+	return &domain.SyncLink{
+		Source:       doc.ID.Source,
+		ExternalID:   doc.ID.ExternalID,
+		MovieID:      doc.MovieID,
+		Fingerprint:  doc.Fingerprint,
+		LocalVersion: doc.LocalVersion,
+		SyncedAt:     doc.SyncedAt,
+	}, nil
+}
+
+func (r *MongoSyncRepository) SaveLink(ctx context.Context, link domain.SyncLink) error {
+	doc := syncLinkDocument{
+		ID:           syncKey{Source: link.Source, ExternalID: link.ExternalID},
+		MovieID:      link.MovieID,
+		Fingerprint:  link.Fingerprint,
+		LocalVersion: link.LocalVersion,
+		SyncedAt:     link.SyncedAt,
+	}
+	_, err := r.links.ReplaceOne(ctx, bson.M{"_id": doc.ID}, doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to save sync link", "source", link.Source, "external_id", link.ExternalID, "error", err)
+		return storageError("failed to save sync link", err)
+	}
+	return nil
+}
+
+// SaveConflict queues the conflict, replacing the remote movie and detection time of the
+// conflict already queued for the same remote movie while keeping its ID
+func (r *MongoSyncRepository) SaveConflict(ctx context.Context, conflict domain.SyncConflict) error {
+	query := bson.M{"source": conflict.Remote.Source, "external_id": conflict.Remote.ExternalID}
+	update := bson.M{
+		"$set": bson.M{
+			"movie_id":    conflict.MovieID,
+			"remote":      conflict.Remote,
+			"detected_at": conflict.DetectedAt,
+		},
+		"$setOnInsert": bson.M{"_id": primitive.NewObjectID()},
+	}
+	if _, err := r.conflicts.UpdateOne(ctx, query, update, options.Update().SetUpsert(true)); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to queue sync conflict", "movie_id", conflict.MovieID, "error", err)
+		return storageError("failed to queue sync conflict", err)
+	}
+	return nil
+}
+
+func (r *MongoSyncRepository) ListConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	total, err := r.conflicts.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to count sync conflicts", "error", err)
+		return nil, 0, storageError("failed to count sync conflicts", err)
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "detected_at", Value: 1}})
+	cursor, err := r.conflicts.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to list sync conflicts", "error", err)
+		return nil, 0, storageError("failed to list sync conflicts", err)
+	}
+	var docs []syncConflictDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to decode sync conflicts", "error", err)
+		return nil, 0, storageError("failed to decode sync conflicts", err)
+	}
+
+	conflicts := make([]*domain.SyncConflict, len(docs))
+	for i, doc := range docs {
+		conflicts[i] = doc.toDomain()
+	}
+	return conflicts, int32(total), nil
+}
+
+func (r *MongoSyncRepository) FindConflict(ctx context.Context, id string) (*domain.SyncConflict, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrSyncConflictNotFound
+	}
+
+	var doc syncConflictDocument
+	if err := r.conflicts.FindOne(ctx, bson.M{"_id": objectID}).Decode(&doc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, domain.ErrSyncConflictNotFound
+		}
+		logging.FromContext(ctx, r.logger).Error("Failed to find sync conflict", "id", id, "error", err)
+		return nil, storageError("failed to find sync conflict", err)
+	}
+	return doc.toDomain(), nil
+}
+
+func (r *MongoSyncRepository) DeleteConflict(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrSyncConflictNotFound
+	}
+
+	result, err := r.conflicts.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		logging.FromContext(ctx, r.logger).Error("Failed to delete sync conflict", "id", id, "error", err)
+		return storageError("failed to delete sync conflict", err)
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrSyncConflictNotFound
+	}
+	return nil
+}
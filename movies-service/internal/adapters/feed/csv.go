@@ -0,0 +1,166 @@
+// Package feed reads the external catalogs synced into the movie catalog
+package feed
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// csvListSeparator separates the regions, awards and genres within a CSV column
+const csvListSeparator = ";"
+
+// fetchRetry retries the requests of the feeds failing on the network or answering a
+// retryable status; invalid content is not fetched again
+var fetchRetry = retry.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// statusError reports an unexpected answer of a feed, permanent unless the status is
+// retryable
+func statusError(err error, code int) error {
+	if retry.RetryableStatus(code) {
+		return err
+	}
+	return retry.Permanent(err)
+}
+
+// CSV reads a catalog published as a CSV file over HTTP. The header names the columns:
+// id, title and year are required; certification, regions, awards, imdb_id, tmdb_id,
+// genres, director, synopsis and runtime_minutes are optional, with regions, awards and
+// genres separated by semicolons
+type CSV struct {
+	url    string
+	client *http.Client
+}
+
+func NewCSV(url string, timeout time.Duration) *CSV {
+	return &CSV{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (c *CSV) Name() string {
+	return "csv"
+}
+
+func (c *CSV) Fetch(ctx context.Context) ([]domain.RemoteMovie, error) {
+	var movies []domain.RemoteMovie
+	err := fetchRetry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		movies, err = c.fetch(ctx)
+		return err
+	})
+	return movies, err
+}
+
+func (c *CSV) fetch(ctx context.Context) ([]domain.RemoteMovie, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, retry.Permanent(fmt.Errorf("invalid CSV feed request: %w", err))
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("CSV feed unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(fmt.Errorf("CSV feed answered %s", resp.Status), resp.StatusCode)
+	}
+	movies, err := c.parse(resp.Body)
+	if err != nil {
+		return nil, retry.Permanent(err)
+	}
+	return movies, nil
+}
+
+func (c *CSV) parse(r io.Reader) ([]domain.RemoteMovie, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV feed header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"id", "title", "year"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV feed has no %s column", required)
+		}
+	}
+
+	var movies []domain.RemoteMovie
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return movies, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV feed: %w", err)
+		}
+		column := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if column("id") == "" {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("CSV feed line %d has no id", line)
+		}
+		runtime, err := parseRuntime(column("runtime_minutes"))
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("CSV feed line %d: %w", line, err)
+		}
+		movies = append(movies, domain.RemoteMovie{
+			Source:         c.Name(),
+			ExternalID:     column("id"),
+			Title:          column("title"),
+			Year:           column("year"),
+			Certification:  column("certification"),
+			Regions:        splitColumn(column("regions")),
+			Awards:         splitColumn(column("awards")),
+			IMDbID:         column("imdb_id"),
+			TMDbID:         column("tmdb_id"),
+			Genres:         splitColumn(column("genres")),
+			Director:       column("director"),
+			Synopsis:       column("synopsis"),
+			RuntimeMinutes: runtime,
+		})
+	}
+}
+
+func splitColumn(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRuntime reads the runtime_minutes column, 0 when it is empty
+func parseRuntime(value string) (int32, error) {
+	if value == "" {
+		return 0, nil
+	}
+	minutes, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid runtime_minutes %q", value)
+	}
+	return int32(minutes), nil
+}
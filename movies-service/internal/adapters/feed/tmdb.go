@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// maxTMDbResponseBytes bounds each page read from the TMDb API
+const maxTMDbResponseBytes = 1 << 20
+
+type tmdbPage struct {
+	Page       int `json:"page"`
+	TotalPages int `json:"total_pages"`
+	Results    []struct {
+		ID          int64  `json:"id"`
+		Title       string `json:"title"`
+		ReleaseDate string `json:"release_date"`
+		Overview    string `json:"overview"`
+	} `json:"results"`
+}
+
+// TMDb reads the movies of the TMDb discover endpoint, authenticating with an API read
+// access token. Query holds the discover filters, such as primary_release_year=1995;
+// movies without a release date are skipped, since the catalog requires a year
+type TMDb struct {
+	baseURL  string
+	token    string
+	query    url.Values
+	maxPages int
+	client   *http.Client
+}
+
+func NewTMDb(baseURL, token, query string, maxPages int, timeout time.Duration) (*TMDb, error) {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TMDb discover query: %w", err)
+	}
+	return &TMDb{baseURL: baseURL, token: token, query: values, maxPages: maxPages, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (t *TMDb) Name() string {
+	return "tmdb"
+}
+
+func (t *TMDb) Fetch(ctx context.Context) ([]domain.RemoteMovie, error) {
+	var movies []domain.RemoteMovie
+	for page := 1; page <= t.maxPages; page++ {
+		var result *tmdbPage
+		err := fetchRetry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			result, err = t.fetchPage(ctx, page)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range result.Results {
+			if len(movie.ReleaseDate) < 4 {
+				continue
+			}
+			movies = append(movies, domain.RemoteMovie{
+				Source:     t.Name(),
+				ExternalID: strconv.FormatInt(movie.ID, 10),
+				Title:      movie.Title,
+				Year:       movie.ReleaseDate[:4],
+				TMDbID:     strconv.FormatInt(movie.ID, 10),
+				Synopsis:   movie.Overview,
+			})
+		}
+		if page >= result.TotalPages {
+			break
+		}
+	}
+	return movies, nil
+}
+
+func (t *TMDb) fetchPage(ctx context.Context, page int) (*tmdbPage, error) {
+	query := url.Values{}
+	for key, values := range t.query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(page))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.baseURL+"/discover/movie?"+query.Encode(), nil)
+	if err != nil {
+		return nil, retry.Permanent(fmt.Errorf("invalid TMDb request: %w", err))
+	}
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("TMDb unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(fmt.Errorf("TMDb answered %s for page %d", resp.Status, page), resp.StatusCode)
+	}
+
+	var result tmdbPage
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTMDbResponseBytes)).Decode(&result); err != nil {
+		return nil, retry.Permanent(fmt.Errorf("invalid TMDb page %d: %w", page, err))
+	}
+	return &result, nil
+}
@@ -0,0 +1,165 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+type CommentServer struct {
+	pb.UnimplementedCommentServiceServer
+	service ports.CommentService
+	logger  *slog.Logger
+}
+
+func NewCommentServer(service ports.CommentService, logger *slog.Logger) *CommentServer {
+	return &CommentServer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *CommentServer) ListComments(ctx context.Context, req *pb.ListCommentsRequest) (*pb.ListCommentsResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC ListComments called", "movie_id", req.MovieId, "page", req.Page, "limit", req.Limit)
+
+	comments, total, err := s.service.ListComments(ctx, req.MovieId, req.Page, req.Limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list comments", "movie_id", req.MovieId, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.ListCommentsResponse{Comments: toProtoComments(comments), Total: total}, nil
+}
+
+func (s *CommentServer) CreateComment(ctx context.Context, req *pb.CreateCommentRequest) (*pb.CreateCommentResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC CreateComment called", "movie_id", req.MovieId, "parent_id", req.ParentId)
+
+	comment, err := s.service.CreateComment(ctx, domain.CommentInput{
+		MovieID:  req.MovieId,
+		ParentID: req.ParentId,
+		Author:   req.Author,
+		Body:     req.Body,
+	})
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to create comment", "movie_id", req.MovieId, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.CreateCommentResponse{Comment: toProtoComment(comment)}, nil
+}
+
+func (s *CommentServer) FlagComment(ctx context.Context, req *pb.FlagCommentRequest) (*pb.FlagCommentResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC FlagComment called", "id", req.Id)
+
+	if req.Id == "" {
+		return nil, invalidArgument("comment ID is required", "id")
+	}
+
+	comment, err := s.service.FlagComment(ctx, req.Id, req.Reason)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to flag comment", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.FlagCommentResponse{Comment: toProtoComment(comment)}, nil
+}
+
+func (s *CommentServer) ListFlaggedComments(ctx context.Context, req *pb.ListFlaggedCommentsRequest) (*pb.ListFlaggedCommentsResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC ListFlaggedComments called", "page", req.Page, "limit", req.Limit)
+
+	comments, total, err := s.service.ListFlaggedComments(ctx, req.Page, req.Limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list flagged comments", "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.ListFlaggedCommentsResponse{Comments: toProtoComments(comments), Total: total}, nil
+}
+
+func (s *CommentServer) DeleteComment(ctx context.Context, req *pb.DeleteCommentRequest) (*pb.DeleteCommentResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC DeleteComment called", "id", req.Id)
+
+	if req.Id == "" {
+		return nil, invalidArgument("comment ID is required", "id")
+	}
+
+	if err := s.service.DeleteComment(ctx, req.Id); err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to delete comment", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.DeleteCommentResponse{}, nil
+}
+
+func (s *CommentServer) DismissCommentFlags(ctx context.Context, req *pb.DismissCommentFlagsRequest) (*pb.DismissCommentFlagsResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC DismissCommentFlags called", "id", req.Id)
+
+	if req.Id == "" {
+		return nil, invalidArgument("comment ID is required", "id")
+	}
+
+	comment, err := s.service.DismissCommentFlags(ctx, req.Id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to dismiss comment flags", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.DismissCommentFlagsResponse{Comment: toProtoComment(comment)}, nil
+}
+
+func (s *CommentServer) ListModerationQueue(ctx context.Context, req *pb.ListModerationQueueRequest) (*pb.ListModerationQueueResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC ListModerationQueue called", "page", req.Page, "limit", req.Limit)
+
+	comments, total, err := s.service.ListModerationQueue(ctx, req.Page, req.Limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list moderation queue", "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.ListModerationQueueResponse{Comments: toProtoComments(comments), Total: total}, nil
+}
+
+func (s *CommentServer) DecideModeration(ctx context.Context, req *pb.DecideModerationRequest) (*pb.DecideModerationResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC DecideModeration called", "id", req.Id, "decision", req.Decision)
+
+	if req.Id == "" {
+		return nil, invalidArgument("comment ID is required", "id")
+	}
+	decision, err := domain.ParseModerationDecision(req.Decision)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	comment, err := s.service.DecideModeration(ctx, req.Id, decision)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to decide on comment", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.DecideModerationResponse{Comment: toProtoComment(comment)}, nil
+}
+
+func toProtoComment(comment *domain.Comment) *pb.Comment {
+	return &pb.Comment{
+		Id:                comment.ID,
+		MovieId:           comment.MovieID,
+		ParentId:          comment.ParentID,
+		Author:            comment.Author,
+		Body:              comment.Body,
+		CreatedAt:         convert.ToTimestamp(comment.CreatedAt),
+		Deleted:           comment.Deleted(),
+		FlagCount:         comment.FlagCount,
+		FlagReasons:       comment.FlagReasons,
+		Replies:           toProtoComments(comment.Replies),
+		Status:            string(comment.Status),
+		ModerationReasons: comment.ModerationReasons,
+	}
+}
+
+func toProtoComments(comments []*domain.Comment) []*pb.Comment {
+	if len(comments) == 0 {
+		return nil
+	}
+	pbComments := make([]*pb.Comment, len(comments))
+	for i, comment := range comments {
+		pbComments[i] = toProtoComment(comment)
+	}
+	return pbComments
+}
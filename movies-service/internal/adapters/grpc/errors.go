@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/proto/apperr"
+	"github.com/movie-microservice/proto/convert"
+)
+
+// errorDomain is the ErrorInfo domain of the reasons reported by this service
+const errorDomain = "movies-service"
+
+// storageRetryDelay is the delay suggested to clients when the database cannot be
+// reached, about the time the MongoDB driver takes to select a server again
+const storageRetryDelay = 2 * time.Second
+
+// errDeadlineExceeded classifies the calls that did not finish before their deadline
+var errDeadlineExceeded = apperr.New(apperr.Timeout, "DEADLINE_EXCEEDED", "deadline exceeded")
+
+// toStatusError converts service errors into gRPC status errors so clients can tell
+// failures apart by code instead of parsing messages. The code comes from the kind of
+// the most specific classified error and the status carries an ErrorInfo with its
+// reason, a BadRequest naming the rejected field and, when the request can be retried,
+// a RetryInfo with the delay to wait
+func toStatusError(err error) error {
+	known, ok := apperr.Find(err)
+	// A call whose deadline passes while waiting for the database reports the deadline,
+	// not the database
+	if (!ok || known.Kind == apperr.Unavailable) && errors.Is(err, context.DeadlineExceeded) {
+		known, ok = errDeadlineExceeded, true
+	}
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: known.Reason, Domain: errorDomain}}
+	var fieldErr *domain.FieldError
+	if errors.As(err, &fieldErr) {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: fieldErr.Field, Description: fieldErr.Error()}},
+		})
+	}
+	if known.Kind == apperr.Unavailable {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: convert.ToDuration(storageRetryDelay)})
+	}
+	return withDetails(status.New(known.Kind.Code(), err.Error()), details...)
+}
+
+// invalidArgument reports a request rejected before reaching the service, naming each
+// missing or malformed field
+func invalidArgument(message string, fields ...string) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+	for i, field := range fields {
+		violations[i] = &errdetails.BadRequest_FieldViolation{Field: field, Description: message}
+	}
+	return withDetails(status.New(codes.InvalidArgument, message),
+		&errdetails.ErrorInfo{Reason: "INVALID_ARGUMENT", Domain: errorDomain},
+		&errdetails.BadRequest{FieldViolations: violations},
+	)
+}
+
+// withDetails attaches details to st, falling back to the bare status if they cannot be encoded
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+const (
+	// exportBatchSize is the number of movies read from the repository at a time while
+	// streaming an export
+	exportBatchSize = 500
+
+	// ImportedThroughTrailer carries the ID of the last movie imported by ImportMovies,
+	// sent even when the import fails so it can be resumed after that movie
+	ImportedThroughTrailer = "imported-through-id"
+)
+
+// ExportMovies streams the catalog in ID order, reading it in batches so exports of any
+// size use constant memory
+func (s *MovieServer) ExportMovies(req *pb.ExportMoviesRequest, stream pb.MovieService_ExportMoviesServer) error {
+	logging.FromContext(stream.Context(), s.logger).Debug("gRPC ExportMovies called", "after_id", req.AfterId)
+
+	if req.AfterId < 0 {
+		return invalidArgument("invalid movie ID", "after_id")
+	}
+
+	after, exported := req.AfterId, 0
+	for {
+		movies, err := s.service.ExportMovies(stream.Context(), after, exportBatchSize)
+		if err != nil {
+			logging.FromContext(stream.Context(), s.logger).Error("Failed to export movies", "after_id", after, "error", err)
+			return toStatusError(err)
+		}
+
+		for _, movie := range movies {
+			if err := stream.Send(&pb.ExportMoviesResponse{Movie: toProtoMovie(movie)}); err != nil {
+				return err
+			}
+			after = movie.ID
+		}
+		exported += len(movies)
+
+		if len(movies) < exportBatchSize {
+			logging.FromContext(stream.Context(), s.logger).Info("Exported movies via gRPC", "after_id", req.AfterId, "count", exported)
+			return nil
+		}
+	}
+}
+
+// ImportMovies upserts each streamed movie under its own ID. Movies are imported as
+// current movies: archived ones return to the hot collection and versions restart from
+// the version the repository assigns
+func (s *MovieServer) ImportMovies(stream pb.MovieService_ImportMoviesServer) error {
+	logging.FromContext(stream.Context(), s.logger).Debug("gRPC ImportMovies called")
+
+	var imported, lastID int32
+	defer func() {
+		stream.SetTrailer(metadata.Pairs(ImportedThroughTrailer, strconv.Itoa(int(lastID))))
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			logging.FromContext(stream.Context(), s.logger).Info("Imported movies via gRPC", "count", imported, "last_id", lastID)
+			return stream.SendAndClose(&pb.ImportMoviesResponse{Imported: imported, LastId: lastID})
+		}
+		if err != nil {
+			return err
+		}
+
+		movie := convert.FromProtoMovie(req.GetMovie())
+		if movie.ID <= 0 {
+			return invalidArgument("invalid movie ID", "movie.id")
+		}
+		if missing := missingFields(movie.Title, movie.Year); len(missing) > 0 {
+			return invalidArgument("title and year are required", missing...)
+		}
+
+		input := domain.MovieInput{
+			Title:          movie.Title,
+			Year:           movie.Year,
+			Regions:        movie.Regions,
+			Awards:         movie.Awards,
+			Certification:  movie.Certification,
+			Genres:         movie.Genres,
+			Director:       movie.Director,
+			Synopsis:       movie.Synopsis,
+			RuntimeMinutes: movie.RuntimeMinutes,
+		}
+		if _, _, err := s.service.UpsertMovie(stream.Context(), movie.ID, input); err != nil {
+			logging.FromContext(stream.Context(), s.logger).Error("Failed to import movie", "id", movie.ID, "imported", imported, "error", err)
+			return toStatusError(err)
+		}
+		imported++
+		lastID = movie.ID
+	}
+}
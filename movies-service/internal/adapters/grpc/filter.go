@@ -0,0 +1,76 @@
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+const maxFilterDepth = 10
+
+var domainFilterOperators = map[pb.FilterOperator]domain.FilterOperator{
+	pb.FilterOperator_FILTER_OPERATOR_EQ:       domain.OpEq,
+	pb.FilterOperator_FILTER_OPERATOR_NE:       domain.OpNe,
+	pb.FilterOperator_FILTER_OPERATOR_GT:       domain.OpGt,
+	pb.FilterOperator_FILTER_OPERATOR_GTE:      domain.OpGte,
+	pb.FilterOperator_FILTER_OPERATOR_LT:       domain.OpLt,
+	pb.FilterOperator_FILTER_OPERATOR_LTE:      domain.OpLte,
+	pb.FilterOperator_FILTER_OPERATOR_CONTAINS: domain.OpContains,
+}
+
+// toDomainFilter converts a protobuf filter tree into a domain filter expression
+func toDomainFilter(filter *pb.Filter) (*domain.FilterExpr, error) {
+	return toDomainFilterNode(filter, 0)
+}
+
+func toDomainFilterNode(filter *pb.Filter, depth int) (*domain.FilterExpr, error) {
+	if depth > maxFilterDepth {
+		return nil, fmt.Errorf("%w: expression nested deeper than %d levels", domain.ErrInvalidFilter, maxFilterDepth)
+	}
+
+	switch node := filter.GetNode().(type) {
+	case *pb.Filter_Condition:
+		op, ok := domainFilterOperators[node.Condition.GetOperator()]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown operator %v", domain.ErrInvalidFilter, node.Condition.GetOperator())
+		}
+		return &domain.FilterExpr{Condition: &domain.FilterCondition{
+			Field: node.Condition.GetField(),
+			Op:    op,
+			Value: node.Condition.GetValue(),
+		}}, nil
+
+	case *pb.Filter_And:
+		exprs, err := toDomainFilterGroup(node.And, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.FilterExpr{And: exprs}, nil
+
+	case *pb.Filter_Or:
+		exprs, err := toDomainFilterGroup(node.Or, depth)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.FilterExpr{Or: exprs}, nil
+	}
+
+	return nil, fmt.Errorf("%w: empty expression", domain.ErrInvalidFilter)
+}
+
+func toDomainFilterGroup(group *pb.FilterGroup, depth int) ([]*domain.FilterExpr, error) {
+	if len(group.GetFilters()) == 0 {
+		return nil, fmt.Errorf("%w: empty group", domain.ErrInvalidFilter)
+	}
+
+	exprs := make([]*domain.FilterExpr, len(group.GetFilters()))
+	for i, filter := range group.GetFilters() {
+		expr, err := toDomainFilterNode(filter, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+	return exprs, nil
+}
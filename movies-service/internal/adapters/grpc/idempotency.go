@@ -0,0 +1,166 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/movie-microservice/movies-service/internal/idempotency"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pbv1 "github.com/movie-microservice/proto/movies/v1"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+const (
+	// IdempotencyKeyHeader is the metadata key carrying the client-supplied idempotency key
+	IdempotencyKeyHeader = "idempotency-key"
+	// IdempotentReplayedHeader is set on responses replayed from a previous call
+	IdempotentReplayedHeader = "idempotent-replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyLease is how long a key is held while its call runs. A replica that dies
+	// mid-call leaves the key reserved, so its retries are refused only until the lease ends
+	idempotencyLease = 30 * time.Second
+)
+
+// idempotentMethods are the calls whose retries are deduplicated; the other calls are
+// idempotent already
+var idempotentMethods = map[string]bool{
+	pb.MovieService_CreateMovie_FullMethodName:     true,
+	pbv1.MovieService_CreateMovie_FullMethodName:   true,
+	pb.CommentService_CreateComment_FullMethodName: true,
+	pb.ImportService_StartImport_FullMethodName:    true,
+	pb.JobService_SubmitJob_FullMethodName:         true,
+}
+
+// IdempotencyInterceptor deduplicates CreateMovie, CreateComment, StartImport and SubmitJob calls sent with an idempotency key:
+// the first successful result is stored for ttl and returned to retries of the same
+// request. Failed calls are not stored, so they can be retried with the same key, nor are
+// results that cannot be stored, whose keys are released instead.
+func IdempotencyInterceptor(store idempotency.Store, ttl time.Duration, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !idempotentMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		key := metadata.ValueFromIncomingContext(ctx, IdempotencyKeyHeader)
+		if len(key) == 0 || key[0] == "" {
+			return handler(ctx, req)
+		}
+		if len(key[0]) > maxIdempotencyKeyLength {
+			return nil, invalidArgument("idempotency key is too long", IdempotencyKeyHeader)
+		}
+
+		message, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		fingerprint, err := requestFingerprint(message)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to fingerprint request: %v", err)
+		}
+
+		// Keys are scoped to the method, since v1 and v2 calls have different responses
+		storeKey := info.FullMethod + " " + key[0]
+		logger := logging.FromContext(ctx, logger.With("method", info.FullMethod))
+		record, reserved, err := store.Reserve(ctx, storeKey, fingerprint, min(idempotencyLease, ttl))
+		if err != nil {
+			logger.Error("Failed to reserve idempotency key", "error", err)
+			return nil, withDetails(status.New(codes.Unavailable, "idempotency keys are unavailable"),
+				&errdetails.ErrorInfo{Reason: "STORAGE_UNAVAILABLE", Domain: errorDomain},
+				&errdetails.RetryInfo{RetryDelay: convert.ToDuration(storageRetryDelay)},
+			)
+		}
+		if !reserved {
+			return replay(ctx, record, fingerprint, logger)
+		}
+
+		resp, err := handler(ctx, req)
+
+		// The outcome is recorded even if the client went away, since it will retry
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		release := func() {
+			if releaseErr := store.Release(storeCtx, storeKey); releaseErr != nil {
+				logger.Error("Failed to release idempotency key", "error", releaseErr)
+			}
+		}
+		if err != nil {
+			release()
+			return resp, err
+		}
+		if stored, encodeErr := encodeResponse(resp); encodeErr != nil {
+			logger.Error("Failed to encode idempotent response", "error", encodeErr)
+			release()
+		} else if completeErr := store.Complete(storeCtx, storeKey, stored, ttl); completeErr != nil {
+			logger.Error("Failed to store idempotent response", "error", completeErr)
+			release()
+		}
+		return resp, nil
+	}
+}
+
+// replay returns the stored response of a key already used, rejecting reuse of the key
+// for another request and retries arriving while the first call still runs
+func replay(ctx context.Context, record idempotency.Record, fingerprint string, logger *slog.Logger) (interface{}, error) {
+	if record.Fingerprint != fingerprint {
+		return nil, withDetails(status.New(codes.InvalidArgument, "idempotency key was already used with a different request"),
+			&errdetails.ErrorInfo{Reason: "IDEMPOTENCY_KEY_REUSED", Domain: errorDomain},
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       IdempotencyKeyHeader,
+				Description: "idempotency key was already used with a different request",
+			}}},
+		)
+	}
+	if !record.Done() {
+		return nil, withDetails(status.New(codes.Aborted, "a request with the same idempotency key is in progress"),
+			&errdetails.ErrorInfo{Reason: "IDEMPOTENCY_KEY_IN_USE", Domain: errorDomain},
+		)
+	}
+
+	stored := &anypb.Any{}
+	if err := proto.Unmarshal(record.Response, stored); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+	}
+	resp, err := stored.UnmarshalNew()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decode stored response: %v", err)
+	}
+
+	grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedHeader, "true"))
+	logger.Info("Replayed idempotent response")
+	return resp, nil
+}
+
+// requestFingerprint hashes the encoded request, so a key reused for different data is detected
+func requestFingerprint(req proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// encodeResponse wraps the response in an Any so its type is known when it is replayed
+func encodeResponse(resp interface{}) ([]byte, error) {
+	message, ok := resp.(proto.Message)
+	if !ok {
+		return nil, status.Error(codes.Internal, "response is not a protobuf message")
+	}
+	wrapped, err := anypb.New(message)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(wrapped)
+}
@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+type ImportServer struct {
+	pb.UnimplementedImportServiceServer
+	service ports.ImportService
+	logger  *slog.Logger
+}
+
+func NewImportServer(service ports.ImportService, logger *slog.Logger) *ImportServer {
+	return &ImportServer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// StartImport leaves the validation of the rows to the import, which reports each
+// invalid row instead of rejecting the call
+func (s *ImportServer) StartImport(ctx context.Context, req *pb.StartImportRequest) (*pb.StartImportResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC StartImport called", "rows", len(req.Rows))
+
+	rows := make([]domain.ImportRow, len(req.Rows))
+	for i, row := range req.Rows {
+		rows[i] = domain.ImportRow{ID: row.Id, Input: domain.MovieInput(convert.FromProtoMovieInput(row.Movie))}
+	}
+
+	imp, err := s.service.StartImport(ctx, rows)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to start import", "rows", len(rows), "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.StartImportResponse{MovieImport: toProtoImport(imp)}, nil
+}
+
+func (s *ImportServer) GetImport(ctx context.Context, req *pb.GetImportRequest) (*pb.GetImportResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetImport called", "id", req.Id)
+
+	if req.Id == "" {
+		return nil, invalidArgument("import ID is required", "id")
+	}
+
+	imp, err := s.service.GetImport(ctx, req.Id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get import", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.GetImportResponse{MovieImport: toProtoImport(imp)}, nil
+}
+
+func toProtoImport(imp *domain.Import) *pb.MovieImport {
+	results := make([]*pb.ImportRowResult, len(imp.Results))
+	for i, result := range imp.Results {
+		results[i] = &pb.ImportRowResult{
+			Row:     result.Row,
+			Status:  string(result.Status),
+			MovieId: result.MovieID,
+			Field:   result.Field,
+			Error:   result.Error,
+		}
+	}
+	return &pb.MovieImport{
+		Id:         imp.ID,
+		Status:     string(imp.Status),
+		Total:      imp.Total,
+		Processed:  int32(len(imp.Results)),
+		Created:    imp.Count(domain.RowCreated),
+		Updated:    imp.Count(domain.RowUpdated),
+		Invalid:    imp.Count(domain.RowInvalid),
+		Failed:     imp.Count(domain.RowFailed),
+		Results:    results,
+		Error:      imp.Error,
+		CreatedAt:  convert.ToTimestamp(imp.CreatedAt),
+		FinishedAt: convert.ToTimestamp(imp.FinishedAt),
+	}
+}
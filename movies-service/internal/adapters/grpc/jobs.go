@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// jobFileChunkSize is the size of the chunks of job files sent by DownloadJobFile
+const jobFileChunkSize = 64 << 10
+
+type JobServer struct {
+	pb.UnimplementedJobServiceServer
+	service ports.JobService
+	logger  *slog.Logger
+}
+
+func NewJobServer(service ports.JobService, logger *slog.Logger) *JobServer {
+	return &JobServer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *JobServer) SubmitJob(ctx context.Context, req *pb.SubmitJobRequest) (*pb.SubmitJobResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC SubmitJob called", "type", req.Type, "priority", req.Priority)
+
+	if req.Type == "" {
+		return nil, invalidArgument("job type is required", "type")
+	}
+	var params json.RawMessage
+	if req.ParamsJson != "" {
+		if !json.Valid([]byte(req.ParamsJson)) {
+			return nil, invalidArgument("job parameters must be JSON", "params")
+		}
+		params = json.RawMessage(req.ParamsJson)
+	}
+
+	job, err := s.service.SubmitJob(ctx, domain.JobType(req.Type), domain.JobPriority(req.Priority), params)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to submit job", "type", req.Type, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.SubmitJobResponse{Job: toProtoJob(job)}, nil
+}
+
+func (s *JobServer) GetJob(ctx context.Context, req *pb.GetJobRequest) (*pb.GetJobResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetJob called", "id", req.Id)
+
+	if req.Id == "" {
+		return nil, invalidArgument("job ID is required", "id")
+	}
+
+	job, err := s.service.GetJob(ctx, req.Id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get job", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	return &pb.GetJobResponse{Job: toProtoJob(job)}, nil
+}
+
+func (s *JobServer) DownloadJobFile(req *pb.DownloadJobFileRequest, stream pb.JobService_DownloadJobFileServer) error {
+	logging.FromContext(stream.Context(), s.logger).Debug("gRPC DownloadJobFile called", "id", req.Id, "offset", req.Offset)
+
+	if req.Id == "" {
+		return invalidArgument("job ID is required", "id")
+	}
+
+	file, err := s.service.OpenJobFile(stream.Context(), req.Id, req.Offset)
+	if err != nil {
+		logging.FromContext(stream.Context(), s.logger).Error("Failed to open job file", "id", req.Id, "error", err)
+		return toStatusError(err)
+	}
+	defer file.Close()
+
+	// The first message carries the size of the file, even without a chunk
+	buf := make([]byte, jobFileChunkSize)
+	for sent := false; ; {
+		n, err := file.Read(buf)
+		if n > 0 || !sent {
+			resp := &pb.DownloadJobFileResponse{Chunk: buf[:n]}
+			if !sent {
+				resp.Size = file.Size
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			sent = true
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			logging.FromContext(stream.Context(), s.logger).Error("Failed to read job file", "id", req.Id, "error", err)
+			return toStatusError(err)
+		}
+	}
+}
+
+func toProtoJob(job *domain.Job) *pb.Job {
+	return &pb.Job{
+		Id:         job.ID,
+		Type:       string(job.Type),
+		Status:     string(job.Status),
+		Priority:   string(job.Priority),
+		Done:       job.Done,
+		Total:      job.Total,
+		ResultJson: string(job.Result),
+		File:       job.File,
+		Error:      job.Error,
+		CreatedAt:  convert.ToTimestamp(job.CreatedAt),
+		StartedAt:  convert.ToTimestamp(job.StartedAt),
+		FinishedAt: convert.ToTimestamp(job.FinishedAt),
+	}
+}
@@ -0,0 +1,220 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/movies-service/internal/leakcheck"
+	"github.com/movie-microservice/proto/drain"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type methodCode struct {
+	method string
+	code   string
+}
+
+// exemplar links a bucket to the last sampled trace observed in it
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+type durationHistogram struct {
+	counts    []uint64 // one count per bucket, the last one for +Inf, not cumulative
+	exemplars []exemplar
+	sum       float64
+	total     uint64
+}
+
+// Metrics counts gRPC requests by method and status code and records their durations,
+// written in the Prometheus text format or in the OpenMetrics format. Each duration
+// bucket keeps the trace ID of its last sampled call as an exemplar, so a latency spike
+// can be followed to an example trace. The calls in flight, the streams among them and
+// the outcome of the shutdown drain are reported too
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[methodCode]uint64
+	durations map[string]*durationHistogram
+	started   time.Time
+	drain     *drain.Tracker
+	streams   atomic.Int64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[methodCode]uint64),
+		durations: make(map[string]*durationHistogram),
+		started:   time.Now(),
+		drain:     drain.NewTracker("movies_grpc"),
+	}
+}
+
+// Drain returns the tracker of the calls in flight, which drains them on shutdown
+func (m *Metrics) Drain() *drain.Tracker {
+	return m.drain
+}
+
+// InFlightInterceptor counts the unary calls being served; chain it first, so calls
+// refused by later interceptors are counted too
+func (m *Metrics) InFlightInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		defer m.drain.Begin()()
+		return handler(ctx, req)
+	}
+}
+
+// InFlightStreamInterceptor counts the streaming calls being served
+func (m *Metrics) InFlightStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		defer m.drain.Begin()()
+		m.streams.Add(1)
+		defer m.streams.Add(-1)
+		return handler(srv, stream)
+	}
+}
+
+// ActiveStreams returns the number of streaming calls being served
+func (m *Metrics) ActiveStreams() int64 {
+	return m.streams.Load()
+}
+
+// UnaryInterceptor records every unary call served
+func (m *Metrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		traceID, sampled := TraceID(ctx)
+		if !sampled {
+			traceID = ""
+		}
+		m.observe(info.FullMethod, status.Code(err).String(), traceID, time.Since(start))
+		return resp, err
+	}
+}
+
+func (m *Metrics) observe(method, code, traceID string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[methodCode{method: method, code: code}]++
+
+	histogram, ok := m.durations[method]
+	if !ok {
+		histogram = &durationHistogram{
+			counts:    make([]uint64, len(durationBuckets)+1),
+			exemplars: make([]exemplar, len(durationBuckets)+1),
+		}
+		m.durations[method] = histogram
+	}
+	seconds := duration.Seconds()
+	bucket := sort.SearchFloat64s(durationBuckets, seconds)
+	histogram.counts[bucket]++
+	if traceID != "" {
+		histogram.exemplars[bucket] = exemplar{traceID: traceID, value: seconds, at: time.Now()}
+	}
+	histogram.sum += seconds
+	histogram.total++
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	m.write(w, false)
+}
+
+// WriteOpenMetrics writes the metrics in the OpenMetrics format, with the exemplars of
+// each duration bucket
+func (m *Metrics) WriteOpenMetrics(w io.Writer) {
+	m.write(w, true)
+}
+
+func (m *Metrics) write(w io.Writer, openMetrics bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// OpenMetrics names counter families without the _total suffix of their samples
+	family := "movies_grpc_requests_total"
+	if openMetrics {
+		family = "movies_grpc_requests"
+	}
+	fmt.Fprintf(w, "# HELP %s gRPC requests served, by method and status code.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	keys := make([]methodCode, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		fmt.Fprintf(w, "movies_grpc_requests_total{method=%q,code=%q} %d\n", key.method, key.code, m.requests[key])
+	}
+
+	fmt.Fprintln(w, "# HELP movies_grpc_request_duration_seconds Time spent serving gRPC requests, by method.")
+	fmt.Fprintln(w, "# TYPE movies_grpc_request_duration_seconds histogram")
+	methods := make([]string, 0, len(m.durations))
+	for method := range m.durations {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		histogram := m.durations[method]
+		var cumulative uint64
+		for i := range histogram.counts {
+			cumulative += histogram.counts[i]
+			le := "+Inf"
+			if i < len(durationBuckets) {
+				le = strconv.FormatFloat(durationBuckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(w, "movies_grpc_request_duration_seconds_bucket{method=%q,le=%q} %d", method, le, cumulative)
+			if ex := histogram.exemplars[i]; openMetrics && ex.traceID != "" {
+				fmt.Fprintf(w, " # {trace_id=%q} %g %.3f", ex.traceID, ex.value, float64(ex.at.UnixMilli())/1000)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "movies_grpc_request_duration_seconds_sum{method=%q} %g\n", method, histogram.sum)
+		fmt.Fprintf(w, "movies_grpc_request_duration_seconds_count{method=%q} %d\n", method, histogram.total)
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	fmt.Fprintln(w, "# HELP go_goroutines Number of goroutines that currently exist.")
+	fmt.Fprintln(w, "# TYPE go_goroutines gauge")
+	fmt.Fprintf(w, "go_goroutines %d\n", runtime.NumGoroutine())
+	if openFiles := leakcheck.OpenFiles(); openFiles >= 0 {
+		fmt.Fprintln(w, "# HELP process_open_fds Number of open file descriptors.")
+		fmt.Fprintln(w, "# TYPE process_open_fds gauge")
+		fmt.Fprintf(w, "process_open_fds %d\n", openFiles)
+	}
+	fmt.Fprintln(w, "# HELP movies_grpc_active_streams Streaming gRPC calls being served.")
+	fmt.Fprintln(w, "# TYPE movies_grpc_active_streams gauge")
+	fmt.Fprintf(w, "movies_grpc_active_streams %d\n", m.streams.Load())
+	fmt.Fprintln(w, "# HELP go_memstats_heap_alloc_bytes Number of heap bytes allocated and still in use.")
+	fmt.Fprintln(w, "# TYPE go_memstats_heap_alloc_bytes gauge")
+	fmt.Fprintf(w, "go_memstats_heap_alloc_bytes %d\n", mem.HeapAlloc)
+	fmt.Fprintln(w, "# HELP process_start_time_seconds Start time of the process since unix epoch in seconds.")
+	fmt.Fprintln(w, "# TYPE process_start_time_seconds gauge")
+	fmt.Fprintf(w, "process_start_time_seconds %d\n", m.started.Unix())
+
+	m.drain.Write(w, openMetrics)
+
+	if openMetrics {
+		fmt.Fprintln(w, "# EOF")
+	}
+}
@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+const redactedValue = "[REDACTED]"
+
+// PayloadLogging configures the payload logging interceptor
+type PayloadLogging struct {
+	// RedactFields are the field names, in any case, whose values are never logged
+	RedactFields []string
+	// MaxBytes truncates each logged payload; zero logs payloads whole
+	MaxBytes int
+}
+
+// PayloadLoggingInterceptor logs the request and response messages of unary calls at debug
+// level, redacting the configured fields at any depth
+func PayloadLoggingInterceptor(opts PayloadLogging, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	redact := make(map[string]bool, len(opts.RedactFields))
+	for _, field := range opts.RedactFields {
+		redact[strings.ToLower(field)] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !logger.Enabled(ctx, slog.LevelDebug) {
+			return handler(ctx, req)
+		}
+
+		logger := logging.FromContext(ctx, logger.With("method", info.FullMethod))
+		logger.DebugContext(ctx, "gRPC request payload", "payload", formatPayload(req, redact, opts.MaxBytes))
+		resp, err := handler(ctx, req)
+		if err == nil {
+			logger.DebugContext(ctx, "gRPC response payload", "payload", formatPayload(resp, redact, opts.MaxBytes))
+		}
+		return resp, err
+	}
+}
+
+// formatPayload renders a message as JSON with the redacted fields masked
+func formatPayload(msg interface{}, redact map[string]bool, maxBytes int) string {
+	message, ok := msg.(proto.Message)
+	if !ok {
+		return "<not a protobuf message>"
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+	if err != nil {
+		return "<unprintable payload>"
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "<unprintable payload>"
+	}
+	data, _ = json.Marshal(redactFields(value, redact))
+
+	if maxBytes > 0 && len(data) > maxBytes {
+		return string(data[:maxBytes]) + "...(truncated)"
+	}
+	return string(data)
+}
+
+func redactFields(value interface{}, redact map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redact[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactFields(field, redact)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactFields(item, redact)
+		}
+	}
+	return value
+}
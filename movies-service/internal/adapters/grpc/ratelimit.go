@@ -0,0 +1,118 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/convert"
+)
+
+// RetryPushbackHeader is the trailer telling gRPC clients with a retry policy how many
+// milliseconds to wait before retrying
+const RetryPushbackHeader = "grpc-retry-pushback-ms"
+
+// maxRateLimitPeers bounds the number of tracked peers; idle peers are dropped once it is reached
+const maxRateLimitPeers = 10000
+
+// RateLimiter is a token bucket per peer address
+type RateLimiter struct {
+	rate    float64
+	burst   float64
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	clock   clock.Clock
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter refilling the buckets by clk
+func NewRateLimiter(requestsPerSecond float64, burst int, clk clock.Clock) *RateLimiter {
+	return &RateLimiter{
+		rate:    requestsPerSecond,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		clock:   clk,
+	}
+}
+
+// Take takes a token from the bucket of the peer, returning zero when one was available
+// or the time until the next one otherwise
+func (l *RateLimiter) Take(client string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	b, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitPeers {
+			l.prune(now)
+		}
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+}
+
+// prune drops the buckets that refilled completely, which behave like new ones
+func (l *RateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// UnaryInterceptor refuses calls of peers that ran out of tokens with ResourceExhausted,
+// a RetryInfo and the retry pushback trailer
+func (l *RateLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		wait := l.Take(peerHost(ctx))
+		if wait == 0 {
+			return handler(ctx, req)
+		}
+
+		grpc.SetTrailer(ctx, metadata.Pairs(RetryPushbackHeader, strconv.FormatInt(max(wait.Milliseconds(), 1), 10)))
+		return nil, withDetails(status.New(codes.ResourceExhausted, "rate limit exceeded"),
+			&errdetails.ErrorInfo{Reason: "RATE_LIMITED", Domain: errorDomain},
+			&errdetails.RetryInfo{RetryDelay: convert.ToDuration(wait)},
+		)
+	}
+}
+
+// peerHost identifies the caller by IP address, so its connections share a bucket
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
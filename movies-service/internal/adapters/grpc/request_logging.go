@@ -0,0 +1,94 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+// writePrefixes start the names of the methods that change data; other methods are reads
+var writePrefixes = []string{"Create", "Upsert", "Update", "Patch", "Delete"}
+
+// RequestLoggingInterceptor writes the canonical log line of each unary call: every
+// failed, slow or writing call and the sample of successful reads chosen by the policy.
+// Calls failing because of the client are warnings and other failures are errors. It
+// runs first, giving the call a request logger with its method and trace ID, which the
+// interceptors, services and repositories read with logging.FromContext
+func RequestLoggingInterceptor(policy logging.Policy, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		traceID, _ := TraceID(ctx)
+		logger := logger.With("method", info.FullMethod, "trace_id", traceID)
+		resp, err := handler(logging.NewContext(ctx, logger), req)
+		duration := time.Since(start)
+
+		decision := policy.Decide(isRead(info.FullMethod), err != nil, duration)
+		if !decision.Log {
+			return resp, err
+		}
+
+		code := status.Code(err)
+		attrs := []slog.Attr{
+			slog.String("code", code.String()),
+			slog.Duration("duration", duration),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		if decision.Sampled {
+			attrs = append(attrs, slog.Float64("sample_rate", policy.SampleRate))
+		}
+		logger.LogAttrs(ctx, callLevel(code), "gRPC request", attrs...)
+		return resp, err
+	}
+}
+
+// StreamLoggerInterceptor gives each streaming call a request logger with its method and
+// trace ID, read from the context of the stream
+func StreamLoggerInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		traceID, _ := TraceID(stream.Context())
+		logger := logger.With("method", info.FullMethod, "trace_id", traceID)
+		return handler(srv, &loggedStream{ServerStream: stream, ctx: logging.NewContext(stream.Context(), logger)})
+	}
+}
+
+// loggedStream is a server stream whose context carries the request logger
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+func isRead(fullMethod string) bool {
+	method := path.Base(fullMethod)
+	for _, prefix := range writePrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// callLevel logs failures caused by the service, or that it cannot tell apart, as errors
+func callLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.DeadlineExceeded, codes.Unimplemented:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
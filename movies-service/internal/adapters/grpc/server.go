@@ -2,12 +2,14 @@ package grpc
 
 import (
 	"context"
-	
+	"errors"
 	"log/slog"
 
-	pb "github.com/movie-microservice/proto/movies"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
 	"github.com/movie-microservice/movies-service/internal/core/domain"
 	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
 )
 
 type MovieServer struct {
@@ -24,140 +26,311 @@ func NewMovieServer(service ports.MovieService, logger *slog.Logger) *MovieServe
 }
 
 func (s *MovieServer) GetMovies(ctx context.Context, req *pb.GetMoviesRequest) (*pb.GetMoviesResponse, error) {
-	s.logger.Info("gRPC GetMovies called", "page", req.Page, "limit", req.Limit)
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetMovies called", "page", req.Page, "limit", req.Limit)
 
 	filter := domain.MovieFilter{
-		Page:  req.Page,
-		Limit: req.Limit,
+		Page:          req.Page,
+		Limit:         req.Limit,
+		Region:        req.Region,
+		Certification: req.Certification,
+		SkipCount:     req.SkipCount,
+	}
+
+	if req.Filter != nil {
+		expr, err := toDomainFilter(req.Filter)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Warn("Invalid filter", "error", err)
+			return nil, toStatusError(err)
+		}
+		filter.Expr = expr
 	}
 
 	movies, total, err := s.service.GetMovies(ctx, filter)
 	if err != nil {
-		s.logger.Error("Failed to get movies", "error", err)
-		return &pb.GetMoviesResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		logging.FromContext(ctx, s.logger).Error("Failed to get movies", "error", err)
+		return nil, toStatusError(err)
 	}
 
 	// Convert domain movies to protobuf movies
 	pbMovies := make([]*pb.Movie, len(movies))
 	for i, movie := range movies {
-		pbMovies[i] = &pb.Movie{
-			Id:    movie.ID,
-			Title: movie.Title,
-			Year:  movie.Year,
-		}
+		pbMovies[i] = toProtoMovie(movie)
 	}
 
-	s.logger.Info("Successfully retrieved movies via gRPC", "count", len(movies))
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movies via gRPC", "count", len(movies))
 	return &pb.GetMoviesResponse{
-		Movies:  pbMovies,
-		Total:   total,
-		Success: true,
+		Movies: pbMovies,
+		Total:  total,
 	}, nil
 }
 
 func (s *MovieServer) GetMovie(ctx context.Context, req *pb.GetMovieRequest) (*pb.GetMovieResponse, error) {
-	s.logger.Info("gRPC GetMovie called", "id", req.Id)
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetMovie called", "id", req.Id)
 
 	if req.Id <= 0 {
-		s.logger.Warn("Invalid movie ID", "id", req.Id)
-		return &pb.GetMovieResponse{
-			Success: false,
-			Error:   "invalid movie ID",
-		}, nil
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie ID", "id", req.Id)
+		return nil, invalidArgument("invalid movie ID", "id")
 	}
 
 	movie, err := s.service.GetMovie(ctx, req.Id)
 	if err != nil {
-		s.logger.Error("Failed to get movie", "id", req.Id, "error", err)
-		
-		if err == domain.ErrMovieNotFound {
-			return &pb.GetMovieResponse{
-				Success: false,
-				Error:   "movie not found",
-			}, nil
-		}
-
-		return &pb.GetMovieResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
 	}
 
-	s.logger.Info("Successfully retrieved movie via gRPC", "id", req.Id)
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie via gRPC", "id", req.Id)
 	return &pb.GetMovieResponse{
-		Movie: &pb.Movie{
-			Id:    movie.ID,
-			Title: movie.Title,
-			Year:  movie.Year,
-		},
-		Success: true,
+		Movie: toProtoMovie(movie),
+	}, nil
+}
+
+func (s *MovieServer) GetMovieByExternalId(ctx context.Context, req *pb.GetMovieByExternalIdRequest) (*pb.GetMovieByExternalIdResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetMovieByExternalId called", "source", req.Source, "external_id", req.ExternalId)
+
+	var missing []string
+	if req.Source == "" {
+		missing = append(missing, "source")
+	}
+	if req.ExternalId == "" {
+		missing = append(missing, "external_id")
+	}
+	if len(missing) > 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid external ID", "source", req.Source, "external_id", req.ExternalId)
+		return nil, invalidArgument("source and external_id are required", missing...)
+	}
+
+	movie, err := s.service.GetMovieByExternalID(ctx, req.Source, req.ExternalId)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie by external ID", "source", req.Source, "external_id", req.ExternalId, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie by external ID via gRPC", "id", movie.ID)
+	return &pb.GetMovieByExternalIdResponse{
+		Movie: toProtoMovie(movie),
 	}, nil
 }
 
 func (s *MovieServer) CreateMovie(ctx context.Context, req *pb.CreateMovieRequest) (*pb.CreateMovieResponse, error) {
-	s.logger.Info("gRPC CreateMovie called", "title", req.Title, "year", req.Year)
+	input := convert.FromProtoMovieInput(req.Movie)
+	logging.FromContext(ctx, s.logger).Debug("gRPC CreateMovie called", "title", input.Title, "year", input.Year)
 
-	if req.Title == "" || req.Year == "" {
-		s.logger.Warn("Invalid movie data", "title", req.Title, "year", req.Year)
-		return &pb.CreateMovieResponse{
-			Success: false,
-			Error:   "title and year are required",
-		}, nil
+	if missing := missingFields(input.Title, input.Year); len(missing) > 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie data", "title", input.Title, "year", input.Year)
+		return nil, invalidArgument("title and year are required", missing...)
 	}
 
-	movie, err := s.service.CreateMovie(ctx, req.Title, req.Year)
+	movie, err := s.service.CreateMovie(ctx, domain.MovieInput(input))
 	if err != nil {
-		s.logger.Error("Failed to create movie", "title", req.Title, "year", req.Year, "error", err)
-		return &pb.CreateMovieResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+		logging.FromContext(ctx, s.logger).Error("Failed to create movie", "title", input.Title, "year", input.Year, "error", err)
+		return nil, toStatusError(err)
 	}
 
-	s.logger.Info("Successfully created movie via gRPC", "id", movie.ID)
+	logging.FromContext(ctx, s.logger).Debug("Successfully created movie via gRPC", "id", movie.ID)
 	return &pb.CreateMovieResponse{
-		Movie: &pb.Movie{
-			Id:    movie.ID,
-			Title: movie.Title,
-			Year:  movie.Year,
-		},
-		Success: true,
+		Movie: toProtoMovie(movie),
 	}, nil
 }
 
+func (s *MovieServer) UpsertMovie(ctx context.Context, req *pb.UpsertMovieRequest) (*pb.UpsertMovieResponse, error) {
+	input := convert.FromProtoMovieInput(req.Movie)
+	logging.FromContext(ctx, s.logger).Debug("gRPC UpsertMovie called", "id", req.Id, "title", input.Title, "year", input.Year)
+
+	if req.Id <= 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie ID", "id", req.Id)
+		return nil, invalidArgument("invalid movie ID", "id")
+	}
+	if missing := missingFields(input.Title, input.Year); len(missing) > 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie data", "title", input.Title, "year", input.Year)
+		return nil, invalidArgument("title and year are required", missing...)
+	}
+
+	movie, created, err := s.service.UpsertMovie(ctx, req.Id, domain.MovieInput(input))
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to upsert movie", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully upserted movie via gRPC", "id", movie.ID, "created", created)
+	return &pb.UpsertMovieResponse{
+		Movie:   toProtoMovie(movie),
+		Created: created,
+	}, nil
+}
+
+func (s *MovieServer) UpdateMovie(ctx context.Context, req *pb.UpdateMovieRequest) (*pb.UpdateMovieResponse, error) {
+	input := convert.FromProtoMovieInput(req.Movie)
+	logging.FromContext(ctx, s.logger).Debug("gRPC UpdateMovie called", "id", req.Id, "title", input.Title, "year", input.Year, "expected_version", req.ExpectedVersion)
+
+	if req.Id <= 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie ID", "id", req.Id)
+		return nil, invalidArgument("invalid movie ID", "id")
+	}
+	if missing := missingFields(input.Title, input.Year); len(missing) > 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie data", "title", input.Title, "year", input.Year)
+		return nil, invalidArgument("title and year are required", missing...)
+	}
+
+	var movie *domain.Movie
+	var err error
+	if req.ExpectedVersion != nil {
+		movie, err = s.service.UpdateMovieIfVersion(ctx, req.Id, domain.MovieInput(input), req.GetExpectedVersion())
+	} else {
+		movie, err = s.service.UpdateMovie(ctx, req.Id, domain.MovieInput(input))
+	}
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to update movie", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully updated movie via gRPC", "id", movie.ID, "version", movie.Version)
+	return &pb.UpdateMovieResponse{Movie: toProtoMovie(movie)}, nil
+}
+
+func (s *MovieServer) PatchMovie(ctx context.Context, req *pb.PatchMovieRequest) (*pb.PatchMovieResponse, error) {
+	paths := req.GetUpdateMask().GetPaths()
+	logging.FromContext(ctx, s.logger).Debug("gRPC PatchMovie called", "id", req.Id, "paths", paths, "expected_version", req.ExpectedVersion)
+
+	if req.Id <= 0 {
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie ID", "id", req.Id)
+		return nil, invalidArgument("invalid movie ID", "id")
+	}
+	if len(paths) == 0 {
+		logging.FromContext(ctx, s.logger).Warn("Empty update mask", "id", req.Id)
+		return nil, invalidArgument("update mask must name at least one field", "update_mask")
+	}
+
+	movie, err := s.service.PatchMovie(ctx, req.Id, domain.MoviePatch{
+		Input:           domain.MovieInput(convert.FromProtoMovieInput(req.Movie)),
+		Paths:           paths,
+		ExpectedVersion: req.ExpectedVersion,
+	})
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to patch movie", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully patched movie via gRPC", "id", movie.ID, "version", movie.Version)
+	return &pb.PatchMovieResponse{Movie: toProtoMovie(movie)}, nil
+}
+
 func (s *MovieServer) DeleteMovie(ctx context.Context, req *pb.DeleteMovieRequest) (*pb.DeleteMovieResponse, error) {
-	s.logger.Info("gRPC DeleteMovie called", "id", req.Id)
+	logging.FromContext(ctx, s.logger).Debug("gRPC DeleteMovie called", "id", req.Id, "expected_version", req.ExpectedVersion)
 
 	if req.Id <= 0 {
-		s.logger.Warn("Invalid movie ID", "id", req.Id)
-		return &pb.DeleteMovieResponse{
-			Success: false,
-			Error:   "invalid movie ID",
-		}, nil
+		logging.FromContext(ctx, s.logger).Warn("Invalid movie ID", "id", req.Id)
+		return nil, invalidArgument("invalid movie ID", "id")
 	}
 
-	err := s.service.DeleteMovie(ctx, req.Id)
+	var err error
+	if req.ExpectedVersion != nil {
+		err = s.service.DeleteMovieIfVersion(ctx, req.Id, req.GetExpectedVersion())
+	} else {
+		err = s.service.DeleteMovie(ctx, req.Id)
+	}
 	if err != nil {
-		s.logger.Error("Failed to delete movie", "id", req.Id, "error", err)
-		
-		if err == domain.ErrMovieNotFound {
-			return &pb.DeleteMovieResponse{
-				Success: false,
-				Error:   "movie not found",
-			}, nil
+		logging.FromContext(ctx, s.logger).Error("Failed to delete movie", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully deleted movie via gRPC", "id", req.Id)
+	return &pb.DeleteMovieResponse{}, nil
+}
+
+func toProtoMovie(movie *domain.Movie) *pb.Movie {
+	return convert.ToProtoMovie(convert.Movie(*movie))
+}
+
+// missingFields names the required movie fields left empty
+func missingFields(title, year string) []string {
+	var missing []string
+	if title == "" {
+		missing = append(missing, "title")
+	}
+	if year == "" {
+		missing = append(missing, "year")
+	}
+	return missing
+}
+
+func (s *MovieServer) GetMovieFacets(ctx context.Context, req *pb.GetMovieFacetsRequest) (*pb.GetMovieFacetsResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetMovieFacets called")
+
+	filter := domain.MovieFilter{
+		Region:        req.Region,
+		Certification: req.Certification,
+	}
+	if req.Filter != nil {
+		expr, err := toDomainFilter(req.Filter)
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Warn("Invalid filter", "error", err)
+			return nil, toStatusError(err)
 		}
+		filter.Expr = expr
+	}
 
-		return &pb.DeleteMovieResponse{
-			Success: false,
-			Error:   err.Error(),
-		}, nil
+	facets, err := s.service.GetMovieFacets(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie facets", "error", err)
+		return nil, toStatusError(err)
+	}
+
+	years := make([]*pb.FacetBucket, len(facets.Years))
+	for i, bucket := range facets.Years {
+		years[i] = &pb.FacetBucket{Value: bucket.Value, Count: bucket.Count}
+	}
+	genres := make([]*pb.FacetBucket, len(facets.Genres))
+	for i, bucket := range facets.Genres {
+		genres[i] = &pb.FacetBucket{Value: bucket.Value, Count: bucket.Count}
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie facets via gRPC", "total", facets.Total)
+	return &pb.GetMovieFacetsResponse{
+		Years:  years,
+		Genres: genres,
+		Total:  facets.Total,
+	}, nil
+}
+
+func (s *MovieServer) GetMovieHistory(ctx context.Context, req *pb.GetMovieHistoryRequest) (*pb.GetMovieHistoryResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC GetMovieHistory called", "id", req.Id)
+
+	revisions, err := s.service.GetMovieHistory(ctx, req.Id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie history", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+
+	pbRevisions := make([]*pb.MovieRevision, len(revisions))
+	for i, revision := range revisions {
+		pbRevisions[i] = &pb.MovieRevision{
+			Sequence:      revision.Event.Sequence,
+			Type:          string(revision.Event.Type),
+			ChangedFields: revision.Event.Changes.Fields(),
+			OccurredAt:    convert.ToTimestamp(revision.Event.OccurredAt),
+			Version:       revision.Event.Version,
+		}
+		if revision.Movie != nil {
+			pbRevisions[i].Movie = toProtoMovie(revision.Movie)
+		}
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie history via gRPC", "id", req.Id, "revisions", len(pbRevisions))
+	return &pb.GetMovieHistoryResponse{
+		Revisions: pbRevisions,
+	}, nil
+}
+
+func (s *MovieServer) GetMovieVersion(ctx context.Context, req *pb.GetMovieVersionRequest) (*pb.GetMovieVersionResponse, error) {
+	version, err := s.service.GetMovieVersion(ctx, req.Id)
+	if err != nil {
+		if !errors.Is(err, domain.ErrMovieNotFound) {
+			logging.FromContext(ctx, s.logger).Error("Failed to get movie version", "id", req.Id, "error", err)
+		}
+		return nil, toStatusError(err)
 	}
 
-	s.logger.Info("Successfully deleted movie via gRPC", "id", req.Id)
-	return &pb.DeleteMovieResponse{
-		Success: true,
+	return &pb.GetMovieVersionResponse{
+		Version: version,
 	}, nil
 }
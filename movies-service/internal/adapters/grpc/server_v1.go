@@ -0,0 +1,213 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	pbv1 "github.com/movie-microservice/proto/movies/v1"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// MovieServerV1 serves the movies.v1 API by translating each call to and from the v2
+// server, so v1 consumers keep working while v2 evolves. v1 reports failures as before
+// v2 used statuses for them, in responses with Success false and the message in Error
+// v1MoviePaths are the fields of MovieInput that v1 knows about
+var v1MoviePaths = []string{"title", "year", "regions", "awards", "certification"}
+
+type MovieServerV1 struct {
+	pbv1.UnimplementedMovieServiceServer
+	v2 pb.MovieServiceServer
+}
+
+func NewMovieServerV1(v2 pb.MovieServiceServer) *MovieServerV1 {
+	return &MovieServerV1{v2: v2}
+}
+
+func (s *MovieServerV1) GetMovies(ctx context.Context, req *pbv1.GetMoviesRequest) (*pbv1.GetMoviesResponse, error) {
+	filter, err := toV2Filter(req.Filter)
+	if err != nil {
+		return &pbv1.GetMoviesResponse{Error: failure(err)}, nil
+	}
+
+	resp, err := s.v2.GetMovies(ctx, &pb.GetMoviesRequest{
+		Page:          req.Page,
+		Limit:         req.Limit,
+		Filter:        filter,
+		Region:        req.Region,
+		Certification: req.Certification,
+	})
+	if err != nil {
+		return &pbv1.GetMoviesResponse{Error: failure(err)}, nil
+	}
+
+	movies := make([]*pbv1.Movie, len(resp.Movies))
+	for i, movie := range resp.Movies {
+		movies[i] = toV1Movie(movie)
+	}
+	return &pbv1.GetMoviesResponse{Movies: movies, Total: resp.Total, Success: true}, nil
+}
+
+func (s *MovieServerV1) GetMovie(ctx context.Context, req *pbv1.GetMovieRequest) (*pbv1.GetMovieResponse, error) {
+	resp, err := s.v2.GetMovie(ctx, &pb.GetMovieRequest{Id: req.Id})
+	if err != nil {
+		return &pbv1.GetMovieResponse{Error: failure(err)}, nil
+	}
+	return &pbv1.GetMovieResponse{
+		Movie:       toV1Movie(resp.Movie),
+		Success:     true,
+		FromArchive: resp.Movie.GetArchived(),
+	}, nil
+}
+
+func (s *MovieServerV1) CreateMovie(ctx context.Context, req *pbv1.CreateMovieRequest) (*pbv1.CreateMovieResponse, error) {
+	resp, err := s.v2.CreateMovie(ctx, &pb.CreateMovieRequest{Movie: &pb.MovieInput{
+		Title:         req.Title,
+		Year:          req.Year,
+		Regions:       req.Regions,
+		Awards:        req.Awards,
+		Certification: req.Certification,
+	}})
+	if err != nil {
+		return &pbv1.CreateMovieResponse{Error: failure(err)}, nil
+	}
+	return &pbv1.CreateMovieResponse{Movie: toV1Movie(resp.Movie), Success: true}, nil
+}
+
+// UpsertMovie replaces only the fields v1 knows about, so the fields added by v2, such
+// as genres or the external IDs, survive a v1 client replacing the movie
+func (s *MovieServerV1) UpsertMovie(ctx context.Context, req *pbv1.UpsertMovieRequest) (*pbv1.UpsertMovieResponse, error) {
+	if missing := missingFields(req.Title, req.Year); len(missing) > 0 {
+		return &pbv1.UpsertMovieResponse{Error: failure(invalidArgument("title and year are required", missing...))}, nil
+	}
+	input := &pb.MovieInput{
+		Title:         req.Title,
+		Year:          req.Year,
+		Regions:       req.Regions,
+		Awards:        req.Awards,
+		Certification: req.Certification,
+	}
+
+	patched, err := s.v2.PatchMovie(ctx, &pb.PatchMovieRequest{
+		Id:         req.Id,
+		Movie:      input,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: v1MoviePaths},
+	})
+	if err == nil {
+		return &pbv1.UpsertMovieResponse{Movie: toV1Movie(patched.Movie), Success: true}, nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return &pbv1.UpsertMovieResponse{Error: failure(err)}, nil
+	}
+
+	resp, err := s.v2.UpsertMovie(ctx, &pb.UpsertMovieRequest{Id: req.Id, Movie: input})
+	if err != nil {
+		return &pbv1.UpsertMovieResponse{Error: failure(err)}, nil
+	}
+	return &pbv1.UpsertMovieResponse{Movie: toV1Movie(resp.Movie), Created: resp.Created, Success: true}, nil
+}
+
+func (s *MovieServerV1) DeleteMovie(ctx context.Context, req *pbv1.DeleteMovieRequest) (*pbv1.DeleteMovieResponse, error) {
+	if _, err := s.v2.DeleteMovie(ctx, &pb.DeleteMovieRequest{Id: req.Id, ExpectedVersion: req.ExpectedVersion}); err != nil {
+		return &pbv1.DeleteMovieResponse{Error: failure(err)}, nil
+	}
+	return &pbv1.DeleteMovieResponse{Success: true}, nil
+}
+
+func (s *MovieServerV1) GetMovieFacets(ctx context.Context, req *pbv1.GetMovieFacetsRequest) (*pbv1.GetMovieFacetsResponse, error) {
+	filter, err := toV2Filter(req.Filter)
+	if err != nil {
+		return &pbv1.GetMovieFacetsResponse{Error: failure(err)}, nil
+	}
+
+	resp, err := s.v2.GetMovieFacets(ctx, &pb.GetMovieFacetsRequest{
+		Filter:        filter,
+		Region:        req.Region,
+		Certification: req.Certification,
+	})
+	if err != nil {
+		return &pbv1.GetMovieFacetsResponse{Error: failure(err)}, nil
+	}
+
+	years := make([]*pbv1.FacetBucket, len(resp.Years))
+	for i, bucket := range resp.Years {
+		years[i] = &pbv1.FacetBucket{Value: bucket.Value, Count: bucket.Count}
+	}
+	return &pbv1.GetMovieFacetsResponse{Years: years, Total: resp.Total, Success: true}, nil
+}
+
+func (s *MovieServerV1) GetMovieHistory(ctx context.Context, req *pbv1.GetMovieHistoryRequest) (*pbv1.GetMovieHistoryResponse, error) {
+	resp, err := s.v2.GetMovieHistory(ctx, &pb.GetMovieHistoryRequest{Id: req.Id})
+	if err != nil {
+		return &pbv1.GetMovieHistoryResponse{Error: failure(err)}, nil
+	}
+
+	revisions := make([]*pbv1.MovieRevision, len(resp.Revisions))
+	for i, revision := range resp.Revisions {
+		revisions[i] = &pbv1.MovieRevision{
+			Sequence:      revision.Sequence,
+			Type:          revision.Type,
+			ChangedFields: revision.ChangedFields,
+			OccurredAt:    revision.OccurredAt,
+			Version:       revision.Version,
+		}
+		if revision.Movie != nil {
+			revisions[i].Movie = toV1Movie(revision.Movie)
+		}
+	}
+	return &pbv1.GetMovieHistoryResponse{Revisions: revisions, Success: true}, nil
+}
+
+func (s *MovieServerV1) GetMovieVersion(ctx context.Context, req *pbv1.GetMovieVersionRequest) (*pbv1.GetMovieVersionResponse, error) {
+	resp, err := s.v2.GetMovieVersion(ctx, &pb.GetMovieVersionRequest{Id: req.Id})
+	if err != nil {
+		return &pbv1.GetMovieVersionResponse{Error: failure(err)}, nil
+	}
+	return &pbv1.GetMovieVersionResponse{Version: resp.Version, Success: true}, nil
+}
+
+// failure returns the message v1 puts in the Error of a failed response: "movie not
+// found" for a missing movie, as v1 always answered, or else the message of the status
+// returned by v2, without its code and details
+func failure(err error) string {
+	st := status.Convert(err)
+	if st.Code() == codes.NotFound {
+		return "movie not found"
+	}
+	return st.Message()
+}
+
+// toV1Movie drops the fields v1 does not have; v1 reports archived movies on
+// GetMovieResponse instead
+func toV1Movie(movie *pb.Movie) *pbv1.Movie {
+	return &pbv1.Movie{
+		Id:            movie.GetId(),
+		Title:         movie.GetTitle(),
+		Year:          movie.GetYear(),
+		Version:       movie.GetVersion(),
+		Regions:       movie.GetRegions(),
+		Awards:        movie.GetAwards(),
+		Certification: movie.GetCertification(),
+	}
+}
+
+// toV2Filter converts a v1 filter tree by re-decoding it, since the filter messages of
+// both versions have the same fields and field numbers
+func toV2Filter(filter *pbv1.Filter) (*pb.Filter, error) {
+	if filter == nil {
+		return nil, nil
+	}
+
+	data, err := proto.Marshal(filter)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	converted := &pb.Filter{}
+	if err := proto.Unmarshal(data, converted); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	return converted, nil
+}
@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+type SyncServer struct {
+	pb.UnimplementedCatalogSyncServiceServer
+	service ports.SyncService
+	logger  *slog.Logger
+}
+
+func NewSyncServer(service ports.SyncService, logger *slog.Logger) *SyncServer {
+	return &SyncServer{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (s *SyncServer) ListSyncConflicts(ctx context.Context, req *pb.ListSyncConflictsRequest) (*pb.ListSyncConflictsResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC ListSyncConflicts called", "page", req.Page, "limit", req.Limit)
+
+	conflicts, total, err := s.service.ListSyncConflicts(ctx, req.Page, req.Limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list sync conflicts", "error", err)
+		return nil, toStatusError(err)
+	}
+
+	pbConflicts := make([]*pb.SyncConflict, len(conflicts))
+	for i, conflict := range conflicts {
+		pbConflicts[i] = toProtoSyncConflict(conflict)
+	}
+	return &pb.ListSyncConflictsResponse{Conflicts: pbConflicts, Total: total}, nil
+}
+
+func (s *SyncServer) ResolveSyncConflict(ctx context.Context, req *pb.ResolveSyncConflictRequest) (*pb.ResolveSyncConflictResponse, error) {
+	logging.FromContext(ctx, s.logger).Debug("gRPC ResolveSyncConflict called", "id", req.Id, "resolution", req.Resolution)
+
+	if req.Id == "" {
+		return nil, invalidArgument("conflict ID is required", "id")
+	}
+	resolution, err := domain.ParseSyncResolution(req.Resolution)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	movie, err := s.service.ResolveSyncConflict(ctx, req.Id, resolution)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to resolve sync conflict", "id", req.Id, "error", err)
+		return nil, toStatusError(err)
+	}
+	resp := &pb.ResolveSyncConflictResponse{}
+	if movie != nil {
+		resp.Movie = toProtoMovie(movie)
+	}
+	return resp, nil
+}
+
+func toProtoSyncConflict(conflict *domain.SyncConflict) *pb.SyncConflict {
+	return &pb.SyncConflict{
+		Id:      conflict.ID,
+		MovieId: conflict.MovieID,
+		Remote: &pb.RemoteMovie{
+			Source:         conflict.Remote.Source,
+			ExternalId:     conflict.Remote.ExternalID,
+			Title:          conflict.Remote.Title,
+			Year:           conflict.Remote.Year,
+			Certification:  conflict.Remote.Certification,
+			Regions:        conflict.Remote.Regions,
+			Awards:         conflict.Remote.Awards,
+			Genres:         conflict.Remote.Genres,
+			Director:       conflict.Remote.Director,
+			Synopsis:       conflict.Remote.Synopsis,
+			RuntimeMinutes: conflict.Remote.RuntimeMinutes,
+		},
+		DetectedAt: convert.ToTimestamp(conflict.DetectedAt),
+	}
+}
@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TraceParentHeader is the metadata key of the W3C trace context sent by the gateway
+	TraceParentHeader = "traceparent"
+	// B3Header is the metadata key of the Zipkin B3 trace context, sent by the gateway when
+	// its caller used B3 and by clients of meshes that only speak B3
+	B3Header = "b3"
+)
+
+// TraceID returns the trace ID of the incoming call and whether the caller sampled it,
+// read from a traceparent of version 00 such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", or else from a b3 such as
+// "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1". The ID is empty when the call
+// carries no valid trace context
+func TraceID(ctx context.Context) (traceID string, sampled bool) {
+	if traceID, sampled, ok := traceParentID(ctx); ok {
+		return traceID, sampled
+	}
+	return b3TraceID(ctx)
+}
+
+func traceParentID(ctx context.Context) (traceID string, sampled, ok bool) {
+	values := metadata.ValueFromIncomingContext(ctx, TraceParentHeader)
+	if len(values) == 0 {
+		return "", false, false
+	}
+	parts := strings.Split(values[0], "-")
+	if len(parts) != 4 || parts[0] != "00" || !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", false, false
+	}
+	if strings.Trim(parts[1], "0") == "" {
+		return "", false, false
+	}
+	// The low bit of the flags is the sampled flag
+	return parts[1], strings.IndexByte("13579bdf", parts[3][1]) >= 0, true
+}
+
+// b3TraceID reads a single b3 header. 64-bit trace IDs are widened to 128 bits, and only
+// a sampling state of "0" leaves the call unsampled
+func b3TraceID(ctx context.Context) (traceID string, sampled bool) {
+	values := metadata.ValueFromIncomingContext(ctx, B3Header)
+	if len(values) == 0 {
+		return "", false
+	}
+	parts := strings.Split(strings.ToLower(values[0]), "-")
+	if len(parts) < 2 || len(parts) > 4 || !isHex(parts[1], 16) {
+		return "", false
+	}
+	traceID = parts[0]
+	if isHex(traceID, 16) {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !isHex(traceID, 32) || strings.Trim(traceID, "0") == "" {
+		return "", false
+	}
+	return traceID, len(parts) < 3 || parts[2] != "0"
+}
+
+// isHex reports whether s has n lowercase hex digits, as the trace context requires
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// maxAPIResponseBytes bounds the response read from the moderation API
+const maxAPIResponseBytes = 64 << 10
+
+const (
+	// apiRetryTokens and apiRetryTokenRatio size the retry budget of the moderation API:
+	// retries stop after about five failures more than a tenth of the successes
+	apiRetryTokens     = 10
+	apiRetryTokenRatio = 0.1
+)
+
+// apiVerdicts maps the verdicts answered by the moderation API
+var apiVerdicts = map[string]domain.ModerationVerdict{
+	"approve": domain.VerdictApprove,
+	"review":  domain.VerdictReview,
+	"reject":  domain.VerdictReject,
+}
+
+type apiRequest struct {
+	Text string `json:"text"`
+}
+
+type apiResponse struct {
+	Verdict string   `json:"verdict"`
+	Reasons []string `json:"reasons"`
+}
+
+// API asks an external moderation service for a verdict. The service receives
+// {"text": "..."} by POST and answers {"verdict": "approve|review|reject", "reasons": [...]};
+// an adapter in front of a vendor API maps its categories and scores onto that contract.
+// A failed check is retried once, within a budget shared by the checks
+type API struct {
+	url    string
+	client *http.Client
+	retry  retry.Policy
+}
+
+func NewAPI(url string, timeout time.Duration) *API {
+	return &API{url: url, client: &http.Client{Timeout: timeout}, retry: retry.Policy{
+		MaxAttempts: 2,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    time.Second,
+		Budget:      retry.NewBudget(apiRetryTokens, apiRetryTokenRatio),
+	}}
+}
+
+func (a *API) Name() string {
+	return "api"
+}
+
+func (a *API) Check(ctx context.Context, text string) (domain.ModerationResult, error) {
+	var result domain.ModerationResult
+	err := a.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = a.check(ctx, text)
+		return err
+	})
+	return result, err
+}
+
+func (a *API) check(ctx context.Context, text string) (domain.ModerationResult, error) {
+	body, err := json.Marshal(apiRequest{Text: text})
+	if err != nil {
+		return domain.ModerationResult{}, retry.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return domain.ModerationResult{}, retry.Permanent(fmt.Errorf("invalid moderation API request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return domain.ModerationResult{}, fmt.Errorf("moderation API unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("moderation API answered %s", resp.Status)
+		if !retry.RetryableStatus(resp.StatusCode) {
+			err = retry.Permanent(err)
+		}
+		return domain.ModerationResult{}, err
+	}
+
+	var answer apiResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAPIResponseBytes)).Decode(&answer); err != nil {
+		return domain.ModerationResult{}, retry.Permanent(fmt.Errorf("invalid moderation API response: %w", err))
+	}
+	verdict, ok := apiVerdicts[answer.Verdict]
+	if !ok {
+		return domain.ModerationResult{}, retry.Permanent(fmt.Errorf("unknown moderation API verdict %q", answer.Verdict))
+	}
+	return domain.ModerationResult{Verdict: verdict, Reasons: answer.Reasons}, nil
+}
@@ -0,0 +1,78 @@
+// Package moderation implements the filters run by the comment moderation worker
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// WordList rejects texts containing a blocked word and queues texts containing a word
+// that needs review. Words and phrases match whole words, ignoring case and accents
+type WordList struct {
+	blocked []string
+	review  []string
+}
+
+// NewWordList creates the filter from comma-separated lists of words or phrases
+func NewWordList(blocked, review string) *WordList {
+	return &WordList{blocked: parseWords(blocked), review: parseWords(review)}
+}
+
+// Empty reports whether the filter has no words to match
+func (w *WordList) Empty() bool {
+	return len(w.blocked) == 0 && len(w.review) == 0
+}
+
+func (w *WordList) Name() string {
+	return "word_list"
+}
+
+func (w *WordList) Check(ctx context.Context, text string) (domain.ModerationResult, error) {
+	normalized := " " + normalizeWords(text) + " "
+
+	var result domain.ModerationResult
+	for _, word := range w.blocked {
+		if strings.Contains(normalized, " "+word+" ") {
+			result.Verdict = domain.VerdictReject
+			result.Reasons = append(result.Reasons, fmt.Sprintf("blocked word %q", word))
+		}
+	}
+	for _, word := range w.review {
+		if strings.Contains(normalized, " "+word+" ") {
+			result.Verdict = max(result.Verdict, domain.VerdictReview)
+			result.Reasons = append(result.Reasons, fmt.Sprintf("word %q needs review", word))
+		}
+	}
+	return result, nil
+}
+
+func parseWords(list string) []string {
+	var words []string
+	for _, word := range strings.Split(list, ",") {
+		if word = normalizeWords(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// normalizeWords lowercases the text, removes accents and joins its words with single
+// spaces, so punctuation does not hide a match
+func normalizeWords(text string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if folded, _, err := transform.String(t, text); err == nil {
+		text = folded
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
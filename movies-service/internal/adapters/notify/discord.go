@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// discordColors set the accent of the embed of each event type
+var discordColors = map[domain.MovieEventType]int{
+	domain.MovieCreated:  0x2ECC71,
+	domain.MovieReplaced: 0x3498DB,
+	domain.MovieDeleted:  0xE74C3C,
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+	Footer      discordFooter  `json:"footer"`
+	Timestamp   string         `json:"timestamp"`
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+// Discord posts catalog events to a Discord channel webhook
+type Discord struct {
+	url    string
+	client *http.Client
+}
+
+func NewDiscord(url string, timeout time.Duration) *Discord {
+	return &Discord{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (d *Discord) Name() string {
+	return "discord"
+}
+
+func (d *Discord) Notify(ctx context.Context, event domain.CatalogEvent) error {
+	return post(ctx, d.client, d.url, discordPayload(event))
+}
+
+func discordPayload(event domain.CatalogEvent) discordMessage {
+	embed := discordEmbed{
+		Title:       title(event.Movie),
+		Description: headlines[event.Type],
+		Color:       discordColors[event.Type],
+		Footer:      discordFooter{Text: "Movie #" + strconv.Itoa(int(event.Movie.ID))},
+		Timestamp:   event.OccurredAt.Format(time.RFC3339),
+	}
+	for _, f := range details(event.Movie) {
+		// Short values sit side by side; award lists get a row of their own
+		embed.Fields = append(embed.Fields, discordField{Name: f.name, Value: f.value, Inline: f.name != "Awards"})
+	}
+	return discordMessage{Embeds: []discordEmbed{embed}}
+}
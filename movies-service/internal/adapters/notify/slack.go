@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// slackEscaper escapes the characters Slack reserves for links and mentions
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackMessage struct {
+	// Text is shown in notifications and by clients that cannot render blocks
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// Slack posts catalog events to a Slack incoming webhook
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlack(url string, timeout time.Duration) *Slack {
+	return &Slack{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Notify(ctx context.Context, event domain.CatalogEvent) error {
+	return post(ctx, s.client, s.url, slackPayload(event))
+}
+
+func slackPayload(event domain.CatalogEvent) slackMessage {
+	headline, name := headlines[event.Type], slackEscaper.Replace(title(event.Movie))
+	section := slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "*" + headline + "*\n" + name}}
+	for _, f := range details(event.Movie) {
+		section.Fields = append(section.Fields, slackText{Type: "mrkdwn", Text: "*" + f.name + "*\n" + slackEscaper.Replace(f.value)})
+	}
+	return slackMessage{Text: headline + ": " + name, Blocks: []slackBlock{section}}
+}
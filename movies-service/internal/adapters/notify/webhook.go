@@ -0,0 +1,97 @@
+// Package notify posts catalog events to chat webhooks
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// maxErrorBodyBytes bounds the part of a failed webhook response kept in the error
+const maxErrorBodyBytes = 512
+
+// headlines introduce each event type
+var headlines = map[domain.MovieEventType]string{
+	domain.MovieCreated:  "New movie",
+	domain.MovieReplaced: "Movie updated",
+	domain.MovieDeleted:  "Movie removed",
+}
+
+// field is a labelled detail of a movie shown in a message
+type field struct {
+	name  string
+	value string
+}
+
+// details lists the catalog fields of the movie that are set, in display order
+func details(movie *domain.Movie) []field {
+	var fields []field
+	if movie.Year != "" {
+		fields = append(fields, field{"Year", movie.Year})
+	}
+	if movie.Director != "" {
+		fields = append(fields, field{"Director", movie.Director})
+	}
+	if len(movie.Genres) > 0 {
+		fields = append(fields, field{"Genres", strings.Join(movie.Genres, ", ")})
+	}
+	if movie.RuntimeMinutes > 0 {
+		fields = append(fields, field{"Runtime", strconv.Itoa(int(movie.RuntimeMinutes)) + " min"})
+	}
+	if movie.Certification != "" {
+		fields = append(fields, field{"Certification", movie.Certification})
+	}
+	if len(movie.Regions) > 0 {
+		fields = append(fields, field{"Regions", strings.Join(movie.Regions, ", ")})
+	}
+	if len(movie.Awards) > 0 {
+		fields = append(fields, field{"Awards", strings.Join(movie.Awards, "\n")})
+	}
+	return fields
+}
+
+// title names the movie, falling back to its ID when only the ID is known
+func title(movie *domain.Movie) string {
+	if movie.Title == "" {
+		return "Movie #" + strconv.Itoa(int(movie.ID))
+	}
+	return movie.Title
+}
+
+// post sends the payload as JSON to the webhook, failing on any non-2xx answer. Errors
+// that a retry won't fix, such as a revoked webhook, are marked permanent
+func post(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(fmt.Errorf("invalid webhook request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		answer, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		err := fmt.Errorf("webhook answered %s: %s", resp.Status, bytes.TrimSpace(answer))
+		if !retry.RetryableStatus(resp.StatusCode) {
+			return retry.Permanent(err)
+		}
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
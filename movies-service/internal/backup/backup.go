@@ -0,0 +1,263 @@
+// Package backup reads and writes catalog backups: gzipped NDJSON files made of a header
+// line, one line per movie in ID order and a trailer line holding the number of movies
+// and the SHA-256 of their lines. A backup without its trailer was interrupted and can be
+// resumed from its last movie
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// FormatVersion is the version of the backup format written by this package
+const FormatVersion = 1
+
+const (
+	headerRecord  = "header"
+	movieRecord   = "movie"
+	trailerRecord = "trailer"
+)
+
+var (
+	// ErrIncomplete is returned when a backup ends before its trailer
+	ErrIncomplete = errors.New("backup is incomplete")
+	// ErrChecksumMismatch is returned when the movies of a backup don't match its trailer
+	ErrChecksumMismatch = errors.New("backup checksum mismatch")
+	// ErrComplete is returned when resuming a backup that already has its trailer
+	ErrComplete = errors.New("backup is already complete")
+)
+
+type record struct {
+	Type      string          `json:"type"`
+	Version   int             `json:"version,omitempty"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	Movie     json.RawMessage `json:"movie,omitempty"`
+	Count     int             `json:"count,omitempty"`
+	SHA256    string          `json:"sha256,omitempty"`
+}
+
+// Writer writes a backup. Movies must be written in ID order for the backup to be
+// resumable
+type Writer struct {
+	gz     *gzip.Writer
+	hash   hash.Hash
+	count  int
+	lastID int32
+}
+
+// NewWriter writes the header of a backup created at createdAt to w
+func NewWriter(w io.Writer, createdAt time.Time) (*Writer, error) {
+	writer := &Writer{gz: gzip.NewWriter(w), hash: sha256.New()}
+	createdAt = createdAt.UTC()
+	if err := writer.writeRecord(record{Type: headerRecord, Version: FormatVersion, CreatedAt: &createdAt}); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Resume copies the movies of the interrupted backup read from r to w and returns a
+// Writer appending after them. Movies cut off by the interruption are dropped, so the
+// backup continues after LastID
+func Resume(r io.Reader, w io.Writer) (*Writer, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := NewWriter(w, reader.CreatedAt())
+	if err != nil {
+		return nil, err
+	}
+	for {
+		movie, err := reader.Next()
+		if errors.Is(err, ErrIncomplete) {
+			return writer, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return nil, ErrComplete
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := writer.Write(movie); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Write appends a movie to the backup
+func (w *Writer) Write(movie *pb.Movie) error {
+	data, err := protojson.Marshal(movie)
+	if err != nil {
+		return fmt.Errorf("failed to encode movie %d: %w", movie.GetId(), err)
+	}
+	if err := w.writeRecord(record{Type: movieRecord, Movie: data}); err != nil {
+		return err
+	}
+	w.count++
+	w.lastID = movie.GetId()
+	return nil
+}
+
+// Flush writes the compressed movies so far to the underlying writer, so they survive
+// an interruption
+func (w *Writer) Flush() error {
+	return w.gz.Flush()
+}
+
+// Close writes the trailer and finishes the compressed stream, without closing the
+// underlying writer
+func (w *Writer) Close() error {
+	if err := w.writeRecord(record{Type: trailerRecord, Count: w.count, SHA256: w.Checksum()}); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// Count returns the number of movies written
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// LastID returns the ID of the last movie written, or 0 when none was
+func (w *Writer) LastID() int32 {
+	return w.lastID
+}
+
+// Checksum returns the hex SHA-256 of the movie lines written so far
+func (w *Writer) Checksum() string {
+	return hex.EncodeToString(w.hash.Sum(nil))
+}
+
+func (w *Writer) writeRecord(rec record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", rec.Type, err)
+	}
+	line = append(line, '\n')
+	if rec.Type == movieRecord {
+		w.hash.Write(line)
+	}
+	if _, err := w.gz.Write(line); err != nil {
+		return fmt.Errorf("failed to write %s record: %w", rec.Type, err)
+	}
+	return nil
+}
+
+// Reader reads the movies of a backup, checking them against its trailer
+type Reader struct {
+	lines     *bufio.Reader
+	hash      hash.Hash
+	count     int
+	createdAt time.Time
+	done      bool
+}
+
+// NewReader reads the header of the backup read from r
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, ErrIncomplete
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+
+	reader := &Reader{lines: bufio.NewReader(gz), hash: sha256.New()}
+	header, _, err := reader.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if header.Type != headerRecord {
+		return nil, fmt.Errorf("backup starts with a %s record instead of its header", header.Type)
+	}
+	if header.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", header.Version)
+	}
+	if header.CreatedAt != nil {
+		reader.createdAt = *header.CreatedAt
+	}
+	return reader, nil
+}
+
+// CreatedAt returns when the backup was started
+func (r *Reader) CreatedAt() time.Time {
+	return r.createdAt
+}
+
+// Count returns the number of movies read so far
+func (r *Reader) Count() int {
+	return r.count
+}
+
+// Next returns the next movie of the backup. After the last movie it returns io.EOF once
+// the trailer matched the movies read, ErrChecksumMismatch when it did not and
+// ErrIncomplete when the backup has no trailer
+func (r *Reader) Next() (*pb.Movie, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	rec, line, err := r.readRecord()
+	if err != nil {
+		return nil, err
+	}
+
+	switch rec.Type {
+	case movieRecord:
+		var movie pb.Movie
+		// Fields added to movies after the backup was written are skipped
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(rec.Movie, &movie); err != nil {
+			return nil, fmt.Errorf("invalid movie record %d: %w", r.count+1, err)
+		}
+		r.hash.Write(line)
+		r.count++
+		return &movie, nil
+	case trailerRecord:
+		if rec.Count != r.count || rec.SHA256 != hex.EncodeToString(r.hash.Sum(nil)) {
+			return nil, fmt.Errorf("%w: trailer lists %d movies, %d read", ErrChecksumMismatch, rec.Count, r.count)
+		}
+		// Reading to the end checks the gzip CRC and that nothing follows the trailer
+		if extra, err := r.lines.ReadByte(); err == nil || !errors.Is(err, io.EOF) {
+			if err == nil {
+				err = fmt.Errorf("unexpected data %q", extra)
+			}
+			return nil, fmt.Errorf("invalid data after backup trailer: %w", err)
+		}
+		r.done = true
+		return nil, io.EOF
+	default:
+		return nil, fmt.Errorf("unexpected %s record", rec.Type)
+	}
+}
+
+// readRecord reads one line. A missing or unterminated line means the backup was cut
+// off while it was written
+func (r *Reader) readRecord() (record, []byte, error) {
+	line, err := r.lines.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return record{}, nil, ErrIncomplete
+		}
+		return record{}, nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	var rec record
+	if err := json.Unmarshal(bytes.TrimSuffix(line, []byte("\n")), &rec); err != nil {
+		return record{}, nil, fmt.Errorf("invalid backup record: %w", err)
+	}
+	return rec, line, nil
+}
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/convert"
+)
+
+// exportJobBatchSize is the number of movies read at a time by export jobs
+const exportJobBatchSize = 500
+
+// ExportResult is the result of export jobs
+type ExportResult struct {
+	Movies int    `json:"movies"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ExportJob writes a backup of the whole catalog, archived movies included, as the file
+// of a job. The file is the same as a moviectl backup, so moviectl restore reads it
+type ExportJob struct {
+	movies ports.MovieService
+	files  ports.JobFileStore
+	logger *slog.Logger
+}
+
+func NewExportJob(movies ports.MovieService, files ports.JobFileStore, logger *slog.Logger) *ExportJob {
+	return &ExportJob{
+		movies: movies,
+		files:  files,
+		logger: logger,
+	}
+}
+
+// Validate accepts any parameters, since exports have none
+func (h *ExportJob) Validate(params json.RawMessage) error {
+	return nil
+}
+
+func (h *ExportJob) Run(ctx context.Context, job *domain.Job, progress ports.JobProgress) (interface{}, error) {
+	file, err := h.files.Create(ctx, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	stored := false
+	defer func() {
+		if !stored {
+			// A failed export leaves no partial file behind
+			file.Close()
+			if err := h.files.Delete(context.WithoutCancel(ctx), job.ID); err != nil {
+				logging.FromContext(ctx, h.logger).Error("Failed to delete partial export file", "id", job.ID, "error", err)
+			}
+		}
+	}()
+
+	writer, err := NewWriter(file, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var after int32
+	for {
+		movies, err := h.movies.ExportMovies(ctx, after, exportJobBatchSize)
+		if err != nil {
+			return ExportResult{Movies: writer.Count()}, err
+		}
+		for _, movie := range movies {
+			if err := writer.Write(convert.ToProtoMovie(convert.Movie(*movie))); err != nil {
+				return ExportResult{Movies: writer.Count()}, err
+			}
+			after = movie.ID
+		}
+		progress(int32(writer.Count()), 0, nil)
+		if len(movies) < exportJobBatchSize {
+			break
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	stored = true
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to store export file: %w", err)
+	}
+	job.File = true
+	logging.FromContext(ctx, h.logger).Info("Export finished", "id", job.ID, "movies", writer.Count())
+	return ExportResult{Movies: writer.Count(), SHA256: writer.Checksum()}, nil
+}
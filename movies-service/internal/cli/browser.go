@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+const (
+	// browserPageSize is the number of movies listed per page
+	browserPageSize = 20
+	// browserCallTimeout bounds each call to the movie service
+	browserCallTimeout = 10 * time.Second
+)
+
+// browserMode is the screen the browser shows
+type browserMode int
+
+const (
+	modeList browserMode = iota
+	modeSearch
+	modeDetail
+	modeConfirmDelete
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+	errorStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+// Messages of the calls to the movie service
+type (
+	moviesMsg struct {
+		movies []*pb.Movie
+		total  int32
+		page   int32
+		err    error
+	}
+	movieMsg struct {
+		movie *pb.Movie
+		err   error
+	}
+	deletedMsg struct {
+		movie *pb.Movie
+		err   error
+	}
+)
+
+// Browser is the terminal UI of moviectl tui: it lists the catalog page by page,
+// searches it by title, shows the details of a movie and deletes it once confirmed
+type Browser struct {
+	ctx    context.Context
+	client pb.MovieServiceClient
+
+	mode    browserMode
+	movies  []*pb.Movie
+	total   int32
+	page    int32
+	cursor  int
+	query   string
+	input   string
+	detail  *pb.Movie
+	loading bool
+	status  string
+	err     error
+}
+
+// NewBrowser returns the browser of the catalog served by client, making its calls
+// under ctx
+func NewBrowser(ctx context.Context, client pb.MovieServiceClient) *Browser {
+	return &Browser{ctx: ctx, client: client, page: 1, loading: true}
+}
+
+func (b *Browser) Init() tea.Cmd {
+	return b.fetchMovies(1)
+}
+
+func (b *Browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case moviesMsg:
+		b.loading, b.err = false, msg.err
+		if msg.err == nil {
+			b.movies, b.total, b.page = msg.movies, msg.total, msg.page
+			b.cursor = min(b.cursor, max(len(b.movies)-1, 0))
+		}
+		return b, nil
+	case movieMsg:
+		b.loading, b.err = false, msg.err
+		if msg.err == nil {
+			b.detail, b.mode = msg.movie, modeDetail
+		}
+		return b, nil
+	case deletedMsg:
+		b.loading, b.err = false, msg.err
+		if msg.err != nil {
+			b.mode = modeDetail
+			return b, nil
+		}
+		b.mode, b.detail = modeList, nil
+		b.status = fmt.Sprintf("deleted movie %d, %s", msg.movie.GetId(), msg.movie.GetTitle())
+		b.loading = true
+		return b, b.fetchMovies(b.page)
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return b, tea.Quit
+		}
+		return b.key(msg)
+	}
+	return b, nil
+}
+
+// key handles a key press on the current screen
+func (b *Browser) key(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	switch b.mode {
+	case modeSearch:
+		switch msg.Type {
+		case tea.KeyEnter:
+			b.mode, b.query, b.cursor, b.loading = modeList, strings.TrimSpace(b.input), 0, true
+			return b, b.fetchMovies(1)
+		case tea.KeyEsc:
+			b.mode = modeList
+		case tea.KeyBackspace:
+			if runes := []rune(b.input); len(runes) > 0 {
+				b.input = string(runes[:len(runes)-1])
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			b.input += string(msg.Runes)
+		}
+		return b, nil
+
+	case modeConfirmDelete:
+		switch key {
+		case "y", "Y":
+			b.loading = true
+			return b, b.deleteMovie(b.detail)
+		case "n", "N", "esc":
+			b.mode = modeDetail
+		}
+		return b, nil
+
+	case modeDetail:
+		switch key {
+		case "q":
+			return b, tea.Quit
+		case "esc", "backspace", "left", "h":
+			b.mode, b.detail = modeList, nil
+		case "d":
+			b.mode, b.status, b.err = modeConfirmDelete, "", nil
+		}
+		return b, nil
+	}
+
+	switch key {
+	case "q":
+		return b, tea.Quit
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, max(len(b.movies)-1, 0))
+	case "right", "l", "pgdown":
+		if b.page < b.pages() {
+			b.cursor, b.loading = 0, true
+			return b, b.fetchMovies(b.page + 1)
+		}
+	case "left", "h", "pgup":
+		if b.page > 1 {
+			b.cursor, b.loading = 0, true
+			return b, b.fetchMovies(b.page - 1)
+		}
+	case "/":
+		b.mode, b.input, b.status = modeSearch, b.query, ""
+	case "esc":
+		if b.query != "" {
+			b.query, b.cursor, b.loading = "", 0, true
+			return b, b.fetchMovies(1)
+		}
+	case "r":
+		b.loading, b.status = true, ""
+		return b, b.fetchMovies(b.page)
+	case "enter":
+		if b.cursor < len(b.movies) {
+			b.loading, b.status = true, ""
+			return b, b.fetchMovie(b.movies[b.cursor].GetId())
+		}
+	case "d":
+		if b.cursor < len(b.movies) {
+			b.detail, b.mode, b.status, b.err = b.movies[b.cursor], modeConfirmDelete, "", nil
+		}
+	}
+	return b, nil
+}
+
+// pages returns the number of pages of the current list
+func (b *Browser) pages() int32 {
+	return max((b.total+browserPageSize-1)/browserPageSize, 1)
+}
+
+func (b *Browser) View() string {
+	var view strings.Builder
+	switch b.mode {
+	case modeDetail, modeConfirmDelete:
+		b.viewDetail(&view)
+	default:
+		b.viewList(&view)
+	}
+
+	view.WriteString("\n")
+	switch {
+	case b.loading:
+		view.WriteString("loading...\n")
+	case b.err != nil:
+		view.WriteString(errorStyle.Render("error: "+b.err.Error()) + "\n")
+	case b.status != "":
+		view.WriteString(b.status + "\n")
+	}
+	return view.String()
+}
+
+func (b *Browser) viewList(view *strings.Builder) {
+	header := fmt.Sprintf("Movies: %d, page %d of %d", b.total, b.page, b.pages())
+	if b.query != "" {
+		header += fmt.Sprintf(", titles containing %q", b.query)
+	}
+	view.WriteString(titleStyle.Render(header) + "\n\n")
+	if len(b.movies) == 0 && !b.loading {
+		view.WriteString("  no movies\n")
+	}
+	for i, movie := range b.movies {
+		line := fmt.Sprintf("%6d  %-48s  %4s", movie.GetId(), truncate(movie.GetTitle(), 48), movie.GetYear())
+		if i == b.cursor {
+			line = selectedStyle.Render(line)
+		}
+		view.WriteString("  " + line + "\n")
+	}
+
+	view.WriteString("\n")
+	if b.mode == modeSearch {
+		view.WriteString("Search titles: " + b.input + "█\n")
+		view.WriteString(helpStyle.Render("enter search • esc cancel") + "\n")
+		return
+	}
+	view.WriteString(helpStyle.Render("↑/↓ select • ←/→ page • enter details • / search • d delete • r refresh • q quit") + "\n")
+}
+
+func (b *Browser) viewDetail(view *strings.Builder) {
+	movie := b.detail
+	view.WriteString(titleStyle.Render(fmt.Sprintf("%s (%s)", movie.GetTitle(), movie.GetYear())) + "\n\n")
+	for _, row := range [][2]string{
+		{"ID", fmt.Sprint(movie.GetId())},
+		{"Version", fmt.Sprint(movie.GetVersion())},
+		{"Director", movie.GetDirector()},
+		{"Genres", strings.Join(movie.GetGenres(), ", ")},
+		{"Runtime", formatRuntime(movie.GetRuntimeMinutes())},
+		{"Certification", movie.GetCertification()},
+		{"Regions", strings.Join(movie.GetRegions(), ", ")},
+		{"Awards", strings.Join(movie.GetAwards(), "; ")},
+		{"IMDb", movie.GetImdbId()},
+		{"TMDB", movie.GetTmdbId()},
+		{"Archived", fmt.Sprint(movie.GetArchived())},
+	} {
+		if row[1] != "" {
+			fmt.Fprintf(view, "  %-14s %s\n", row[0], row[1])
+		}
+	}
+
+	if synopsis := movie.GetSynopsis(); synopsis != "" {
+		view.WriteString("\n  " + synopsis + "\n")
+	}
+
+	view.WriteString("\n")
+	if b.mode == modeConfirmDelete {
+		view.WriteString(errorStyle.Render(fmt.Sprintf("Delete movie %d, %s? (y/n)", movie.GetId(), movie.GetTitle())) + "\n")
+		return
+	}
+	view.WriteString(helpStyle.Render("esc back • d delete • q quit") + "\n")
+}
+
+func (b *Browser) fetchMovies(page int32) tea.Cmd {
+	query := b.query
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(b.ctx, browserCallTimeout)
+		defer cancel()
+		req := &pb.GetMoviesRequest{Page: page, Limit: browserPageSize}
+		if query != "" {
+			req.Filter = &pb.Filter{Node: &pb.Filter_Condition{Condition: &pb.FilterCondition{
+				Field: "title", Operator: pb.FilterOperator_FILTER_OPERATOR_CONTAINS, Value: query,
+			}}}
+		}
+		resp, err := b.client.GetMovies(ctx, req)
+		if err != nil {
+			return moviesMsg{err: err}
+		}
+		return moviesMsg{movies: resp.GetMovies(), total: resp.GetTotal(), page: page}
+	}
+}
+
+func (b *Browser) fetchMovie(id int32) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(b.ctx, browserCallTimeout)
+		defer cancel()
+		resp, err := b.client.GetMovie(ctx, &pb.GetMovieRequest{Id: id})
+		if err != nil {
+			return movieMsg{err: err}
+		}
+		return movieMsg{movie: resp.GetMovie()}
+	}
+}
+
+// deleteMovie deletes the movie only if it is still the version shown, so a movie
+// changed since it was listed is not deleted unseen
+func (b *Browser) deleteMovie(movie *pb.Movie) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(b.ctx, browserCallTimeout)
+		defer cancel()
+		version := movie.GetVersion()
+		_, err := b.client.DeleteMovie(ctx, &pb.DeleteMovieRequest{Id: movie.GetId(), ExpectedVersion: &version})
+		return deletedMsg{movie: movie, err: err}
+	}
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// formatRuntime formats a running time such as "1h 50m", empty when unknown
+func formatRuntime(minutes int32) string {
+	switch {
+	case minutes <= 0:
+		return ""
+	case minutes < 60:
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
@@ -0,0 +1,99 @@
+// Package cli formats the results and errors of the moviectl commands for people and
+// scripts: results are written as a table, JSON or YAML, and each class of error exits
+// with its own stable code. Browser is the terminal UI of moviectl tui.
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes of moviectl, one per class of error. They are part of the interface of the
+// command: scripts may rely on them, so existing codes never change meaning
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 2
+	ExitUnavailable = 3
+	ExitInvalid     = 4
+	ExitNotFound    = 5
+	ExitConflict    = 6
+	ExitDenied      = 7
+	ExitInterrupted = 8
+)
+
+// classes name the exit codes in the error reports
+var classes = map[int]string{
+	ExitFailure:     "failure",
+	ExitUsage:       "usage",
+	ExitUnavailable: "unavailable",
+	ExitInvalid:     "invalid",
+	ExitNotFound:    "not_found",
+	ExitConflict:    "conflict",
+	ExitDenied:      "denied",
+	ExitInterrupted: "interrupted",
+}
+
+// Class returns the name of the class of an exit code
+func Class(code int) string {
+	if class, ok := classes[code]; ok {
+		return class
+	}
+	return classes[ExitFailure]
+}
+
+// classError forces the class of an error that Code could not tell from its cause
+type classError struct {
+	code int
+	err  error
+}
+
+func (e *classError) Error() string { return e.err.Error() }
+func (e *classError) Unwrap() error { return e.err }
+
+// Invalid marks err as caused by invalid input, such as a corrupted backup file
+func Invalid(err error) error {
+	return &classError{code: ExitInvalid, err: err}
+}
+
+// Code returns the exit code of the class of err: forced by Invalid, told by the gRPC
+// status of a failed call, or by the cause of a failed file operation
+func Code(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var classErr *classError
+	if errors.As(err, &classErr) {
+		return classErr.code
+	}
+	if errors.Is(err, context.Canceled) {
+		return ExitInterrupted
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return ExitNotFound
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return ExitDenied
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Canceled:
+			return ExitInterrupted
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return ExitUnavailable
+		case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+			return ExitInvalid
+		case codes.NotFound:
+			return ExitNotFound
+		case codes.AlreadyExists, codes.Aborted:
+			return ExitConflict
+		case codes.PermissionDenied, codes.Unauthenticated:
+			return ExitDenied
+		}
+	}
+	return ExitFailure
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats of the results
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// Printer writes the results of a command to stdout in its format, and its notes and
+// errors to stderr. Results are flat structs, whose fields are named by their json tags
+type Printer struct {
+	Format string
+	// Quiet writes nothing but the errors, as a single line on stderr
+	Quiet  bool
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewPrinter returns a printer of tables
+func NewPrinter(stdout, stderr io.Writer) *Printer {
+	return &Printer{Format: FormatTable, Stdout: stdout, Stderr: stderr}
+}
+
+// Register adds the -output and -quiet flags to flags, defaulting to the current settings,
+// so they are accepted both before and after the command
+func (p *Printer) Register(flags *flag.FlagSet) {
+	flags.StringVar(&p.Format, "output", p.Format, "output format: table, json or yaml")
+	flags.BoolVar(&p.Quiet, "quiet", p.Quiet, "print nothing but errors")
+}
+
+// Validate checks the format is known
+func (p *Printer) Validate() error {
+	switch p.Format {
+	case FormatTable, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, use table, json or yaml", p.Format)
+}
+
+// Notef writes a progress note on stderr, unless quiet
+func (p *Printer) Notef(format string, args ...any) {
+	if !p.Quiet {
+		fmt.Fprintf(p.Stderr, format+"\n", args...)
+	}
+}
+
+// Usage reports a misuse of the command and returns ExitUsage
+func (p *Printer) Usage(format string, args ...any) int {
+	fmt.Fprintf(p.Stderr, format+"\n", args...)
+	return ExitUsage
+}
+
+// Result writes the result of a successful command and returns ExitOK
+func (p *Printer) Result(result any) int {
+	if !p.Quiet {
+		p.write(fields(result))
+	}
+	return ExitOK
+}
+
+// Fail reports the error of the command and returns its exit code. Tables get the error
+// and hint on stderr; JSON and YAML get a report on stdout, with the class and code of the
+// error, the fields of progress, such as what was done before failing, and the hint
+func (p *Printer) Fail(command string, err error, progress any, hint string) int {
+	code := Code(err)
+	if p.Quiet || p.Format == FormatTable {
+		fmt.Fprintf(p.Stderr, "%s: %v\n", command, err)
+		if hint != "" && !p.Quiet {
+			fmt.Fprintln(p.Stderr, hint)
+		}
+		return code
+	}
+
+	report := []field{
+		{"error", Class(code)},
+		{"message", err.Error()},
+		{"exit_code", code},
+	}
+	report = append(report, fields(progress)...)
+	if hint != "" {
+		report = append(report, field{"hint", hint})
+	}
+	p.write(report)
+	return code
+}
+
+// field is a named value of a result
+type field struct {
+	name  string
+	value any
+}
+
+// fields lists the exported fields of a struct by their json names, in order, leaving
+// out the omitempty ones that are zero
+func fields(v any) []field {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if !value.IsValid() || value.Kind() != reflect.Struct {
+		return nil
+	}
+	var list []field
+	for i := 0; i < value.NumField(); i++ {
+		info := value.Type().Field(i)
+		if !info.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(info.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = info.Name
+		}
+		if strings.Contains(options, "omitempty") && value.Field(i).IsZero() {
+			continue
+		}
+		list = append(list, field{name, value.Field(i).Interface()})
+	}
+	return list
+}
+
+func (p *Printer) write(list []field) {
+	switch p.Format {
+	case FormatJSON:
+		fmt.Fprintln(p.Stdout, "{")
+		for i, f := range list {
+			value, _ := json.Marshal(f.value)
+			separator := ","
+			if i == len(list)-1 {
+				separator = ""
+			}
+			fmt.Fprintf(p.Stdout, "  %q: %s%s\n", f.name, value, separator)
+		}
+		fmt.Fprintln(p.Stdout, "}")
+	case FormatYAML:
+		for _, f := range list {
+			fmt.Fprintf(p.Stdout, "%s: %s\n", f.name, yamlScalar(f.value))
+		}
+	default:
+		table := tabwriter.NewWriter(p.Stdout, 0, 4, 2, ' ', 0)
+		names := make([]string, len(list))
+		values := make([]string, len(list))
+		for i, f := range list {
+			names[i] = strings.ToUpper(f.name)
+			values[i] = text(f.value)
+		}
+		fmt.Fprintln(table, strings.Join(names, "\t"))
+		fmt.Fprintln(table, strings.Join(values, "\t"))
+		table.Flush()
+	}
+}
+
+// text formats a value for a table
+func text(value any) string {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// plainYAML matches the strings YAML reads back as the same string without quotes
+var plainYAML = regexp.MustCompile(`^[A-Za-z_./][A-Za-z0-9_./@+-]*$`)
+
+// yamlScalar formats a value as a YAML scalar, quoting the strings YAML would read as
+// something else. The escapes of Go quoted strings are valid in double-quoted YAML
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "false", "yes", "no", "on", "off", "null", "y", "n", "~", ".inf", ".nan":
+		default:
+			if plainYAML.MatchString(v) {
+				return v
+			}
+		}
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
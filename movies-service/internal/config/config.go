@@ -1,15 +1,34 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/logging"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	GRPC     GRPCConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	GRPC        GRPCConfig
+	Pagination  PaginationConfig
+	Catalog     CatalogConfig
+	Archive     ArchiveConfig
+	Scheduler   SchedulerConfig
+	Jobs        JobsConfig
+	Debug       DebugConfig
+	Admin       AdminConfig
+	Idempotency IdempotencyConfig
+	Moderation  ModerationConfig
+	Notify      NotifyConfig
+	Sync        SyncConfig
 }
 
 type ServerConfig struct {
@@ -22,13 +41,207 @@ type DatabaseConfig struct {
 	ConnectionString string
 	DatabaseName     string
 	MaxPoolSize      int
+	// PersistenceMode is PersistenceState to store the current state of movies, or
+	// PersistenceEvents to store every change as an event stream
+	PersistenceMode string
+	// SnapshotEvery is the number of events of a movie between snapshots of its state
+	SnapshotEvery int
+	// ReadModelSchedule is when the read model queried by listings catches up with the
+	// event store; empty makes listings query the movies collection
+	ReadModelSchedule string
 }
 
+const (
+	PersistenceState  = "state"
+	PersistenceEvents = "events"
+)
+
 type GRPCConfig struct {
 	Port string
+	// RateLimitPerSecond is the sustained rate of calls accepted from each peer address;
+	// 0 disables rate limiting
+	RateLimitPerSecond int
+	// RateLimitBurst is the number of calls a peer can make at once
+	RateLimitBurst int
+	// MaxConcurrentStreams bounds the calls in flight on each client connection
+	MaxConcurrentStreams int
+	// ShutdownGraceSeconds is the time in-flight calls get to finish on shutdown before
+	// they are cancelled
+	ShutdownGraceSeconds int
+}
+
+// AdminConfig configures the HTTP listener serving health checks, metrics and profiles
+type AdminConfig struct {
+	// Port is the HTTP port of the listener; empty disables it
+	Port string
+}
+
+// IdempotencyConfig configures the deduplication of CreateMovie retries sent with an
+// idempotency key
+type IdempotencyConfig struct {
+	// TTLSeconds is how long the first result of a key is kept; 0 disables deduplication
+	TTLSeconds int
+}
+
+type CatalogConfig struct {
+	// Certifications is the comma-separated list of accepted age certifications
+	Certifications string
+}
+
+type ArchiveConfig struct {
+	// AfterDays is how long a movie may go unread before it is archived; 0 disables archiving
+	AfterDays int
+	Schedule  string
+}
+
+// ModerationConfig configures the worker checking new comments before they are shown
+type ModerationConfig struct {
+	// Schedule is when the worker checks new comments; empty shows comments right away
+	Schedule string
+	// BlockedWords and ReviewWords are comma-separated words or phrases that reject a
+	// comment or queue it for a moderator
+	BlockedWords string
+	ReviewWords  string
+	// APIURL is the external moderation service asked for a verdict; empty skips it
+	APIURL       string
+	APITimeoutMs int
+}
+
+// NotifyConfig configures the chat webhooks announcing catalog changes
+type NotifyConfig struct {
+	// SlackWebhookURL and DiscordWebhookURL are the incoming webhooks posted to; empty
+	// disables the channel
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+	// SlackEvents and DiscordEvents are the comma-separated event types each channel
+	// announces, among created, replaced and deleted
+	SlackEvents   string
+	DiscordEvents string
+	TimeoutMs     int
+	// Workers, QueueSize and Attempts size the worker pool delivering notifications;
+	// notifications beyond the queue are dropped and failed ones are sent Attempts times
+	Workers   int
+	QueueSize int
+	Attempts  int
+}
+
+// NotifyEvents lists the catalog event types a channel can announce
+var NotifyEvents = []string{"created", "replaced", "deleted"}
+
+// EventList returns the event types of a comma-separated list
+func (c NotifyConfig) EventList(list string) []string {
+	return splitList(list)
+}
+
+// SyncConfig configures the job applying an external catalog feed to the catalog
+type SyncConfig struct {
+	// Schedule is when the feed is synced; empty disables the sync
+	Schedule string
+	// Source is SyncSourceCSV or SyncSourceTMDb
+	Source string
+	// CSVURL is the CSV file read by the csv source
+	CSVURL string
+	// TMDbURL, TMDbToken and TMDbQuery are the API base URL, read access token and
+	// discover filters of the tmdb source, which reads at most TMDbMaxPages pages
+	TMDbURL      string
+	TMDbToken    string
+	TMDbQuery    string
+	TMDbMaxPages int
+	// Policy is local-wins, remote-wins or manual, applied to movies changed on both sides
+	Policy    string
+	TimeoutMs int
+}
+
+const (
+	SyncSourceCSV  = "csv"
+	SyncSourceTMDb = "tmdb"
+)
+
+type SchedulerConfig struct {
+	// LockTTLSeconds is how long the leader keeps its lease without renewing it
+	LockTTLSeconds int
+}
+
+// JobsConfig configures the workers running the jobs queued through the API
+type JobsConfig struct {
+	// Workers is the number of jobs a replica runs at once; 0 leaves them to other replicas
+	Workers        int
+	PollIntervalMs int
+	// LeaseSeconds is how long a job stays with its worker without a renewal
+	LeaseSeconds int
+	// RetentionHours is how long finished jobs and their files are kept
+	RetentionHours int
+	// MaxWaitSeconds is how long a pending job waits for higher priorities before it
+	// runs next anyway
+	MaxWaitSeconds int
+}
+
+// DebugConfig controls troubleshooting features that expose internals; production
+// deployments turn them off unless they are enabled explicitly
+type DebugConfig struct {
+	// Environment is EnvironmentDevelopment, EnvironmentStaging or EnvironmentProduction
+	Environment string
+	// Reflection registers the gRPC reflection service used by tools such as grpcurl
+	Reflection bool
+	// LogLevel is debug, info, warn or error; per-layer request details are logged at debug
+	LogLevel string
+	// LogSampleRate is the fraction of successful reads logged, from 0 to 1; failed, slow
+	// and writing calls are always logged
+	LogSampleRate float64
+	// LogSlowRequestMs logs every call taking at least this long; 0 disables it
+	LogSlowRequestMs int
+	// PayloadLogging logs request and response messages at debug level
+	PayloadLogging bool
+	// PayloadRedactFields is the comma-separated list of fields masked in logged payloads
+	PayloadRedactFields string
+	// PayloadLogMaxBytes truncates logged payloads; 0 logs them whole
+	PayloadLogMaxBytes int
+	// Pprof serves runtime profiles on the admin listener
+	Pprof bool
+	// LeakDetectorIntervalSeconds is the time between the snapshots of the leak detector,
+	// which warns about goroutines, files and streams that keep growing; 0 disables it
+	LeakDetectorIntervalSeconds int
+}
+
+// LogPolicy returns the policy applied to request log lines
+func (c DebugConfig) LogPolicy() logging.Policy {
+	return logging.Policy{
+		SampleRate:    c.LogSampleRate,
+		SlowThreshold: time.Duration(c.LogSlowRequestMs) * time.Millisecond,
+	}
+}
+
+// RedactFields returns the fields masked in logged payloads
+func (c DebugConfig) RedactFields() []string {
+	return splitList(c.PayloadRedactFields)
+}
+
+// splitList returns the non-empty items of a comma-separated list
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentStaging     = "staging"
+	EnvironmentProduction  = "production"
+)
+
+type PaginationConfig struct {
+	DefaultPageSize int
+	MaxPageSize     int
+	MaxPage         int
 }
 
 func Load() *Config {
+	environment := getEnv("ENVIRONMENT", EnvironmentDevelopment)
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnv("SERVER_PORT", "8080"),
@@ -36,12 +249,87 @@ func Load() *Config {
 			WriteTimeout: getEnvAsInt("WRITE_TIMEOUT", 10),
 		},
 		Database: DatabaseConfig{
-			ConnectionString: getEnv("MONGODB_URI", "mongodb://mongodb:27017"),
-			DatabaseName:     getEnv("DATABASE_NAME", "movies_db"),
-			MaxPoolSize:      getEnvAsInt("MAX_POOL_SIZE", 10),
+			ConnectionString:  getEnv("MONGODB_URI", "mongodb://mongodb:27017"),
+			DatabaseName:      getEnv("DATABASE_NAME", "movies_db"),
+			MaxPoolSize:       getEnvAsInt("MAX_POOL_SIZE", 10),
+			PersistenceMode:   getEnv("PERSISTENCE_MODE", PersistenceState),
+			SnapshotEvery:     getEnvAsInt("SNAPSHOT_EVERY", 50),
+			ReadModelSchedule: getEnv("READ_MODEL_SCHEDULE", ""),
 		},
 		GRPC: GRPCConfig{
-			Port: getEnv("GRPC_PORT", "50051"),
+			Port:                 getEnv("GRPC_PORT", "50051"),
+			RateLimitPerSecond:   getEnvAsInt("GRPC_RATE_LIMIT_PER_SECOND", 0),
+			RateLimitBurst:       getEnvAsInt("GRPC_RATE_LIMIT_BURST", 50),
+			MaxConcurrentStreams: getEnvAsInt("GRPC_MAX_CONCURRENT_STREAMS", 100),
+			ShutdownGraceSeconds: getEnvAsInt("SHUTDOWN_GRACE_SECONDS", 30),
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: getEnvAsInt("DEFAULT_PAGE_SIZE", 10),
+			MaxPageSize:     getEnvAsInt("MAX_PAGE_SIZE", 100),
+			MaxPage:         getEnvAsInt("MAX_PAGE", 0),
+		},
+		Catalog: CatalogConfig{
+			Certifications: getEnv("CERTIFICATIONS", "G,PG,PG-13,R,NC-17"),
+		},
+		Archive: ArchiveConfig{
+			AfterDays: getEnvAsInt("ARCHIVE_AFTER_DAYS", 0),
+			Schedule:  getEnv("ARCHIVE_SCHEDULE", "@hourly"),
+		},
+		Scheduler: SchedulerConfig{
+			LockTTLSeconds: getEnvAsInt("SCHEDULER_LOCK_TTL_SECONDS", 30),
+		},
+		Jobs: JobsConfig{
+			Workers:        getEnvAsInt("JOB_WORKERS", 2),
+			PollIntervalMs: getEnvAsInt("JOB_POLL_INTERVAL_MS", 1000),
+			LeaseSeconds:   getEnvAsInt("JOB_LEASE_SECONDS", 30),
+			RetentionHours: getEnvAsInt("JOB_RETENTION_HOURS", 168),
+			MaxWaitSeconds: getEnvAsInt("JOB_MAX_WAIT_SECONDS", 600),
+		},
+		Debug: DebugConfig{
+			Environment:                 environment,
+			Reflection:                  getEnvAsBool("GRPC_REFLECTION", environment != EnvironmentProduction),
+			LogLevel:                    getEnv("LOG_LEVEL", "info"),
+			LogSampleRate:               getEnvAsFloat("LOG_SAMPLE_RATE", 1),
+			LogSlowRequestMs:            getEnvAsInt("LOG_SLOW_REQUEST_MS", 1000),
+			PayloadLogging:              getEnvAsBool("PAYLOAD_LOGGING", false),
+			PayloadRedactFields:         getEnv("PAYLOAD_REDACT_FIELDS", "password,token,secret,api_key,email"),
+			PayloadLogMaxBytes:          getEnvAsInt("PAYLOAD_LOG_MAX_BYTES", 2048),
+			Pprof:                       getEnvAsBool("PPROF", environment != EnvironmentProduction),
+			LeakDetectorIntervalSeconds: getEnvAsInt("LEAK_DETECTOR_INTERVAL_SECONDS", 0),
+		},
+		Admin: AdminConfig{
+			Port: getEnv("ADMIN_PORT", "8081"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTLSeconds: getEnvAsInt("IDEMPOTENCY_TTL_SECONDS", 86400),
+		},
+		Moderation: ModerationConfig{
+			Schedule:     getEnv("MODERATION_SCHEDULE", ""),
+			BlockedWords: getEnv("MODERATION_BLOCKED_WORDS", ""),
+			ReviewWords:  getEnv("MODERATION_REVIEW_WORDS", ""),
+			APIURL:       getEnv("MODERATION_API_URL", ""),
+			APITimeoutMs: getEnvAsInt("MODERATION_API_TIMEOUT_MS", 2000),
+		},
+		Notify: NotifyConfig{
+			SlackWebhookURL:   getEnv("SLACK_WEBHOOK_URL", ""),
+			DiscordWebhookURL: getEnv("DISCORD_WEBHOOK_URL", ""),
+			SlackEvents:       getEnv("SLACK_NOTIFY_EVENTS", "created"),
+			DiscordEvents:     getEnv("DISCORD_NOTIFY_EVENTS", "created"),
+			TimeoutMs:         getEnvAsInt("NOTIFY_TIMEOUT_MS", 5000),
+			Workers:           getEnvAsInt("NOTIFY_WORKERS", 4),
+			QueueSize:         getEnvAsInt("NOTIFY_QUEUE_SIZE", 1000),
+			Attempts:          getEnvAsInt("NOTIFY_ATTEMPTS", 3),
+		},
+		Sync: SyncConfig{
+			Schedule:     getEnv("SYNC_SCHEDULE", ""),
+			Source:       getEnv("SYNC_SOURCE", SyncSourceCSV),
+			CSVURL:       getEnv("SYNC_CSV_URL", ""),
+			TMDbURL:      getEnv("SYNC_TMDB_URL", "https://api.themoviedb.org/3"),
+			TMDbToken:    getEnv("SYNC_TMDB_TOKEN", ""),
+			TMDbQuery:    getEnv("SYNC_TMDB_QUERY", "sort_by=popularity.desc"),
+			TMDbMaxPages: getEnvAsInt("SYNC_TMDB_MAX_PAGES", 5),
+			Policy:       getEnv("SYNC_POLICY", "manual"),
+			TimeoutMs:    getEnvAsInt("SYNC_TIMEOUT_MS", 10000),
 		},
 	}
 }
@@ -61,6 +349,22 @@ func getEnvAsInt(name string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvAsBool(name string, defaultVal bool) bool {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
+func getEnvAsFloat(name string, defaultVal float64) float64 {
+	valueStr := getEnv(name, "")
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultVal
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
 	if c.Database.ConnectionString == "" {
@@ -72,5 +376,172 @@ func (c *Config) Validate() error {
 	if c.GRPC.Port == "" {
 		log.Fatal("GRPC port is required")
 	}
+	if c.Database.PersistenceMode != PersistenceState && c.Database.PersistenceMode != PersistenceEvents {
+		return fmt.Errorf("persistence mode must be %q or %q", PersistenceState, PersistenceEvents)
+	}
+	if c.Database.SnapshotEvery < 1 {
+		return fmt.Errorf("snapshot interval must be at least 1 event")
+	}
+	if c.Database.ReadModelSchedule != "" && c.Database.PersistenceMode != PersistenceEvents {
+		return fmt.Errorf("the read model requires persistence mode %q", PersistenceEvents)
+	}
+	if c.Pagination.DefaultPageSize < 1 || c.Pagination.DefaultPageSize > c.Pagination.MaxPageSize {
+		return fmt.Errorf("default page size must be between 1 and max page size (%d)", c.Pagination.MaxPageSize)
+	}
+	if c.Pagination.MaxPage < 0 {
+		return fmt.Errorf("max page cannot be negative")
+	}
+	if strings.TrimSpace(strings.ReplaceAll(c.Catalog.Certifications, ",", "")) == "" {
+		return fmt.Errorf("at least one certification is required")
+	}
+	if c.Archive.AfterDays < 0 {
+		return fmt.Errorf("archive after days cannot be negative")
+	}
+	if c.Scheduler.LockTTLSeconds < 3 {
+		return fmt.Errorf("scheduler lock TTL must be at least 3 seconds")
+	}
+	if c.Jobs.Workers < 0 {
+		return fmt.Errorf("job workers cannot be negative")
+	}
+	if c.Jobs.PollIntervalMs <= 0 {
+		return fmt.Errorf("job poll interval must be positive")
+	}
+	if c.Jobs.LeaseSeconds < 3 {
+		return fmt.Errorf("job lease must be at least 3 seconds")
+	}
+	if c.Jobs.RetentionHours <= 0 {
+		return fmt.Errorf("job retention must be positive")
+	}
+	if c.Jobs.MaxWaitSeconds <= 0 {
+		return fmt.Errorf("job max wait must be positive")
+	}
+	switch c.Debug.Environment {
+	case EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction:
+	default:
+		return fmt.Errorf("environment must be %q, %q or %q", EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Debug.LogLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q", c.Debug.LogLevel)
+	}
+	if c.Debug.LogSampleRate < 0 || c.Debug.LogSampleRate > 1 {
+		return fmt.Errorf("log sample rate must be between 0 and 1")
+	}
+	if c.Debug.LogSlowRequestMs < 0 {
+		return fmt.Errorf("slow request threshold cannot be negative")
+	}
+	if c.Debug.PayloadLogMaxBytes < 0 {
+		return fmt.Errorf("payload log size cannot be negative")
+	}
+	if c.Debug.LeakDetectorIntervalSeconds < 0 {
+		return fmt.Errorf("leak detector interval cannot be negative")
+	}
+	if c.GRPC.RateLimitPerSecond < 0 {
+		return fmt.Errorf("gRPC rate limit cannot be negative")
+	}
+	if c.GRPC.RateLimitPerSecond > 0 && c.GRPC.RateLimitBurst < 1 {
+		return fmt.Errorf("gRPC rate limit burst must be at least 1")
+	}
+	if c.GRPC.ShutdownGraceSeconds < 1 {
+		return fmt.Errorf("shutdown grace period must be at least 1 second")
+	}
+	if c.GRPC.MaxConcurrentStreams < 1 {
+		return fmt.Errorf("gRPC max concurrent streams must be at least 1")
+	}
+	if c.Idempotency.TTLSeconds < 0 {
+		return fmt.Errorf("idempotency TTL cannot be negative")
+	}
+	if c.Moderation.Schedule != "" && c.Moderation.APIURL == "" &&
+		strings.TrimSpace(strings.ReplaceAll(c.Moderation.BlockedWords+c.Moderation.ReviewWords, ",", "")) == "" {
+		return fmt.Errorf("comment moderation requires blocked words, review words or a moderation API")
+	}
+	if c.Moderation.APIURL != "" {
+		if !isHTTPURL(c.Moderation.APIURL) {
+			return fmt.Errorf("moderation API URL must be an absolute http or https URL")
+		}
+		if c.Moderation.APITimeoutMs < 1 {
+			return fmt.Errorf("moderation API timeout must be at least 1 millisecond")
+		}
+	}
+	channels := []struct{ name, url, events string }{
+		{"Slack", c.Notify.SlackWebhookURL, c.Notify.SlackEvents},
+		{"Discord", c.Notify.DiscordWebhookURL, c.Notify.DiscordEvents},
+	}
+	for _, channel := range channels {
+		if channel.url == "" {
+			continue
+		}
+		if !isHTTPURL(channel.url) {
+			return fmt.Errorf("%s webhook URL must be an absolute http or https URL", channel.name)
+		}
+		events := c.Notify.EventList(channel.events)
+		if len(events) == 0 {
+			return fmt.Errorf("%s notifications require at least one event type", channel.name)
+		}
+		for _, event := range events {
+			if !slices.Contains(NotifyEvents, event) {
+				return fmt.Errorf("unknown %s notification event %q, expected one of %s", channel.name, event, strings.Join(NotifyEvents, ", "))
+			}
+		}
+		if c.Notify.TimeoutMs < 1 {
+			return fmt.Errorf("notification timeout must be at least 1 millisecond")
+		}
+		if c.Notify.Workers < 1 {
+			return fmt.Errorf("notification workers must be at least 1")
+		}
+		if c.Notify.QueueSize < 0 {
+			return fmt.Errorf("notification queue size cannot be negative")
+		}
+		if c.Notify.Attempts < 1 {
+			return fmt.Errorf("notification attempts must be at least 1")
+		}
+	}
+	if c.Sync.Schedule != "" {
+		if err := c.Sync.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Admin.Port != "" && c.Admin.Port == c.GRPC.Port {
+		return fmt.Errorf("admin port must differ from the gRPC port")
+	}
+	return nil
+}
+
+func (c SyncConfig) validate() error {
+	switch c.Source {
+	case SyncSourceCSV:
+		if !isHTTPURL(c.CSVURL) {
+			return fmt.Errorf("the csv sync source requires an absolute http or https SYNC_CSV_URL")
+		}
+	case SyncSourceTMDb:
+		if !isHTTPURL(c.TMDbURL) {
+			return fmt.Errorf("TMDb URL must be an absolute http or https URL")
+		}
+		if c.TMDbToken == "" {
+			return fmt.Errorf("the tmdb sync source requires SYNC_TMDB_TOKEN")
+		}
+		if _, err := url.ParseQuery(c.TMDbQuery); err != nil {
+			return fmt.Errorf("invalid TMDb discover query: %w", err)
+		}
+		if c.TMDbMaxPages < 1 {
+			return fmt.Errorf("TMDb max pages must be at least 1")
+		}
+	default:
+		return fmt.Errorf("sync source must be %q or %q", SyncSourceCSV, SyncSourceTMDb)
+	}
+	switch c.Policy {
+	case "local-wins", "remote-wins", "manual":
+	default:
+		return fmt.Errorf("sync policy must be local-wins, remote-wins or manual")
+	}
+	if c.TimeoutMs < 1 {
+		return fmt.Errorf("sync timeout must be at least 1 millisecond")
+	}
 	return nil
 }
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,46 @@
+package config
+
+import "log/slog"
+
+// LogSummary logs what the configuration enables, so the effective setup of an instance
+// can be read from its first log lines
+func (c *Config) LogSummary(logger *slog.Logger) {
+	logger.Info("Startup configuration",
+		slog.String("environment", c.Debug.Environment),
+		slog.Group("grpc",
+			slog.String("port", c.GRPC.Port),
+			slog.Int("rate_limit_per_second", c.GRPC.RateLimitPerSecond),
+			slog.Int("max_concurrent_streams", c.GRPC.MaxConcurrentStreams),
+			slog.Bool("reflection", c.Debug.Reflection),
+		),
+		slog.Group("persistence",
+			slog.String("database", c.Database.DatabaseName),
+			slog.String("mode", c.Database.PersistenceMode),
+			slog.Bool("events", c.Database.PersistenceMode == PersistenceEvents),
+			slog.Bool("read_model", c.Database.ReadModelSchedule != ""),
+		),
+		slog.Group("jobs",
+			slog.Bool("archive", c.Archive.AfterDays > 0),
+			slog.Int("archive_after_days", c.Archive.AfterDays),
+			slog.Bool("comment_moderation", c.Moderation.Schedule != ""),
+			slog.Bool("catalog_sync", c.Sync.Schedule != ""),
+			slog.Int("workers", c.Jobs.Workers),
+		),
+		slog.Bool("idempotency", c.Idempotency.TTLSeconds > 0),
+		slog.Group("notifications",
+			slog.Bool("slack", c.Notify.SlackWebhookURL != ""),
+			slog.Bool("discord", c.Notify.DiscordWebhookURL != ""),
+			slog.Int("workers", c.Notify.Workers),
+		),
+		slog.Group("admin",
+			slog.String("port", c.Admin.Port),
+			slog.Bool("pprof", c.Admin.Port != "" && c.Debug.Pprof),
+		),
+		slog.Group("logging",
+			slog.String("level", c.Debug.LogLevel),
+			slog.Float64("sample_rate", c.Debug.LogSampleRate),
+			slog.Int("slow_request_ms", c.Debug.LogSlowRequestMs),
+			slog.Bool("payload_logging", c.Debug.PayloadLogging),
+		),
+	)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var ErrInvalidCertification = apperr.New(apperr.Invalid, "INVALID_CERTIFICATION", "invalid certification")
+
+// Certifications is the set of accepted age certifications, e.g. "PG-13"
+type Certifications []string
+
+// DefaultCertifications returns the MPA ratings
+func DefaultCertifications() Certifications {
+	return Certifications{"G", "PG", "PG-13", "R", "NC-17"}
+}
+
+// ParseCertifications parses a comma-separated list of certifications
+func ParseCertifications(list string) Certifications {
+	var certifications Certifications
+	for _, code := range strings.Split(list, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			certifications = append(certifications, code)
+		}
+	}
+	return certifications
+}
+
+// Normalize returns the accepted spelling of code, ignoring case
+func (c Certifications) Normalize(code string) (string, error) {
+	for _, certification := range c {
+		if strings.EqualFold(strings.TrimSpace(code), certification) {
+			return certification, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q is not one of %s", ErrInvalidCertification, code, strings.Join(c, ", "))
+}
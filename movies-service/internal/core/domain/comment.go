@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+const (
+	// MaxCommentLength is the maximum number of characters of a comment body
+	MaxCommentLength = 2000
+	// MaxAuthorLength is the maximum number of characters of an author name
+	MaxAuthorLength = 50
+	// MaxFlagReasonLength is the maximum number of characters of a flag reason
+	MaxFlagReasonLength = 200
+)
+
+var (
+	ErrCommentNotFound = apperr.New(apperr.NotFound, "COMMENT_NOT_FOUND", "comment not found")
+	ErrInvalidComment  = apperr.New(apperr.Invalid, "INVALID_COMMENT", "invalid comment data")
+	// ErrCommentDeleted is returned when replying to or flagging a deleted comment
+	ErrCommentDeleted = apperr.New(apperr.Precondition, "COMMENT_DELETED", "comment was deleted")
+)
+
+// Comment is a message in a discussion thread of a movie. Threads are one level deep:
+// replies to a reply join the thread of its root, keeping ParentID to show what they
+// answer
+type Comment struct {
+	ID       string
+	MovieID  int32
+	ParentID string
+	// ThreadID is the ID of the comment starting the thread, the comment itself for roots
+	ThreadID  string
+	Author    string
+	Body      string
+	CreatedAt time.Time
+	// DeletedAt is set once a moderator deleted the comment
+	DeletedAt   *time.Time
+	FlagCount   int32
+	FlagReasons []string
+	// Status is where the comment is in the moderation pipeline
+	Status ModerationStatus
+	// ModerationReasons explain why the moderation worker queued or rejected the comment
+	ModerationReasons []string
+	// Replies lists the other comments of the thread, oldest first, on listed roots
+	Replies []*Comment
+}
+
+// CommentInput holds the client-provided fields of a comment
+type CommentInput struct {
+	MovieID  int32
+	ParentID string
+	Author   string
+	Body     string
+}
+
+// Deleted reports whether a moderator deleted the comment
+func (c *Comment) Deleted() bool {
+	return c.DeletedAt != nil
+}
+
+// Redacted returns the comment as shown to readers: a deleted comment keeps its place in
+// the thread without its author and body, and reports are only shown to moderators
+func (c *Comment) Redacted() *Comment {
+	redacted := *c
+	redacted.FlagReasons, redacted.ModerationReasons = nil, nil
+	if c.Deleted() {
+		redacted.Author, redacted.Body = "", ""
+	}
+	redacted.Replies = make([]*Comment, len(c.Replies))
+	for i, reply := range c.Replies {
+		redacted.Replies[i] = reply.Redacted()
+	}
+	return &redacted
+}
+
+// NewComment validates the input of a new comment; the thread is set by the service
+func NewComment(input CommentInput, now time.Time) (*Comment, error) {
+	if input.MovieID <= 0 {
+		return nil, NewFieldError("movie_id", errors.New("movie_id must be positive"))
+	}
+
+	author := strings.TrimSpace(input.Author)
+	if author == "" || utf8.RuneCountInString(author) > MaxAuthorLength {
+		return nil, NewFieldError("author", errors.New("author must have between 1 and 50 characters"))
+	}
+
+	body := strings.TrimSpace(input.Body)
+	if body == "" || utf8.RuneCountInString(body) > MaxCommentLength {
+		return nil, NewFieldError("body", errors.New("body must have between 1 and 2000 characters"))
+	}
+
+	return &Comment{
+		MovieID:   input.MovieID,
+		ParentID:  strings.TrimSpace(input.ParentID),
+		Author:    author,
+		Body:      body,
+		CreatedAt: now.UTC(),
+	}, nil
+}
+
+// NormalizeFlagReason trims the reason given when flagging a comment
+func NormalizeFlagReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxFlagReasonLength {
+		return "", NewFieldError("reason", errors.New("reason must have between 1 and 200 characters"))
+	}
+	return reason, nil
+}
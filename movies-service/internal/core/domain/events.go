@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+// ErrHistoryUnavailable is returned when movie history is requested from a store that
+// only keeps the current state of movies
+var ErrHistoryUnavailable = apperr.New(apperr.Unimplemented, "HISTORY_UNAVAILABLE", "movie history is only available with event-sourced persistence")
+
+type MovieEventType string
+
+const (
+	MovieCreated  MovieEventType = "created"
+	MovieReplaced MovieEventType = "replaced"
+	MovieDeleted  MovieEventType = "deleted"
+)
+
+// MovieEvent is one entry of the append-only stream of changes of a movie
+type MovieEvent struct {
+	MovieID int32 `bson:"movie_id"`
+	// Sequence is the position of the event in the stream of the movie, starting at 1. It
+	// keeps growing when a deleted movie is created again, unlike the movie version.
+	Sequence int64          `bson:"sequence"`
+	Type     MovieEventType `bson:"type"`
+	// Version is the version of the movie after the event
+	Version    int64        `bson:"version"`
+	Changes    MovieChanges `bson:"changes"`
+	OccurredAt time.Time    `bson:"occurred_at"`
+}
+
+// MovieChanges holds the fields set by an event; nil fields are left unchanged
+type MovieChanges struct {
+	Title          *string   `bson:"title,omitempty"`
+	Year           *string   `bson:"year,omitempty"`
+	Regions        *[]string `bson:"regions,omitempty"`
+	Awards         *[]string `bson:"awards,omitempty"`
+	Certification  *string   `bson:"certification,omitempty"`
+	IMDbID         *string   `bson:"imdb_id,omitempty"`
+	TMDbID         *string   `bson:"tmdb_id,omitempty"`
+	Genres         *[]string `bson:"genres,omitempty"`
+	Director       *string   `bson:"director,omitempty"`
+	Synopsis       *string   `bson:"synopsis,omitempty"`
+	RuntimeMinutes *int32    `bson:"runtime_minutes,omitempty"`
+}
+
+// DiffMovies returns the changes turning before into after; a nil before means every
+// field of after is set
+func DiffMovies(before, after *Movie) MovieChanges {
+	if before == nil {
+		before = &Movie{}
+	}
+
+	var changes MovieChanges
+	if after.Title != before.Title {
+		changes.Title = &after.Title
+	}
+	if after.Year != before.Year {
+		changes.Year = &after.Year
+	}
+	if !slices.Equal(after.Regions, before.Regions) {
+		regions := slices.Clone(after.Regions)
+		changes.Regions = &regions
+	}
+	if !slices.Equal(after.Awards, before.Awards) {
+		awards := slices.Clone(after.Awards)
+		changes.Awards = &awards
+	}
+	if after.Certification != before.Certification {
+		changes.Certification = &after.Certification
+	}
+	if after.IMDbID != before.IMDbID {
+		changes.IMDbID = &after.IMDbID
+	}
+	if after.TMDbID != before.TMDbID {
+		changes.TMDbID = &after.TMDbID
+	}
+	if !slices.Equal(after.Genres, before.Genres) {
+		genres := slices.Clone(after.Genres)
+		changes.Genres = &genres
+	}
+	if after.Director != before.Director {
+		changes.Director = &after.Director
+	}
+	if after.Synopsis != before.Synopsis {
+		changes.Synopsis = &after.Synopsis
+	}
+	if after.RuntimeMinutes != before.RuntimeMinutes {
+		changes.RuntimeMinutes = &after.RuntimeMinutes
+	}
+	return changes
+}
+
+// Fields lists the names of the fields set by the changes
+func (c MovieChanges) Fields() []string {
+	var fields []string
+	if c.Title != nil {
+		fields = append(fields, "title")
+	}
+	if c.Year != nil {
+		fields = append(fields, "year")
+	}
+	if c.Regions != nil {
+		fields = append(fields, "regions")
+	}
+	if c.Awards != nil {
+		fields = append(fields, "awards")
+	}
+	if c.Certification != nil {
+		fields = append(fields, "certification")
+	}
+	if c.IMDbID != nil {
+		fields = append(fields, "imdb_id")
+	}
+	if c.TMDbID != nil {
+		fields = append(fields, "tmdb_id")
+	}
+	if c.Genres != nil {
+		fields = append(fields, "genres")
+	}
+	if c.Director != nil {
+		fields = append(fields, "director")
+	}
+	if c.Synopsis != nil {
+		fields = append(fields, "synopsis")
+	}
+	if c.RuntimeMinutes != nil {
+		fields = append(fields, "runtime_minutes")
+	}
+	return fields
+}
+
+// Apply returns the state of the movie after the event; state is nil when the movie does
+// not exist, and so is the result after a deletion
+func (e MovieEvent) Apply(state *Movie) (*Movie, error) {
+	switch e.Type {
+	case MovieCreated:
+		if state != nil {
+			return nil, fmt.Errorf("event %d of movie %d creates an existing movie", e.Sequence, e.MovieID)
+		}
+		return e.Changes.applyTo(&Movie{ID: e.MovieID, Version: e.Version}), nil
+	case MovieReplaced:
+		if state == nil {
+			return nil, fmt.Errorf("event %d of movie %d replaces a missing movie", e.Sequence, e.MovieID)
+		}
+		movie := e.Changes.applyTo(state)
+		movie.Version = e.Version
+		return movie, nil
+	case MovieDeleted:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("event %d of movie %d has unknown type %q", e.Sequence, e.MovieID, e.Type)
+}
+
+func (c MovieChanges) applyTo(state *Movie) *Movie {
+	movie := *state
+	if c.Title != nil {
+		movie.Title = *c.Title
+	}
+	if c.Year != nil {
+		movie.Year = *c.Year
+	}
+	if c.Regions != nil {
+		movie.Regions = slices.Clone(*c.Regions)
+	}
+	if c.Awards != nil {
+		movie.Awards = slices.Clone(*c.Awards)
+	}
+	if c.Certification != nil {
+		movie.Certification = *c.Certification
+	}
+	if c.IMDbID != nil {
+		movie.IMDbID = *c.IMDbID
+	}
+	if c.TMDbID != nil {
+		movie.TMDbID = *c.TMDbID
+	}
+	if c.Genres != nil {
+		movie.Genres = slices.Clone(*c.Genres)
+	}
+	if c.Director != nil {
+		movie.Director = *c.Director
+	}
+	if c.Synopsis != nil {
+		movie.Synopsis = *c.Synopsis
+	}
+	if c.RuntimeMinutes != nil {
+		movie.RuntimeMinutes = *c.RuntimeMinutes
+	}
+	return &movie
+}
+
+// ReplayMovieEvents applies the events in order to state, returning the resulting movie
+func ReplayMovieEvents(state *Movie, events []MovieEvent) (*Movie, error) {
+	for _, event := range events {
+		var err error
+		if state, err = event.Apply(state); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// MovieRevision is an event of the history of a movie with the state it produced
+type MovieRevision struct {
+	Event MovieEvent
+	// Movie is the state after the event, nil after a deletion
+	Movie *Movie
+}
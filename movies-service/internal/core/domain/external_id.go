@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrInvalidExternalID = apperr.New(apperr.Invalid, "INVALID_EXTERNAL_ID", "invalid external ID")
+	// ErrExternalIDTaken is returned when an external ID is already held by another movie
+	ErrExternalIDTaken = apperr.New(apperr.Conflict, "EXTERNAL_ID_TAKEN", "external ID already belongs to another movie")
+)
+
+// External catalogs whose identifiers are stored on movies
+const (
+	SourceIMDb = "imdb"
+	SourceTMDb = "tmdb"
+)
+
+// ExternalSources lists the external catalogs in the order their IDs are checked
+var ExternalSources = []string{SourceIMDb, SourceTMDb}
+
+// NormalizeExternalID validates the identifier of a movie in an external catalog: IMDb
+// IDs are "tt" followed by at least 7 digits and TMDb IDs are positive numbers
+func NormalizeExternalID(source, id string) (string, error) {
+	id = strings.TrimSpace(id)
+	switch source {
+	case SourceIMDb:
+		id = strings.ToLower(id)
+		digits, ok := strings.CutPrefix(id, "tt")
+		if !ok || len(digits) < 7 || len(digits) > 10 || !isDigits(digits) {
+			return "", fmt.Errorf("%w: %q is not an IMDb ID such as tt0113277", ErrInvalidExternalID, id)
+		}
+	case SourceTMDb:
+		if len(id) > 10 || !isDigits(id) || id[0] == '0' {
+			return "", fmt.Errorf("%w: %q is not a TMDb ID such as 949", ErrInvalidExternalID, id)
+		}
+	default:
+		return "", fmt.Errorf("%w: unknown source %q, expected %q or %q", ErrInvalidExternalID, source, SourceIMDb, SourceTMDb)
+	}
+	return id, nil
+}
+
+// ExternalID returns the identifier of the movie in the external catalog, empty when it
+// has none
+func (m *Movie) ExternalID(source string) string {
+	switch source {
+	case SourceIMDb:
+		return m.IMDbID
+	case SourceTMDb:
+		return m.TMDbID
+	}
+	return ""
+}
+
+// setExternalID validates and stores the identifier of the movie in the external
+// catalog; an empty id leaves the movie without one
+func (m *Movie) setExternalID(source, id string) error {
+	if id != "" {
+		var err error
+		if id, err = NormalizeExternalID(source, id); err != nil {
+			return NewFieldError(source+"_id", err)
+		}
+	}
+	switch source {
+	case SourceIMDb:
+		m.IMDbID = id
+	case SourceTMDb:
+		m.TMDbID = id
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,21 @@
+package domain
+
+// FieldError reports which client-provided field failed validation; errors.Is matches
+// the wrapped error, so callers can keep checking for ErrInvalidYear and the like
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// NewFieldError attributes err to the named field, using the field names of the API
+func NewFieldError(field string, err error) error {
+	return &FieldError{Field: field, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
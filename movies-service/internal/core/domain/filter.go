@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var ErrInvalidFilter = apperr.New(apperr.Invalid, "INVALID_FILTER", "invalid filter")
+
+type FilterOperator string
+
+const (
+	OpEq       FilterOperator = "="
+	OpNe       FilterOperator = "!="
+	OpGt       FilterOperator = ">"
+	OpGte      FilterOperator = ">="
+	OpLt       FilterOperator = "<"
+	OpLte      FilterOperator = "<="
+	OpContains FilterOperator = "~"
+)
+
+// FilterExpr is a node of a filter expression. Exactly one of Condition, And or Or is set.
+type FilterExpr struct {
+	Condition *FilterCondition
+	And       []*FilterExpr
+	Or        []*FilterExpr
+}
+
+type FilterCondition struct {
+	Field string
+	Op    FilterOperator
+	Value string
+}
+
+// filterableFields lists the movie fields that can be used in filters
+var filterableFields = map[string]bool{
+	"id":    true,
+	"title": true,
+	"year":  true,
+}
+
+// Validate checks that the expression only references filterable fields with values of the right type
+func (e *FilterExpr) Validate() error {
+	switch {
+	case e.Condition != nil:
+		return e.Condition.Validate()
+	case len(e.And) > 0:
+		return validateFilterGroup(e.And)
+	case len(e.Or) > 0:
+		return validateFilterGroup(e.Or)
+	}
+	return fmt.Errorf("%w: empty expression", ErrInvalidFilter)
+}
+
+func validateFilterGroup(exprs []*FilterExpr) error {
+	for _, expr := range exprs {
+		if expr == nil {
+			return fmt.Errorf("%w: empty expression", ErrInvalidFilter)
+		}
+		if err := expr.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a single comparison
+func (c *FilterCondition) Validate() error {
+	if !filterableFields[c.Field] {
+		return fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, c.Field)
+	}
+
+	switch c.Op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+	case OpContains:
+		if c.Field != "title" {
+			return fmt.Errorf("%w: operator ~ is only supported on text fields", ErrInvalidFilter)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operator %q", ErrInvalidFilter, c.Op)
+	}
+
+	switch c.Field {
+	case "id":
+		if _, err := strconv.ParseInt(c.Value, 10, 32); err != nil {
+			return fmt.Errorf("%w: id must be an integer", ErrInvalidFilter)
+		}
+	case "year":
+		// Years are stored as 4-digit strings, which keeps range comparisons correct
+		if _, err := strconv.Atoi(c.Value); err != nil || len(c.Value) != 4 {
+			return fmt.Errorf("%w: year must be a 4-digit number", ErrInvalidFilter)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrImportNotFound = apperr.New(apperr.NotFound, "IMPORT_NOT_FOUND", "import not found")
+	// ErrInvalidImport is returned for imports without rows or with more than MaxImportRows
+	ErrInvalidImport = apperr.New(apperr.Invalid, "INVALID_IMPORT", "invalid import")
+)
+
+// MaxImportRows bounds the rows of an import, so the job holding them and its report fit
+// in a document
+const MaxImportRows = 10000
+
+// ImportRowStatus is the outcome of one row of an import
+type ImportRowStatus string
+
+const (
+	RowCreated ImportRowStatus = "created"
+	RowUpdated ImportRowStatus = "updated"
+	// RowInvalid marks rows rejected by validation
+	RowInvalid ImportRowStatus = "invalid"
+	// RowFailed marks valid rows that could not be stored, such as on a conflict with
+	// another movie
+	RowFailed ImportRowStatus = "failed"
+)
+
+// ImportRow is a movie to import. Rows with an ID create or replace the movie with that
+// ID; the others are created with the next free ID
+type ImportRow struct {
+	ID    int32      `json:"id,omitempty"`
+	Input MovieInput `json:"movie"`
+}
+
+// ImportParams are the parameters of import jobs
+type ImportParams struct {
+	Rows []ImportRow `json:"rows"`
+}
+
+// ImportRowResult is the outcome of a row, numbered from 1 in the order of the import
+type ImportRowResult struct {
+	Row     int32           `json:"row"`
+	Status  ImportRowStatus `json:"status"`
+	MovieID int32           `json:"movie_id,omitempty"`
+	// Field is the rejected field of invalid rows, when validation named one
+	Field string `json:"field,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportReport is the result of import jobs
+type ImportReport struct {
+	Results []ImportRowResult `json:"results"`
+}
+
+// Import is an import job with the results of the rows processed so far
+type Import struct {
+	ID      string
+	Status  JobStatus
+	Total   int32
+	Results []ImportRowResult
+	// Error tells why a failed import stopped
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// ImportFromJob reads the import run by a job, returning ErrImportNotFound for jobs of
+// other types
+func ImportFromJob(job *Job) (*Import, error) {
+	if job.Type != JobImport {
+		return nil, ErrImportNotFound
+	}
+	imp := &Import{
+		ID:         job.ID,
+		Status:     job.Status,
+		Total:      job.Total,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if job.Status == JobPending {
+		// Jobs report their total once they run, so it is counted from the rows meanwhile
+		var params ImportParams
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid rows of import %s: %w", job.ID, err)
+		}
+		imp.Total = int32(len(params.Rows))
+	}
+	if len(job.Result) > 0 {
+		var report ImportReport
+		if err := json.Unmarshal(job.Result, &report); err != nil {
+			return nil, fmt.Errorf("invalid report of import %s: %w", job.ID, err)
+		}
+		imp.Results = report.Results
+	}
+	return imp, nil
+}
+
+// Count returns the number of rows processed with the given outcome
+func (i *Import) Count(status ImportRowStatus) int32 {
+	var n int32
+	for _, result := range i.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
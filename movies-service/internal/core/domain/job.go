@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrJobNotFound = apperr.New(apperr.NotFound, "JOB_NOT_FOUND", "job not found")
+	// ErrInvalidJob is returned for jobs of an unknown type or with invalid parameters
+	ErrInvalidJob = apperr.New(apperr.Invalid, "INVALID_JOB", "invalid job")
+	// ErrNoJobFile is returned for the file of a job that produced none, or not yet
+	ErrNoJobFile = apperr.New(apperr.Precondition, "NO_JOB_FILE", "job has no file")
+)
+
+// JobType names what a job does; each type has its own parameters and result
+type JobType string
+
+const (
+	JobImport  JobType = "import"
+	JobExport  JobType = "export"
+	JobArchive JobType = "archive"
+)
+
+// JobStatus is the progress of a job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	// JobFailed marks jobs that returned an error or were stopped, such as by a shutdown
+	JobFailed JobStatus = "failed"
+)
+
+// JobPriority orders the pending jobs: higher priorities are claimed first, and jobs of
+// the same priority in the order they were queued
+type JobPriority string
+
+const (
+	// JobPriorityHigh is meant for work a user waits on
+	JobPriorityHigh   JobPriority = "high"
+	JobPriorityNormal JobPriority = "normal"
+	// JobPriorityLow is meant for bulk maintenance, run when no other job is pending
+	JobPriorityLow JobPriority = "low"
+)
+
+// DefaultJobPriority is the priority of the jobs of a type queued without one: archiving
+// is maintenance, the other types run for a user
+func DefaultJobPriority(jobType JobType) JobPriority {
+	if jobType == JobArchive {
+		return JobPriorityLow
+	}
+	return JobPriorityNormal
+}
+
+// Valid reports whether p is one of the priorities
+func (p JobPriority) Valid() bool {
+	return p == JobPriorityHigh || p == JobPriorityNormal || p == JobPriorityLow
+}
+
+// Rank orders the priorities, from -1 for low to 1 for high
+func (p JobPriority) Rank() int32 {
+	switch p {
+	case JobPriorityHigh:
+		return 1
+	case JobPriorityLow:
+		return -1
+	}
+	return 0
+}
+
+// JobPriorityOfRank returns the priority of a rank, normal for unknown ranks
+func JobPriorityOfRank(rank int32) JobPriority {
+	switch {
+	case rank > 0:
+		return JobPriorityHigh
+	case rank < 0:
+		return JobPriorityLow
+	}
+	return JobPriorityNormal
+}
+
+// Done reports whether the job stopped running
+func (s JobStatus) Done() bool {
+	return s == JobCompleted || s == JobFailed
+}
+
+// Job is a long-running operation queued for the workers of any replica
+type Job struct {
+	ID       string
+	Type     JobType
+	Status   JobStatus
+	Priority JobPriority
+	// Params are the JSON parameters of the job, specific to its type
+	Params json.RawMessage
+	// Done and Total measure the progress of the job; Total is zero while unknown
+	Done  int32
+	Total int32
+	// Result is the JSON outcome of the job, specific to its type. Running jobs may have
+	// a partial result
+	Result json.RawMessage
+	// File is set once the job stored a file to download, such as the backup of an export
+	File  bool
+	Error string
+	// Owner is the worker running the job, which keeps it until LeaseUntil unless it
+	// renews the lease
+	Owner      string
+	LeaseUntil time.Time
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Copy returns a deep copy of the job
+func (j *Job) Copy() *Job {
+	c := *j
+	c.Params = append(json.RawMessage(nil), j.Params...)
+	c.Result = append(json.RawMessage(nil), j.Result...)
+	return &c
+}
+
+// JobFile is the file of a job, read from an offset
+type JobFile struct {
+	io.ReadCloser
+	// Size is the length of the whole file
+	Size int64
+}
+
+// JobFileOffset resolves the offset a job file of size is read from: negative offsets
+// count from the end, and offsets past either end are clamped to the file
+func JobFileOffset(offset, size int64) int64 {
+	if offset < 0 {
+		offset += size
+	}
+	return min(max(offset, 0), size)
+}
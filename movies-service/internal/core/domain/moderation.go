@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+// ModerationStatus is where a comment is in the moderation pipeline
+type ModerationStatus string
+
+const (
+	// ModerationPending comments wait for the moderation worker
+	ModerationPending ModerationStatus = "pending"
+	// ModerationApproved comments are shown to readers
+	ModerationApproved ModerationStatus = "approved"
+	// ModerationReview comments wait in the queue for a moderator's decision
+	ModerationReview ModerationStatus = "review"
+	// ModerationRejected comments are never shown
+	ModerationRejected ModerationStatus = "rejected"
+)
+
+var (
+	// ErrNotAwaitingModeration is returned when deciding on a comment that already left
+	// the moderation queue
+	ErrNotAwaitingModeration = apperr.New(apperr.Precondition, "NOT_AWAITING_MODERATION", "comment is not awaiting moderation")
+	ErrInvalidDecision       = apperr.New(apperr.Invalid, "INVALID_DECISION", "invalid moderation decision")
+)
+
+// Visible reports whether readers see comments in the status. Comments stored before
+// moderation existed have no status and are visible
+func (s ModerationStatus) Visible() bool {
+	return s == "" || s == ModerationApproved
+}
+
+// ParseModerationDecision reads the decision of a moderator on a queued comment,
+// "approve" or "reject", as the status it moves the comment to
+func ParseModerationDecision(decision string) (ModerationStatus, error) {
+	switch decision {
+	case "approve":
+		return ModerationApproved, nil
+	case "reject":
+		return ModerationRejected, nil
+	}
+	return "", NewFieldError("decision", fmt.Errorf("%w %q, want \"approve\" or \"reject\"", ErrInvalidDecision, decision))
+}
+
+// ModerationVerdict is the outcome of checking a text, ordered by severity
+type ModerationVerdict int
+
+const (
+	VerdictApprove ModerationVerdict = iota
+	VerdictReview
+	VerdictReject
+)
+
+// Status returns the status of a comment given the verdict
+func (v ModerationVerdict) Status() ModerationStatus {
+	switch v {
+	case VerdictReject:
+		return ModerationRejected
+	case VerdictReview:
+		return ModerationReview
+	}
+	return ModerationApproved
+}
+
+// ModerationResult is the verdict of a filter on a text with the reasons for it
+type ModerationResult struct {
+	Verdict ModerationVerdict
+	Reasons []string
+}
+
+// Merge combines the results of two filters, keeping the most severe verdict and every reason
+func (r ModerationResult) Merge(other ModerationResult) ModerationResult {
+	merged := ModerationResult{Verdict: max(r.Verdict, other.Verdict)}
+	merged.Reasons = append(append(merged.Reasons, r.Reasons...), other.Reasons...)
+	return merged
+}
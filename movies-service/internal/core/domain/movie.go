@@ -2,61 +2,153 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/movie-microservice/proto/apperr"
 )
 
 var (
-	ErrMovieNotFound     = errors.New("movie not found")
-	ErrInvalidMovieData  = errors.New("invalid movie data")
-	ErrMovieAlreadyExists = errors.New("movie already exists")
-	ErrInvalidYear       = errors.New("invalid year format")
+	ErrMovieNotFound     = apperr.New(apperr.NotFound, "MOVIE_NOT_FOUND", "movie not found")
+	ErrInvalidMovieData  = apperr.New(apperr.Invalid, "INVALID_MOVIE_DATA", "invalid movie data")
+	ErrMovieAlreadyExists = apperr.New(apperr.Conflict, "MOVIE_ALREADY_EXISTS", "movie already exists")
+	ErrInvalidYear       = apperr.New(apperr.Invalid, "INVALID_YEAR", "invalid year format")
+	ErrVersionMismatch   = apperr.New(apperr.Precondition, "VERSION_MISMATCH", "movie version mismatch")
+	// ErrStorageUnavailable is returned when the database cannot be reached in time
+	ErrStorageUnavailable = apperr.New(apperr.Unavailable, "STORAGE_UNAVAILABLE", "movie storage unavailable")
 )
 
 type Movie struct {
-	ID    int32  `json:"id" bson:"_id"`
-	Title string `json:"title" bson:"title"`
-	Year  string `json:"year" bson:"year"`
+	ID      int32  `json:"id" bson:"_id"`
+	Title   string `json:"title" bson:"title"`
+	Year    string `json:"year" bson:"year"`
+	Version int64  `json:"version" bson:"version"`
+	// Regions lists the markets where the movie is available; empty means everywhere
+	Regions []string `json:"regions,omitempty" bson:"regions,omitempty"`
+	// Awards lists the awards the movie received, e.g. "Oscar for Best Picture"
+	Awards        []string `json:"awards,omitempty" bson:"awards,omitempty"`
+	Certification string   `json:"certification,omitempty" bson:"certification,omitempty"`
+	// IMDbID and TMDbID identify the movie in external catalogs; each is unique among movies
+	IMDbID string `json:"imdb_id,omitempty" bson:"imdb_id,omitempty"`
+	TMDbID string `json:"tmdb_id,omitempty" bson:"tmdb_id,omitempty"`
+	// Genres lists the genres of the movie, e.g. "Crime" and "Drama"
+	Genres   []string `json:"genres,omitempty" bson:"genres,omitempty"`
+	Director string   `json:"director,omitempty" bson:"director,omitempty"`
+	Synopsis string   `json:"synopsis,omitempty" bson:"synopsis,omitempty"`
+	// RuntimeMinutes is the running time of the movie; 0 means unknown
+	RuntimeMinutes int32 `json:"runtime_minutes,omitempty" bson:"runtime_minutes,omitempty"`
+	// Archived is set when the movie was read from the archive of rarely accessed movies
+	Archived bool `json:"-" bson:"-"`
+}
+
+// MovieInput holds the client-provided fields of a movie, named as in the API when it is
+// stored with the parameters of a job
+type MovieInput struct {
+	Title          string   `json:"title"`
+	Year           string   `json:"year"`
+	Regions        []string `json:"regions,omitempty"`
+	Awards         []string `json:"awards,omitempty"`
+	Certification  string   `json:"certification,omitempty"`
+	IMDbID         string   `json:"imdb_id,omitempty"`
+	TMDbID         string   `json:"tmdb_id,omitempty"`
+	Genres         []string `json:"genres,omitempty"`
+	Director       string   `json:"director,omitempty"`
+	Synopsis       string   `json:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty"`
 }
 
 type MovieFilter struct {
 	Page  int32
 	Limit int32
+	Expr  *FilterExpr
+	// Region restricts results to movies available in the region
+	Region        string
+	Certification string
+	// SkipCount leaves the total of matching movies uncounted, reported as UncountedTotal
+	SkipCount bool
 }
 
 // NewMovie creates a new movie with validation
 func NewMovie(id int32, title, year string) (*Movie, error) {
 	if title == "" {
-		return nil, errors.New("title cannot be empty")
+		return nil, NewFieldError("title", errors.New("title cannot be empty"))
 	}
 	
 	if year == "" {
-		return nil, errors.New("year cannot be empty")
+		return nil, NewFieldError("year", errors.New("year cannot be empty"))
 	}
 
 	// Validate year format (should be 4 digits)
 	if len(year) != 4 {
-		return nil, ErrInvalidYear
+		return nil, NewFieldError("year", ErrInvalidYear)
 	}
 
 	if _, err := strconv.Atoi(year); err != nil {
-		return nil, ErrInvalidYear
+		return nil, NewFieldError("year", ErrInvalidYear)
 	}
 
 	// Validate year range (1800 to current year + 10)
 	currentYear := time.Now().Year()
 	yearInt, _ := strconv.Atoi(year)
 	if yearInt < 1800 || yearInt > currentYear+10 {
-		return nil, errors.New("year must be between 1800 and current year + 10")
+		return nil, NewFieldError("year", errors.New("year must be between 1800 and current year + 10"))
 	}
 
 	return &Movie{
-		ID:    id,
-		Title: title,
-		Year:  year,
+		ID:      id,
+		Title:   title,
+		Year:    year,
+		Version: 1,
 	}, nil
 }
 
+// NewMovieFromInput creates a new movie from client input with validation
+func NewMovieFromInput(id int32, input MovieInput) (*Movie, error) {
+	movie, err := NewMovie(id, input.Title, input.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	if movie.Regions, err = NormalizeRegions(input.Regions); err != nil {
+		return nil, NewFieldError("regions", err)
+	}
+
+	if movie.Awards, err = normalizeAwards(input.Awards); err != nil {
+		return nil, NewFieldError("awards", err)
+	}
+
+	// The certification itself is checked against the configured list by the service
+	movie.Certification = strings.TrimSpace(input.Certification)
+
+	if err := movie.setExternalID(SourceIMDb, input.IMDbID); err != nil {
+		return nil, err
+	}
+	if err := movie.setExternalID(SourceTMDb, input.TMDbID); err != nil {
+		return nil, err
+	}
+
+	if movie.Genres, err = normalizeGenres(input.Genres); err != nil {
+		return nil, NewFieldError("genres", err)
+	}
+
+	if movie.Director = strings.TrimSpace(input.Director); len(movie.Director) > maxDirectorLength {
+		return nil, NewFieldError("director", fmt.Errorf("director must have at most %d characters", maxDirectorLength))
+	}
+
+	if movie.Synopsis = strings.TrimSpace(input.Synopsis); len(movie.Synopsis) > maxSynopsisLength {
+		return nil, NewFieldError("synopsis", fmt.Errorf("synopsis must have at most %d characters", maxSynopsisLength))
+	}
+
+	if input.RuntimeMinutes < 0 || input.RuntimeMinutes > maxRuntimeMinutes {
+		return nil, NewFieldError("runtime_minutes", fmt.Errorf("runtime must be between 0 and %d minutes", maxRuntimeMinutes))
+	}
+	movie.RuntimeMinutes = input.RuntimeMinutes
+
+	return movie, nil
+}
+
 // Validate validates movie data
 func (m *Movie) Validate() error {
 	if m.Title == "" {
@@ -105,8 +197,72 @@ func (m *Movie) IsEqual(other *Movie) bool {
 // Copy creates a copy of the movie
 func (m *Movie) Copy() *Movie {
 	return &Movie{
-		ID:    m.ID,
-		Title: m.Title,
-		Year:  m.Year,
+		ID:             m.ID,
+		Title:          m.Title,
+		Year:           m.Year,
+		Version:        m.Version,
+		Regions:        append([]string(nil), m.Regions...),
+		Awards:         append([]string(nil), m.Awards...),
+		Certification:  m.Certification,
+		IMDbID:         m.IMDbID,
+		TMDbID:         m.TMDbID,
+		Genres:         append([]string(nil), m.Genres...),
+		Director:       m.Director,
+		Synopsis:       m.Synopsis,
+		RuntimeMinutes: m.RuntimeMinutes,
+		Archived:       m.Archived,
+	}
+}
+
+const maxAwardLength = 200
+
+// normalizeAwards trims award names and rejects empty or overly long ones
+func normalizeAwards(awards []string) ([]string, error) {
+	if len(awards) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, len(awards))
+	for i, award := range awards {
+		award = strings.TrimSpace(award)
+		if award == "" || len(award) > maxAwardLength {
+			return nil, fmt.Errorf("award names must have between 1 and %d characters", maxAwardLength)
+		}
+		normalized[i] = award
+	}
+	return normalized, nil
+}
+
+const (
+	maxGenres         = 10
+	maxGenreLength    = 50
+	maxDirectorLength = 200
+	maxSynopsisLength = 2000
+	// maxRuntimeMinutes is a day, longer than any movie released in theaters
+	maxRuntimeMinutes = 24 * 60
+)
+
+// normalizeGenres trims genre names, dropping repeated ones regardless of case, and
+// rejects empty or overly long ones
+func normalizeGenres(genres []string) ([]string, error) {
+	if len(genres) == 0 {
+		return nil, nil
+	}
+	if len(genres) > maxGenres {
+		return nil, fmt.Errorf("a movie can have at most %d genres", maxGenres)
 	}
-}
\ No newline at end of file
+
+	normalized := make([]string, 0, len(genres))
+	seen := make(map[string]bool, len(genres))
+	for _, genre := range genres {
+		genre = strings.TrimSpace(genre)
+		if genre == "" || len(genre) > maxGenreLength {
+			return nil, fmt.Errorf("genre names must have between 1 and %d characters", maxGenreLength)
+		}
+		if key := strings.ToLower(genre); !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, genre)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MoviePaths are the fields of MovieInput a patch can change, named as in the API
+var MoviePaths = []string{"title", "year", "regions", "awards", "certification", "imdb_id", "tmdb_id", "genres", "director", "synopsis", "runtime_minutes"}
+
+// MoviePatch changes some fields of a movie: those named in Paths take their values from
+// Input, and the others keep theirs
+type MoviePatch struct {
+	Input MovieInput
+	Paths []string
+	// ExpectedVersion, when set, is the version the movie must have to be patched
+	ExpectedVersion *int64
+}
+
+// Input returns the client-provided fields of the movie
+func (m *Movie) Input() MovieInput {
+	return MovieInput{
+		Title:          m.Title,
+		Year:           m.Year,
+		Regions:        append([]string(nil), m.Regions...),
+		Awards:         append([]string(nil), m.Awards...),
+		Certification:  m.Certification,
+		IMDbID:         m.IMDbID,
+		TMDbID:         m.TMDbID,
+		Genres:         append([]string(nil), m.Genres...),
+		Director:       m.Director,
+		Synopsis:       m.Synopsis,
+		RuntimeMinutes: m.RuntimeMinutes,
+	}
+}
+
+// Apply returns input with the fields named in the patch replaced. The result is
+// validated as a whole when the movie is rebuilt from it
+func (p MoviePatch) Apply(input MovieInput) (MovieInput, error) {
+	if len(p.Paths) == 0 {
+		return input, NewFieldError("update_mask", errors.New("update mask must name at least one field"))
+	}
+	for _, path := range p.Paths {
+		switch path {
+		case "title":
+			input.Title = p.Input.Title
+		case "year":
+			input.Year = p.Input.Year
+		case "regions":
+			input.Regions = p.Input.Regions
+		case "awards":
+			input.Awards = p.Input.Awards
+		case "certification":
+			input.Certification = p.Input.Certification
+		case "imdb_id":
+			input.IMDbID = p.Input.IMDbID
+		case "tmdb_id":
+			input.TMDbID = p.Input.TMDbID
+		case "genres":
+			input.Genres = p.Input.Genres
+		case "director":
+			input.Director = p.Input.Director
+		case "synopsis":
+			input.Synopsis = p.Input.Synopsis
+		case "runtime_minutes":
+			input.RuntimeMinutes = p.Input.RuntimeMinutes
+		default:
+			return input, NewFieldError("update_mask", fmt.Errorf("unknown field %q, use one of %v", path, MoviePaths))
+		}
+	}
+	return input, nil
+}
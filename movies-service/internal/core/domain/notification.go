@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// CatalogEvent is a change of the catalog announced to the notification channels
+type CatalogEvent struct {
+	Type MovieEventType
+	// Movie is the state after the change, or the last state before a deletion; only its
+	// ID is set when the deleted movie could not be read
+	Movie      *Movie
+	OccurredAt time.Time
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "github.com/movie-microservice/proto/paging"
+
+var ErrPageOutOfRange = paging.ErrPageOutOfRange
+
+// UncountedTotal is the total of listings whose matching movies were not counted
+const UncountedTotal = -1
+
+// Pagination holds the paging defaults and limits applied to listings, normalized by the
+// paging package shared with the gateway
+type Pagination = paging.Pagination
+
+// DefaultPagination returns the limits used when none are configured
+func DefaultPagination() Pagination {
+	return paging.Default()
+}
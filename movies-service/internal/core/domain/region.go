@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var ErrInvalidRegion = apperr.New(apperr.Invalid, "INVALID_REGION", "invalid region")
+
+// NormalizeRegion validates an ISO 3166-1 alpha-2 region code and returns it in upper case
+func NormalizeRegion(code string) (string, error) {
+	region := strings.ToUpper(strings.TrimSpace(code))
+	if len(region) != 2 || region[0] < 'A' || region[0] > 'Z' || region[1] < 'A' || region[1] > 'Z' {
+		return "", fmt.Errorf("%w: %q is not a 2-letter country code", ErrInvalidRegion, code)
+	}
+	return region, nil
+}
+
+// NormalizeRegions validates region codes, removing duplicates and sorting them
+func NormalizeRegions(codes []string) ([]string, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(codes))
+	regions := make([]string, 0, len(codes))
+	for _, code := range codes {
+		region, err := NormalizeRegion(code)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[region] {
+			seen[region] = true
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/movie-microservice/proto/apperr"
+)
+
+var (
+	ErrSyncConflictNotFound = apperr.New(apperr.NotFound, "SYNC_CONFLICT_NOT_FOUND", "sync conflict not found")
+	ErrInvalidResolution    = apperr.New(apperr.Invalid, "INVALID_RESOLUTION", "invalid sync conflict resolution")
+)
+
+// SyncPolicy decides what a sync does with a movie changed both locally and in the feed
+type SyncPolicy string
+
+const (
+	// SyncLocalWins keeps the local movie and skips the remote change
+	SyncLocalWins SyncPolicy = "local-wins"
+	// SyncRemoteWins overwrites the local movie with the remote one
+	SyncRemoteWins SyncPolicy = "remote-wins"
+	// SyncManual queues the conflict for a moderator
+	SyncManual SyncPolicy = "manual"
+)
+
+// SyncResolution is the decision taken on a queued sync conflict
+type SyncResolution string
+
+const (
+	ResolutionKeepLocal  SyncResolution = "keep_local"
+	ResolutionTakeRemote SyncResolution = "take_remote"
+)
+
+// ParseSyncResolution validates the resolution of a sync conflict
+func ParseSyncResolution(resolution string) (SyncResolution, error) {
+	switch r := SyncResolution(resolution); r {
+	case ResolutionKeepLocal, ResolutionTakeRemote:
+		return r, nil
+	}
+	return "", NewFieldError("resolution", fmt.Errorf("%w: must be %q or %q", ErrInvalidResolution, ResolutionKeepLocal, ResolutionTakeRemote))
+}
+
+// RemoteMovie is a movie of an external catalog feed. Empty certification, regions,
+// awards, external IDs, genres, director, synopsis and runtime are not carried by the
+// feed and keep their local values
+type RemoteMovie struct {
+	Source         string   `json:"source" bson:"source"`
+	ExternalID     string   `json:"external_id" bson:"external_id"`
+	Title          string   `json:"title" bson:"title"`
+	Year           string   `json:"year" bson:"year"`
+	Certification  string   `json:"certification,omitempty" bson:"certification,omitempty"`
+	Regions        []string `json:"regions,omitempty" bson:"regions,omitempty"`
+	Awards         []string `json:"awards,omitempty" bson:"awards,omitempty"`
+	IMDbID         string   `json:"imdb_id,omitempty" bson:"imdb_id,omitempty"`
+	TMDbID         string   `json:"tmdb_id,omitempty" bson:"tmdb_id,omitempty"`
+	Genres         []string `json:"genres,omitempty" bson:"genres,omitempty"`
+	Director       string   `json:"director,omitempty" bson:"director,omitempty"`
+	Synopsis       string   `json:"synopsis,omitempty" bson:"synopsis,omitempty"`
+	RuntimeMinutes int32    `json:"runtime_minutes,omitempty" bson:"runtime_minutes,omitempty"`
+}
+
+// ExternalIDIn returns the identifier of the remote movie in the external catalog source,
+// empty when the feed does not carry it
+func (r RemoteMovie) ExternalIDIn(source string) string {
+	switch source {
+	case SourceIMDb:
+		return r.IMDbID
+	case SourceTMDb:
+		return r.TMDbID
+	}
+	return ""
+}
+
+// Fingerprint identifies the content of the remote movie, so a sync can tell whether it
+// changed since it was last applied
+func (r RemoteMovie) Fingerprint() string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// MergeInto returns the input replacing local with the remote fields the feed carries;
+// local is nil when the movie does not exist
+func (r RemoteMovie) MergeInto(local *Movie) MovieInput {
+	input := MovieInput{
+		Title:          r.Title,
+		Year:           r.Year,
+		Certification:  r.Certification,
+		Regions:        r.Regions,
+		Awards:         r.Awards,
+		IMDbID:         r.IMDbID,
+		TMDbID:         r.TMDbID,
+		Genres:         r.Genres,
+		Director:       r.Director,
+		Synopsis:       r.Synopsis,
+		RuntimeMinutes: r.RuntimeMinutes,
+	}
+	if local == nil {
+		return input
+	}
+	if input.Certification == "" {
+		input.Certification = local.Certification
+	}
+	if len(input.Regions) == 0 {
+		input.Regions = local.Regions
+	}
+	if len(input.Awards) == 0 {
+		input.Awards = local.Awards
+	}
+	if input.IMDbID == "" {
+		input.IMDbID = local.IMDbID
+	}
+	if input.TMDbID == "" {
+		input.TMDbID = local.TMDbID
+	}
+	if len(input.Genres) == 0 {
+		input.Genres = local.Genres
+	}
+	if input.Director == "" {
+		input.Director = local.Director
+	}
+	if input.Synopsis == "" {
+		input.Synopsis = local.Synopsis
+	}
+	if input.RuntimeMinutes == 0 {
+		input.RuntimeMinutes = local.RuntimeMinutes
+	}
+	return input
+}
+
+// Matches reports whether applying the remote movie would leave local unchanged
+func (r RemoteMovie) Matches(local *Movie) bool {
+	merged := r.MergeInto(local)
+	return merged.Title == local.Title && merged.Year == local.Year && merged.Certification == local.Certification &&
+		slices.Equal(merged.Regions, local.Regions) && slices.Equal(merged.Awards, local.Awards) &&
+		merged.IMDbID == local.IMDbID && merged.TMDbID == local.TMDbID &&
+		slices.Equal(merged.Genres, local.Genres) && merged.Director == local.Director &&
+		merged.Synopsis == local.Synopsis && merged.RuntimeMinutes == local.RuntimeMinutes
+}
+
+// SyncLink ties a movie of a feed to the local movie it was applied to, with the state
+// of both sides after the last sync
+type SyncLink struct {
+	Source     string
+	ExternalID string
+	MovieID    int32
+	// Fingerprint is the fingerprint of the remote movie last applied or kept
+	Fingerprint string
+	// LocalVersion is the version of the local movie after the last sync; a different
+	// version means the movie was changed locally since
+	LocalVersion int64
+	SyncedAt     time.Time
+}
+
+// SyncConflict is a movie changed both locally and in the feed, queued for a moderator
+type SyncConflict struct {
+	ID         string
+	MovieID    int32
+	Remote     RemoteMovie
+	DetectedAt time.Time
+}
+
+// SyncReport counts what a sync did with the movies of the feed
+type SyncReport struct {
+	Fetched   int
+	Created   int
+	Updated   int
+	Unchanged int
+	// Kept counts conflicts resolved by keeping the local movie
+	Kept   int
+	Queued int
+	Failed int
+}
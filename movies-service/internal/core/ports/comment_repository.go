@@ -0,0 +1,53 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// CommentRepository defines the contract for comment data access
+type CommentRepository interface {
+	// ListThreads returns a page of the threads of the movie, newest first, with their
+	// replies, and the number of threads
+	ListThreads(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error)
+	FindByID(ctx context.Context, id string) (*domain.Comment, error)
+	// Create stores a new comment under its ID, or a new one when it has none
+	Create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	Flag(ctx context.Context, id, reason string) (*domain.Comment, error)
+	// ListFlagged returns a page of the flagged comments that were not deleted, most
+	// flagged first, and their number
+	ListFlagged(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	// SoftDelete marks the comment deleted and clears its flags
+	SoftDelete(ctx context.Context, id string) error
+	DismissFlags(ctx context.Context, id string) (*domain.Comment, error)
+	// ListPending returns up to limit comments awaiting the moderation worker, oldest first
+	ListPending(ctx context.Context, limit int) ([]*domain.Comment, error)
+	// ListModerationQueue returns a page of the comments awaiting a moderator, oldest
+	// first, and their number
+	ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	// SetStatus moves a comment in one of the from statuses to status, returning
+	// domain.ErrNotAwaitingModeration when it is in another
+	SetStatus(ctx context.Context, id string, from []domain.ModerationStatus, status domain.ModerationStatus, reasons []string) (*domain.Comment, error)
+}
+
+// CommentService defines the contract for comment business logic
+type CommentService interface {
+	ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error)
+	CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error)
+	FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error)
+	ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	DeleteComment(ctx context.Context, id string) error
+	DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error)
+	ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error)
+	// DecideModeration approves or rejects a comment awaiting moderation
+	DecideModeration(ctx context.Context, id string, decision domain.ModerationStatus) (*domain.Comment, error)
+}
+
+// ModerationFilter checks the text of submitted content. Filters are run in turn by the
+// moderation worker, which keeps the most severe verdict
+type ModerationFilter interface {
+	// Name identifies the filter in moderation reasons and logs
+	Name() string
+	Check(ctx context.Context, text string) (domain.ModerationResult, error)
+}
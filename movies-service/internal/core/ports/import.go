@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// ImportService defines the contract for bulk imports processed in the background
+type ImportService interface {
+	// StartImport returns the pending import of the rows, processed after it returns
+	StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error)
+	GetImport(ctx context.Context, id string) (*domain.Import, error)
+}
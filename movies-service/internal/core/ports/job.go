@@ -0,0 +1,65 @@
+package ports
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// JobRepository stores the queue of jobs shared by the workers of every replica
+type JobRepository interface {
+	// CreateJob stores a new job under its ID, setting a new one when it has none
+	CreateJob(ctx context.Context, job *domain.Job) error
+	FindJob(ctx context.Context, id string) (*domain.Job, error)
+	// ClaimJob marks a pending job running for owner until leaseUntil, returning nil when
+	// no job is pending. The oldest job queued before starvedBefore is claimed first, so
+	// low priorities are not starved; otherwise the oldest job of the highest priority
+	ClaimJob(ctx context.Context, owner string, leaseUntil, starvedBefore time.Time) (*domain.Job, error)
+	// UpdateJob saves the status, progress and result of a job running for its owner,
+	// returning domain.ErrJobNotFound when the job is no longer running for that owner
+	UpdateJob(ctx context.Context, job *domain.Job) error
+	// RenewJobLease extends the lease of a job running for owner, with the same errors as
+	// UpdateJob
+	RenewJobLease(ctx context.Context, id, owner string, leaseUntil time.Time) error
+	// FailExpiredJobs marks failed the running jobs whose lease ended before now, such as
+	// those of a replica that crashed, returning how many were failed
+	FailExpiredJobs(ctx context.Context, now time.Time, reason string) (int, error)
+	// DeleteFinishedJobs removes the jobs finished before the given time, returning their
+	// IDs
+	DeleteFinishedJobs(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// JobFileStore keeps the files produced by jobs, one per job
+type JobFileStore interface {
+	Create(ctx context.Context, jobID string) (io.WriteCloser, error)
+	// Open returns the file read from offset, resolved by domain.JobFileOffset, or
+	// domain.ErrNoJobFile when the job has no file
+	Open(ctx context.Context, jobID string, offset int64) (*domain.JobFile, error)
+	Delete(ctx context.Context, jobID string) error
+}
+
+// JobProgress reports the progress of a running job with its partial result, which may
+// be nil
+type JobProgress func(done, total int32, result interface{})
+
+// JobHandler runs the jobs of one type
+type JobHandler interface {
+	// Validate checks the parameters of a job before it is queued
+	Validate(params json.RawMessage) error
+	// Run runs the job, returning its result, stored as JSON. It stops when ctx is done,
+	// and sets job.File once it stored the file of the job
+	Run(ctx context.Context, job *domain.Job, progress JobProgress) (interface{}, error)
+}
+
+// JobService defines the contract for queuing jobs and following them
+type JobService interface {
+	// SubmitJob queues a job; an empty priority uses domain.DefaultJobPriority
+	SubmitJob(ctx context.Context, jobType domain.JobType, priority domain.JobPriority, params json.RawMessage) (*domain.Job, error)
+	GetJob(ctx context.Context, id string) (*domain.Job, error)
+	// OpenJobFile returns the file of a completed job read from offset, so interrupted
+	// downloads resume; negative offsets count from the end
+	OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error)
+}
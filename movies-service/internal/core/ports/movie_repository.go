@@ -2,6 +2,8 @@ package ports
 
 import (
 	"context"
+	"time"
+
 	"github.com/movie-microservice/movies-service/internal/core/domain"
 )
 
@@ -9,17 +11,41 @@ import (
 type MovieRepository interface {
 	FindAll(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, error)
 	FindByID(ctx context.Context, id int32) (*domain.Movie, error)
+	// FindByExternalID returns the movie with the ID in the external catalog source
+	FindByExternalID(ctx context.Context, source, id string) (*domain.Movie, error)
 	Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
+	Upsert(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error)
+	// UpdateVersion replaces the movie only if its stored version matches, bumping it
+	UpdateVersion(ctx context.Context, movie *domain.Movie, version int64) (*domain.Movie, error)
 	Delete(ctx context.Context, id int32) error
-	Count(ctx context.Context) (int32, error)
+	DeleteVersion(ctx context.Context, id int32, version int64) error
+	Count(ctx context.Context, filter domain.MovieFilter) (int32, error)
+	Facets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error)
 	ExistsByID(ctx context.Context, id int32) (bool, error)
+	FindVersion(ctx context.Context, id int32) (int64, error)
+	// GetNextID allocates an ID no stored movie has, never returning the same ID twice,
+	// even to concurrent callers
 	GetNextID(ctx context.Context) (int32, error)
+	Archive(ctx context.Context, olderThan time.Time, limit int) (int, error)
+	History(ctx context.Context, id int32) ([]domain.MovieRevision, error)
+	Export(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error)
 }
 
 // MovieService defines the contract for movie business logic
 type MovieService interface {
 	GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error)
 	GetMovie(ctx context.Context, id int32) (*domain.Movie, error)
-	CreateMovie(ctx context.Context, title, year string) (*domain.Movie, error)
+	GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error)
+	CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error)
+	UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error)
+	// UpdateMovie replaces an existing movie, never creating it
+	UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error)
+	UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error)
+	PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error)
 	DeleteMovie(ctx context.Context, id int32) error
+	DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error
+	GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error)
+	GetMovieHistory(ctx context.Context, id int32) ([]domain.MovieRevision, error)
+	GetMovieVersion(ctx context.Context, id int32) (int64, error)
+	ExportMovies(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error)
 }
@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// CatalogNotifier posts catalog events to an outbound channel such as a chat webhook
+type CatalogNotifier interface {
+	// Name identifies the channel in logs
+	Name() string
+	Notify(ctx context.Context, event domain.CatalogEvent) error
+}
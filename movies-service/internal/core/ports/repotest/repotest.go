@@ -0,0 +1,446 @@
+// Package repotest checks that an adapter of ports.MovieRepository behaves as the
+// services expect, so the adapters cannot drift apart: every adapter runs the same
+// RepositoryTestSuite from its integration tests, and the in-memory mock of the unit
+// tests from theirs
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+)
+
+// Factory returns a repository over an empty store, used by a single test. Whatever it
+// creates is cleaned up through t.Cleanup
+type Factory func(t *testing.T) ports.MovieRepository
+
+// concurrentWriters is the number of goroutines racing on the same movie
+const concurrentWriters = 8
+
+// RepositoryTestSuite runs the behaviors shared by every movie repository, each subtest
+// on a repository of its own
+func RepositoryTestSuite(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo ports.MovieRepository)
+	}{
+		{"NotFound", testNotFound},
+		{"CreateAndFind", testCreateAndFind},
+		{"DuplicateID", testDuplicateID},
+		{"Upsert", testUpsert},
+		{"UpdateVersion", testUpdateVersion},
+		{"Delete", testDelete},
+		{"DeleteVersion", testDeleteVersion},
+		{"DeleteArchivedVersion", testDeleteArchivedVersion},
+		{"Pagination", testPagination},
+		{"Export", testExport},
+		{"NextID", testNextID},
+		{"ConcurrentNextIDs", testConcurrentNextIDs},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+		{"ConcurrentUpserts", testConcurrentUpserts},
+		{"ConcurrentDeletes", testConcurrentDeletes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+// create stores the movie with the given ID, failing the test if it cannot
+func create(t *testing.T, repo ports.MovieRepository, id int32, title string) *domain.Movie {
+	t.Helper()
+	movie, err := domain.NewMovie(id, title, "2000")
+	if err != nil {
+		t.Fatalf("NewMovie() unexpected error = %v", err)
+	}
+	created, err := repo.Create(context.Background(), movie)
+	if err != nil {
+		t.Fatalf("Create(%d) unexpected error = %v", id, err)
+	}
+	return created
+}
+
+func testNotFound(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	movie, _ := domain.NewMovie(1, "Missing", "2000")
+
+	if _, err := repo.FindByID(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("FindByID() error = %v, want ErrMovieNotFound", err)
+	}
+	if _, err := repo.FindVersion(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("FindVersion() error = %v, want ErrMovieNotFound", err)
+	}
+	if _, err := repo.FindByExternalID(ctx, domain.SourceIMDb, "tt0000001"); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("FindByExternalID() error = %v, want ErrMovieNotFound", err)
+	}
+	if exists, err := repo.ExistsByID(ctx, 1); err != nil || exists {
+		t.Errorf("ExistsByID() = %v, %v, want false", exists, err)
+	}
+	if _, err := repo.UpdateVersion(ctx, movie, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("UpdateVersion() error = %v, want ErrMovieNotFound", err)
+	}
+	if err := repo.Delete(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("Delete() error = %v, want ErrMovieNotFound", err)
+	}
+	if err := repo.DeleteVersion(ctx, 1, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("DeleteVersion() error = %v, want ErrMovieNotFound", err)
+	}
+	if count, err := repo.Count(ctx, domain.MovieFilter{}); err != nil || count != 0 {
+		t.Errorf("Count() = %d, %v, want 0", count, err)
+	}
+}
+
+func testCreateAndFind(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	movie := &domain.Movie{
+		ID: 1, Title: "Heat", Year: "1995", Version: 1, Regions: []string{"BR"}, IMDbID: "tt0113277", TMDbID: "949",
+		Genres: []string{"Crime", "Drama"}, Director: "Michael Mann", Synopsis: "Robbers and the police.", RuntimeMinutes: 170,
+	}
+	if _, err := repo.Create(ctx, movie.Copy()); err != nil {
+		t.Fatalf("Create() unexpected error = %v", err)
+	}
+
+	found, err := repo.FindByID(ctx, 1)
+	if err != nil || !found.IsEqual(movie) || found.Version != 1 || found.IMDbID != movie.IMDbID || len(found.Regions) != 1 ||
+		!slices.Equal(found.Genres, movie.Genres) || found.Director != movie.Director || found.Synopsis != movie.Synopsis ||
+		found.RuntimeMinutes != movie.RuntimeMinutes {
+		t.Errorf("FindByID() = %+v, %v, want %+v", found, err, movie)
+	}
+	if found, err := repo.FindByExternalID(ctx, domain.SourceTMDb, "949"); err != nil || found.ID != 1 {
+		t.Errorf("FindByExternalID() = %v, %v, want movie 1", found, err)
+	}
+	if version, err := repo.FindVersion(ctx, 1); err != nil || version != 1 {
+		t.Errorf("FindVersion() = %d, %v, want 1", version, err)
+	}
+	if exists, err := repo.ExistsByID(ctx, 1); err != nil || !exists {
+		t.Errorf("ExistsByID() = %v, %v, want true", exists, err)
+	}
+
+	if _, err := repo.Create(ctx, &domain.Movie{ID: 2, Title: "", Year: "1995", Version: 1}); !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("Create() of an invalid movie error = %v, want ErrInvalidMovieData", err)
+	}
+}
+
+func testDuplicateID(t *testing.T, repo ports.MovieRepository) {
+	create(t, repo, 1, "Original")
+
+	duplicate, _ := domain.NewMovie(1, "Duplicate", "2001")
+	if _, err := repo.Create(context.Background(), duplicate); !errors.Is(err, domain.ErrMovieAlreadyExists) {
+		t.Errorf("Create() of a taken ID error = %v, want ErrMovieAlreadyExists", err)
+	}
+	if found, err := repo.FindByID(context.Background(), 1); err != nil || found.Title != "Original" {
+		t.Errorf("FindByID() = %v, %v, want the original movie kept", found, err)
+	}
+}
+
+func testUpsert(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	movie, _ := domain.NewMovie(1, "Draft", "2000")
+	created, isNew, err := repo.Upsert(ctx, movie)
+	if err != nil || !isNew || created.Version != 1 {
+		t.Fatalf("Upsert() of a new movie = %+v, %v, %v, want it created at version 1", created, isNew, err)
+	}
+
+	replacement, _ := domain.NewMovie(1, "Final", "2000")
+	replaced, isNew, err := repo.Upsert(ctx, replacement)
+	if err != nil || isNew || replaced.Version != 2 {
+		t.Fatalf("Upsert() of an existing movie = %+v, %v, %v, want it replaced at version 2", replaced, isNew, err)
+	}
+	if found, err := repo.FindByID(ctx, 1); err != nil || found.Title != "Final" || found.Version != 2 {
+		t.Errorf("FindByID() = %+v, %v, want the replacement at version 2", found, err)
+	}
+
+	// A movie created again after its deletion does not reuse the versions it had
+	if err := repo.Delete(ctx, 1); err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+	again, _ := domain.NewMovie(1, "Remake", "2000")
+	recreated, isNew, err := repo.Upsert(ctx, again)
+	if err != nil || !isNew || recreated.Version != 3 {
+		t.Errorf("Upsert() of a deleted movie = %+v, %v, %v, want it created at version 3", recreated, isNew, err)
+	}
+}
+
+func testUpdateVersion(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	create(t, repo, 1, "Draft")
+
+	stale, _ := domain.NewMovie(1, "Stale", "2000")
+	if _, err := repo.UpdateVersion(ctx, stale, 2); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("UpdateVersion() of a stale version error = %v, want ErrVersionMismatch", err)
+	}
+
+	update, _ := domain.NewMovie(1, "Final", "2000")
+	updated, err := repo.UpdateVersion(ctx, update, 1)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("UpdateVersion() = %+v, %v, want version 2", updated, err)
+	}
+	if found, err := repo.FindByID(ctx, 1); err != nil || found.Title != "Final" || found.Version != 2 {
+		t.Errorf("FindByID() = %+v, %v, want the update at version 2", found, err)
+	}
+}
+
+func testDelete(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	create(t, repo, 1, "Doomed")
+	create(t, repo, 2, "Kept")
+
+	if err := repo.Delete(ctx, 1); err != nil {
+		t.Fatalf("Delete() unexpected error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("FindByID() after deletion error = %v, want ErrMovieNotFound", err)
+	}
+	if err := repo.Delete(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("Delete() twice error = %v, want ErrMovieNotFound", err)
+	}
+	if count, err := repo.Count(ctx, domain.MovieFilter{}); err != nil || count != 1 {
+		t.Errorf("Count() after deletion = %d, %v, want 1", count, err)
+	}
+}
+
+func testDeleteVersion(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	create(t, repo, 1, "Versioned")
+
+	if err := repo.DeleteVersion(ctx, 1, 2); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("DeleteVersion() of a stale version error = %v, want ErrVersionMismatch", err)
+	}
+	if err := repo.DeleteVersion(ctx, 1, 1); err != nil {
+		t.Fatalf("DeleteVersion() unexpected error = %v", err)
+	}
+	if err := repo.DeleteVersion(ctx, 1, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("DeleteVersion() twice error = %v, want ErrMovieNotFound", err)
+	}
+}
+
+// testDeleteArchivedVersion deletes a movie moved to the archive, which keeps its version
+func testDeleteArchivedVersion(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	create(t, repo, 1, "Archived")
+	if archived, err := repo.Archive(ctx, time.Now().Add(time.Hour), 10); err != nil || archived != 1 {
+		t.Fatalf("Archive() = %d, %v, want 1 movie archived", archived, err)
+	}
+
+	if err := repo.DeleteVersion(ctx, 1, 2); !errors.Is(err, domain.ErrVersionMismatch) {
+		t.Errorf("DeleteVersion() of a stale version error = %v, want ErrVersionMismatch", err)
+	}
+	if err := repo.DeleteVersion(ctx, 1, 1); err != nil {
+		t.Fatalf("DeleteVersion() unexpected error = %v", err)
+	}
+	if _, err := repo.FindByID(ctx, 1); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("FindByID() after deletion error = %v, want ErrMovieNotFound", err)
+	}
+}
+
+func testPagination(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	// Created out of order, listed by ID
+	for _, id := range []int32{5, 3, 1, 4, 2} {
+		create(t, repo, id, fmt.Sprintf("Movie %d", id))
+	}
+
+	pages := [][]int32{{1, 2}, {3, 4}, {5}, nil}
+	for i, want := range pages {
+		page := int32(i + 1)
+		movies, err := repo.FindAll(ctx, domain.MovieFilter{Page: page, Limit: 2})
+		if err != nil {
+			t.Fatalf("FindAll() page %d unexpected error = %v", page, err)
+		}
+		if got := ids(movies); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("FindAll() page %d = %v, want %v", page, got, want)
+		}
+	}
+
+	filter := domain.MovieFilter{
+		Page:  1,
+		Limit: 10,
+		Expr:  &domain.FilterExpr{Condition: &domain.FilterCondition{Field: "id", Op: domain.OpGt, Value: "3"}},
+	}
+	if movies, err := repo.FindAll(ctx, filter); err != nil || fmt.Sprint(ids(movies)) != "[4 5]" {
+		t.Errorf("FindAll() with id > 3 = %v, %v, want [4 5]", ids(movies), err)
+	}
+	if count, err := repo.Count(ctx, filter); err != nil || count != 2 {
+		t.Errorf("Count() with id > 3 = %d, %v, want 2", count, err)
+	}
+	if count, err := repo.Count(ctx, domain.MovieFilter{}); err != nil || count != 5 {
+		t.Errorf("Count() = %d, %v, want 5", count, err)
+	}
+}
+
+func testExport(t *testing.T, repo ports.MovieRepository) {
+	for _, id := range []int32{3, 1, 2} {
+		create(t, repo, id, fmt.Sprintf("Movie %d", id))
+	}
+
+	movies, err := repo.Export(context.Background(), 1, 10)
+	if err != nil || fmt.Sprint(ids(movies)) != "[2 3]" {
+		t.Errorf("Export() after 1 = %v, %v, want [2 3]", ids(movies), err)
+	}
+	movies, err = repo.Export(context.Background(), 0, 1)
+	if err != nil || fmt.Sprint(ids(movies)) != "[1]" {
+		t.Errorf("Export() limited to 1 = %v, %v, want [1]", ids(movies), err)
+	}
+}
+
+func testNextID(t *testing.T, repo ports.MovieRepository) {
+	ctx := context.Background()
+	if id, err := repo.GetNextID(ctx); err != nil || id != 1 {
+		t.Errorf("GetNextID() of an empty store = %d, %v, want 1", id, err)
+	}
+
+	create(t, repo, 7, "Seventh")
+	create(t, repo, 3, "Third")
+	// Adapters may or may not reuse the IDs of deleted movies, but never those in use
+	if id, err := repo.GetNextID(ctx); err != nil || id <= 7 {
+		t.Errorf("GetNextID() = %d, %v, want an ID above 7", id, err)
+	}
+}
+
+// testConcurrentNextIDs races ID allocations: each caller gets an ID of its own, so
+// concurrent creations of new movies do not collide
+func testConcurrentNextIDs(t *testing.T, repo ports.MovieRepository) {
+	create(t, repo, 1, "First")
+
+	allocated := make([]int32, concurrentWriters)
+	errs := race(func(i int) error {
+		var err error
+		allocated[i], err = repo.GetNextID(context.Background())
+		return err
+	})
+
+	seen := make(map[int32]bool)
+	for i, id := range allocated {
+		switch {
+		case errs[i] != nil:
+			t.Errorf("GetNextID() unexpected error = %v", errs[i])
+		case id <= 1 || seen[id]:
+			t.Errorf("GetNextID() = %d, allocated %v, want distinct IDs above 1", id, allocated)
+		}
+		seen[id] = true
+	}
+}
+
+// testConcurrentCreates races creations of the same ID: exactly one wins
+func testConcurrentCreates(t *testing.T, repo ports.MovieRepository) {
+	errs := race(func(i int) error {
+		movie, _ := domain.NewMovie(1, fmt.Sprintf("Writer %d", i), "2000")
+		_, err := repo.Create(context.Background(), movie)
+		return err
+	})
+	checkOneWinner(t, "Create()", errs, domain.ErrMovieAlreadyExists)
+}
+
+// testConcurrentUpdates races updates from the same version: exactly one wins, and the
+// others must not be applied on top of it
+func testConcurrentUpdates(t *testing.T, repo ports.MovieRepository) {
+	create(t, repo, 1, "Draft")
+
+	errs := race(func(i int) error {
+		movie, _ := domain.NewMovie(1, fmt.Sprintf("Writer %d", i), "2000")
+		_, err := repo.UpdateVersion(context.Background(), movie, 1)
+		return err
+	})
+	checkOneWinner(t, "UpdateVersion()", errs, domain.ErrVersionMismatch)
+
+	if version, err := repo.FindVersion(context.Background(), 1); err != nil || version != 2 {
+		t.Errorf("FindVersion() after the race = %d, %v, want 2", version, err)
+	}
+}
+
+// testConcurrentUpserts races upserts of a new movie: exactly one creates it, the others
+// replace it or fail with ErrVersionMismatch
+func testConcurrentUpserts(t *testing.T, repo ports.MovieRepository) {
+	var created atomic.Int32
+	errs := race(func(i int) error {
+		movie, _ := domain.NewMovie(1, fmt.Sprintf("Writer %d", i), "2000")
+		_, isNew, err := repo.Upsert(context.Background(), movie)
+		if isNew {
+			created.Add(1)
+		}
+		return err
+	})
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, domain.ErrVersionMismatch) {
+			t.Errorf("Upsert() unexpected error = %v", err)
+		}
+	}
+	if n := created.Load(); n != 1 {
+		t.Errorf("Upsert() created the movie %d times, want once", n)
+	}
+}
+
+// testConcurrentDeletes races deletions of the same version: exactly one wins
+func testConcurrentDeletes(t *testing.T, repo ports.MovieRepository) {
+	create(t, repo, 1, "Doomed")
+
+	errs := race(func(int) error {
+		return repo.DeleteVersion(context.Background(), 1, 1)
+	})
+	// The losers see the movie either gone or deleted under them
+	var winners int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			winners++
+		case !errors.Is(err, domain.ErrMovieNotFound) && !errors.Is(err, domain.ErrVersionMismatch):
+			t.Errorf("DeleteVersion() unexpected error = %v", err)
+		}
+	}
+	if winners != 1 {
+		t.Errorf("DeleteVersion() succeeded %d times, want once", winners)
+	}
+}
+
+// race runs write concurrently from concurrentWriters goroutines, started together
+func race(write func(i int) error) []error {
+	errs := make([]error, concurrentWriters)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = write(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// checkOneWinner checks exactly one write succeeded and the others failed with lost
+func checkOneWinner(t *testing.T, call string, errs []error, lost error) {
+	t.Helper()
+	var winners int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			winners++
+		case !errors.Is(err, lost):
+			t.Errorf("%s unexpected error = %v, want %v", call, err, lost)
+		}
+	}
+	if winners != 1 {
+		t.Errorf("%s succeeded %d times, want once", call, winners)
+	}
+}
+
+func ids(movies []*domain.Movie) []int32 {
+	var list []int32
+	for _, movie := range movies {
+		list = append(list, movie.ID)
+	}
+	return list
+}
@@ -0,0 +1,33 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+// CatalogFeed reads the movies of an external catalog
+type CatalogFeed interface {
+	// Name identifies the feed; it is the source of the movies it returns
+	Name() string
+	Fetch(ctx context.Context) ([]domain.RemoteMovie, error)
+}
+
+// SyncRepository stores the links between feed movies and local movies and the queue of
+// conflicts awaiting a moderator
+type SyncRepository interface {
+	// FindLink returns nil when the remote movie was never synced
+	FindLink(ctx context.Context, source, externalID string) (*domain.SyncLink, error)
+	SaveLink(ctx context.Context, link domain.SyncLink) error
+	// SaveConflict queues the conflict, replacing the one queued for the same remote movie
+	SaveConflict(ctx context.Context, conflict domain.SyncConflict) error
+	ListConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error)
+	FindConflict(ctx context.Context, id string) (*domain.SyncConflict, error)
+	DeleteConflict(ctx context.Context, id string) error
+}
+
+// SyncService defines the contract for the manual resolution of sync conflicts
+type SyncService interface {
+	ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error)
+	ResolveSyncConflict(ctx context.Context, id string, resolution domain.SyncResolution) (*domain.Movie, error)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/clock"
+)
+
+const archiveBatchSize = 500
+
+// Archiver moves movies that were not read for a while out of the main collection.
+// Archived movies remain readable by ID.
+type Archiver struct {
+	repo   ports.MovieRepository
+	maxAge time.Duration
+	clock  clock.Clock
+	logger *slog.Logger
+}
+
+func NewArchiver(repo ports.MovieRepository, maxAge time.Duration, clk clock.Clock, logger *slog.Logger) *Archiver {
+	return &Archiver{
+		repo:   repo,
+		maxAge: maxAge,
+		clock:  clk,
+		logger: logger,
+	}
+}
+
+// Run archives stale movies; it is meant to be scheduled as a recurring job
+func (a *Archiver) Run(ctx context.Context) error {
+	_, err := a.ArchiveOnce(ctx)
+	return err
+}
+
+// ArchiveOnce archives every movie not read within the maximum age, returning how many were moved
+func (a *Archiver) ArchiveOnce(ctx context.Context) (int, error) {
+	olderThan := a.clock.Now().Add(-a.maxAge)
+
+	total := 0
+	for {
+		archived, err := a.repo.Archive(ctx, olderThan, archiveBatchSize)
+		total += archived
+		if err != nil {
+			return total, err
+		}
+		if archived < archiveBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logging.FromContext(ctx, a.logger).Info("Archived movies not accessed recently", "count", total, "max_age", a.maxAge)
+	}
+	return total, nil
+}
+
+// ArchiveParams are the parameters of archive jobs; AfterDays defaults to the age of the
+// scheduled archiving
+type ArchiveParams struct {
+	AfterDays int `json:"after_days,omitempty"`
+}
+
+// ArchiveResult is the result of archive jobs
+type ArchiveResult struct {
+	Archived int `json:"archived"`
+}
+
+// ArchiveJob runs archiving on demand as a job, such as before a scheduled run
+type ArchiveJob struct {
+	repo          ports.MovieRepository
+	defaultMaxAge time.Duration
+	clock         clock.Clock
+	logger        *slog.Logger
+}
+
+func NewArchiveJob(repo ports.MovieRepository, defaultMaxAge time.Duration, clk clock.Clock, logger *slog.Logger) *ArchiveJob {
+	return &ArchiveJob{
+		repo:          repo,
+		defaultMaxAge: defaultMaxAge,
+		clock:         clk,
+		logger:        logger,
+	}
+}
+
+func (h *ArchiveJob) Validate(params json.RawMessage) error {
+	_, err := h.maxAge(params)
+	return err
+}
+
+func (h *ArchiveJob) Run(ctx context.Context, job *domain.Job, progress ports.JobProgress) (interface{}, error) {
+	maxAge, err := h.maxAge(job.Params)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := NewArchiver(h.repo, maxAge, h.clock, h.logger).ArchiveOnce(ctx)
+	return ArchiveResult{Archived: archived}, err
+}
+
+// maxAge returns the age after which the job archives movies
+func (h *ArchiveJob) maxAge(params json.RawMessage) (time.Duration, error) {
+	var decoded ArchiveParams
+	if err := json.Unmarshal(params, &decoded); err != nil {
+		return 0, err
+	}
+	if decoded.AfterDays < 0 {
+		return 0, domain.NewFieldError("after_days", errors.New("after_days must not be negative"))
+	}
+	if decoded.AfterDays == 0 {
+		if h.defaultMaxAge <= 0 {
+			return 0, domain.NewFieldError("after_days", errors.New("after_days is required when archiving is not scheduled"))
+		}
+		return h.defaultMaxAge, nil
+	}
+	return time.Duration(decoded.AfterDays) * 24 * time.Hour, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// CatalogSync applies the movies of an external feed to the catalog. Each remote movie is
+// linked to a local movie by its ID in the feed, first matched by the IMDb and TMDb IDs
+// stored on movies; a remote movie changed since the last sync
+// updates its local movie, unless the local movie changed too, in which case the policy
+// decides. Writes go through the movie service, so they are validated and announced like
+// any other. Movies missing from the feed are left alone. Without a feed it only resolves
+// the conflicts queued by earlier syncs
+type CatalogSync struct {
+	feed       ports.CatalogFeed
+	movies     ports.MovieService
+	repo       ports.SyncRepository
+	policy     domain.SyncPolicy
+	pagination domain.Pagination
+	clock      clock.Clock
+	logger     *slog.Logger
+}
+
+func NewCatalogSync(feed ports.CatalogFeed, movies ports.MovieService, repo ports.SyncRepository, policy domain.SyncPolicy, pagination domain.Pagination, clk clock.Clock, logger *slog.Logger) *CatalogSync {
+	return &CatalogSync{
+		feed:       feed,
+		movies:     movies,
+		repo:       repo,
+		policy:     policy,
+		pagination: pagination,
+		clock:      clk,
+		logger:     logger,
+	}
+}
+
+// Run syncs the feed once; it is meant to be scheduled as a recurring job
+func (s *CatalogSync) Run(ctx context.Context) error {
+	_, err := s.SyncOnce(ctx)
+	return err
+}
+
+// SyncOnce fetches the feed and applies its movies. Movies the catalog rejects, including
+// those with an external ID held by another movie, are counted as failed without
+// stopping the sync
+func (s *CatalogSync) SyncOnce(ctx context.Context) (domain.SyncReport, error) {
+	var report domain.SyncReport
+	remotes, err := s.feed.Fetch(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to fetch %s feed: %w", s.feed.Name(), err)
+	}
+	report.Fetched = len(remotes)
+
+	for _, remote := range remotes {
+		err := s.syncMovie(ctx, remote, &report)
+		if errors.Is(err, domain.ErrInvalidMovieData) || errors.Is(err, domain.ErrExternalIDTaken) {
+			logging.FromContext(ctx, s.logger).Warn("Skipped invalid remote movie", "source", remote.Source, "external_id", remote.ExternalID, "error", err)
+			report.Failed++
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Catalog synced", "source", s.feed.Name(), "fetched", report.Fetched, "created", report.Created,
+		"updated", report.Updated, "unchanged", report.Unchanged, "kept", report.Kept, "queued", report.Queued, "failed", report.Failed)
+	return report, nil
+}
+
+func (s *CatalogSync) syncMovie(ctx context.Context, remote domain.RemoteMovie, report *domain.SyncReport) error {
+	// The title, year and external IDs are checked first, since unlinked movies are looked
+	// up by them
+	if _, err := domain.NewMovieFromInput(1, domain.MovieInput{Title: remote.Title, Year: remote.Year, IMDbID: remote.IMDbID, TMDbID: remote.TMDbID}); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+	link, err := s.repo.FindLink(ctx, remote.Source, remote.ExternalID)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		return s.linkMovie(ctx, remote, report)
+	}
+	if link.Fingerprint == remote.Fingerprint() {
+		report.Unchanged++
+		return nil
+	}
+
+	local, err := s.movies.GetMovie(ctx, link.MovieID)
+	if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+		return err
+	}
+	// A deleted local movie counts as a local change
+	if local != nil && local.Version == link.LocalVersion {
+		return s.update(ctx, remote, link.MovieID, local, report)
+	}
+	return s.conflict(ctx, remote, link.MovieID, local, report)
+}
+
+// linkMovie handles a remote movie seen for the first time: it is linked to the local
+// movie found by findLocal, or created. A local movie with other details is a
+// conflict, since there is no earlier sync telling which side changed
+func (s *CatalogSync) linkMovie(ctx context.Context, remote domain.RemoteMovie, report *domain.SyncReport) error {
+	local, err := s.findLocal(ctx, remote)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case local == nil:
+		movie, err := s.movies.CreateMovie(ctx, remote.MergeInto(nil))
+		if err != nil {
+			return err
+		}
+		report.Created++
+		return s.saveLink(ctx, remote, movie)
+	case remote.Matches(local):
+		report.Unchanged++
+		return s.saveLink(ctx, remote, local)
+	default:
+		return s.conflict(ctx, remote, local.ID, local, report)
+	}
+}
+
+// findLocal finds the local movie of a remote movie seen for the first time: by the
+// external IDs the feed carries, then by title and year among the movies without another
+// ID in those catalogs
+func (s *CatalogSync) findLocal(ctx context.Context, remote domain.RemoteMovie) (*domain.Movie, error) {
+	for _, source := range domain.ExternalSources {
+		id := remote.ExternalIDIn(source)
+		if id == "" {
+			continue
+		}
+		movie, err := s.movies.GetMovieByExternalID(ctx, source, id)
+		if errors.Is(err, domain.ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return movie, nil
+	}
+
+	movies, _, err := s.movies.GetMovies(ctx, domain.MovieFilter{
+		Expr: &domain.FilterExpr{And: []*domain.FilterExpr{
+			{Condition: &domain.FilterCondition{Field: "title", Op: domain.OpEq, Value: remote.Title}},
+			{Condition: &domain.FilterCondition{Field: "year", Op: domain.OpEq, Value: remote.Year}},
+		}},
+		Page:      1,
+		Limit:     1,
+		SkipCount: true,
+	})
+	if err != nil || len(movies) == 0 {
+		return nil, err
+	}
+	for _, source := range domain.ExternalSources {
+		if id := movies[0].ExternalID(source); id != "" && remote.ExternalIDIn(source) != "" {
+			// A namesake of the remote movie, identified as another movie in the catalog
+			return nil, nil
+		}
+	}
+	return movies[0], nil
+}
+
+func (s *CatalogSync) conflict(ctx context.Context, remote domain.RemoteMovie, movieID int32, local *domain.Movie, report *domain.SyncReport) error {
+	switch s.policy {
+	case domain.SyncRemoteWins:
+		return s.update(ctx, remote, movieID, local, report)
+	case domain.SyncLocalWins:
+		report.Kept++
+		return s.keep(ctx, remote, movieID, local)
+	default:
+		report.Queued++
+		return s.repo.SaveConflict(ctx, domain.SyncConflict{MovieID: movieID, Remote: remote, DetectedAt: s.clock.Now().UTC()})
+	}
+}
+
+func (s *CatalogSync) update(ctx context.Context, remote domain.RemoteMovie, movieID int32, local *domain.Movie, report *domain.SyncReport) error {
+	if _, err := s.apply(ctx, remote, movieID, local); err != nil {
+		return err
+	}
+	report.Updated++
+	return nil
+}
+
+// apply writes the remote movie over the local one, recreating it when it was deleted
+func (s *CatalogSync) apply(ctx context.Context, remote domain.RemoteMovie, movieID int32, local *domain.Movie) (*domain.Movie, error) {
+	movie, _, err := s.movies.UpsertMovie(ctx, movieID, remote.MergeInto(local))
+	if err != nil {
+		return nil, err
+	}
+	return movie, s.saveLink(ctx, remote, movie)
+}
+
+// keep records the remote movie as seen without applying it, so it is only reconsidered
+// once it changes again
+func (s *CatalogSync) keep(ctx context.Context, remote domain.RemoteMovie, movieID int32, local *domain.Movie) error {
+	if local == nil {
+		local = &domain.Movie{ID: movieID}
+	}
+	return s.saveLink(ctx, remote, local)
+}
+
+func (s *CatalogSync) saveLink(ctx context.Context, remote domain.RemoteMovie, local *domain.Movie) error {
+	return s.repo.SaveLink(ctx, domain.SyncLink{
+		Source:       remote.Source,
+		ExternalID:   remote.ExternalID,
+		MovieID:      local.ID,
+		Fingerprint:  remote.Fingerprint(),
+		LocalVersion: local.Version,
+		SyncedAt:     s.clock.Now().UTC(),
+	})
+}
+
+func (s *CatalogSync) ListSyncConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, domain.NewFieldError("page", err)
+	}
+
+	conflicts, total, err := s.repo.ListConflicts(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list sync conflicts", "error", err)
+		return nil, 0, fmt.Errorf("failed to list sync conflicts: %w", err)
+	}
+	return conflicts, total, nil
+}
+
+// ResolveSyncConflict applies or discards the remote movie of a queued conflict, returning
+// the local movie after the decision; it is nil when the kept local movie was deleted
+func (s *CatalogSync) ResolveSyncConflict(ctx context.Context, id string, resolution domain.SyncResolution) (*domain.Movie, error) {
+	if resolution != domain.ResolutionKeepLocal && resolution != domain.ResolutionTakeRemote {
+		return nil, domain.NewFieldError("resolution", domain.ErrInvalidResolution)
+	}
+
+	conflict, err := s.repo.FindConflict(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sync conflict %s: %w", id, err)
+	}
+	local, err := s.movies.GetMovie(ctx, conflict.MovieID)
+	if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+		return nil, err
+	}
+
+	if resolution == domain.ResolutionTakeRemote {
+		if local, err = s.apply(ctx, conflict.Remote, conflict.MovieID, local); err != nil {
+			logging.FromContext(ctx, s.logger).Error("Failed to apply remote movie", "conflict_id", id, "error", err)
+			return nil, fmt.Errorf("failed to apply remote movie: %w", err)
+		}
+	} else if err := s.keep(ctx, conflict.Remote, conflict.MovieID, local); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteConflict(ctx, id); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx, s.logger).Info("Sync conflict resolved", "conflict_id", id, "movie_id", conflict.MovieID, "resolution", resolution)
+	return local, nil
+}
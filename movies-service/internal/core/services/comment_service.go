@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/idgen"
+)
+
+type CommentService struct {
+	repo       ports.CommentRepository
+	movies     ports.MovieRepository
+	pagination domain.Pagination
+	// moderated holds new comments until the moderation worker approved them
+	moderated bool
+	clock     clock.Clock
+	ids       idgen.Generator
+	logger    *slog.Logger
+}
+
+// NewCommentService creates the comment service, dating new comments by clk and naming
+// them by ids. With moderated set, new comments are hidden until the moderation worker or
+// a moderator approves them
+func NewCommentService(repo ports.CommentRepository, movies ports.MovieRepository, pagination domain.Pagination, moderated bool, clk clock.Clock, ids idgen.Generator, logger *slog.Logger) ports.CommentService {
+	return &CommentService{
+		repo:       repo,
+		movies:     movies,
+		pagination: pagination,
+		moderated:  moderated,
+		clock:      clk,
+		ids:        ids,
+		logger:     logger,
+	}
+}
+
+// ListComments returns a page of the threads of a movie, newest first, as shown to readers
+func (s *CommentService) ListComments(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	logging.FromContext(ctx, s.logger).Debug("Listing comments", "movie_id", movieID, "page", page, "limit", limit)
+
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, domain.NewFieldError("page", err)
+	}
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, 0, err
+	}
+
+	threads, total, err := s.repo.ListThreads(ctx, movieID, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list comments", "movie_id", movieID, "error", err)
+		return nil, 0, fmt.Errorf("failed to list comments of movie %d: %w", movieID, err)
+	}
+	for i, thread := range threads {
+		threads[i] = thread.Redacted()
+	}
+	return threads, total, nil
+}
+
+// CreateComment starts a thread, or replies to the comment given as parent when it
+// belongs to the same movie and was not deleted
+func (s *CommentService) CreateComment(ctx context.Context, input domain.CommentInput) (*domain.Comment, error) {
+	logging.FromContext(ctx, s.logger).Debug("Creating comment", "movie_id", input.MovieID, "parent_id", input.ParentID)
+
+	comment, err := domain.NewComment(input, s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidComment, err)
+	}
+	comment.ID = s.ids.NewID()
+	if err := s.checkMovie(ctx, comment.MovieID); err != nil {
+		return nil, err
+	}
+
+	if comment.ParentID != "" {
+		parent, err := s.repo.FindByID(ctx, comment.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find parent comment: %w", err)
+		}
+		if !parent.Status.Visible() {
+			return nil, domain.ErrCommentNotFound
+		}
+		if parent.MovieID != comment.MovieID {
+			return nil, fmt.Errorf("%w: %w", domain.ErrInvalidComment,
+				domain.NewFieldError("parent_id", fmt.Errorf("comment %s is not about movie %d", parent.ID, comment.MovieID)))
+		}
+		if parent.Deleted() {
+			return nil, domain.ErrCommentDeleted
+		}
+		comment.ThreadID = parent.ThreadID
+	}
+
+	comment.Status = domain.ModerationApproved
+	if s.moderated {
+		comment.Status = domain.ModerationPending
+	}
+
+	created, err := s.repo.Create(ctx, comment)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to create comment", "movie_id", comment.MovieID, "error", err)
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully created comment", "id", created.ID, "movie_id", created.MovieID, "status", created.Status)
+	return created.Redacted(), nil
+}
+
+// FlagComment reports a comment to the moderators
+func (s *CommentService) FlagComment(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	reason, err := domain.NormalizeFlagReason(reason)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidComment, err)
+	}
+
+	comment, err := s.repo.Flag(ctx, id, reason)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to flag comment", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to flag comment %s: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Comment flagged", "id", id, "movie_id", comment.MovieID, "flag_count", comment.FlagCount)
+	return comment.Redacted(), nil
+}
+
+// ListFlaggedComments returns a page of the flagged comments, most flagged first, with
+// their flag reasons
+func (s *CommentService) ListFlaggedComments(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, domain.NewFieldError("page", err)
+	}
+
+	comments, total, err := s.repo.ListFlagged(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list flagged comments", "error", err)
+		return nil, 0, fmt.Errorf("failed to list flagged comments: %w", err)
+	}
+	return comments, total, nil
+}
+
+// DeleteComment hides the author and body of a comment; its replies stay in the thread
+func (s *CommentService) DeleteComment(ctx context.Context, id string) error {
+	if err := s.repo.SoftDelete(ctx, id); err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to delete comment", "id", id, "error", err)
+		return fmt.Errorf("failed to delete comment %s: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Comment deleted", "id", id)
+	return nil
+}
+
+// DismissCommentFlags clears the flags of a comment the moderators chose to keep
+func (s *CommentService) DismissCommentFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	comment, err := s.repo.DismissFlags(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to dismiss comment flags", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to dismiss flags of comment %s: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Comment flags dismissed", "id", id)
+	return comment, nil
+}
+
+// ListModerationQueue returns a page of the comments the moderation worker queued for a
+// moderator, oldest first, with the reasons they were queued for
+func (s *CommentService) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	page, limit, err := s.pagination.Normalize(page, limit)
+	if err != nil {
+		return nil, 0, domain.NewFieldError("page", err)
+	}
+
+	comments, total, err := s.repo.ListModerationQueue(ctx, page, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to list moderation queue", "error", err)
+		return nil, 0, fmt.Errorf("failed to list moderation queue: %w", err)
+	}
+	return comments, total, nil
+}
+
+// DecideModeration approves or rejects a comment awaiting moderation, including comments
+// the worker did not check yet
+func (s *CommentService) DecideModeration(ctx context.Context, id string, decision domain.ModerationStatus) (*domain.Comment, error) {
+	if decision != domain.ModerationApproved && decision != domain.ModerationRejected {
+		return nil, domain.NewFieldError("decision", domain.ErrInvalidDecision)
+	}
+
+	from := []domain.ModerationStatus{domain.ModerationPending, domain.ModerationReview}
+	comment, err := s.repo.SetStatus(ctx, id, from, decision, nil)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to decide on comment", "id", id, "decision", decision, "error", err)
+		return nil, fmt.Errorf("failed to decide on comment %s: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("Comment moderated", "id", id, "status", comment.Status)
+	return comment, nil
+}
+
+func (s *CommentService) checkMovie(ctx context.Context, movieID int32) error {
+	if movieID <= 0 {
+		return fmt.Errorf("%w: %w", domain.ErrInvalidComment, domain.NewFieldError("movie_id", errors.New("movie_id must be positive")))
+	}
+	exists, err := s.movies.ExistsByID(ctx, movieID)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to check movie existence", "id", movieID, "error", err)
+		return fmt.Errorf("failed to check movie existence: %w", err)
+	}
+	if !exists {
+		return domain.ErrMovieNotFound
+	}
+	return nil
+}
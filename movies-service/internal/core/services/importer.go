@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+// ImportJob runs bulk imports as jobs. Rows go through the movie service, so they are
+// validated and announced like any other write, and each one gets a result in the report
+// of the import. Rows that fail don't stop the import
+type ImportJob struct {
+	movies ports.MovieService
+	logger *slog.Logger
+}
+
+func NewImportJob(movies ports.MovieService, logger *slog.Logger) *ImportJob {
+	return &ImportJob{
+		movies: movies,
+		logger: logger,
+	}
+}
+
+func (h *ImportJob) Validate(params json.RawMessage) error {
+	_, err := decodeImportParams(params)
+	return err
+}
+
+// Run imports the rows in order, reporting the results so far as progress. A job stopped
+// by ctx returns the results of the rows it processed
+func (h *ImportJob) Run(ctx context.Context, job *domain.Job, progress ports.JobProgress) (interface{}, error) {
+	params, err := decodeImportParams(job.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	total := int32(len(params.Rows))
+	report := domain.ImportReport{Results: make([]domain.ImportRowResult, 0, total)}
+	progress(0, total, report)
+	for i, row := range params.Rows {
+		if ctx.Err() != nil {
+			return report, fmt.Errorf("import stopped after %d of %d rows: %w", i, total, ctx.Err())
+		}
+		report.Results = append(report.Results, h.importRow(ctx, int32(i+1), row))
+		progress(int32(i+1), total, report)
+	}
+
+	imp := domain.Import{Results: report.Results}
+	logging.FromContext(ctx, h.logger).Info("Import finished", "id", job.ID, "rows", len(report.Results),
+		"created", imp.Count(domain.RowCreated), "updated", imp.Count(domain.RowUpdated),
+		"invalid", imp.Count(domain.RowInvalid), "failed", imp.Count(domain.RowFailed))
+	return report, nil
+}
+
+// importRow creates or replaces the movie of a row. Validation failures make the row
+// invalid, naming the rejected field; any other failure makes it failed
+func (h *ImportJob) importRow(ctx context.Context, number int32, row domain.ImportRow) domain.ImportRowResult {
+	result := domain.ImportRowResult{Row: number}
+
+	var movie *domain.Movie
+	var err error
+	if row.ID != 0 {
+		var created bool
+		movie, created, err = h.movies.UpsertMovie(ctx, row.ID, row.Input)
+		result.Status = domain.RowUpdated
+		if created {
+			result.Status = domain.RowCreated
+		}
+	} else {
+		movie, err = h.movies.CreateMovie(ctx, row.Input)
+		result.Status = domain.RowCreated
+	}
+	if err == nil {
+		result.MovieID = movie.ID
+		return result
+	}
+
+	result.Status, result.MovieID, result.Error = domain.RowFailed, row.ID, err.Error()
+	if errors.Is(err, domain.ErrInvalidMovieData) {
+		result.Status = domain.RowInvalid
+	}
+	var fieldErr *domain.FieldError
+	if errors.As(err, &fieldErr) {
+		result.Field = fieldErr.Field
+	}
+	return result
+}
+
+func decodeImportParams(params json.RawMessage) (*domain.ImportParams, error) {
+	var decoded domain.ImportParams
+	if err := json.Unmarshal(params, &decoded); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+	if len(decoded.Rows) == 0 || len(decoded.Rows) > domain.MaxImportRows {
+		return nil, domain.NewFieldError("rows",
+			fmt.Errorf("%w: an import must have between 1 and %d rows", domain.ErrInvalidImport, domain.MaxImportRows))
+	}
+	return &decoded, nil
+}
+
+// Importer serves the import API on top of the jobs: an import is the job of type
+// domain.JobImport with the same ID
+type Importer struct {
+	jobs   ports.JobService
+	logger *slog.Logger
+}
+
+func NewImporter(jobs ports.JobService, logger *slog.Logger) *Importer {
+	return &Importer{
+		jobs:   jobs,
+		logger: logger,
+	}
+}
+
+func (s *Importer) StartImport(ctx context.Context, rows []domain.ImportRow) (*domain.Import, error) {
+	params, err := json.Marshal(domain.ImportParams{Rows: rows})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode import: %w", err)
+	}
+	job, err := s.jobs.SubmitJob(ctx, domain.JobImport, "", params)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx, s.logger).Info("Import queued", "id", job.ID, "rows", len(rows))
+
+	return domain.ImportFromJob(job)
+}
+
+func (s *Importer) GetImport(ctx context.Context, id string) (*domain.Import, error) {
+	job, err := s.jobs.GetJob(ctx, id)
+	if errors.Is(err, domain.ErrJobNotFound) {
+		return nil, fmt.Errorf("failed to get import: %w", domain.ErrImportNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	imp, err := domain.ImportFromJob(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return imp, nil
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/idgen"
+)
+
+const (
+	// jobProgressInterval is the minimum delay between two saves of the progress of a job,
+	// so pollers see it move without a write per step
+	jobProgressInterval = time.Second
+	// jobSaveTimeout bounds the final save of a job stopped by a shutdown
+	jobSaveTimeout = 5 * time.Second
+)
+
+// JobOptions configures the workers of a replica
+type JobOptions struct {
+	// Owner identifies the replica in the leases of the jobs it runs
+	Owner        string
+	PollInterval time.Duration
+	// Lease is how long a job stays with its worker without a renewal
+	Lease time.Duration
+	// Retention is how long finished jobs and their files are kept
+	Retention time.Duration
+	// MaxWait is how long a job waits for higher priorities before it runs next anyway
+	MaxWait time.Duration
+}
+
+// Jobs queues long-running operations and runs them on a worker pool, claiming a job
+// whenever a worker is idle; a pool without workers leaves the jobs to other replicas.
+// Jobs are stored, so any replica can run them and report on them, and each type is run
+// by its handler. A job whose worker stops renewing its lease, such as after a crash, is
+// failed by Sweep rather than run again, since a job may not be safe to repeat
+type Jobs struct {
+	repo     ports.JobRepository
+	files    ports.JobFileStore
+	handlers map[domain.JobType]ports.JobHandler
+	pool     *workerpool.Pool
+	opts     JobOptions
+	clock    clock.Clock
+	ids      idgen.Generator
+	logger   *slog.Logger
+}
+
+func NewJobs(repo ports.JobRepository, files ports.JobFileStore, handlers map[domain.JobType]ports.JobHandler, pool *workerpool.Pool, opts JobOptions, clk clock.Clock, ids idgen.Generator, logger *slog.Logger) *Jobs {
+	return &Jobs{
+		repo:     repo,
+		files:    files,
+		handlers: handlers,
+		pool:     pool,
+		opts:     opts,
+		clock:    clk,
+		ids:      ids,
+		logger:   logger,
+	}
+}
+
+func (s *Jobs) SubmitJob(ctx context.Context, jobType domain.JobType, priority domain.JobPriority, params json.RawMessage) (*domain.Job, error) {
+	handler, ok := s.handlers[jobType]
+	if !ok {
+		return nil, domain.NewFieldError("type", fmt.Errorf("%w: unknown job type %q", domain.ErrInvalidJob, jobType))
+	}
+	if priority == "" {
+		priority = domain.DefaultJobPriority(jobType)
+	}
+	if !priority.Valid() {
+		return nil, domain.NewFieldError("priority", fmt.Errorf("%w: unknown priority %q", domain.ErrInvalidJob, priority))
+	}
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	if err := handler.Validate(params); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidJob, err)
+	}
+
+	job := &domain.Job{
+		ID:        s.ids.NewID(),
+		Type:      jobType,
+		Status:    domain.JobPending,
+		Priority:  priority,
+		Params:    params,
+		CreatedAt: s.clock.Now().UTC(),
+	}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to queue job", "type", jobType, "error", err)
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+	logging.FromContext(ctx, s.logger).Info("Job queued", "id", job.ID, "type", jobType, "priority", priority)
+	return job, nil
+}
+
+func (s *Jobs) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	job, err := s.repo.FindJob(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+func (s *Jobs) OpenJobFile(ctx context.Context, id string, offset int64) (*domain.JobFile, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != domain.JobCompleted || !job.File {
+		return nil, domain.ErrNoJobFile
+	}
+	return s.files.Open(ctx, id, offset)
+}
+
+// Run claims queued jobs for the idle workers of the pool until ctx is done, polling the
+// queue while it is empty. Jobs run by priority, except those waiting longer than MaxWait,
+// which run first. Running jobs are stopped and marked failed when the pool is closed
+func (s *Jobs) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		var job *domain.Job
+		if s.pool.Idle() {
+			var err error
+			now := s.clock.Now().UTC()
+			job, err = s.repo.ClaimJob(ctx, s.opts.Owner, now.Add(s.opts.Lease), now.Add(-s.opts.MaxWait))
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx, s.logger).Error("Failed to claim job", "error", err)
+			}
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(s.opts.PollInterval):
+			}
+			continue
+		}
+
+		err := s.pool.SubmitWait(ctx, string(job.Type)+" job "+job.ID, func(ctx context.Context) error {
+			s.execute(ctx, job)
+			return nil
+		})
+		if err != nil {
+			// The lease of the job runs out and Sweep fails it
+			logging.FromContext(ctx, s.logger).Error("Failed to run claimed job", "id", job.ID, "error", err)
+		}
+	}
+}
+
+// Sweep fails the jobs abandoned by their worker and removes the jobs finished before
+// the retention, with their files; it is meant to be scheduled as a recurring job
+func (s *Jobs) Sweep(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	failed, err := s.repo.FailExpiredJobs(ctx, now, "job abandoned by its worker")
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		logging.FromContext(ctx, s.logger).Warn("Failed jobs abandoned by their worker", "count", failed)
+	}
+
+	deleted, err := s.repo.DeleteFinishedJobs(ctx, now.Add(-s.opts.Retention))
+	if err != nil {
+		return err
+	}
+	for _, id := range deleted {
+		if err := s.files.Delete(ctx, id); err != nil && !errors.Is(err, domain.ErrNoJobFile) {
+			logging.FromContext(ctx, s.logger).Error("Failed to delete job file", "id", id, "error", err)
+		}
+	}
+	if len(deleted) > 0 {
+		logging.FromContext(ctx, s.logger).Info("Removed finished jobs", "count", len(deleted))
+	}
+	return nil
+}
+
+// execute runs a claimed job on a worker of the pool, renewing its lease in the
+// background. The job is stopped when ctx is done or its lease is lost
+func (s *Jobs) execute(ctx context.Context, job *domain.Job) {
+	logger := s.logger.With("id", job.ID, "type", job.Type)
+	logger.Info("Job started", "priority", job.Priority, "waited", job.StartedAt.Sub(job.CreatedAt))
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLease(jobCtx, job.ID, cancel, logger)
+	}()
+
+	var result interface{}
+	var err error
+	if handler, ok := s.handlers[job.Type]; ok {
+		lastSave := s.clock.Now()
+		result, err = handler.Run(jobCtx, job, func(done, total int32, partial interface{}) {
+			job.Done, job.Total = done, total
+			if partial != nil {
+				result = partial
+			}
+			if s.clock.Now().Sub(lastSave) < jobProgressInterval {
+				return
+			}
+			lastSave = s.clock.Now()
+			s.save(jobCtx, job, result, logger)
+		})
+	} else {
+		err = fmt.Errorf("no handler for jobs of type %q", job.Type)
+	}
+	cancel()
+	<-renewed
+
+	job.Status, job.FinishedAt = domain.JobCompleted, s.clock.Now().UTC()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		job.Status, job.Error = domain.JobFailed, "job interrupted by a shutdown"
+	case err != nil:
+		job.Status, job.Error = domain.JobFailed, err.Error()
+	}
+
+	// The outcome is saved even when a shutdown stopped the job
+	saveCtx, cancelSave := context.WithTimeout(context.WithoutCancel(ctx), jobSaveTimeout)
+	defer cancelSave()
+	s.save(saveCtx, job, result, logger)
+	logger.Info("Job finished", "status", job.Status, "done", job.Done, "total", job.Total, "error", job.Error)
+}
+
+// renewLease extends the lease of a job until ctx is done, calling stop when the job
+// was taken from its worker
+func (s *Jobs) renewLease(ctx context.Context, id string, stop context.CancelFunc, logger *slog.Logger) {
+	ticker := time.NewTicker(s.opts.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		err := s.repo.RenewJobLease(ctx, id, s.opts.Owner, s.clock.Now().UTC().Add(s.opts.Lease))
+		if errors.Is(err, domain.ErrJobNotFound) {
+			logger.Warn("Job lease lost, stopping the job")
+			stop()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Failed to renew job lease", "error", err)
+		}
+	}
+}
+
+// save stores the state of a job with its result so far; failures are logged, since the
+// job goes on and the next save catches up
+func (s *Jobs) save(ctx context.Context, job *domain.Job, result interface{}, logger *slog.Logger) {
+	if result != nil {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			logger.Error("Failed to encode job result", "error", err)
+		} else {
+			job.Result = encoded
+		}
+	}
+	job.LeaseUntil = s.clock.Now().UTC().Add(s.opts.Lease)
+	if err := s.repo.UpdateJob(ctx, job); err != nil {
+		logger.Error("Failed to save job", "status", job.Status, "error", err)
+	}
+}
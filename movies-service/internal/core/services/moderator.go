@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+)
+
+const moderationBatchSize = 100
+
+// CommentModerator runs the moderation filters on new comments, approving the ones every
+// filter accepts, rejecting the ones a filter rejects and queueing the others for a
+// moderator. A filter that fails queues the comment, so an outage of an external
+// moderation service neither publishes nor loses comments
+type CommentModerator struct {
+	repo    ports.CommentRepository
+	filters []ports.ModerationFilter
+	logger  *slog.Logger
+}
+
+func NewCommentModerator(repo ports.CommentRepository, filters []ports.ModerationFilter, logger *slog.Logger) *CommentModerator {
+	return &CommentModerator{
+		repo:    repo,
+		filters: filters,
+		logger:  logger,
+	}
+}
+
+// Run moderates the pending comments; it is meant to be scheduled as a recurring job
+func (m *CommentModerator) Run(ctx context.Context) error {
+	_, err := m.ModerateOnce(ctx)
+	return err
+}
+
+// ModerateOnce moderates every pending comment, returning how many were moderated
+func (m *CommentModerator) ModerateOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		pending, err := m.repo.ListPending(ctx, moderationBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to list pending comments: %w", err)
+		}
+
+		for _, comment := range pending {
+			if err := m.moderate(ctx, comment); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(pending) < moderationBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		m.logger.Info("Moderated comments", "count", total)
+	}
+	return total, nil
+}
+
+// Check runs every filter on the text and combines their verdicts
+func (m *CommentModerator) Check(ctx context.Context, text string) domain.ModerationResult {
+	var result domain.ModerationResult
+	for _, filter := range m.filters {
+		checked, err := filter.Check(ctx, text)
+		if err != nil {
+			m.logger.Warn("Moderation filter failed", "filter", filter.Name(), "error", err)
+			checked = domain.ModerationResult{Verdict: domain.VerdictReview, Reasons: []string{"filter unavailable"}}
+		}
+		for i, reason := range checked.Reasons {
+			checked.Reasons[i] = filter.Name() + ": " + reason
+		}
+		result = result.Merge(checked)
+	}
+	return result
+}
+
+func (m *CommentModerator) moderate(ctx context.Context, comment *domain.Comment) error {
+	result := m.Check(ctx, comment.Author+"\n"+comment.Body)
+	status := result.Verdict.Status()
+
+	_, err := m.repo.SetStatus(ctx, comment.ID, []domain.ModerationStatus{domain.ModerationPending}, status, result.Reasons)
+	if errors.Is(err, domain.ErrNotAwaitingModeration) || errors.Is(err, domain.ErrCommentNotFound) {
+		// A moderator decided or deleted the comment in the meantime
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to moderate comment %s: %w", comment.ID, err)
+	}
+
+	m.logger.Debug("Moderated comment", "id", comment.ID, "status", status, "reasons", result.Reasons)
+	return nil
+}
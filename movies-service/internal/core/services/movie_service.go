@@ -2,54 +2,66 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/movie-microservice/movies-service/internal/core/domain"
 	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
 )
 
 type MovieService struct {
-	repo   ports.MovieRepository
-	logger *slog.Logger
+	repo           ports.MovieRepository
+	pagination     domain.Pagination
+	certifications domain.Certifications
+	logger         *slog.Logger
 }
 
-func NewMovieService(repo ports.MovieRepository, logger *slog.Logger) ports.MovieService {
+func NewMovieService(repo ports.MovieRepository, pagination domain.Pagination, certifications domain.Certifications, logger *slog.Logger) ports.MovieService {
 	return &MovieService{
-		repo:   repo,
-		logger: logger,
+		repo:           repo,
+		pagination:     pagination,
+		certifications: certifications,
+		logger:         logger,
 	}
 }
 
 func (s *MovieService) GetMovies(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, int32, error) {
-	s.logger.Info("Getting movies with filter", "page", filter.Page, "limit", filter.Limit)
+	logging.FromContext(ctx, s.logger).Debug("Getting movies with filter", "page", filter.Page, "limit", filter.Limit)
 
 	// Validate filter
-	if filter.Page < 1 {
-		filter.Page = 1
+	page, limit, err := s.pagination.Normalize(filter.Page, filter.Limit)
+	if err != nil {
+		return nil, 0, domain.NewFieldError("page", err)
 	}
-	if filter.Limit < 1 || filter.Limit > 100 {
-		filter.Limit = 10
+	filter.Page, filter.Limit = page, limit
+	if err := s.validateFilter(&filter); err != nil {
+		return nil, 0, err
 	}
 
 	movies, err := s.repo.FindAll(ctx, filter)
 	if err != nil {
-		s.logger.Error("Failed to get movies", "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to get movies", "error", err)
 		return nil, 0, fmt.Errorf("failed to get movies: %w", err)
 	}
+	if filter.SkipCount {
+		logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movies without counting", "count", len(movies))
+		return movies, domain.UncountedTotal, nil
+	}
 
-	total, err := s.repo.Count(ctx)
+	total, err := s.repo.Count(ctx, filter)
 	if err != nil {
-		s.logger.Error("Failed to count movies", "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to count movies", "error", err)
 		return movies, 0, nil // Return movies even if count fails
 	}
 
-	s.logger.Info("Successfully retrieved movies", "count", len(movies), "total", total)
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movies", "count", len(movies), "total", total)
 	return movies, total, nil
 }
 
 func (s *MovieService) GetMovie(ctx context.Context, id int32) (*domain.Movie, error) {
-	s.logger.Info("Getting movie by ID", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("Getting movie by ID", "id", id)
 
 	if id <= 0 {
 		return nil, domain.ErrInvalidMovieData
@@ -57,54 +69,190 @@ func (s *MovieService) GetMovie(ctx context.Context, id int32) (*domain.Movie, e
 
 	movie, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get movie", "id", id, "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie", "id", id, "error", err)
 		return nil, fmt.Errorf("failed to get movie with id %d: %w", id, err)
 	}
 
-	s.logger.Info("Successfully retrieved movie", "id", id, "title", movie.Title)
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie", "id", id, "title", movie.Title)
 	return movie, nil
 }
 
-func (s *MovieService) CreateMovie(ctx context.Context, title, year string) (*domain.Movie, error) {
-	s.logger.Info("Creating new movie", "title", title, "year", year)
+// GetMovieByExternalID returns the movie with the ID in the external catalog source
+func (s *MovieService) GetMovieByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("Getting movie by external ID", "source", source, "external_id", id)
+
+	id, err := domain.NormalizeExternalID(source, id)
+	if err != nil {
+		return nil, domain.NewFieldError("external_id", err)
+	}
+
+	movie, err := s.repo.FindByExternalID(ctx, source, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie by external ID", "source", source, "external_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get movie with %s ID %s: %w", source, id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie by external ID", "source", source, "external_id", id, "id", movie.ID)
+	return movie, nil
+}
+
+func (s *MovieService) CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("Creating new movie", "title", input.Title, "year", input.Year)
 
 	// Get next available ID
 	nextID, err := s.repo.GetNextID(ctx)
 	if err != nil {
-		s.logger.Error("Failed to get next ID", "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to get next ID", "error", err)
 		return nil, fmt.Errorf("failed to generate movie ID: %w", err)
 	}
 
 	// Create and validate movie
-	movie, err := domain.NewMovie(nextID, title, year)
+	movie, err := s.newMovie(nextID, input)
 	if err != nil {
-		s.logger.Error("Invalid movie data", "title", title, "year", year, "error", err)
-		return nil, fmt.Errorf("invalid movie data: %w", err)
+		logging.FromContext(ctx, s.logger).Error("Invalid movie data", "title", input.Title, "year", input.Year, "error", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
 	}
 
 	// Check if movie with same ID already exists
 	exists, err := s.repo.ExistsByID(ctx, movie.ID)
 	if err != nil {
-		s.logger.Error("Failed to check movie existence", "id", movie.ID, "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to check movie existence", "id", movie.ID, "error", err)
 		return nil, fmt.Errorf("failed to check movie existence: %w", err)
 	}
 	if exists {
 		return nil, domain.ErrMovieAlreadyExists
 	}
+	if err := s.checkExternalIDs(ctx, movie); err != nil {
+		return nil, err
+	}
 
 	// Save movie
 	createdMovie, err := s.repo.Create(ctx, movie)
 	if err != nil {
-		s.logger.Error("Failed to create movie", "movie", movie, "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to create movie", "movie", movie, "error", err)
 		return nil, fmt.Errorf("failed to create movie: %w", err)
 	}
 
-	s.logger.Info("Successfully created movie", "id", createdMovie.ID, "title", createdMovie.Title)
+	logging.FromContext(ctx, s.logger).Debug("Successfully created movie", "id", createdMovie.ID, "title", createdMovie.Title)
 	return createdMovie, nil
 }
 
+func (s *MovieService) UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error) {
+	logging.FromContext(ctx, s.logger).Debug("Upserting movie", "id", id, "title", input.Title, "year", input.Year)
+
+	if id <= 0 {
+		return nil, false, domain.ErrInvalidMovieData
+	}
+
+	movie, err := s.newMovie(id, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Invalid movie data", "id", id, "title", input.Title, "year", input.Year, "error", err)
+		return nil, false, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+	if err := s.checkExternalIDs(ctx, movie); err != nil {
+		return nil, false, err
+	}
+
+	upserted, created, err := s.repo.Upsert(ctx, movie)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to upsert movie", "movie", movie, "error", err)
+		return nil, false, fmt.Errorf("failed to upsert movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully upserted movie", "id", upserted.ID, "created", created)
+	return upserted, created, nil
+}
+
+// UpdateMovie replaces an existing movie, failing with ErrMovieNotFound instead of
+// creating it as UpsertMovie does
+func (s *MovieService) UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error) {
+	return s.updateMovie(ctx, id, input, nil)
+}
+
+// UpdateMovieIfVersion replaces an existing movie only if its current version matches
+func (s *MovieService) UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error) {
+	return s.updateMovie(ctx, id, input, &version)
+}
+
+// updateMovie replaces the movie at the expected version, or at the version it has now
+// when none is expected, so a change stored in between still fails with
+// ErrVersionMismatch
+func (s *MovieService) updateMovie(ctx context.Context, id int32, input domain.MovieInput, expected *int64) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("Updating movie", "id", id, "expected_version", expected)
+
+	if id <= 0 {
+		return nil, domain.ErrInvalidMovieData
+	}
+
+	movie, err := s.newMovie(id, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Invalid movie data", "id", id, "title", input.Title, "year", input.Year, "error", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+	if err := s.checkExternalIDs(ctx, movie); err != nil {
+		return nil, err
+	}
+
+	var version int64
+	if expected != nil {
+		version = *expected
+	} else if version, err = s.repo.FindVersion(ctx, id); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateVersion(ctx, movie, version)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to update movie", "id", id, "version", version, "error", err)
+		return nil, fmt.Errorf("failed to update movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully updated movie", "id", updated.ID, "version", updated.Version)
+	return updated, nil
+}
+
+// PatchMovie changes the fields of the movie named in the patch. The movie is rebuilt and
+// checked as a whole, then stored only if no other change was stored since it was read
+func (s *MovieService) PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error) {
+	logging.FromContext(ctx, s.logger).Debug("Patching movie", "id", id, "paths", patch.Paths)
+
+	if id <= 0 {
+		return nil, domain.ErrInvalidMovieData
+	}
+
+	current, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.ExpectedVersion != nil && current.Version != *patch.ExpectedVersion {
+		logging.FromContext(ctx, s.logger).Debug("Movie version mismatch on patch", "id", id, "version", current.Version, "expected", *patch.ExpectedVersion)
+		return nil, domain.ErrVersionMismatch
+	}
+
+	input, err := patch.Apply(current.Input())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+	movie, err := s.newMovie(id, input)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Invalid movie data", "id", id, "paths", patch.Paths, "error", err)
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
+	if err := s.checkExternalIDs(ctx, movie); err != nil {
+		return nil, err
+	}
+
+	patched, err := s.repo.UpdateVersion(ctx, movie, current.Version)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to patch movie", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to patch movie: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully patched movie", "id", patched.ID, "version", patched.Version)
+	return patched, nil
+}
+
 func (s *MovieService) DeleteMovie(ctx context.Context, id int32) error {
-	s.logger.Info("Deleting movie", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("Deleting movie", "id", id)
 
 	if id <= 0 {
 		return domain.ErrInvalidMovieData
@@ -113,7 +261,7 @@ func (s *MovieService) DeleteMovie(ctx context.Context, id int32) error {
 	// Check if movie exists
 	exists, err := s.repo.ExistsByID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to check movie existence", "id", id, "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to check movie existence", "id", id, "error", err)
 		return fmt.Errorf("failed to check movie existence: %w", err)
 	}
 	if !exists {
@@ -122,10 +270,162 @@ func (s *MovieService) DeleteMovie(ctx context.Context, id int32) error {
 
 	// Delete movie
 	if err := s.repo.Delete(ctx, id); err != nil {
-		s.logger.Error("Failed to delete movie", "id", id, "error", err)
+		logging.FromContext(ctx, s.logger).Error("Failed to delete movie", "id", id, "error", err)
 		return fmt.Errorf("failed to delete movie with id %d: %w", id, err)
 	}
 
-	s.logger.Info("Successfully deleted movie", "id", id)
+	logging.FromContext(ctx, s.logger).Debug("Successfully deleted movie", "id", id)
+	return nil
+}
+
+func (s *MovieService) DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error {
+	logging.FromContext(ctx, s.logger).Debug("Deleting movie if version matches", "id", id, "version", version)
+
+	if id <= 0 {
+		return domain.ErrInvalidMovieData
+	}
+
+	if err := s.repo.DeleteVersion(ctx, id, version); err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to delete movie", "id", id, "version", version, "error", err)
+		return fmt.Errorf("failed to delete movie with id %d: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully deleted movie", "id", id, "version", version)
+	return nil
+}
+
+// GetMovieFacets returns the facet counts of the movies matching the filter; pagination is ignored
+func (s *MovieService) GetMovieFacets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	logging.FromContext(ctx, s.logger).Debug("Getting movie facets")
+
+	if err := s.validateFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	facets, err := s.repo.Facets(ctx, filter)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie facets", "error", err)
+		return nil, fmt.Errorf("failed to get movie facets: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie facets", "total", facets.Total)
+	return facets, nil
+}
+
+// GetMovieHistory returns every change of the movie, oldest first, including changes
+// made before it was deleted
+func (s *MovieService) GetMovieHistory(ctx context.Context, id int32) ([]domain.MovieRevision, error) {
+	logging.FromContext(ctx, s.logger).Debug("Getting movie history", "id", id)
+
+	if id <= 0 {
+		return nil, domain.ErrInvalidMovieData
+	}
+
+	revisions, err := s.repo.History(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to get movie history", "id", id, "error", err)
+		return nil, fmt.Errorf("failed to get history of movie with id %d: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Successfully retrieved movie history", "id", id, "revisions", len(revisions))
+	return revisions, nil
+}
+
+// GetMovieVersion returns the current version of the movie without loading it
+func (s *MovieService) GetMovieVersion(ctx context.Context, id int32) (int64, error) {
+	if id <= 0 {
+		return 0, domain.ErrInvalidMovieData
+	}
+
+	version, err := s.repo.FindVersion(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get version of movie with id %d: %w", id, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Retrieved movie version", "id", id, "version", version)
+	return version, nil
+}
+
+// ExportMovies returns up to limit movies, archived ones included, following afterID in
+// ID order
+func (s *MovieService) ExportMovies(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error) {
+	if afterID < 0 || limit <= 0 {
+		return nil, domain.ErrInvalidMovieData
+	}
+
+	movies, err := s.repo.Export(ctx, afterID, limit)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).Error("Failed to export movies", "after_id", afterID, "error", err)
+		return nil, fmt.Errorf("failed to export movies: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Debug("Exported movies", "after_id", afterID, "count", len(movies))
+	return movies, nil
+}
+
+// newMovie builds a validated movie from client input
+func (s *MovieService) newMovie(id int32, input domain.MovieInput) (*domain.Movie, error) {
+	movie, err := domain.NewMovieFromInput(id, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if movie.Certification != "" {
+		if movie.Certification, err = s.certifications.Normalize(movie.Certification); err != nil {
+			return nil, domain.NewFieldError("certification", err)
+		}
+	}
+
+	return movie, nil
+}
+
+// checkExternalIDs fails with ErrExternalIDTaken when another movie holds one of the
+// external IDs of the movie. The unique indexes catch concurrent writes, except in the
+// projection of the event store, which only this check protects
+func (s *MovieService) checkExternalIDs(ctx context.Context, movie *domain.Movie) error {
+	for _, source := range domain.ExternalSources {
+		id := movie.ExternalID(source)
+		if id == "" {
+			continue
+		}
+
+		holder, err := s.repo.FindByExternalID(ctx, source, id)
+		if errors.Is(err, domain.ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check %s ID: %w", source, err)
+		}
+		if holder.ID != movie.ID {
+			return domain.NewFieldError(source+"_id", fmt.Errorf("%w: %s ID %s belongs to movie %d", domain.ErrExternalIDTaken, source, id, holder.ID))
+		}
+	}
+	return nil
+}
+
+// validateFilter checks the filter expression and normalizes the region and certification
+func (s *MovieService) validateFilter(filter *domain.MovieFilter) error {
+	if filter.Expr != nil {
+		if err := filter.Expr.Validate(); err != nil {
+			return domain.NewFieldError("filter", err)
+		}
+	}
+
+	if filter.Region != "" {
+		region, err := domain.NormalizeRegion(filter.Region)
+		if err != nil {
+			return domain.NewFieldError("region", err)
+		}
+		filter.Region = region
+	}
+
+	if filter.Certification != "" {
+		certification, err := s.certifications.Normalize(filter.Certification)
+		if err != nil {
+			return domain.NewFieldError("certification", err)
+		}
+		filter.Certification = certification
+	}
+
 	return nil
 }
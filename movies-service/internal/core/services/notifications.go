@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/logging"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// NotificationChannel is a notifier with the event types it is enabled for
+type NotificationChannel struct {
+	Notifier ports.CatalogNotifier
+	Events   []domain.MovieEventType
+}
+
+// NotifyingMovieService announces the catalog changes made through the wrapped service.
+// Notifications are sent by the worker pool once the change is stored, so a slow or
+// failing channel neither delays nor fails the call; the pool retries and logs failures,
+// and notifications beyond its queue are dropped
+type NotifyingMovieService struct {
+	ports.MovieService
+	channels []NotificationChannel
+	timeout  time.Duration
+	pool     *workerpool.Pool
+	clock    clock.Clock
+	logger   *slog.Logger
+}
+
+func NewNotifyingMovieService(service ports.MovieService, channels []NotificationChannel, timeout time.Duration, pool *workerpool.Pool, clk clock.Clock, logger *slog.Logger) *NotifyingMovieService {
+	return &NotifyingMovieService{
+		MovieService: service,
+		channels:     channels,
+		timeout:      timeout,
+		pool:         pool,
+		clock:        clk,
+		logger:       logger,
+	}
+}
+
+func (s *NotifyingMovieService) CreateMovie(ctx context.Context, input domain.MovieInput) (*domain.Movie, error) {
+	movie, err := s.MovieService.CreateMovie(ctx, input)
+	if err == nil {
+		s.publish(ctx, domain.MovieCreated, movie)
+	}
+	return movie, err
+}
+
+func (s *NotifyingMovieService) UpsertMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, bool, error) {
+	movie, created, err := s.MovieService.UpsertMovie(ctx, id, input)
+	if err == nil {
+		eventType := domain.MovieReplaced
+		if created {
+			eventType = domain.MovieCreated
+		}
+		s.publish(ctx, eventType, movie)
+	}
+	return movie, created, err
+}
+
+func (s *NotifyingMovieService) UpdateMovie(ctx context.Context, id int32, input domain.MovieInput) (*domain.Movie, error) {
+	movie, err := s.MovieService.UpdateMovie(ctx, id, input)
+	if err == nil {
+		s.publish(ctx, domain.MovieReplaced, movie)
+	}
+	return movie, err
+}
+
+func (s *NotifyingMovieService) UpdateMovieIfVersion(ctx context.Context, id int32, input domain.MovieInput, version int64) (*domain.Movie, error) {
+	movie, err := s.MovieService.UpdateMovieIfVersion(ctx, id, input, version)
+	if err == nil {
+		s.publish(ctx, domain.MovieReplaced, movie)
+	}
+	return movie, err
+}
+
+func (s *NotifyingMovieService) PatchMovie(ctx context.Context, id int32, patch domain.MoviePatch) (*domain.Movie, error) {
+	movie, err := s.MovieService.PatchMovie(ctx, id, patch)
+	if err == nil {
+		s.publish(ctx, domain.MovieReplaced, movie)
+	}
+	return movie, err
+}
+
+func (s *NotifyingMovieService) DeleteMovie(ctx context.Context, id int32) error {
+	before := s.deleted(ctx, id)
+	err := s.MovieService.DeleteMovie(ctx, id)
+	if err == nil {
+		s.publish(ctx, domain.MovieDeleted, before)
+	}
+	return err
+}
+
+func (s *NotifyingMovieService) DeleteMovieIfVersion(ctx context.Context, id int32, version int64) error {
+	before := s.deleted(ctx, id)
+	err := s.MovieService.DeleteMovieIfVersion(ctx, id, version)
+	if err == nil {
+		s.publish(ctx, domain.MovieDeleted, before)
+	}
+	return err
+}
+
+// deleted reads the movie about to be deleted, so the notification can name it. It is
+// only read when a channel announces deletions
+func (s *NotifyingMovieService) deleted(ctx context.Context, id int32) *domain.Movie {
+	if !s.enabled(domain.MovieDeleted) {
+		return nil
+	}
+	movie, err := s.MovieService.GetMovie(ctx, id)
+	if err != nil {
+		return &domain.Movie{ID: id}
+	}
+	return movie
+}
+
+func (s *NotifyingMovieService) enabled(eventType domain.MovieEventType) bool {
+	for _, channel := range s.channels {
+		if slices.Contains(channel.Events, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NotifyingMovieService) publish(ctx context.Context, eventType domain.MovieEventType, movie *domain.Movie) {
+	event := domain.CatalogEvent{Type: eventType, Movie: movie, OccurredAt: s.clock.Now().UTC()}
+	for _, channel := range s.channels {
+		if !slices.Contains(channel.Events, eventType) {
+			continue
+		}
+		notifier := channel.Notifier
+		name := fmt.Sprintf("%s %s notification of movie %d", notifier.Name(), eventType, movie.ID)
+		err := s.pool.Submit(name, func(ctx context.Context) error {
+			// Notifications queued before a shutdown are still sent
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.timeout)
+			defer cancel()
+			return notifier.Notify(ctx, event)
+		})
+		if err != nil {
+			logging.FromContext(ctx, s.logger).Warn("Dropped catalog notification", "channel", notifier.Name(),
+				"event", eventType, "movie_id", movie.ID, "error", err)
+		}
+	}
+}
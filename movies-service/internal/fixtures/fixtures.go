@@ -0,0 +1,189 @@
+// Package fixtures loads datasets of movies into a movie repository, for the tests of
+// every adapter and for seeding a local database. A dataset is a YAML or JSON document
+// listing the movies with the fields of the API:
+//
+//	movies:
+//	  - id: 1
+//	    title: Heat
+//	    year: "1995"
+//	    genres: [Crime, Drama]
+//
+// Years are strings, as in the API, so YAML needs them quoted. A plain list of movies,
+// as in the movies.json written by the init script, is accepted as well
+package fixtures
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+)
+
+// ErrUnknownDataset is returned by Open for a name no embedded dataset has
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+//go:embed datasets/*.yaml
+var datasets embed.FS
+
+// Movie is a movie of a dataset
+type Movie struct {
+	// ID is the ID the movie is stored under. Without one the movie takes the ID of the
+	// stored movie with the same IMDb ID, or a new one
+	ID int32 `json:"id,omitempty"`
+	domain.MovieInput
+}
+
+// Dataset is a list of movies to load
+type Dataset struct {
+	Movies []Movie `json:"movies"`
+}
+
+// Result counts the movies of a dataset by what loading them did
+type Result struct {
+	Created   int
+	Replaced  int
+	Unchanged int
+	// Movies holds the movies as stored, in the order of the dataset
+	Movies []*domain.Movie
+}
+
+// Names lists the embedded datasets
+func Names() []string {
+	entries, _ := datasets.ReadDir("datasets")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open returns the embedded dataset with the name
+func Open(name string) (*Dataset, error) {
+	data, err := datasets.ReadFile(path.Join("datasets", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("%w %q, expected one of %s", ErrUnknownDataset, name, strings.Join(Names(), ", "))
+	}
+	dataset, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("dataset %s: %w", name, err)
+	}
+	return dataset, nil
+}
+
+// ReadFile reads a dataset from a YAML or JSON file
+func ReadFile(name string) (*Dataset, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	dataset, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return dataset, nil
+}
+
+// Parse decodes a dataset from YAML or JSON, which YAML includes. The document goes
+// through JSON to be decoded with the names of the API, rejecting unknown fields so a
+// misspelled one is not silently dropped
+func Parse(data []byte) (*Dataset, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+	if list, ok := document.([]interface{}); ok {
+		document = map[string]interface{}{"movies": list}
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	var dataset Dataset
+	if err := decoder.Decode(&dataset); err != nil {
+		return nil, fmt.Errorf("invalid dataset: %w", err)
+	}
+	return &dataset, nil
+}
+
+// Load validates the movies of the dataset as the service does on creation, accepting
+// only the given certifications, and stores them in the repository, replacing the movies
+// with the same IDs. Movies already stored as in the dataset are left as they are, so
+// loading a dataset again changes nothing. It stops at the first movie that cannot be
+// stored, naming it in the error
+func Load(ctx context.Context, repo ports.MovieRepository, certifications domain.Certifications, dataset *Dataset) (*Result, error) {
+	result := &Result{Movies: make([]*domain.Movie, 0, len(dataset.Movies))}
+	for i, fixture := range dataset.Movies {
+		stored, err := load(ctx, repo, certifications, fixture, result)
+		if err != nil {
+			return result, fmt.Errorf("movie %d (%q): %w", i+1, fixture.Title, err)
+		}
+		result.Movies = append(result.Movies, stored)
+	}
+	return result, nil
+}
+
+// load stores a movie of a dataset, counting what it did in the result
+func load(ctx context.Context, repo ports.MovieRepository, certifications domain.Certifications, fixture Movie, result *Result) (*domain.Movie, error) {
+	id := fixture.ID
+	if id == 0 && fixture.IMDbID != "" {
+		existing, err := repo.FindByExternalID(ctx, domain.SourceIMDb, fixture.IMDbID)
+		if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+			return nil, err
+		}
+		if existing != nil {
+			id = existing.ID
+		}
+	}
+	if id == 0 {
+		next, err := repo.GetNextID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		id = next
+	}
+
+	movie, err := domain.NewMovieFromInput(id, fixture.MovieInput)
+	if err != nil {
+		return nil, err
+	}
+	if movie.Certification != "" {
+		if movie.Certification, err = certifications.Normalize(movie.Certification); err != nil {
+			return nil, domain.NewFieldError("certification", err)
+		}
+	}
+
+	existing, err := repo.FindByID(ctx, id)
+	if err != nil && !errors.Is(err, domain.ErrMovieNotFound) {
+		return nil, err
+	}
+	if existing != nil && len(domain.DiffMovies(existing, movie).Fields()) == 0 {
+		result.Unchanged++
+		return existing, nil
+	}
+
+	stored, created, err := repo.Upsert(ctx, movie)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		result.Created++
+	} else {
+		result.Replaced++
+	}
+	return stored, nil
+}
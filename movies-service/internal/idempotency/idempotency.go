@@ -0,0 +1,35 @@
+// Package idempotency stores the first result of requests sent with a client-supplied
+// idempotency key, so retries of the same request get that result back instead of
+// running again. Keys expire after a TTL, after which a retry runs as a new request.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is the stored state of an idempotency key
+type Record struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string
+	// Response is the encoded result of the request; it is empty while the request runs
+	Response []byte
+}
+
+// Done reports whether the request of the key has completed
+func (r Record) Done() bool {
+	return len(r.Response) > 0
+}
+
+// Store keeps idempotency records identified by key
+type Store interface {
+	// Reserve records key for the request with the given fingerprint when the key is
+	// unused or expired, reporting true. Otherwise it returns the existing record and false.
+	// The key is held for lease while the request runs, so a request whose outcome is never
+	// recorded only blocks its retries that long
+	Reserve(ctx context.Context, key, fingerprint string, lease time.Duration) (Record, bool, error)
+	// Complete stores the response of the request holding key, keeping it for ttl
+	Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error
+	// Release forgets key so the request can be retried, after it failed
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,58 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/proto/clock"
+)
+
+// Memory is an in-process Store, for single-replica deployments and tests
+type Memory struct {
+	mu      sync.Mutex
+	records map[string]entry
+	clock   clock.Clock
+}
+
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+// NewMemory returns a Store expiring keys by clk
+func NewMemory(clk clock.Clock) *Memory {
+	return &Memory{records: make(map[string]entry), clock: clk}
+}
+
+func (m *Memory) Reserve(ctx context.Context, key, fingerprint string, lease time.Duration) (Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	if current, ok := m.records[key]; ok && now.Before(current.expires) {
+		return current.record, false, nil
+	}
+	m.records[key] = entry{record: Record{Fingerprint: fingerprint}, expires: now.Add(lease)}
+	return Record{}, true, nil
+}
+
+func (m *Memory) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.records[key]; ok {
+		current.record.Response = response
+		current.expires = m.clock.Now().Add(ttl)
+		m.records[key] = current
+	}
+	return nil
+}
+
+func (m *Memory) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.records, key)
+	return nil
+}
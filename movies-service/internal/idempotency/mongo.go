@@ -0,0 +1,88 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyCollection = "idempotency_keys"
+
+// Mongo stores each key as a document of the idempotency_keys collection, removed by a
+// TTL index once it expires
+type Mongo struct {
+	collection *mongo.Collection
+}
+
+func NewMongo(client *mongo.Client, databaseName string) *Mongo {
+	return &Mongo{collection: client.Database(databaseName).Collection(idempotencyCollection)}
+}
+
+type document struct {
+	Key         string    `bson:"_id"`
+	Fingerprint string    `bson:"fingerprint"`
+	Response    []byte    `bson:"response,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// EnsureIndexes creates the TTL index that removes expired keys
+func (m *Mongo) EnsureIndexes(ctx context.Context) error {
+	_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("expires_at").SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency key index: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) Reserve(ctx context.Context, key, fingerprint string, lease time.Duration) (Record, bool, error) {
+	now := time.Now().UTC()
+
+	// The TTL monitor runs about once a minute, so expired keys may still be stored
+	filter := bson.M{"_id": key, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set":   bson.M{"fingerprint": fingerprint, "expires_at": now.Add(lease)},
+		"$unset": bson.M{"response": ""},
+	}
+
+	_, err := m.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return Record{}, true, nil
+	}
+	// The upsert collides with the document of a live key
+	if !mongo.IsDuplicateKeyError(err) {
+		return Record{}, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var existing document
+	if err := m.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&existing); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Released between the upsert and the lookup; let the client retry
+			return Record{Fingerprint: fingerprint}, false, nil
+		}
+		return Record{}, false, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return Record{Fingerprint: existing.Fingerprint, Response: existing.Response}, false, nil
+}
+
+func (m *Mongo) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	update := bson.M{"$set": bson.M{"response": response, "expires_at": time.Now().UTC().Add(ttl)}}
+	if _, err := m.collection.UpdateByID(ctx, key, update); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (m *Mongo) Release(ctx context.Context, key string) error {
+	if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
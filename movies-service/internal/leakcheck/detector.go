@@ -0,0 +1,146 @@
+package leakcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Resources watched by a Detector, as named in its logs and metrics
+const (
+	ResourceGoroutines = "goroutines"
+	ResourceOpenFiles  = "open_files"
+	ResourceStreams    = "grpc_streams"
+)
+
+var resources = []string{ResourceGoroutines, ResourceOpenFiles, ResourceStreams}
+
+// DetectorOptions configures a Detector
+type DetectorOptions struct {
+	// Interval is the time between two snapshots
+	Interval time.Duration
+	// Window is the number of snapshots reduced to their lowest counts (default 10)
+	Window int
+	// Windows is the number of consecutive windows whose lowest count must rise for a
+	// resource to be reported as leaking (default 3)
+	Windows int
+}
+
+// Detector watches the counts of the running process for leaks. Load makes them go up
+// and down, so each window of snapshots is reduced to its lowest counts, the floor the
+// process returns to between requests; only a leak keeps raising it. A resource whose
+// floor rose in each of the last windows is reported as suspected, logged once and
+// written as a metric until its floor stops rising
+type Detector struct {
+	probe  Probe
+	opts   DetectorOptions
+	logger *slog.Logger
+
+	mu sync.Mutex
+	// window holds the lowest counts of the current window and samples its size
+	window  map[string]int64
+	samples int
+	// floors holds the lowest counts of the last windows, oldest first
+	floors    map[string][]int64
+	suspected map[string]bool
+}
+
+func NewDetector(probe Probe, opts DetectorOptions, logger *slog.Logger) *Detector {
+	if opts.Window < 1 {
+		opts.Window = 10
+	}
+	if opts.Windows < 1 {
+		opts.Windows = 3
+	}
+	return &Detector{
+		probe:     probe,
+		opts:      opts,
+		logger:    logger,
+		floors:    make(map[string][]int64),
+		suspected: make(map[string]bool),
+	}
+}
+
+// Run takes a snapshot every interval until ctx ends
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.Observe(d.probe.Take())
+		}
+	}
+}
+
+// Observe adds a snapshot to the current window, closing it once full
+func (d *Detector) Observe(snapshot Snapshot) {
+	counts := map[string]int64{
+		ResourceGoroutines: int64(snapshot.Goroutines),
+		ResourceOpenFiles:  int64(snapshot.OpenFiles),
+		ResourceStreams:    snapshot.Streams,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.samples == 0 {
+		d.window = counts
+	}
+	for resource, count := range counts {
+		d.window[resource] = min(d.window[resource], count)
+	}
+	d.samples++
+	if d.samples < d.opts.Window {
+		return
+	}
+	d.samples = 0
+
+	for _, resource := range resources {
+		floor := d.window[resource]
+		// Platforms that don't list open files report -1
+		if floor < 0 {
+			continue
+		}
+		floors := append(d.floors[resource], floor)
+		if len(floors) > d.opts.Windows+1 {
+			floors = floors[1:]
+		}
+		d.floors[resource] = floors
+
+		rising := len(floors) == d.opts.Windows+1
+		for i := 1; i < len(floors) && rising; i++ {
+			rising = floors[i] > floors[i-1]
+		}
+		if rising && !d.suspected[resource] {
+			d.logger.Warn("Possible resource leak, its count keeps growing between requests",
+				"resource", resource, "floors", floors, "window", d.opts.Interval*time.Duration(d.opts.Window))
+		}
+		d.suspected[resource] = rising
+	}
+}
+
+// Suspected reports whether the resource is suspected of leaking
+func (d *Detector) Suspected(resource string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.suspected[resource]
+}
+
+// WritePrometheus writes which resources are suspected of leaking in the Prometheus text
+// format, which OpenMetrics accepts as well
+func (d *Detector) WritePrometheus(w io.Writer) {
+	fmt.Fprintln(w, "# HELP movies_leak_suspected Whether the lowest count of the resource rose in each of the last windows.")
+	fmt.Fprintln(w, "# TYPE movies_leak_suspected gauge")
+	for _, resource := range resources {
+		suspected := 0
+		if d.Suspected(resource) {
+			suspected = 1
+		}
+		fmt.Fprintf(w, "movies_leak_suspected{resource=%q} %d\n", resource, suspected)
+	}
+}
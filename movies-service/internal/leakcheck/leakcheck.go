@@ -0,0 +1,148 @@
+// Package leakcheck finds the goroutines, file descriptors and gRPC streams a process
+// keeps after the work that needed them is over. Tests take a snapshot before running a
+// load and settle back to it afterwards; a Detector watches the running service for
+// counts that keep growing
+package leakcheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+)
+
+// settleInterval is the time between the snapshots taken by Settle
+const settleInterval = 50 * time.Millisecond
+
+// Snapshot counts the resources held by the process at a point in time
+type Snapshot struct {
+	Goroutines int
+	// OpenFiles counts the open file descriptors, sockets included, or is -1 where the
+	// platform doesn't list them
+	OpenFiles int
+	// Streams counts the gRPC streams being served
+	Streams int64
+	// stacks counts the goroutines by stack, to tell which ones were left behind
+	stacks map[string]int
+}
+
+// Tolerance is how far above the baseline a count may stay without being a leak, for
+// the goroutines and connections the runtime and the libraries keep in pools
+type Tolerance struct {
+	Goroutines int
+	OpenFiles  int
+}
+
+// Probe takes snapshots of the process
+type Probe struct {
+	// Streams, when set, returns the number of gRPC streams being served
+	Streams func() int64
+}
+
+// Take returns the current counts
+func (p Probe) Take() Snapshot {
+	snapshot := Snapshot{OpenFiles: OpenFiles(), stacks: goroutineStacks()}
+	for _, count := range snapshot.stacks {
+		snapshot.Goroutines += count
+	}
+	if p.Streams != nil {
+		snapshot.Streams = p.Streams()
+	}
+	return snapshot
+}
+
+// Settle waits until no count exceeds the baseline by more than the tolerance, as the
+// resources released at the end of a load are closed asynchronously. It fails once ctx
+// ends with the counts still above it, listing the goroutines left behind by their stacks
+func (p Probe) Settle(ctx context.Context, baseline Snapshot, tolerance Tolerance) (Snapshot, error) {
+	ticker := time.NewTicker(settleInterval)
+	defer ticker.Stop()
+	for {
+		current := p.Take()
+		leaks := current.Leaks(baseline, tolerance)
+		if len(leaks) == 0 {
+			return current, nil
+		}
+		select {
+		case <-ctx.Done():
+			return current, fmt.Errorf("resources leaked: %s\n%s", strings.Join(leaks, ", "), current.grownStacks(baseline))
+		case <-ticker.C:
+		}
+	}
+}
+
+// Leaks describes the counts of s exceeding the baseline by more than the tolerance
+func (s Snapshot) Leaks(baseline Snapshot, tolerance Tolerance) []string {
+	var leaks []string
+	if s.Goroutines > baseline.Goroutines+tolerance.Goroutines {
+		leaks = append(leaks, fmt.Sprintf("%d goroutines, baseline %d", s.Goroutines, baseline.Goroutines))
+	}
+	if s.OpenFiles >= 0 && baseline.OpenFiles >= 0 && s.OpenFiles > baseline.OpenFiles+tolerance.OpenFiles {
+		leaks = append(leaks, fmt.Sprintf("%d open files, baseline %d", s.OpenFiles, baseline.OpenFiles))
+	}
+	if s.Streams > baseline.Streams {
+		leaks = append(leaks, fmt.Sprintf("%d gRPC streams, baseline %d", s.Streams, baseline.Streams))
+	}
+	return leaks
+}
+
+// grownStacks lists the stacks with more goroutines than in the baseline, most first
+func (s Snapshot) grownStacks(baseline Snapshot) string {
+	type grown struct {
+		stack string
+		extra int
+	}
+	var list []grown
+	for stack, count := range s.stacks {
+		if extra := count - baseline.stacks[stack]; extra > 0 {
+			list = append(list, grown{stack, extra})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].extra > list[j].extra })
+
+	var out strings.Builder
+	for _, g := range list {
+		fmt.Fprintf(&out, "%d more goroutines in:\n%s\n", g.extra, g.stack)
+	}
+	return out.String()
+}
+
+// goroutineStacks counts the goroutines by stack, named by function and line. The
+// profile groups the goroutines with the same stack, each group headed by its count
+func goroutineStacks() map[string]int {
+	var profile bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&profile, 1)
+
+	stacks := make(map[string]int)
+	for _, group := range strings.Split(profile.String(), "\n\n") {
+		var count int
+		if _, err := fmt.Sscanf(group, "%d @", &count); err != nil {
+			continue
+		}
+		var frames []string
+		for _, line := range strings.Split(group, "\n") {
+			// Frames are "#\t0x4a5b6c\tpackage.function+0x1f\tfile.go:12", padded with tabs
+			if fields := strings.Fields(line); len(fields) == 4 && fields[0] == "#" {
+				frames = append(frames, "\t"+strings.Split(fields[2], "+0x")[0]+" "+fields[3])
+			}
+		}
+		stacks[strings.Join(frames, "\n")] += count
+	}
+	return stacks
+}
+
+// OpenFiles returns the number of file descriptors open by the process, or -1 where the
+// platform doesn't list them
+func OpenFiles() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			// Reading the directory opened one more descriptor
+			return len(entries) - 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,79 @@
+// Package lock provides distributed locks so background workers run on exactly one
+// replica at a time. Locks are leases: they expire unless their owner renews them, so a
+// crashed replica never holds a lock forever.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrNotAcquired is returned by WithLock when another owner holds the lock
+var ErrNotAcquired = errors.New("lock held by another owner")
+
+// Locker stores leases identified by name
+type Locker interface {
+	// Acquire takes the named lock for ttl when it is free or expired, or renews it when
+	// owner already holds it, reporting whether owner holds it afterwards
+	Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// Release frees the named lock if owner holds it
+	Release(ctx context.Context, name, owner string) error
+}
+
+// DefaultOwner identifies this process among replicas
+func DefaultOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// WithLock runs fn while holding the named lock, renewing the lease in the background.
+// If a renewal fails the context passed to fn is cancelled, since another owner may
+// take over. It returns ErrNotAcquired without running fn when the lock is held elsewhere.
+func WithLock(ctx context.Context, locker Locker, name, owner string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	acquired, err := locker.Acquire(ctx, name, owner, ttl)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrNotAcquired
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		renew := time.NewTicker(ttl / 3)
+		defer renew.Stop()
+
+		for {
+			select {
+			case <-fnCtx.Done():
+				return
+			case <-renew.C:
+				if ok, err := locker.Acquire(fnCtx, name, owner, ttl); err != nil || !ok {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	err = fn(fnCtx)
+	cancel()
+	<-renewed
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer releaseCancel()
+	if releaseErr := locker.Release(releaseCtx, name, owner); releaseErr != nil && err == nil {
+		err = releaseErr
+	}
+	return err
+}
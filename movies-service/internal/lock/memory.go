@@ -0,0 +1,48 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/movie-microservice/proto/clock"
+)
+
+// Memory is an in-process Locker, for single-replica deployments and tests
+type Memory struct {
+	mu     sync.Mutex
+	leases map[string]lease
+	clock  clock.Clock
+}
+
+type lease struct {
+	owner   string
+	expires time.Time
+}
+
+// NewMemory returns a Locker expiring leases by clk
+func NewMemory(clk clock.Clock) *Memory {
+	return &Memory{leases: make(map[string]lease), clock: clk}
+}
+
+func (m *Memory) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	if current, ok := m.leases[name]; ok && current.owner != owner && now.Before(current.expires) {
+		return false, nil
+	}
+	m.leases[name] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (m *Memory) Release(ctx context.Context, name, owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases[name].owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const locksCollection = "locks"
+
+// Mongo stores each lock as a document of the locks collection. It relies on replica
+// clocks being roughly in sync, which lease TTLs should comfortably exceed.
+type Mongo struct {
+	collection *mongo.Collection
+}
+
+func NewMongo(client *mongo.Client, databaseName string) *Mongo {
+	return &Mongo{collection: client.Database(databaseName).Collection(locksCollection)}
+}
+
+func (m *Mongo) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(ttl)}}
+
+	_, err := m.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// The upsert collides with the document of the current holder
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+func (m *Mongo) Release(ctx context.Context, name, owner string) error {
+	if _, err := m.collection.DeleteOne(ctx, bson.M{"_id": name, "owner": owner}); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package scheduler runs recurring background jobs. When several replicas run, a shared
+// lock elects a single leader and only the leader runs jobs.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/lock"
+)
+
+// leaderLockName is the lock the replicas compete for
+const leaderLockName = "scheduler"
+
+type job struct {
+	name     string
+	schedule Schedule
+	run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	locker  lock.Locker
+	owner   string
+	ttl     time.Duration
+	jobs    []job
+	leader  atomic.Bool
+	logger  *slog.Logger
+	running sync.WaitGroup
+}
+
+// New creates a scheduler that elects its leader through locker, identifying this replica
+// as owner. A nil locker makes this replica always the leader.
+func New(locker lock.Locker, owner string, ttl time.Duration, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		locker: locker,
+		owner:  owner,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// Add registers a job run on the given schedule (see Parse)
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context) error) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// IsLeader reports whether this replica currently runs the jobs
+func (s *Scheduler) IsLeader() bool {
+	return s.leader.Load()
+}
+
+// Run runs the jobs until the context is cancelled, then waits for running jobs to
+// finish and gives up leadership
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("Scheduler started", "jobs", len(s.jobs), "owner", s.owner)
+
+	if s.locker == nil {
+		s.leader.Store(true)
+	} else {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.elect(ctx)
+		}()
+	}
+
+	for _, j := range s.jobs {
+		s.running.Add(1)
+		go func(j job) {
+			defer s.running.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+
+	<-ctx.Done()
+	s.running.Wait()
+
+	if s.locker != nil && s.leader.Load() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.locker.Release(releaseCtx, leaderLockName, s.owner); err != nil {
+			s.logger.Warn("Failed to release scheduler leadership", "error", err)
+		}
+	}
+	s.logger.Info("Scheduler stopped")
+}
+
+// elect keeps trying to acquire or renew the leader lease
+func (s *Scheduler) elect(ctx context.Context) {
+	renew := time.NewTicker(s.ttl / 3)
+	defer renew.Stop()
+
+	for {
+		acquired, err := s.locker.Acquire(ctx, leaderLockName, s.owner, s.ttl)
+		if err != nil {
+			// Without a renewed lease another replica may take over, so stop running jobs
+			acquired = false
+			if ctx.Err() == nil {
+				s.logger.Error("Failed to acquire scheduler leadership", "error", err)
+			}
+		}
+		if was := s.leader.Swap(acquired); was != acquired {
+			s.logger.Info("Scheduler leadership changed", "leader", acquired, "owner", s.owner)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-renew.C:
+		}
+	}
+}
+
+// loop waits for each activation of the job and runs it when this replica is the leader
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Warn("Job has no future activations", "job", j.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !s.leader.Load() {
+			continue
+		}
+
+		start := time.Now()
+		if err := j.run(ctx); err != nil {
+			s.logger.Error("Job failed", "job", j.name, "duration", time.Since(start), "error", err)
+			continue
+		}
+		s.logger.Info("Job completed", "job", j.name, "duration", time.Since(start))
+	}
+}
@@ -0,0 +1,74 @@
+package workerpool
+
+import (
+	"fmt"
+	"io"
+)
+
+// WritePrometheus writes the counts of the pools in the Prometheus text exposition format
+func WritePrometheus(w io.Writer, pools ...*Pool) {
+	write(w, false, pools)
+}
+
+// WriteOpenMetrics writes the counts of the pools in the OpenMetrics format, without the
+// final EOF marker, so they can precede other metrics
+func WriteOpenMetrics(w io.Writer, pools ...*Pool) {
+	write(w, true, pools)
+}
+
+func write(w io.Writer, openMetrics bool, pools []*Pool) {
+	if len(pools) == 0 {
+		return
+	}
+	stats := make([]Stats, len(pools))
+	for i, pool := range pools {
+		stats[i] = pool.Stats()
+	}
+
+	gauges := []struct {
+		name, help string
+		value      func(Stats) int
+	}{
+		{"movies_worker_pool_workers", "Workers of the pool.", func(s Stats) int { return s.Workers }},
+		{"movies_worker_pool_queue_depth", "Tasks waiting for a worker.", func(s Stats) int { return s.Queued }},
+		{"movies_worker_pool_running", "Tasks being run.", func(s Stats) int { return s.Running }},
+	}
+	for _, gauge := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", gauge.name, gauge.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", gauge.name)
+		for i, pool := range pools {
+			fmt.Fprintf(w, "%s{pool=%q} %d\n", gauge.name, pool.name, gauge.value(stats[i]))
+		}
+	}
+
+	// OpenMetrics names counter families without the _total suffix of their samples
+	family := "movies_worker_pool_tasks_total"
+	if openMetrics {
+		family = "movies_worker_pool_tasks"
+	}
+	fmt.Fprintf(w, "# HELP %s Tasks finished or refused, by outcome.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	for i, pool := range pools {
+		for _, outcome := range []struct {
+			name  string
+			count uint64
+		}{
+			{"completed", stats[i].Completed},
+			{"failed", stats[i].Failed},
+			{"panicked", stats[i].Panicked},
+			{"rejected", stats[i].Rejected},
+		} {
+			fmt.Fprintf(w, "movies_worker_pool_tasks_total{pool=%q,outcome=%q} %d\n", pool.name, outcome.name, outcome.count)
+		}
+	}
+
+	family = "movies_worker_pool_retries_total"
+	if openMetrics {
+		family = "movies_worker_pool_retries"
+	}
+	fmt.Fprintf(w, "# HELP %s Attempts of failed tasks run again.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	for i, pool := range pools {
+		fmt.Fprintf(w, "movies_worker_pool_retries_total{pool=%q} %d\n", pool.name, stats[i].Retried)
+	}
+}
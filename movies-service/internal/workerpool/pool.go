@@ -0,0 +1,253 @@
+// Package workerpool runs background tasks on a bounded number of goroutines. Tasks wait
+// in a bounded queue, failing tasks are retried by the retry policy of the pool and a
+// panicking task is recovered and logged instead of crashing the process. Each pool
+// counts its tasks, written as metrics
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/movie-microservice/proto/retry"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the queue is full
+	ErrQueueFull = errors.New("worker pool queue is full")
+	// ErrClosed is returned by Submit once the pool is closed
+	ErrClosed = errors.New("worker pool is closed")
+)
+
+// Task is the work submitted to a pool. Its context is canceled when the pool is closed
+type Task func(ctx context.Context) error
+
+// Options configures a pool
+type Options struct {
+	// Workers is the number of tasks run at once; a pool without workers accepts no tasks
+	Workers int
+	// QueueSize is the number of tasks waiting for a worker beyond which Submit fails
+	QueueSize int
+	// Retry decides when failing tasks run again; tasks run once when its MaxAttempts is
+	// below 1, and retries stop once the pool is closed
+	Retry retry.Policy
+}
+
+type task struct {
+	name string
+	run  Task
+}
+
+// Stats counts the tasks of a pool
+type Stats struct {
+	Workers int
+	Queued  int
+	Running int
+	// Completed, Failed and Panicked count the tasks by outcome, after their retries
+	Completed uint64
+	Failed    uint64
+	Panicked  uint64
+	// Rejected counts the tasks refused by Submit because the queue was full
+	Rejected uint64
+	Retried  uint64
+}
+
+type Pool struct {
+	name   string
+	opts   Options
+	logger *slog.Logger
+
+	queue  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mu guards closed against tasks submitted while the queue is closed
+	mu      sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+
+	// pending counts the tasks accepted and not finished yet, queued or running
+	pending   atomic.Int64
+	running   atomic.Int64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	panicked  atomic.Uint64
+	rejected  atomic.Uint64
+	retried   atomic.Uint64
+}
+
+// New starts a pool named name, used in its logs and metrics
+func New(name string, opts Options, logger *slog.Logger) *Pool {
+	if opts.Retry.MaxAttempts < 1 {
+		opts.Retry.MaxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		name:   name,
+		opts:   opts,
+		logger: logger.With("pool", name),
+		queue:  make(chan task, queueSize(opts)),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < opts.Workers; i++ {
+		p.workers.Add(1)
+		go func() {
+			defer p.workers.Done()
+			for t := range p.queue {
+				p.execute(t)
+				p.pending.Add(-1)
+			}
+		}()
+	}
+	return p
+}
+
+// queueSize returns the capacity of the queue; pools without workers queue nothing, since
+// nothing would run the tasks
+func queueSize(opts Options) int {
+	if opts.Workers < 1 {
+		return 0
+	}
+	return opts.QueueSize
+}
+
+// Name returns the name of the pool
+func (p *Pool) Name() string {
+	return p.name
+}
+
+// Submit queues a task without waiting, returning ErrQueueFull when the queue is full
+func (p *Pool) Submit(name string, run Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.pending.Add(1)
+	select {
+	case p.queue <- task{name: name, run: run}:
+		return nil
+	default:
+		p.pending.Add(-1)
+		p.rejected.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// SubmitWait queues a task, waiting for room in the queue until ctx is done or the pool
+// is closed
+func (p *Pool) SubmitWait(ctx context.Context, name string, run Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.pending.Add(1)
+	select {
+	case p.queue <- task{name: name, run: run}:
+		return nil
+	case <-ctx.Done():
+		p.pending.Add(-1)
+		return ctx.Err()
+	case <-p.ctx.Done():
+		p.pending.Add(-1)
+		return ErrClosed
+	}
+}
+
+// Idle reports whether a worker is free to run a task right away
+func (p *Pool) Idle() bool {
+	return int(p.pending.Load()) < p.opts.Workers
+}
+
+// Close stops accepting tasks, cancels the context of the running ones and waits for
+// them and for the queued ones, which still run. Tasks that must finish their work after
+// Close, such as a delivery with its own timeout, don't derive it from their context
+func (p *Pool) Close() {
+	p.cancel()
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	p.workers.Wait()
+}
+
+// Stats returns the current counts of the pool
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Workers:   p.opts.Workers,
+		Queued:    len(p.queue),
+		Running:   int(p.running.Load()),
+		Completed: p.completed.Load(),
+		Failed:    p.failed.Load(),
+		Panicked:  p.panicked.Load(),
+		Rejected:  p.rejected.Load(),
+		Retried:   p.retried.Load(),
+	}
+}
+
+// execute runs a task until it succeeds or the retry policy gives up
+func (p *Pool) execute(t task) {
+	p.running.Add(1)
+	defer p.running.Add(-1)
+
+	var attempts int
+	var last error
+	policy := p.opts.Retry
+	policy.OnRetry = func(attempt int, delay time.Duration, err error) {
+		p.retried.Add(1)
+		p.logger.Debug("Retrying task", "task", t.name, "attempt", attempt, "backoff", delay, "error", err)
+	}
+	err := policy.Do(p.ctx, func(ctx context.Context) error {
+		if attempts > 0 && ctx.Err() != nil {
+			// The pool was closed while the retry waited
+			return retry.Permanent(last)
+		}
+		attempts++
+		last = p.attempt(t)
+		var panicErr *panicError
+		if errors.As(last, &panicErr) {
+			return retry.Permanent(last)
+		}
+		return last
+	})
+
+	var panicErr *panicError
+	switch {
+	case err == nil:
+		p.completed.Add(1)
+	case errors.As(err, &panicErr):
+		p.panicked.Add(1)
+		p.logger.Error("Task panicked", "task", t.name, "panic", panicErr.value, "stack", string(panicErr.stack))
+	default:
+		p.failed.Add(1)
+		p.logger.Warn("Task failed", "task", t.name, "attempts", attempts, "error", err)
+	}
+}
+
+// panicError carries a panic recovered from a task; panicking tasks are not retried
+type panicError struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.value)
+}
+
+// attempt runs a task once, turning a panic into an error
+func (p *Pool) attempt(t task) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = &panicError{value: value, stack: debug.Stack()}
+		}
+	}()
+	return t.run(p.ctx)
+}
@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"testing"
@@ -45,13 +46,38 @@ func TestMovieRepository_Integration(t *testing.T) {
 	// Create repository
 	repo := database.NewMongoMovieRepository(client, testDB, logger)
 
-	t.Run("CreateAndFindMovie", func(t *testing.T) {
-		// Create test movie
-		movie, err := domain.NewMovie(1, "Integration Test Movie", "2023")
+	t.Run("CheckPermissions", func(t *testing.T) {
+		if err := database.CheckPermissions(context.Background(), client, testDB); err != nil {
+			t.Errorf("CheckPermissions() unexpected error = %v", err)
+		}
+	})
+
+	t.Run("Bootstrap", func(t *testing.T) {
+		opts := database.BootstrapOptions{EventStore: true, ReadModel: true, Sync: true}
+		if _, err := database.Bootstrap(context.Background(), client, testDB, opts, logger); err != nil {
+			t.Fatalf("Bootstrap() unexpected error = %v", err)
+		}
+
+		// A second run keeps the existing collections
+		steps, err := database.Bootstrap(context.Background(), client, testDB, opts, logger)
 		if err != nil {
-			t.Fatalf("Failed to create movie: %v", err)
+			t.Fatalf("Bootstrap() second run unexpected error = %v", err)
+		}
+		for _, step := range steps {
+			if step.Created {
+				t.Errorf("Bootstrap() second run created %s again", step.Collection)
+			}
 		}
 
+		_, err = client.Database(testDB).Collection("movies").InsertOne(context.Background(), map[string]interface{}{"_id": int32(900), "title": "Bad Year", "year": "99"})
+		if err == nil {
+			t.Errorf("InsertOne() of a movie with an invalid year succeeded, want a validation error")
+		}
+	})
+
+	t.Run("CreateAndFindMovie", func(t *testing.T) {
+		movie := testMovie(t, "repository", 1)
+
 		// Save movie
 		createdMovie, err := repo.Create(context.Background(), movie)
 		if err != nil {
@@ -75,14 +101,8 @@ func TestMovieRepository_Integration(t *testing.T) {
 
 	t.Run("FindAllMovies", func(t *testing.T) {
 		// Create multiple test movies
-		movies := []*domain.Movie{
-			{ID: 2, Title: "Movie 2", Year: "2022"},
-			{ID: 3, Title: "Movie 3", Year: "2021"},
-			{ID: 4, Title: "Movie 4", Year: "2020"},
-		}
-
-		for _, movie := range movies {
-			if _, err := repo.Create(context.Background(), movie); err != nil {
+		for id := int32(2); id <= 4; id++ {
+			if _, err := repo.Create(context.Background(), testMovie(t, "repository", id)); err != nil {
 				t.Fatalf("Failed to create test movie: %v", err)
 			}
 		}
@@ -101,7 +121,7 @@ func TestMovieRepository_Integration(t *testing.T) {
 
 	t.Run("DeleteMovie", func(t *testing.T) {
 		// Create test movie
-		movie := &domain.Movie{ID: 5, Title: "Movie to Delete", Year: "2023"}
+		movie := testMovie(t, "repository", 5)
 		if _, err := repo.Create(context.Background(), movie); err != nil {
 			t.Fatalf("Failed to create movie to delete: %v", err)
 		}
@@ -118,8 +138,158 @@ func TestMovieRepository_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("DeleteVersion", func(t *testing.T) {
+		movie := testMovie(t, "repository", 6)
+		if _, err := repo.Create(context.Background(), movie); err != nil {
+			t.Fatalf("Failed to create versioned movie: %v", err)
+		}
+
+		if err := repo.DeleteVersion(context.Background(), movie.ID, movie.Version+1); err != domain.ErrVersionMismatch {
+			t.Errorf("Expected ErrVersionMismatch, got %v", err)
+		}
+
+		if err := repo.DeleteVersion(context.Background(), movie.ID, movie.Version); err != nil {
+			t.Fatalf("Failed to delete movie with matching version: %v", err)
+		}
+
+		if err := repo.DeleteVersion(context.Background(), movie.ID, movie.Version); err != domain.ErrMovieNotFound {
+			t.Errorf("Expected ErrMovieNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+		movie := testMovie(t, "repository", 100)
+
+		created, isNew, err := repo.Upsert(context.Background(), movie)
+		if err != nil {
+			t.Fatalf("Failed to upsert new movie: %v", err)
+		}
+		if !isNew || created.Version != 1 {
+			t.Errorf("Expected a new movie with version 1, got created=%v version=%d", isNew, created.Version)
+		}
+
+		replacement := testMovie(t, "repository", 100)
+		replacement.Title = "Upserted Movie (Remastered)"
+		replaced, isNew, err := repo.Upsert(context.Background(), replacement)
+		if err != nil {
+			t.Fatalf("Failed to replace movie: %v", err)
+		}
+		if isNew || replaced.Version != 2 {
+			t.Errorf("Expected a replaced movie with version 2, got created=%v version=%d", isNew, replaced.Version)
+		}
+
+		found, err := repo.FindByID(context.Background(), 100)
+		if err != nil {
+			t.Fatalf("Failed to find upserted movie: %v", err)
+		}
+		if found.Title != replacement.Title {
+			t.Errorf("Found title = %v, want %v", found.Title, replacement.Title)
+		}
+	})
+
+	t.Run("ExternalIDs", func(t *testing.T) {
+		if err := database.EnsureIndexes(context.Background(), client, testDB, logger); err != nil {
+			t.Fatalf("EnsureIndexes() unexpected error = %v", err)
+		}
+
+		heat := testMovie(t, "repository", 110)
+		if _, err := repo.Create(context.Background(), heat); err != nil {
+			t.Fatalf("Create() unexpected error = %v", err)
+		}
+		found, err := repo.FindByExternalID(context.Background(), domain.SourceTMDb, "949")
+		if err != nil || found.ID != 110 {
+			t.Errorf("FindByExternalID() = %v, %v, want movie 110", found, err)
+		}
+		if _, err := repo.FindByExternalID(context.Background(), domain.SourceIMDb, "tt0000001"); !errors.Is(err, domain.ErrMovieNotFound) {
+			t.Errorf("FindByExternalID() of unknown ID error = %v, want ErrMovieNotFound", err)
+		}
+
+		// Movies without external IDs don't collide on the sparse indexes
+		for id := int32(111); id <= 112; id++ {
+			if _, err := repo.Create(context.Background(), testMovie(t, "repository", id)); err != nil {
+				t.Fatalf("Create() of movie without external IDs unexpected error = %v", err)
+			}
+		}
+		remake := testMovie(t, "repository", 113)
+		remake.TMDbID = heat.TMDbID
+		_, err = repo.Create(context.Background(), remake)
+		if !errors.Is(err, domain.ErrExternalIDTaken) {
+			t.Errorf("Create() with a taken TMDb ID error = %v, want ErrExternalIDTaken", err)
+		}
+	})
+
+	t.Run("SearchIgnoresCaseAndDiacritics", func(t *testing.T) {
+		if err := database.EnsureIndexes(context.Background(), client, testDB, logger); err != nil {
+			t.Fatalf("Failed to ensure indexes: %v", err)
+		}
+		if _, err := repo.Create(context.Background(), testMovie(t, "repository", 30)); err != nil {
+			t.Fatalf("Failed to create movie: %v", err)
+		}
+
+		filters := map[string]*domain.FilterCondition{
+			"contains": {Field: "title", Op: domain.OpContains, Value: "AMELIE"},
+			"exact":    {Field: "title", Op: domain.OpEq, Value: "le fabuleux destin d'amelie poulain"},
+		}
+		for name, cond := range filters {
+			movies, err := repo.FindAll(context.Background(), domain.MovieFilter{
+				Page:  1,
+				Limit: 10,
+				Expr:  &domain.FilterExpr{Condition: cond},
+			})
+			if err != nil {
+				t.Fatalf("%s: failed to search movies: %v", name, err)
+			}
+			if len(movies) != 1 || movies[0].ID != 30 {
+				t.Errorf("%s: found %d movies, want movie 30", name, len(movies))
+			}
+		}
+	})
+
+	t.Run("FindAllByRegion", func(t *testing.T) {
+		if _, err := repo.Create(context.Background(), testMovie(t, "repository", 40)); err != nil {
+			t.Fatalf("Failed to create movie: %v", err)
+		}
+
+		regionalFilter := func(region string) domain.MovieFilter {
+			return domain.MovieFilter{
+				Page:   1,
+				Limit:  10,
+				Region: region,
+				Expr:   &domain.FilterExpr{Condition: &domain.FilterCondition{Field: "id", Op: domain.OpEq, Value: "40"}},
+			}
+		}
+
+		if movies, err := repo.FindAll(context.Background(), regionalFilter("BR")); err != nil || len(movies) != 1 {
+			t.Errorf("FindAll(BR) = %d movies, err %v, want 1", len(movies), err)
+		}
+		if movies, err := repo.FindAll(context.Background(), regionalFilter("US")); err != nil || len(movies) != 0 {
+			t.Errorf("FindAll(US) = %d movies, err %v, want 0", len(movies), err)
+		}
+	})
+
+	t.Run("Facets", func(t *testing.T) {
+		facets, err := repo.Facets(context.Background(), domain.MovieFilter{})
+		if err != nil {
+			t.Fatalf("Failed to get facets: %v", err)
+		}
+
+		var sum int32
+		for _, bucket := range facets.Years {
+			sum += bucket.Count
+		}
+		if facets.Total == 0 || sum != facets.Total {
+			t.Errorf("Year buckets sum to %d, want total %d", sum, facets.Total)
+		}
+		for i, bucket := range facets.Genres {
+			if bucket.Count > facets.Total || i > 0 && bucket.Count > facets.Genres[i-1].Count {
+				t.Errorf("Genre buckets = %+v, want counts up to the total, the most common first", facets.Genres)
+				break
+			}
+		}
+	})
+
 	t.Run("Count", func(t *testing.T) {
-		count, err := repo.Count(context.Background())
+		count, err := repo.Count(context.Background(), domain.MovieFilter{})
 		if err != nil {
 			t.Fatalf("Failed to count movies: %v", err)
 		}
@@ -128,6 +298,176 @@ func TestMovieRepository_Integration(t *testing.T) {
 			t.Errorf("Count should not be negative, got %d", count)
 		}
 	})
+
+	t.Run("QueriesUseIndexes", func(t *testing.T) {
+		if err := database.EnsureIndexes(context.Background(), client, testDB, logger); err != nil {
+			t.Fatalf("Failed to ensure indexes: %v", err)
+		}
+		shapes, err := database.QueryShapes(false)
+		if err != nil {
+			t.Fatalf("QueryShapes() unexpected error = %v", err)
+		}
+
+		for _, result := range database.ExplainQueries(context.Background(), client, testDB, shapes) {
+			if result.Err != nil {
+				t.Errorf("%s: %v", result.Shape.Name, result.Err)
+			} else if result.Regression() {
+				t.Errorf("%s scans the collection: %v", result.Shape.Name, result.Stages)
+			}
+		}
+	})
+
+	t.Run("ArchiveAndFallback", func(t *testing.T) {
+		if _, err := repo.Create(context.Background(), testMovie(t, "repository", 50)); err != nil {
+			t.Fatalf("Failed to create movie: %v", err)
+		}
+
+		// Everything created so far was accessed before now
+		archived, err := repo.Archive(context.Background(), time.Now().Add(time.Minute), 1000)
+		if err != nil {
+			t.Fatalf("Failed to archive movies: %v", err)
+		}
+		if archived == 0 {
+			t.Fatalf("Archive() moved no movies")
+		}
+
+		movie, err := repo.FindByID(context.Background(), 50)
+		if err != nil {
+			t.Fatalf("Failed to find archived movie: %v", err)
+		}
+		if !movie.Archived {
+			t.Errorf("FindByID() Archived = false, want true")
+		}
+
+		nextID, err := repo.GetNextID(context.Background())
+		if err != nil || nextID <= 50 {
+			t.Errorf("GetNextID() = %d, err %v, want an ID above archived movies", nextID, err)
+		}
+
+		exported, err := repo.Export(context.Background(), 49, 1)
+		if err != nil || len(exported) != 1 || exported[0].ID != 50 || !exported[0].Archived {
+			t.Errorf("Export() after 49 = %v, err %v, want the archived movie 50", exported, err)
+		}
+
+		if err := repo.Delete(context.Background(), 50); err != nil {
+			t.Errorf("Failed to delete archived movie: %v", err)
+		}
+	})
+
+	t.Run("CommentThreads", func(t *testing.T) {
+		comments := database.NewMongoCommentRepository(client, testDB, logger)
+		ctx := context.Background()
+
+		root, err := comments.Create(ctx, &domain.Comment{MovieID: 1, Author: "Ana", Body: "Loved it", CreatedAt: time.Now().UTC()})
+		if err != nil {
+			t.Fatalf("Failed to create comment: %v", err)
+		}
+		reply, err := comments.Create(ctx, &domain.Comment{MovieID: 1, ParentID: root.ID, ThreadID: root.ThreadID, Author: "Bia", Body: "Me too", CreatedAt: time.Now().UTC()})
+		if err != nil {
+			t.Fatalf("Failed to create reply: %v", err)
+		}
+
+		threads, total, err := comments.ListThreads(ctx, 1, 1, 10)
+		if err != nil || total != 1 || len(threads) != 1 || len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != reply.ID {
+			t.Fatalf("ListThreads() = %v, %d, err %v, want the thread with its reply", threads, total, err)
+		}
+
+		if _, err := comments.Flag(ctx, reply.ID, "spam"); err != nil {
+			t.Fatalf("Failed to flag comment: %v", err)
+		}
+		flagged, total, err := comments.ListFlagged(ctx, 1, 10)
+		if err != nil || total != 1 || flagged[0].FlagReasons[0] != "spam" {
+			t.Errorf("ListFlagged() = %v, %d, err %v, want the flagged reply", flagged, total, err)
+		}
+
+		if err := comments.SoftDelete(ctx, reply.ID); err != nil {
+			t.Fatalf("Failed to delete comment: %v", err)
+		}
+		if _, err := comments.Flag(ctx, reply.ID, "spam"); !errors.Is(err, domain.ErrCommentDeleted) {
+			t.Errorf("Flag() of deleted comment error = %v, want ErrCommentDeleted", err)
+		}
+		if _, total, _ := comments.ListFlagged(ctx, 1, 10); total != 0 {
+			t.Errorf("ListFlagged() total = %d after deletion, want 0", total)
+		}
+	})
+
+	t.Run("JobQueuePriorities", func(t *testing.T) {
+		jobs := database.NewMongoJobRepository(client, testDB, logger)
+		ctx := context.Background()
+		now := time.Now().UTC()
+		queued := []struct {
+			priority domain.JobPriority
+			age      time.Duration
+		}{
+			{domain.JobPriorityLow, time.Hour},
+			{domain.JobPriorityNormal, time.Minute},
+			{domain.JobPriorityHigh, time.Second},
+		}
+		ids := make(map[domain.JobPriority]string)
+		for _, q := range queued {
+			job := &domain.Job{Type: domain.JobExport, Status: domain.JobPending, Priority: q.priority, Params: []byte("{}"), CreatedAt: now.Add(-q.age)}
+			if err := jobs.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob() unexpected error = %v", err)
+			}
+			ids[q.priority] = job.ID
+		}
+
+		// The low job waited past the starvation limit, so it runs before the others
+		order := []struct {
+			starvedBefore time.Time
+			want          domain.JobPriority
+		}{
+			{now.Add(-10 * time.Minute), domain.JobPriorityLow},
+			{now.Add(-10 * time.Minute), domain.JobPriorityHigh},
+			{now.Add(-10 * time.Minute), domain.JobPriorityNormal},
+		}
+		for _, o := range order {
+			job, err := jobs.ClaimJob(ctx, "test", now.Add(time.Minute), o.starvedBefore)
+			if err != nil || job == nil {
+				t.Fatalf("ClaimJob() = %v, %v, want the %s job", job, err, o.want)
+			}
+			if job.ID != ids[o.want] || job.Priority != o.want {
+				t.Errorf("ClaimJob() claimed the %s job %s, want the %s job", job.Priority, job.ID, o.want)
+			}
+		}
+		if job, err := jobs.ClaimJob(ctx, "test", now.Add(time.Minute), now); job != nil || err != nil {
+			t.Errorf("ClaimJob() of an empty queue = %v, %v, want nil", job, err)
+		}
+	})
+
+	t.Run("SyncLinksAndConflicts", func(t *testing.T) {
+		sync := database.NewMongoSyncRepository(client, testDB, logger)
+		ctx := context.Background()
+
+		if link, err := sync.FindLink(ctx, "csv", "tt1"); err != nil || link != nil {
+			t.Fatalf("FindLink() of unsynced movie = %v, %v, want nil", link, err)
+		}
+		link := domain.SyncLink{Source: "csv", ExternalID: "tt1", MovieID: 1, Fingerprint: "abc", LocalVersion: 2, SyncedAt: time.Now().UTC()}
+		if err := sync.SaveLink(ctx, link); err != nil {
+			t.Fatalf("SaveLink() unexpected error = %v", err)
+		}
+		if found, err := sync.FindLink(ctx, "csv", "tt1"); err != nil || found.MovieID != 1 || found.Fingerprint != "abc" {
+			t.Errorf("FindLink() = %+v, %v, want the saved link", found, err)
+		}
+
+		remote := domain.RemoteMovie{Source: "csv", ExternalID: "tt1", Title: "Heat", Year: "1995"}
+		for _, certification := range []string{"R", "PG-13"} {
+			remote.Certification = certification
+			if err := sync.SaveConflict(ctx, domain.SyncConflict{MovieID: 1, Remote: remote, DetectedAt: time.Now().UTC()}); err != nil {
+				t.Fatalf("SaveConflict() unexpected error = %v", err)
+			}
+		}
+		conflicts, total, err := sync.ListConflicts(ctx, 1, 10)
+		if err != nil || total != 1 || conflicts[0].Remote.Certification != "PG-13" {
+			t.Fatalf("ListConflicts() = %v, %d, %v, want the latest conflict once", conflicts, total, err)
+		}
+		if err := sync.DeleteConflict(ctx, conflicts[0].ID); err != nil {
+			t.Fatalf("DeleteConflict() unexpected error = %v", err)
+		}
+		if _, err := sync.FindConflict(ctx, conflicts[0].ID); !errors.Is(err, domain.ErrSyncConflictNotFound) {
+			t.Errorf("FindConflict() after deletion error = %v, want ErrSyncConflictNotFound", err)
+		}
+	})
 }
 
 func getEnv(key, defaultValue string) string {
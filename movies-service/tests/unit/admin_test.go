@@ -0,0 +1,142 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/admin"
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+)
+
+func TestAdminServer_Probes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var readyErr error
+	server := admin.NewServer(admin.Options{
+		Ready: func(ctx context.Context) error { return readyErr },
+	}, logger)
+	handler := server.Handler()
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz status = %d, want %d", code, http.StatusOK)
+	}
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz status = %d, want %d", code, http.StatusOK)
+	}
+
+	readyErr = errors.New("mongo unreachable")
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz status with failing dependency = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz status with failing dependency = %d, want %d", code, http.StatusOK)
+	}
+
+	readyErr = nil
+	server.Drain()
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz status while draining = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	if code := get("/debug/pprof/"); code != http.StatusNotFound {
+		t.Errorf("/debug/pprof/ status with profiles disabled = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestAdminServer_Metrics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	metrics := grpcAdapter.NewMetrics()
+	interceptor := metrics.UnaryInterceptor()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/GetMovie"}
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "movie not found")
+	})
+
+	handler := admin.NewServer(admin.Options{Metrics: metrics.WritePrometheus, Pprof: true}, logger).Handler()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`movies_grpc_requests_total{method="/movies.v2.MovieService/GetMovie",code="OK"} 1`,
+		`movies_grpc_requests_total{method="/movies.v2.MovieService/GetMovie",code="NotFound"} 1`,
+		`movies_grpc_request_duration_seconds_count{method="/movies.v2.MovieService/GetMovie"} 2`,
+		`movies_grpc_request_duration_seconds_bucket{method="/movies.v2.MovieService/GetMovie",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/debug/pprof/ status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAdminServer_OpenMetricsExemplars(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	metrics := grpcAdapter.NewMetrics()
+	interceptor := metrics.UnaryInterceptor()
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/GetMovie"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcAdapter.TraceParentHeader, "00-"+traceID+"-00f067aa0ba902b7-01"))
+	interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+
+	handler := admin.NewServer(admin.Options{Metrics: metrics.WritePrometheus, OpenMetrics: metrics.WriteOpenMetrics}, logger).Handler()
+	scrape := func(accept string) (string, string) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Content-Type"), rec.Body.String()
+	}
+
+	contentType, body := scrape("application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	if !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("Content-Type = %q, want OpenMetrics", contentType)
+	}
+	for _, want := range []string{
+		"# TYPE movies_grpc_requests counter",
+		`le="0.005"} 1 # {trace_id="` + traceID + `"}`,
+		"# EOF",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("OpenMetrics body missing %q:\n%s", want, body)
+		}
+	}
+
+	contentType, body = scrape("")
+	if !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Content-Type without Accept = %q, want text/plain", contentType)
+	}
+	if strings.Contains(body, "trace_id") || strings.Contains(body, "# EOF") {
+		t.Errorf("Prometheus text body has OpenMetrics syntax:\n%s", body)
+	}
+}
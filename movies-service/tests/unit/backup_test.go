@@ -0,0 +1,229 @@
+package unit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/backup"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+)
+
+func writeBackup(t *testing.T, movies []*pb.Movie) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := backup.NewWriter(&buf, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("NewWriter() unexpected error = %v", err)
+	}
+	for _, movie := range movies {
+		if err := writer.Write(movie); err != nil {
+			t.Fatalf("Write() unexpected error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// readBackup returns the movies of a backup and the error that ended reading it
+func readBackup(data []byte) ([]*pb.Movie, error) {
+	reader, err := backup.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var movies []*pb.Movie
+	for {
+		movie, err := reader.Next()
+		if err != nil {
+			return movies, err
+		}
+		movies = append(movies, movie)
+	}
+}
+
+// rewriteBackup decompresses a backup, edits its lines and compresses it again
+func rewriteBackup(t *testing.T, data []byte, edit func(lines []string) []string) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := edit(strings.SplitAfter(string(plain), "\n"))
+
+	var buf bytes.Buffer
+	out := gzip.NewWriter(&buf)
+	out.Write([]byte(strings.Join(lines, "")))
+	out.Close()
+	return buf.Bytes()
+}
+
+func backupMovies() []*pb.Movie {
+	return []*pb.Movie{
+		{Id: 1, Title: "Central Station", Year: "1998", Regions: []string{"BR"}},
+		{Id: 2, Title: "City of God", Year: "2002", Awards: []string{"BAFTA for Best Editing"}},
+		{Id: 5, Title: "Heat", Year: "1995", Certification: "R", Archived: true},
+	}
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	movies, err := readBackup(writeBackup(t, backupMovies()))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("readBackup() error = %v, want io.EOF after a valid trailer", err)
+	}
+	if len(movies) != 3 || movies[1].Title != "City of God" || movies[1].Awards[0] != "BAFTA for Best Editing" || !movies[2].Archived {
+		t.Errorf("readBackup() = %v, want the movies written", movies)
+	}
+}
+
+func TestBackup_Validation(t *testing.T) {
+	data := writeBackup(t, backupMovies())
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"truncated file", data[:len(data)/2], backup.ErrIncomplete},
+		{"missing trailer", rewriteBackup(t, data, func(lines []string) []string { return lines[:len(lines)-2] }), backup.ErrIncomplete},
+		{"unterminated movie", rewriteBackup(t, data, func(lines []string) []string {
+			return append(lines[:2], strings.TrimSuffix(lines[2], "\n"))
+		}), backup.ErrIncomplete},
+		{"edited movie", rewriteBackup(t, data, func(lines []string) []string {
+			lines[1] = strings.Replace(lines[1], "1998", "1999", 1)
+			return lines
+		}), backup.ErrChecksumMismatch},
+		{"removed movie", rewriteBackup(t, data, func(lines []string) []string {
+			return append(lines[:1], lines[2:]...)
+		}), backup.ErrChecksumMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readBackup(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("readBackup() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackup_Resume(t *testing.T) {
+	complete := writeBackup(t, backupMovies())
+	interrupted := rewriteBackup(t, complete, func(lines []string) []string {
+		// The second movie was being written when the backup stopped
+		return append(lines[:2], lines[2][:10])
+	})
+
+	var buf bytes.Buffer
+	writer, err := backup.Resume(bytes.NewReader(interrupted), &buf)
+	if err != nil {
+		t.Fatalf("Resume() unexpected error = %v", err)
+	}
+	if writer.Count() != 1 || writer.LastID() != 1 {
+		t.Fatalf("Resume() count = %d, last ID = %d, want 1 movie through ID 1", writer.Count(), writer.LastID())
+	}
+	for _, movie := range backupMovies()[1:] {
+		if err := writer.Write(movie); err != nil {
+			t.Fatalf("Write() unexpected error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() unexpected error = %v", err)
+	}
+
+	movies, err := readBackup(buf.Bytes())
+	if !errors.Is(err, io.EOF) || len(movies) != 3 {
+		t.Errorf("readBackup() of resumed backup = %d movies, %v, want 3 movies and a valid trailer", len(movies), err)
+	}
+
+	if _, err := backup.Resume(bytes.NewReader(complete), io.Discard); !errors.Is(err, backup.ErrComplete) {
+		t.Errorf("Resume() of complete backup error = %v, want ErrComplete", err)
+	}
+}
+
+func TestMovieServer_ExportImport(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	source := NewMockMovieRepository()
+	for _, movie := range backupMovies() {
+		source.movies[movie.Id] = &domain.Movie{ID: movie.Id, Title: movie.Title, Year: movie.Year, Version: 3}
+	}
+	target := NewMockMovieRepository()
+
+	dial := func(repo *MockMovieRepository) pb.MovieServiceClient {
+		listener := bufconn.Listen(1 << 20)
+		server := grpc.NewServer()
+		service := services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+		pb.RegisterMovieServiceServer(server, grpcAdapter.NewMovieServer(service, logger))
+		go server.Serve(listener)
+		t.Cleanup(server.Stop)
+
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return pb.NewMovieServiceClient(conn)
+	}
+	ctx := context.Background()
+
+	export, err := dial(source).ExportMovies(ctx, &pb.ExportMoviesRequest{AfterId: 1})
+	if err != nil {
+		t.Fatalf("ExportMovies() unexpected error = %v", err)
+	}
+	var exported []*pb.Movie
+	for {
+		resp, err := export.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ExportMovies() stream error = %v", err)
+		}
+		exported = append(exported, resp.Movie)
+	}
+	if len(exported) != 2 || exported[0].Id != 2 || exported[1].Id != 5 {
+		t.Fatalf("ExportMovies() after ID 1 = %v, want movies 2 and 5", exported)
+	}
+
+	stream, err := dial(target).ImportMovies(ctx)
+	if err != nil {
+		t.Fatalf("ImportMovies() unexpected error = %v", err)
+	}
+	for _, movie := range append(exported, &pb.Movie{Id: 7, Year: "2001"}) {
+		if err := stream.Send(&pb.ImportMoviesRequest{Movie: movie}); err != nil {
+			break
+		}
+	}
+	if _, err := stream.CloseAndRecv(); err == nil {
+		t.Fatal("ImportMovies() of a movie without title succeeded, want an error")
+	}
+	if got := stream.Trailer().Get(grpcAdapter.ImportedThroughTrailer); len(got) != 1 || got[0] != "5" {
+		t.Errorf("ImportMovies() trailer = %v, want %v", stream.Trailer(), metadata.Pairs(grpcAdapter.ImportedThroughTrailer, "5"))
+	}
+	if len(target.movies) != 2 || target.movies[5] == nil || target.movies[5].Title != "Heat" {
+		t.Errorf("ImportMovies() stored %v, want movies 2 and 5 under their own IDs", target.movies)
+	}
+}
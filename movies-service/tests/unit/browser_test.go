@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/grpc"
+
+	"github.com/movie-microservice/movies-service/internal/cli"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// browserClient serves the calls of the browser from a fixed catalog
+type browserClient struct {
+	pb.MovieServiceClient
+	movies  []*pb.Movie
+	query   string
+	deleted *pb.DeleteMovieRequest
+}
+
+func (c *browserClient) GetMovies(ctx context.Context, req *pb.GetMoviesRequest, opts ...grpc.CallOption) (*pb.GetMoviesResponse, error) {
+	c.query = req.GetFilter().GetCondition().GetValue()
+	var movies []*pb.Movie
+	for _, movie := range c.movies {
+		if strings.Contains(movie.Title, c.query) {
+			movies = append(movies, movie)
+		}
+	}
+	return &pb.GetMoviesResponse{Movies: movies, Total: int32(len(movies))}, nil
+}
+
+func (c *browserClient) GetMovie(ctx context.Context, req *pb.GetMovieRequest, opts ...grpc.CallOption) (*pb.GetMovieResponse, error) {
+	for _, movie := range c.movies {
+		if movie.Id == req.Id {
+			return &pb.GetMovieResponse{Movie: movie}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *browserClient) DeleteMovie(ctx context.Context, req *pb.DeleteMovieRequest, opts ...grpc.CallOption) (*pb.DeleteMovieResponse, error) {
+	c.deleted = req
+	return &pb.DeleteMovieResponse{}, nil
+}
+
+// press sends keys to the browser, running the calls they start
+func press(browser tea.Model, keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		_, cmd := browser.Update(key)
+		settle(browser, cmd)
+	}
+}
+
+func settle(browser tea.Model, cmd tea.Cmd) {
+	for cmd != nil {
+		_, cmd = browser.Update(cmd())
+	}
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestBrowser(t *testing.T) {
+	client := &browserClient{movies: []*pb.Movie{
+		{Id: 1, Title: "Alien", Year: "1979", Version: 2},
+		{Id: 2, Title: "Aliens", Year: "1986", Version: 1},
+		{Id: 3, Title: "Heat", Year: "1995", Version: 4},
+	}}
+	browser := cli.NewBrowser(context.Background(), client)
+	settle(browser, browser.Init())
+	if view := browser.View(); !strings.Contains(view, "Movies: 3, page 1 of 1") || !strings.Contains(view, "Heat") {
+		t.Fatalf("View() of the list =\n%s\nwant the 3 movies", view)
+	}
+
+	press(browser, runes("/"), runes("Ali"), tea.KeyMsg{Type: tea.KeyEnter})
+	view := browser.View()
+	if client.query != "Ali" || strings.Contains(view, "Heat") || !strings.Contains(view, `titles containing "Ali"`) {
+		t.Fatalf("search of Ali: query %q, view\n%s\nwant Alien and Aliens only", client.query, view)
+	}
+
+	press(browser, runes("j"), tea.KeyMsg{Type: tea.KeyEnter})
+	if view := browser.View(); !strings.Contains(view, "Aliens (1986)") || !strings.Contains(view, "Version") {
+		t.Fatalf("View() of the details =\n%s\nwant Aliens", view)
+	}
+
+	press(browser, runes("d"))
+	if view := browser.View(); !strings.Contains(view, "Delete movie 2, Aliens? (y/n)") {
+		t.Fatalf("View() of the confirmation =\n%s\nwant the delete prompt", view)
+	}
+	press(browser, runes("n"))
+	if client.deleted != nil {
+		t.Fatal("DeleteMovie() called after the delete was cancelled")
+	}
+
+	press(browser, runes("d"), runes("y"))
+	if client.deleted == nil || client.deleted.Id != 2 || client.deleted.GetExpectedVersion() != 1 {
+		t.Fatalf("DeleteMovie() request = %v, want movie 2 at version 1", client.deleted)
+	}
+	if view := browser.View(); !strings.Contains(view, "deleted movie 2, Aliens") {
+		t.Errorf("View() after the delete =\n%s\nwant the list and the deleted movie", view)
+	}
+
+	press(browser, tea.KeyMsg{Type: tea.KeyEsc})
+	if client.query != "" || !strings.Contains(browser.View(), "Heat") {
+		t.Errorf("esc on the list kept the search %q, want it cleared", client.query)
+	}
+	if _, cmd := browser.Update(runes("q")); cmd == nil {
+		t.Error("q returned no command, want tea.Quit")
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/movie-microservice/movies-service/internal/cli"
+)
+
+type cliResult struct {
+	File   string `json:"file"`
+	Movies int    `json:"movies"`
+	Note   string `json:"note,omitempty"`
+	Label  string `json:"label"`
+}
+
+func TestPrinter_Formats(t *testing.T) {
+	result := cliResult{File: "movies.ndjson.gz", Movies: 3, Label: "yes"}
+	for format, want := range map[string]string{
+		cli.FormatTable: "FILE              MOVIES  LABEL\nmovies.ndjson.gz  3       yes\n",
+		cli.FormatJSON:  "{\n  \"file\": \"movies.ndjson.gz\",\n  \"movies\": 3,\n  \"label\": \"yes\"\n}\n",
+		cli.FormatYAML:  "file: movies.ndjson.gz\nmovies: 3\nlabel: \"yes\"\n",
+	} {
+		var stdout, stderr bytes.Buffer
+		out := cli.NewPrinter(&stdout, &stderr)
+		out.Format = format
+		if code := out.Result(result); code != cli.ExitOK || stdout.String() != want {
+			t.Errorf("%s: code %d, output\n%s\nwant\n%s", format, code, stdout.String(), want)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	out := cli.NewPrinter(&stdout, &stderr)
+	out.Format = "xml"
+	if out.Validate() == nil {
+		t.Error("Validate() accepted the xml format")
+	}
+	out.Format, out.Quiet = cli.FormatJSON, true
+	out.Notef("resuming")
+	out.Result(result)
+	if stdout.Len() != 0 || stderr.Len() != 0 {
+		t.Errorf("quiet printer wrote %q and %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestPrinter_Fail(t *testing.T) {
+	err := fmt.Errorf("export failed: %w", status.Error(codes.Unavailable, "connection refused"))
+	progress := struct {
+		LastID int32 `json:"last_id"`
+	}{LastID: 42}
+
+	var stdout, stderr bytes.Buffer
+	out := cli.NewPrinter(&stdout, &stderr)
+	out.Format = cli.FormatYAML
+	if code := out.Fail("backup", err, progress, "run again with -resume"); code != cli.ExitUnavailable {
+		t.Errorf("code = %d, want %d", code, cli.ExitUnavailable)
+	}
+	want := "error: unavailable\nmessage: \"export failed: rpc error: code = Unavailable desc = connection refused\"\n" +
+		"exit_code: 3\nlast_id: 42\nhint: \"run again with -resume\"\n"
+	if stdout.String() != want || stderr.Len() != 0 {
+		t.Errorf("report = %q, stderr %q, want %q", stdout.String(), stderr.String(), want)
+	}
+
+	stdout.Reset()
+	out.Format = cli.FormatTable
+	out.Fail("backup", err, progress, "run again with -resume")
+	if stdout.Len() != 0 || stderr.String() != "backup: "+err.Error()+"\nrun again with -resume\n" {
+		t.Errorf("table failure wrote %q and %q", stdout.String(), stderr.String())
+	}
+}
+
+func TestCode(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{nil, cli.ExitOK},
+		{errors.New("disk full"), cli.ExitFailure},
+		{fmt.Errorf("open: %w", os.ErrNotExist), cli.ExitNotFound},
+		{context.Canceled, cli.ExitInterrupted},
+		{status.Error(codes.Canceled, "canceled"), cli.ExitInterrupted},
+		{status.Error(codes.DeadlineExceeded, "slow"), cli.ExitUnavailable},
+		{status.Error(codes.InvalidArgument, "bad movie"), cli.ExitInvalid},
+		{status.Error(codes.AlreadyExists, "duplicate"), cli.ExitConflict},
+		{status.Error(codes.PermissionDenied, "no"), cli.ExitDenied},
+		{fmt.Errorf("restore: %w", cli.Invalid(errors.New("checksum mismatch"))), cli.ExitInvalid},
+	} {
+		if got := cli.Code(tt.err); got != tt.want {
+			t.Errorf("Code(%v) = %d (%s), want %d", tt.err, got, cli.Class(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/idgen"
+)
+
+// MockCommentRepository keeps comments in creation order
+type MockCommentRepository struct {
+	comments []*domain.Comment
+}
+
+func (m *MockCommentRepository) ListThreads(ctx context.Context, movieID int32, page, limit int32) ([]*domain.Comment, int32, error) {
+	var threads []*domain.Comment
+	for i := len(m.comments) - 1; i >= 0; i-- {
+		if c := m.comments[i]; c.MovieID == movieID && c.ParentID == "" && c.Status.Visible() {
+			thread := *c
+			for _, reply := range m.comments {
+				if reply.ThreadID == c.ID && reply.ParentID != "" && reply.Status.Visible() {
+					thread.Replies = append(thread.Replies, reply)
+				}
+			}
+			threads = append(threads, &thread)
+		}
+	}
+	total := int32(len(threads))
+	start := min(int((page-1)*limit), len(threads))
+	end := min(start+int(limit), len(threads))
+	return threads[start:end], total, nil
+}
+
+func (m *MockCommentRepository) FindByID(ctx context.Context, id string) (*domain.Comment, error) {
+	for _, c := range m.comments {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, domain.ErrCommentNotFound
+}
+
+func (m *MockCommentRepository) Create(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	created := *comment
+	if created.ID == "" {
+		created.ID = fmt.Sprintf("c%d", len(m.comments)+1)
+	}
+	if created.ThreadID == "" {
+		created.ThreadID = created.ID
+	}
+	m.comments = append(m.comments, &created)
+	return &created, nil
+}
+
+func (m *MockCommentRepository) Flag(ctx context.Context, id, reason string) (*domain.Comment, error) {
+	return m.update(id, func(c *domain.Comment) {
+		c.FlagCount++
+		c.FlagReasons = append(c.FlagReasons, reason)
+	})
+}
+
+func (m *MockCommentRepository) ListFlagged(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	var flagged []*domain.Comment
+	for _, c := range m.comments {
+		if c.FlagCount > 0 && !c.Deleted() {
+			flagged = append(flagged, c)
+		}
+	}
+	return flagged, int32(len(flagged)), nil
+}
+
+func (m *MockCommentRepository) SoftDelete(ctx context.Context, id string) error {
+	_, err := m.update(id, func(c *domain.Comment) {
+		now := time.Now()
+		c.DeletedAt, c.FlagCount, c.FlagReasons = &now, 0, nil
+	})
+	return err
+}
+
+func (m *MockCommentRepository) DismissFlags(ctx context.Context, id string) (*domain.Comment, error) {
+	return m.update(id, func(c *domain.Comment) { c.FlagCount, c.FlagReasons = 0, nil })
+}
+
+func (m *MockCommentRepository) ListPending(ctx context.Context, limit int) ([]*domain.Comment, error) {
+	var pending []*domain.Comment
+	for _, c := range m.comments {
+		if c.Status == domain.ModerationPending && !c.Deleted() && len(pending) < limit {
+			copied := *c
+			pending = append(pending, &copied)
+		}
+	}
+	return pending, nil
+}
+
+func (m *MockCommentRepository) ListModerationQueue(ctx context.Context, page, limit int32) ([]*domain.Comment, int32, error) {
+	var queued []*domain.Comment
+	for _, c := range m.comments {
+		if c.Status == domain.ModerationReview && !c.Deleted() {
+			queued = append(queued, c)
+		}
+	}
+	return queued, int32(len(queued)), nil
+}
+
+func (m *MockCommentRepository) SetStatus(ctx context.Context, id string, from []domain.ModerationStatus, status domain.ModerationStatus, reasons []string) (*domain.Comment, error) {
+	c, err := m.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Deleted() || !slices.Contains(from, c.Status) {
+		return nil, domain.ErrNotAwaitingModeration
+	}
+	c.Status = status
+	if reasons != nil {
+		c.ModerationReasons = reasons
+	}
+	return c, nil
+}
+
+func (m *MockCommentRepository) update(id string, apply func(*domain.Comment)) (*domain.Comment, error) {
+	c, err := m.FindByID(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Deleted() {
+		return nil, domain.ErrCommentDeleted
+	}
+	apply(c)
+	return c, nil
+}
+
+func newCommentService(moderated bool) (*MockCommentRepository, *services.CommentService) {
+	movies := NewMockMovieRepository()
+	movies.movies[1] = &domain.Movie{ID: 1, Title: "Central Station", Year: "1998"}
+	movies.movies[2] = &domain.Movie{ID: 2, Title: "City of God", Year: "2002"}
+	repo := &MockCommentRepository{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return repo, services.NewCommentService(repo, movies, domain.DefaultPagination(), moderated, clock.NewFake(testNow), &idgen.Sequence{}, logger).(*services.CommentService)
+}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	_, service := newCommentService(false)
+	ctx := context.Background()
+
+	root, err := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, Author: " Ana ", Body: "Loved it"})
+	if err != nil {
+		t.Fatalf("CreateComment() unexpected error = %v", err)
+	}
+	if root.Author != "Ana" || root.ThreadID != root.ID {
+		t.Errorf("CreateComment() = %+v, want a trimmed thread root", root)
+	}
+	if root.ID != "000000000000000000000001" || !root.CreatedAt.Equal(testNow) {
+		t.Errorf("CreateComment() ID = %s, CreatedAt = %v, want the first ID at %v", root.ID, root.CreatedAt, testNow)
+	}
+	reply, err := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, ParentID: root.ID, Author: "Bia", Body: "Me too"})
+	if err != nil {
+		t.Fatalf("CreateComment() reply unexpected error = %v", err)
+	}
+	nested, err := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, ParentID: reply.ID, Author: "Caio", Body: "Same"})
+	if err != nil {
+		t.Fatalf("CreateComment() nested reply unexpected error = %v", err)
+	}
+	if nested.ThreadID != root.ID || nested.ParentID != reply.ID {
+		t.Errorf("CreateComment() nested reply = %+v, want it in thread %s", nested, root.ID)
+	}
+
+	tests := []struct {
+		name  string
+		input domain.CommentInput
+		want  error
+	}{
+		{"missing body", domain.CommentInput{MovieID: 1, Author: "Ana", Body: "  "}, domain.ErrInvalidComment},
+		{"long body", domain.CommentInput{MovieID: 1, Author: "Ana", Body: strings.Repeat("a", domain.MaxCommentLength+1)}, domain.ErrInvalidComment},
+		{"missing author", domain.CommentInput{MovieID: 1, Body: "Loved it"}, domain.ErrInvalidComment},
+		{"unknown movie", domain.CommentInput{MovieID: 9, Author: "Ana", Body: "Loved it"}, domain.ErrMovieNotFound},
+		{"unknown parent", domain.CommentInput{MovieID: 1, ParentID: "missing", Author: "Ana", Body: "Loved it"}, domain.ErrCommentNotFound},
+		{"parent of another movie", domain.CommentInput{MovieID: 2, ParentID: root.ID, Author: "Ana", Body: "Loved it"}, domain.ErrInvalidComment},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateComment(ctx, tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("CreateComment() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentService_Moderation(t *testing.T) {
+	repo, service := newCommentService(false)
+	ctx := context.Background()
+
+	root, _ := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, Author: "Ana", Body: "Spoiler: it ends"})
+	service.CreateComment(ctx, domain.CommentInput{MovieID: 1, ParentID: root.ID, Author: "Bia", Body: "Why would you"})
+
+	flagged, err := service.FlagComment(ctx, root.ID, " spoiler ")
+	if err != nil {
+		t.Fatalf("FlagComment() unexpected error = %v", err)
+	}
+	if flagged.FlagCount != 1 || flagged.FlagReasons != nil {
+		t.Errorf("FlagComment() = %+v, want one flag with the reasons hidden", flagged)
+	}
+	if _, err := service.FlagComment(ctx, root.ID, ""); !errors.Is(err, domain.ErrInvalidComment) {
+		t.Errorf("FlagComment() without reason error = %v, want ErrInvalidComment", err)
+	}
+
+	queue, total, err := service.ListFlaggedComments(ctx, 0, 0)
+	if err != nil || total != 1 || queue[0].FlagReasons[0] != "spoiler" {
+		t.Fatalf("ListFlaggedComments() = %v, %d, %v, want the comment with its reason", queue, total, err)
+	}
+
+	if err := service.DeleteComment(ctx, root.ID); err != nil {
+		t.Fatalf("DeleteComment() unexpected error = %v", err)
+	}
+	if _, err := service.FlagComment(ctx, root.ID, "spam"); !errors.Is(err, domain.ErrCommentDeleted) {
+		t.Errorf("FlagComment() of deleted comment error = %v, want ErrCommentDeleted", err)
+	}
+	if _, err := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, ParentID: root.ID, Author: "Caio", Body: "Hm"}); !errors.Is(err, domain.ErrCommentDeleted) {
+		t.Errorf("CreateComment() replying to deleted comment error = %v, want ErrCommentDeleted", err)
+	}
+	if _, total, _ := service.ListFlaggedComments(ctx, 0, 0); total != 0 {
+		t.Errorf("ListFlaggedComments() total = %d after deletion, want 0", total)
+	}
+
+	threads, total, err := service.ListComments(ctx, 1, 0, 0)
+	if err != nil {
+		t.Fatalf("ListComments() unexpected error = %v", err)
+	}
+	if total != 1 || len(threads[0].Replies) != 1 {
+		t.Fatalf("ListComments() = %v, %d, want the thread with its reply", threads, total)
+	}
+	if thread := threads[0]; !thread.Deleted() || thread.Body != "" || thread.Author != "" || thread.Replies[0].Body != "Why would you" {
+		t.Errorf("ListComments() thread = %+v, want the deleted root redacted and its reply kept", thread)
+	}
+	if repo.comments[0].Body == "" {
+		t.Error("ListComments() redacted the stored comment")
+	}
+}
+
+func TestCommentService_ListComments(t *testing.T) {
+	_, service := newCommentService(false)
+	ctx := context.Background()
+
+	for i := 1; i <= 3; i++ {
+		service.CreateComment(ctx, domain.CommentInput{MovieID: 1, Author: "Ana", Body: fmt.Sprintf("Comment %d", i)})
+	}
+
+	threads, total, err := service.ListComments(ctx, 1, 2, 2)
+	if err != nil {
+		t.Fatalf("ListComments() unexpected error = %v", err)
+	}
+	if total != 3 || len(threads) != 1 || threads[0].Body != "Comment 1" {
+		t.Errorf("ListComments() page 2 = %v, %d, want the oldest of 3 threads", threads, total)
+	}
+	if _, _, err := service.ListComments(ctx, 9, 1, 10); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("ListComments() of unknown movie error = %v, want ErrMovieNotFound", err)
+	}
+}
@@ -0,0 +1,42 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/movie-microservice/movies-service/internal/config"
+)
+
+func TestLoad_DebugFeatures(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		reflection  string
+		want        bool
+	}{
+		{name: "development default", environment: "", want: true},
+		{name: "production default", environment: "production", want: false},
+		{name: "production enabled explicitly", environment: "production", reflection: "true", want: true},
+		{name: "development disabled explicitly", environment: "development", reflection: "false", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.environment != "" {
+				t.Setenv("ENVIRONMENT", tt.environment)
+			}
+			if tt.reflection != "" {
+				t.Setenv("GRPC_REFLECTION", tt.reflection)
+			}
+
+			cfg := config.Load()
+			if cfg.Debug.Reflection != tt.want {
+				t.Errorf("Reflection = %v, want %v", cfg.Debug.Reflection, tt.want)
+			}
+		})
+	}
+
+	t.Setenv("ENVIRONMENT", "prod")
+	if err := config.Load().Validate(); err == nil {
+		t.Error("Validate() expected error for an unknown environment")
+	}
+}
@@ -0,0 +1,55 @@
+package unit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+func TestDiffMovies(t *testing.T) {
+	before := &domain.Movie{ID: 1, Title: "Movie", Year: "2000", Regions: []string{"BR"}}
+	after := &domain.Movie{ID: 1, Title: "Movie", Year: "2001", Regions: []string{"BR", "US"}}
+
+	changes := domain.DiffMovies(before, after)
+	if got, want := changes.Fields(), []string{"year", "regions"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffMovies().Fields() = %v, want %v", got, want)
+	}
+
+	created := domain.DiffMovies(nil, after)
+	if got, want := created.Fields(), []string{"title", "year", "regions"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffMovies(nil).Fields() = %v, want %v", got, want)
+	}
+}
+
+func TestReplayMovieEvents(t *testing.T) {
+	v1 := &domain.Movie{ID: 7, Title: "Movie", Year: "2000", Version: 1, Awards: []string{"Oscar"}, Genres: []string{"Drama"}, Director: "Director", RuntimeMinutes: 120}
+	v2 := &domain.Movie{ID: 7, Title: "Movie (Director's Cut)", Year: "2000", Version: 2, Genres: []string{"Drama"}, Director: "Director", Synopsis: "Longer.", RuntimeMinutes: 150}
+
+	events := []domain.MovieEvent{
+		{MovieID: 7, Sequence: 1, Type: domain.MovieCreated, Version: 1, Changes: domain.DiffMovies(nil, v1)},
+		{MovieID: 7, Sequence: 2, Type: domain.MovieReplaced, Version: 2, Changes: domain.DiffMovies(v1, v2)},
+	}
+
+	movie, err := domain.ReplayMovieEvents(nil, events)
+	if err != nil {
+		t.Fatalf("ReplayMovieEvents() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(movie, v2) {
+		t.Errorf("ReplayMovieEvents() = %+v, want %+v", movie, v2)
+	}
+
+	// Replaying from a snapshot only needs the following events
+	if movie, _ := domain.ReplayMovieEvents(v1, events[1:]); !reflect.DeepEqual(movie, v2) {
+		t.Errorf("ReplayMovieEvents(snapshot) = %+v, want %+v", movie, v2)
+	}
+
+	deleted := append(events, domain.MovieEvent{MovieID: 7, Sequence: 3, Type: domain.MovieDeleted, Version: 2})
+	if movie, err := domain.ReplayMovieEvents(nil, deleted); err != nil || movie != nil {
+		t.Errorf("ReplayMovieEvents() after deletion = %+v, %v, want nil movie", movie, err)
+	}
+
+	if _, err := domain.ReplayMovieEvents(nil, events[1:]); err == nil {
+		t.Errorf("ReplayMovieEvents() replacing a missing movie expected error")
+	}
+}
@@ -0,0 +1,38 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/database"
+)
+
+func TestQueryShapes(t *testing.T) {
+	for _, useReadModel := range []bool{false, true} {
+		shapes, err := database.QueryShapes(useReadModel)
+		if err != nil {
+			t.Fatalf("QueryShapes(%v) unexpected error = %v", useReadModel, err)
+		}
+
+		names := make(map[string]bool)
+		for _, shape := range shapes {
+			if names[shape.Name] {
+				t.Errorf("QueryShapes(%v) repeats %s", useReadModel, shape.Name)
+			}
+			names[shape.Name] = true
+
+			filtered := strings.Contains(shape.Name, "_by_")
+			if filtered && shape.ScanAllowed {
+				t.Errorf("%s allows a collection scan", shape.Name)
+			}
+			if strings.HasPrefix(shape.Name, "list") && useReadModel && shape.Collection != "movies_read" {
+				t.Errorf("%s reads %s with the read model enabled", shape.Name, shape.Collection)
+			}
+		}
+		for _, want := range []string{"find_by_id", "list", "list_by_title_contains", "count_by_region", "facets_by_year_range"} {
+			if !names[want] {
+				t.Errorf("QueryShapes(%v) misses %s", useReadModel, want)
+			}
+		}
+	}
+}
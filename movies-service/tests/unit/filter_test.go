@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/database"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+)
+
+func cond(field string, op domain.FilterOperator, value string) *domain.FilterExpr {
+	return &domain.FilterExpr{Condition: &domain.FilterCondition{Field: field, Op: op, Value: value}}
+}
+
+func TestFilterToBSON(t *testing.T) {
+	tests := []struct {
+		name string
+		expr *domain.FilterExpr
+		want bson.M
+	}{
+		{
+			name: "no filter",
+			expr: nil,
+			want: bson.M{},
+		},
+		{
+			name: "year range",
+			expr: cond("year", domain.OpGte, "2000"),
+			want: bson.M{"year": bson.M{"$gte": "2000"}},
+		},
+		{
+			name: "id is numeric",
+			expr: cond("id", domain.OpEq, "42"),
+			want: bson.M{"_id": bson.M{"$eq": int32(42)}},
+		},
+		{
+			name: "contains is escaped",
+			expr: cond("title", domain.OpContains, "a.b"),
+			want: bson.M{"search_title": bson.M{"$regex": `a\.b`}},
+		},
+		{
+			name: "contains ignores case and diacritics",
+			expr: cond("title", domain.OpContains, "AMÉLIE"),
+			want: bson.M{"search_title": bson.M{"$regex": "amelie"}},
+		},
+		{
+			name: "nested groups",
+			expr: &domain.FilterExpr{And: []*domain.FilterExpr{
+				cond("year", domain.OpLt, "1990"),
+				{Or: []*domain.FilterExpr{
+					cond("title", domain.OpEq, "Alien"),
+					cond("title", domain.OpNe, "Aliens"),
+				}},
+			}},
+			want: bson.M{"$and": bson.A{
+				bson.M{"year": bson.M{"$lt": "1990"}},
+				bson.M{"$or": bson.A{
+					bson.M{"title": bson.M{"$eq": "Alien"}},
+					bson.M{"title": bson.M{"$ne": "Aliens"}},
+				}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := database.FilterToBSON(domain.MovieFilter{Expr: tt.expr})
+			if err != nil {
+				t.Fatalf("FilterToBSON() unexpected error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterToBSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterToBSON_InvalidConditions(t *testing.T) {
+	tests := []struct {
+		name string
+		expr *domain.FilterExpr
+	}{
+		{"unknown field", cond("director", domain.OpEq, "x")},
+		{"non-numeric id", cond("id", domain.OpEq, "abc")},
+		{"malformed year", cond("year", domain.OpGt, "20")},
+		{"contains on year", cond("year", domain.OpContains, "19")},
+		{"empty group", &domain.FilterExpr{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := database.FilterToBSON(domain.MovieFilter{Expr: tt.expr})
+			if !errors.Is(err, domain.ErrInvalidFilter) {
+				t.Errorf("FilterToBSON() error = %v, want ErrInvalidFilter", err)
+			}
+		})
+	}
+}
+
+func TestFilterToBSON_RegionAndCertification(t *testing.T) {
+	got, err := database.FilterToBSON(domain.MovieFilter{
+		Expr:          cond("year", domain.OpGte, "2000"),
+		Region:        "BR",
+		Certification: "PG-13",
+	})
+	if err != nil {
+		t.Fatalf("FilterToBSON() unexpected error = %v", err)
+	}
+
+	want := bson.M{"$and": bson.A{
+		bson.M{"year": bson.M{"$gte": "2000"}},
+		bson.M{"$or": bson.A{
+			bson.M{"regions": "BR"},
+			bson.M{"regions": bson.M{"$exists": false}},
+			bson.M{"regions": bson.A{}},
+		}},
+		bson.M{"certification": "PG-13"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterToBSON() = %v, want %v", got, want)
+	}
+}
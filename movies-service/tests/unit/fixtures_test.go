@@ -0,0 +1,160 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/fixtures"
+)
+
+// seedCatalog loads the catalog dataset into the repository
+func seedCatalog(tb testing.TB, repo *MockMovieRepository) *fixtures.Dataset {
+	tb.Helper()
+	catalog, err := fixtures.Open("catalog")
+	if err != nil {
+		tb.Fatalf("Open() unexpected error = %v", err)
+	}
+	if _, err := fixtures.Load(context.Background(), repo, domain.DefaultCertifications(), catalog); err != nil {
+		tb.Fatalf("Load() unexpected error = %v", err)
+	}
+	return catalog
+}
+
+func TestFixtures_Parse(t *testing.T) {
+	yamlDataset := `
+movies:
+  - id: 3
+    title: Heat
+    year: "1995"
+    genres: [Crime, Drama]
+    runtime_minutes: 170
+`
+	jsonDataset := `{"movies": [{"id": 3, "title": "Heat", "year": "1995", "genres": ["Crime", "Drama"], "runtime_minutes": 170}]}`
+	// The movies.json written by the init script is a plain list
+	listDataset := `[{"id": 3, "title": "Heat", "year": "1995", "genres": ["Crime", "Drama"], "runtime_minutes": 170}]`
+
+	want := &fixtures.Dataset{Movies: []fixtures.Movie{{ID: 3, MovieInput: domain.MovieInput{
+		Title: "Heat", Year: "1995", Genres: []string{"Crime", "Drama"}, RuntimeMinutes: 170,
+	}}}}
+	for name, data := range map[string]string{"YAML": yamlDataset, "JSON": jsonDataset, "list": listDataset} {
+		dataset, err := fixtures.Parse([]byte(data))
+		if err != nil || !reflect.DeepEqual(dataset, want) {
+			t.Errorf("Parse(%s) = %+v, %v, want %+v", name, dataset, err, want)
+		}
+	}
+
+	invalid := map[string]string{
+		"an unknown field": "movies:\n  - title: Heat\n    year: \"1995\"\n    budget: 60000000\n",
+		"an unquoted year": "movies:\n  - title: Heat\n    year: 1995\n",
+		"a syntax error":   "movies: [",
+	}
+	for name, data := range invalid {
+		if _, err := fixtures.Parse([]byte(data)); err == nil {
+			t.Errorf("Parse() of a dataset with %s succeeded, want an error", name)
+		}
+	}
+}
+
+func TestFixtures_Open(t *testing.T) {
+	if names := fixtures.Names(); !slices.Contains(names, "catalog") {
+		t.Errorf("Names() = %v, want the catalog dataset", names)
+	}
+	if _, err := fixtures.Open("missing"); !errors.Is(err, fixtures.ErrUnknownDataset) {
+		t.Errorf("Open(missing) error = %v, want %v", err, fixtures.ErrUnknownDataset)
+	}
+
+	// Every movie of the catalog is valid, with a certification of the default list
+	repo := NewMockMovieRepository()
+	catalog := seedCatalog(t, repo)
+	if len(repo.movies) != len(catalog.Movies) {
+		t.Errorf("catalog loaded %d movies, want %d", len(repo.movies), len(catalog.Movies))
+	}
+	for _, movie := range repo.movies {
+		if _, err := domain.DefaultCertifications().Normalize(movie.Certification); err != nil {
+			t.Errorf("catalog movie %d certification: %v", movie.ID, err)
+		}
+	}
+}
+
+func TestFixtures_Load(t *testing.T) {
+	repo := NewMockMovieRepository()
+	repo.nextID = 100
+	dataset := &fixtures.Dataset{Movies: []fixtures.Movie{
+		{ID: 1, MovieInput: domain.MovieInput{Title: "Heat", Year: "1995", IMDbID: "tt0113277"}},
+		{MovieInput: domain.MovieInput{Title: "Amélie", Year: "2001", IMDbID: "tt0211915"}},
+	}}
+	ctx := context.Background()
+
+	result, err := fixtures.Load(ctx, repo, domain.DefaultCertifications(), dataset)
+	if err != nil || result.Created != 2 || result.Movies[1].ID != 100 {
+		t.Fatalf("Load() = %+v, %v, want 2 movies created, the one without an ID as 100", result, err)
+	}
+
+	// Loading again leaves the movies as they are, the one without an ID found by its
+	// IMDb ID instead of created again
+	dataset.Movies[0].Director = "Michael Mann"
+	result, err = fixtures.Load(ctx, repo, domain.DefaultCertifications(), dataset)
+	if err != nil || result.Replaced != 1 || result.Unchanged != 1 || len(repo.movies) != 2 {
+		t.Errorf("Load() again = %+v, %v, want Heat replaced and Amélie unchanged", result, err)
+	}
+	if heat := repo.movies[1]; heat.Director != "Michael Mann" || heat.Version != 2 {
+		t.Errorf("movie 1 = %+v, want the director set in version 2", heat)
+	}
+	if amelie := repo.movies[100]; amelie.Version != 1 {
+		t.Errorf("movie 100 = %+v, want version 1 kept", amelie)
+	}
+
+	dataset.Movies = append(dataset.Movies, fixtures.Movie{ID: 2, MovieInput: domain.MovieInput{Title: "Bad Year", Year: "99"}})
+	if _, err := fixtures.Load(ctx, repo, domain.DefaultCertifications(), dataset); err == nil || !strings.Contains(err.Error(), `movie 3 ("Bad Year")`) {
+		t.Errorf("Load() of an invalid movie error = %v, want it named", err)
+	}
+
+	// Certifications are checked against the accepted list, as the service does
+	rated := &fixtures.Dataset{Movies: []fixtures.Movie{{ID: 5, MovieInput: domain.MovieInput{Title: "Ronin", Year: "1998", Certification: "r"}}}}
+	if result, err := fixtures.Load(ctx, repo, domain.DefaultCertifications(), rated); err != nil || result.Movies[0].Certification != "R" {
+		t.Errorf("Load() of a lower-case certification = %+v, %v, want it normalized to R", result, err)
+	}
+	rated.Movies[0].Certification = "TV-MA"
+	if _, err := fixtures.Load(ctx, repo, domain.DefaultCertifications(), rated); !errors.Is(err, domain.ErrInvalidCertification) {
+		t.Errorf("Load() of an unknown certification error = %v, want %v", err, domain.ErrInvalidCertification)
+	}
+}
+
+func TestFixtures_ReadFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "movies.json")
+	if err := os.WriteFile(name, []byte(`[{"id": 1, "title": "Heat", "year": "1995"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dataset, err := fixtures.ReadFile(name)
+	if err != nil || len(dataset.Movies) != 1 || dataset.Movies[0].Title != "Heat" {
+		t.Errorf("ReadFile() = %+v, %v, want Heat", dataset, err)
+	}
+	if _, err := fixtures.ReadFile(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadFile() of a missing file error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func BenchmarkMovieService_GetMovies(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := NewMockMovieRepository()
+	seedCatalog(b, repo)
+	service := services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, err := service.GetMovies(ctx, domain.MovieFilter{Page: 1, Limit: 10}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
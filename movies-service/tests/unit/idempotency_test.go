@@ -0,0 +1,177 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/idempotency"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestIdempotencyInterceptor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := services.NewMovieService(NewMockMovieRepository(), domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	server := grpcAdapter.NewMovieServer(service, logger)
+	interceptor := grpcAdapter.IdempotencyInterceptor(idempotency.NewMemory(clock.System{}), time.Minute, logger)
+
+	info := &grpc.UnaryServerInfo{FullMethod: pb.MovieService_CreateMovie_FullMethodName}
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return server.CreateMovie(ctx, req.(*pb.CreateMovieRequest))
+	}
+	create := func(key, title string) (*pb.CreateMovieResponse, error) {
+		ctx := context.Background()
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(grpcAdapter.IdempotencyKeyHeader, key))
+		}
+		resp, err := interceptor(ctx, &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: title, Year: "2002"}}, info, handler)
+		if err != nil {
+			return nil, err
+		}
+		return resp.(*pb.CreateMovieResponse), nil
+	}
+
+	first, err := create("key-1", "City of God")
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	retry, err := create("key-1", "City of God")
+	if err != nil {
+		t.Fatalf("CreateMovie() retry unexpected error = %v", err)
+	}
+	if calls != 1 || retry.Movie.Id != first.Movie.Id || retry.Movie.Title != "City of God" {
+		t.Errorf("retry = %v after %d calls, want the stored movie %d without a second call", retry, calls, first.Movie.Id)
+	}
+
+	if _, err := create("key-1", "Carandiru"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateMovie() with a reused key error = %v, want InvalidArgument", err)
+	}
+
+	second, err := create("", "City of God")
+	if err != nil || second.Movie.Id == first.Movie.Id || calls != 2 {
+		t.Errorf("CreateMovie() without a key = %v, %v, want a new movie", second, err)
+	}
+}
+
+func TestIdempotencyInterceptor_FailedCallsAreNotStored(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	interceptor := grpcAdapter.IdempotencyInterceptor(idempotency.NewMemory(clock.System{}), time.Minute, logger)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.MovieService_CreateMovie_FullMethodName}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcAdapter.IdempotencyKeyHeader, "key-1"))
+	req := &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: "Pixote", Year: "1980"}}
+
+	_, err := interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.Unavailable, "database down")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("first call error = %v, want Unavailable", err)
+	}
+
+	retried := false
+	_, err = interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		retried = true
+		return &pb.CreateMovieResponse{Movie: &pb.Movie{Id: 1}}, nil
+	})
+	if err != nil || !retried {
+		t.Errorf("retry after a failure ran = %v, error = %v, want the call to run again", retried, err)
+	}
+
+	// A result that cannot be stored releases the key as well
+	calls := 0
+	unstorable := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return "not a protobuf message", nil
+	}
+	unstoredCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcAdapter.IdempotencyKeyHeader, "key-2"))
+	for range 2 {
+		if _, err := interceptor(unstoredCtx, req, info, unstorable); err != nil {
+			t.Fatalf("call with an unstorable result unexpected error = %v", err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("unstorable result ran %d times, want the retry to run again", calls)
+	}
+}
+
+func TestIdempotencyInterceptor_InProgress(t *testing.T) {
+	store := idempotency.NewMemory(clock.System{})
+	ctx := context.Background()
+
+	if _, reserved, _ := store.Reserve(ctx, "key", "a", time.Minute); !reserved {
+		t.Fatalf("Reserve() = false, want true for an unused key")
+	}
+	record, reserved, _ := store.Reserve(ctx, "key", "a", time.Minute)
+	if reserved || record.Done() {
+		t.Errorf("Reserve() = %v, %v, want the pending record", record, reserved)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	interceptor := grpcAdapter.IdempotencyInterceptor(store, time.Minute, logger)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.MovieService_CreateMovie_FullMethodName}
+	mdCtx := metadata.NewIncomingContext(ctx, metadata.Pairs(grpcAdapter.IdempotencyKeyHeader, "slow"))
+	req := &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: "Pixote", Year: "1980"}}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		_, err := interceptor(mdCtx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return &pb.CreateMovieResponse{Movie: &pb.Movie{Id: 1}}, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	_, err := interceptor(mdCtx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("handler must not run")
+	})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("concurrent retry error = %v, want Aborted", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("first call unexpected error = %v", err)
+	}
+}
+
+func TestMemory_ReserveExpired(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	store := idempotency.NewMemory(clk)
+	ctx := context.Background()
+
+	store.Reserve(ctx, "key", "a", time.Second)
+	clk.Advance(time.Second / 2)
+	store.Complete(ctx, "key", []byte("response"), time.Minute)
+	clk.Advance(time.Minute - time.Second)
+	if record, reserved, _ := store.Reserve(ctx, "key", "b", time.Minute); reserved || string(record.Response) != "response" {
+		t.Errorf("Reserve() = %+v, %v before the key expired, want the stored response", record, reserved)
+	}
+
+	clk.Advance(time.Second)
+	if _, reserved, _ := store.Reserve(ctx, "key", "b", time.Minute); !reserved {
+		t.Error("Reserve() = false once the key expired, want the key reserved again")
+	}
+
+	// A key never completed is held only for its lease
+	store.Reserve(ctx, "abandoned", "a", time.Second)
+	clk.Advance(time.Second)
+	if _, reserved, _ := store.Reserve(ctx, "abandoned", "a", time.Second); !reserved {
+		t.Error("Reserve() = false once the lease ended, want the key reserved again")
+	}
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+)
+
+// waitForImport polls the import until it is done
+func waitForImport(t *testing.T, server *grpcAdapter.ImportServer, id string) *pb.MovieImport {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := server.GetImport(context.Background(), &pb.GetImportRequest{Id: id})
+		if err != nil {
+			t.Fatalf("GetImport() unexpected error = %v", err)
+		}
+		if domain.JobStatus(resp.MovieImport.Status).Done() {
+			return resp.MovieImport
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import %s still %s", id, resp.MovieImport.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newImportServer returns the import API of the catalog held by the returned movie
+// repository, with imports run by the returned jobs
+func newImportServer() (*grpcAdapter.ImportServer, *MockMovieRepository, *testJobs) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	movieRepo := NewMockMovieRepository()
+	jobs := newTestJobs(movieRepo)
+	return grpcAdapter.NewImportServer(services.NewImporter(jobs, logger), logger), movieRepo, jobs
+}
+
+func TestImporter_Report(t *testing.T) {
+	server, movieRepo, jobs := newImportServer()
+	defer jobs.stop()
+	movieRepo.movies[7] = &domain.Movie{ID: 7, Title: "Heat", Year: "1995", Version: 1, IMDbID: "tt0113277"}
+	movieRepo.nextID = 8
+
+	resp, err := server.StartImport(context.Background(), &pb.StartImportRequest{Rows: []*pb.ImportRow{
+		{Movie: &pb.MovieInput{Title: "Central Station", Year: "1998"}},
+		{Id: 7, Movie: &pb.MovieInput{Title: "Heat", Year: "1995", ImdbId: "tt0113277", Regions: []string{"US"}}},
+		{Id: 20, Movie: &pb.MovieInput{Title: "City of God", Year: "2002"}},
+		{Movie: &pb.MovieInput{Year: "2001"}},
+		{Movie: &pb.MovieInput{Title: "Movie", Year: "19x0"}},
+		{Movie: &pb.MovieInput{Title: "Heat", Year: "1986", ImdbId: "tt0113277"}},
+	}})
+	if err != nil {
+		t.Fatalf("StartImport() unexpected error = %v", err)
+	}
+	if resp.MovieImport.Id == "" || resp.MovieImport.Total != 6 || resp.MovieImport.Status != string(domain.JobPending) {
+		t.Fatalf("StartImport() = %v, want a pending import of 6 rows", resp.MovieImport)
+	}
+
+	report := waitForImport(t, server, resp.MovieImport.Id)
+	if report.Status != string(domain.JobCompleted) || report.Total != 6 || report.Processed != 6 || report.FinishedAt == nil {
+		t.Fatalf("import = %v, want 6 rows completed", report)
+	}
+	if report.Created != 2 || report.Updated != 1 || report.Invalid != 2 || report.Failed != 1 {
+		t.Errorf("import counts = %d created, %d updated, %d invalid, %d failed, want 2, 1, 2 and 1",
+			report.Created, report.Updated, report.Invalid, report.Failed)
+	}
+
+	want := []struct {
+		status  domain.ImportRowStatus
+		movieID int32
+		field   string
+	}{
+		{domain.RowCreated, 8, ""},
+		{domain.RowUpdated, 7, ""},
+		{domain.RowCreated, 20, ""},
+		{domain.RowInvalid, 0, "title"},
+		{domain.RowInvalid, 0, "year"},
+		{domain.RowFailed, 0, "imdb_id"},
+	}
+	for i, w := range want {
+		got := report.Results[i]
+		if got.Row != int32(i+1) || got.Status != string(w.status) || got.MovieId != w.movieID || got.Field != w.field {
+			t.Errorf("row %d = %v, want %s with movie %d and field %q", i+1, got, w.status, w.movieID, w.field)
+		}
+		if (w.status == domain.RowInvalid || w.status == domain.RowFailed) && got.Error == "" {
+			t.Errorf("row %d has no error message", i+1)
+		}
+	}
+	if movie := movieRepo.movies[7]; len(movie.Regions) != 1 {
+		t.Errorf("movie 7 = %+v, want the regions of the import", movie)
+	}
+
+	job, err := jobs.GetJob(context.Background(), resp.MovieImport.Id)
+	if err != nil {
+		t.Fatalf("GetJob() unexpected error = %v", err)
+	}
+	if job.Type != domain.JobImport || job.Done != 6 || job.Total != 6 {
+		t.Errorf("import job = %s with %d of %d rows, want the 6 rows of an import", job.Type, job.Done, job.Total)
+	}
+}
+
+func TestImporter_Shutdown(t *testing.T) {
+	server, _, jobs := newImportServer()
+
+	rows := make([]*pb.ImportRow, 5000)
+	for i := range rows {
+		rows[i] = &pb.ImportRow{Movie: &pb.MovieInput{Title: fmt.Sprintf("Movie %d", i), Year: "2000"}}
+	}
+	resp, err := server.StartImport(context.Background(), &pb.StartImportRequest{Rows: rows})
+	if err != nil {
+		t.Fatalf("StartImport() unexpected error = %v", err)
+	}
+	for {
+		job, err := jobs.GetJob(context.Background(), resp.MovieImport.Id)
+		if err != nil {
+			t.Fatalf("GetJob() unexpected error = %v", err)
+		}
+		if job.Status != domain.JobPending {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	jobs.stop()
+
+	report := waitForImport(t, server, resp.MovieImport.Id)
+	if report.FinishedAt == nil {
+		t.Errorf("import after a shutdown = %s, want it done with its finish time", report.Status)
+	}
+	if report.Status == string(domain.JobFailed) && (report.Error == "" || report.Processed == report.Total) {
+		t.Errorf("interrupted import = %d of %d rows, error %q, want the rows left and why", report.Processed, report.Total, report.Error)
+	}
+	if _, err := server.GetImport(context.Background(), &pb.GetImportRequest{Id: "job-42"}); err == nil {
+		t.Error("GetImport() of an unknown import succeeded")
+	}
+
+	job, err := jobs.SubmitJob(context.Background(), domain.JobArchive, "", nil)
+	if err != nil {
+		t.Fatalf("SubmitJob() unexpected error = %v", err)
+	}
+	importer := services.NewImporter(jobs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := importer.GetImport(context.Background(), job.ID); !errors.Is(err, domain.ErrImportNotFound) {
+		t.Errorf("GetImport() of an archive job error = %v, want ErrImportNotFound", err)
+	}
+}
@@ -0,0 +1,415 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/backup"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/idgen"
+)
+
+// MockJobRepository is safe for concurrent use, since jobs run on background workers
+type MockJobRepository struct {
+	mu    sync.Mutex
+	jobs  map[string]*domain.Job
+	order []string
+	saves int
+}
+
+func NewMockJobRepository() *MockJobRepository {
+	return &MockJobRepository{jobs: make(map[string]*domain.Job)}
+}
+
+func (m *MockJobRepository) CreateJob(ctx context.Context, job *domain.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("job-%d", len(m.order)+1)
+	}
+	m.jobs[job.ID] = job.Copy()
+	m.order = append(m.order, job.ID)
+	return nil
+}
+
+func (m *MockJobRepository) FindJob(ctx context.Context, id string) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	return job.Copy(), nil
+}
+
+func (m *MockJobRepository) ClaimJob(ctx context.Context, owner string, leaseUntil, starvedBefore time.Time) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var next *domain.Job
+	for _, id := range m.order {
+		job, ok := m.jobs[id]
+		if !ok || job.Status != domain.JobPending {
+			continue
+		}
+		if job.CreatedAt.Before(starvedBefore) {
+			next = job
+			break
+		}
+		if next == nil || job.Priority.Rank() > next.Priority.Rank() {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status, next.Owner, next.LeaseUntil, next.StartedAt = domain.JobRunning, owner, leaseUntil, time.Now().UTC()
+	return next.Copy(), nil
+}
+
+func (m *MockJobRepository) UpdateJob(ctx context.Context, job *domain.Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.jobs[job.ID]
+	if !ok || stored.Status != domain.JobRunning || stored.Owner != job.Owner {
+		return domain.ErrJobNotFound
+	}
+	m.jobs[job.ID] = job.Copy()
+	m.saves++
+	return nil
+}
+
+func (m *MockJobRepository) RenewJobLease(ctx context.Context, id, owner string, leaseUntil time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.jobs[id]
+	if !ok || stored.Status != domain.JobRunning || stored.Owner != owner {
+		return domain.ErrJobNotFound
+	}
+	stored.LeaseUntil = leaseUntil
+	return nil
+}
+
+func (m *MockJobRepository) FailExpiredJobs(ctx context.Context, now time.Time, reason string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	failed := 0
+	for _, job := range m.jobs {
+		if job.Status == domain.JobRunning && job.LeaseUntil.Before(now) {
+			job.Status, job.Error, job.FinishedAt = domain.JobFailed, reason, now
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+func (m *MockJobRepository) DeleteFinishedJobs(ctx context.Context, before time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted []string
+	for id, job := range m.jobs {
+		if !job.FinishedAt.IsZero() && job.FinishedAt.Before(before) {
+			delete(m.jobs, id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+// MockJobFileStore keeps the files of jobs in memory once they are closed
+type MockJobFileStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMockJobFileStore() *MockJobFileStore {
+	return &MockJobFileStore{files: make(map[string][]byte)}
+}
+
+type mockJobFile struct {
+	bytes.Buffer
+	close func([]byte)
+}
+
+func (f *mockJobFile) Close() error {
+	f.close(f.Bytes())
+	return nil
+}
+
+func (m *MockJobFileStore) Create(ctx context.Context, jobID string) (io.WriteCloser, error) {
+	return &mockJobFile{close: func(data []byte) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.files[jobID] = data
+	}}, nil
+}
+
+func (m *MockJobFileStore) Open(ctx context.Context, jobID string, offset int64) (*domain.JobFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[jobID]
+	if !ok {
+		return nil, domain.ErrNoJobFile
+	}
+	size := int64(len(data))
+	return &domain.JobFile{ReadCloser: io.NopCloser(bytes.NewReader(data[domain.JobFileOffset(offset, size):])), Size: size}, nil
+}
+
+func (m *MockJobFileStore) Delete(ctx context.Context, jobID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[jobID]; !ok {
+		return domain.ErrNoJobFile
+	}
+	delete(m.files, jobID)
+	return nil
+}
+
+// testJobs runs the jobs of a test on a single worker until stop is called
+type testJobs struct {
+	*services.Jobs
+	repo  *MockJobRepository
+	files *MockJobFileStore
+	stop  func()
+}
+
+// newTestJobs returns the jobs of the catalog held by movieRepo, with the default
+// archive age set to a day
+func newTestJobs(movieRepo *MockMovieRepository) *testJobs {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	movieService := services.NewMovieService(movieRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	repo, files := NewMockJobRepository(), NewMockJobFileStore()
+	pool := workerpool.New("jobs", workerpool.Options{Workers: 1}, logger)
+	jobs := services.NewJobs(repo, files, map[domain.JobType]ports.JobHandler{
+		domain.JobImport:  services.NewImportJob(movieService, logger),
+		domain.JobExport:  backup.NewExportJob(movieService, files, logger),
+		domain.JobArchive: services.NewArchiveJob(movieRepo, 24*time.Hour, clock.System{}, logger),
+	}, pool, services.JobOptions{
+		Owner:        "test",
+		PollInterval: 5 * time.Millisecond,
+		Lease:        3 * time.Second,
+		Retention:    time.Hour,
+		MaxWait:      time.Minute,
+	}, clock.System{}, &idgen.Sequence{}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		jobs.Run(ctx)
+		close(done)
+	}()
+	return &testJobs{Jobs: jobs, repo: repo, files: files, stop: func() {
+		cancel()
+		<-done
+		pool.Close()
+	}}
+}
+
+// waitForJob polls the job until it is done
+func waitForJob(t *testing.T, jobs ports.JobService, id string) *domain.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobs.GetJob(context.Background(), id)
+		if err != nil {
+			t.Fatalf("GetJob() unexpected error = %v", err)
+		}
+		if job.Status.Done() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s still %s", id, job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobs_Export(t *testing.T) {
+	movieRepo := NewMockMovieRepository()
+	for id := int32(1); id <= 3; id++ {
+		movieRepo.movies[id] = &domain.Movie{ID: id, Title: fmt.Sprintf("Movie %d", id), Year: "2000", Version: 1}
+	}
+	jobs := newTestJobs(movieRepo)
+	defer jobs.stop()
+
+	job, err := jobs.SubmitJob(context.Background(), domain.JobExport, "", nil)
+	if err != nil {
+		t.Fatalf("SubmitJob() unexpected error = %v", err)
+	}
+	if _, err := jobs.OpenJobFile(context.Background(), job.ID, 0); !errors.Is(err, domain.ErrNoJobFile) {
+		t.Errorf("OpenJobFile() of a pending export error = %v, want ErrNoJobFile", err)
+	}
+
+	job = waitForJob(t, jobs, job.ID)
+	var result backup.ExportResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		t.Fatalf("export result %s: %v", job.Result, err)
+	}
+	if job.Status != domain.JobCompleted || !job.File || result.Movies != 3 || result.SHA256 == "" || job.Done != 3 {
+		t.Fatalf("export = %+v with result %s, want 3 movies in a file", job, job.Result)
+	}
+
+	file, err := jobs.OpenJobFile(context.Background(), job.ID, 0)
+	if err != nil {
+		t.Fatalf("OpenJobFile() unexpected error = %v", err)
+	}
+	defer file.Close()
+	whole, err := io.ReadAll(file)
+	if err != nil || int64(len(whole)) != file.Size {
+		t.Fatalf("read %d bytes of a %d byte file, error = %v", len(whole), file.Size, err)
+	}
+
+	// Resumed and suffix downloads read the end of the same file
+	for _, offset := range []int64{10, -10} {
+		tail, err := jobs.OpenJobFile(context.Background(), job.ID, offset)
+		if err != nil {
+			t.Fatalf("OpenJobFile(%d) unexpected error = %v", offset, err)
+		}
+		data, _ := io.ReadAll(tail)
+		tail.Close()
+		if want := whole[domain.JobFileOffset(offset, file.Size):]; !bytes.Equal(data, want) || tail.Size != file.Size {
+			t.Errorf("OpenJobFile(%d) = %d bytes of %d, want the %d bytes from the offset", offset, len(data), tail.Size, len(want))
+		}
+	}
+
+	reader, err := backup.NewReader(bytes.NewReader(whole))
+	if err != nil {
+		t.Fatalf("NewReader() unexpected error = %v", err)
+	}
+	for {
+		if _, err := reader.Next(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Next() unexpected error = %v", err)
+			}
+			break
+		}
+	}
+	if reader.Count() != 3 {
+		t.Errorf("export file holds %d movies, want 3", reader.Count())
+	}
+}
+
+func TestJobs_Archive(t *testing.T) {
+	movieRepo := NewMockMovieRepository()
+	movieRepo.stale = 3
+	jobs := newTestJobs(movieRepo)
+	defer jobs.stop()
+
+	job, err := jobs.SubmitJob(context.Background(), domain.JobArchive, "", json.RawMessage(`{"after_days":30}`))
+	if err != nil {
+		t.Fatalf("SubmitJob() unexpected error = %v", err)
+	}
+	job = waitForJob(t, jobs, job.ID)
+	if job.Status != domain.JobCompleted || string(job.Result) != `{"archived":3}` || job.File {
+		t.Errorf("archive = %s with result %s, want 3 movies archived", job.Status, job.Result)
+	}
+}
+
+func TestJobs_SubmitInvalid(t *testing.T) {
+	jobs := newTestJobs(NewMockMovieRepository())
+	defer jobs.stop()
+
+	tests := []struct {
+		name      string
+		jobType   domain.JobType
+		priority  domain.JobPriority
+		params    string
+		wantErr   error
+		wantField string
+	}{
+		{"unknown type", "enrich", "", "", domain.ErrInvalidJob, "type"},
+		{"unknown priority", domain.JobExport, "urgent", "", domain.ErrInvalidJob, "priority"},
+		{"negative archive age", domain.JobArchive, "", `{"after_days":-1}`, domain.ErrInvalidJob, "after_days"},
+		{"invalid parameters", domain.JobArchive, "", `{"after_days":"30"}`, domain.ErrInvalidJob, ""},
+		{"import without rows", domain.JobImport, "", `{"rows":[]}`, domain.ErrInvalidImport, "rows"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jobs.SubmitJob(context.Background(), tt.jobType, tt.priority, json.RawMessage(tt.params))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubmitJob() error = %v, want %v", err, tt.wantErr)
+			}
+			var fieldErr *domain.FieldError
+			field := ""
+			if errors.As(err, &fieldErr) {
+				field = fieldErr.Field
+			}
+			if field != tt.wantField {
+				t.Errorf("SubmitJob() error = %v, want it on field %q", err, tt.wantField)
+			}
+		})
+	}
+
+	jobs.repo.mu.Lock()
+	defer jobs.repo.mu.Unlock()
+	if len(jobs.repo.jobs) != 0 {
+		t.Errorf("invalid jobs queued %d jobs, want none", len(jobs.repo.jobs))
+	}
+}
+
+func TestJobs_Priority(t *testing.T) {
+	jobs := newTestJobs(NewMockMovieRepository())
+	jobs.stop()
+
+	tests := []struct {
+		jobType  domain.JobType
+		priority domain.JobPriority
+		want     domain.JobPriority
+	}{
+		{domain.JobArchive, "", domain.JobPriorityLow},
+		{domain.JobExport, "", domain.JobPriorityNormal},
+		{domain.JobExport, domain.JobPriorityHigh, domain.JobPriorityHigh},
+		{domain.JobArchive, domain.JobPriorityNormal, domain.JobPriorityNormal},
+	}
+	for _, tt := range tests {
+		job, err := jobs.SubmitJob(context.Background(), tt.jobType, tt.priority, nil)
+		if err != nil {
+			t.Fatalf("SubmitJob(%s, %q) unexpected error = %v", tt.jobType, tt.priority, err)
+		}
+		if stored, _ := jobs.GetJob(context.Background(), job.ID); stored.Priority != tt.want {
+			t.Errorf("SubmitJob(%s, %q) priority = %q, want %q", tt.jobType, tt.priority, stored.Priority, tt.want)
+		}
+	}
+}
+
+func TestJobs_Sweep(t *testing.T) {
+	jobs := newTestJobs(NewMockMovieRepository())
+	jobs.stop()
+	now := time.Now().UTC()
+	jobs.repo.jobs["abandoned"] = &domain.Job{ID: "abandoned", Type: domain.JobExport, Status: domain.JobRunning, Owner: "crashed", LeaseUntil: now.Add(-time.Second)}
+	jobs.repo.jobs["running"] = &domain.Job{ID: "running", Type: domain.JobExport, Status: domain.JobRunning, Owner: "test", LeaseUntil: now.Add(time.Minute)}
+	jobs.repo.jobs["old"] = &domain.Job{ID: "old", Type: domain.JobExport, Status: domain.JobCompleted, File: true, FinishedAt: now.Add(-2 * time.Hour)}
+	jobs.repo.jobs["recent"] = &domain.Job{ID: "recent", Type: domain.JobExport, Status: domain.JobCompleted, File: true, FinishedAt: now.Add(-time.Minute)}
+	jobs.files.files["old"] = []byte("backup")
+	jobs.files.files["recent"] = []byte("backup")
+
+	if err := jobs.Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() unexpected error = %v", err)
+	}
+	if job := jobs.repo.jobs["abandoned"]; job.Status != domain.JobFailed || job.Error == "" {
+		t.Errorf("abandoned job = %s %q, want it failed with the reason", job.Status, job.Error)
+	}
+	if job := jobs.repo.jobs["running"]; job.Status != domain.JobRunning {
+		t.Errorf("running job = %s, want it still running", job.Status)
+	}
+	if _, ok := jobs.repo.jobs["old"]; ok {
+		t.Error("job finished before the retention was kept")
+	}
+	if _, ok := jobs.files.files["old"]; ok {
+		t.Error("file of a removed job was kept")
+	}
+	if _, ok := jobs.files.files["recent"]; !ok {
+		t.Error("file of a recent job was removed")
+	}
+}
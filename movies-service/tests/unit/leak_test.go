@@ -0,0 +1,227 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/adapters/notify"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/leakcheck"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/clock"
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+var soak = flag.Duration("soak", 0, "keep the load of TestMovieServer_NoLeaks running this long, checking for leaks after each round")
+
+// leakTolerance covers the goroutines and descriptors the runtime and gRPC start or
+// close lazily, far fewer than a leak in a round of load leaves behind
+var leakTolerance = leakcheck.Tolerance{Goroutines: 4, OpenFiles: 2}
+
+// TestMovieServer_NoLeaks serves rounds of load over TCP, with streams read to the end
+// or abandoned, webhook notifications sent by the worker pool and connections opened and
+// closed by each client, then checks the goroutines, descriptors and streams go back to
+// what they were before. With -soak the rounds go on for the given time, as a soak test:
+//
+//	go test ./tests/unit -run NoLeaks -soak 10m
+func TestMovieServer_NoLeaks(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+	defer webhook.Close()
+
+	pool := workerpool.New("notifications", workerpool.Options{Workers: 2, QueueSize: 1000}, logger)
+	defer pool.Close()
+	repo := &lockedMovieRepository{MockMovieRepository: NewMockMovieRepository()}
+	service := services.NewNotifyingMovieService(
+		services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger),
+		[]services.NotificationChannel{{
+			Notifier: notify.NewSlack(webhook.URL, time.Second),
+			Events:   []domain.MovieEventType{domain.MovieCreated, domain.MovieReplaced, domain.MovieDeleted},
+		}},
+		time.Second, pool, clock.System{}, logger)
+
+	metrics := grpcAdapter.NewMetrics()
+	server := grpc.NewServer(grpc.ChainStreamInterceptor(metrics.InFlightStreamInterceptor()))
+	pb.RegisterMovieServiceServer(server, grpcAdapter.NewMovieServer(service, logger))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	probe := leakcheck.Probe{Streams: metrics.ActiveStreams}
+	baseline := probe.Take()
+	deadline := time.Now().Add(*soak)
+	for round := 1; round == 1 || time.Now().Before(deadline); round++ {
+		runLoad(t, listener.Addr().String(), round)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		for stats := pool.Stats(); stats.Queued > 0 || stats.Running > 0; stats = pool.Stats() {
+			if ctx.Err() != nil {
+				t.Fatalf("round %d: notifications still being sent, stats = %+v", round, stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Idle keep-alive connections to the webhook are pooled, not leaked
+		http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+		current, err := probe.Settle(ctx, baseline, leakTolerance)
+		cancel()
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		t.Logf("round %d: %d goroutines, %d open files, %d streams", round, current.Goroutines, current.OpenFiles, current.Streams)
+	}
+	if stats := pool.Stats(); stats.Completed == 0 || stats.Failed > 0 {
+		t.Errorf("notification pool stats = %+v, want every notification sent", stats)
+	}
+}
+
+// runLoad makes clients call the server at once, each over a connection of its own
+func runLoad(t *testing.T, address string, round int) {
+	const clients, calls = 4, 10
+	var wg sync.WaitGroup
+	for c := 0; c < clients; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+			client := pb.NewMovieServiceClient(conn)
+			for i := 0; i < calls; i++ {
+				if err := loadCalls(client, fmt.Sprintf("Round %d client %d call %d", round, c, i)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// loadCalls makes the calls of one client: a movie is created, read, listed, exported,
+// imported again and deleted, and an export is abandoned after its first movie
+func loadCalls(client pb.MovieServiceClient, title string) error {
+	ctx := context.Background()
+	created, err := client.CreateMovie(ctx, &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: title, Year: "2020"}})
+	if err != nil {
+		return fmt.Errorf("CreateMovie() unexpected error = %w", err)
+	}
+	id := created.GetMovie().GetId()
+	if _, err := client.GetMovie(ctx, &pb.GetMovieRequest{Id: id}); err != nil {
+		return fmt.Errorf("GetMovie() unexpected error = %w", err)
+	}
+	if _, err := client.GetMovies(ctx, &pb.GetMoviesRequest{Page: 1, Limit: 10}); err != nil {
+		return fmt.Errorf("GetMovies() unexpected error = %w", err)
+	}
+
+	export, err := client.ExportMovies(ctx, &pb.ExportMoviesRequest{AfterId: id - 1})
+	if err != nil {
+		return fmt.Errorf("ExportMovies() unexpected error = %w", err)
+	}
+	var exported *pb.Movie
+	for {
+		resp, err := export.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ExportMovies() stream error = %w", err)
+		}
+		if resp.GetMovie().GetId() == id {
+			exported = resp.GetMovie()
+		}
+	}
+
+	abandoned, cancel := context.WithCancel(ctx)
+	defer cancel()
+	export, err = client.ExportMovies(abandoned, &pb.ExportMoviesRequest{})
+	if err != nil {
+		return fmt.Errorf("ExportMovies() unexpected error = %w", err)
+	}
+	if _, err := export.Recv(); err != nil {
+		return fmt.Errorf("ExportMovies() stream error = %w", err)
+	}
+	cancel()
+
+	stream, err := client.ImportMovies(ctx)
+	if err != nil {
+		return fmt.Errorf("ImportMovies() unexpected error = %w", err)
+	}
+	if err := stream.Send(&pb.ImportMoviesRequest{Movie: exported}); err != nil {
+		return fmt.Errorf("ImportMovies() send error = %w", err)
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return fmt.Errorf("ImportMovies() unexpected error = %w", err)
+	}
+
+	if _, err := client.DeleteMovie(ctx, &pb.DeleteMovieRequest{Id: id}); err != nil {
+		return fmt.Errorf("DeleteMovie() unexpected error = %w", err)
+	}
+	return nil
+}
+
+func TestDetector_RisingFloor(t *testing.T) {
+	var logs bytes.Buffer
+	detector := leakcheck.NewDetector(leakcheck.Probe{}, leakcheck.DetectorOptions{Window: 3, Windows: 2},
+		slog.New(slog.NewTextHandler(&logs, nil)))
+
+	// Goroutines spike with load and come back to a floor that keeps rising, while the
+	// streams go up and down to the same floor
+	windows := [][]int{{10, 40, 12}, {11, 50, 30}, {25, 12, 60}}
+	for _, window := range windows {
+		for i, goroutines := range window {
+			detector.Observe(leakcheck.Snapshot{Goroutines: goroutines, OpenFiles: -1, Streams: int64(i * 5)})
+		}
+	}
+
+	if !detector.Suspected(leakcheck.ResourceGoroutines) {
+		t.Error("Suspected(goroutines) = false, want true")
+	}
+	if detector.Suspected(leakcheck.ResourceStreams) || detector.Suspected(leakcheck.ResourceOpenFiles) {
+		t.Error("Suspected() = true for a resource whose floor did not rise")
+	}
+	if strings.Count(logs.String(), "Possible resource leak") != 1 {
+		t.Errorf("logs = %q, want the leak reported once", logs.String())
+	}
+
+	var metrics bytes.Buffer
+	detector.WritePrometheus(&metrics)
+	for _, want := range []string{`movies_leak_suspected{resource="goroutines"} 1`, `movies_leak_suspected{resource="grpc_streams"} 0`} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Errorf("WritePrometheus() = %q, want %q", metrics.String(), want)
+		}
+	}
+
+	// Once the floor stops rising the resource is no longer suspected
+	for range 3 {
+		detector.Observe(leakcheck.Snapshot{Goroutines: 5, OpenFiles: -1})
+	}
+	if detector.Suspected(leakcheck.ResourceGoroutines) {
+		t.Error("Suspected(goroutines) = true after the floor fell, want false")
+	}
+}
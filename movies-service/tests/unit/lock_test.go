@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/lock"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestMemory_Acquire(t *testing.T) {
+	locker := lock.NewMemory(clock.System{})
+	ctx := context.Background()
+
+	if ok, _ := locker.Acquire(ctx, "job", "a", time.Minute); !ok {
+		t.Fatalf("Acquire(a) = false, want true on a free lock")
+	}
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); ok {
+		t.Errorf("Acquire(b) = true, want false while a holds the lock")
+	}
+	if ok, _ := locker.Acquire(ctx, "other", "b", time.Minute); !ok {
+		t.Errorf("Acquire(b) on another lock = false, want true")
+	}
+
+	if err := locker.Release(ctx, "job", "b"); err != nil {
+		t.Fatalf("Release(b) unexpected error = %v", err)
+	}
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); ok {
+		t.Errorf("Release by a non-owner freed the lock")
+	}
+
+	if err := locker.Release(ctx, "job", "a"); err != nil {
+		t.Fatalf("Release(a) unexpected error = %v", err)
+	}
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); !ok {
+		t.Errorf("Acquire(b) = false, want true after a released the lock")
+	}
+}
+
+func TestMemory_AcquireExpired(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	locker := lock.NewMemory(clk)
+	ctx := context.Background()
+
+	locker.Acquire(ctx, "job", "a", time.Minute)
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); ok {
+		t.Fatalf("Acquire(b) = true, want false while the lease of a runs")
+	}
+	clk.Advance(time.Minute)
+
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); !ok {
+		t.Errorf("Acquire(b) = false, want true once the lease of a expired")
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	locker := lock.NewMemory(clock.System{})
+	ctx := context.Background()
+
+	ran := false
+	err := lock.WithLock(ctx, locker, "job", "a", time.Minute, func(ctx context.Context) error {
+		ran = true
+		if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); ok {
+			t.Errorf("Acquire(b) = true while a runs under the lock")
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("WithLock() ran = %v, err = %v, want ran without error", ran, err)
+	}
+
+	// The lock was released once fn returned
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); !ok {
+		t.Fatalf("Acquire(b) = false, want true after WithLock returned")
+	}
+
+	err = lock.WithLock(ctx, locker, "job", "a", time.Minute, func(ctx context.Context) error {
+		t.Errorf("fn ran while b holds the lock")
+		return nil
+	})
+	if !errors.Is(err, lock.ErrNotAcquired) {
+		t.Errorf("WithLock() error = %v, want ErrNotAcquired", err)
+	}
+}
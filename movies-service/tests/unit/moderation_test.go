@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/moderation"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+)
+
+func TestWordList_Check(t *testing.T) {
+	words := moderation.NewWordList("idiota, compre agora", "spoiler")
+
+	tests := []struct {
+		text string
+		want domain.ModerationVerdict
+	}{
+		{"Que filme incrível", domain.VerdictApprove},
+		{"Só um IDIOTA não gosta", domain.VerdictReject},
+		{"Idióta!", domain.VerdictReject},
+		{"COMPRE... agora em nosso site", domain.VerdictReject},
+		{"compre o DVD agora", domain.VerdictApprove},
+		{"Spoiler: o final", domain.VerdictReview},
+		{"spoilers são chatos", domain.VerdictApprove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			result, err := words.Check(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Check() unexpected error = %v", err)
+			}
+			if result.Verdict != tt.want {
+				t.Errorf("Check() verdict = %v (%v), want %v", result.Verdict, result.Reasons, tt.want)
+			}
+		})
+	}
+
+	if !moderation.NewWordList(" , ", "").Empty() {
+		t.Error("NewWordList() of blank lists is not empty")
+	}
+}
+
+func TestModerationAPI_Check(t *testing.T) {
+	answer, status := `{"verdict":"review","reasons":["toxicity 0.7"]}`, http.StatusOK
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var req struct{ Text string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Text != "some text" {
+			t.Errorf("moderation API received %+v, err %v", req, err)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, answer)
+	}))
+	defer server.Close()
+	api := moderation.NewAPI(server.URL, time.Second)
+
+	result, err := api.Check(context.Background(), "some text")
+	if err != nil || result.Verdict != domain.VerdictReview || result.Reasons[0] != "toxicity 0.7" {
+		t.Errorf("Check() = %+v, %v, want review for toxicity", result, err)
+	}
+
+	answer = `{"verdict":"maybe"}`
+	requests.Store(0)
+	if _, err := api.Check(context.Background(), "some text"); err == nil {
+		t.Error("Check() with unknown verdict succeeded, want an error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("Check() with unknown verdict sent %d requests, want no retry", n)
+	}
+	answer, status = `{}`, http.StatusInternalServerError
+	requests.Store(0)
+	if _, err := api.Check(context.Background(), "some text"); err == nil {
+		t.Error("Check() with failed API succeeded, want an error")
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("Check() with failed API sent %d requests, want 1 retry", n)
+	}
+}
+
+// failingFilter stands in for an unreachable moderation API
+type failingFilter struct{}
+
+func (failingFilter) Name() string { return "api" }
+
+func (failingFilter) Check(ctx context.Context, text string) (domain.ModerationResult, error) {
+	if strings.Contains(text, "outage") {
+		return domain.ModerationResult{}, errors.New("connection refused")
+	}
+	return domain.ModerationResult{}, nil
+}
+
+func TestCommentModerator_ModerateOnce(t *testing.T) {
+	repo, service := newCommentService(true)
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	bodies := []string{"Great movie", "Spoiler: he dies", "You idiot", "Posted during an outage"}
+	for _, body := range bodies {
+		comment, err := service.CreateComment(ctx, domain.CommentInput{MovieID: 1, Author: "Ana", Body: body})
+		if err != nil {
+			t.Fatalf("CreateComment() unexpected error = %v", err)
+		}
+		if comment.Status != domain.ModerationPending {
+			t.Errorf("CreateComment() status = %q, want pending", comment.Status)
+		}
+	}
+	if threads, _, _ := service.ListComments(ctx, 1, 1, 10); len(threads) != 0 {
+		t.Errorf("ListComments() before moderation = %d threads, want none", len(threads))
+	}
+
+	filters := []ports.ModerationFilter{moderation.NewWordList("idiot", "spoiler"), failingFilter{}}
+	moderated, err := services.NewCommentModerator(repo, filters, logger).ModerateOnce(ctx)
+	if err != nil || moderated != 4 {
+		t.Fatalf("ModerateOnce() = %d, %v, want 4 comments moderated", moderated, err)
+	}
+
+	want := []domain.ModerationStatus{domain.ModerationApproved, domain.ModerationReview, domain.ModerationRejected, domain.ModerationReview}
+	for i, comment := range repo.comments {
+		if comment.Status != want[i] {
+			t.Errorf("comment %q status = %q (%v), want %q", comment.Body, comment.Status, comment.ModerationReasons, want[i])
+		}
+	}
+	if reasons := repo.comments[3].ModerationReasons; len(reasons) != 1 || reasons[0] != "api: filter unavailable" {
+		t.Errorf("failed filter reasons = %v, want the filter named", reasons)
+	}
+
+	queue, total, err := service.ListModerationQueue(ctx, 1, 10)
+	if err != nil || total != 2 || queue[0].ModerationReasons[0] != `word_list: word "spoiler" needs review` {
+		t.Fatalf("ListModerationQueue() = %v, %d, %v, want the 2 queued comments with reasons", queue, total, err)
+	}
+
+	if _, err := service.DecideModeration(ctx, queue[0].ID, domain.ModerationApproved); err != nil {
+		t.Fatalf("DecideModeration() unexpected error = %v", err)
+	}
+	if _, err := service.DecideModeration(ctx, queue[0].ID, domain.ModerationRejected); !errors.Is(err, domain.ErrNotAwaitingModeration) {
+		t.Errorf("DecideModeration() of approved comment error = %v, want ErrNotAwaitingModeration", err)
+	}
+	if _, err := service.DecideModeration(ctx, queue[1].ID, domain.ModerationReview); !errors.Is(err, domain.ErrInvalidDecision) {
+		t.Errorf("DecideModeration() to review error = %v, want ErrInvalidDecision", err)
+	}
+
+	threads, total, _ := service.ListComments(ctx, 1, 1, 10)
+	if total != 2 || threads[0].ModerationReasons != nil {
+		t.Errorf("ListComments() = %d threads, want the 2 approved ones without moderation reasons", total)
+	}
+}
+
+func TestParseModerationDecision(t *testing.T) {
+	if status, err := domain.ParseModerationDecision("approve"); err != nil || status != domain.ModerationApproved {
+		t.Errorf("ParseModerationDecision(approve) = %q, %v", status, err)
+	}
+	if _, err := domain.ParseModerationDecision("approved"); !errors.Is(err, domain.ErrInvalidDecision) {
+		t.Errorf("ParseModerationDecision(approved) error = %v, want ErrInvalidDecision", err)
+	}
+}
@@ -3,12 +3,23 @@ package unit
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/ports"
 	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/fixtures"
+	"github.com/movie-microservice/proto/clock"
 )
 
 // Mock repository for testing
@@ -16,12 +27,101 @@ type MockMovieRepository struct {
 	movies   map[int32]*domain.Movie
 	nextID   int32
 	findFail bool
+	// written is when each stored movie was last written, which Archive compares
+	written map[int32]time.Time
+	// tombstones keeps the last version of deleted movies, which are created again after it
+	tombstones map[int32]int64
+	// stale is the number of movies beyond those stored that Archive considers not
+	// recently accessed
+	stale        int
+	archiveCalls int
+	archivedTo   time.Time
 }
 
+// testNow is the time of the fake clocks given to the services under test
+var testNow = time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
 func NewMockMovieRepository() *MockMovieRepository {
 	return &MockMovieRepository{
-		movies: make(map[int32]*domain.Movie),
-		nextID: 1,
+		movies:     make(map[int32]*domain.Movie),
+		nextID:     1,
+		written:    make(map[int32]time.Time),
+		tombstones: make(map[int32]int64),
+	}
+}
+
+// store keeps a copy of the movie as written now
+func (m *MockMovieRepository) store(movie *domain.Movie) {
+	m.movies[movie.ID] = movie.Copy()
+	m.written[movie.ID] = time.Now()
+}
+
+// remove deletes the movie, keeping its version in a tombstone
+func (m *MockMovieRepository) remove(movie *domain.Movie) {
+	delete(m.movies, movie.ID)
+	delete(m.written, movie.ID)
+	m.tombstones[movie.ID] = movie.Version
+}
+
+// matching returns the stored movies the filter selects, ordered by ID
+func (m *MockMovieRepository) matching(filter domain.MovieFilter) []*domain.Movie {
+	var movies []*domain.Movie
+	for _, movie := range m.movies {
+		if filter.Expr == nil || matchesFilter(movie, filter.Expr) {
+			movies = append(movies, movie)
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ID < movies[j].ID })
+	return movies
+}
+
+// matchesFilter evaluates the expression on the movie, as the database would
+func matchesFilter(movie *domain.Movie, expr *domain.FilterExpr) bool {
+	switch {
+	case expr.Condition != nil:
+		c := expr.Condition
+		var cmp int
+		switch c.Field {
+		case "id":
+			value, _ := strconv.ParseInt(c.Value, 10, 32)
+			cmp = int(int64(movie.ID) - value)
+		case "title":
+			if c.Op == domain.OpContains {
+				return strings.Contains(strings.ToLower(movie.Title), strings.ToLower(c.Value))
+			}
+			cmp = strings.Compare(movie.Title, c.Value)
+		case "year":
+			cmp = strings.Compare(movie.Year, c.Value)
+		}
+		switch c.Op {
+		case domain.OpEq:
+			return cmp == 0
+		case domain.OpNe:
+			return cmp != 0
+		case domain.OpGt:
+			return cmp > 0
+		case domain.OpGte:
+			return cmp >= 0
+		case domain.OpLt:
+			return cmp < 0
+		case domain.OpLte:
+			return cmp <= 0
+		}
+		return false
+	case len(expr.And) > 0:
+		for _, sub := range expr.And {
+			if !matchesFilter(movie, sub) {
+				return false
+			}
+		}
+		return true
+	default:
+		for _, sub := range expr.Or {
+			if matchesFilter(movie, sub) {
+				return true
+			}
+		}
+		return false
 	}
 }
 
@@ -34,7 +134,7 @@ func (m *MockMovieRepository) FindAll(ctx context.Context, filter domain.MovieFi
 	count := 0
 	skip := (filter.Page - 1) * filter.Limit
 
-	for _, movie := range m.movies {
+	for _, movie := range m.matching(filter) {
 		if count >= int(skip) && len(movies) < int(filter.Limit) {
 			movies = append(movies, movie.Copy())
 		}
@@ -57,38 +157,155 @@ func (m *MockMovieRepository) FindByID(ctx context.Context, id int32) (*domain.M
 	return movie.Copy(), nil
 }
 
+func (m *MockMovieRepository) FindByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	if m.findFail {
+		return nil, errors.New("database error")
+	}
+
+	for _, movie := range m.movies {
+		if movie.ExternalID(source) == id {
+			return movie.Copy(), nil
+		}
+	}
+	return nil, domain.ErrMovieNotFound
+}
+
 func (m *MockMovieRepository) Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
 	if m.findFail {
 		return nil, errors.New("database error")
 	}
 
+	if err := movie.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidMovieData, err)
+	}
 	if _, exists := m.movies[movie.ID]; exists {
 		return nil, domain.ErrMovieAlreadyExists
 	}
 
-	m.movies[movie.ID] = movie.Copy()
+	m.store(movie)
 	return movie.Copy(), nil
 }
 
+func (m *MockMovieRepository) Upsert(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error) {
+	if m.findFail {
+		return nil, false, errors.New("database error")
+	}
+
+	upserted := movie.Copy()
+	existing, exists := m.movies[movie.ID]
+	upserted.Version = m.tombstones[movie.ID] + 1
+	if exists {
+		upserted.Version = existing.Version + 1
+	}
+
+	m.store(upserted)
+	return upserted.Copy(), !exists, nil
+}
+
+func (m *MockMovieRepository) UpdateVersion(ctx context.Context, movie *domain.Movie, version int64) (*domain.Movie, error) {
+	if m.findFail {
+		return nil, errors.New("database error")
+	}
+
+	existing, exists := m.movies[movie.ID]
+	if !exists {
+		return nil, domain.ErrMovieNotFound
+	}
+	if existing.Version != version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	updated := movie.Copy()
+	updated.Version = version + 1
+	m.store(updated)
+	return updated.Copy(), nil
+}
+
 func (m *MockMovieRepository) Delete(ctx context.Context, id int32) error {
 	if m.findFail {
 		return errors.New("database error")
 	}
 
-	if _, exists := m.movies[id]; !exists {
+	movie, exists := m.movies[id]
+	if !exists {
 		return domain.ErrMovieNotFound
 	}
 
-	delete(m.movies, id)
+	m.remove(movie)
 	return nil
 }
 
-func (m *MockMovieRepository) Count(ctx context.Context) (int32, error) {
+func (m *MockMovieRepository) DeleteVersion(ctx context.Context, id int32, version int64) error {
+	if m.findFail {
+		return errors.New("database error")
+	}
+
+	movie, exists := m.movies[id]
+	if !exists {
+		return domain.ErrMovieNotFound
+	}
+	if movie.Version != version {
+		return domain.ErrVersionMismatch
+	}
+
+	m.remove(movie)
+	return nil
+}
+
+func (m *MockMovieRepository) Count(ctx context.Context, filter domain.MovieFilter) (int32, error) {
 	if m.findFail {
 		return 0, errors.New("database error")
 	}
 
-	return int32(len(m.movies)), nil
+	return int32(len(m.matching(filter))), nil
+}
+
+func (m *MockMovieRepository) Facets(ctx context.Context, filter domain.MovieFilter) (*domain.MovieFacets, error) {
+	if m.findFail {
+		return nil, errors.New("database error")
+	}
+
+	counts := make(map[string]int32)
+	genres := make(map[string]int32)
+	for _, movie := range m.movies {
+		counts[movie.Year[:3]+"0s"]++
+		for _, genre := range movie.Genres {
+			genres[genre]++
+		}
+	}
+
+	facets := &domain.MovieFacets{Total: int32(len(m.movies))}
+	for decade, count := range counts {
+		facets.Years = append(facets.Years, domain.FacetBucket{Value: decade, Count: count})
+	}
+	sort.Slice(facets.Years, func(i, j int) bool { return facets.Years[i].Value < facets.Years[j].Value })
+	for genre, count := range genres {
+		facets.Genres = append(facets.Genres, domain.FacetBucket{Value: genre, Count: count})
+	}
+	sort.Slice(facets.Genres, func(i, j int) bool {
+		a, b := facets.Genres[i], facets.Genres[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Value < b.Value
+	})
+	return facets, nil
+}
+
+func (m *MockMovieRepository) Archive(ctx context.Context, olderThan time.Time, limit int) (int, error) {
+	m.archiveCalls++
+	m.archivedTo = olderThan
+	archived := 0
+	for _, movie := range m.matching(domain.MovieFilter{}) {
+		if archived < limit && !movie.Archived && m.written[movie.ID].Before(olderThan) {
+			movie.Archived = true
+			archived++
+		}
+	}
+	stale := min(m.stale, limit-archived)
+	m.stale -= stale
+	return archived + stale, nil
+}
+
+func (m *MockMovieRepository) History(ctx context.Context, id int32) ([]domain.MovieRevision, error) {
+	return nil, domain.ErrHistoryUnavailable
 }
 
 func (m *MockMovieRepository) ExistsByID(ctx context.Context, id int32) (bool, error) {
@@ -100,20 +317,54 @@ func (m *MockMovieRepository) ExistsByID(ctx context.Context, id int32) (bool, e
 	return exists, nil
 }
 
+func (m *MockMovieRepository) FindVersion(ctx context.Context, id int32) (int64, error) {
+	if m.findFail {
+		return 0, errors.New("database error")
+	}
+
+	movie, exists := m.movies[id]
+	if !exists {
+		return 0, domain.ErrMovieNotFound
+	}
+	return movie.Version, nil
+}
+
 func (m *MockMovieRepository) GetNextID(ctx context.Context) (int32, error) {
 	if m.findFail {
 		return 0, errors.New("database error")
 	}
 
+	// Like the counter of the database, IDs stay above those of the stored movies
 	id := m.nextID
-	m.nextID++
+	for stored := range m.movies {
+		id = max(id, stored+1)
+	}
+	m.nextID = id + 1
 	return id, nil
 }
 
+func (m *MockMovieRepository) Export(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error) {
+	if m.findFail {
+		return nil, errors.New("database error")
+	}
+
+	var movies []*domain.Movie
+	for id, movie := range m.movies {
+		if id > afterID {
+			movies = append(movies, movie.Copy())
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ID < movies[j].ID })
+	if len(movies) > limit {
+		movies = movies[:limit]
+	}
+	return movies, nil
+}
+
 func TestMovieService_CreateMovie(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	mockRepo := NewMockMovieRepository()
-	service := services.NewMovieService(mockRepo, logger)
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
 
 	tests := []struct {
 		name    string
@@ -160,7 +411,7 @@ func TestMovieService_CreateMovie(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			movie, err := service.CreateMovie(context.Background(), tt.title, tt.year)
+			movie, err := service.CreateMovie(context.Background(), domain.MovieInput{Title: tt.title, Year: tt.year})
 
 			if tt.wantErr {
 				if err == nil {
@@ -186,10 +437,278 @@ func TestMovieService_CreateMovie(t *testing.T) {
 	}
 }
 
+func TestMovieService_CreateMovie_Regions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	movie, err := service.CreateMovie(context.Background(), domain.MovieInput{
+		Title:   "Regional Movie",
+		Year:    "2020",
+		Regions: []string{"us", "BR", " br "},
+	})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if want := []string{"BR", "US"}; !reflect.DeepEqual(movie.Regions, want) {
+		t.Errorf("CreateMovie() regions = %v, want %v", movie.Regions, want)
+	}
+
+	_, err = service.CreateMovie(context.Background(), domain.MovieInput{Title: "Movie", Year: "2020", Regions: []string{"BRA"}})
+	if !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("CreateMovie() error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+}
+
+// lockedMovieRepository serializes the calls to the mock repository of the movie
+// operations, as a database serializes its operations, so goroutines may share it
+type lockedMovieRepository struct {
+	*MockMovieRepository
+	mu sync.Mutex
+}
+
+func (r *lockedMovieRepository) FindAll(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.FindAll(ctx, filter)
+}
+
+func (r *lockedMovieRepository) Count(ctx context.Context, filter domain.MovieFilter) (int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.Count(ctx, filter)
+}
+
+func (r *lockedMovieRepository) FindByID(ctx context.Context, id int32) (*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.FindByID(ctx, id)
+}
+
+func (r *lockedMovieRepository) Upsert(ctx context.Context, movie *domain.Movie) (*domain.Movie, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.Upsert(ctx, movie)
+}
+
+func (r *lockedMovieRepository) Delete(ctx context.Context, id int32) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.Delete(ctx, id)
+}
+
+func (r *lockedMovieRepository) Export(ctx context.Context, afterID int32, limit int) ([]*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.Export(ctx, afterID, limit)
+}
+
+func (r *lockedMovieRepository) GetNextID(ctx context.Context) (int32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.GetNextID(ctx)
+}
+
+func (r *lockedMovieRepository) ExistsByID(ctx context.Context, id int32) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.ExistsByID(ctx, id)
+}
+
+func (r *lockedMovieRepository) FindByExternalID(ctx context.Context, source, id string) (*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.FindByExternalID(ctx, source, id)
+}
+
+func (r *lockedMovieRepository) Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.Create(ctx, movie)
+}
+
+func (r *lockedMovieRepository) UpdateVersion(ctx context.Context, movie *domain.Movie, version int64) (*domain.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.UpdateVersion(ctx, movie, version)
+}
+
+func (r *lockedMovieRepository) DeleteVersion(ctx context.Context, id int32, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.DeleteVersion(ctx, id, version)
+}
+
+func (r *lockedMovieRepository) FindVersion(ctx context.Context, id int32) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.MockMovieRepository.FindVersion(ctx, id)
+}
+
+var _ ports.MovieRepository = (*lockedMovieRepository)(nil)
+
+// TestMovieService_CreateMovie_Concurrent creates movies in parallel; run with -race, it
+// also checks CreateMovie shares no state between calls. Each creation must keep the ID
+// the repository allocated for it, and none may be lost
+func TestMovieService_CreateMovie_Concurrent(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &lockedMovieRepository{MockMovieRepository: NewMockMovieRepository()}
+	service := services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	const creates = 100
+	created := make([]*domain.Movie, creates)
+	errs := make([]error, creates)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range created {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			created[i], errs[i] = service.CreateMovie(context.Background(), domain.MovieInput{Title: fmt.Sprintf("Movie %d", i), Year: "2020"})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	ids := make(map[int32]bool)
+	for i, movie := range created {
+		if errs[i] != nil {
+			t.Fatalf("CreateMovie(%d) unexpected error = %v", i, errs[i])
+		}
+		if ids[movie.ID] {
+			t.Errorf("CreateMovie(%d) got the ID %d of another movie", i, movie.ID)
+		}
+		ids[movie.ID] = true
+		if stored := repo.movies[movie.ID]; stored == nil || stored.Title != movie.Title {
+			t.Errorf("movie %d stored as %v, want %q", movie.ID, stored, movie.Title)
+		}
+	}
+	if len(repo.movies) != creates {
+		t.Errorf("stored %d movies, want %d", len(repo.movies), creates)
+	}
+}
+
+func TestMovieService_CreateMovie_Certification(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	certifications := domain.ParseCertifications("L, 10, 12, 14, 16, 18")
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), certifications, logger)
+
+	movie, err := service.CreateMovie(context.Background(), domain.MovieInput{
+		Title:         "Central do Brasil",
+		Year:          "1998",
+		Awards:        []string{" Golden Bear "},
+		Certification: "14",
+	})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if movie.Certification != "14" || !reflect.DeepEqual(movie.Awards, []string{"Golden Bear"}) {
+		t.Errorf("CreateMovie() = %+v, want certification 14 and award Golden Bear", movie)
+	}
+
+	_, err = service.CreateMovie(context.Background(), domain.MovieInput{Title: "Movie", Year: "2020", Certification: "PG-13"})
+	if !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("CreateMovie() error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+
+	_, _, err = service.GetMovies(context.Background(), domain.MovieFilter{Certification: "PG-13"})
+	if !errors.Is(err, domain.ErrInvalidCertification) {
+		t.Errorf("GetMovies() error = %v, want %v", err, domain.ErrInvalidCertification)
+	}
+}
+
+func TestMovieService_CreateMovie_Details(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	movie, err := service.CreateMovie(context.Background(), domain.MovieInput{
+		Title:          "Heat",
+		Year:           "1995",
+		Genres:         []string{" Crime ", "Drama", "crime"},
+		Director:       " Michael Mann ",
+		Synopsis:       "A group of professional bank robbers start to feel the heat from police.",
+		RuntimeMinutes: 170,
+	})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if !reflect.DeepEqual(movie.Genres, []string{"Crime", "Drama"}) || movie.Director != "Michael Mann" || movie.RuntimeMinutes != 170 {
+		t.Errorf("CreateMovie() = %+v, want genres Crime and Drama, director Michael Mann and 170 minutes", movie)
+	}
+
+	for _, input := range []domain.MovieInput{
+		{Title: "Movie", Year: "2020", Genres: []string{" "}},
+		{Title: "Movie", Year: "2020", Director: strings.Repeat("x", 201)},
+		{Title: "Movie", Year: "2020", Synopsis: strings.Repeat("x", 2001)},
+		{Title: "Movie", Year: "2020", RuntimeMinutes: -1},
+		{Title: "Movie", Year: "2020", RuntimeMinutes: 24*60 + 1},
+	} {
+		if _, err := service.CreateMovie(context.Background(), input); !errors.Is(err, domain.ErrInvalidMovieData) {
+			t.Errorf("CreateMovie(%+v) error = %v, want %v", input, err, domain.ErrInvalidMovieData)
+		}
+	}
+}
+
+func TestMovieService_ExternalIDs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	ctx := context.Background()
+
+	heat, err := service.CreateMovie(ctx, domain.MovieInput{Title: "Heat", Year: "1995", IMDbID: " TT0113277 ", TMDbID: "949"})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if heat.IMDbID != "tt0113277" || heat.TMDbID != "949" {
+		t.Errorf("CreateMovie() external IDs = %q, %q, want tt0113277 and 949", heat.IMDbID, heat.TMDbID)
+	}
+
+	for _, tt := range []struct{ source, id string }{{domain.SourceIMDb, "tt0113277"}, {domain.SourceTMDb, "949"}} {
+		movie, err := service.GetMovieByExternalID(ctx, tt.source, tt.id)
+		if err != nil || movie.ID != heat.ID {
+			t.Errorf("GetMovieByExternalID(%s, %s) = %v, %v, want movie %d", tt.source, tt.id, movie, err, heat.ID)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		id     string
+		want   error
+	}{
+		{"unknown movie", domain.SourceTMDb, "598", domain.ErrMovieNotFound},
+		{"malformed IMDb ID", domain.SourceIMDb, "0113277", domain.ErrInvalidExternalID},
+		{"malformed TMDb ID", domain.SourceTMDb, "0949", domain.ErrInvalidExternalID},
+		{"unknown source", "letterboxd", "heat", domain.ErrInvalidExternalID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GetMovieByExternalID(ctx, tt.source, tt.id); !errors.Is(err, tt.want) {
+				t.Errorf("GetMovieByExternalID() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	_, err = service.CreateMovie(ctx, domain.MovieInput{Title: "Heat", Year: "1986", TMDbID: "949"})
+	var fieldErr *domain.FieldError
+	if !errors.Is(err, domain.ErrExternalIDTaken) || !errors.As(err, &fieldErr) || fieldErr.Field != "tmdb_id" {
+		t.Errorf("CreateMovie() with a taken TMDb ID error = %v, want %v on tmdb_id", err, domain.ErrExternalIDTaken)
+	}
+	if _, _, err := service.UpsertMovie(ctx, heat.ID, domain.MovieInput{Title: "Heat", Year: "1995", IMDbID: "tt0113277"}); err != nil {
+		t.Errorf("UpsertMovie() keeping its own IMDb ID error = %v", err)
+	}
+	if _, err := service.CreateMovie(ctx, domain.MovieInput{Title: "Heat", Year: "1995", IMDbID: "nm0000199"}); !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("CreateMovie() with a malformed IMDb ID error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+}
+
 func TestMovieService_GetMovie(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	mockRepo := NewMockMovieRepository()
-	service := services.NewMovieService(mockRepo, logger)
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
 
 	// Create a test movie
 	testMovie, _ := domain.NewMovie(1, "Test Movie", "2023")
@@ -250,7 +769,7 @@ func TestMovieService_GetMovie(t *testing.T) {
 func TestMovieService_DeleteMovie(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	mockRepo := NewMockMovieRepository()
-	service := services.NewMovieService(mockRepo, logger)
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
 
 	// Create a test movie
 	testMovie, _ := domain.NewMovie(1, "Test Movie", "2023")
@@ -295,9 +814,281 @@ func TestMovieService_DeleteMovie(t *testing.T) {
 	}
 }
 
+func TestMovieService_UpsertMovie(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	movie, created, err := service.UpsertMovie(context.Background(), 42, domain.MovieInput{Title: "Synced Movie", Year: "2001"})
+	if err != nil {
+		t.Fatalf("UpsertMovie() unexpected error = %v", err)
+	}
+	if !created || movie.ID != 42 || movie.Version != 1 {
+		t.Errorf("UpsertMovie() = %+v, created = %v, want new movie 42 with version 1", movie, created)
+	}
+
+	movie, created, err = service.UpsertMovie(context.Background(), 42, domain.MovieInput{Title: "Synced Movie (Director's Cut)", Year: "2001"})
+	if err != nil {
+		t.Fatalf("UpsertMovie() unexpected error = %v", err)
+	}
+	if created || movie.Title != "Synced Movie (Director's Cut)" || movie.Version != 2 {
+		t.Errorf("UpsertMovie() = %+v, created = %v, want replaced movie with version 2", movie, created)
+	}
+
+	if _, _, err := service.UpsertMovie(context.Background(), 0, domain.MovieInput{Title: "Movie", Year: "2001"}); !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("UpsertMovie() error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+}
+
+func TestMovieService_UpdateMovie(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	mockRepo.movies[1] = &domain.Movie{ID: 1, Title: "Heat", Year: "1995", Version: 2}
+	ctx := context.Background()
+
+	movie, err := service.UpdateMovie(ctx, 1, domain.MovieInput{Title: "Heat", Year: "1995", Director: "Michael Mann"})
+	if err != nil || movie.Director != "Michael Mann" || movie.Version != 3 {
+		t.Fatalf("UpdateMovie() = %+v, %v, want the replacement at version 3", movie, err)
+	}
+	movie, err = service.UpdateMovieIfVersion(ctx, 1, domain.MovieInput{Title: "Heat", Year: "1996"}, 3)
+	if err != nil || movie.Year != "1996" || movie.Version != 4 {
+		t.Fatalf("UpdateMovieIfVersion() = %+v, %v, want the replacement at version 4", movie, err)
+	}
+	if _, err := service.UpdateMovie(ctx, 2, domain.MovieInput{Title: "Ronin", Year: "1998"}); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("UpdateMovie() of a missing movie error = %v, want %v", err, domain.ErrMovieNotFound)
+	}
+
+	tests := []struct {
+		name    string
+		id      int32
+		input   domain.MovieInput
+		version int64
+		wantErr error
+	}{
+		{"stale version", 1, domain.MovieInput{Title: "Heat", Year: "1997"}, 3, domain.ErrVersionMismatch},
+		{"invalid year", 1, domain.MovieInput{Title: "Heat", Year: "19x6"}, 4, domain.ErrInvalidMovieData},
+		{"not created", 2, domain.MovieInput{Title: "Ronin", Year: "1998"}, 1, domain.ErrMovieNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.UpdateMovieIfVersion(ctx, tt.id, tt.input, tt.version); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateMovieIfVersion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if len(mockRepo.movies) != 1 || mockRepo.movies[1].Version != 4 {
+		t.Errorf("rejected updates changed the movies to %+v", mockRepo.movies)
+	}
+}
+
+func TestMovieService_PatchMovie(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	mockRepo.movies[1] = &domain.Movie{ID: 1, Title: "Heat", Year: "1995", Version: 3, Regions: []string{"US"}, Certification: "R"}
+	ctx := context.Background()
+
+	movie, err := service.PatchMovie(ctx, 1, domain.MoviePatch{Input: domain.MovieInput{Title: "Heat (Director's Cut)", Year: "2024"}, Paths: []string{"title"}})
+	if err != nil {
+		t.Fatalf("PatchMovie() unexpected error = %v", err)
+	}
+	want := &domain.Movie{ID: 1, Title: "Heat (Director's Cut)", Year: "1995", Version: 4, Regions: []string{"US"}, Certification: "R"}
+	if !reflect.DeepEqual(movie, want) {
+		t.Errorf("PatchMovie() of the title = %+v, want %+v", movie, want)
+	}
+
+	version := int64(4)
+	movie, err = service.PatchMovie(ctx, 1, domain.MoviePatch{Input: domain.MovieInput{Year: "1996"}, Paths: []string{"year", "certification"}, ExpectedVersion: &version})
+	if err != nil {
+		t.Fatalf("PatchMovie() unexpected error = %v", err)
+	}
+	if movie.Year != "1996" || movie.Certification != "" || movie.Title != "Heat (Director's Cut)" || movie.Version != 5 {
+		t.Errorf("PatchMovie() of the year and certification = %+v, want year 1996 without certification", movie)
+	}
+
+	tests := []struct {
+		name    string
+		id      int32
+		patch   domain.MoviePatch
+		wantErr error
+	}{
+		{"stale version", 1, domain.MoviePatch{Input: domain.MovieInput{Year: "1997"}, Paths: []string{"year"}, ExpectedVersion: &version}, domain.ErrVersionMismatch},
+		{"empty mask", 1, domain.MoviePatch{Input: domain.MovieInput{Year: "1997"}}, domain.ErrInvalidMovieData},
+		{"unknown field", 1, domain.MoviePatch{Paths: []string{"budget"}}, domain.ErrInvalidMovieData},
+		{"empty title", 1, domain.MoviePatch{Paths: []string{"title"}}, domain.ErrInvalidMovieData},
+		{"invalid year", 1, domain.MoviePatch{Input: domain.MovieInput{Year: "19x6"}, Paths: []string{"year"}}, domain.ErrInvalidMovieData},
+		{"not found", 2, domain.MoviePatch{Input: domain.MovieInput{Year: "1997"}, Paths: []string{"year"}}, domain.ErrMovieNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.PatchMovie(ctx, tt.id, tt.patch); !errors.Is(err, tt.wantErr) {
+				t.Errorf("PatchMovie() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if mockRepo.movies[1].Version != 5 {
+		t.Errorf("rejected patches changed the movie to %+v", mockRepo.movies[1])
+	}
+}
+
+func TestMovieService_DeleteMovieIfVersion(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	// Create a test movie
+	testMovie, _ := domain.NewMovie(1, "Test Movie", "2023")
+	mockRepo.movies[1] = testMovie
+
+	tests := []struct {
+		name    string
+		id      int32
+		version int64
+		wantErr error
+	}{
+		{
+			name:    "stale version",
+			id:      1,
+			version: 2,
+			wantErr: domain.ErrVersionMismatch,
+		},
+		{
+			name:    "matching version",
+			id:      1,
+			version: 1,
+		},
+		{
+			name:    "non-existing movie",
+			id:      999,
+			version: 1,
+			wantErr: domain.ErrMovieNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.DeleteMovieIfVersion(context.Background(), tt.id, tt.version)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteMovieIfVersion() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
 }
+
+func TestMovieService_GetMovies_SkipCount(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	mockRepo.movies[1] = &domain.Movie{ID: 1, Title: "Movie 1", Year: "1994"}
+	mockRepo.movies[2] = &domain.Movie{ID: 2, Title: "Movie 2", Year: "1999"}
+
+	movies, total, err := service.GetMovies(context.Background(), domain.MovieFilter{})
+	if err != nil || len(movies) != 2 || total != 2 {
+		t.Errorf("GetMovies() = %d movies, total %d, %v, want 2 and 2", len(movies), total, err)
+	}
+
+	movies, total, err = service.GetMovies(context.Background(), domain.MovieFilter{SkipCount: true})
+	if err != nil || len(movies) != 2 || total != domain.UncountedTotal {
+		t.Errorf("GetMovies() with SkipCount = %d movies, total %d, %v, want 2 and an uncounted total", len(movies), total, err)
+	}
+}
+
+func TestMovieService_GetMovieFacets(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	dataset, err := fixtures.Parse([]byte(`
+movies:
+  - {id: 1, title: The Godfather, year: "1972", genres: [Crime, Drama]}
+  - {id: 2, title: Heat, year: "1995", genres: [Crime, Thriller]}
+  - {id: 3, title: Ronin, year: "1998", genres: [Thriller]}
+  - {id: 4, title: Amélie, year: "2001", genres: [Comedy]}
+`))
+	if err != nil {
+		t.Fatalf("Parse() unexpected error = %v", err)
+	}
+	if _, err := fixtures.Load(context.Background(), mockRepo, domain.DefaultCertifications(), dataset); err != nil {
+		t.Fatalf("Load() unexpected error = %v", err)
+	}
+
+	facets, err := service.GetMovieFacets(context.Background(), domain.MovieFilter{})
+	if err != nil {
+		t.Fatalf("GetMovieFacets() unexpected error = %v", err)
+	}
+
+	want := []domain.FacetBucket{{Value: "1970s", Count: 1}, {Value: "1990s", Count: 2}, {Value: "2000s", Count: 1}}
+	if facets.Total != 4 || !reflect.DeepEqual(facets.Years, want) {
+		t.Errorf("GetMovieFacets() = %+v, want years %v and total 4", facets, want)
+	}
+	wantGenres := []domain.FacetBucket{{Value: "Crime", Count: 2}, {Value: "Thriller", Count: 2}, {Value: "Comedy", Count: 1}, {Value: "Drama", Count: 1}}
+	if !reflect.DeepEqual(facets.Genres, wantGenres) {
+		t.Errorf("GetMovieFacets() genres = %+v, want %v", facets.Genres, wantGenres)
+	}
+
+	invalid := domain.MovieFilter{Expr: &domain.FilterExpr{Condition: &domain.FilterCondition{Field: "genre", Op: domain.OpEq, Value: "drama"}}}
+	if _, err := service.GetMovieFacets(context.Background(), invalid); !errors.Is(err, domain.ErrInvalidFilter) {
+		t.Errorf("GetMovieFacets() error = %v, want %v", err, domain.ErrInvalidFilter)
+	}
+}
+
+func TestArchiver_ArchiveOnce(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	mockRepo.stale = 1200
+	archiver := services.NewArchiver(mockRepo, 30*24*time.Hour, clock.NewFake(testNow), logger)
+
+	archived, err := archiver.ArchiveOnce(context.Background())
+	if err != nil {
+		t.Fatalf("ArchiveOnce() unexpected error = %v", err)
+	}
+	if archived != 1200 {
+		t.Errorf("ArchiveOnce() = %d, want 1200", archived)
+	}
+	if mockRepo.archiveCalls != 3 {
+		t.Errorf("Archive() called %d times, want 3 batches", mockRepo.archiveCalls)
+	}
+	if want := testNow.Add(-30 * 24 * time.Hour); !mockRepo.archivedTo.Equal(want) {
+		t.Errorf("Archive() of movies read before %v, want %v", mockRepo.archivedTo, want)
+	}
+}
+
+func TestMovieService_GetMovieHistory_Unavailable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	service := services.NewMovieService(NewMockMovieRepository(), domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	if _, err := service.GetMovieHistory(context.Background(), 1); !errors.Is(err, domain.ErrHistoryUnavailable) {
+		t.Errorf("GetMovieHistory() error = %v, want %v", err, domain.ErrHistoryUnavailable)
+	}
+	if _, err := service.GetMovieHistory(context.Background(), 0); !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("GetMovieHistory(0) error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+}
+
+func TestMovieService_GetMovieVersion(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mockRepo := NewMockMovieRepository()
+	mockRepo.movies[1] = &domain.Movie{ID: 1, Title: "Movie", Year: "1994", Version: 4}
+	service := services.NewMovieService(mockRepo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+
+	version, err := service.GetMovieVersion(context.Background(), 1)
+	if err != nil || version != 4 {
+		t.Errorf("GetMovieVersion() = %d, %v, want 4", version, err)
+	}
+	if _, err := service.GetMovieVersion(context.Background(), 2); !errors.Is(err, domain.ErrMovieNotFound) {
+		t.Errorf("GetMovieVersion() missing movie error = %v, want %v", err, domain.ErrMovieNotFound)
+	}
+	if _, err := service.GetMovieVersion(context.Background(), 0); !errors.Is(err, domain.ErrInvalidMovieData) {
+		t.Errorf("GetMovieVersion(0) error = %v, want %v", err, domain.ErrInvalidMovieData)
+	}
+}
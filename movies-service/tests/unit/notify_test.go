@@ -0,0 +1,147 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/notify"
+	"github.com/movie-microservice/movies-service/internal/config"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/clock"
+	"github.com/movie-microservice/proto/retry"
+)
+
+// webhookRecorder collects the JSON payloads posted to a test webhook
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []map[string]any
+}
+
+func (w *webhookRecorder) serve(t *testing.T, status int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("webhook received invalid JSON: %v", err)
+		}
+		w.mu.Lock()
+		w.payloads = append(w.payloads, payload)
+		w.mu.Unlock()
+		rw.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNotifyingMovieService(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var slack, discord webhookRecorder
+	slackServer, discordServer := slack.serve(t, http.StatusOK), discord.serve(t, http.StatusNoContent)
+
+	repo := NewMockMovieRepository()
+	pool := workerpool.New("notifications", workerpool.Options{Workers: 2, QueueSize: 10}, logger)
+	service := services.NewNotifyingMovieService(
+		services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger),
+		[]services.NotificationChannel{
+			{Notifier: notify.NewSlack(slackServer.URL, time.Second), Events: []domain.MovieEventType{domain.MovieCreated}},
+			{Notifier: notify.NewDiscord(discordServer.URL, time.Second), Events: []domain.MovieEventType{domain.MovieCreated, domain.MovieDeleted}},
+		},
+		time.Second, pool, clock.NewFake(testNow), logger)
+	ctx := context.Background()
+
+	movie, err := service.CreateMovie(ctx, domain.MovieInput{Title: "Heat <1995>", Year: "1995", Certification: "R", Awards: []string{"Saturn Award"}})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if _, _, err := service.UpsertMovie(ctx, movie.ID, domain.MovieInput{Title: "Heat", Year: "1995"}); err != nil {
+		t.Fatalf("UpsertMovie() unexpected error = %v", err)
+	}
+	if err := service.DeleteMovie(ctx, movie.ID); err != nil {
+		t.Fatalf("DeleteMovie() unexpected error = %v", err)
+	}
+	if err := service.DeleteMovie(ctx, movie.ID); err == nil {
+		t.Fatal("DeleteMovie() of a deleted movie succeeded, want an error")
+	}
+	pool.Close()
+
+	if len(slack.payloads) != 1 {
+		t.Fatalf("Slack received %d messages, want only the creation", len(slack.payloads))
+	}
+	if text := slack.payloads[0]["text"]; text != "New movie: Heat &lt;1995&gt;" {
+		t.Errorf("Slack text = %q, want the escaped title", text)
+	}
+
+	if len(discord.payloads) != 2 {
+		t.Fatalf("Discord received %d messages, want the creation and the deletion", len(discord.payloads))
+	}
+	// Channels are notified concurrently, so messages may arrive in any order
+	all, _ := json.Marshal(discord.payloads)
+	if !strings.Contains(string(all), `"description":"Movie removed","fields":[{"inline":true,"name":"Year","value":"1995"}],"footer":{"text":"Movie #1"}`) {
+		t.Errorf("Discord embeds = %s, want the removed movie described", all)
+	}
+}
+
+func TestNotifyingMovieService_FailingChannel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var slack webhookRecorder
+	server := slack.serve(t, http.StatusInternalServerError)
+
+	pool := workerpool.New("notifications", workerpool.Options{Workers: 1, QueueSize: 10, Retry: retry.Policy{MaxAttempts: 2, BaseDelay: time.Millisecond}}, logger)
+	service := services.NewNotifyingMovieService(
+		services.NewMovieService(NewMockMovieRepository(), domain.DefaultPagination(), domain.DefaultCertifications(), logger),
+		[]services.NotificationChannel{{Notifier: notify.NewSlack(server.URL, time.Second), Events: []domain.MovieEventType{domain.MovieCreated}}},
+		time.Second, pool, clock.NewFake(testNow), logger)
+
+	if _, err := service.CreateMovie(context.Background(), domain.MovieInput{Title: "Heat", Year: "1995"}); err != nil {
+		t.Errorf("CreateMovie() with a failing webhook error = %v, want the movie created", err)
+	}
+	// Closing the pool stops retries, so the delivery is awaited first
+	deadline := time.Now().Add(5 * time.Second)
+	for pool.Stats().Failed == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Close()
+	if len(slack.payloads) != 2 {
+		t.Errorf("Slack received %d messages, want the message and its retry", len(slack.payloads))
+	}
+	if stats := pool.Stats(); stats.Failed != 1 || stats.Retried != 1 {
+		t.Errorf("pool stats = %+v, want 1 failed task retried once", stats)
+	}
+}
+
+func TestLoad_Notifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "disabled", env: map[string]string{}},
+		{name: "slack", env: map[string]string{"SLACK_WEBHOOK_URL": "https://hooks.slack.com/services/T/B/X"}},
+		{name: "discord events", env: map[string]string{"DISCORD_WEBHOOK_URL": "https://discord.com/api/webhooks/1/x", "DISCORD_NOTIFY_EVENTS": "created, deleted"}},
+		{name: "relative URL", env: map[string]string{"SLACK_WEBHOOK_URL": "hooks.slack.com/services"}, wantErr: true},
+		{name: "unknown event", env: map[string]string{"SLACK_WEBHOOK_URL": "https://hooks.slack.com/x", "SLACK_NOTIFY_EVENTS": "created,updated"}, wantErr: true},
+		{name: "no events", env: map[string]string{"DISCORD_WEBHOOK_URL": "https://discord.com/x", "DISCORD_NOTIFY_EVENTS": " , "}, wantErr: true},
+		{name: "no workers", env: map[string]string{"SLACK_WEBHOOK_URL": "https://hooks.slack.com/x", "NOTIFY_WORKERS": "0"}, wantErr: true},
+		{name: "no attempts", env: map[string]string{"SLACK_WEBHOOK_URL": "https://hooks.slack.com/x", "NOTIFY_ATTEMPTS": "0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if err := config.Load().Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+)
+
+func TestPayloadLoggingInterceptor(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	interceptor := grpcAdapter.PayloadLoggingInterceptor(grpcAdapter.PayloadLogging{RedactFields: []string{"Title"}}, logger)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/CreateMovie"}
+	req := &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: "Secret Project", Year: "2030"}}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &pb.CreateMovieResponse{Movie: &pb.Movie{Id: 7, Title: "Secret Project", Year: "2030"}}, nil
+	}
+
+	if _, err := interceptor(context.Background(), req, info, handler); err != nil {
+		t.Fatalf("interceptor returned error = %v", err)
+	}
+
+	output := logs.String()
+	if strings.Contains(output, "Secret Project") {
+		t.Errorf("logged payload leaks a redacted field: %s", output)
+	}
+	if !strings.Contains(output, "2030") || !strings.Contains(output, "[REDACTED]") {
+		t.Errorf("logged payload = %s, want the year and the redacted title", output)
+	}
+	if !strings.Contains(output, "gRPC response payload") || !strings.Contains(output, `\"id\":7`) {
+		t.Errorf("response payload not logged: %s", output)
+	}
+}
+
+func TestPayloadLoggingInterceptor_Truncation(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	interceptor := grpcAdapter.PayloadLoggingInterceptor(grpcAdapter.PayloadLogging{MaxBytes: 16}, logger)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/CreateMovie"}
+	req := &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: strings.Repeat("long title ", 20), Year: "2030"}}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	interceptor(context.Background(), req, info, handler)
+
+	if !strings.Contains(logs.String(), "...(truncated)") || strings.Contains(logs.String(), strings.Repeat("long title ", 2)) {
+		t.Errorf("payload not truncated: %s", logs.String())
+	}
+}
+
+func TestPayloadLoggingInterceptor_InfoLevel(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	interceptor := grpcAdapter.PayloadLoggingInterceptor(grpcAdapter.PayloadLogging{}, logger)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/GetMovie"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return &pb.GetMovieResponse{}, nil }
+	interceptor(context.Background(), &pb.GetMovieRequest{Id: 1}, info, handler)
+
+	if logs.Len() != 0 {
+		t.Errorf("payloads logged above debug level: %s", logs.String())
+	}
+}
@@ -0,0 +1,68 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	clk := clock.NewFake(testNow)
+	limiter := grpcAdapter.NewRateLimiter(1, 2, clk)
+
+	if wait := limiter.Take("10.0.0.1"); wait != 0 {
+		t.Errorf("Take() first call wait = %v, want 0", wait)
+	}
+	if wait := limiter.Take("10.0.0.1"); wait != 0 {
+		t.Errorf("Take() within the burst wait = %v, want 0", wait)
+	}
+	if wait := limiter.Take("10.0.0.1"); wait != time.Second {
+		t.Errorf("Take() past the burst wait = %v, want 1s until the next token", wait)
+	}
+	clk.Advance(time.Second)
+	if wait := limiter.Take("10.0.0.1"); wait != 0 {
+		t.Errorf("Take() once a token was added wait = %v, want 0", wait)
+	}
+	if wait := limiter.Take("10.0.0.2"); wait != 0 {
+		t.Errorf("Take() for another peer wait = %v, want 0", wait)
+	}
+}
+
+func TestRateLimiter_UnaryInterceptor(t *testing.T) {
+	interceptor := grpcAdapter.NewRateLimiter(1, 1, clock.System{}).UnaryInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40000}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/GetMovies"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	if _, err := interceptor(ctx, nil, info, handler); err != nil {
+		t.Fatalf("first call unexpected error = %v", err)
+	}
+
+	// Another connection from the same host shares the bucket
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 40001}})
+	_, err := interceptor(ctx, nil, info, handler)
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("second call error = %v, want ResourceExhausted", err)
+	}
+
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() <= 0 {
+		t.Errorf("details = %v, want a RetryInfo with a positive delay", st.Details())
+	}
+}
@@ -0,0 +1,16 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/movie-microservice/movies-service/internal/core/ports"
+	"github.com/movie-microservice/movies-service/internal/core/ports/repotest"
+)
+
+// TestMockMovieRepository_Conformance runs the shared repository suite against the mock
+// the services are tested with, so it cannot drift from the adapters either
+func TestMockMovieRepository_Conformance(t *testing.T) {
+	repotest.RepositoryTestSuite(t, func(t *testing.T) ports.MovieRepository {
+		return &lockedMovieRepository{MockMovieRepository: NewMockMovieRepository()}
+	})
+}
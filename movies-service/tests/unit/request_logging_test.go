@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/logging"
+)
+
+func TestLoggingPolicy_Decide(t *testing.T) {
+	never := logging.Policy{SampleRate: 0, SlowThreshold: time.Second}
+	tests := []struct {
+		name     string
+		read     bool
+		failed   bool
+		duration time.Duration
+		want     bool
+	}{
+		{"successful read", true, false, time.Millisecond, false},
+		{"failed read", true, true, time.Millisecond, true},
+		{"slow read", true, false, 2 * time.Second, true},
+		{"write", false, false, time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		if got := never.Decide(tt.read, tt.failed, tt.duration); got.Log != tt.want || got.Sampled {
+			t.Errorf("%s: Decide() = %+v, want Log %v", tt.name, got, tt.want)
+		}
+	}
+
+	always := logging.Policy{SampleRate: 1}
+	if got := always.Decide(true, false, time.Millisecond); !got.Log || got.Sampled {
+		t.Errorf("Decide() with sample rate 1 = %+v, want logged unsampled", got)
+	}
+
+	half := logging.Policy{SampleRate: 0.5}
+	logged := 0
+	for i := 0; i < 1000; i++ {
+		if got := half.Decide(true, false, time.Millisecond); got.Log {
+			if !got.Sampled {
+				t.Fatalf("Decide() with sample rate 0.5 = %+v, want sampled", got)
+			}
+			logged++
+		}
+	}
+	if logged < 400 || logged > 600 {
+		t.Errorf("sample rate 0.5 logged %d of 1000 reads", logged)
+	}
+}
+
+func TestRequestLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	interceptor := grpcAdapter.RequestLoggingInterceptor(logging.Policy{SampleRate: 0}, logger)
+
+	call := func(method string, err error) map[string]interface{} {
+		buf.Reset()
+		interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+		if buf.Len() == 0 {
+			return nil
+		}
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("log line is not JSON: %v", err)
+		}
+		return line
+	}
+
+	if line := call("/movies.v2.MovieService/GetMovie", nil); line != nil {
+		t.Errorf("successful read logged with sample rate 0: %v", line)
+	}
+	if line := call("/movies.v2.MovieService/CreateMovie", nil); line == nil || line["level"] != "INFO" {
+		t.Errorf("write log line = %v, want an INFO line", line)
+	}
+	if line := call("/movies.v2.MovieService/GetMovie", status.Error(codes.NotFound, "movie not found")); line == nil || line["level"] != "WARN" || line["code"] != "NotFound" {
+		t.Errorf("not found log line = %v, want a WARN line", line)
+	}
+	if line := call("/movies.v2.MovieService/GetMovies", status.Error(codes.Unavailable, "storage unavailable")); line == nil || line["level"] != "ERROR" {
+		t.Errorf("unavailable log line = %v, want an ERROR line", line)
+	}
+}
+
+func TestRequestLoggingInterceptor_RequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	interceptor := grpcAdapter.RequestLoggingInterceptor(logging.Policy{SampleRate: 0}, logger)
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcAdapter.TraceParentHeader, "00-"+traceID+"-00f067aa0ba902b7-01"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/movies.v2.MovieService/GetMovie"}
+	interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		logging.FromContext(ctx, nil).Info("Movie found", "id", 1)
+		return nil, nil
+	})
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if line["msg"] != "Movie found" || line["method"] != info.FullMethod || line["trace_id"] != traceID {
+		t.Errorf("log line of the handler = %v, want the method and trace ID of the call", line)
+	}
+
+	buf.Reset()
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcAdapter.B3Header, "a3ce929d0e0e4736-00f067aa0ba902b7-1"))
+	interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		logging.FromContext(ctx, nil).Info("Movie found", "id", 1)
+		return nil, nil
+	})
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if line["trace_id"] != "0000000000000000a3ce929d0e0e4736" {
+		t.Errorf("trace_id of a b3 call = %v, want the widened B3 trace ID", line["trace_id"])
+	}
+
+	if got := logging.FromContext(context.Background(), logger); got != logger {
+		t.Error("FromContext() outside of a call did not return the fallback logger")
+	}
+}
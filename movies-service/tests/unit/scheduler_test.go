@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/lock"
+	"github.com/movie-microservice/movies-service/internal/scheduler"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestParse_Next(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 17, 30, 0, time.UTC) // a Wednesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, time.February, 1, 3, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * 1-5", time.Date(2024, time.January, 31, 13, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, time.February, 4, 12, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := scheduler.Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse() unexpected error = %v", err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every soon", "@every 10ms"} {
+		if _, err := scheduler.Parse(spec); err == nil {
+			t.Errorf("Parse(%q) expected error but got none", spec)
+		}
+	}
+}
+
+func TestScheduler_OnlyLeaderRunsJobs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	locker := lock.NewMemory(clock.System{})
+
+	var runs [2]int32
+	schedulers := make([]*scheduler.Scheduler, 2)
+	for i := range schedulers {
+		i := i
+		schedulers[i] = scheduler.New(locker, []string{"a", "b"}[i], time.Minute, logger)
+		if err := schedulers[i].Add("count", "@every 1s", func(ctx context.Context) error {
+			atomic.AddInt32(&runs[i], 1)
+			return nil
+		}); err != nil {
+			t.Fatalf("Add() unexpected error = %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, s := range schedulers {
+		wg.Add(1)
+		go func(s *scheduler.Scheduler) {
+			defer wg.Done()
+			s.Run(ctx)
+		}(s)
+	}
+	wg.Wait()
+
+	leaders := 0
+	for i, s := range schedulers {
+		if s.IsLeader() {
+			leaders++
+		} else if runs[i] != 0 {
+			t.Errorf("follower %d ran the job %d times", i, runs[i])
+		}
+	}
+	if leaders != 1 {
+		t.Errorf("leaders = %d, want 1", leaders)
+	}
+	if runs[0]+runs[1] == 0 {
+		t.Errorf("job never ran")
+	}
+}
@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	pbv1 "github.com/movie-microservice/proto/movies/v1"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+)
+
+func TestMovieServerV1(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := services.NewMovieService(NewMockMovieRepository(), domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	server := grpcAdapter.NewMovieServerV1(grpcAdapter.NewMovieServer(service, logger))
+	ctx := context.Background()
+
+	created, err := server.CreateMovie(ctx, &pbv1.CreateMovieRequest{Title: "Central Station", Year: "1998", Regions: []string{"br"}})
+	if err != nil {
+		t.Fatalf("CreateMovie() unexpected error = %v", err)
+	}
+	if !created.Success || created.Movie.Title != "Central Station" || len(created.Movie.Regions) != 1 || created.Movie.Regions[0] != "BR" {
+		t.Errorf("CreateMovie() = %v, want the created movie with success set", created)
+	}
+
+	got, err := server.GetMovie(ctx, &pbv1.GetMovieRequest{Id: created.Movie.Id})
+	if err != nil || !got.Success || got.Movie.Year != "1998" || got.FromArchive {
+		t.Errorf("GetMovie() = %v, %v, want the movie from the live collection", got, err)
+	}
+
+	listed, err := server.GetMovies(ctx, &pbv1.GetMoviesRequest{
+		Page:  1,
+		Limit: 10,
+		Filter: &pbv1.Filter{Node: &pbv1.Filter_Condition{Condition: &pbv1.FilterCondition{
+			Field:    "year",
+			Operator: pbv1.FilterOperator_FILTER_OPERATOR_GTE,
+			Value:    "1990",
+		}}},
+	})
+	if err != nil || !listed.Success || listed.Total != 1 {
+		t.Errorf("GetMovies() = %v, %v, want one movie", listed, err)
+	}
+
+	// Failures are reported in the response, as v1 did before v2 used statuses for them
+	missing, err := server.GetMovie(ctx, &pbv1.GetMovieRequest{Id: 99})
+	if err != nil || missing.Success || missing.Error != "movie not found" {
+		t.Errorf("GetMovie() missing movie = %v, %v, want success false and %q", missing, err, "movie not found")
+	}
+	invalid, err := server.CreateMovie(ctx, &pbv1.CreateMovieRequest{Title: "Bad Year", Year: "99"})
+	if err != nil || invalid.Success || invalid.Error == "" {
+		t.Errorf("CreateMovie() invalid movie = %v, %v, want success false with an error", invalid, err)
+	}
+	stale, err := server.DeleteMovie(ctx, &pbv1.DeleteMovieRequest{Id: created.Movie.Id, ExpectedVersion: proto.Int64(5)})
+	if err != nil || stale.Success || stale.Error == "" {
+		t.Errorf("DeleteMovie() stale version = %v, %v, want success false with an error", stale, err)
+	}
+
+	deleted, err := server.DeleteMovie(ctx, &pbv1.DeleteMovieRequest{Id: created.Movie.Id})
+	if err != nil || !deleted.Success {
+		t.Errorf("DeleteMovie() = %v, %v, want success", deleted, err)
+	}
+}
+
+func TestMovieServerV1_UpsertKeepsV2Fields(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := NewMockMovieRepository()
+	service := services.NewMovieService(repo, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	server := grpcAdapter.NewMovieServerV1(grpcAdapter.NewMovieServer(service, logger))
+	ctx := context.Background()
+
+	repo.movies[7] = &domain.Movie{
+		ID: 7, Title: "Heat", Year: "1995", Version: 1,
+		Genres: []string{"Crime", "Drama"}, Director: "Michael Mann", Synopsis: "A heist goes wrong.",
+		RuntimeMinutes: 170, IMDbID: "tt0113277", TMDbID: "949",
+	}
+	replaced, err := server.UpsertMovie(ctx, &pbv1.UpsertMovieRequest{Id: 7, Title: "Heat (Remastered)", Year: "1995", Regions: []string{"US"}})
+	if err != nil || !replaced.Success || replaced.Created || replaced.Movie.Title != "Heat (Remastered)" {
+		t.Fatalf("UpsertMovie() = %v, %v, want the movie replaced", replaced, err)
+	}
+	want := &domain.Movie{
+		ID: 7, Title: "Heat (Remastered)", Year: "1995", Version: 2, Regions: []string{"US"},
+		Genres: []string{"Crime", "Drama"}, Director: "Michael Mann", Synopsis: "A heist goes wrong.",
+		RuntimeMinutes: 170, IMDbID: "tt0113277", TMDbID: "949",
+	}
+	if got := repo.movies[7]; !reflect.DeepEqual(got, want) {
+		t.Errorf("movie after a v1 upsert = %+v, want the v2 fields kept: %+v", got, want)
+	}
+
+	// Movies that do not exist yet are created
+	created, err := server.UpsertMovie(ctx, &pbv1.UpsertMovieRequest{Id: 8, Title: "Ronin", Year: "1998"})
+	if err != nil || !created.Success || !created.Created || created.Movie.Id != 8 {
+		t.Errorf("UpsertMovie() of a new movie = %v, %v, want it created", created, err)
+	}
+	missing, err := server.UpsertMovie(ctx, &pbv1.UpsertMovieRequest{Id: 9, Year: "1998"})
+	if err != nil || missing.Success || missing.Error == "" {
+		t.Errorf("UpsertMovie() without a title = %v, %v, want success false with an error", missing, err)
+	}
+}
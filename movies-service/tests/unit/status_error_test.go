@@ -0,0 +1,217 @@
+package unit
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/movie-microservice/proto/movies/v2"
+
+	grpcAdapter "github.com/movie-microservice/movies-service/internal/adapters/grpc"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
+)
+
+func TestMovieServer_ErrorDetails(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	service := services.NewMovieService(NewMockMovieRepository(), domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	server := grpcAdapter.NewMovieServer(service, logger)
+	_, commentService := newCommentService(false)
+	commentServer := grpcAdapter.NewCommentServer(commentService, logger)
+	importServer, _, jobs := newImportServer()
+	defer jobs.stop()
+	jobServer := grpcAdapter.NewJobServer(jobs, logger)
+	syncServer := grpcAdapter.NewSyncServer(services.NewCatalogSync(nil, service, NewMockSyncRepository(), domain.SyncManual, domain.DefaultPagination(), clock.System{}, logger), logger)
+
+	tests := []struct {
+		name       string
+		call       func() error
+		wantCode   codes.Code
+		wantReason string
+		wantFields []string
+	}{
+		{
+			name: "invalid year",
+			call: func() error {
+				_, err := server.CreateMovie(context.Background(), &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: "Movie", Year: "19x0"}})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_YEAR",
+			wantFields: []string{"year"},
+		},
+		{
+			name: "invalid region",
+			call: func() error {
+				_, err := server.CreateMovie(context.Background(), &pb.CreateMovieRequest{Movie: &pb.MovieInput{Title: "Movie", Year: "1999", Regions: []string{"USA"}}})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_REGION",
+			wantFields: []string{"regions"},
+		},
+		{
+			name: "missing fields",
+			call: func() error {
+				_, err := server.UpsertMovie(context.Background(), &pb.UpsertMovieRequest{Id: 1})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_ARGUMENT",
+			wantFields: []string{"title", "year"},
+		},
+		{
+			name: "not found",
+			call: func() error {
+				_, err := server.GetMovie(context.Background(), &pb.GetMovieRequest{Id: 42})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "MOVIE_NOT_FOUND",
+		},
+		{
+			name: "update of a missing movie",
+			call: func() error {
+				_, err := server.UpdateMovie(context.Background(), &pb.UpdateMovieRequest{Id: 42, Movie: &pb.MovieInput{Title: "Heat", Year: "1995"}})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "MOVIE_NOT_FOUND",
+		},
+		{
+			name: "invalid external ID",
+			call: func() error {
+				_, err := server.GetMovieByExternalId(context.Background(), &pb.GetMovieByExternalIdRequest{Source: "imdb", ExternalId: "113277"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_EXTERNAL_ID",
+			wantFields: []string{"external_id"},
+		},
+		{
+			name: "missing external ID",
+			call: func() error {
+				_, err := server.GetMovieByExternalId(context.Background(), &pb.GetMovieByExternalIdRequest{Source: "tmdb"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_ARGUMENT",
+			wantFields: []string{"external_id"},
+		},
+		{
+			name: "invalid comment",
+			call: func() error {
+				_, err := commentServer.CreateComment(context.Background(), &pb.CreateCommentRequest{MovieId: 1, Author: "Ana"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_COMMENT",
+			wantFields: []string{"body"},
+		},
+		{
+			name: "comment not found",
+			call: func() error {
+				_, err := commentServer.FlagComment(context.Background(), &pb.FlagCommentRequest{Id: "missing", Reason: "spam"})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "COMMENT_NOT_FOUND",
+		},
+		{
+			name: "invalid resolution",
+			call: func() error {
+				_, err := syncServer.ResolveSyncConflict(context.Background(), &pb.ResolveSyncConflictRequest{Id: "conflict-1", Resolution: "merge"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_RESOLUTION",
+			wantFields: []string{"resolution"},
+		},
+		{
+			name: "sync conflict not found",
+			call: func() error {
+				_, err := syncServer.ResolveSyncConflict(context.Background(), &pb.ResolveSyncConflictRequest{Id: "conflict-1", Resolution: "keep_local"})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "SYNC_CONFLICT_NOT_FOUND",
+		},
+		{
+			name: "empty import",
+			call: func() error {
+				_, err := importServer.StartImport(context.Background(), &pb.StartImportRequest{})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_IMPORT",
+			wantFields: []string{"rows"},
+		},
+		{
+			name: "import not found",
+			call: func() error {
+				_, err := importServer.GetImport(context.Background(), &pb.GetImportRequest{Id: "job-42"})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "IMPORT_NOT_FOUND",
+		},
+		{
+			name: "unknown job type",
+			call: func() error {
+				_, err := jobServer.SubmitJob(context.Background(), &pb.SubmitJobRequest{Type: "enrich"})
+				return err
+			},
+			wantCode:   codes.InvalidArgument,
+			wantReason: "INVALID_JOB",
+			wantFields: []string{"type"},
+		},
+		{
+			name: "job not found",
+			call: func() error {
+				_, err := jobServer.GetJob(context.Background(), &pb.GetJobRequest{Id: "job-42"})
+				return err
+			},
+			wantCode:   codes.NotFound,
+			wantReason: "JOB_NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != tt.wantCode {
+				t.Fatalf("code = %s, want %s", st.Code(), tt.wantCode)
+			}
+
+			var reason string
+			var fields []string
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					reason = d.Reason
+				case *errdetails.BadRequest:
+					for _, violation := range d.FieldViolations {
+						fields = append(fields, violation.Field)
+					}
+				}
+			}
+			if reason != tt.wantReason {
+				t.Errorf("ErrorInfo reason = %q, want %q", reason, tt.wantReason)
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Fatalf("field violations = %v, want %v", fields, tt.wantFields)
+			}
+			for i := range fields {
+				if fields[i] != tt.wantFields[i] {
+					t.Errorf("field violations = %v, want %v", fields, tt.wantFields)
+				}
+			}
+		})
+	}
+}
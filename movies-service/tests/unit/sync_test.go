@@ -0,0 +1,325 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/adapters/feed"
+	"github.com/movie-microservice/movies-service/internal/core/domain"
+	"github.com/movie-microservice/movies-service/internal/core/services"
+	"github.com/movie-microservice/proto/clock"
+)
+
+// titleYearRepository answers the title and year lookups the sync makes for movies it
+// has not linked yet
+type titleYearRepository struct {
+	*MockMovieRepository
+}
+
+func (r titleYearRepository) FindAll(ctx context.Context, filter domain.MovieFilter) ([]*domain.Movie, error) {
+	want := map[string]string{}
+	for _, expr := range filter.Expr.And {
+		want[expr.Condition.Field] = expr.Condition.Value
+	}
+	for _, movie := range r.movies {
+		if movie.Title == want["title"] && movie.Year == want["year"] {
+			return []*domain.Movie{movie.Copy()}, nil
+		}
+	}
+	return nil, nil
+}
+
+// MockSyncRepository keeps sync links and conflicts in memory
+type MockSyncRepository struct {
+	links     map[string]domain.SyncLink
+	conflicts []*domain.SyncConflict
+	nextID    int
+}
+
+func NewMockSyncRepository() *MockSyncRepository {
+	return &MockSyncRepository{links: make(map[string]domain.SyncLink)}
+}
+
+func (m *MockSyncRepository) FindLink(ctx context.Context, source, externalID string) (*domain.SyncLink, error) {
+	if link, ok := m.links[source+"/"+externalID]; ok {
+		return &link, nil
+	}
+	return nil, nil
+}
+
+func (m *MockSyncRepository) SaveLink(ctx context.Context, link domain.SyncLink) error {
+	m.links[link.Source+"/"+link.ExternalID] = link
+	return nil
+}
+
+func (m *MockSyncRepository) SaveConflict(ctx context.Context, conflict domain.SyncConflict) error {
+	for _, queued := range m.conflicts {
+		if queued.Remote.Source == conflict.Remote.Source && queued.Remote.ExternalID == conflict.Remote.ExternalID {
+			queued.Remote, queued.DetectedAt = conflict.Remote, conflict.DetectedAt
+			return nil
+		}
+	}
+	m.nextID++
+	conflict.ID = fmt.Sprintf("conflict-%d", m.nextID)
+	m.conflicts = append(m.conflicts, &conflict)
+	return nil
+}
+
+func (m *MockSyncRepository) ListConflicts(ctx context.Context, page, limit int32) ([]*domain.SyncConflict, int32, error) {
+	return m.conflicts, int32(len(m.conflicts)), nil
+}
+
+func (m *MockSyncRepository) FindConflict(ctx context.Context, id string) (*domain.SyncConflict, error) {
+	for _, conflict := range m.conflicts {
+		if conflict.ID == id {
+			return conflict, nil
+		}
+	}
+	return nil, domain.ErrSyncConflictNotFound
+}
+
+func (m *MockSyncRepository) DeleteConflict(ctx context.Context, id string) error {
+	for i, conflict := range m.conflicts {
+		if conflict.ID == id {
+			m.conflicts = append(m.conflicts[:i], m.conflicts[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrSyncConflictNotFound
+}
+
+// csvFeed serves the current content of a CSV catalog
+func csvFeed(t *testing.T, content *string) *feed.CSV {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, *content)
+	}))
+	t.Cleanup(server.Close)
+	return feed.NewCSV(server.URL, time.Second)
+}
+
+func newCatalogSync(t *testing.T, content *string, policy domain.SyncPolicy) (*services.CatalogSync, *MockMovieRepository, *MockSyncRepository) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	movies := NewMockMovieRepository()
+	movies.movies[1] = &domain.Movie{ID: 1, Title: "Heat", Year: "1995", Version: 1, Awards: []string{"Saturn Award"}}
+	movies.nextID = 2
+	repo := NewMockSyncRepository()
+	service := services.NewMovieService(titleYearRepository{movies}, domain.DefaultPagination(), domain.DefaultCertifications(), logger)
+	return services.NewCatalogSync(csvFeed(t, content), service, repo, policy, domain.DefaultPagination(), clock.NewFake(testNow), logger), movies, repo
+}
+
+func TestCatalogSync_SyncOnce(t *testing.T) {
+	content := "id,title,year,certification\n" +
+		"tt1,Heat,1995,\n" +
+		"tt2,Central Station,1998,PG\n" +
+		"tt3,Undated,19x5,\n"
+	sync, movies, _ := newCatalogSync(t, &content, domain.SyncManual)
+	ctx := context.Background()
+
+	report, err := sync.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("SyncOnce() unexpected error = %v", err)
+	}
+	want := domain.SyncReport{Fetched: 3, Created: 1, Unchanged: 1, Failed: 1}
+	if report != want {
+		t.Errorf("SyncOnce() = %+v, want %+v", report, want)
+	}
+	if movies.movies[2] == nil || movies.movies[2].Title != "Central Station" || movies.movies[2].Certification != "PG" {
+		t.Fatalf("SyncOnce() stored %+v, want Central Station created", movies.movies[2])
+	}
+
+	// Only the feed changed Central Station, so it is updated; Heat changed nowhere
+	content = "id,title,year,certification\ntt1,Heat,1995,\ntt2,Central do Brasil,1998,PG\n"
+	report, err = sync.SyncOnce(ctx)
+	if err != nil || report.Updated != 1 || report.Unchanged != 1 {
+		t.Fatalf("SyncOnce() after a remote change = %+v, %v, want 1 updated and 1 unchanged", report, err)
+	}
+	if movies.movies[2].Title != "Central do Brasil" {
+		t.Errorf("Central Station title = %q, want the remote title", movies.movies[2].Title)
+	}
+	if awards := movies.movies[1].Awards; len(awards) != 1 {
+		t.Errorf("Heat awards = %v, want the local awards kept", awards)
+	}
+}
+
+func TestCatalogSync_Conflicts(t *testing.T) {
+	content := "id,title,year\ntt1,Heat,1995\n"
+	sync, movies, repo := newCatalogSync(t, &content, domain.SyncManual)
+	ctx := context.Background()
+	if _, err := sync.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce() unexpected error = %v", err)
+	}
+
+	// Both sides change Heat
+	movies.movies[1].Certification, movies.movies[1].Version = "PG-13", 2
+	content = "id,title,year,certification\ntt1,Heat,1995,R\n"
+	report, err := sync.SyncOnce(ctx)
+	if err != nil || report.Queued != 1 {
+		t.Fatalf("SyncOnce() of a conflict = %+v, %v, want it queued", report, err)
+	}
+	if report, _ := sync.SyncOnce(ctx); report.Queued != 1 || len(repo.conflicts) != 1 {
+		t.Errorf("SyncOnce() again queued %d conflicts, want the queued one replaced", len(repo.conflicts))
+	}
+
+	conflicts, total, err := sync.ListSyncConflicts(ctx, 1, 10)
+	if err != nil || total != 1 || conflicts[0].MovieID != 1 || conflicts[0].Remote.Certification != "R" {
+		t.Fatalf("ListSyncConflicts() = %v, %d, %v, want the Heat conflict", conflicts, total, err)
+	}
+	if _, err := sync.ResolveSyncConflict(ctx, conflicts[0].ID, "overwrite"); !errors.Is(err, domain.ErrInvalidResolution) {
+		t.Errorf("ResolveSyncConflict() with unknown resolution error = %v, want ErrInvalidResolution", err)
+	}
+	movie, err := sync.ResolveSyncConflict(ctx, conflicts[0].ID, domain.ResolutionTakeRemote)
+	if err != nil || movie.Certification != "R" {
+		t.Fatalf("ResolveSyncConflict() = %+v, %v, want the remote certification", movie, err)
+	}
+	if _, err := sync.ResolveSyncConflict(ctx, conflicts[0].ID, domain.ResolutionTakeRemote); !errors.Is(err, domain.ErrSyncConflictNotFound) {
+		t.Errorf("ResolveSyncConflict() twice error = %v, want ErrSyncConflictNotFound", err)
+	}
+	if report, _ := sync.SyncOnce(ctx); report.Unchanged != 1 {
+		t.Errorf("SyncOnce() after resolving = %+v, want Heat unchanged", report)
+	}
+}
+
+func TestCatalogSync_Policies(t *testing.T) {
+	tests := []struct {
+		policy            domain.SyncPolicy
+		wantCertification string
+		wantReport        domain.SyncReport
+	}{
+		{domain.SyncLocalWins, "PG-13", domain.SyncReport{Fetched: 1, Kept: 1}},
+		{domain.SyncRemoteWins, "R", domain.SyncReport{Fetched: 1, Updated: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			// Heat has a local certification the first sync cannot attribute to either side
+			content := "id,title,year,certification\ntt1,Heat,1995,R\n"
+			sync, movies, _ := newCatalogSync(t, &content, tt.policy)
+			movies.movies[1].Certification = "PG-13"
+
+			report, err := sync.SyncOnce(context.Background())
+			if err != nil || report != tt.wantReport {
+				t.Fatalf("SyncOnce() = %+v, %v, want %+v", report, err, tt.wantReport)
+			}
+			if got := movies.movies[1].Certification; got != tt.wantCertification {
+				t.Errorf("certification = %q, want %q", got, tt.wantCertification)
+			}
+			if report, _ := sync.SyncOnce(context.Background()); report.Unchanged != 1 {
+				t.Errorf("SyncOnce() again = %+v, want the decision kept", report)
+			}
+		})
+	}
+}
+
+func TestCatalogSync_ExternalIDs(t *testing.T) {
+	content := "id,title,year,tmdb_id\n" +
+		"x1,Heat,1995,949\n" +
+		"x2,Heat,1995,12345\n"
+	sync, movies, repo := newCatalogSync(t, &content, domain.SyncRemoteWins)
+	movies.movies[1].Title, movies.movies[1].TMDbID = "Fogo Contra Fogo", "949"
+
+	report, err := sync.SyncOnce(context.Background())
+	want := domain.SyncReport{Fetched: 2, Created: 1, Updated: 1}
+	if err != nil || report != want {
+		t.Fatalf("SyncOnce() = %+v, %v, want %+v", report, err, want)
+	}
+	// The first movie is found by its TMDb ID despite its local title
+	if link := repo.links["csv/x1"]; link.MovieID != 1 || movies.movies[1].Title != "Heat" {
+		t.Errorf("x1 linked to movie %d titled %q, want movie 1 retitled Heat", link.MovieID, movies.movies[1].Title)
+	}
+	// The second one shares its title and year but not its TMDb ID, so it is another movie
+	if link := repo.links["csv/x2"]; link.MovieID != 2 || movies.movies[2].TMDbID != "12345" {
+		t.Errorf("x2 linked to movie %d, stored %+v, want movie 2 created with its TMDb ID", link.MovieID, movies.movies[2])
+	}
+}
+
+func TestTMDbFeed_Fetch(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"page":1,"total_pages":2,"results":[{"id":949,"title":"Heat","release_date":"1995-12-15","overview":"A group of bank robbers."},{"id":7,"title":"Unreleased","release_date":""}]}`,
+		"2": `{"page":2,"total_pages":2,"results":[{"id":666,"title":"Central Station","release_date":"1998-04-03"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/discover/movie" || r.Header.Get("Authorization") != "Bearer token" || r.URL.Query().Get("primary_release_year") != "1995" {
+			http.Error(w, "unexpected request", http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, pages[r.URL.Query().Get("page")])
+	}))
+	defer server.Close()
+
+	tmdb, err := feed.NewTMDb(server.URL, "token", "primary_release_year=1995", 5, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	movies, err := tmdb.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() unexpected error = %v", err)
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].ExternalID < movies[j].ExternalID })
+	if len(movies) != 2 || movies[0].ExternalID != "666" || movies[1].Year != "1995" || movies[1].Source != "tmdb" || movies[1].TMDbID != "949" ||
+		movies[1].Synopsis != "A group of bank robbers." {
+		t.Errorf("Fetch() = %+v, want Heat and Central Station with their years", movies)
+	}
+}
+
+func TestCSVFeed_Fetch(t *testing.T) {
+	feeds := map[string]string{
+		"/movies.csv": "id,title,year,genres,director,synopsis,runtime_minutes\n" +
+			"1,Heat,1995,Crime; Drama,Michael Mann,\"Robbers, and the police.\",170\n",
+		"/invalid.csv": "id,title,year,runtime_minutes\n1,Heat,1995,long\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, feeds[r.URL.Path])
+	}))
+	defer server.Close()
+
+	movies, err := feed.NewCSV(server.URL+"/movies.csv", time.Second).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch() unexpected error = %v", err)
+	}
+	want := domain.RemoteMovie{
+		Source: "csv", ExternalID: "1", Title: "Heat", Year: "1995", Genres: []string{"Crime", "Drama"},
+		Director: "Michael Mann", Synopsis: "Robbers, and the police.", RuntimeMinutes: 170,
+	}
+	if len(movies) != 1 || !reflect.DeepEqual(movies[0], want) {
+		t.Errorf("Fetch() = %+v, want %+v", movies, want)
+	}
+
+	if _, err := feed.NewCSV(server.URL+"/invalid.csv", time.Second).Fetch(context.Background()); err == nil {
+		t.Error("Fetch() with an invalid runtime expected error")
+	}
+}
+
+func TestCSVFeed_Retry(t *testing.T) {
+	var requests atomic.Int32
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(status)
+			return
+		}
+		io.WriteString(w, "id,title,year\n1,Heat,1995\n")
+	}))
+	defer server.Close()
+	csv := feed.NewCSV(server.URL, time.Second)
+
+	movies, err := csv.Fetch(context.Background())
+	if err != nil || len(movies) != 1 || requests.Load() != 2 {
+		t.Errorf("Fetch() = %+v, %v after %d requests, want Heat after 1 retry", movies, err, requests.Load())
+	}
+
+	requests.Store(0)
+	status = http.StatusNotFound
+	if _, err := csv.Fetch(context.Background()); err == nil || requests.Load() != 1 {
+		t.Errorf("Fetch() error = %v after %d requests, want the missing feed reported without a retry", err, requests.Load())
+	}
+}
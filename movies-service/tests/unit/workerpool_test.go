@@ -0,0 +1,159 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/movie-microservice/movies-service/internal/workerpool"
+	"github.com/movie-microservice/proto/retry"
+)
+
+func newTestPool(opts workerpool.Options) *workerpool.Pool {
+	return workerpool.New("test", opts, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestPool_Retry(t *testing.T) {
+	pool := newTestPool(workerpool.Options{Workers: 1, QueueSize: 1, Retry: retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+
+	var runs atomic.Int32
+	done := make(chan struct{})
+	err := pool.Submit("flaky", func(ctx context.Context) error {
+		if runs.Add(1) < 3 {
+			return errors.New("unavailable")
+		}
+		close(done)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Submit() unexpected error = %v", err)
+	}
+	<-done
+	pool.Close()
+
+	if stats := pool.Stats(); stats.Completed != 1 || stats.Retried != 2 || stats.Failed != 0 {
+		t.Errorf("Stats() = %+v, want 1 task completed after 2 retries", stats)
+	}
+}
+
+func TestPool_Panic(t *testing.T) {
+	pool := newTestPool(workerpool.Options{Workers: 1, QueueSize: 2, Retry: retry.Policy{MaxAttempts: 3}})
+
+	var ran atomic.Bool
+	pool.Submit("broken", func(ctx context.Context) error {
+		panic("nil map")
+	})
+	pool.Submit("next", func(ctx context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+	pool.Close()
+
+	if !ran.Load() {
+		t.Error("task after a panic did not run, want the worker to survive")
+	}
+	if stats := pool.Stats(); stats.Panicked != 1 || stats.Retried != 0 || stats.Completed != 1 {
+		t.Errorf("Stats() = %+v, want 1 panicked task not retried and 1 completed", stats)
+	}
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	pool := newTestPool(workerpool.Options{Workers: 1, QueueSize: 1})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	block := func(ctx context.Context) error {
+		<-release
+		return nil
+	}
+	pool.Submit("running", func(ctx context.Context) error {
+		close(started)
+		return block(ctx)
+	})
+	<-started
+	if pool.Idle() {
+		t.Error("Idle() = true with a running task, want false")
+	}
+	if err := pool.Submit("queued", block); err != nil {
+		t.Fatalf("Submit() unexpected error = %v", err)
+	}
+	if err := pool.Submit("rejected", block); !errors.Is(err, workerpool.ErrQueueFull) {
+		t.Errorf("Submit() error = %v, want ErrQueueFull", err)
+	}
+
+	var metrics bytes.Buffer
+	workerpool.WritePrometheus(&metrics, pool)
+	for _, want := range []string{
+		`movies_worker_pool_queue_depth{pool="test"} 1`,
+		`movies_worker_pool_running{pool="test"} 1`,
+		`movies_worker_pool_tasks_total{pool="test",outcome="rejected"} 1`,
+	} {
+		if !strings.Contains(metrics.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics.String())
+		}
+	}
+
+	close(release)
+	pool.Close()
+	if stats := pool.Stats(); stats.Completed != 2 || stats.Rejected != 1 {
+		t.Errorf("Stats() = %+v, want the queued task run before Close returned", stats)
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	pool := newTestPool(workerpool.Options{Workers: 1, QueueSize: 1})
+
+	started := make(chan struct{})
+	pool.Submit("long", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	pool.Close()
+
+	if err := pool.Submit("late", func(ctx context.Context) error { return nil }); !errors.Is(err, workerpool.ErrClosed) {
+		t.Errorf("Submit() after Close error = %v, want ErrClosed", err)
+	}
+	if err := pool.SubmitWait(context.Background(), "late", func(ctx context.Context) error { return nil }); !errors.Is(err, workerpool.ErrClosed) {
+		t.Errorf("SubmitWait() after Close error = %v, want ErrClosed", err)
+	}
+	if stats := pool.Stats(); stats.Failed != 1 {
+		t.Errorf("Stats() = %+v, want the canceled task failed", stats)
+	}
+}
+
+func TestPool_NoWorkers(t *testing.T) {
+	pool := newTestPool(workerpool.Options{QueueSize: 10})
+	defer pool.Close()
+
+	if pool.Idle() {
+		t.Error("Idle() = true without workers, want false")
+	}
+	if err := pool.Submit("task", func(ctx context.Context) error { return nil }); !errors.Is(err, workerpool.ErrQueueFull) {
+		t.Errorf("Submit() without workers error = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestWriteOpenMetrics_WorkerPool(t *testing.T) {
+	pool := newTestPool(workerpool.Options{Workers: 2})
+	pool.Close()
+
+	var metrics bytes.Buffer
+	workerpool.WriteOpenMetrics(&metrics, pool)
+	out := metrics.String()
+	if !strings.Contains(out, "# TYPE movies_worker_pool_tasks counter\n") {
+		t.Errorf("OpenMetrics output missing the tasks counter family:\n%s", out)
+	}
+	if !strings.Contains(out, `movies_worker_pool_workers{pool="test"} 2`) {
+		t.Errorf("OpenMetrics output missing the workers gauge:\n%s", out)
+	}
+	if strings.Contains(out, "# EOF") {
+		t.Errorf("OpenMetrics output ends with EOF, want it left to the last writer:\n%s", out)
+	}
+}
@@ -0,0 +1,157 @@
+// Package apperr classifies the errors of the gateway and the service, so repositories,
+// services and both transports tell failures apart with errors.Is instead of parsing
+// messages. Each failure has a Kind, which decides its gRPC code and HTTP status, and a
+// reason identifying it in both services, such as MOVIE_NOT_FOUND
+package apperr
+
+import (
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Kind is the class of a failure. Kinds are errors themselves, so errors.Is(err,
+// apperr.NotFound) tells whether err is of that kind
+type Kind string
+
+const (
+	// Internal is the kind of the failures that are not classified, such as bugs
+	Internal Kind = "internal"
+	// NotFound reports a missing record
+	NotFound Kind = "not found"
+	// Conflict reports a record clashing with an existing one, such as a taken ID
+	Conflict Kind = "conflict"
+	// Precondition reports a record not in the state the request requires, such as a
+	// stale version or a deleted comment
+	Precondition Kind = "failed precondition"
+	// Invalid reports a request the client has to fix before sending it again
+	Invalid Kind = "invalid"
+	// Unavailable reports a dependency failing for a while; the request can be retried
+	Unavailable Kind = "unavailable"
+	// Timeout reports a request that did not finish before its deadline
+	Timeout Kind = "timeout"
+	// Unimplemented reports an operation the deployment does not support
+	Unimplemented Kind = "unimplemented"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+// kindCodes lists the gRPC code and HTTP status of each kind
+var kindCodes = map[Kind]struct {
+	code       codes.Code
+	httpStatus int
+}{
+	Internal:      {codes.Internal, http.StatusInternalServerError},
+	NotFound:      {codes.NotFound, http.StatusNotFound},
+	Conflict:      {codes.AlreadyExists, http.StatusConflict},
+	Precondition:  {codes.FailedPrecondition, http.StatusConflict},
+	Invalid:       {codes.InvalidArgument, http.StatusBadRequest},
+	Unavailable:   {codes.Unavailable, http.StatusServiceUnavailable},
+	Timeout:       {codes.DeadlineExceeded, http.StatusGatewayTimeout},
+	Unimplemented: {codes.Unimplemented, http.StatusNotImplemented},
+}
+
+// Code returns the gRPC code of the kind
+func (k Kind) Code() codes.Code {
+	if c, ok := kindCodes[k]; ok {
+		return c.code
+	}
+	return codes.Internal
+}
+
+// HTTPStatus returns the HTTP status of the kind
+func (k Kind) HTTPStatus() int {
+	if c, ok := kindCodes[k]; ok {
+		return c.httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// KindOfCode returns the kind of a gRPC code, or false for codes no kind maps to, such
+// as Canceled or ResourceExhausted
+func KindOfCode(code codes.Code) (Kind, bool) {
+	for kind, c := range kindCodes {
+		if c.code == code {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// Violation names a request field that was rejected and why
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Error is a classified failure. Two errors with the same reason match with errors.Is,
+// so a failure decoded from a gRPC status matches the sentinel of the same reason
+type Error struct {
+	Kind Kind
+	// Reason identifies the failure, such as MOVIE_NOT_FOUND
+	Reason  string
+	Message string
+	// Violations lists the rejected request fields
+	Violations []Violation
+	// RetryAfter is the delay to wait before retrying; zero when the request should not
+	// be retried as is
+	RetryAfter time.Duration
+}
+
+// New returns a failure of kind, used to declare the sentinel errors of a domain
+func New(kind Kind, reason, message string) *Error {
+	return &Error{Kind: kind, Reason: reason, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches the kind of the failure and failures of the same reason
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Kind:
+		return e.Kind == t
+	case *Error:
+		return e.Reason != "" && e.Reason == t.Reason
+	}
+	return false
+}
+
+// Find returns the most specific failure wrapped by err: the innermost one, so wrapping
+// a failure into a broader one, as fmt.Errorf("%w: %w", ErrInvalidMovieData, err) does,
+// keeps the reason of err
+func Find(err error) (*Error, bool) {
+	var found *Error
+	walk(err, func(e *Error) { found = e })
+	return found, found != nil
+}
+
+// KindOf returns the kind of the most specific failure wrapped by err, or Internal
+func KindOf(err error) Kind {
+	if e, ok := Find(err); ok {
+		return e.Kind
+	}
+	return Internal
+}
+
+// walk calls fn with every failure in the tree of err, outermost first
+func walk(err error, fn func(*Error)) {
+	if err == nil {
+		return
+	}
+	if e, ok := err.(*Error); ok {
+		fn(e)
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walk(u.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			walk(inner, fn)
+		}
+	}
+}
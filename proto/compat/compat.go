@@ -0,0 +1,241 @@
+// Package compat finds the changes to a proto file that break the clients built against
+// an earlier version of it. Only wire compatibility is checked: a change that keeps
+// every message decodable and every RPC callable by old clients is allowed, so renaming
+// a field or adding one passes, while reusing a field number or changing its type fails.
+// The v1 and v2 APIs keep their baselines next to their .proto files, checked by the unit
+// tests
+package compat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Breaking lists the changes from base to current that break wire compatibility, in the
+// order of the declarations of base
+func Breaking(base, current protoreflect.FileDescriptor) []string {
+	c := &checker{}
+	for i := 0; i < base.Services().Len(); i++ {
+		service := base.Services().Get(i)
+		c.service(service, current.Services().ByName(service.Name()))
+	}
+	c.messages(base.Messages(), index(current))
+	c.enums(base.Enums(), index(current))
+	return c.changes
+}
+
+// Baseline encodes file as the baseline of later versions: its descriptor as indented
+// JSON, without comments, so the baseline only changes along with the API. protojson
+// varies its whitespace on purpose, so the JSON is indented again to be stable
+func Baseline(file protoreflect.FileDescriptor) ([]byte, error) {
+	descriptor := protodesc.ToFileDescriptorProto(file)
+	descriptor.SourceCodeInfo = nil
+	data, err := protojson.Marshal(descriptor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode baseline of %s: %w", file.Path(), err)
+	}
+	var compact, indented bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("failed to encode baseline of %s: %w", file.Path(), err)
+	}
+	if err := json.Indent(&indented, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to encode baseline of %s: %w", file.Path(), err)
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), nil
+}
+
+// ParseBaseline decodes a baseline written by Baseline. Its imports, such as the well-known
+// types, are resolved from the files linked into the binary
+func ParseBaseline(data []byte) (protoreflect.FileDescriptor, error) {
+	var descriptor descriptorpb.FileDescriptorProto
+	if err := protojson.Unmarshal(data, &descriptor); err != nil {
+		return nil, fmt.Errorf("failed to decode baseline: %w", err)
+	}
+	file, err := protodesc.NewFile(&descriptor, protoregistry.GlobalFiles)
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline of %s: %w", descriptor.GetName(), err)
+	}
+	return file, nil
+}
+
+type checker struct {
+	changes []string
+}
+
+func (c *checker) add(format string, args ...any) {
+	c.changes = append(c.changes, fmt.Sprintf(format, args...))
+}
+
+// declarations holds the messages and enums of a file by full name, nested ones included
+type declarations struct {
+	messages map[protoreflect.FullName]protoreflect.MessageDescriptor
+	enums    map[protoreflect.FullName]protoreflect.EnumDescriptor
+}
+
+func index(file protoreflect.FileDescriptor) declarations {
+	decls := declarations{
+		messages: make(map[protoreflect.FullName]protoreflect.MessageDescriptor),
+		enums:    make(map[protoreflect.FullName]protoreflect.EnumDescriptor),
+	}
+	decls.add(file.Messages(), file.Enums())
+	return decls
+}
+
+func (d declarations) add(messages protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors) {
+	for i := 0; i < enums.Len(); i++ {
+		d.enums[enums.Get(i).FullName()] = enums.Get(i)
+	}
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		d.messages[message.FullName()] = message
+		d.add(message.Messages(), message.Enums())
+	}
+}
+
+// service checks every method of base is still served with the same messages and
+// streaming, since clients call them by name
+func (c *checker) service(base, current protoreflect.ServiceDescriptor) {
+	if current == nil {
+		c.add("service %s deleted", base.FullName())
+		return
+	}
+	for i := 0; i < base.Methods().Len(); i++ {
+		method := base.Methods().Get(i)
+		now := current.Methods().ByName(method.Name())
+		switch {
+		case now == nil:
+			c.add("rpc %s deleted", method.FullName())
+		case now.Input().FullName() != method.Input().FullName():
+			c.add("rpc %s request changed from %s to %s", method.FullName(), method.Input().FullName(), now.Input().FullName())
+		case now.Output().FullName() != method.Output().FullName():
+			c.add("rpc %s response changed from %s to %s", method.FullName(), method.Output().FullName(), now.Output().FullName())
+		case now.IsStreamingClient() != method.IsStreamingClient() || now.IsStreamingServer() != method.IsStreamingServer():
+			c.add("rpc %s streaming changed", method.FullName())
+		}
+	}
+}
+
+func (c *checker) messages(base protoreflect.MessageDescriptors, current declarations) {
+	for i := 0; i < base.Len(); i++ {
+		message := base.Get(i)
+		now := current.messages[message.FullName()]
+		if now == nil {
+			c.add("message %s deleted", message.FullName())
+			continue
+		}
+		c.fields(message, now)
+		c.messages(message.Messages(), current)
+		c.enums(message.Enums(), current)
+	}
+}
+
+// fields checks each field number of base still decodes the same way, and that numbers
+// once reserved are not given to new fields, which old clients would misread
+func (c *checker) fields(base, current protoreflect.MessageDescriptor) {
+	for i := 0; i < base.Fields().Len(); i++ {
+		field := base.Fields().Get(i)
+		now := current.Fields().ByNumber(field.Number())
+		if now == nil {
+			if !current.ReservedRanges().Has(field.Number()) {
+				c.add("field %s (%d) deleted without reserving its number", field.FullName(), field.Number())
+			}
+			continue
+		}
+		if change := fieldChange(field, now); change != "" {
+			c.add("field %s (%d) %s", field.FullName(), field.Number(), change)
+		}
+	}
+	for i := 0; i < current.Fields().Len(); i++ {
+		field := current.Fields().Get(i)
+		if base.ReservedRanges().Has(field.Number()) {
+			c.add("field %s reuses the reserved number %d", field.FullName(), field.Number())
+		}
+	}
+}
+
+// fieldChange describes how the encoding of field changed, if it did
+func fieldChange(base, current protoreflect.FieldDescriptor) string {
+	switch {
+	case wireType(base.Kind()) != wireType(current.Kind()):
+		return fmt.Sprintf("type changed from %s to %s", base.Kind(), current.Kind())
+	case typeName(base) != "" && typeName(current) != "" && typeName(base) != typeName(current):
+		return fmt.Sprintf("type changed from %s to %s", typeName(base), typeName(current))
+	case base.Cardinality() == protoreflect.Repeated != (current.Cardinality() == protoreflect.Repeated):
+		return fmt.Sprintf("changed from %s to %s", base.Cardinality(), current.Cardinality())
+	case base.IsMap() != current.IsMap():
+		return "changed between a map and a list"
+	case oneofName(base) != oneofName(current):
+		return fmt.Sprintf("moved from oneof %q to %q", oneofName(base), oneofName(current))
+	}
+	return ""
+}
+
+// wireType groups the kinds encoded alike, between which a field can change type
+func wireType(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Int64Kind,
+		protoreflect.Uint64Kind, protoreflect.BoolKind, protoreflect.EnumKind:
+		return "varint"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	}
+	return kind.String()
+}
+
+// typeName is the message or enum of a field, or "" for scalars. Enums changed to or from
+// integers keep decoding, so only a change from one enum to another is reported
+func typeName(field protoreflect.FieldDescriptor) protoreflect.FullName {
+	switch {
+	case field.Message() != nil:
+		return field.Message().FullName()
+	case field.Enum() != nil:
+		return field.Enum().FullName()
+	}
+	return ""
+}
+
+// oneofName is the oneof holding field. The synthetic oneofs of proto3 optional fields
+// are left out: making a field optional keeps its encoding
+func oneofName(field protoreflect.FieldDescriptor) protoreflect.Name {
+	if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+		return oneof.Name()
+	}
+	return ""
+}
+
+func (c *checker) enums(base protoreflect.EnumDescriptors, current declarations) {
+	for i := 0; i < base.Len(); i++ {
+		enum := base.Get(i)
+		now := current.enums[enum.FullName()]
+		if now == nil {
+			c.add("enum %s deleted", enum.FullName())
+			continue
+		}
+		for j := 0; j < enum.Values().Len(); j++ {
+			value := enum.Values().Get(j)
+			if now.Values().ByNumber(value.Number()) == nil && !now.ReservedRanges().Has(value.Number()) {
+				c.add("enum value %s (%d) deleted without reserving its number", value.FullName(), value.Number())
+			}
+		}
+		for j := 0; j < now.Values().Len(); j++ {
+			value := now.Values().Get(j)
+			if enum.ReservedRanges().Has(value.Number()) {
+				c.add("enum value %s reuses the reserved number %d", value.FullName(), value.Number())
+			}
+		}
+	}
+}
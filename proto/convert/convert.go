@@ -0,0 +1,51 @@
+// Package convert holds the mapping between the movie service API messages and plain Go
+// values, shared by the gateway client and the service server so both sides convert
+// every field the same way
+package convert
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToTimestamp converts t to a protobuf timestamp; the zero time converts to nil so an
+// unset time stays unset on the wire
+func ToTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// FromTimestamp converts a protobuf timestamp to a UTC time; nil converts to the zero time
+func FromTimestamp(ts *timestamppb.Timestamp) (time.Time, error) {
+	if ts == nil {
+		return time.Time{}, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return time.Time{}, err
+	}
+	return ts.AsTime(), nil
+}
+
+// ToDuration converts d to a protobuf duration; zero converts to nil
+func ToDuration(d time.Duration) *durationpb.Duration {
+	if d == 0 {
+		return nil
+	}
+	return durationpb.New(d)
+}
+
+// FromDuration converts a protobuf duration to a time.Duration; nil converts to zero and
+// durations beyond the range of time.Duration saturate
+func FromDuration(d *durationpb.Duration) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+	if err := d.CheckValid(); err != nil {
+		return 0, err
+	}
+	return d.AsDuration(), nil
+}
@@ -0,0 +1,111 @@
+package convert
+
+import (
+	pb "github.com/movie-microservice/proto/movies/v2"
+)
+
+// Movie has the same fields, in the same order, as the domain movie of both services,
+// so each service converts with a plain type conversion such as convert.Movie(*movie).
+// That conversion stops compiling as soon as a field is added on one side only
+type Movie struct {
+	ID             int32
+	Title          string
+	Year           string
+	Version        int64
+	Regions        []string
+	Awards         []string
+	Certification  string
+	IMDbID         string
+	TMDbID         string
+	Genres         []string
+	Director       string
+	Synopsis       string
+	RuntimeMinutes int32
+	// Archived is set when the movie was served from the archive of rarely accessed movies
+	Archived bool
+}
+
+// MovieInput has the same fields as the client-provided movie input of both services
+type MovieInput struct {
+	Title          string
+	Year           string
+	Regions        []string
+	Awards         []string
+	Certification  string
+	IMDbID         string
+	TMDbID         string
+	Genres         []string
+	Director       string
+	Synopsis       string
+	RuntimeMinutes int32
+}
+
+func ToProtoMovie(m Movie) *pb.Movie {
+	return &pb.Movie{
+		Id:             m.ID,
+		Title:          m.Title,
+		Year:           m.Year,
+		Version:        m.Version,
+		Regions:        m.Regions,
+		Awards:         m.Awards,
+		Certification:  m.Certification,
+		ImdbId:         m.IMDbID,
+		TmdbId:         m.TMDbID,
+		Genres:         m.Genres,
+		Director:       m.Director,
+		Synopsis:       m.Synopsis,
+		RuntimeMinutes: m.RuntimeMinutes,
+		Archived:       m.Archived,
+	}
+}
+
+func FromProtoMovie(m *pb.Movie) Movie {
+	return Movie{
+		ID:             m.GetId(),
+		Title:          m.GetTitle(),
+		Year:           m.GetYear(),
+		Version:        m.GetVersion(),
+		Regions:        m.GetRegions(),
+		Awards:         m.GetAwards(),
+		Certification:  m.GetCertification(),
+		IMDbID:         m.GetImdbId(),
+		TMDbID:         m.GetTmdbId(),
+		Genres:         m.GetGenres(),
+		Director:       m.GetDirector(),
+		Synopsis:       m.GetSynopsis(),
+		RuntimeMinutes: m.GetRuntimeMinutes(),
+		Archived:       m.GetArchived(),
+	}
+}
+
+func ToProtoMovieInput(input MovieInput) *pb.MovieInput {
+	return &pb.MovieInput{
+		Title:          input.Title,
+		Year:           input.Year,
+		Regions:        input.Regions,
+		Awards:         input.Awards,
+		Certification:  input.Certification,
+		ImdbId:         input.IMDbID,
+		TmdbId:         input.TMDbID,
+		Genres:         input.Genres,
+		Director:       input.Director,
+		Synopsis:       input.Synopsis,
+		RuntimeMinutes: input.RuntimeMinutes,
+	}
+}
+
+func FromProtoMovieInput(input *pb.MovieInput) MovieInput {
+	return MovieInput{
+		Title:          input.GetTitle(),
+		Year:           input.GetYear(),
+		Regions:        input.GetRegions(),
+		Awards:         input.GetAwards(),
+		Certification:  input.GetCertification(),
+		IMDbID:         input.GetImdbId(),
+		TMDbID:         input.GetTmdbId(),
+		Genres:         input.GetGenres(),
+		Director:       input.GetDirector(),
+		Synopsis:       input.GetSynopsis(),
+		RuntimeMinutes: input.GetRuntimeMinutes(),
+	}
+}
@@ -0,0 +1,117 @@
+// Package drain tracks the requests a server is handling, so its shutdown reports how
+// many were still running, how long draining them took and how many were cut off when
+// the grace period ran out. Operators tune the grace period from these, and spot
+// deploys that kill active requests
+package drain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is how often the requests still running are logged while draining
+const progressInterval = time.Second
+
+// Tracker counts in-flight requests and the outcome of the last drain. Its metrics are
+// named after prefix, such as gateway_in_flight_requests
+type Tracker struct {
+	prefix   string
+	inFlight atomic.Int64
+	draining atomic.Bool
+	// drainNanos is the duration of the last drain
+	drainNanos atomic.Int64
+	forced     atomic.Int64
+}
+
+func NewTracker(prefix string) *Tracker {
+	return &Tracker{prefix: prefix}
+}
+
+// Begin counts a request as in flight until the returned func is called
+func (t *Tracker) Begin() (end func()) {
+	t.inFlight.Add(1)
+	return func() { t.inFlight.Add(-1) }
+}
+
+// InFlight returns the number of requests being handled
+func (t *Tracker) InFlight() int64 {
+	return t.inFlight.Load()
+}
+
+// Drain stops the server with stop, which stops accepting requests and returns once the
+// in-flight ones finished or its context is done. The context ends after grace; force
+// then closes the requests still running. Progress and the outcome are logged, and the
+// error of stop is returned, set when requests had to be closed.
+func (t *Tracker) Drain(grace time.Duration, stop func(context.Context) error, force func(), logger *slog.Logger) error {
+	t.draining.Store(true)
+	start := time.Now()
+	logger.Info("Draining in-flight requests", "in_flight", t.InFlight(), "grace_period", grace.String())
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- stop(ctx) }()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	var err error
+wait:
+	for {
+		select {
+		case err = <-done:
+			break wait
+		case <-ticker.C:
+			logger.Info("Draining in-flight requests", "in_flight", t.InFlight(), "elapsed", time.Since(start).String())
+		}
+	}
+
+	var forced int64
+	if err != nil {
+		forced = t.InFlight()
+		logger.Warn("Grace period over, closing in-flight requests", "in_flight", forced, "error", err)
+		force()
+	}
+	elapsed := time.Since(start)
+	t.drainNanos.Store(int64(elapsed))
+	t.forced.Add(forced)
+	logger.Info("Drain finished", "drain_time", elapsed.String(), "forced_closes", forced)
+	return err
+}
+
+// Write writes the in-flight requests and the outcome of the last drain in the
+// Prometheus text format or, when openMetrics is set, in the OpenMetrics format without
+// the final EOF marker
+func (t *Tracker) Write(w io.Writer, openMetrics bool) {
+	draining := 0
+	if t.draining.Load() {
+		draining = 1
+	}
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"in_flight_requests", "Requests being handled.", float64(t.InFlight())},
+		{"draining", "1 while the server drains its requests to stop.", float64(draining)},
+		{"shutdown_drain_seconds", "Time the last drain took.", time.Duration(t.drainNanos.Load()).Seconds()},
+	}
+	for _, gauge := range gauges {
+		name := t.prefix + "_" + gauge.name
+		fmt.Fprintf(w, "# HELP %s %s\n", name, gauge.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(w, "%s %g\n", name, gauge.value)
+	}
+
+	// OpenMetrics names counter families without the _total suffix of their samples
+	name := t.prefix + "_shutdown_forced_closes_total"
+	family := name
+	if openMetrics {
+		family = t.prefix + "_shutdown_forced_closes"
+	}
+	fmt.Fprintf(w, "# HELP %s Requests closed because the grace period ran out.\n", family)
+	fmt.Fprintf(w, "# TYPE %s counter\n", family)
+	fmt.Fprintf(w, "%s %d\n", name, t.forced.Load())
+}
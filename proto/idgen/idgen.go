@@ -0,0 +1,27 @@
+// Package idgen gives the code creating records their IDs through a Generator, so tests
+// know the IDs in advance instead of reading back random ones
+package idgen
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Generator returns a new unique ID on each call
+type Generator interface {
+	NewID() string
+}
+
+// Sequence generates IDs counting from 1, for tests. They have 24 hex digits, the format
+// of MongoDB ObjectIDs, so they are accepted wherever generated ObjectIDs are
+type Sequence struct {
+	mu   sync.Mutex
+	last uint64
+}
+
+func (s *Sequence) NewID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last++
+	return fmt.Sprintf("%024x", s.last)
+}
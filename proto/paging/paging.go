@@ -0,0 +1,38 @@
+// Package paging holds the paging defaults and limits of listings, shared by the gateway
+// and the service so both normalize pages the same way
+package paging
+
+import "github.com/movie-microservice/proto/apperr"
+
+var ErrPageOutOfRange = apperr.New(apperr.Invalid, "PAGE_OUT_OF_RANGE", "page exceeds maximum allowed page")
+
+// Pagination holds the paging defaults and limits applied to listings
+type Pagination struct {
+	DefaultLimit int32
+	MaxLimit     int32
+	MaxPage      int32 // 0 means unlimited
+}
+
+// Default returns the limits used when none are configured
+func Default() Pagination {
+	return Pagination{
+		DefaultLimit: 10,
+		MaxLimit:     100,
+	}
+}
+
+// Normalize applies defaults to the page and limit and checks the page against the
+// configured maximum. A limit out of range falls back to the default rather than being
+// clamped to the maximum, as listings always did
+func (p Pagination) Normalize(page, limit int32) (int32, int32, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > p.MaxLimit {
+		limit = p.DefaultLimit
+	}
+	if p.MaxPage > 0 && page > p.MaxPage {
+		return 0, 0, ErrPageOutOfRange
+	}
+	return page, limit, nil
+}
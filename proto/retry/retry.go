@@ -0,0 +1,176 @@
+// Package retry runs operations again after transient failures, shared by the gateway
+// and the service so every adapter retries the same way. Attempts are spaced by a
+// backoff with decorrelated jitter, so callers failing together don't retry together,
+// and bounded by a number of attempts, a total time and an optional budget shared by
+// the callers of a dependency, which stops retries while the dependency keeps failing
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Policy decides whether and when a failed operation runs again
+type Policy struct {
+	// MaxAttempts bounds the attempts, the first one included; 0 leaves them unbounded,
+	// so only MaxElapsed, the budget or the context stop the retries
+	MaxAttempts int
+	// BaseDelay is the shortest wait before a retry and MaxDelay the longest; 0 leaves
+	// the wait unbounded
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxElapsed is the time since the first attempt after which no retry starts; 0
+	// leaves it unbounded
+	MaxElapsed time.Duration
+	// Retryable tells transient errors from the others; nil retries every error not
+	// marked Permanent
+	Retryable func(err error) bool
+	// Budget, when set, is spent by transient failures and refilled by successes
+	Budget *Budget
+	// OnRetry, when set, is called before waiting for each retry, such as to log it
+	OnRetry func(attempt int, delay time.Duration, err error)
+}
+
+// Do runs op until it succeeds or the policy gives up, returning the error of the last
+// attempt. An error marked Permanent is returned unwrapped without a retry. The context
+// of op is ctx, and the wait before a retry ends when ctx is done
+func (p Policy) Do(ctx context.Context, op func(ctx context.Context) error) error {
+	start := time.Now()
+	backoff := p.Backoff()
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			if p.Budget != nil {
+				p.Budget.success()
+			}
+			return nil
+		}
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+		if p.Budget != nil {
+			p.Budget.failure()
+		}
+
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return err
+		}
+		delay := backoff.Next()
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return err
+		}
+		if p.Budget != nil && !p.Budget.Allow() {
+			return err
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff returns the delays of the policy, for callers that schedule retries themselves
+func (p Policy) Backoff() *Backoff {
+	return &Backoff{base: p.BaseDelay, max: p.MaxDelay}
+}
+
+// Backoff computes the waits between attempts with decorrelated jitter: each delay is
+// drawn between the base delay and three times the previous one, capped by the maximum
+type Backoff struct {
+	base time.Duration
+	max  time.Duration
+	prev time.Duration
+}
+
+// Next returns the delay before the next retry
+func (b *Backoff) Next() time.Duration {
+	if b.base <= 0 {
+		return 0
+	}
+	delay := b.base
+	if upper := 3 * b.prev; upper > b.base {
+		delay += time.Duration(rand.Int63n(int64(upper - b.base)))
+	}
+	if b.max > 0 && delay > b.max {
+		delay = b.max
+	}
+	b.prev = delay
+	return delay
+}
+
+// RetryableStatus reports whether an HTTP request answered with code may succeed when
+// sent again: timeouts, rate limits and server errors
+func RetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+}
+
+// Permanent marks an error that retrying won't fix, such as a rejected request, so Do
+// returns it at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Budget limits the retries sent to a dependency, like the retry throttling of gRPC:
+// each transient failure spends a token and each success gives back part of one, and
+// retries stop while fewer than half the tokens are left. A budget is safe for
+// concurrent use, so it is shared by all the callers of the dependency
+type Budget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+// NewBudget returns a full budget of maxTokens, refilled by ratio tokens on each success
+func NewBudget(maxTokens, ratio float64) *Budget {
+	return &Budget{tokens: maxTokens, max: maxTokens, ratio: ratio}
+}
+
+// Allow reports whether a retry may be sent
+func (b *Budget) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.max/2
+}
+
+func (b *Budget) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.max, b.tokens+b.ratio)
+}
+
+func (b *Budget) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = max(0, b.tokens-1)
+}
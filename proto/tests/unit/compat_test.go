@@ -0,0 +1,178 @@
+package unit
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/movie-microservice/proto/compat"
+	moviesv1 "github.com/movie-microservice/proto/movies/v1"
+	moviesv2 "github.com/movie-microservice/proto/movies/v2"
+)
+
+var update = flag.Bool("update", false, "rewrite the proto baselines from the current .proto files")
+
+// TestProtoBaselines fails when a .proto change breaks the clients of the API it
+// belongs to. After a compatible change the baseline is refreshed in the same commit
+// with -update, so the new fields are guarded too
+func TestProtoBaselines(t *testing.T) {
+	for _, file := range []protoreflect.FileDescriptor{
+		moviesv1.File_movies_v1_movies_proto,
+		moviesv2.File_movies_v2_movies_proto,
+	} {
+		path := filepath.Join("..", "..", filepath.Dir(file.Path()), "movies.baseline.json")
+		current, err := compat.Baseline(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *update {
+			if err := os.WriteFile(path, current, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("missing baseline of %s, write it with go test ./tests/unit -run TestProtoBaselines -update: %v", file.Path(), err)
+		}
+		base, err := compat.ParseBaseline(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if changes := compat.Breaking(base, file); len(changes) > 0 {
+			for _, change := range changes {
+				t.Errorf("%s: breaking change: %s", file.Path(), change)
+			}
+			continue
+		}
+		if !bytes.Equal(data, current) {
+			t.Errorf("%s changed compatibly but its baseline is stale, refresh it with go test ./tests/unit -run TestProtoBaselines -update", file.Path())
+		}
+	}
+}
+
+// changed returns the v2 file with edit applied to a copy of its descriptor
+func changed(t *testing.T, edit func(*descriptorpb.FileDescriptorProto)) protoreflect.FileDescriptor {
+	t.Helper()
+	descriptor := proto.Clone(protodesc.ToFileDescriptorProto(moviesv2.File_movies_v2_movies_proto)).(*descriptorpb.FileDescriptorProto)
+	edit(descriptor)
+	file, err := protodesc.NewFile(descriptor, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("invalid edited descriptor: %v", err)
+	}
+	return file
+}
+
+func message(file *descriptorpb.FileDescriptorProto, name string) *descriptorpb.DescriptorProto {
+	for _, message := range file.MessageType {
+		if message.GetName() == name {
+			return message
+		}
+	}
+	panic("no message " + name)
+}
+
+func TestBreaking(t *testing.T) {
+	base := moviesv2.File_movies_v2_movies_proto
+	tests := []struct {
+		name string
+		edit func(*descriptorpb.FileDescriptorProto)
+		want []string
+	}{
+		{
+			name: "field added and renamed",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				movie := message(file, "Movie")
+				movie.Field[1].Name = proto.String("name")
+				movie.Field[1].JsonName = proto.String("name")
+				movie.Field = append(movie.Field, &descriptorpb.FieldDescriptorProto{
+					Name: proto.String("budget"), JsonName: proto.String("budget"), Number: proto.Int32(15),
+					Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+				})
+			},
+		},
+		{
+			name: "field deleted and reserved",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				movie := message(file, "Movie")
+				movie.Field = movie.Field[:len(movie.Field)-1]
+				movie.ReservedRange = append(movie.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(14), End: proto.Int32(15)})
+			},
+		},
+		{
+			name: "int32 widened to int64",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				message(file, "Movie").Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+			},
+		},
+		{
+			name: "field deleted",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				movie := message(file, "Movie")
+				movie.Field = movie.Field[:len(movie.Field)-1]
+			},
+			want: []string{"field movies.v2.Movie.runtime_minutes (14) deleted without reserving its number"},
+		},
+		{
+			name: "field type and cardinality changed",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				movie := message(file, "Movie")
+				movie.Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum()
+				movie.Field[4].Label = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+			},
+			want: []string{
+				"field movies.v2.Movie.title (2) type changed from string to int32",
+				"field movies.v2.Movie.regions (5) changed from repeated to optional",
+			},
+		},
+		{
+			name: "rpc deleted and streaming changed",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				service := file.Service[0]
+				service.Method[0].ServerStreaming = proto.Bool(true)
+				service.Method = service.Method[:len(service.Method)-1]
+			},
+			want: []string{
+				"rpc movies.v2.MovieService.GetMovies streaming changed",
+				"rpc movies.v2.MovieService.GetMovieByExternalId deleted",
+			},
+		},
+		{
+			name: "enum value deleted",
+			edit: func(file *descriptorpb.FileDescriptorProto) {
+				operator := file.EnumType[0]
+				operator.Value = append(operator.Value[:1:1], operator.Value[2:]...)
+			},
+			want: []string{"enum value movies.v2.FILTER_OPERATOR_EQ (1) deleted without reserving its number"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compat.Breaking(base, changed(t, tt.edit)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Breaking() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreaking_ReservedNumberReused(t *testing.T) {
+	base := changed(t, func(file *descriptorpb.FileDescriptorProto) {
+		movie := message(file, "Movie")
+		movie.Field = movie.Field[:len(movie.Field)-1]
+		movie.ReservedRange = append(movie.ReservedRange, &descriptorpb.DescriptorProto_ReservedRange{Start: proto.Int32(14), End: proto.Int32(15)})
+	})
+	want := []string{"field movies.v2.Movie.runtime_minutes reuses the reserved number 14"}
+	if got := compat.Breaking(base, moviesv2.File_movies_v2_movies_proto); !reflect.DeepEqual(got, want) {
+		t.Errorf("Breaking() = %q, want %q", got, want)
+	}
+}
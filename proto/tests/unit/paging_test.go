@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/movie-microservice/proto/paging"
+)
+
+func TestPagination_Normalize(t *testing.T) {
+	pagination := paging.Pagination{DefaultLimit: 10, MaxLimit: 100, MaxPage: 50}
+	tests := []struct {
+		name        string
+		page, limit int32
+		wantPage    int32
+		wantLimit   int32
+		wantErr     error
+	}{
+		{"defaults", 0, 0, 1, 10, nil},
+		{"negative", -3, -1, 1, 10, nil},
+		{"within limits", 2, 25, 2, 25, nil},
+		{"max limit", 1, 100, 1, 100, nil},
+		{"limit above max falls back to the default", 1, 101, 1, 10, nil},
+		{"max page", 50, 10, 50, 10, nil},
+		{"page above max", 51, 10, 0, 0, paging.ErrPageOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit, err := pagination.Normalize(tt.page, tt.limit)
+			if page != tt.wantPage || limit != tt.wantLimit || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Normalize(%d, %d) = %d, %d, %v, want %d, %d, %v", tt.page, tt.limit, page, limit, err, tt.wantPage, tt.wantLimit, tt.wantErr)
+			}
+		})
+	}
+
+	// Without a maximum page any page is accepted
+	if page, _, err := paging.Default().Normalize(100000, 0); err != nil || page != 100000 {
+		t.Errorf("Normalize() without a max page = %d, %v, want 100000", page, err)
+	}
+}
@@ -7,17 +7,36 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
+	"unicode"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 type Movie struct {
 	ID    int32  `json:"id" bson:"_id"`
 	Title string `json:"title" bson:"title"`
 	Year  string `json:"year" bson:"year"`
+	// SearchTitle is matched by title searches. The service backfills it only at startup,
+	// which may run before this script, so it is written here as the service writes it
+	SearchTitle string `json:"-" bson:"search_title"`
+}
+
+// normalizeSearchText lower-cases s and strips its diacritics, as the movies service
+// does for search_title
+func normalizeSearchText(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
 }
 
 func main() {
@@ -62,7 +81,8 @@ func main() {
 
 	fmt.Printf("Loaded %d movies from JSON file\n", len(movies))
 
-	// Get database and collection
+	// Get database and collection; its validator and indexes are created by
+	// "movies-service bootstrap", which runs first
 	db := client.Database(databaseName)
 	collection := db.Collection("movies")
 
@@ -80,6 +100,7 @@ func main() {
 	// Convert to interface slice for bulk insert
 	docs := make([]interface{}, len(movies))
 	for i, movie := range movies {
+		movie.SearchTitle = normalizeSearchText(movie.Title)
 		docs[i] = movie
 	}
 
@@ -91,24 +112,6 @@ func main() {
 
 	fmt.Printf("Successfully inserted %d movies into the database!\n", len(result.InsertedIDs))
 
-	// Create indexes
-	indexes := []mongo.IndexModel{
-		{
-			Keys:    bson.D{{Key: "_id", Value: 1}},
-			Options: options.Index().SetUnique(true),
-		},
-		{
-			Keys: bson.D{{Key: "title", Value: "text"}, {Key: "year", Value: 1}},
-		},
-	}
-
-	_, err = collection.Indexes().CreateMany(ctx, indexes)
-	if err != nil {
-		log.Printf("Warning: Failed to create indexes: %v", err)
-	} else {
-		fmt.Println("Successfully created database indexes!")
-	}
-
 	fmt.Println("Database initialization completed successfully!")
 }
 